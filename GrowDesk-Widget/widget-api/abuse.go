@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// submissionCheck verifica una solicitud de creación de ticket antes de guardarla.
+// body es el JSON recibido decodificado de forma flexible (puede ser nil).
+// Devolver un *submissionError permite elegir el código HTTP de la respuesta.
+type submissionCheck func(c *gin.Context, body map[string]interface{}) error
+
+// submissionError es un rechazo con código HTTP propio
+type submissionError struct {
+	status  int
+	message string
+}
+
+func (e *submissionError) Error() string { return e.message }
+
+var (
+	ticketChecks     []submissionCheck
+	ticketChecksOnce sync.Once
+)
+
+// registerTicketCheck añade un hook de verificación para la creación de tickets
+func registerTicketCheck(check submissionCheck) {
+	ticketChecks = append(ticketChecks, check)
+}
+
+// initTicketChecks registra los hooks configurados por entorno:
+// prueba de trabajo (WIDGET_POW_DIFFICULTY) y captcha (CAPTCHA_SECRET)
+func initTicketChecks() {
+	ticketChecksOnce.Do(func() {
+		if powDifficulty() > 0 {
+			registerTicketCheck(verifyProofOfWork)
+			logging.Infof("Prueba de trabajo activada para la creación de tickets (dificultad %d)", powDifficulty())
+		}
+		if getEnv("CAPTCHA_SECRET", "") != "" {
+			registerTicketCheck(verifyCaptcha)
+			logging.Infof("Verificación de captcha activada para la creación de tickets")
+		}
+	})
+}
+
+// honeypotTriggered indica si un bot rellenó el campo trampa oculto del formulario
+func honeypotTriggered(body map[string]interface{}) bool {
+	field := getEnv("WIDGET_HONEYPOT_FIELD", "website")
+	if field == "" || body == nil {
+		return false
+	}
+	value, ok := body[field]
+	if !ok || value == nil {
+		return false
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", value)) != ""
+}
+
+// guardTicketSubmission aplica el honeypot y los hooks registrados. Devuelve false si
+// la solicitud ya fue respondida y no debe procesarse.
+func guardTicketSubmission(c *gin.Context, body map[string]interface{}) bool {
+	initTicketChecks()
+
+	if honeypotTriggered(body) {
+		// Responder como si todo hubiera ido bien para no dar pistas al bot
+		logging.Warnf("Honeypot activado desde %s, ticket descartado", c.ClientIP())
+		fakeID := newTicketID()
+		c.JSON(http.StatusCreated, gin.H{
+			"ticketId": fakeID,
+			"id":       fakeID,
+			"message":  "Ticket creado correctamente",
+			"success":  true,
+		})
+		return false
+	}
+
+	for _, check := range ticketChecks {
+		if err := check(c, body); err != nil {
+			status := http.StatusForbidden
+			var subErr *submissionError
+			if errors.As(err, &subErr) {
+				status = subErr.status
+			}
+			logging.Warnf("Creación de ticket rechazada desde %s: %v", c.ClientIP(), err)
+			c.JSON(status, gin.H{"error": err.Error(), "success": false})
+			return false
+		}
+	}
+
+	return true
+}
+
+// submissionValue obtiene un campo del cuerpo o, si no está, de la cabecera indicada
+func submissionValue(c *gin.Context, body map[string]interface{}, field, header string) string {
+	if body != nil {
+		if value, ok := body[field].(string); ok && value != "" {
+			return value
+		}
+	}
+	return c.GetHeader(header)
+}
+
+// --- Prueba de trabajo ---
+//
+// GET /widget/challenge entrega un reto firmado "<expira>.<aleatorio>.<dificultad>.<firma>".
+// El cliente busca un nonce tal que sha256(reto + ":" + nonce) empiece por
+// <dificultad> bits a cero y lo envía con el ticket (powChallenge/powNonce o
+// cabeceras X-PoW-Challenge/X-PoW-Nonce). Cada reto sólo puede usarse una vez.
+
+const powChallengeTTL = 5 * time.Minute
+
+var (
+	powSecret     []byte
+	powSecretOnce sync.Once
+	usedPoW       = make(map[string]time.Time)
+	usedPoWMutex  sync.Mutex
+)
+
+func powDifficulty() int {
+	difficulty, err := strconv.Atoi(getEnv("WIDGET_POW_DIFFICULTY", "0"))
+	if err != nil || difficulty < 0 {
+		return 0
+	}
+	if difficulty > 32 {
+		return 32
+	}
+	return difficulty
+}
+
+// powKey devuelve la clave de firma de los retos. Sin WIDGET_POW_SECRET se genera una
+// aleatoria, válida sólo para esta instancia.
+func powKey() []byte {
+	powSecretOnce.Do(func() {
+		if secret := getEnv("WIDGET_POW_SECRET", ""); secret != "" {
+			powSecret = []byte(secret)
+			return
+		}
+		powSecret = make([]byte, 32)
+		if _, err := rand.Read(powSecret); err != nil {
+			logging.Errorf("Error al generar la clave de los retos: %v", err)
+		}
+	})
+	return powSecret
+}
+
+func signPoW(payload string) string {
+	mac := hmac.New(sha256.New, powKey())
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// issueChallenge entrega un nuevo reto de prueba de trabajo
+func issueChallenge(c *gin.Context) {
+	difficulty := powDifficulty()
+	if difficulty == 0 {
+		c.JSON(http.StatusOK, gin.H{"required": false})
+		return
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el reto"})
+		return
+	}
+
+	expiresAt := time.Now().Add(powChallengeTTL)
+	payload := fmt.Sprintf("%d.%s.%d", expiresAt.Unix(), hex.EncodeToString(random), difficulty)
+
+	c.JSON(http.StatusOK, gin.H{
+		"required":   true,
+		"algorithm":  "sha256",
+		"challenge":  payload + "." + signPoW(payload),
+		"difficulty": difficulty,
+		"expiresAt":  expiresAt,
+	})
+}
+
+// verifyProofOfWork comprueba el reto y el nonce enviados con el ticket
+func verifyProofOfWork(c *gin.Context, body map[string]interface{}) error {
+	challenge := submissionValue(c, body, "powChallenge", "X-PoW-Challenge")
+	nonce := submissionValue(c, body, "powNonce", "X-PoW-Nonce")
+	if challenge == "" || nonce == "" {
+		return &submissionError{http.StatusPreconditionRequired, "Se requiere una prueba de trabajo (GET /widget/challenge)"}
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return &submissionError{http.StatusBadRequest, "Reto de prueba de trabajo inválido"}
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(signPoW(payload))) {
+		return &submissionError{http.StatusBadRequest, "Reto de prueba de trabajo inválido"}
+	}
+
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return &submissionError{http.StatusPreconditionRequired, "El reto de prueba de trabajo ha expirado"}
+	}
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil || difficulty < powDifficulty() {
+		return &submissionError{http.StatusBadRequest, "Reto de prueba de trabajo inválido"}
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return &submissionError{http.StatusForbidden, "Prueba de trabajo incorrecta"}
+	}
+
+	// Evitar que un mismo reto resuelto se reutilice
+	usedPoWMutex.Lock()
+	defer usedPoWMutex.Unlock()
+	now := time.Now()
+	for key, exp := range usedPoW {
+		if now.After(exp) {
+			delete(usedPoW, key)
+		}
+	}
+	if _, used := usedPoW[parts[1]]; used {
+		return &submissionError{http.StatusForbidden, "El reto de prueba de trabajo ya fue utilizado"}
+	}
+	usedPoW[parts[1]] = time.Unix(expires, 0)
+
+	return nil
+}
+
+func leadingZeroBits(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b == 0 {
+			count += 8
+			continue
+		}
+		return count + bits.LeadingZeros8(b)
+	}
+	return count
+}
+
+// --- Captcha ---
+//
+// Compatible con hCaptcha, reCAPTCHA y Cloudflare Turnstile, que comparten el
+// protocolo siteverify (secret + response). El token se envía en captchaToken
+// o en la cabecera X-Captcha-Token.
+
+// verifyCaptcha valida el token de captcha contra CAPTCHA_VERIFY_URL
+func verifyCaptcha(c *gin.Context, body map[string]interface{}) error {
+	token := submissionValue(c, body, "captchaToken", "X-Captcha-Token")
+	if token == "" {
+		return &submissionError{http.StatusBadRequest, "Se requiere completar el captcha"}
+	}
+
+	verifyURL := getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify")
+	form := url.Values{
+		"secret":   {getEnv("CAPTCHA_SECRET", "")},
+		"response": {token},
+		"remoteip": {c.ClientIP()},
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.PostForm(verifyURL, form)
+	if err != nil {
+		logging.Errorf("Error al verificar captcha: %v", err)
+		return &submissionError{http.StatusServiceUnavailable, "No se pudo verificar el captcha, inténtalo de nuevo"}
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		logging.Warnf("Respuesta de captcha inválida: %v", err)
+		return &submissionError{http.StatusServiceUnavailable, "No se pudo verificar el captcha, inténtalo de nuevo"}
+	}
+	if !result.Success {
+		logging.Warnf("Captcha rechazado: %v", result.ErrorCodes)
+		return &submissionError{http.StatusForbidden, "Captcha inválido"}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/growdesk/widget-api/growdesk"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// availabilityCache guarda la última respuesta del backend por equipo y widget durante
+// unos segundos, para no consultar la presencia de los agentes en cada carga del widget
+type availabilityCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]availabilityEntry
+}
+
+type availabilityEntry struct {
+	value     growdesk.Availability
+	fetchedAt time.Time
+}
+
+var widgetAvailability = &availabilityCache{
+	ttl:     getDurationEnv("AVAILABILITY_CACHE_TTL", 15*time.Second),
+	entries: make(map[string]availabilityEntry),
+}
+
+// get devuelve la disponibilidad del chat en vivo. Si el backend no responde se
+// ofrece "dejar un mensaje": es preferible a prometer un chat que nadie atenderá.
+func (a *availabilityCache) get(ctx context.Context, teamID, widgetID string) growdesk.Availability {
+	key := teamID + "|" + widgetID
+	a.mu.Lock()
+	entry, ok := a.entries[key]
+	a.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < a.ttl {
+		return entry.value
+	}
+
+	availability, err := backend().GetAvailability(ctx, growdesk.AvailabilityOptions{TeamID: teamID, WidgetID: widgetID})
+	if err != nil {
+		logging.Errorf("Error al consultar la disponibilidad de agentes: %v", err)
+		return growdesk.Availability{Mode: "leave_message"}
+	}
+
+	a.mu.Lock()
+	a.entries[key] = availabilityEntry{value: *availability, fetchedAt: time.Now()}
+	a.mu.Unlock()
+	return *availability
+}
+
+// getAvailability indica al widget si debe mostrar el chat en vivo o el formulario
+// para dejar un mensaje, y cuándo vuelve a abrir si está fuera de horario.
+// ?teamId= limita el cálculo a los agentes de un equipo; X-Widget-ID elige su horario.
+func getAvailability(c *gin.Context) {
+	widgetID := c.GetHeader("X-Widget-ID")
+	if widgetID == "" {
+		widgetID = c.Query("widgetId")
+	}
+	c.JSON(http.StatusOK, widgetAvailability.get(c.Request.Context(), c.Query("teamId"), widgetID))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/growdesk/widget-api/growdesk"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
+)
+
+var (
+	backendClient     *growdesk.Client
+	backendClientOnce sync.Once
+)
+
+// backend devuelve el cliente del backend de GrowDesk compartido por widget-api.
+// Se configura con GROWDESK_API_URL, GROWDESK_API_KEY, BACKEND_TIMEOUT,
+// BACKEND_MAX_RETRIES y BACKEND_BREAKER_COOLDOWN. Las solicitudes pasan por
+// telemetry.Transport, que las mide y propaga la traza con traceparent, y por
+// logging.Transport, que envía el ID de solicitud en X-Request-ID.
+func backend() *growdesk.Client {
+	backendClientOnce.Do(func() {
+		maxRetries := 2
+		if v := getEnv("BACKEND_MAX_RETRIES", ""); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				maxRetries = n
+				if n == 0 {
+					maxRetries = -1
+				}
+			}
+		}
+
+		backendClient = growdesk.New(growdesk.Config{
+			BaseURL:         getEnv("GROWDESK_API_URL", "http://growdesk-backend:8080"),
+			APIKey:          backendAPIKey(),
+			MaxRetries:      maxRetries,
+			BreakerCooldown: getDurationEnv("BACKEND_BREAKER_COOLDOWN", 30*time.Second),
+			Headers:         map[string]string{"X-Source": "widget"},
+			HTTPClient: &http.Client{
+				Timeout:   getDurationEnv("BACKEND_TIMEOUT", 10*time.Second),
+				Transport: telemetry.NewTransport("widget_api_backend", &logging.Transport{}),
+			},
+		})
+	})
+	return backendClient
+}
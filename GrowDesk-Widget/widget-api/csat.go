@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/growdesk/widget-api/growdesk"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Encuestas de satisfacción.
+//
+// Cuando un ticket se resuelve o se cierra, el backend envía la encuesta a
+// /api/agent/csat-survey y widget-api la reenvía como evento csat_survey a las
+// sesiones del widget del ticket. El widget responde en
+// /widget/tickets/:ticketId/csat con el token firmado del evento, y widget-api
+// lo reenvía al backend, que comprueba la firma.
+
+// CSATSurveyEvent es el evento de encuesta que envía el backend
+type CSATSurveyEvent struct {
+	Type     string                 `json:"type"`
+	TicketID string                 `json:"ticketId" binding:"required"`
+	Data     map[string]interface{} `json:"data" binding:"required"`
+}
+
+// CSATSubmitRequest es la respuesta del widget a una encuesta
+type CSATSubmitRequest struct {
+	SurveyID string `json:"surveyId" binding:"required"`
+	Token    string `json:"token" binding:"required"`
+	Rating   int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment  string `json:"comment"`
+}
+
+// handleCSATSurvey reenvía la encuesta del backend a los clientes conectados al ticket
+func handleCSATSurvey(c *gin.Context) {
+	var req CSATSurveyEvent
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	localIDs, err := queryStrings(`SELECT ticket_id FROM widget_tickets WHERE backend_ticket_id=$1 OR ticket_id=$1`, req.TicketID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load tickets", "details": err.Error()})
+		return
+	}
+	if len(localIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket no encontrado en el widget"})
+		return
+	}
+
+	delivered := 0
+	for _, id := range localIDs {
+		delivered += sendCSATSurveyToWebSocketClients(id, req.Data)
+	}
+
+	logging.Infof("Encuesta de satisfacción del ticket %s enviada a %d conexiones", req.TicketID, delivered)
+	c.JSON(http.StatusOK, gin.H{"success": true, "delivered": delivered})
+}
+
+// sendCSATSurveyToWebSocketClients envía el evento csat_survey a los clientes de un
+// ticket local y devuelve a cuántos llegó
+func sendCSATSurveyToWebSocketClients(ticketID string, data map[string]interface{}) int {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":     "csat_survey",
+		"ticketId": ticketID,
+		"data":     data,
+	})
+	if err != nil {
+		logging.Errorf("Error al serializar la encuesta: %v", err)
+		return 0
+	}
+
+	wsConnectionsMutex.Lock()
+	defer wsConnectionsMutex.Unlock()
+	sent := 0
+	for _, conn := range wsConnections[ticketID] {
+		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+			logging.Errorf("Error al enviar la encuesta por WebSocket: %v", err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// submitCSAT reenvía al backend la valoración del cliente
+func submitCSAT(c *gin.Context) {
+	ticketID := c.Param("ticketId")
+	var req CSATSubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valoración inválida", "details": err.Error(), "success": false})
+		return
+	}
+	if _, err := LoadTicket(ticketID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket no encontrado", "success": false})
+		return
+	}
+
+	err := backend().SubmitCSAT(c.Request.Context(), req.SurveyID, growdesk.CSATResponse{
+		Token:   req.Token,
+		Rating:  req.Rating,
+		Comment: req.Comment,
+		Channel: "widget",
+	})
+	if err != nil {
+		var apiErr *growdesk.APIError
+		switch status := growdesk.StatusCode(err); status {
+		case http.StatusBadRequest, http.StatusForbidden, http.StatusGone:
+			errors.As(err, &apiErr)
+			c.JSON(status, gin.H{"error": apiErr.Message, "success": false})
+		default:
+			logging.Errorf("Error al enviar la valoración del ticket %s: %v", ticketID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo guardar la valoración", "success": false})
+		}
+		return
+	}
+
+	logging.Infof("Ticket %s valorado con %d desde el widget", ticketID, req.Rating)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,25 @@
+package growdesk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// GetAvailability indica si el horario de atención está abierto y hay agentes en línea
+// para atender el chat en vivo
+func (c *Client) GetAvailability(ctx context.Context, opts AvailabilityOptions) (*Availability, error) {
+	req := request{method: http.MethodGet, path: "/widget/availability", query: url.Values{}}
+	if opts.TeamID != "" {
+		req.query.Set("teamId", opts.TeamID)
+	}
+	if opts.WidgetID != "" {
+		req.query.Set("widgetId", opts.WidgetID)
+	}
+
+	var availability Availability
+	if err := c.do(ctx, req, &availability); err != nil {
+		return nil, err
+	}
+	return &availability, nil
+}
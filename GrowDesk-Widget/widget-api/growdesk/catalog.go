@@ -0,0 +1,31 @@
+package growdesk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListCategories devuelve las categorías de tickets
+func (c *Client) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/categories"}, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ListFAQs devuelve las preguntas frecuentes, opcionalmente filtradas por widget
+func (c *Client) ListFAQs(ctx context.Context, opts FAQOptions) ([]FAQ, error) {
+	req := request{method: http.MethodGet, path: "/api/faqs"}
+	if opts.WidgetID != "" {
+		req.query = url.Values{"widgetId": {opts.WidgetID}}
+		req.headers = map[string]string{"X-Widget-ID": opts.WidgetID}
+	}
+
+	var faqs []FAQ
+	if err := c.do(ctx, req, &faqs); err != nil {
+		return nil, err
+	}
+	return faqs, nil
+}
@@ -0,0 +1,265 @@
+// Package growdesk es un cliente tipado para la API REST del backend de GrowDesk.
+//
+// Ofrece métodos para tickets, mensajes, categorías y FAQs con soporte de
+// context, reintentos con backoff exponencial, circuit breaker y errores
+// estructurados (*APIError). Lo usa widget-api y sirve para integraciones propias:
+//
+//	client := growdesk.New(growdesk.Config{
+//		BaseURL: "http://growdesk-backend:8080",
+//		APIKey:  os.Getenv("GROWDESK_API_KEY"),
+//	})
+//	ticket, err := client.GetTicket(ctx, "TICKET-20250101-120000")
+//	if growdesk.IsNotFound(err) { ... }
+package growdesk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Config configura un Client. Los campos a cero toman valores por defecto.
+type Config struct {
+	// BaseURL es la URL del backend, p. ej. http://growdesk-backend:8080
+	BaseURL string
+	// APIKey es la credencial enviada como Bearer (clave de servicio, clave de API o JWT)
+	APIKey string
+	// Timeout por intento (por defecto 10s). Se ignora si se indica HTTPClient.
+	Timeout time.Duration
+	// MaxRetries es el número de reintentos tras el primer intento (por defecto 2; -1 desactiva)
+	MaxRetries int
+	// RetryBaseDelay y RetryMaxDelay acotan el backoff exponencial (por defecto 200ms y 5s)
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// BreakerThreshold es el número de fallos consecutivos que abre el circuito
+	// (por defecto 5; -1 desactiva el circuit breaker)
+	BreakerThreshold int
+	// BreakerCooldown es el tiempo que el circuito permanece abierto (por defecto 30s)
+	BreakerCooldown time.Duration
+	// Headers se añaden a todas las solicitudes (p. ej. X-Source)
+	Headers map[string]string
+	// HTTPClient permite usar un cliente HTTP propio
+	HTTPClient *http.Client
+}
+
+// Client es un cliente de la API del backend, seguro para uso concurrente
+type Client struct {
+	baseURL    string
+	apiKey     string
+	headers    map[string]string
+	http       *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	breaker    *breaker
+}
+
+// New crea un cliente con la configuración indicada
+func New(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = 200 * time.Millisecond
+	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = 5 * time.Second
+	}
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		headers:    cfg.Headers,
+		http:       httpClient,
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.RetryBaseDelay,
+		maxDelay:   cfg.RetryMaxDelay,
+		breaker:    newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+// BaseURL devuelve la URL del backend configurada
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// BreakerState devuelve el estado del circuit breaker: closed, open o half-open
+func (c *Client) BreakerState() string {
+	return c.breaker.currentState()
+}
+
+// request describe una llamada a la API
+type request struct {
+	method  string
+	path    string
+	query   url.Values
+	headers map[string]string
+	body    interface{}
+	// idempotent permite reintentar métodos que no lo son por naturaleza (p. ej. POST con ID externo)
+	idempotent bool
+}
+
+// do ejecuta la solicitud con reintentos y circuit breaker y decodifica la respuesta en out
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var payload []byte
+	if req.body != nil {
+		var err error
+		payload, err = json.Marshal(req.body)
+		if err != nil {
+			return fmt.Errorf("growdesk: error al serializar solicitud: %v", err)
+		}
+	}
+
+	retryable := req.idempotent || req.method == http.MethodGet || req.method == http.MethodHead ||
+		req.method == http.MethodPut || req.method == http.MethodDelete
+	attempts := 1
+	if retryable {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return err
+			}
+		}
+
+		if !c.breaker.allow() {
+			return ErrCircuitOpen
+		}
+
+		resp, body, err := c.send(ctx, req, payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.breaker.abort()
+				return ctx.Err()
+			}
+			c.breaker.record(false)
+			lastErr = fmt.Errorf("growdesk: %s %s: %w", req.method, req.path, err)
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			c.breaker.record(!countsAsFailure(resp.StatusCode))
+			lastErr = newAPIError(req.method, req.path, resp, body)
+			if isRetryableStatus(resp.StatusCode) {
+				continue
+			}
+			return lastErr
+		}
+
+		c.breaker.record(true)
+		if out != nil && len(body) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("growdesk: error al parsear respuesta de %s %s: %v", req.method, req.path, err)
+			}
+		}
+		return nil
+	}
+
+	return lastErr
+}
+
+// send realiza un único intento
+func (c *Client) send(ctx context.Context, req request, payload []byte) (*http.Response, []byte, error) {
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	for k, v := range req.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al leer respuesta: %v", err)
+	}
+	return resp, body, nil
+}
+
+// backoff calcula la espera antes del reintento: exponencial con jitter,
+// o la indicada por el backend en Retry-After
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > c.maxDelay {
+			return c.maxDelay
+		}
+		return apiErr.RetryAfter
+	}
+
+	delay := c.baseDelay << uint(attempt-1)
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	// Jitter entre el 50% y el 100% del retardo
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Health comprueba que el backend responde en /api/health
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodGet, path: "/api/health"}, nil)
+}
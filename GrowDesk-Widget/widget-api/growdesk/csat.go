@@ -0,0 +1,16 @@
+package growdesk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SubmitCSAT envía la valoración del cliente a una encuesta de satisfacción
+func (c *Client) SubmitCSAT(ctx context.Context, surveyID string, resp CSATResponse) error {
+	if surveyID == "" {
+		return fmt.Errorf("growdesk: ID de encuesta vacío")
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: "/csat/" + url.PathEscape(surveyID), body: resp}, nil)
+}
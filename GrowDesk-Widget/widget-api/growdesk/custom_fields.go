@@ -0,0 +1,16 @@
+package growdesk
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListPreChatFields devuelve los campos personalizados activos que se piden en el
+// formulario pre-chat del widget, de ticket y de contacto
+func (c *Client) ListPreChatFields(ctx context.Context) ([]CustomField, error) {
+	var fields []CustomField
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/widget/custom-fields"}, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,114 @@
+package growdesk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrCircuitOpen indica que el circuito está abierto tras fallos consecutivos
+// del backend y la solicitud no se llegó a enviar
+var ErrCircuitOpen = errors.New("growdesk: circuito abierto, el backend no está disponible")
+
+// APIError es una respuesta no exitosa del backend
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Message es el mensaje de error del backend (texto plano o campo "error" del JSON)
+	Message string
+	// Body es el cuerpo completo de la respuesta
+	Body string
+	// RetryAfter es la espera indicada por el backend en 429/503, si la hubo
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("growdesk: %s %s: código %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// newAPIError construye el error a partir de la respuesta
+func newAPIError(method, path string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Path:       path,
+		Body:       strings.TrimSpace(string(body)),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil && (payload.Error != "" || payload.Message != "") {
+		apiErr.Message = payload.Error
+		if apiErr.Message == "" {
+			apiErr.Message = payload.Message
+		}
+	} else {
+		apiErr.Message = apiErr.Body
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+// StatusCode devuelve el código HTTP del error, o 0 si no es un *APIError
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound indica si el backend respondió 404
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsPermanent indica si reintentar no tiene sentido (la solicitud es inválida o el
+// recurso ya no existe). Los errores de red, 5xx, 401/403, 408 y 429 no son permanentes.
+func IsPermanent(err error) bool {
+	switch StatusCode(err) {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus indica si un código merece reintento inmediato dentro del cliente
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// countsAsFailure indica si un código cuenta como fallo del backend para el circuito
+func countsAsFailure(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	var seconds int
+	if _, err := fmt.Sscanf(value, "%d", &seconds); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
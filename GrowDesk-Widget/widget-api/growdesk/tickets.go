@@ -0,0 +1,81 @@
+package growdesk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+func ticketPath(id string) string {
+	return "/api/tickets/" + url.PathEscape(id)
+}
+
+// ListTickets devuelve los tickets visibles para la credencial
+func (c *Client) ListTickets(ctx context.Context) ([]Ticket, error) {
+	var tickets []Ticket
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/tickets"}, &tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// GetTicket obtiene un ticket por su ID
+func (c *Client) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	if id == "" {
+		return nil, fmt.Errorf("growdesk: ID de ticket vacío")
+	}
+	var ticket Ticket
+	if err := c.do(ctx, request{method: http.MethodGet, path: ticketPath(id)}, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// CreateTicket crea un ticket. Si req.Metadata.ExternalID está definido la creación es
+// idempotente en el backend y la solicitud se reintenta ante fallos transitorios.
+func (c *Client) CreateTicket(ctx context.Context, req CreateTicketRequest) (*Ticket, error) {
+	idempotent := req.Metadata != nil && req.Metadata.ExternalID != ""
+
+	var ticket Ticket
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/tickets", body: req, idempotent: idempotent}, &ticket)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.ID == "" {
+		return nil, fmt.Errorf("growdesk: el backend no devolvió el ID del ticket")
+	}
+	return &ticket, nil
+}
+
+// UpdateTicket actualiza los campos indicados de un ticket
+func (c *Client) UpdateTicket(ctx context.Context, id string, req UpdateTicketRequest) (*Ticket, error) {
+	var ticket Ticket
+	if err := c.do(ctx, request{method: http.MethodPut, path: ticketPath(id), body: req}, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// ListMessages devuelve los mensajes de un ticket
+func (c *Client) ListMessages(ctx context.Context, ticketID string) ([]Message, error) {
+	var messages []Message
+	if err := c.do(ctx, request{method: http.MethodGet, path: ticketPath(ticketID) + "/messages"}, &messages); err != nil {
+		return nil, err
+	}
+	if messages == nil {
+		messages = []Message{}
+	}
+	return messages, nil
+}
+
+// AddMessage añade un mensaje a un ticket. No se reintenta para no duplicar mensajes.
+func (c *Client) AddMessage(ctx context.Context, ticketID string, req AddMessageRequest) (*Message, error) {
+	var resp struct {
+		Data Message `json:"data"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: ticketPath(ticketID) + "/messages", body: req}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
@@ -0,0 +1,169 @@
+package growdesk
+
+import "time"
+
+// Ticket es un ticket de soporte tal como lo devuelve el backend
+type Ticket struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Subject     string    `json:"subject,omitempty"`
+	Status      string    `json:"status"`
+	Priority    string    `json:"priority,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	CategoryID  string    `json:"categoryId,omitempty"`
+	AssignedTo  string    `json:"assignedTo,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	UserID      string    `json:"userId,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Customer    Customer  `json:"customer"`
+	Messages    []Message `json:"messages,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	WidgetID    string    `json:"widgetId,omitempty"`
+	Department  string    `json:"department,omitempty"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
+	MergedInto  string    `json:"mergedInto,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Customer es el cliente asociado a un ticket
+type Customer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Metadata contiene información adicional del origen de un ticket.
+// ExternalID hace idempotente la creación: el backend devuelve el ticket
+// existente si ya recibió uno con el mismo ID externo.
+type Metadata struct {
+	URL         string `json:"url,omitempty"`
+	Referrer    string `json:"referrer,omitempty"`
+	UserAgent   string `json:"userAgent,omitempty"`
+	ScreenSize  string `json:"screenSize,omitempty"`
+	ExternalID  string `json:"externalId,omitempty"`
+	Source      string `json:"source,omitempty"`
+	WidgetID    string `json:"widgetId,omitempty"`
+	ClientName  string `json:"clientName,omitempty"`
+	ClientEmail string `json:"clientEmail,omitempty"`
+	Department  string `json:"department,omitempty"`
+}
+
+// Message es un mensaje de un ticket
+type Message struct {
+	ID         string    `json:"id"`
+	Content    string    `json:"content"`
+	IsClient   bool      `json:"isClient"`
+	IsInternal bool      `json:"isInternal,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	UserID     string    `json:"userId,omitempty"`
+	UserName   string    `json:"userName,omitempty"`
+	UserEmail  string    `json:"userEmail,omitempty"`
+}
+
+// Category es una categoría de tickets
+type Category struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Color       string    `json:"color,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// FAQ es una pregunta frecuente
+type FAQ struct {
+	ID          int       `json:"id"`
+	Question    string    `json:"question"`
+	Answer      string    `json:"answer"`
+	Category    string    `json:"category"`
+	IsPublished bool      `json:"isPublished"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// CreateTicketRequest es el cuerpo de POST /api/tickets
+type CreateTicketRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CategoryID  string    `json:"categoryId,omitempty"` // Vacía: la deciden las reglas de enrutamiento
+	Priority    string    `json:"priority,omitempty"`
+	UserName    string    `json:"userName,omitempty"`
+	UserEmail   string    `json:"userEmail,omitempty"`
+	IsClient    bool      `json:"isClient"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
+	// Valores de los campos personalizados del formulario pre-chat
+	CustomFields  map[string]interface{} `json:"customFields,omitempty"`
+	ContactFields map[string]interface{} `json:"contactFields,omitempty"`
+}
+
+// UpdateTicketRequest es el cuerpo de PUT /api/tickets/{id}; los campos vacíos no se modifican
+type UpdateTicketRequest struct {
+	Status     string `json:"status,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+	AssignedTo string `json:"assignedTo,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Department string `json:"department,omitempty"`
+	Subject    string `json:"subject,omitempty"`
+}
+
+// AddMessageRequest es el cuerpo de POST /api/tickets/{id}/messages
+type AddMessageRequest struct {
+	TicketID   string `json:"ticketId,omitempty"`
+	Content    string `json:"content"`
+	IsClient   bool   `json:"isClient"`
+	IsInternal bool   `json:"isInternal,omitempty"`
+	UserID     string `json:"userId,omitempty"`
+	UserName   string `json:"userName,omitempty"`
+	UserEmail  string `json:"userEmail,omitempty"`
+}
+
+// CSATResponse es la respuesta del cliente a una encuesta de satisfacción. El token
+// firmado llega al widget en el evento csat_survey.
+type CSATResponse struct {
+	Token   string `json:"token"`
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Availability indica si el widget puede ofrecer chat en vivo o sólo dejar un mensaje
+type Availability struct {
+	LiveChatAvailable bool       `json:"liveChatAvailable"`
+	Mode              string     `json:"mode"` // "live_chat" o "leave_message"
+	OnlineAgents      int        `json:"onlineAgents"`
+	Open              bool       `json:"open"`                 // Dentro del horario de atención
+	NextOpenAt        *time.Time `json:"nextOpenAt,omitempty"` // Próxima apertura si está cerrado
+}
+
+// AvailabilityOptions limita el cálculo de disponibilidad
+type AvailabilityOptions struct {
+	// TeamID cuenta sólo a los agentes de un equipo
+	TeamID string
+	// WidgetID usa el horario de atención asignado al widget
+	WidgetID string
+}
+
+// CustomField es un campo personalizado que el widget pide en el formulario pre-chat
+type CustomField struct {
+	ID            string   `json:"id"`
+	Key           string   `json:"key"`
+	Label         string   `json:"label"`
+	Description   string   `json:"description,omitempty"`
+	Placeholder   string   `json:"placeholder,omitempty"`
+	Type          string   `json:"type"`   // text, number, date, dropdown, multi_select o checkbox
+	Entity        string   `json:"entity"` // "ticket" o "contact"
+	Options       []string `json:"options,omitempty"`
+	Required      bool     `json:"required"`
+	ShowInPreChat bool     `json:"showInPreChat"`
+	Position      int      `json:"position"`
+	Active        bool     `json:"active"`
+}
+
+// FAQOptions filtra el listado de FAQs
+type FAQOptions struct {
+	// WidgetID limita las FAQs a las de un widget
+	WidgetID string
+}
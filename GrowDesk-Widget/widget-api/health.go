@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/pkg/health"
+	"github.com/hmdev/GrowDeskV2/pkg/ratelimit"
+)
+
+// newHealthChecker registra las dependencias que revisa /health/ready. La base de datos y
+// la versión del esquema son críticas; el resto sólo marca el servicio como degradado.
+func newHealthChecker() *health.Checker {
+	checker := health.New("growdesk-widget-api")
+	checker.Add("database", true, health.Database(db))
+	checker.Add("schema", true, checkSchema)
+
+	checker.Add("websocket", false, func(ctx context.Context) health.Result {
+		wsConnectionsMutex.Lock()
+		tickets, connections := len(wsConnections), 0
+		for _, conns := range wsConnections {
+			connections += len(conns)
+		}
+		wsConnectionsMutex.Unlock()
+		return health.OK("", map[string]interface{}{"connections": connections, "tickets": tickets})
+	})
+
+	maxBacklog, err := strconv.Atoi(getEnv("HEALTH_MAX_OUTBOX_BACKLOG", "1000"))
+	if err != nil || maxBacklog <= 0 {
+		maxBacklog = 1000
+	}
+	maxAge := getDurationEnv("HEALTH_MAX_OUTBOX_AGE", 15*time.Minute)
+	checker.Add("outbox", false, func(ctx context.Context) health.Result {
+		return checkOutbox(ctx, maxBacklog, maxAge)
+	})
+
+	// Con RATE_LIMIT_STORE=redis el limitador vuelve a memoria si Redis falla, así que no
+	// es crítico
+	initRateLimiting()
+	if rl, ok := limiter.(*ratelimit.RedisLimiter); ok {
+		checker.Add("redis", false, func(ctx context.Context) health.Result {
+			details := map[string]interface{}{"addr": rl.Addr()}
+			if err := rl.Ping(); err != nil {
+				return health.Degraded("Redis no responde, se usa el límite en memoria: "+err.Error(), details)
+			}
+			return health.OK("", details)
+		})
+	}
+
+	// Si el backend no responde los mensajes quedan en el outbox y se reintentan
+	backendURL := strings.TrimSuffix(getEnv("GROWDESK_API_URL", "http://localhost:8080"), "/")
+	peer := health.Peer(backendURL + "/api/health/live")
+	checker.Add("backend", false, func(ctx context.Context) health.Result {
+		result := peer(ctx)
+		result.Details["circuit"] = backend().BreakerState()
+		return result
+	})
+	return checker
+}
+
+// checkSchema compara la versión del esquema aplicada con la que espera el binario
+func checkSchema(ctx context.Context) health.Result {
+	if db == nil {
+		return health.Down("base de datos no inicializada", nil)
+	}
+	var applied int
+	err := db.QueryRowContext(ctx, `SELECT version FROM widget_schema_version WHERE id`).Scan(&applied)
+	if err != nil && err != sql.ErrNoRows {
+		return health.Down("no se pudo leer la versión del esquema: "+err.Error(), nil)
+	}
+	details := map[string]interface{}{"applied": applied, "expected": widgetSchemaVersion}
+	switch {
+	case applied < widgetSchemaVersion:
+		return health.Down("el esquema de la base de datos está desactualizado", details)
+	case applied > widgetSchemaVersion:
+		return health.Degraded("el esquema es más reciente que este binario", details)
+	}
+	return health.OK("", details)
+}
+
+// checkOutbox informa de las entregas pendientes al backend y del dead-letter. Un outbox
+// que crece o no avanza indica que la sincronización está atascada.
+func checkOutbox(ctx context.Context, maxBacklog int, maxAge time.Duration) health.Result {
+	if db == nil {
+		return health.Down("base de datos no inicializada", nil)
+	}
+	var pending, dead int
+	var oldestSeconds float64
+	err := db.QueryRowContext(ctx, `
+                SELECT
+                        (SELECT COUNT(*) FROM widget_outbox WHERE status = 'pending'),
+                        (SELECT COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0) FROM widget_outbox WHERE status = 'pending'),
+                        (SELECT COUNT(*) FROM widget_outbox_dead)
+        `).Scan(&pending, &oldestSeconds, &dead)
+	if err != nil {
+		return health.Down("no se pudo leer el outbox: "+err.Error(), nil)
+	}
+
+	details := map[string]interface{}{
+		"pending":              pending,
+		"oldestPendingSeconds": int64(oldestSeconds),
+		"deadLetters":          dead,
+		"maxBacklog":           maxBacklog,
+	}
+	switch {
+	case pending > maxBacklog:
+		return health.Degraded("demasiadas entregas pendientes en el outbox", details)
+	case time.Duration(oldestSeconds)*time.Second > maxAge:
+		return health.Degraded("el outbox no avanza: hay entregas pendientes antiguas", details)
+	}
+	return health.OK("", details)
+}
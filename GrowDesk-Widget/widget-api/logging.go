@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
+)
+
+// initLogging instala el logger estructurado (LOG_LEVEL, LOG_FORMAT). Los logs de cada
+// solicitud llevan request_id y, si hay trazas, trace_id.
+func initLogging() {
+	logging.Init("growdesk-widget-api")
+	logging.AddContextAttr(func(ctx context.Context) slog.Attr {
+		return slog.String("trace_id", telemetry.TraceID(ctx))
+	})
+}
+
+// requestLogMiddleware asigna un ID a cada solicitud (o conserva el de X-Request-ID), lo
+// devuelve en la respuesta y registra una línea por solicitud. Nunca registra encabezados
+// ni cuerpos. Las sondas de salud y /metrics se registran en nivel debug para no llenar los logs.
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.IncomingRequestID(c.GetHeader(logging.RequestIDHeader))
+		c.Header(logging.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case strings.HasPrefix(c.Request.URL.Path, "/health") || c.Request.URL.Path == "/metrics":
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(c.Request.Context(), level, "solicitud HTTP",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// getLogLevel devuelve el nivel de registro actual
+func getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logging.Level()})
+}
+
+// setLogLevel cambia el nivel de registro (debug, info, warn o error) sin reiniciar
+func setLogLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Level == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere el nivel de registro (debug, info, warn o error)"})
+		return
+	}
+
+	previous := logging.Level()
+	if err := logging.SetLevel(req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slog.WarnContext(c.Request.Context(), "nivel de registro cambiado",
+		slog.String("from", previous), slog.String("to", logging.Level()))
+	c.JSON(http.StatusOK, gin.H{"level": logging.Level()})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Fusión de tickets.
+//
+// Cuando un agente fusiona en el backend un ticket del widget en otro ticket, el
+// backend lo notifica en /api/agent/ticket-merged. El ticket local conserva su ID
+// (la sesión del cliente sigue funcionando) y guarda el ticket destino en
+// merged_into: los mensajes que el cliente escriba llegan al backend por el
+// ticket original, que los redirige al destino, y las respuestas de los agentes
+// en el destino se entregan también a los tickets locales fusionados en él.
+
+// TicketMergedRequest es la notificación de fusión que envía el backend
+type TicketMergedRequest struct {
+	TicketID   string `json:"ticketId" binding:"required"`
+	MergedInto string `json:"mergedInto" binding:"required"`
+}
+
+// handleTicketMerged registra la fusión de un ticket del backend y avisa a los clientes conectados
+func handleTicketMerged(c *gin.Context) {
+	var req TicketMergedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	localIDs, err := markTicketMerged(req.TicketID, req.MergedInto)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record merge", "details": err.Error()})
+		return
+	}
+	if len(localIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ticket no encontrado en el widget"})
+		return
+	}
+
+	for _, id := range localIDs {
+		sendTicketMergedToWebSocketClients(id, req.MergedInto)
+	}
+
+	logging.Infof("Ticket %s fusionado en %s (%d tickets locales)", req.TicketID, req.MergedInto, len(localIDs))
+	c.JSON(http.StatusOK, gin.H{"success": true, "tickets": localIDs})
+}
+
+// markTicketMerged guarda el ticket destino en los tickets locales enlazados con
+// backendTicketID (o fusionados en él) y devuelve sus IDs locales
+func markTicketMerged(backendTicketID, mergedInto string) ([]string, error) {
+	rows, err := db.Query(`
+                UPDATE widget_tickets SET merged_into=$2, updated_at=NOW()
+                WHERE backend_ticket_id=$1 OR merged_into=$1
+                RETURNING ticket_id
+        `, backendTicketID, mergedInto)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// loadMergedTickets carga los tickets locales fusionados en el ticket del backend indicado
+func loadMergedTickets(backendTicketID string) ([]Ticket, error) {
+	ids, err := queryStrings(`SELECT ticket_id FROM widget_tickets WHERE merged_into=$1`, backendTicketID)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := make([]Ticket, 0, len(ids))
+	for _, id := range ids {
+		ticket, err := LoadTicket(id)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, nil
+}
+
+// sendTicketMergedToWebSocketClients avisa a los clientes de un ticket local de que se fusionó
+func sendTicketMergedToWebSocketClients(ticketID, mergedInto string) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":     "ticket_merged",
+		"ticketId": ticketID,
+		"data":     map[string]interface{}{"mergedInto": mergedInto},
+	})
+	if err != nil {
+		logging.Errorf("Error al serializar aviso de fusión: %v", err)
+		return
+	}
+
+	wsConnectionsMutex.Lock()
+	defer wsConnectionsMutex.Unlock()
+	for _, conn := range wsConnections[ticketID] {
+		if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+			logging.Errorf("Error al enviar aviso de fusión por WebSocket: %v", err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/growdesk/widget-api/growdesk"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// preChatCache guarda durante unos minutos los campos personalizados del formulario
+// pre-chat, que cambian muy poco y se consultan en cada carga del widget
+type preChatCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	fields    []growdesk.CustomField
+	fetchedAt time.Time
+}
+
+var widgetPreChat = &preChatCache{
+	ttl: getDurationEnv("PRECHAT_FIELDS_CACHE_TTL", 5*time.Minute),
+}
+
+// get devuelve los campos del pre-chat. Si el backend no responde se usa la última
+// lista conocida; ok es false si nunca se pudo obtener.
+func (p *preChatCache) get(ctx context.Context) ([]growdesk.CustomField, bool) {
+	p.mu.Lock()
+	fields, fetchedAt := p.fields, p.fetchedAt
+	p.mu.Unlock()
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < p.ttl {
+		return fields, true
+	}
+
+	fresh, err := backend().ListPreChatFields(ctx)
+	if err != nil {
+		logging.Errorf("Error al consultar los campos del pre-chat: %v", err)
+		return fields, !fetchedAt.IsZero()
+	}
+
+	p.mu.Lock()
+	p.fields, p.fetchedAt = fresh, time.Now()
+	p.mu.Unlock()
+	return fresh, true
+}
+
+// validate comprueba los valores del formulario pre-chat contra los campos definidos:
+// descarta las claves que ya no se piden, exige los obligatorios y convierte los
+// valores a su tipo. Si no se conocen los campos se envían tal cual y el backend decide.
+func (p *preChatCache) validate(ctx context.Context, ticketValues, contactValues map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	fields, ok := p.get(ctx)
+	if !ok {
+		return ticketValues, contactValues, nil
+	}
+
+	result := map[string]map[string]interface{}{"ticket": nil, "contact": nil}
+	input := map[string]map[string]interface{}{"ticket": ticketValues, "contact": contactValues}
+	for _, field := range fields {
+		value, present := input[field.Entity][field.Key]
+		if !present || isEmptyPreChatValue(value) {
+			if field.Required {
+				return nil, nil, fmt.Errorf("El campo '%s' es obligatorio", field.Label)
+			}
+			continue
+		}
+		normalized, err := coercePreChatValue(field, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("El campo '%s' no es válido: %v", field.Label, err)
+		}
+		if result[field.Entity] == nil {
+			result[field.Entity] = make(map[string]interface{})
+		}
+		result[field.Entity][field.Key] = normalized
+	}
+	return result["ticket"], result["contact"], nil
+}
+
+func isEmptyPreChatValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func coercePreChatValue(field growdesk.CustomField, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if number, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return number, nil
+			}
+		}
+		return nil, fmt.Errorf("se esperaba un número")
+	case "checkbox":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if checked, err := strconv.ParseBool(v); err == nil {
+				return checked, nil
+			}
+		}
+		return nil, fmt.Errorf("se esperaba true o false")
+	case "date":
+		text, _ := value.(string)
+		if _, err := time.Parse("2006-01-02", strings.TrimSpace(text)); err != nil {
+			return nil, fmt.Errorf("se esperaba una fecha YYYY-MM-DD")
+		}
+		return strings.TrimSpace(text), nil
+	case "dropdown":
+		text, _ := value.(string)
+		if !containsOption(field.Options, text) {
+			return nil, fmt.Errorf("opción inválida")
+		}
+		return text, nil
+	case "multi_select":
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("se esperaba una lista de opciones")
+		}
+		selected := make([]string, 0, len(list))
+		for _, item := range list {
+			text, _ := item.(string)
+			if !containsOption(field.Options, text) {
+				return nil, fmt.Errorf("opción inválida")
+			}
+			selected = append(selected, text)
+		}
+		return selected, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("se esperaba un texto")
+	}
+	return strings.TrimSpace(text), nil
+}
+
+func containsOption(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getPreChatFields devuelve al widget los campos personalizados que debe pedir en el
+// formulario inicial. Si el backend no responde el formulario se muestra sin ellos.
+func getPreChatFields(c *gin.Context) {
+	fields, _ := widgetPreChat.get(c.Request.Context())
+	if fields == nil {
+		fields = []growdesk.CustomField{}
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": fields})
+}
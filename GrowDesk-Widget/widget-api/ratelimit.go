@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/ratelimit"
+)
+
+// defaultRateLimits son los límites por ruta si RATE_LIMITS no los redefine
+var defaultRateLimits = ratelimit.Routes{
+	"tickets.create": {
+		{Scope: ratelimit.ScopeIP, Limit: 5, Per: 10 * time.Minute},
+		{Scope: ratelimit.ScopeSession, Limit: 3, Per: 10 * time.Minute},
+		{Scope: ratelimit.ScopeWidget, Limit: 120, Per: time.Minute},
+	},
+	"messages.create": {
+		{Scope: ratelimit.ScopeIP, Limit: 30, Per: time.Minute},
+		{Scope: ratelimit.ScopeSession, Limit: 20, Per: time.Minute},
+		{Scope: ratelimit.ScopeWidget, Limit: 600, Per: time.Minute},
+	},
+	"messages.list": {
+		{Scope: ratelimit.ScopeIP, Limit: 120, Per: time.Minute},
+	},
+	"faqs": {
+		{Scope: ratelimit.ScopeIP, Limit: 60, Per: time.Minute},
+	},
+	"availability": {
+		{Scope: ratelimit.ScopeIP, Limit: 60, Per: time.Minute},
+	},
+	"prechat": {
+		{Scope: ratelimit.ScopeIP, Limit: 60, Per: time.Minute},
+	},
+	"csat": {
+		{Scope: ratelimit.ScopeIP, Limit: 20, Per: time.Minute},
+	},
+	"challenge": {
+		{Scope: ratelimit.ScopeIP, Limit: 30, Per: time.Minute},
+	},
+	"ws": {
+		{Scope: ratelimit.ScopeIP, Limit: 20, Per: time.Minute},
+	},
+}
+
+var (
+	limiter     ratelimit.Limiter
+	limiterOnce sync.Once
+	routeLimits ratelimit.Routes
+)
+
+// initRateLimiting configura el limitador (memoria o Redis) y los límites por ruta
+func initRateLimiting() {
+	limiterOnce.Do(func() {
+		routeLimits = ratelimit.LoadRoutes(defaultRateLimits, getEnv("RATE_LIMITS", ""))
+		limiter = ratelimit.NewFromEnv("growdesk:widget-api:rl:")
+	})
+}
+
+// sessionKey identifica la sesión del visitante: cabecera X-Session-ID, cookie
+// de sesión del widget o, en su defecto, el ticket sobre el que se opera
+func sessionKey(c *gin.Context) string {
+	if id := c.GetHeader("X-Session-ID"); id != "" {
+		return id
+	}
+	if cookie, err := c.Cookie("growdesk_session"); err == nil && cookie != "" {
+		return cookie
+	}
+	if id := c.GetHeader("X-Ticket-ID"); id != "" {
+		return id
+	}
+	return c.Param("ticketId")
+}
+
+// limitSubject devuelve el identificador del ámbito o "" si la solicitud no lo aporta.
+// c.ClientIP sólo respeta X-Forwarded-For si la conexión viene de TRUSTED_PROXIES
+// (ver configureTrustedProxies).
+func limitSubject(c *gin.Context, scope string) string {
+	switch scope {
+	case ratelimit.ScopeIP:
+		return c.ClientIP()
+	case ratelimit.ScopeWidget:
+		return c.GetHeader("X-Widget-ID")
+	case ratelimit.ScopeSession:
+		return ratelimit.SessionSubject(c.ClientIP(), sessionKey(c))
+	}
+	return ""
+}
+
+// configureTrustedProxies limita a TRUSTED_PROXIES los proxies cuyos X-Forwarded-For y
+// X-Real-IP acepta gin; sin la variable, la IP del cliente es la de la conexión
+func configureTrustedProxies(router *gin.Engine) {
+	proxies := ratelimit.ProxiesFromEnv().List()
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		logging.Errorf("TRUSTED_PROXIES inválido, no se confía en ningún proxy: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+}
+
+// rateLimit aplica los límites configurados para la ruta y responde 429 con Retry-After
+func rateLimit(route string) gin.HandlerFunc {
+	initRateLimiting()
+
+	return func(c *gin.Context) {
+		if strings.ToLower(getEnv("RATE_LIMIT_ENABLED", "true")) == "false" {
+			c.Next()
+			return
+		}
+
+		for _, rule := range routeLimits[route] {
+			if rule.Limit <= 0 {
+				continue
+			}
+			subject := limitSubject(c, rule.Scope)
+			if subject == "" {
+				continue
+			}
+
+			allowed, wait, err := limiter.Allow(ratelimit.Key(route, rule.Scope, subject), rule.Limit, rule.Per)
+			if err != nil {
+				logging.Errorf("Error en el limitador de solicitudes (se usa memoria): %v", err)
+			}
+			if !allowed {
+				retryAfter := int(math.Ceil(wait.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				logging.Warnf("Límite de solicitudes alcanzado en %s por %s", route, rule.Scope)
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error":      "Demasiadas solicitudes, inténtalo de nuevo más tarde",
+					"success":    false,
+					"retryAfter": retryAfter,
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
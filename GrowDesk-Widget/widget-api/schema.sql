@@ -1,4 +1,16 @@
-CREATE TABLE IF NOT EXISTS widget_tickets_api (
+-- Modelo único de tickets del widget.
+-- widget-api guarda aquí su copia local y la sincroniza con el backend
+-- a través del outbox (widget_outbox). backend_ticket_id enlaza cada ticket
+-- con el ticket autoritativo del backend.
+-- Versión del esquema de widget-api (una sola fila). La registra initSchema con
+-- widgetSchemaVersion, que hay que incrementar al modificar este archivo.
+CREATE TABLE IF NOT EXISTS widget_schema_version (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version INTEGER NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS widget_tickets (
     ticket_id TEXT PRIMARY KEY,
     title TEXT,
     subject TEXT,
@@ -13,9 +25,16 @@ CREATE TABLE IF NOT EXISTS widget_tickets_api (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
-CREATE TABLE IF NOT EXISTS widget_messages_api (
+ALTER TABLE widget_tickets ADD COLUMN IF NOT EXISTS backend_ticket_id TEXT UNIQUE;
+ALTER TABLE widget_tickets ADD COLUMN IF NOT EXISTS synced BOOLEAN DEFAULT FALSE;
+ALTER TABLE widget_tickets ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE;
+-- Ticket del backend en el que se fusionó este ticket; la conversación sigue allí
+ALTER TABLE widget_tickets ADD COLUMN IF NOT EXISTS merged_into TEXT;
+CREATE INDEX IF NOT EXISTS idx_widget_tickets_merged_into ON widget_tickets(merged_into);
+
+CREATE TABLE IF NOT EXISTS widget_messages (
     id TEXT PRIMARY KEY,
-    ticket_id TEXT REFERENCES widget_tickets_api(ticket_id) ON DELETE CASCADE,
+    ticket_id TEXT REFERENCES widget_tickets(ticket_id) ON DELETE CASCADE,
     content TEXT,
     is_client BOOLEAN DEFAULT TRUE,
     user_name TEXT,
@@ -23,5 +42,91 @@ CREATE TABLE IF NOT EXISTS widget_messages_api (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+ALTER TABLE widget_messages ADD COLUMN IF NOT EXISTS backend_message_id TEXT UNIQUE;
+ALTER TABLE widget_messages ADD COLUMN IF NOT EXISTS synced BOOLEAN DEFAULT FALSE;
+ALTER TABLE widget_messages ADD COLUMN IF NOT EXISTS synced_at TIMESTAMP WITH TIME ZONE;
+
+-- Outbox con las operaciones pendientes de enviar al backend.
+-- Se escribe en la misma transacción que el ticket o mensaje local.
+CREATE TABLE IF NOT EXISTS widget_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('create_ticket', 'add_message')),
+    ticket_id TEXT NOT NULL REFERENCES widget_tickets(ticket_id) ON DELETE CASCADE,
+    message_id TEXT,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- traceparent (W3C) de la solicitud que originó la operación, para continuar su traza
+-- al entregarla al backend
+ALTER TABLE widget_outbox ADD COLUMN IF NOT EXISTS traceparent TEXT;
+-- X-Request-ID de la solicitud que originó la operación, para correlacionar los logs
+ALTER TABLE widget_outbox ADD COLUMN IF NOT EXISTS request_id TEXT;
+
+-- Dead-letter: operaciones que superaron la edad máxima o fallaron de forma
+-- permanente. Se pueden inspeccionar y reencolar desde /api/sync/dead-letters.
+CREATE TABLE IF NOT EXISTS widget_outbox_dead (
+    id BIGINT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    ticket_id TEXT NOT NULL REFERENCES widget_tickets(ticket_id) ON DELETE CASCADE,
+    message_id TEXT,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Conflictos detectados por el job de reconciliación
+CREATE TABLE IF NOT EXISTS widget_sync_conflicts (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id TEXT NOT NULL REFERENCES widget_tickets(ticket_id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    local_value TEXT,
+    remote_value TEXT,
+    resolution TEXT NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Migración de las tablas heredadas widget_tickets_api / widget_messages_api
+DO $$
+BEGIN
+    IF to_regclass('widget_tickets_api') IS NOT NULL THEN
+        INSERT INTO widget_tickets (
+            ticket_id, title, subject, description, status, priority,
+            client_name, client_email, widget_id, department, created_at, updated_at
+        )
+        SELECT ticket_id, title, subject, description, status, priority,
+               client_name, client_email, widget_id, department, created_at, updated_at
+        FROM widget_tickets_api
+        ON CONFLICT (ticket_id) DO NOTHING;
+
+        IF to_regclass('widget_messages_api') IS NOT NULL THEN
+            INSERT INTO widget_messages (
+                id, ticket_id, content, is_client, user_name, user_email, created_at
+            )
+            SELECT id, ticket_id, content, is_client, user_name, user_email, created_at
+            FROM widget_messages_api
+            ON CONFLICT (id) DO NOTHING;
+
+            DROP TABLE widget_messages_api;
+        END IF;
+
+        DROP TABLE widget_tickets_api;
+        RAISE NOTICE 'Tablas widget_tickets_api/widget_messages_api migradas a widget_tickets/widget_messages';
+    END IF;
+END $$;
+
 -- Índices para mejorar el rendimiento
-CREATE INDEX IF NOT EXISTS idx_widget_messages_api_ticket_id ON widget_messages_api(ticket_id); 
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_widget_messages_ticket_id ON widget_messages(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_widget_tickets_synced ON widget_tickets(synced) WHERE synced = FALSE;
+CREATE INDEX IF NOT EXISTS idx_widget_messages_synced ON widget_messages(synced) WHERE synced = FALSE;
+CREATE INDEX IF NOT EXISTS idx_widget_outbox_pending ON widget_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_widget_outbox_dead_ticket_id ON widget_outbox_dead(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_widget_sync_conflicts_ticket_id ON widget_sync_conflicts(ticket_id);
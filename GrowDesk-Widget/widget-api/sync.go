@@ -0,0 +1,1096 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/growdesk/widget-api/growdesk"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sincronización bidireccional entre widget_tickets/widget_messages y el backend.
+//
+// Cada escritura local que debe llegar al backend se registra en widget_outbox
+// dentro de la misma transacción. Un worker entrega el outbox y guarda los IDs
+// asignados por el backend (backend_ticket_id / backend_message_id), mientras
+// que un job de reconciliación detecta lo que quedó sin sincronizar, trae los
+// mensajes que faltan desde el backend y registra los conflictos encontrados.
+//
+// Los fallos se reintentan con backoff exponencial y jitter. Las operaciones
+// que superan SYNC_MAX_AGE o que el backend rechaza de forma permanente pasan
+// a widget_outbox_dead, desde donde un administrador puede reencolarlas.
+
+const (
+	outboxKindCreateTicket = "create_ticket"
+	outboxKindAddMessage   = "add_message"
+)
+
+// outboxEntry representa una operación pendiente de enviar al backend
+type outboxEntry struct {
+	ID              int64
+	Kind            string
+	TicketID        string
+	MessageID       string
+	Payload         []byte
+	Attempts        int
+	CreatedAt       time.Time
+	BackendTicketID string
+	Traceparent     string
+	RequestID       string
+}
+
+// getDurationEnv lee una duración de las variables de entorno (p. ej. "30s", "5m")
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	if v := getEnv(key, ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		logging.Warnf("Valor inválido para %s: %s, usando %v", key, v, def)
+	}
+	return def
+}
+
+// enqueueOutbox registra una operación para el backend dentro de la transacción indicada.
+// Guarda el traceparent y el ID de solicitud de ctx para que la entrega continúe la traza
+// y los logs de la solicitud que la originó.
+func enqueueOutbox(ctx context.Context, tx *sql.Tx, kind, ticketID, messageID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error al serializar payload del outbox: %v", err)
+	}
+
+	header := http.Header{}
+	telemetry.Inject(ctx, header)
+
+	_, err = tx.ExecContext(ctx, `
+                INSERT INTO widget_outbox (kind, ticket_id, message_id, payload, traceparent, request_id)
+                VALUES ($1, $2, $3, $4, $5, $6)
+        `, kind, ticketID, nullIfEmpty(messageID), data, nullIfEmpty(header.Get("traceparent")), nullIfEmpty(logging.RequestID(ctx)))
+	return err
+}
+
+// buildCreateTicketPayload arma el cuerpo que espera POST /api/tickets del backend
+func buildCreateTicketPayload(ticket Ticket) growdesk.CreateTicketRequest {
+	return growdesk.CreateTicketRequest{
+		Title:       ticket.Title,
+		Description: ticket.Description,
+		Priority:    normalizePriority(ticket.Priority),
+		UserName:    ticket.UserName,
+		UserEmail:   ticket.UserEmail,
+		IsClient:    true, // Siempre true para tickets del widget
+		Metadata: &growdesk.Metadata{
+			URL:         ticket.Metadata.URL,
+			UserAgent:   ticket.Metadata.UserAgent,
+			Referrer:    ticket.Metadata.Referrer,
+			ScreenSize:  ticket.Metadata.ScreenSize,
+			ExternalID:  ticket.ID,
+			Source:      "widget",
+			WidgetID:    ticket.WidgetID,
+			ClientName:  ticket.ClientName,
+			ClientEmail: ticket.ClientEmail,
+			Department:  ticket.Department,
+		},
+		CustomFields:  ticket.CustomFields,
+		ContactFields: ticket.ContactFields,
+	}
+}
+
+// buildAddMessagePayload arma el cuerpo que espera POST /api/tickets/{id}/messages
+func buildAddMessagePayload(ticketID string, message Message) growdesk.AddMessageRequest {
+	return growdesk.AddMessageRequest{
+		TicketID:  ticketID,
+		Content:   message.Content,
+		UserID:    message.UserEmail,
+		IsClient:  message.IsClient,
+		UserName:  message.UserName,
+		UserEmail: message.UserEmail,
+	}
+}
+
+// createTicketWithOutbox guarda un ticket nuevo y encola su creación en el backend
+func createTicketWithOutbox(ctx context.Context, ticket Ticket) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTicketWith(tx, ticket); err != nil {
+		return err
+	}
+
+	initialMessageID := ""
+	if len(ticket.Messages) > 0 {
+		initialMessageID = ticket.Messages[0].ID
+	}
+	if err := enqueueOutbox(ctx, tx, outboxKindCreateTicket, ticket.ID, initialMessageID, buildCreateTicketPayload(ticket)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addClientMessageWithOutbox guarda un mensaje del cliente y encola su envío al backend
+func addClientMessageWithOutbox(ctx context.Context, ticketID string, message Message) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertMessageWith(tx, ticketID, message); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE widget_tickets SET updated_at=NOW() WHERE ticket_id=$1`, ticketID); err != nil {
+		return err
+	}
+	if err := enqueueOutbox(ctx, tx, outboxKindAddMessage, ticketID, message.ID, buildAddMessagePayload(ticketID, message)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// startSyncWorkers arranca el worker del outbox y el job de reconciliación
+func startSyncWorkers() {
+	outboxInterval := getDurationEnv("SYNC_INTERVAL", 5*time.Second)
+	reconcileInterval := getDurationEnv("RECONCILE_INTERVAL", 5*time.Minute)
+
+	go func() {
+		ticker := time.NewTicker(outboxInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := processOutbox(); err != nil {
+				logging.Errorf("Error al procesar el outbox: %v", err)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := reconcile(); err != nil {
+				logging.Errorf("Error en la reconciliación con el backend: %v", err)
+			}
+		}
+	}()
+
+	logging.Infof("Sincronización con el backend activa (outbox cada %v, reconciliación cada %v)", outboxInterval, reconcileInterval)
+}
+
+// claimOutboxEntries reserva un lote de entradas pendientes para este proceso
+func claimOutboxEntries(limit int) ([]outboxEntry, error) {
+	rows, err := db.Query(`
+                UPDATE widget_outbox o
+                SET next_attempt_at = NOW() + INTERVAL '2 minutes', updated_at = NOW()
+                FROM widget_tickets t
+                WHERE o.ticket_id = t.ticket_id
+                  AND o.id IN (
+                        SELECT id FROM widget_outbox
+                        WHERE status = 'pending' AND next_attempt_at <= NOW()
+                        ORDER BY id
+                        LIMIT $1
+                        FOR UPDATE SKIP LOCKED
+                  )
+                RETURNING o.id, o.kind, o.ticket_id, COALESCE(o.message_id, ''), o.payload, o.attempts,
+                          o.created_at, COALESCE(t.backend_ticket_id, ''), COALESCE(o.traceparent, ''), COALESCE(o.request_id, '')
+        `, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []outboxEntry
+	for rows.Next() {
+		var e outboxEntry
+		if err := rows.Scan(&e.ID, &e.Kind, &e.TicketID, &e.MessageID, &e.Payload, &e.Attempts, &e.CreatedAt, &e.BackendTicketID, &e.Traceparent, &e.RequestID); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Respetar el orden de inserción: el ticket debe crearse antes que sus mensajes
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// processOutbox entrega al backend las operaciones pendientes
+func processOutbox() error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	entries, err := claimOutboxEntries(20)
+	if err != nil {
+		return err
+	}
+
+	// Tickets creados en este mismo lote, para no esperar al siguiente ciclo
+	created := make(map[string]string)
+
+	for _, e := range entries {
+		if e.BackendTicketID == "" {
+			e.BackendTicketID = created[e.TicketID]
+		}
+
+		var deliverErr error
+		switch e.Kind {
+		case outboxKindCreateTicket:
+			var backendID string
+			backendID, deliverErr = deliverCreateTicket(e)
+			if deliverErr == nil {
+				created[e.TicketID] = backendID
+			}
+		case outboxKindAddMessage:
+			if e.BackendTicketID == "" {
+				// El ticket todavía no existe en el backend: esperar sin contar un intento
+				if time.Since(e.CreatedAt) > getDurationEnv("SYNC_MAX_AGE", 72*time.Hour) {
+					if err := moveToDeadLetter(e.ID, "edad máxima superada esperando al ticket"); err != nil {
+						logging.Errorf("Error al mover entrada %d al dead-letter: %v", e.ID, err)
+					}
+					outboxDeliveries.Inc(e.Kind, "dead")
+					continue
+				}
+				if err := postponeOutboxEntry(e.ID, "ticket pendiente de sincronizar"); err != nil {
+					logging.Errorf("Error al posponer entrada %d del outbox: %v", e.ID, err)
+				}
+				outboxDeliveries.Inc(e.Kind, "postponed")
+				continue
+			}
+			deliverErr = deliverAddMessage(e)
+		default:
+			deliverErr = fmt.Errorf("tipo de operación desconocido: %s", e.Kind)
+		}
+
+		if errors.Is(deliverErr, growdesk.ErrCircuitOpen) {
+			// El backend está caído: esperar sin gastar intentos
+			if err := postponeOutboxEntry(e.ID, deliverErr.Error()); err != nil {
+				logging.Errorf("Error al posponer entrada %d del outbox: %v", e.ID, err)
+			}
+			outboxDeliveries.Inc(e.Kind, "postponed")
+			continue
+		}
+		if deliverErr != nil {
+			logging.Errorf("Error al sincronizar %s del ticket %s (intento %d): %v", e.Kind, e.TicketID, e.Attempts+1, deliverErr)
+			if err := failOutboxEntry(e, deliverErr); err != nil {
+				logging.Errorf("Error al registrar fallo de la entrada %d del outbox: %v", e.ID, err)
+			}
+			outboxDeliveries.Inc(e.Kind, "failure")
+			continue
+		}
+
+		outboxDeliveries.Inc(e.Kind, "success")
+		logging.Infof("Sincronizado %s del ticket %s con el backend", e.Kind, e.TicketID)
+	}
+
+	return nil
+}
+
+// deliverCreateTicket crea el ticket en el backend y enlaza los IDs asignados
+func deliverCreateTicket(e outboxEntry) (id string, err error) {
+	ctx, span := e.startSpan()
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	var payload growdesk.CreateTicketRequest
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return "", fmt.Errorf("payload inválido: %v", err)
+	}
+
+	// Sin categoría explícita, el backend la decide con sus reglas de enrutamiento
+	created, err := backend().CreateTicket(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+                UPDATE widget_tickets SET backend_ticket_id=$2, synced=TRUE, synced_at=NOW()
+                WHERE ticket_id=$1
+        `, e.TicketID, created.ID); err != nil {
+		return "", err
+	}
+
+	// El backend crea el mensaje inicial a partir de la descripción
+	if e.MessageID != "" {
+		backendMessageID := ""
+		if len(created.Messages) > 0 {
+			backendMessageID = created.Messages[0].ID
+		}
+		if _, err := tx.Exec(`
+                        UPDATE widget_messages SET backend_message_id=$2, synced=TRUE, synced_at=NOW()
+                        WHERE id=$1
+                `, e.MessageID, nullIfEmpty(backendMessageID)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := completeOutboxEntry(tx, e.ID); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// deliverAddMessage envía un mensaje del cliente al ticket del backend
+func deliverAddMessage(e outboxEntry) (err error) {
+	ctx, span := e.startSpan()
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	var payload growdesk.AddMessageRequest
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return fmt.Errorf("payload inválido: %v", err)
+	}
+	payload.TicketID = e.BackendTicketID
+
+	created, err := backend().AddMessage(ctx, e.BackendTicketID, payload)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+                UPDATE widget_messages SET backend_message_id=$2, synced=TRUE, synced_at=NOW()
+                WHERE id=$1
+        `, e.MessageID, nullIfEmpty(created.ID)); err != nil {
+		return err
+	}
+	if err := completeOutboxEntry(tx, e.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// startSpan abre el span de la entrega, hijo de la solicitud que encoló la operación, y
+// recupera su ID de solicitud para enviarlo al backend
+func (e outboxEntry) startSpan() (context.Context, trace.Span) {
+	ctx := telemetry.Extract(context.Background(), http.Header{"Traceparent": {e.Traceparent}})
+	if e.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, e.RequestID)
+	}
+	ctx, span := telemetry.StartSpan(ctx, "outbox "+e.Kind, telemetry.SpanKindInternal)
+	span.SetAttributes(
+		attribute.Int64("outbox.id", e.ID),
+		attribute.Int("outbox.attempt", e.Attempts+1),
+		attribute.String("ticket.id", e.TicketID),
+	)
+	return ctx, span
+}
+
+// completeOutboxEntry marca una entrada como entregada
+func completeOutboxEntry(tx *sql.Tx, id int64) error {
+	_, err := tx.Exec(`
+                UPDATE widget_outbox SET status='done', attempts=attempts+1, last_error=NULL, updated_at=NOW()
+                WHERE id=$1
+        `, id)
+	return err
+}
+
+// failOutboxEntry registra un intento fallido y programa el siguiente,
+// o mueve la entrada al dead-letter si el fallo es definitivo
+func failOutboxEntry(e outboxEntry, deliverErr error) error {
+	_, err := db.Exec(`
+                UPDATE widget_outbox
+                SET attempts=attempts+1, last_error=$2, next_attempt_at=$3, updated_at=NOW()
+                WHERE id=$1
+        `, e.ID, deliverErr.Error(), time.Now().Add(retryBackoff(e.Attempts)))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case growdesk.IsPermanent(deliverErr):
+		return moveToDeadLetter(e.ID, "rechazado por el backend")
+	case time.Since(e.CreatedAt) > getDurationEnv("SYNC_MAX_AGE", 72*time.Hour):
+		return moveToDeadLetter(e.ID, "edad máxima superada")
+	}
+	return nil
+}
+
+// retryBackoff calcula la espera antes del siguiente intento: backoff exponencial
+// desde SYNC_RETRY_BASE hasta SYNC_RETRY_MAX, con jitter sobre la mitad del valor
+func retryBackoff(attempts int) time.Duration {
+	base := getDurationEnv("SYNC_RETRY_BASE", 5*time.Second)
+	maxDelay := getDurationEnv("SYNC_RETRY_MAX", 30*time.Minute)
+
+	delay := maxDelay
+	if attempts < 30 {
+		if d := base << uint(attempts); d > 0 && d < maxDelay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// moveToDeadLetter saca una entrada del outbox y la guarda en widget_outbox_dead
+func moveToDeadLetter(id int64, reason string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+                INSERT INTO widget_outbox_dead (id, kind, ticket_id, message_id, payload, attempts, last_error, reason, created_at)
+                SELECT id, kind, ticket_id, message_id, payload, attempts, last_error, $2, created_at
+                FROM widget_outbox WHERE id=$1 AND status='pending'
+                ON CONFLICT (id) DO NOTHING
+        `, id, reason)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM widget_outbox WHERE id=$1`, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logging.Warnf("Entrada %d del outbox movida al dead-letter: %s", id, reason)
+	return nil
+}
+
+// postponeOutboxEntry reprograma una entrada sin contarla como intento
+func postponeOutboxEntry(id int64, reason string) error {
+	retryDelay := getDurationEnv("SYNC_INTERVAL", 5*time.Second)
+	_, err := db.Exec(`
+                UPDATE widget_outbox SET last_error=$2, next_attempt_at=$3, updated_at=NOW()
+                WHERE id=$1
+        `, id, reason, time.Now().Add(retryDelay))
+	return err
+}
+
+// recordSyncConflict guarda un conflicto si no se había registrado ya
+func recordSyncConflict(ticketID, kind, localValue, remoteValue, resolution string) {
+	_, err := db.Exec(`
+                INSERT INTO widget_sync_conflicts (ticket_id, kind, local_value, remote_value, resolution)
+                SELECT $1, $2, $3, $4, $5
+                WHERE NOT EXISTS (
+                        SELECT 1 FROM widget_sync_conflicts
+                        WHERE ticket_id=$1 AND kind=$2 AND local_value IS NOT DISTINCT FROM $3
+                          AND remote_value IS NOT DISTINCT FROM $4
+                )
+        `, ticketID, kind, localValue, remoteValue, resolution)
+	if err != nil {
+		logging.Errorf("Error al registrar conflicto de sincronización para %s: %v", ticketID, err)
+		return
+	}
+	logging.Warnf("Conflicto de sincronización en ticket %s: %s (local=%q, backend=%q, resolución=%s)",
+		ticketID, kind, localValue, remoteValue, resolution)
+}
+
+// reconcileSummary resume lo realizado en una pasada de reconciliación
+type reconcileSummary struct {
+	TicketsEnqueued  int `json:"ticketsEnqueued"`
+	TicketsLinked    int `json:"ticketsLinked"`
+	MessagesEnqueued int `json:"messagesEnqueued"`
+	MessagesPulled   int `json:"messagesPulled"`
+	Conflicts        int `json:"conflicts"`
+}
+
+// reconcile compara el estado local con el del backend y corrige las diferencias
+func reconcile() (reconcileSummary, error) {
+	var summary reconcileSummary
+	if db == nil {
+		return summary, fmt.Errorf("database not initialized")
+	}
+
+	if err := reconcileUnlinkedTickets(&summary); err != nil {
+		return summary, err
+	}
+	if err := reconcileUnsyncedMessages(&summary); err != nil {
+		return summary, err
+	}
+	if err := reconcileLinkedTickets(&summary); err != nil {
+		return summary, err
+	}
+
+	logging.Infof("Reconciliación completada: %+v", summary)
+	return summary, nil
+}
+
+// reconcileUnlinkedTickets vuelve a encolar tickets que nunca llegaron al backend
+func reconcileUnlinkedTickets(summary *reconcileSummary) error {
+	ids, err := queryStrings(`
+                SELECT t.ticket_id FROM widget_tickets t
+                WHERE t.backend_ticket_id IS NULL
+                  AND NOT EXISTS (
+                        SELECT 1 FROM widget_outbox o
+                        WHERE o.ticket_id = t.ticket_id AND o.kind = 'create_ticket' AND o.status = 'pending'
+                  )
+                  AND NOT EXISTS (
+                        SELECT 1 FROM widget_outbox_dead d
+                        WHERE d.ticket_id = t.ticket_id AND d.kind = 'create_ticket'
+                  )
+        `)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		ticket, err := LoadTicket(id)
+		if err != nil {
+			logging.Errorf("Error al cargar ticket %s para reconciliar: %v", id, err)
+			continue
+		}
+
+		// Tickets antiguos pudieron crearse en el backend con el mismo ID antes de existir el outbox
+		remote, err := backend().GetTicket(context.Background(), id)
+		switch {
+		case err == nil && remote.Title == ticket.Title:
+			if err := linkTicket(ticket, remote); err != nil {
+				logging.Errorf("Error al enlazar ticket %s: %v", id, err)
+				continue
+			}
+			summary.TicketsLinked++
+			continue
+		case err == nil:
+			recordSyncConflict(id, "id_collision", ticket.Title, remote.Title, "create_new")
+			summary.Conflicts++
+		case !growdesk.IsNotFound(err):
+			logging.Errorf("No se pudo consultar el ticket %s en el backend: %v", id, err)
+			continue
+		}
+
+		if err := enqueueTicketCreation(ticket); err != nil {
+			logging.Errorf("Error al encolar ticket %s: %v", id, err)
+			continue
+		}
+		summary.TicketsEnqueued++
+	}
+	return nil
+}
+
+// enqueueTicketCreation encola la creación de un ticket existente localmente
+func enqueueTicketCreation(ticket Ticket) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	initialMessageID := ""
+	if len(ticket.Messages) > 0 && ticket.Messages[0].IsClient {
+		initialMessageID = ticket.Messages[0].ID
+		ticket.Description = ticket.Messages[0].Content
+	}
+	if err := enqueueOutbox(context.Background(), tx, outboxKindCreateTicket, ticket.ID, initialMessageID, buildCreateTicketPayload(ticket)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// linkTicket enlaza un ticket local con uno existente del backend y sus mensajes
+func linkTicket(ticket Ticket, remote *growdesk.Ticket) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+                UPDATE widget_tickets SET backend_ticket_id=$2, synced=TRUE, synced_at=NOW()
+                WHERE ticket_id=$1
+        `, ticket.ID, remote.ID); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, m := range ticket.Messages {
+		if m.BackendMessageID != "" {
+			continue
+		}
+		for _, rm := range remote.Messages {
+			if used[rm.ID] || rm.Content != m.Content || rm.IsClient != m.IsClient {
+				continue
+			}
+			used[rm.ID] = true
+			if _, err := tx.Exec(`
+                                UPDATE widget_messages SET backend_message_id=$2, synced=TRUE, synced_at=NOW()
+                                WHERE id=$1
+                        `, m.ID, rm.ID); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+// reconcileUnsyncedMessages vuelve a encolar mensajes del cliente que no llegaron al backend
+func reconcileUnsyncedMessages(summary *reconcileSummary) error {
+	rows, err := db.Query(`
+                SELECT m.id, m.ticket_id, m.content, m.is_client, m.user_name, m.user_email
+                FROM widget_messages m
+                JOIN widget_tickets t ON t.ticket_id = m.ticket_id
+                WHERE COALESCE(m.synced, FALSE) = FALSE
+                  AND m.is_client = TRUE
+                  AND t.backend_ticket_id IS NOT NULL
+                  AND NOT EXISTS (
+                        SELECT 1 FROM widget_outbox o
+                        WHERE o.message_id = m.id AND o.status = 'pending'
+                  )
+                  AND NOT EXISTS (
+                        SELECT 1 FROM widget_outbox_dead d WHERE d.message_id = m.id
+                  )
+        `)
+	if err != nil {
+		return err
+	}
+
+	type pendingMessage struct {
+		ticketID string
+		message  Message
+	}
+	var pending []pendingMessage
+	for rows.Next() {
+		var p pendingMessage
+		var userName, userEmail sql.NullString
+		if err := rows.Scan(&p.message.ID, &p.ticketID, &p.message.Content, &p.message.IsClient, &userName, &userEmail); err != nil {
+			rows.Close()
+			return err
+		}
+		p.message.UserName = userName.String
+		p.message.UserEmail = userEmail.String
+		pending = append(pending, p)
+	}
+	rows.Close()
+
+	for _, p := range pending {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := enqueueOutbox(context.Background(), tx, outboxKindAddMessage, p.ticketID, p.message.ID, buildAddMessagePayload(p.ticketID, p.message)); err != nil {
+			tx.Rollback()
+			logging.Errorf("Error al encolar mensaje %s: %v", p.message.ID, err)
+			continue
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		summary.MessagesEnqueued++
+	}
+	return nil
+}
+
+// reconcileLinkedTickets compara tickets enlazados con el backend y trae lo que falte
+func reconcileLinkedTickets(summary *reconcileSummary) error {
+	ids, err := queryStrings(`
+                SELECT ticket_id FROM widget_tickets
+                WHERE backend_ticket_id IS NOT NULL AND COALESCE(status, '') <> 'closed'
+        `)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		ticket, err := LoadTicket(id)
+		if err != nil {
+			logging.Errorf("Error al cargar ticket %s para reconciliar: %v", id, err)
+			continue
+		}
+
+		// Un ticket fusionado se reconcilia con el ticket en el que se fusionó
+		remoteID := ticket.BackendTicketID
+		if ticket.MergedInto != "" {
+			remoteID = ticket.MergedInto
+		}
+		remote, err := backend().GetTicket(context.Background(), remoteID)
+		if err == nil && remote.MergedInto != "" {
+			// Fusión de la que no llegó la notificación (p. ej. widget-api caído)
+			if _, err := markTicketMerged(remoteID, remote.MergedInto); err != nil {
+				logging.Errorf("Error al registrar la fusión del ticket %s: %v", ticket.ID, err)
+			}
+			remoteID = remote.MergedInto
+			remote, err = backend().GetTicket(context.Background(), remoteID)
+		}
+		if err != nil {
+			if growdesk.IsNotFound(err) {
+				recordSyncConflict(ticket.ID, "missing_in_backend", remoteID, "", "kept_local")
+				summary.Conflicts++
+			} else {
+				logging.Errorf("No se pudo consultar el ticket %s en el backend: %v", remoteID, err)
+			}
+			continue
+		}
+
+		// El backend es la fuente de verdad del estado del ticket
+		if remote.Status != "" && remote.Status != ticket.Status {
+			recordSyncConflict(ticket.ID, "status_mismatch", ticket.Status, remote.Status, "backend_wins")
+			summary.Conflicts++
+			if _, err := db.Exec(`UPDATE widget_tickets SET status=$2, updated_at=NOW() WHERE ticket_id=$1`, ticket.ID, remote.Status); err != nil {
+				logging.Errorf("Error al actualizar estado del ticket %s: %v", ticket.ID, err)
+			}
+		}
+
+		pulled, err := pullBackendMessages(ticket, remote)
+		if err != nil {
+			logging.Errorf("Error al traer mensajes del ticket %s: %v", ticket.ID, err)
+			continue
+		}
+		summary.MessagesPulled += pulled
+	}
+	return nil
+}
+
+// pullBackendMessages guarda localmente los mensajes del backend que no se conocían
+func pullBackendMessages(ticket Ticket, remote *growdesk.Ticket) (int, error) {
+	known := make(map[string]bool)
+	for _, m := range ticket.Messages {
+		if m.BackendMessageID != "" {
+			known[m.BackendMessageID] = true
+		}
+	}
+
+	pulled := 0
+	for _, rm := range remote.Messages {
+		if rm.ID == "" || known[rm.ID] {
+			continue
+		}
+
+		// Un mensaje del cliente ya entregado pero sin enlazar (p. ej. respuesta perdida)
+		res, err := db.Exec(`
+                        UPDATE widget_messages SET backend_message_id=$3, synced=TRUE, synced_at=NOW()
+                        WHERE id = (
+                                SELECT id FROM widget_messages
+                                WHERE ticket_id=$1 AND content=$2 AND is_client=$4 AND backend_message_id IS NULL
+                                ORDER BY created_at LIMIT 1
+                        )
+                `, ticket.ID, rm.Content, rm.ID, rm.IsClient)
+		if err != nil {
+			return pulled, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+
+		createdAt := rm.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = rm.Timestamp
+		}
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		message := Message{
+			ID:               fmt.Sprintf("MSG-%d", time.Now().UnixNano()),
+			Content:          rm.Content,
+			IsClient:         rm.IsClient,
+			CreatedAt:        createdAt,
+			UserName:         rm.UserName,
+			UserEmail:        rm.UserEmail,
+			BackendMessageID: rm.ID,
+			Synced:           true,
+		}
+		if err := insertMessageWith(db, ticket.ID, message); err != nil {
+			return pulled, err
+		}
+		pulled++
+		sendMessageToWebSocketClients(ticket.ID, message)
+	}
+	return pulled, nil
+}
+
+// queryStrings ejecuta una consulta que devuelve una sola columna de texto
+func queryStrings(query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// adminAuth protege los endpoints de administración con WIDGET_ADMIN_TOKEN
+func adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := getEnv("WIDGET_ADMIN_TOKEN", "")
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Endpoints de administración deshabilitados (WIDGET_ADMIN_TOKEN no definido)"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// getSyncStatus devuelve un resumen del estado de la sincronización
+func getSyncStatus(c *gin.Context) {
+	var status struct {
+		UnsyncedTickets  int    `json:"unsyncedTickets"`
+		UnsyncedMessages int    `json:"unsyncedMessages"`
+		PendingOutbox    int    `json:"pendingOutbox"`
+		DeadLetters      int    `json:"deadLetters"`
+		Conflicts        int    `json:"conflicts"`
+		BackendCircuit   string `json:"backendCircuit"`
+	}
+	err := db.QueryRow(`
+                SELECT
+                        (SELECT COUNT(*) FROM widget_tickets WHERE COALESCE(synced, FALSE) = FALSE),
+                        (SELECT COUNT(*) FROM widget_messages WHERE COALESCE(synced, FALSE) = FALSE),
+                        (SELECT COUNT(*) FROM widget_outbox WHERE status = 'pending'),
+                        (SELECT COUNT(*) FROM widget_outbox_dead),
+                        (SELECT COUNT(*) FROM widget_sync_conflicts)
+        `).Scan(&status.UnsyncedTickets, &status.UnsyncedMessages, &status.PendingOutbox, &status.DeadLetters, &status.Conflicts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener estado de sincronización", "details": err.Error()})
+		return
+	}
+	status.BackendCircuit = backend().BreakerState()
+	c.JSON(http.StatusOK, status)
+}
+
+// getSyncConflicts lista los conflictos detectados por la reconciliación
+func getSyncConflicts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := db.Query(`
+                SELECT id, ticket_id, kind, COALESCE(local_value, ''), COALESCE(remote_value, ''), resolution, detected_at
+                FROM widget_sync_conflicts ORDER BY detected_at DESC LIMIT $1
+        `, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener conflictos", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	conflicts := []gin.H{}
+	for rows.Next() {
+		var id int64
+		var ticketID, kind, localValue, remoteValue, resolution string
+		var detectedAt time.Time
+		if err := rows.Scan(&id, &ticketID, &kind, &localValue, &remoteValue, &resolution, &detectedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer conflictos", "details": err.Error()})
+			return
+		}
+		conflicts = append(conflicts, gin.H{
+			"id":          id,
+			"ticketId":    ticketID,
+			"kind":        kind,
+			"localValue":  localValue,
+			"remoteValue": remoteValue,
+			"resolution":  resolution,
+			"detectedAt":  detectedAt,
+		})
+	}
+	c.JSON(http.StatusOK, conflicts)
+}
+
+// runReconcile ejecuta la reconciliación bajo demanda
+func runReconcile(c *gin.Context) {
+	summary, err := reconcile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error en la reconciliación", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// getOutboxEntries lista las operaciones pendientes de entregar al backend
+func getOutboxEntries(c *gin.Context) {
+	rows, err := db.Query(`
+                SELECT id, kind, ticket_id, COALESCE(message_id, ''), attempts, COALESCE(last_error, ''),
+                       next_attempt_at, created_at
+                FROM widget_outbox WHERE status = 'pending'
+                ORDER BY id LIMIT 500
+        `)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el outbox", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	entries := []gin.H{}
+	for rows.Next() {
+		var id int64
+		var kind, ticketID, messageID, lastError string
+		var attempts int
+		var nextAttemptAt, createdAt time.Time
+		if err := rows.Scan(&id, &kind, &ticketID, &messageID, &attempts, &lastError, &nextAttemptAt, &createdAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer el outbox", "details": err.Error()})
+			return
+		}
+		entries = append(entries, gin.H{
+			"id":            id,
+			"kind":          kind,
+			"ticketId":      ticketID,
+			"messageId":     messageID,
+			"attempts":      attempts,
+			"lastError":     lastError,
+			"nextAttemptAt": nextAttemptAt,
+			"createdAt":     createdAt,
+		})
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// getDeadLetters lista las operaciones que fallaron definitivamente
+func getDeadLetters(c *gin.Context) {
+	rows, err := db.Query(`
+                SELECT id, kind, ticket_id, COALESCE(message_id, ''), payload, attempts,
+                       COALESCE(last_error, ''), reason, created_at, failed_at
+                FROM widget_outbox_dead ORDER BY failed_at DESC LIMIT 500
+        `)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el dead-letter", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	entries := []gin.H{}
+	for rows.Next() {
+		var id int64
+		var kind, ticketID, messageID, lastError, reason string
+		var payload []byte
+		var attempts int
+		var createdAt, failedAt sql.NullTime
+		if err := rows.Scan(&id, &kind, &ticketID, &messageID, &payload, &attempts, &lastError, &reason, &createdAt, &failedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer el dead-letter", "details": err.Error()})
+			return
+		}
+		entries = append(entries, gin.H{
+			"id":        id,
+			"kind":      kind,
+			"ticketId":  ticketID,
+			"messageId": messageID,
+			"payload":   json.RawMessage(payload),
+			"attempts":  attempts,
+			"lastError": lastError,
+			"reason":    reason,
+			"createdAt": createdAt.Time,
+			"failedAt":  failedAt.Time,
+		})
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// requeueDeadLetters devuelve al outbox las entradas del dead-letter indicadas
+// (todas si id es 0). La edad se reinicia para que vuelvan a tener margen de reintentos.
+func requeueDeadLetters(id int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+                INSERT INTO widget_outbox (kind, ticket_id, message_id, payload)
+                SELECT kind, ticket_id, message_id, payload FROM widget_outbox_dead
+                WHERE $1 = 0 OR id = $1
+                ORDER BY id
+        `, id)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+
+	if _, err := tx.Exec(`DELETE FROM widget_outbox_dead WHERE $1 = 0 OR id = $1`, id); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// replayDeadLetter reencola una entrada concreta del dead-letter
+func replayDeadLetter(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	n, err := requeueDeadLetters(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reencolar la entrada", "details": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entrada no encontrada en el dead-letter"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "requeued": n})
+}
+
+// replayAllDeadLetters reencola todas las entradas del dead-letter
+func replayAllDeadLetters(c *gin.Context) {
+	n, err := requeueDeadLetters(0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reencolar el dead-letter", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "requeued": n})
+}
+
+// discardDeadLetter elimina definitivamente una entrada del dead-letter
+func discardDeadLetter(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	res, err := db.Exec(`DELETE FROM widget_outbox_dead WHERE id=$1`, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar la entrada", "details": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entrada no encontrada en el dead-letter"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Métricas de widget-api expuestas en /metrics. Las del cliente del backend
+// (widget_api_backend_requests_total...) las registra telemetry.NewTransport.
+var (
+	httpRequests = telemetry.NewCounterVec("widget_api_http_requests_total",
+		"Solicitudes HTTP atendidas por ruta, método y estado", "route", "method", "status")
+	httpDuration = telemetry.NewHistogramVec("widget_api_http_request_duration_seconds",
+		"Duración de las solicitudes HTTP por ruta y método", nil, "route", "method")
+	outboxDeliveries = telemetry.NewCounterVec("widget_api_forward_total",
+		"Entregas del outbox al backend por tipo y resultado (success, failure, postponed, dead)", "kind", "outcome")
+	wsConnectionsGauge = telemetry.NewGaugeVec("widget_api_websocket_connections",
+		"Conexiones WebSocket abiertas por ticket", "ticket_id")
+)
+
+// initTelemetry activa la exportación de trazas y registra las métricas de las colas de
+// sincronización, que se consultan en la base de datos al exponer /metrics
+func initTelemetry() {
+	telemetry.InitTracing("growdesk-widget-api")
+
+	telemetry.NewGaugeFunc("widget_api_outbox_pending", "Operaciones del outbox pendientes de entregar al backend", func() float64 {
+		return countRows(`SELECT COUNT(*) FROM widget_outbox WHERE status = 'pending'`)
+	})
+	telemetry.NewGaugeFunc("widget_api_outbox_dead", "Operaciones en el dead-letter del outbox", func() float64 {
+		return countRows(`SELECT COUNT(*) FROM widget_outbox_dead`)
+	})
+}
+
+// countRows ejecuta un COUNT(*); devuelve -1 si la base de datos no responde
+func countRows(query string) float64 {
+	if db == nil {
+		return -1
+	}
+	var n int64
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		return -1
+	}
+	return float64(n)
+}
+
+// telemetryMiddleware mide cada solicitud y abre un span de servidor que continúa la
+// traza recibida en traceparent. Las series usan la ruta registrada en gin
+// (p. ej. /widget/tickets/:ticketId) para no crear una serie por ID.
+func telemetryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+
+		ctx, span := telemetry.StartSpan(telemetry.Extract(c.Request.Context(), c.Request.Header), method+" "+route, telemetry.SpanKindServer)
+		span.SetAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.route", route),
+			attribute.String("http.target", c.Request.URL.Path),
+		)
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		httpRequests.Inc(route, method, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(start).Seconds(), route, method)
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		if status >= 500 {
+			telemetry.RecordError(span, errors.New(http.StatusText(status)))
+		}
+		span.End()
+	}
+}
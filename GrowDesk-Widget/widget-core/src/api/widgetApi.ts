@@ -87,6 +87,8 @@ interface TicketCreateRequest {
   message: string;
   subject?: string;
   metadata?: any;
+  customFields?: Record<string, unknown>;  // Campos personalizados del ticket (pre-chat)
+  contactFields?: Record<string, unknown>; // Campos personalizados del contacto (pre-chat)
 }
 
 interface MessageRequest {
@@ -105,6 +107,28 @@ export interface FAQ {
   isPublished: boolean;
 }
 
+// Campo personalizado que se pide en el formulario pre-chat
+// Encuesta de satisfacción que llega en el evento csat_survey
+export interface CsatSurvey {
+  surveyId: string;
+  token: string;
+  question?: string;
+  expiresAt?: string;
+}
+
+export interface PreChatField {
+  id: string;
+  key: string;
+  label: string;
+  description?: string;
+  placeholder?: string;
+  type: 'text' | 'number' | 'date' | 'dropdown' | 'multi_select' | 'checkbox';
+  entity: 'ticket' | 'contact';
+  options?: string[];
+  required: boolean;
+  position: number;
+}
+
 // Función para guardar la sesión en cookies
 const saveSession = (data: SessionInfo) => {
   const now = Math.floor(Date.now() / 1000);
@@ -279,7 +303,9 @@ export const useWidgetApi = () => {
           userAgent: navigator.userAgent || "",
           referrer: document.referrer || "",
           screenSize: `${window.innerWidth}x${window.innerHeight}` || "unknown"
-        }
+        },
+        customFields: data.customFields,   // Campos personalizados del ticket
+        contactFields: data.contactFields  // Campos personalizados del contacto
       };
       
       // Intento estándar con axios
@@ -601,6 +627,57 @@ export const useWidgetApi = () => {
     }
   };
   
+  // Consultar si hay agentes en línea: chat en vivo o "dejar un mensaje"
+  const getAvailability = async (teamId?: string) => {
+    try {
+      const baseUrl = apiConfig.apiUrl.endsWith('/') ? apiConfig.apiUrl : `${apiConfig.apiUrl}/`;
+      const availabilityUrl = `${baseUrl}widget/availability`;
+      const response = await axios.get(availabilityUrl, {
+        params: teamId ? { teamId } : undefined,
+        headers: { 'X-Widget-ID': apiConfig.widgetId }
+      });
+      return response.data;
+    } catch (error) {
+      console.log('[WIDGET] Error al consultar la disponibilidad de agentes:', error);
+      return { liveChatAvailable: false, mode: 'leave_message', onlineAgents: 0, open: false };
+    }
+  };
+  
+  // Campos personalizados que se piden en el formulario inicial
+  const getPreChatFields = async (): Promise<PreChatField[]> => {
+    try {
+      const baseUrl = apiConfig.apiUrl.endsWith('/') ? apiConfig.apiUrl : `${apiConfig.apiUrl}/`;
+      const fieldsUrl = `${baseUrl}widget/prechat-fields`;
+      const response = await axios.get(fieldsUrl, {
+        headers: { 'X-Widget-ID': apiConfig.widgetId }
+      });
+      return response.data?.fields || [];
+    } catch (error) {
+      console.log('[WIDGET] Error al obtener los campos del formulario:', error);
+      return [];
+    }
+  };
+  
+  // Enviar la valoración de la encuesta de satisfacción recibida por WebSocket
+  const submitCsat = async (ticketId: string, survey: CsatSurvey, rating: number, comment?: string): Promise<boolean> => {
+    try {
+      const baseUrl = apiConfig.apiUrl.endsWith('/') ? apiConfig.apiUrl : `${apiConfig.apiUrl}/`;
+      const csatUrl = `${baseUrl}widget/tickets/${encodeURIComponent(ticketId)}/csat`;
+      await axios.post(csatUrl, {
+        surveyId: survey.surveyId,
+        token: survey.token,
+        rating,
+        comment: comment || ''
+      }, {
+        headers: { 'X-Widget-ID': apiConfig.widgetId }
+      });
+      return true;
+    } catch (error) {
+      console.log('[WIDGET] Error al enviar la valoración:', error);
+      return false;
+    }
+  };
+  
   // Cerrar sesión (logout)
   const logout = () => {
     clearSession();
@@ -613,6 +690,9 @@ export const useWidgetApi = () => {
     sendMessage,
     getMessageHistory,
     logout,
-    getFaqs
+    getFaqs,
+    getAvailability,
+    getPreChatFields,
+    submitCsat
   };
 }; 
\ No newline at end of file
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/bulk"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/db"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/exports"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/websocket"
+	"github.com/hmdev/GrowDeskV2/pkg/health"
+)
+
+// registerHealthChecks registra las dependencias que revisa /api/health/ready. La base de
+// datos (o el directorio de datos sin PostgreSQL) y la versión del esquema son críticas; el
+// resto sólo marca el servicio como degradado.
+func registerHealthChecks(checker *health.Checker, usePostgres bool, dataDir string, bulkRunner *bulk.Runner) {
+	if usePostgres {
+		checker.Add("database", true, health.Database(db.GetDB()))
+		checker.Add("schema", true, checkSchema)
+	} else {
+		checker.Add("storage", true, func(ctx context.Context) health.Result {
+			return checkDataDir(dataDir)
+		})
+	}
+
+	checker.Add("websocket", false, func(ctx context.Context) health.Result {
+		stats := websocket.Stats()
+		return health.OK("", map[string]interface{}{
+			"connections":     stats.Connections,
+			"upgradeFailures": stats.UpgradeFailures,
+		})
+	})
+
+	// Colas de trabajo en segundo plano: operaciones masivas y exportaciones de informes
+	maxBacklog := getEnvInt("HEALTH_MAX_BULK_BACKLOG", 5000)
+	checker.Add("queues", false, func(ctx context.Context) health.Result {
+		details := map[string]interface{}{
+			"bulkPending":    bulkRunner.Pending(),
+			"exportsRunning": exports.Running(),
+			"maxBulkBacklog": maxBacklog,
+		}
+		if bulkRunner.Pending() > maxBacklog {
+			return health.Degraded("demasiados tickets pendientes en operaciones masivas", details)
+		}
+		return health.OK("", details)
+	})
+
+	// widget-api recibe los mensajes de los agentes; si no responde, se avisa pero el
+	// backend sigue disponible
+	widgetAPIURL := strings.TrimSuffix(getEnv("WIDGET_API_URL", "http://growdesk-widget-api:3000"), "/")
+	checker.Add("widget-api", false, health.Peer(widgetAPIURL+"/health/live"))
+}
+
+// checkSchema compara la versión del esquema aplicada con la que espera el binario
+func checkSchema(ctx context.Context) health.Result {
+	applied, err := db.AppliedSchemaVersion(ctx, db.GetDB())
+	if err != nil {
+		return health.Down("no se pudo leer la versión del esquema: "+err.Error(), nil)
+	}
+	details := map[string]interface{}{"applied": applied, "expected": db.SchemaVersion}
+	switch {
+	case applied < db.SchemaVersion:
+		return health.Down("el esquema de la base de datos está desactualizado", details)
+	case applied > db.SchemaVersion:
+		return health.Degraded("el esquema es más reciente que este binario", details)
+	}
+	return health.OK("", details)
+}
+
+// checkDataDir comprueba que el directorio de datos del almacén en archivos admite escrituras
+func checkDataDir(dataDir string) health.Result {
+	details := map[string]interface{}{"dataDir": dataDir}
+	f, err := os.CreateTemp(dataDir, ".health-*")
+	if err != nil {
+		return health.Down("el directorio de datos no admite escrituras: "+err.Error(), details)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return health.OK("", details)
+}
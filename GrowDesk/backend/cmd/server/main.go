@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,25 +15,42 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/bulk"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/db"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/exports"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/handlers"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/presence"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/teams"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/websocket"
+	"github.com/hmdev/GrowDeskV2/pkg/health"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/ratelimit"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	fmt.Printf("🚀 INICIANDO APLICACIÓN - MAIN FUNCTION\n")
 	// Cargar variables de entorno desde .env
 	err := godotenv.Load()
+
+	// Logger estructurado (LOG_LEVEL, LOG_FORMAT); los logs de cada solicitud llevan
+	// request_id y, si hay trazas, trace_id
+	logging.Init("growdesk-backend")
+	logging.AddContextAttr(func(ctx context.Context) slog.Attr {
+		return slog.String("trace_id", telemetry.TraceID(ctx))
+	})
+	logging.Infof("🚀 INICIANDO APLICACIÓN - MAIN FUNCTION")
 	if err != nil {
-		log.Println("Advertencia: No se encontró archivo .env, usando variables de entorno directamente")
+		logging.Warnf("Advertencia: No se encontró archivo .env, usando variables de entorno directamente")
 	}
 
-	fmt.Printf("🔧 DEBUG: Parseando flags...\n")
+	logging.Debugf("🔧 DEBUG: Parseando flags...")
 	// Parsear flags
 	var (
 		port        = flag.Int("port", getEnvInt("PORT", 8080), "HTTP service port")
@@ -43,21 +61,24 @@ func main() {
 	)
 	flag.Parse()
 
-	fmt.Printf("🔧 DEBUG: Creando directorio de datos...\n")
+	// Trazas de OpenTelemetry (sólo si hay un colector configurado)
+	telemetry.InitTracing("growdesk-backend")
+
+	logging.Debugf("🔧 DEBUG: Creando directorio de datos...")
 	// Crear directorio de datos si no existe
 	if err := os.MkdirAll(*dataDir, 0755); err != nil {
 		log.Fatalf("Error al crear directorio de datos: %v", err)
 	}
 
-	fmt.Printf("🔧 DEBUG: Inicializando store de datos...\n")
+	logging.Debugf("🔧 DEBUG: Inicializando store de datos...")
 	// Inicializar el almacén de datos (store)
 	var store data.DataStore
 
 	// Decidir si usar PostgreSQL o almacenamiento en archivos basado en la flag
 	if *usePostgres {
-		log.Println("Usando PostgreSQL como almacén de datos")
+		logging.Infof("Usando PostgreSQL como almacén de datos")
 
-		fmt.Printf("🔧 DEBUG: Conectando a PostgreSQL...\n")
+		logging.Debugf("🔧 DEBUG: Conectando a PostgreSQL...")
 		// Inicializar conexión a PostgreSQL
 		database, err := db.InitDB()
 		if err != nil {
@@ -65,7 +86,7 @@ func main() {
 		}
 		defer db.Close()
 
-		fmt.Printf("🔧 DEBUG: Inicializando esquema...\n")
+		logging.Debugf("🔧 DEBUG: Inicializando esquema...")
 		// Inicializar esquema de base de datos
 		if err := db.InitializeSchema(database); err != nil {
 			log.Fatalf("Error al inicializar esquema de base de datos: %v", err)
@@ -73,66 +94,141 @@ func main() {
 
 		// Migrar datos desde JSON si se solicita
 		if *migrateData {
-			log.Println("Migrando datos de archivos JSON a PostgreSQL...")
+			logging.Infof("Migrando datos de archivos JSON a PostgreSQL...")
 			if err := db.MigrateAllFromJSON(database, *dataDir); err != nil {
-				log.Printf("Advertencia: Error durante la migración de datos: %v", err)
+				logging.Warnf("Advertencia: Error durante la migración de datos: %v", err)
 			}
 		}
 
-		fmt.Printf("🔧 DEBUG: Creando PostgreSQL store...\n")
+		logging.Debugf("🔧 DEBUG: Creando PostgreSQL store...")
 		// Crear store PostgreSQL
 		store = db.NewPostgreSQLStore(database)
 		ensureWidgetSystemUser(store)
 		ensureAdminUser(store)
+		ensureWidgetAPIServiceKey(store)
 	} else {
-		log.Println("Usando almacenamiento en archivos")
+		logging.Infof("Usando almacenamiento en archivos")
 		store = data.NewStore(*dataDir)
 		ensureWidgetSystemUser(store)
 		ensureAdminUser(store)
+		ensureWidgetAPIServiceKey(store)
+	}
+
+	// Convertir los departamentos de texto libre en equipos (sólo la primera vez)
+	if result, err := teams.MigrateDepartments(store); err != nil {
+		logging.Warnf("Advertencia: Error al migrar departamentos a equipos: %v", err)
+	} else if result != nil {
+		logging.Infof("Departamentos migrados a equipos: %d equipos, %d miembros, %d tickets",
+			result.TeamsCreated, result.MembersAdded, result.TicketsUpdated)
 	}
 
-	fmt.Printf("🔧 DEBUG: Creando handlers...\n")
+	// Dar de alta en el catálogo las etiquetas que ya usaban los tickets
+	if created, err := tags.SyncCatalog(store); err != nil {
+		logging.Warnf("Advertencia: Error al sincronizar el catálogo de etiquetas: %v", err)
+	} else if created > 0 {
+		logging.Infof("Etiquetas añadidas al catálogo: %d", created)
+	}
+
+	// Refrescar periódicamente la vista materializada de los informes
+	if *usePostgres {
+		reports.StartRefresher(store, reports.RefreshInterval())
+	}
+
+	// Lanzar las exportaciones programadas y limpiar las antiguas
+	exports.StartScheduler(store)
+
+	logging.Debugf("🔧 DEBUG: Creando handlers...")
 	// Crear handlers
 	authHandler := &handlers.AuthHandler{Store: store}
 	ticketHandler := &handlers.TicketHandler{Store: store}
 	categoryHandler := &handlers.CategoryHandler{Store: store}
 	faqHandler := &handlers.FAQHandler{Store: store}
-
-	fmt.Printf("🔧 DEBUG: Creando enrutador...\n")
+	serviceKeyHandler := &handlers.ServiceKeyHandler{Store: store}
+	apiKeyHandler := &handlers.APIKeyHandler{Store: store}
+	routingHandler := &handlers.RoutingHandler{Store: store}
+	assignmentHandler := &handlers.AssignmentHandler{Store: store}
+	activityHandler := &handlers.ActivityHandler{Store: store}
+	teamHandler := &handlers.TeamHandler{Store: store}
+	presenceHandler := &handlers.PresenceHandler{Store: store, Tracker: presence.Default()}
+	businessHoursHandler := &handlers.BusinessHoursHandler{Store: store}
+	macroHandler := &handlers.MacroHandler{Store: store}
+	tagHandler := &handlers.TagHandler{Store: store}
+	customFieldHandler := &handlers.CustomFieldHandler{Store: store}
+	contactHandler := &handlers.ContactHandler{Store: store}
+	notificationHandler := &handlers.NotificationHandler{Store: store}
+	csatHandler := &handlers.CSATHandler{Store: store}
+	reportHandler := &handlers.ReportHandler{Store: store}
+	reportExportHandler := &handlers.ReportExportHandler{Store: store}
+	logLevelHandler := &handlers.LogLevelHandler{}
+	bulkRunner := bulk.NewRunner(store)
+	bulkHandler := &handlers.BulkTicketHandler{Store: store, Runner: bulkRunner}
+
+	logging.Debugf("🔧 DEBUG: Creando enrutador...")
 	// Crear enrutador (usando http.ServeMux básico para simplicidad)
 	mux := http.NewServeMux()
 
-	fmt.Printf("🔧 DEBUG: Configurando middleware de autenticación...\n")
+	logging.Debugf("🔧 DEBUG: Configurando middleware de autenticación...")
 	// Middleware de autenticación
 	var authMiddleware func(http.Handler) http.Handler
 
 	if *useMock {
 		authMiddleware = middleware.MockAuth
-		log.Println("Usando autenticación de prueba para desarrollo")
+		logging.Infof("Usando autenticación de prueba para desarrollo")
 	} else {
 		authMiddleware = middleware.Auth
-		log.Println("Usando autenticación REAL")
+		logging.Infof("Usando autenticación REAL")
 	}
 
-	fmt.Printf("🔧 DEBUG: Registrando rutas de salud...\n")
-	// Rutas de comprobación de estado
-	mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
-		utils.WriteJSON(w, http.StatusOK, map[string]string{
-			"status":  "ok",
-			"message": "Servidor funcionando",
-		})
+	// Las claves de servicio (gdsk_...) y de API (gdpk_...) se aceptan en todas las rutas
+	// autenticadas, limitadas a los scopes que tenga concedidos cada clave
+	middleware.SetKeyStore(store)
+	authMiddleware = middleware.WithServiceKeys(store, authMiddleware)
+
+	// Límite de solicitudes (en memoria o Redis) para rutas públicas y claves de API
+	limiter := ratelimit.NewFromEnv("growdesk:rl:")
+	middleware.SetRateLimiter(limiter)
+	middleware.SetTrustedProxies(ratelimit.ProxiesFromEnv())
+	rateLimits := ratelimit.LoadRoutes(ratelimit.Routes{
+		"faqs":    {{Scope: ratelimit.ScopeIP, Limit: 60, Per: time.Minute}},
+		"ws":      {{Scope: ratelimit.ScopeIP, Limit: 30, Per: time.Minute}},
+		"csat":    {{Scope: ratelimit.ScopeIP, Limit: 20, Per: time.Minute}},
+		"exports": {{Scope: ratelimit.ScopeIP, Limit: 30, Per: time.Minute}},
+	}, os.Getenv("RATE_LIMITS"))
+	publicLimit := func(route string, h http.Handler) http.Handler {
+		return middleware.RateLimit(limiter, route, rateLimits[route])(h)
+	}
+
+	// Métricas de Prometheus, incluidas las colas que se procesan en segundo plano
+	telemetry.NewGaugeFunc("growdesk_bulk_queue_depth", "Tickets pendientes en operaciones masivas", func() float64 {
+		return float64(bulkRunner.Pending())
 	})
+	telemetry.NewGaugeFunc("growdesk_report_exports_running", "Exportaciones de informes en curso", func() float64 {
+		return float64(exports.Running())
+	})
+	mux.Handle("/metrics", telemetry.MetricsHandler())
+
+	logging.Debugf("🔧 DEBUG: Registrando rutas de salud...")
+	// Rutas de comprobación de estado: /live sólo indica que el proceso responde;
+	// /ready (y /api/health) revisa las dependencias y devuelve 503 si falla una crítica
+	checker := health.New("growdesk-backend")
+	registerHealthChecks(checker, *usePostgres, *dataDir, bulkRunner)
+	mux.Handle("/api/health", checker.ReadyHandler())
+	mux.Handle("/api/health/live", checker.LiveHandler())
+	mux.Handle("/api/health/ready", checker.ReadyHandler())
 
-	fmt.Printf("🔧 DEBUG: Registrando rutas de autenticación...\n")
+	logging.Debugf("🔧 DEBUG: Registrando rutas de autenticación...")
 
 	// RUTA WEBSOCKET - REGISTRADA PRIMERO PARA MÁXIMA PRIORIDAD
-	fmt.Printf("🔧 REGISTRANDO HANDLER WEBSOCKET para /api/ws/chat/ (PRIORIDAD MÁXIMA)\n")
-	mux.HandleFunc("/api/ws/chat/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Printf("🔧 WEBSOCKET HANDLER EJECUTÁNDOSE para ruta dinámica: %s\n", r.URL.Path)
+	logging.Debugf("🔧 REGISTRANDO HANDLER WEBSOCKET para /api/ws/chat/ (PRIORIDAD MÁXIMA)")
+	mux.Handle("/api/ws/chat/", publicLimit("ws", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.Debugf("🔧 WEBSOCKET HANDLER EJECUTÁNDOSE para ruta dinámica: %s", r.URL.Path)
 
 		// Usar directamente el WebSocket handler con la interfaz DataStore
 		websocket.ChatHandler(store)(w, r)
-	})
+	})))
+
+	// Canal privado de notas internas (sólo agentes; el token va en ?token=)
+	mux.Handle("/api/ws/notes/", publicLimit("ws", websocket.NotesHandler(store)))
 
 	// Rutas de autenticación
 	mux.HandleFunc("/api/auth/login", authHandler.Login)
@@ -152,10 +248,29 @@ func main() {
 		}
 	})))
 
+	// Operaciones masivas sobre tickets y su progreso
+	mux.Handle("/api/tickets/bulk", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			bulkHandler.GetBulkOperations(w, r)
+		case http.MethodPost:
+			bulkHandler.StartBulkOperation(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/tickets/bulk/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")) != 5 {
+			http.NotFound(w, r)
+			return
+		}
+		bulkHandler.GetBulkOperation(w, r)
+	})))
+
 	// Rutas de tickets individuales
 	mux.Handle("/api/tickets/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// DEBUG: Log detallado de todas las peticiones a tickets
-		fmt.Printf("🔍 ROUTER TICKETS - URL: %s, Método: %s, Path: %s\n", r.URL.String(), r.Method, r.URL.Path)
+		logging.Debugf("🔍 ROUTER TICKETS - URL: %s, Método: %s, Path: %s", r.URL.String(), r.Method, r.URL.Path)
 
 		path := r.URL.Path
 
@@ -165,94 +280,145 @@ func main() {
 		ext := filepath.Ext(path)
 		baseDir := filepath.Base(dir)
 
-		fmt.Printf("📂 PATH DEBUG - Dir: %s, Base: %s, Ext: %s, BaseDir: %s\n", dir, base, ext, baseDir)
+		logging.Debugf("📂 PATH DEBUG - Dir: %s, Base: %s, Ext: %s, BaseDir: %s", dir, base, ext, baseDir)
+
+		// Aplicación de macros: /api/tickets/:id/macros/:macroId/apply
+		if segments := strings.Split(strings.TrimSuffix(path, "/"), "/"); len(segments) == 7 && segments[4] == "macros" && segments[6] == "apply" {
+			macroHandler.ApplyMacro(w, r)
+			return
+		}
+
+		// Fusión y división: /api/tickets/:id/merge y /api/tickets/:id/split
+		// Relaciones: /api/tickets/:id/relations y /api/tickets/:id/relations/:relationId
+		// Seguidores: /api/tickets/:id/watchers y /api/tickets/:id/watchers/:watcherId
+		if segments := strings.Split(strings.TrimSuffix(path, "/"), "/"); len(segments) == 5 {
+			switch segments[4] {
+			case "merge":
+				ticketHandler.MergeTicket(w, r)
+				return
+			case "split":
+				ticketHandler.SplitTicket(w, r)
+				return
+			case "relations":
+				if r.Method == http.MethodPost {
+					ticketHandler.CreateTicketRelation(w, r)
+				} else {
+					ticketHandler.GetTicketRelations(w, r)
+				}
+				return
+			case "watchers":
+				if r.Method == http.MethodPost {
+					ticketHandler.AddTicketWatcher(w, r)
+				} else {
+					ticketHandler.GetTicketWatchers(w, r)
+				}
+				return
+			}
+		} else if len(segments) == 6 && segments[4] == "relations" {
+			ticketHandler.DeleteTicketRelation(w, r)
+			return
+		} else if len(segments) == 6 && segments[4] == "watchers" {
+			if r.Method == http.MethodPut {
+				ticketHandler.UpdateTicketWatcher(w, r)
+			} else {
+				ticketHandler.RemoveTicketWatcher(w, r)
+			}
+			return
+		}
 
 		// NUEVO: Manejar la ruta de asignación de tickets PRIMERO
 		if filepath.Base(path) == "assign" {
 			// Esta es una ruta para asignación como /api/tickets/:id/assign
-			fmt.Printf("🎯 MATCHING: Ruta de asignación de ticket detectada\n")
+			logging.Debugf("🎯 MATCHING: Ruta de asignación de ticket detectada")
 			if r.Method == http.MethodPost || r.Method == http.MethodPut {
-				fmt.Printf("➡️ CALLING: ticketHandler.AssignTicket\n")
+				logging.Debugf("➡️ CALLING: ticketHandler.AssignTicket")
 				ticketHandler.AssignTicket(w, r)
 			} else {
-				fmt.Printf("❌ MÉTODO NO PERMITIDO para asignación: %s\n", r.Method)
+				logging.Errorf("❌ MÉTODO NO PERMITIDO para asignación: %s", r.Method)
 				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 			}
 		} else if strings.HasSuffix(path, "/messages") {
 			// Esta es una ruta para mensajes de tickets como /api/tickets/:id/messages
-			fmt.Printf("🎯 MATCHING: Ruta de mensajes de ticket detectada\n")
+			logging.Debugf("🎯 MATCHING: Ruta de mensajes de ticket detectada")
 			switch r.Method {
 			case http.MethodGet:
-				fmt.Printf("➡️ CALLING: ticketHandler.GetTicketMessages\n")
+				logging.Debugf("➡️ CALLING: ticketHandler.GetTicketMessages")
 				ticketHandler.GetTicketMessages(w, r)
 			case http.MethodPost:
-				fmt.Printf("🚀 CALLING: ticketHandler.AddTicketMessage DESDE ROUTER\n")
+				logging.Debugf("🚀 CALLING: ticketHandler.AddTicketMessage DESDE ROUTER")
 				ticketHandler.AddTicketMessage(w, r)
 			default:
-				fmt.Printf("❌ MÉTODO NO PERMITIDO para mensajes: %s\n", r.Method)
+				logging.Errorf("❌ MÉTODO NO PERMITIDO para mensajes: %s", r.Method)
 				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 			}
 		} else if len(strings.Split(path, "/")) == 4 {
 			// Esta es una ruta para un ID de ticket específico como /api/tickets/:id
-			fmt.Printf("🎯 MATCHING: Ruta de ticket específico detectada\n")
+			logging.Debugf("🎯 MATCHING: Ruta de ticket específico detectada")
 			switch r.Method {
 			case http.MethodGet:
-				fmt.Printf("➡️ CALLING: ticketHandler.GetTicket\n")
+				logging.Debugf("➡️ CALLING: ticketHandler.GetTicket")
 				ticketHandler.GetTicket(w, r)
 			case http.MethodPut:
-				fmt.Printf("➡️ CALLING: ticketHandler.UpdateTicket\n")
+				logging.Debugf("➡️ CALLING: ticketHandler.UpdateTicket")
 				ticketHandler.UpdateTicket(w, r)
 			default:
-				fmt.Printf("❌ MÉTODO NO PERMITIDO para ticket específico: %s\n", r.Method)
+				logging.Errorf("❌ MÉTODO NO PERMITIDO para ticket específico: %s", r.Method)
 				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 			}
 		} else {
-			fmt.Printf("❌ NO MATCH: Ningún patrón coincide, enviando 404\n")
+			logging.Errorf("❌ NO MATCH: Ningún patrón coincide, enviando 404")
 			http.NotFound(w, r)
 		}
 	})))
 
+	// Disponibilidad del chat en vivo para widget-api (sólo servicios)
+	mux.Handle("/widget/availability", middleware.ServiceAuth(store, middleware.ScopeWidget, http.HandlerFunc(presenceHandler.GetWidgetAvailability)))
+
+	// Campos personalizados del formulario pre-chat del widget (sólo servicios)
+	mux.Handle("/widget/custom-fields", middleware.ServiceAuth(store, middleware.ScopeWidget, http.HandlerFunc(customFieldHandler.GetPreChatFields)))
+
 	// Rutas de widget (públicas)
 	// Comentado temporalmente porque el método CreateWidgetTicket no existe
 	// mux.HandleFunc("/widget/tickets", ticketHandler.CreateWidgetTicket)
 	// mux.HandleFunc("/api/widget/tickets", ticketHandler.CreateWidgetTicket) // Ruta alternativa para el widget
 
-	// ÚNICO: Endpoint para que widget-api pueda enviar mensajes al backend
-	mux.HandleFunc("/widget/messages", func(w http.ResponseWriter, r *http.Request) {
+	// ÚNICO: Endpoint para que widget-api pueda enviar mensajes al backend.
+	// Sólo accesible para servicios con una clave con scope "widget".
+	mux.Handle("/widget/messages", middleware.ServiceAuth(store, middleware.ScopeWidget, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			fmt.Printf("🚀 WIDGET MESSAGES: Procesando mensaje desde widget-api\n")
+			logging.Debugf("🚀 WIDGET MESSAGES: Procesando mensaje desde widget-api")
 			// Convertir esto en una llamada a AddTicketMessage
 			ticketHandler.AddTicketMessage(w, r)
 		} else {
 			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 		}
-	})
+	})))
 
-	// Ruta para obtener mensajes de un ticket desde el widget
-	mux.HandleFunc("/widget/tickets/", func(w http.ResponseWriter, r *http.Request) {
+	// Ruta para obtener mensajes de un ticket desde el widget (sólo servicios)
+	mux.Handle("/widget/tickets/", middleware.ServiceAuth(store, middleware.ScopeWidget, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// DEBUG: Log cuando esta ruta es llamada
-		fmt.Printf("🔥 WIDGET TICKETS ROUTE - URL: %s, Método: %s, Path: %s\n", r.URL.String(), r.Method, r.URL.Path)
+		logging.Debugf("🔥 WIDGET TICKETS ROUTE - URL: %s, Método: %s, Path: %s", r.URL.String(), r.Method, r.URL.Path)
 
 		path := r.URL.Path
 		if strings.Contains(path, "/messages") {
-			fmt.Printf("📬 WIDGET MESSAGES - Detectada ruta de mensajes\n")
+			logging.Debugf("📬 WIDGET MESSAGES - Detectada ruta de mensajes")
 			// Manejar tanto GET (obtener mensajes) como POST (añadir mensajes)
 			switch r.Method {
 			case http.MethodGet:
-				fmt.Printf("➡️ WIDGET GET: Llamando GetTicketMessages\n")
+				logging.Debugf("➡️ WIDGET GET: Llamando GetTicketMessages")
 				ticketHandler.GetTicketMessages(w, r)
 			case http.MethodPost:
-				fmt.Printf("➡️ WIDGET POST: Llamando AddTicketMessage\n")
+				logging.Debugf("➡️ WIDGET POST: Llamando AddTicketMessage")
 				ticketHandler.AddTicketMessage(w, r)
 			default:
-				fmt.Printf("❌ WIDGET: Método no permitido para esta ruta: %s\n", r.Method)
+				logging.Errorf("❌ WIDGET: Método no permitido para esta ruta: %s", r.Method)
 				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 			}
 		} else {
-			fmt.Printf("❌ WIDGET: No es ruta de mensajes, enviando 404\n")
+			logging.Errorf("❌ WIDGET: No es ruta de mensajes, enviando 404")
 			http.NotFound(w, r)
 		}
-	})
+	})))
 
 	// Rutas de categorías (autenticadas)
 	mux.Handle("/api/categories", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -320,12 +486,13 @@ func main() {
 		}
 	})))
 
-	// Rutas de FAQ públicas
-	mux.HandleFunc("/widget/faqs", faqHandler.GetPublishedFAQs)
-	mux.HandleFunc("/faqs", faqHandler.GetPublishedFAQs) // Endpoint alternativo
+	// Rutas de FAQ públicas (con límite de solicitudes por IP)
+	publicFAQs := publicLimit("faqs", http.HandlerFunc(faqHandler.GetPublishedFAQs))
+	mux.Handle("/widget/faqs", publicFAQs)
+	mux.Handle("/faqs", publicFAQs) // Endpoint alternativo
 
 	// Rutas de compatibilidad de widget (para manejar las rutas duplicadas /widget/widget/...)
-	mux.HandleFunc("/widget/widget/faqs", faqHandler.GetPublishedFAQs)
+	mux.Handle("/widget/widget/faqs", publicFAQs)
 
 	// Rutas de usuarios (autenticadas)
 	mux.Handle("/api/users", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -420,7 +587,7 @@ func main() {
 			return
 		}
 
-		fmt.Printf("📦 Sincronizando %d usuarios...\n", len(usersToSync))
+		logging.Infof("📦 Sincronizando %d usuarios...", len(usersToSync))
 
 		// Obtener todos los usuarios actuales
 		currentUsers, err := store.GetUsers()
@@ -457,11 +624,11 @@ func main() {
 					syncUser.Password = existingUser.Password
 					syncUser.UpdatedAt = time.Now()
 
-					fmt.Printf("🔄 Actualizando usuario %s (%s %s)\n",
+					logging.Infof("🔄 Actualizando usuario %s (%s %s)",
 						syncUser.ID, syncUser.FirstName, syncUser.LastName)
 
 					if err := store.UpdateUser(syncUser); err != nil {
-						fmt.Printf("❌ Error al actualizar usuario %s: %v\n", syncUser.ID, err)
+						logging.Errorf("❌ Error al actualizar usuario %s: %v", syncUser.ID, err)
 					}
 				}
 			} else {
@@ -472,14 +639,14 @@ func main() {
 						syncUser.Password = utils.GenerateRandomPassword(12)
 					}
 
-					fmt.Printf("➕ Creando nuevo usuario %s (%s %s)\n",
+					logging.Infof("➕ Creando nuevo usuario %s (%s %s)",
 						syncUser.ID, syncUser.FirstName, syncUser.LastName)
 
 					syncUser.CreatedAt = time.Now()
 					syncUser.UpdatedAt = time.Now()
 
 					if err := store.CreateUser(syncUser); err != nil {
-						fmt.Printf("❌ Error al crear usuario %s: %v\n", syncUser.ID, err)
+						logging.Errorf("❌ Error al crear usuario %s: %v", syncUser.ID, err)
 					}
 				}
 			}
@@ -492,7 +659,7 @@ func main() {
 			return
 		}
 
-		fmt.Printf("✅ Sincronización completada. %d usuarios en la base de datos.\n", len(updatedUsers))
+		logging.Infof("✅ Sincronización completada. %d usuarios en la base de datos.", len(updatedUsers))
 		utils.WriteJSON(w, http.StatusOK, updatedUsers)
 	})))
 
@@ -517,6 +684,12 @@ func main() {
 
 		userID := segments[3]
 
+		// Configuración de asignación automática: /api/users/:id/assignment
+		if len(segments) > 4 && segments[4] == "assignment" {
+			assignmentHandler.UpdateAgentSettings(w, r)
+			return
+		}
+
 		// Manejar basado en el método HTTP
 		switch r.Method {
 		case http.MethodGet:
@@ -587,6 +760,354 @@ func main() {
 		}
 	})))
 
+	// Rutas de claves de servicio (sólo administradores)
+	mux.Handle("/api/service-keys", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			serviceKeyHandler.GetServiceKeys(w, r)
+		case http.MethodPost:
+			serviceKeyHandler.CreateServiceKey(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/service-keys/", authMiddleware(http.HandlerFunc(serviceKeyHandler.RevokeServiceKey)))
+
+	// Nivel de los logs en tiempo de ejecución (sólo administradores)
+	mux.Handle("/api/admin/log-level", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			logLevelHandler.GetLogLevel(w, r)
+		case http.MethodPut, http.MethodPatch:
+			logLevelHandler.SetLogLevel(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rutas de claves de API para integraciones (personales o de organización)
+	mux.Handle("/api/api-keys", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			apiKeyHandler.GetAPIKeys(w, r)
+		case http.MethodPost:
+			apiKeyHandler.CreateAPIKey(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/api-keys/", authMiddleware(http.HandlerFunc(apiKeyHandler.RevokeAPIKey)))
+
+	// Rutas de reglas de enrutamiento de tickets (sólo administradores)
+	mux.Handle("/api/routing-rules", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			routingHandler.GetRoutingRules(w, r)
+		case http.MethodPost:
+			routingHandler.CreateRoutingRule(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/routing-rules/dry-run", authMiddleware(http.HandlerFunc(routingHandler.DryRunRoutingRules)))
+	mux.Handle("/api/routing-rules/reorder", authMiddleware(http.HandlerFunc(routingHandler.ReorderRoutingRules)))
+	mux.Handle("/api/routing-rules/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			routingHandler.GetRoutingRule(w, r)
+		case http.MethodPut:
+			routingHandler.UpdateRoutingRule(w, r)
+		case http.MethodDelete:
+			routingHandler.DeleteRoutingRule(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rutas de políticas de asignación automática (sólo administradores)
+	mux.Handle("/api/assignment-policies", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assignmentHandler.GetAssignmentPolicies(w, r)
+		case http.MethodPost:
+			assignmentHandler.CreateAssignmentPolicy(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/assignment-policies/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			assignmentHandler.UpdateAssignmentPolicy(w, r)
+		case http.MethodDelete:
+			assignmentHandler.DeleteAssignmentPolicy(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Rutas de equipos: CRUD para administradores, miembros y cola del equipo
+	mux.Handle("/api/teams", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			teamHandler.GetTeams(w, r)
+		case http.MethodPost:
+			teamHandler.CreateTeam(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/teams/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+
+		switch {
+		case len(segments) == 5 && segments[4] == "tickets":
+			// Cola del equipo: /api/teams/:id/tickets
+			teamHandler.GetTeamTickets(w, r)
+		case len(segments) == 5 && segments[4] == "members":
+			// Alta o cambio de rol: /api/teams/:id/members
+			teamHandler.AddTeamMember(w, r)
+		case len(segments) == 6 && segments[4] == "members":
+			// Baja de un miembro: /api/teams/:id/members/:userId
+			teamHandler.RemoveTeamMember(w, r)
+		case len(segments) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				teamHandler.GetTeam(w, r)
+			case http.MethodPut:
+				teamHandler.UpdateTeam(w, r)
+			case http.MethodDelete:
+				teamHandler.DeleteTeam(w, r)
+			default:
+				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})))
+
+	// Calendarios de atención: CRUD, importación de festivos y cálculos de horario
+	mux.Handle("/api/business-hours", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			businessHoursHandler.GetBusinessCalendars(w, r)
+		case http.MethodPost:
+			businessHoursHandler.CreateBusinessCalendar(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/business-hours/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+
+		switch {
+		case len(segments) == 6 && segments[4] == "holidays" && segments[5] == "import":
+			// Importación de festivos: /api/business-hours/:id/holidays/import
+			businessHoursHandler.ImportHolidays(w, r)
+		case len(segments) == 5 && segments[4] == "status":
+			businessHoursHandler.GetBusinessHoursStatus(w, r)
+		case len(segments) == 5 && segments[4] == "business-time":
+			businessHoursHandler.GetBusinessTime(w, r)
+		case len(segments) == 4:
+			switch r.Method {
+			case http.MethodGet:
+				businessHoursHandler.GetBusinessCalendar(w, r)
+			case http.MethodPut:
+				businessHoursHandler.UpdateBusinessCalendar(w, r)
+			case http.MethodDelete:
+				businessHoursHandler.DeleteBusinessCalendar(w, r)
+			default:
+				http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})))
+
+	// Macros: respuestas guardadas con acciones, personales o compartidas
+	mux.Handle("/api/macros", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			macroHandler.GetMacros(w, r)
+		case http.MethodPost:
+			macroHandler.CreateMacro(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/macros/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")) != 4 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			macroHandler.GetMacro(w, r)
+		case http.MethodPut:
+			macroHandler.UpdateMacro(w, r)
+		case http.MethodDelete:
+			macroHandler.DeleteMacro(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Etiquetas: catálogo, autocompletado y etiquetado masivo de tickets
+	mux.Handle("/api/tags", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tagHandler.GetTags(w, r)
+		case http.MethodPost:
+			tagHandler.CreateTag(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/tags/autocomplete", authMiddleware(http.HandlerFunc(tagHandler.AutocompleteTags)))
+	mux.Handle("/api/tags/bulk", authMiddleware(http.HandlerFunc(tagHandler.BulkTagTickets)))
+	mux.Handle("/api/tags/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")) != 4 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			tagHandler.GetTag(w, r)
+		case http.MethodPut:
+			tagHandler.UpdateTag(w, r)
+		case http.MethodDelete:
+			tagHandler.DeleteTag(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Campos personalizados de tickets y contactos
+	mux.Handle("/api/custom-fields", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			customFieldHandler.GetCustomFields(w, r)
+		case http.MethodPost:
+			customFieldHandler.CreateCustomField(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/custom-fields/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")) != 4 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			customFieldHandler.GetCustomField(w, r)
+		case http.MethodPut:
+			customFieldHandler.UpdateCustomField(w, r)
+		case http.MethodDelete:
+			customFieldHandler.DeleteCustomField(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Contactos (clientes identificados por email)
+	mux.Handle("/api/contacts", authMiddleware(http.HandlerFunc(contactHandler.GetContacts)))
+	mux.Handle("/api/contacts/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")) != 4 {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			contactHandler.GetContact(w, r)
+		case http.MethodPut:
+			contactHandler.UpdateContact(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Notificaciones del usuario (menciones en notas internas)
+	mux.Handle("/api/notifications", authMiddleware(http.HandlerFunc(notificationHandler.GetNotifications)))
+	mux.Handle("/api/notifications/read-all", authMiddleware(http.HandlerFunc(notificationHandler.MarkAllNotificationsRead)))
+	mux.Handle("/api/notifications/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+		if len(segments) != 5 || segments[4] != "read" {
+			http.NotFound(w, r)
+			return
+		}
+		notificationHandler.MarkNotificationRead(w, r)
+	})))
+
+	// Encuestas de satisfacción: respuesta pública con el enlace firmado, listado e informe
+	mux.Handle("/csat/", publicLimit("csat", http.HandlerFunc(csatHandler.RespondSurvey)))
+	mux.Handle("/api/csat/surveys", authMiddleware(http.HandlerFunc(csatHandler.GetSurveys)))
+	mux.Handle("/api/csat/report", authMiddleware(http.HandlerFunc(csatHandler.GetReport)))
+
+	// Rutas de informes
+	mux.Handle("/api/reports/volume", authMiddleware(http.HandlerFunc(reportHandler.GetVolume)))
+	mux.Handle("/api/reports/first-response", authMiddleware(http.HandlerFunc(reportHandler.GetFirstResponse)))
+	mux.Handle("/api/reports/resolution", authMiddleware(http.HandlerFunc(reportHandler.GetResolution)))
+	mux.Handle("/api/reports/backlog", authMiddleware(http.HandlerFunc(reportHandler.GetBacklog)))
+	mux.Handle("/api/reports/agents", authMiddleware(http.HandlerFunc(reportHandler.GetAgentWorkload)))
+	mux.Handle("/api/reports/refresh", authMiddleware(http.HandlerFunc(reportHandler.RefreshReports)))
+
+	// Exportaciones programadas, historial de ejecuciones y descarga con enlace firmado
+	mux.Handle("/api/report-schedules", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			reportExportHandler.GetSchedules(w, r)
+		case http.MethodPost:
+			reportExportHandler.CreateSchedule(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/report-schedules/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+		switch {
+		case len(segments) == 5 && segments[4] == "run":
+			reportExportHandler.RunSchedule(w, r)
+		case len(segments) != 4:
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet:
+			reportExportHandler.GetSchedule(w, r)
+		case r.Method == http.MethodPut || r.Method == http.MethodPatch:
+			reportExportHandler.UpdateSchedule(w, r)
+		case r.Method == http.MethodDelete:
+			reportExportHandler.DeleteSchedule(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/api/exports", authMiddleware(http.HandlerFunc(reportExportHandler.GetExports)))
+	mux.Handle("/api/exports/", authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+		switch {
+		case len(segments) == 5 && segments[4] == "download":
+			reportExportHandler.DownloadExport(w, r)
+		case len(segments) != 4:
+			http.NotFound(w, r)
+		case r.Method == http.MethodGet:
+			reportExportHandler.GetExport(w, r)
+		case r.Method == http.MethodDelete:
+			reportExportHandler.DeleteExport(w, r)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	})))
+	mux.Handle("/exports/", publicLimit("exports", http.HandlerFunc(reportExportHandler.PublicDownload)))
+
+	// Tickets que sigue el usuario autenticado
+	mux.Handle("/api/watching", authMiddleware(http.HandlerFunc(ticketHandler.GetWatchedTickets)))
+
+	// Presencia de los agentes y latido del panel
+	mux.Handle("/api/presence", authMiddleware(http.HandlerFunc(presenceHandler.GetPresence)))
+	mux.Handle("/api/presence/heartbeat", authMiddleware(http.HandlerFunc(presenceHandler.Heartbeat)))
+
+	// Historial de actividad (auditoría)
+	mux.Handle("/api/activities", authMiddleware(http.HandlerFunc(activityHandler.GetActivities)))
+
 	// Middleware de CORS
 	corsMiddleware := func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -604,22 +1125,10 @@ func main() {
 		})
 	}
 
-	// Middleware de logging global para capturar TODAS las peticiones
-	loggingMiddleware := func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// DEBUG: Log TODAS las peticiones que llegan al backend
-			fmt.Printf("🌐 GLOBAL REQUEST - URL: %s, Método: %s, Path: %s, Headers: %v\n",
-				r.URL.String(), r.Method, r.URL.Path, r.Header)
-
-			// Llamar al siguiente handler
-			h.ServeHTTP(w, r)
-		})
-	}
-
 	// Crear servidor con manejador envolvente
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", *port),
-		Handler:      corsMiddleware(loggingMiddleware(mux)),
+		Handler:      corsMiddleware(telemetry.Middleware("growdesk", mux, logging.Middleware(mux))),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -627,7 +1136,7 @@ func main() {
 
 	// Iniciar servidor en una goroutine
 	go func() {
-		log.Printf("Iniciando servidor en el puerto %d", *port)
+		logging.Infof("Iniciando servidor en el puerto %d", *port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Error al iniciar servidor: %v", err)
 		}
@@ -637,7 +1146,14 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Servidor se está cerrando...")
+	logging.Infof("Servidor se está cerrando...")
+
+	// Dejar de anunciarse como disponible y, si se configura SHUTDOWN_DRAIN_DELAY, dar
+	// tiempo al balanceador a retirar la instancia antes de cerrar las conexiones
+	checker.SetDraining(true)
+	if delay, err := time.ParseDuration(os.Getenv("SHUTDOWN_DRAIN_DELAY")); err == nil && delay > 0 {
+		time.Sleep(delay)
+	}
 
 	// Crear contexto con timeout para cerrar el servidor
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -647,8 +1163,9 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Servidor forzado a cerrarse: %v", err)
 	}
+	telemetry.ShutdownTracing(ctx)
 
-	log.Println("Servidor cerrado de maneragraceful")
+	logging.Infof("Servidor cerrado de maneragraceful")
 }
 
 // Helper para obtener variables de entorno con valor por defecto
@@ -683,7 +1200,7 @@ func getEnvBool(key string, defaultValue bool) bool {
 
 	value, err := strconv.ParseBool(valueStr)
 	if err != nil {
-		log.Printf("Advertencia: Variable de entorno %s no es un booleano válido, usando valor por defecto: %v", key, defaultValue)
+		logging.Warnf("Advertencia: Variable de entorno %s no es un booleano válido, usando valor por defecto: %v", key, defaultValue)
 		return defaultValue
 	}
 
@@ -696,7 +1213,7 @@ func getEnvBool(key string, defaultValue bool) bool {
 func ensureWidgetSystemUser(store data.DataStore) {
 	const widgetUserID = "widget-system"
 	if _, err := store.GetUser(widgetUserID); err == nil {
-		log.Printf("Usuario widget-system ya existe en la base de datos")
+		logging.Infof("Usuario widget-system ya existe en la base de datos")
 		return
 	}
 
@@ -714,9 +1231,9 @@ func ensureWidgetSystemUser(store data.DataStore) {
 	}
 
 	if err := store.CreateUser(widgetUser); err != nil {
-		log.Printf("Error al crear usuario widget-system: %v", err)
+		logging.Errorf("Error al crear usuario widget-system: %v", err)
 	} else {
-		log.Printf("Usuario widget-system creado por defecto")
+		logging.Infof("Usuario widget-system creado por defecto")
 	}
 }
 
@@ -725,7 +1242,7 @@ func ensureWidgetSystemUser(store data.DataStore) {
 func ensureAdminUser(store data.DataStore) {
 	const adminUserID = "agente-growdesk"
 	if _, err := store.GetUser(adminUserID); err == nil {
-		log.Printf("Usuario AgenteGrowdesk ya existe en la base de datos")
+		logging.Infof("Usuario AgenteGrowdesk ya existe en la base de datos")
 		return
 	}
 
@@ -743,8 +1260,54 @@ func ensureAdminUser(store data.DataStore) {
 	}
 
 	if err := store.CreateUser(adminUser); err != nil {
-		log.Printf("Error al crear usuario AgenteGrowdesk: %v", err)
+		logging.Errorf("Error al crear usuario AgenteGrowdesk: %v", err)
+	} else {
+		logging.Infof("Usuario administrador AgenteGrowdesk creado por defecto")
+	}
+}
+
+// ensureWidgetAPIServiceKey registra la clave de servicio de widget-api definida en
+// WIDGET_API_SERVICE_KEY, para que ambos servicios puedan configurarse con el mismo valor
+// sin pasar por la API de administración. Sólo se guarda su hash.
+func ensureWidgetAPIServiceKey(store data.DataStore) {
+	key := os.Getenv("WIDGET_API_SERVICE_KEY")
+	if key == "" {
+		logging.Warnf("WIDGET_API_SERVICE_KEY no definida, widget-api necesitará una clave creada en /api/service-keys")
+		return
+	}
+
+	prefix := utils.APIKeyPublicPart(key)
+	if !strings.HasPrefix(key, utils.ServiceKeyPrefix) || prefix == "" || prefix == utils.ServiceKeyPrefix {
+		logging.Warnf("ADVERTENCIA: WIDGET_API_SERVICE_KEY no tiene el formato %s<id>_<secreto>, se ignora", utils.ServiceKeyPrefix)
+		return
+	}
+
+	if existing, err := store.GetServiceKeyByPrefix(prefix); err == nil {
+		if !utils.CompareAPIKeyHash(key, existing.KeyHash) {
+			logging.Warnf("ADVERTENCIA: ya existe una clave de servicio %s con otro secreto", prefix)
+		}
+		return
+	}
+
+	serviceKey := models.ServiceKey{
+		Name:      "widget-api (WIDGET_API_SERVICE_KEY)",
+		Service:   "widget-api",
+		KeyPrefix: prefix,
+		KeyHash:   utils.HashAPIKey(key),
+		Scopes: []string{
+			middleware.ScopeTicketsRead,
+			middleware.ScopeTicketsWrite,
+			middleware.ScopeCategoriesRead,
+			middleware.ScopeFAQsRead,
+			middleware.ScopeWidget,
+		},
+		ActAsUserID: "widget-system",
+		CreatedAt:   time.Now(),
+	}
+
+	if err := store.CreateServiceKey(serviceKey); err != nil {
+		logging.Errorf("Error al registrar la clave de servicio de widget-api: %v", err)
 	} else {
-		log.Printf("Usuario administrador AgenteGrowdesk creado por defecto")
+		logging.Infof("Clave de servicio de widget-api registrada (%s)", prefix)
 	}
 }
@@ -0,0 +1,323 @@
+// Package assignment asigna automáticamente los tickets a agentes según la política
+// (round-robin, menor carga o habilidades) de su equipo o departamento.
+package assignment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/presence"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/teams"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Tipos de actividad que se registran en el historial de auditoría
+const (
+	ActivityAutoAssigned     = "ticket.auto_assigned"
+	ActivityReassigned       = "ticket.reassigned"
+	ActivityAssignmentFailed = "ticket.assignment_failed"
+)
+
+// Decision describe el resultado de asignar un ticket
+type Decision struct {
+	TicketID      string `json:"ticketId"`
+	PolicyID      string `json:"policyId"`
+	Strategy      string `json:"strategy"`
+	AgentID       string `json:"agentId,omitempty"` // Vacío si no había ningún agente elegible
+	PreviousAgent string `json:"previousAgent,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+// mu serializa las asignaciones para que el estado del round-robin y las cargas sean coherentes
+var mu sync.Mutex
+
+// IsOpen indica si un ticket cuenta para la carga de su agente
+func IsOpen(ticket models.Ticket) bool {
+	switch ticket.Status {
+	case "open", "pending", "in_progress":
+		return true
+	}
+	return false
+}
+
+// OpenCounts cuenta los tickets abiertos de cada agente
+func OpenCounts(tickets []models.Ticket) map[string]int {
+	counts := make(map[string]int)
+	for _, ticket := range tickets {
+		if ticket.AssignedTo != "" && IsOpen(ticket) {
+			counts[ticket.AssignedTo]++
+		}
+	}
+	return counts
+}
+
+// PolicyFor elige la política activa que corresponde al ticket: la de su equipo, después
+// la de su departamento y por último una política general (sin equipo ni departamento)
+func PolicyFor(policies []models.AssignmentPolicy, ticket models.Ticket) *models.AssignmentPolicy {
+	var byDepartment, general *models.AssignmentPolicy
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.Enabled {
+			continue
+		}
+		switch {
+		case policy.TeamID != "":
+			if policy.TeamID == ticket.TeamID {
+				return policy
+			}
+		case policy.Department != "":
+			if byDepartment == nil && strings.EqualFold(policy.Department, ticket.Department) {
+				byDepartment = policy
+			}
+		default:
+			if general == nil {
+				general = policy
+			}
+		}
+	}
+	if byDepartment != nil {
+		return byDepartment
+	}
+	return general
+}
+
+// Pick elige un agente para el ticket entre los elegibles de la política. exclude permite
+// descartar al agente actual al reasignar. Devuelve nil si nadie puede recibirlo.
+func Pick(policy models.AssignmentPolicy, ticket models.Ticket, agents []models.User, openCounts map[string]int, exclude string) (*models.User, string) {
+	eligible := eligibleAgents(policy, agents, openCounts, exclude)
+	if len(eligible) == 0 {
+		return nil, "ningún agente disponible por debajo de su carga máxima"
+	}
+
+	switch policy.Strategy {
+	case models.AssignRoundRobin:
+		for i := range eligible {
+			if eligible[i].ID > policy.LastAssignedTo {
+				return &eligible[i], "round-robin"
+			}
+		}
+		return &eligible[0], "round-robin"
+
+	case models.AssignSkills:
+		skilled := make([]models.User, 0, len(eligible))
+		for _, agent := range eligible {
+			if ticket.CategoryID != "" && containsFold(agent.Skills, ticket.CategoryID) {
+				skilled = append(skilled, agent)
+			}
+		}
+		if len(skilled) > 0 {
+			return leastOpen(skilled, openCounts), fmt.Sprintf("experto en la categoría %s con menos tickets abiertos", ticket.CategoryID)
+		}
+		return leastOpen(eligible, openCounts), fmt.Sprintf("ningún experto disponible en la categoría %s, agente con menos tickets abiertos", ticket.CategoryID)
+
+	default:
+		return leastOpen(eligible, openCounts), "agente con menos tickets abiertos"
+	}
+}
+
+// AutoAssign asigna el ticket si no tiene agente y existe una política para él.
+// No guarda el ticket: lo hace quien llama (p. ej. al crearlo). Devuelve nil si no aplica.
+func AutoAssign(store data.DataStore, ticket *models.Ticket) (*Decision, error) {
+	if ticket.AssignedTo != "" {
+		return nil, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	policy, agents, counts, err := loadContext(store, *ticket)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	agent, reason := Pick(*policy, *ticket, agents, counts, "")
+	decision := &Decision{TicketID: ticket.ID, PolicyID: policy.ID, Strategy: policy.Strategy, Reason: reason}
+	if agent == nil {
+		record(store, ActivityAssignmentFailed, *decision, "No se pudo asignar automáticamente el ticket: "+reason)
+		return decision, nil
+	}
+
+	ticket.AssignedTo = agent.ID
+	decision.AgentID = agent.ID
+	if err := rememberAssignment(store, policy, agent.ID); err != nil {
+		logging.Warnf("⚠️ No se pudo guardar el estado del round-robin: %v", err)
+	}
+	record(store, ActivityAutoAssigned, *decision, fmt.Sprintf("Ticket asignado automáticamente a %s %s (%s)", agent.FirstName, agent.LastName, reason))
+	return decision, nil
+}
+
+// ReassignFrom reparte los tickets abiertos de un agente que deja de estar disponible.
+// Los tickets sin política o sin otro agente elegible se quedan como están.
+func ReassignFrom(store data.DataStore, agentID string) ([]Decision, error) {
+	tickets, err := store.GetTickets()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tickets: %v", err)
+	}
+
+	decisions := make([]Decision, 0)
+	for _, ticket := range tickets {
+		if ticket.AssignedTo != agentID || !IsOpen(ticket) {
+			continue
+		}
+		decision, err := reassign(store, ticket, agentID)
+		if err != nil {
+			return decisions, err
+		}
+		if decision != nil {
+			decisions = append(decisions, *decision)
+		}
+	}
+	return decisions, nil
+}
+
+func reassign(store data.DataStore, ticket models.Ticket, agentID string) (*Decision, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	policy, agents, counts, err := loadContext(store, ticket)
+	if err != nil || policy == nil {
+		return nil, err
+	}
+
+	agent, reason := Pick(*policy, ticket, agents, counts, agentID)
+	decision := &Decision{TicketID: ticket.ID, PolicyID: policy.ID, Strategy: policy.Strategy, PreviousAgent: agentID, Reason: reason}
+	if agent == nil {
+		record(store, ActivityAssignmentFailed, *decision, "No se pudo reasignar el ticket de un agente no disponible: "+reason)
+		return decision, nil
+	}
+
+	ticket.AssignedTo = agent.ID
+	if err := store.UpdateTicket(ticket); err != nil {
+		return nil, fmt.Errorf("error al reasignar ticket %s: %v", ticket.ID, err)
+	}
+	decision.AgentID = agent.ID
+	if err := rememberAssignment(store, policy, agent.ID); err != nil {
+		logging.Warnf("⚠️ No se pudo guardar el estado del round-robin: %v", err)
+	}
+	record(store, ActivityReassigned, *decision, fmt.Sprintf("Ticket reasignado a %s %s porque el agente anterior no está disponible (%s)", agent.FirstName, agent.LastName, reason))
+	return decision, nil
+}
+
+// loadContext obtiene la política del ticket, los agentes y su carga actual
+func loadContext(store data.DataStore, ticket models.Ticket) (*models.AssignmentPolicy, []models.User, map[string]int, error) {
+	policies, err := store.GetAssignmentPolicies()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener políticas de asignación: %v", err)
+	}
+	policy := PolicyFor(policies, ticket)
+	if policy == nil {
+		return nil, nil, nil, nil
+	}
+
+	agents, err := store.GetUsers()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener agentes: %v", err)
+	}
+	tickets, err := store.GetTickets()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error al obtener tickets: %v", err)
+	}
+
+	// Sin lista de agentes, la política de un equipo reparte sólo entre sus miembros
+	if policy.TeamID != "" && len(policy.AgentIDs) == 0 {
+		team, err := store.GetTeam(policy.TeamID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error al obtener el equipo %s: %v", policy.TeamID, err)
+		}
+		members := make([]models.User, 0, len(team.Members))
+		for _, agent := range agents {
+			if teams.MemberRole(*team, agent.ID) != "" {
+				members = append(members, agent)
+			}
+		}
+		agents = members
+	}
+
+	return policy, agents, OpenCounts(tickets), nil
+}
+
+// eligibleAgents filtra los agentes activos, disponibles, en su horario y por debajo de su
+// carga máxima, ordenados por ID para que el round-robin sea estable
+func eligibleAgents(policy models.AssignmentPolicy, agents []models.User, openCounts map[string]int, exclude string) []models.User {
+	now := time.Now()
+	eligible := make([]models.User, 0, len(agents))
+	for _, agent := range agents {
+		if agent.ID == exclude || !agent.Active || agent.Role == "customer" {
+			continue
+		}
+		if agent.Availability != models.AvailabilityAvailable || !presence.OnShift(agent.Schedule, now) {
+			continue
+		}
+		if len(policy.AgentIDs) > 0 {
+			if !containsFold(policy.AgentIDs, agent.ID) {
+				continue
+			}
+		} else if policy.Department != "" && !strings.EqualFold(agent.Department, policy.Department) {
+			continue
+		}
+
+		limit := agent.MaxOpenTickets
+		if limit == 0 {
+			limit = policy.MaxOpenTickets
+		}
+		if limit > 0 && openCounts[agent.ID] >= limit {
+			continue
+		}
+		eligible = append(eligible, agent)
+	}
+
+	sort.Slice(eligible, func(i, j int) bool { return eligible[i].ID < eligible[j].ID })
+	return eligible
+}
+
+func leastOpen(agents []models.User, openCounts map[string]int) *models.User {
+	best := &agents[0]
+	for i := range agents[1:] {
+		if openCounts[agents[i+1].ID] < openCounts[best.ID] {
+			best = &agents[i+1]
+		}
+	}
+	return best
+}
+
+func rememberAssignment(store data.DataStore, policy *models.AssignmentPolicy, agentID string) error {
+	if policy.Strategy != models.AssignRoundRobin {
+		return nil
+	}
+	policy.LastAssignedTo = agentID
+	return store.UpdateAssignmentPolicy(*policy)
+}
+
+// record deja constancia de la decisión en el historial de actividad del ticket
+func record(store data.DataStore, activityType string, decision Decision, description string) {
+	activity := models.Activity{
+		Type:        activityType,
+		TargetID:    decision.TicketID,
+		Description: description,
+		Metadata: map[string]any{
+			"policyId":      decision.PolicyID,
+			"strategy":      decision.Strategy,
+			"agentId":       decision.AgentID,
+			"previousAgent": decision.PreviousAgent,
+			"reason":        decision.Reason,
+		},
+	}
+	if err := store.CreateActivity(activity); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar la decisión de asignación del ticket %s: %v", decision.TicketID, err)
+	}
+	logging.Infof("🤝 %s", description)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+// Package bulk ejecuta operaciones masivas sobre tickets (cambio de estado, prioridad,
+// agente, categoría, etiquetas o eliminación) en segundo plano y por lotes, y guarda el
+// progreso y los errores de cada ticket para consultarlos mientras se procesan.
+package bulk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/csat"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// BatchSize es el número de tickets que se guardan en cada transacción
+const BatchSize = 100
+
+// MaxTickets limita los tickets de una operación masiva
+const MaxTickets = 5000
+
+// jobTTL es el tiempo que se conserva una operación terminada para consultar su resultado
+const jobTTL = 24 * time.Hour
+
+// Validate comprueba que las operaciones tengan sentido y que existan la categoría y el
+// agente indicados. Devuelve las operaciones con las etiquetas resueltas contra el catálogo.
+func Validate(store data.DataStore, ops models.BulkTicketOperations) (models.BulkTicketOperations, error) {
+	if ops.Delete {
+		if ops.Status != "" || ops.Priority != "" || ops.AssignedTo != "" || ops.CategoryID != "" || len(ops.AddTags) > 0 || len(ops.RemoveTags) > 0 {
+			return ops, fmt.Errorf("la eliminación no se puede combinar con otros cambios")
+		}
+		return ops, nil
+	}
+	if ops.Status == "" && ops.Priority == "" && ops.AssignedTo == "" && ops.CategoryID == "" && len(ops.AddTags) == 0 && len(ops.RemoveTags) == 0 {
+		return ops, fmt.Errorf("indica al menos un cambio")
+	}
+
+	switch ops.Status {
+	case "", "open", "assigned", "in_progress", "pending", "resolved", "closed":
+	default:
+		return ops, fmt.Errorf("estado inválido %q", ops.Status)
+	}
+	switch ops.Priority {
+	case "", "low", "medium", "high", "urgent":
+	default:
+		return ops, fmt.Errorf("prioridad inválida %q", ops.Priority)
+	}
+	if ops.CategoryID != "" {
+		if _, err := store.GetCategory(ops.CategoryID); err != nil {
+			return ops, fmt.Errorf("la categoría %s no existe", ops.CategoryID)
+		}
+	}
+	if ops.AssignedTo != "" {
+		user, err := store.GetUser(ops.AssignedTo)
+		if err != nil || user.Role == "customer" {
+			return ops, fmt.Errorf("el agente %s no existe", ops.AssignedTo)
+		}
+	}
+
+	resolved, err := tags.Resolve(store, ops.AddTags)
+	if err != nil {
+		return ops, err
+	}
+	ops.AddTags = resolved
+	return ops, nil
+}
+
+// Apply aplica las operaciones (salvo Delete) sobre una copia del ticket
+func Apply(store data.DataStore, ticket models.Ticket, ops models.BulkTicketOperations) models.Ticket {
+	if ops.Status != "" {
+		ticket.Status = ops.Status
+	}
+	if ops.Priority != "" {
+		ticket.Priority = ops.Priority
+	}
+	if ops.AssignedTo != "" {
+		ticket.AssignedTo = ops.AssignedTo
+		if ops.Status == "" && (ticket.Status == "" || ticket.Status == "open") {
+			ticket.Status = "assigned"
+		}
+	}
+	if ops.CategoryID != "" && ops.CategoryID != ticket.CategoryID {
+		if category, err := store.GetCategory(ops.CategoryID); err == nil {
+			ticket.CategoryID = category.ID
+			ticket.Category = category.Name
+		}
+	}
+	if len(ops.AddTags) > 0 || len(ops.RemoveTags) > 0 {
+		ticket.Tags = tags.Remove(tags.Add(ticket.Tags, ops.AddTags), ops.RemoveTags)
+	}
+	ticket.UpdatedAt = time.Now()
+	return ticket
+}
+
+// Runner ejecuta las operaciones masivas y guarda su estado. Las operaciones viven en
+// memoria: tras un reinicio se pierde el resultado de las ya terminadas.
+type Runner struct {
+	mu    sync.Mutex
+	store data.DataStore
+	jobs  map[string]*models.BulkJob
+}
+
+// NewRunner crea un ejecutor de operaciones masivas sobre el almacén
+func NewRunner(store data.DataStore) *Runner {
+	return &Runner{store: store, jobs: make(map[string]*models.BulkJob)}
+}
+
+// Start registra una operación sobre los tickets indicados y la ejecuta en segundo plano.
+// rejected son los tickets descartados al seleccionar (p. ej. sin acceso), que cuentan
+// como fallidos desde el principio.
+func (r *Runner) Start(ticketIDs []string, rejected []models.BulkTicketError, ops models.BulkTicketOperations, createdBy string) models.BulkJob {
+	job := &models.BulkJob{
+		ID:         uuid.New().String(),
+		Status:     models.BulkJobQueued,
+		Operations: ops,
+		Total:      len(ticketIDs) + len(rejected),
+		Processed:  len(rejected),
+		Failed:     len(rejected),
+		Errors:     append(make([]models.BulkTicketError, 0, len(rejected)), rejected...),
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+
+	r.mu.Lock()
+	r.cleanup()
+	r.jobs[job.ID] = job
+	snapshot := copyJob(job)
+	r.mu.Unlock()
+
+	go r.run(job.ID, job.CreatedBy, append([]string(nil), ticketIDs...), ops)
+	return snapshot
+}
+
+// Get devuelve el estado actual de una operación
+func (r *Runner) Get(id string) (models.BulkJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return models.BulkJob{}, false
+	}
+	return copyJob(job), true
+}
+
+// List devuelve las operaciones lanzadas por el usuario (todas si userID está vacío),
+// las más recientes primero
+func (r *Runner) List(userID string) []models.BulkJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]models.BulkJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		if userID == "" || job.CreatedBy == userID {
+			result = append(result, copyJob(job))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result
+}
+
+// Pending devuelve cuántos tickets quedan por procesar en las operaciones en curso
+func (r *Runner) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := 0
+	for _, job := range r.jobs {
+		if job.FinishedAt == nil {
+			pending += job.Total - job.Processed
+		}
+	}
+	return pending
+}
+
+func (r *Runner) run(id, actorID string, ticketIDs []string, ops models.BulkTicketOperations) {
+	r.update(id, func(job *models.BulkJob) {
+		now := time.Now()
+		job.Status = models.BulkJobRunning
+		job.StartedAt = &now
+	})
+
+	for start := 0; start < len(ticketIDs); start += BatchSize {
+		end := start + BatchSize
+		if end > len(ticketIDs) {
+			end = len(ticketIDs)
+		}
+		succeeded, failed := r.runBatch(actorID, ticketIDs[start:end], ops)
+		r.update(id, func(job *models.BulkJob) {
+			job.Processed += end - start
+			job.Succeeded += succeeded
+			job.Failed += len(failed)
+			job.Errors = append(job.Errors, failed...)
+		})
+	}
+
+	var finished models.BulkJob
+	r.update(id, func(job *models.BulkJob) {
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Status = models.BulkJobCompleted
+		if job.Total > 0 && job.Succeeded == 0 {
+			job.Status = models.BulkJobFailed
+		}
+		finished = copyJob(job)
+	})
+	logging.Infof("📦 Operación masiva %s terminada: %d correctos, %d fallidos", id, finished.Succeeded, finished.Failed)
+
+	activity := models.Activity{
+		UserID:      finished.CreatedBy,
+		Type:        "tickets.bulk_updated",
+		TargetID:    finished.ID,
+		Description: fmt.Sprintf("Operación masiva sobre %d tickets: %d correctos, %d fallidos", finished.Total, finished.Succeeded, finished.Failed),
+		Metadata: map[string]any{
+			"operations": finished.Operations,
+			"succeeded":  finished.Succeeded,
+			"failed":     finished.Failed,
+		},
+	}
+	if err := r.store.CreateActivity(activity); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar la operación masiva: %v", err)
+	}
+}
+
+// runBatch aplica las operaciones a un lote en una sola transacción. Los tickets que ya
+// no existen se informan por separado; si falla la transacción falla todo el lote.
+// Los seguidores de los tickets que cambian de estado reciben el aviso al terminar el lote
+// y los clientes de los tickets resueltos o cerrados, la encuesta de satisfacción.
+func (r *Runner) runBatch(actorID string, ticketIDs []string, ops models.BulkTicketOperations) (int, []models.BulkTicketError) {
+	var failed []models.BulkTicketError
+	var updated []models.Ticket
+	var deleted []string
+	previousStatus := make(map[string]string)
+	for _, ticketID := range ticketIDs {
+		ticket, err := r.store.GetTicket(ticketID)
+		if err != nil {
+			failed = append(failed, models.BulkTicketError{TicketID: ticketID, Error: "Ticket no encontrado"})
+			continue
+		}
+		if ops.Delete {
+			deleted = append(deleted, ticket.ID)
+			continue
+		}
+		previousStatus[ticket.ID] = ticket.Status
+		updated = append(updated, Apply(r.store, *ticket, ops))
+	}
+
+	if len(updated) == 0 && len(deleted) == 0 {
+		return 0, failed
+	}
+	if err := r.store.UpdateTicketsBatch(updated, deleted); err != nil {
+		logging.Warnf("⚠️ Error al guardar un lote de la operación masiva: %v", err)
+		for _, ticket := range updated {
+			failed = append(failed, models.BulkTicketError{TicketID: ticket.ID, Error: "Error al guardar el lote: " + err.Error()})
+		}
+		for _, id := range deleted {
+			failed = append(failed, models.BulkTicketError{TicketID: id, Error: "Error al guardar el lote: " + err.Error()})
+		}
+		return 0, failed
+	}
+
+	for _, ticket := range updated {
+		if ticket.Status == previousStatus[ticket.ID] {
+			continue
+		}
+		watchers.Notify(r.store, ticket, watchers.StatusEvent(actorID, previousStatus[ticket.ID], ticket.Status))
+		if csat.Closing(previousStatus[ticket.ID], ticket.Status) {
+			if _, err := csat.Send(r.store, ticket); err != nil {
+				logging.Warnf("⚠️ No se pudo crear la encuesta del ticket %s: %v", ticket.ID, err)
+			}
+		}
+	}
+	return len(updated) + len(deleted), failed
+}
+
+func (r *Runner) update(id string, fn func(job *models.BulkJob)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// cleanup olvida las operaciones terminadas hace más de jobTTL; el llamador tiene el mutex
+func (r *Runner) cleanup() {
+	for id, job := range r.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > jobTTL {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+func copyJob(job *models.BulkJob) models.BulkJob {
+	result := *job
+	result.Errors = append(make([]models.BulkTicketError, 0, len(job.Errors)), job.Errors...)
+	return result
+}
@@ -0,0 +1,276 @@
+// Package businesshours calcula horarios de atención: si un calendario está abierto,
+// cuándo vuelve a abrir y cuánto tiempo hábil hay entre dos instantes. Lo usan los
+// tickets (respuestas automáticas fuera de horario), la presencia de los agentes y la
+// disponibilidad del chat del widget.
+package businesshours
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	_ "time/tzdata" // Zonas horarias disponibles aunque la imagen no las incluya
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// searchDays limita la búsqueda de la próxima apertura; un calendario sin turnos en un
+// año completo se considera siempre cerrado
+const searchDays = 400
+
+// Calendar es un horario de atención listo para hacer cálculos
+type Calendar struct {
+	loc      *time.Location
+	shifts   []shift
+	holidays map[string]bool
+}
+
+// shift es un turno en minutos desde la medianoche de su día
+type shift struct {
+	weekday    time.Weekday
+	start, end int
+}
+
+// interval es un tramo abierto [start, end)
+type interval struct {
+	start, end time.Time
+}
+
+// New prepara un calendario a partir de sus turnos y festivos
+func New(calendar models.BusinessCalendar) (*Calendar, error) {
+	c, err := fromShifts(calendar.TimeZone, calendar.Hours)
+	if err != nil {
+		return nil, err
+	}
+	for _, holiday := range calendar.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+			return nil, fmt.Errorf("fecha de festivo inválida %q (formato YYYY-MM-DD)", holiday.Date)
+		}
+		c.holidays[holiday.Date] = true
+	}
+	return c, nil
+}
+
+// FromSchedule prepara el horario laboral de un agente, que no tiene festivos propios
+func FromSchedule(schedule models.WorkingSchedule) (*Calendar, error) {
+	return fromShifts(schedule.TimeZone, schedule.Shifts)
+}
+
+func fromShifts(timeZone string, shifts []models.WorkingShift) (*Calendar, error) {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria inválida %q", timeZone)
+	}
+
+	c := &Calendar{loc: loc, holidays: make(map[string]bool)}
+	for _, s := range shifts {
+		if s.Weekday < 0 || s.Weekday > 6 {
+			return nil, fmt.Errorf("día de la semana inválido %d (0 = domingo ... 6 = sábado)", s.Weekday)
+		}
+		start, err := parseClock(s.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(s.End)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("el turno de %s a %s no tiene duración", s.Start, s.End)
+		}
+		c.shifts = append(c.shifts, shift{weekday: time.Weekday(s.Weekday), start: start, end: end})
+	}
+	return c, nil
+}
+
+// Location devuelve la zona horaria del calendario
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// IsOpen indica si t cae dentro de un turno
+func (c *Calendar) IsOpen(t time.Time) bool {
+	for _, iv := range c.intervals(t.Add(-24*time.Hour), t.Add(24*time.Hour)) {
+		if !t.Before(iv.start) && t.Before(iv.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen devuelve t si el calendario está abierto, o el comienzo del próximo turno.
+// Devuelve false si no abre en el próximo año.
+func (c *Calendar) NextOpen(t time.Time) (time.Time, bool) {
+	from := t.Add(-24 * time.Hour)
+	for week := 0; week*7 < searchDays; week++ {
+		to := from.Add(8 * 24 * time.Hour)
+		for _, iv := range c.intervals(from, to) {
+			if iv.end.After(t) {
+				if iv.start.After(t) {
+					return iv.start, true
+				}
+				return t, true
+			}
+		}
+		from = from.Add(7 * 24 * time.Hour)
+	}
+	return time.Time{}, false
+}
+
+// NextClose devuelve el final del turno en curso, o false si el calendario está cerrado en t
+func (c *Calendar) NextClose(t time.Time) (time.Time, bool) {
+	end := time.Time{}
+	for _, iv := range c.intervals(t.Add(-24*time.Hour), t.Add(8*24*time.Hour)) {
+		if end.IsZero() {
+			if !t.Before(iv.start) && t.Before(iv.end) {
+				end = iv.end
+			}
+			continue
+		}
+		// Turnos contiguos (p. ej. uno nocturno seguido de otro) no cierran entre medias
+		if iv.start.After(end) {
+			break
+		}
+		if iv.end.After(end) {
+			end = iv.end
+		}
+	}
+	return end, !end.IsZero()
+}
+
+// Between devuelve el tiempo hábil entre from y to. Si to es anterior a from devuelve 0.
+func (c *Calendar) Between(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	var total time.Duration
+	var covered time.Time
+	for _, iv := range c.intervals(from, to) {
+		start, end := iv.start, iv.end
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		// Los turnos solapados no cuentan dos veces
+		if start.Before(covered) {
+			start = covered
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+			covered = end
+		}
+	}
+	return total
+}
+
+// intervals devuelve los tramos abiertos que se solapan con [from, to), ordenados por inicio
+func (c *Calendar) intervals(from, to time.Time) []interval {
+	// Un turno nocturno que empezó el día anterior puede seguir abierto en from
+	day := dateOf(from.In(c.loc)).AddDate(0, 0, -1)
+	last := dateOf(to.In(c.loc))
+
+	var result []interval
+	for !day.After(last) {
+		if !c.holidays[day.Format("2006-01-02")] {
+			for _, s := range c.shifts {
+				if day.Weekday() != s.weekday {
+					continue
+				}
+				start := atMinute(day, s.start)
+				end := atMinute(day, s.end)
+				if s.end < s.start {
+					end = atMinute(day.AddDate(0, 0, 1), s.end)
+				}
+				if end.After(from) && start.Before(to) {
+					result = append(result, interval{start: start, end: end})
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].start.Before(result[j].start) })
+	return result
+}
+
+// Validate comprueba la zona horaria, los turnos y los festivos de un calendario
+func Validate(calendar models.BusinessCalendar) error {
+	_, err := New(calendar)
+	return err
+}
+
+// ValidateSchedule comprueba la zona horaria y los turnos del horario de un agente
+func ValidateSchedule(schedule models.WorkingSchedule) error {
+	_, err := FromSchedule(schedule)
+	return err
+}
+
+// Status calcula si el calendario está abierto en at y, si no, cuándo abre
+func Status(calendar models.BusinessCalendar, at time.Time) (*models.BusinessHoursStatus, error) {
+	c, err := New(calendar)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.BusinessHoursStatus{CalendarID: calendar.ID, At: at, Open: c.IsOpen(at)}
+	if !status.Open {
+		if next, ok := c.NextOpen(at); ok {
+			status.NextOpenAt = &next
+		}
+	}
+	return status, nil
+}
+
+// Resolve elige el calendario que aplica: el del equipo, el del widget o el calendario
+// por defecto, en ese orden. Devuelve nil si no hay ninguno (se atiende siempre).
+func Resolve(store data.DataStore, teamID, widgetID string) (*models.BusinessCalendar, error) {
+	if teamID != "" {
+		if team, err := store.GetTeam(teamID); err == nil && team.CalendarID != "" {
+			if calendar, err := store.GetBusinessCalendar(team.CalendarID); err == nil {
+				return calendar, nil
+			}
+		}
+	}
+
+	calendars, err := store.GetBusinessCalendars()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener calendarios: %v", err)
+	}
+	if widgetID != "" {
+		for i := range calendars {
+			for _, id := range calendars[i].WidgetIDs {
+				if id == widgetID {
+					return &calendars[i], nil
+				}
+			}
+		}
+	}
+	for i := range calendars {
+		if calendars[i].IsDefault {
+			return &calendars[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// atMinute construye la hora local del día; time.Date resuelve los cambios de horario
+func atMinute(day time.Time, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+}
+
+// parseClock convierte "HH:MM" en minutos desde medianoche. "24:00" marca el final del día.
+func parseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("hora inválida %q (formato HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
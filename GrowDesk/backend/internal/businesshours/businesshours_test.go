@@ -0,0 +1,246 @@
+package businesshours
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+var madrid = mustLoad("Europe/Madrid")
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// at construye una hora local de Madrid
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, madrid)
+}
+
+func mustCalendar(t *testing.T, calendar models.BusinessCalendar) *Calendar {
+	t.Helper()
+	c, err := New(calendar)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// officeHours abre de lunes a viernes de 9:00 a 17:00; el lunes 12 de octubre de 2026 es
+// festivo
+func officeHours(t *testing.T) *Calendar {
+	var hours []models.WorkingShift
+	for weekday := 1; weekday <= 5; weekday++ {
+		hours = append(hours, models.WorkingShift{Weekday: weekday, Start: "09:00", End: "17:00"})
+	}
+	return mustCalendar(t, models.BusinessCalendar{
+		TimeZone: "Europe/Madrid",
+		Hours:    hours,
+		Holidays: []models.Holiday{{Date: "2026-10-12", Name: "Fiesta Nacional"}},
+	})
+}
+
+// nightShift abre los viernes de 22:00 a 6:00 del sábado
+func nightShift(t *testing.T) *Calendar {
+	return mustCalendar(t, models.BusinessCalendar{
+		TimeZone: "Europe/Madrid",
+		Hours:    []models.WorkingShift{{Weekday: 5, Start: "22:00", End: "06:00"}},
+	})
+}
+
+func TestIsOpen(t *testing.T) {
+	office, night := officeHours(t), nightShift(t)
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		at       time.Time
+		want     bool
+	}{
+		{"dentro del turno", office, at(2026, 10, 16, 10, 0), true},
+		{"apertura incluida", office, at(2026, 10, 16, 9, 0), true},
+		{"cierre excluido", office, at(2026, 10, 16, 17, 0), false},
+		{"antes de abrir", office, at(2026, 10, 16, 8, 59), false},
+		{"fin de semana", office, at(2026, 10, 17, 10, 0), false},
+		{"festivo", office, at(2026, 10, 12, 10, 0), false},
+		{"otra zona horaria", office, time.Date(2026, 10, 16, 7, 30, 0, 0, time.UTC), true},
+		{"turno nocturno antes de medianoche", night, at(2026, 10, 16, 23, 0), true},
+		{"turno nocturno después de medianoche", night, at(2026, 10, 17, 3, 0), true},
+		{"turno nocturno terminado", night, at(2026, 10, 17, 6, 0), false},
+		{"turno nocturno otro día", night, at(2026, 10, 15, 23, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.IsOpen(tt.at); got != tt.want {
+				t.Errorf("IsOpen(%v) = %v, se esperaba %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOpenAcrossClosedDays(t *testing.T) {
+	office, night := officeHours(t), nightShift(t)
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		from     time.Time
+		want     time.Time
+	}{
+		{"abierto devuelve el mismo instante", office, at(2026, 10, 16, 11, 15), at(2026, 10, 16, 11, 15)},
+		{"antes de abrir", office, at(2026, 10, 16, 7, 0), at(2026, 10, 16, 9, 0)},
+		{"tras el cierre abre al día siguiente", office, at(2026, 10, 14, 18, 0), at(2026, 10, 15, 9, 0)},
+		{"salta el fin de semana", office, at(2026, 10, 16, 17, 0), at(2026, 10, 19, 9, 0)},
+		{"salta el fin de semana y el festivo", office, at(2026, 10, 9, 18, 0), at(2026, 10, 13, 9, 0)},
+		{"dentro del turno nocturno", night, at(2026, 10, 17, 2, 0), at(2026, 10, 17, 2, 0)},
+		{"hasta el próximo turno nocturno", night, at(2026, 10, 17, 7, 0), at(2026, 10, 23, 22, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.calendar.NextOpen(tt.from)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("NextOpen(%v) = %v, %v; se esperaba %v", tt.from, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOpenWithoutShifts(t *testing.T) {
+	c := mustCalendar(t, models.BusinessCalendar{TimeZone: "Europe/Madrid"})
+	if next, ok := c.NextOpen(at(2026, 10, 16, 10, 0)); ok {
+		t.Errorf("un calendario sin turnos no debería abrir, NextOpen = %v", next)
+	}
+}
+
+func TestNextClose(t *testing.T) {
+	office, night := officeHours(t), nightShift(t)
+	contiguous := mustCalendar(t, models.BusinessCalendar{
+		TimeZone: "Europe/Madrid",
+		Hours: []models.WorkingShift{
+			{Weekday: 1, Start: "00:00", End: "24:00"},
+			{Weekday: 2, Start: "00:00", End: "12:00"},
+		},
+	})
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		from     time.Time
+		want     time.Time
+		open     bool
+	}{
+		{"turno de oficina", office, at(2026, 10, 16, 10, 0), at(2026, 10, 16, 17, 0), true},
+		{"cerrado", office, at(2026, 10, 17, 10, 0), time.Time{}, false},
+		{"turno nocturno", night, at(2026, 10, 16, 23, 0), at(2026, 10, 17, 6, 0), true},
+		{"turnos contiguos no cierran a medianoche", contiguous, at(2026, 10, 19, 10, 0), at(2026, 10, 20, 12, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, open := tt.calendar.NextClose(tt.from)
+			if open != tt.open || !got.Equal(tt.want) {
+				t.Errorf("NextClose(%v) = %v, %v; se esperaba %v, %v", tt.from, got, open, tt.want, tt.open)
+			}
+		})
+	}
+}
+
+func TestBetweenAcrossClosedDays(t *testing.T) {
+	office, night := officeHours(t), nightShift(t)
+	overlapping := mustCalendar(t, models.BusinessCalendar{
+		TimeZone: "Europe/Madrid",
+		Hours: []models.WorkingShift{
+			{Weekday: 1, Start: "09:00", End: "14:00"},
+			{Weekday: 1, Start: "12:00", End: "18:00"},
+		},
+	})
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"mismo turno", office, at(2026, 10, 16, 10, 0), at(2026, 10, 16, 12, 30), 150 * time.Minute},
+		{"un día completo", office, at(2026, 10, 15, 0, 0), at(2026, 10, 16, 0, 0), 8 * time.Hour},
+		{"una semana", office, at(2026, 10, 19, 0, 0), at(2026, 10, 26, 0, 0), 40 * time.Hour},
+		{"fin de semana y festivo", office, at(2026, 10, 9, 16, 0), at(2026, 10, 13, 10, 0), 2 * time.Hour},
+		{"todo cerrado", office, at(2026, 10, 17, 8, 0), at(2026, 10, 18, 20, 0), 0},
+		{"fin anterior al inicio", office, at(2026, 10, 16, 12, 0), at(2026, 10, 16, 10, 0), 0},
+		{"turno nocturno", night, at(2026, 10, 16, 21, 0), at(2026, 10, 17, 7, 0), 8 * time.Hour},
+		{"turnos solapados", overlapping, at(2026, 10, 19, 0, 0), at(2026, 10, 20, 0, 0), 9 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.Between(tt.from, tt.to); got != tt.want {
+				t.Errorf("Between(%v, %v) = %v, se esperaba %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetweenAcrossDSTChanges(t *testing.T) {
+	// Los domingos de madrugada de 0:00 a 6:00: el 29 de marzo de 2026 se adelanta la
+	// hora (el turno dura 5 horas reales) y el 25 de octubre se atrasa (7 horas)
+	c := mustCalendar(t, models.BusinessCalendar{
+		TimeZone: "Europe/Madrid",
+		Hours:    []models.WorkingShift{{Weekday: 0, Start: "00:00", End: "06:00"}},
+	})
+	tests := []struct {
+		name string
+		day  time.Time
+		want time.Duration
+	}{
+		{"horario de verano", at(2026, 3, 29, 0, 0), 5 * time.Hour},
+		{"horario de invierno", at(2026, 10, 25, 0, 0), 7 * time.Hour},
+		{"domingo sin cambio", at(2026, 10, 18, 0, 0), 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Between(tt.day, tt.day.AddDate(0, 0, 1)); got != tt.want {
+				t.Errorf("Between = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	calendar := models.BusinessCalendar{
+		ID:       "cal-1",
+		TimeZone: "Europe/Madrid",
+		Hours:    []models.WorkingShift{{Weekday: 1, Start: "09:00", End: "17:00"}},
+	}
+	status, err := Status(calendar, at(2026, 10, 16, 10, 0))
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Open || status.NextOpenAt == nil || !status.NextOpenAt.Equal(at(2026, 10, 19, 9, 0)) {
+		t.Errorf("Status = %+v, se esperaba cerrado hasta el lunes a las 9:00", status)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	shift := func(weekday int, start, end string) []models.WorkingShift {
+		return []models.WorkingShift{{Weekday: weekday, Start: start, End: end}}
+	}
+	tests := []struct {
+		name     string
+		calendar models.BusinessCalendar
+		wantErr  bool
+	}{
+		{"válido", models.BusinessCalendar{TimeZone: "Europe/Madrid", Hours: shift(1, "09:00", "24:00")}, false},
+		{"UTC por defecto", models.BusinessCalendar{Hours: shift(1, "09:00", "17:00")}, false},
+		{"zona horaria desconocida", models.BusinessCalendar{TimeZone: "Marte/Olympus", Hours: shift(1, "09:00", "17:00")}, true},
+		{"día de la semana inválido", models.BusinessCalendar{Hours: shift(7, "09:00", "17:00")}, true},
+		{"hora inválida", models.BusinessCalendar{Hours: shift(1, "25:00", "17:00")}, true},
+		{"turno sin duración", models.BusinessCalendar{Hours: shift(1, "09:00", "09:00")}, true},
+		{"festivo inválido", models.BusinessCalendar{Holidays: []models.Holiday{{Date: "12/10/2026"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.calendar); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
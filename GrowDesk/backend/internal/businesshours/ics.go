@@ -0,0 +1,361 @@
+package businesshours
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// maxHolidayDays limita los eventos de varios días para no expandir rangos absurdos
+const maxHolidayDays = 366
+
+// recurrenceYears es cuántos años, contando el actual, se expanden los eventos que se
+// repiten (RRULE); el año anterior se incluye para los cálculos sobre tickets recientes
+const recurrenceYears = 5
+
+// ParseICS lee los festivos de un calendario iCalendar (.ics). Cada VEVENT se convierte
+// en uno o varios días (DTEND es exclusivo, como define RFC 5545); las horas de los
+// eventos con hora se interpretan en loc. Los festivos que se repiten cada año
+// (RRULE:FREQ=YEARLY, con RDATE y EXDATE) se expanden hasta recurrenceYears; cualquier
+// otra repetición devuelve un error en lugar de importar sólo la primera fecha.
+func ParseICS(r io.Reader, loc *time.Location) ([]models.Holiday, error) {
+	return parseICS(r, loc, time.Now())
+}
+
+// parseICS es ParseICS con el instante que fija los años a expandir
+func parseICS(r io.Reader, loc *time.Location, now time.Time) ([]models.Holiday, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.In(loc)
+	firstYear, lastYear := now.Year()-1, now.Year()+recurrenceYears-1
+
+	var holidays []models.Holiday
+	seen := make(map[string]bool)
+	inEvent := false
+	var summary, rrule string
+	var start, end time.Time
+	var rdates []time.Time
+	var exdates map[string]bool
+
+	for _, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent = true
+			summary, rrule, start, end = "", "", time.Time{}, time.Time{}
+			rdates, exdates = nil, make(map[string]bool)
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("evento %q sin DTSTART", summary)
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			days := 0
+			for day := start; day.Before(end) && days < maxHolidayDays; day = day.AddDate(0, 0, 1) {
+				days++
+			}
+
+			starts := []time.Time{start}
+			if rrule != "" {
+				rule, err := parseYearlyRule(rrule, loc)
+				if err != nil {
+					return nil, fmt.Errorf("evento %q: %v", summary, err)
+				}
+				starts = rule.expand(start, lastYear)
+			}
+			starts = append(starts, rdates...)
+
+			for _, first := range starts {
+				if (rrule != "" || len(rdates) > 0) && first.Year() < firstYear {
+					continue
+				}
+				if exdates[first.Format("2006-01-02")] {
+					continue
+				}
+				for n := 0; n < days; n++ {
+					date := first.AddDate(0, 0, n).Format("2006-01-02")
+					if seen[date] {
+						continue
+					}
+					seen[date] = true
+					holidays = append(holidays, models.Holiday{Date: date, Name: summary})
+				}
+			}
+		case !inEvent:
+			continue
+		case name == "SUMMARY":
+			summary = unescapeText(value)
+		case name == "DTSTART":
+			if start, err = parseICSDate(value, params, loc); err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			if end, err = parseICSDate(value, params, loc); err != nil {
+				return nil, err
+			}
+			// Un evento con hora que termina a media mañana sigue ocupando ese día
+			if end.Hour() != 0 || end.Minute() != 0 {
+				end = dateOf(end).AddDate(0, 0, 1)
+			}
+		case name == "RRULE":
+			if rrule != "" {
+				return nil, fmt.Errorf("evento %q: sólo se admite una RRULE por evento", summary)
+			}
+			rrule = value
+		case name == "RDATE" || name == "EXDATE":
+			if params["VALUE"] == "PERIOD" {
+				return nil, fmt.Errorf("evento %q: %s con periodos no soportado", summary, name)
+			}
+			for _, item := range strings.Split(value, ",") {
+				t, err := parseICSDate(item, params, loc)
+				if err != nil {
+					return nil, err
+				}
+				if name == "RDATE" {
+					rdates = append(rdates, t)
+				} else {
+					exdates[t.Format("2006-01-02")] = true
+				}
+			}
+		}
+	}
+
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays, nil
+}
+
+// yearlyRule es una RRULE anual: el mismo día del año (p. ej. 25 de diciembre) o el
+// n-ésimo día de la semana de un mes (p. ej. el cuarto jueves de noviembre)
+type yearlyRule struct {
+	interval int
+	count    int
+	until    time.Time
+	month    time.Month // 0: el mes de DTSTART
+	monthDay int        // 0: el día de DTSTART
+	weekday  time.Weekday
+	nth      int // BYDAY=4TH -> 4, BYDAY=-1MO -> -1; 0 sin BYDAY
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseYearlyRule interpreta una RRULE con FREQ=YEARLY y, opcionalmente, INTERVAL,
+// COUNT, UNTIL, BYMONTH, BYMONTHDAY y BYDAY con ordinal (un solo valor cada una)
+func parseYearlyRule(value string, loc *time.Location) (*yearlyRule, error) {
+	rule := &yearlyRule{interval: 1}
+	freq := ""
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if strings.Contains(val, ",") && key != "UNTIL" {
+			return nil, fmt.Errorf("RRULE %s con varios valores no soportada", key)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			freq = val
+		case "INTERVAL":
+			rule.interval, err = positiveInt(val)
+		case "COUNT":
+			rule.count, err = positiveInt(val)
+		case "UNTIL":
+			rule.until, err = parseICSDate(val, nil, loc)
+		case "BYMONTH":
+			var month int
+			if month, err = positiveInt(val); err == nil && month > 12 {
+				err = fmt.Errorf("mes inválido")
+			}
+			rule.month = time.Month(month)
+		case "BYMONTHDAY":
+			if rule.monthDay, err = positiveInt(val); err == nil && rule.monthDay > 31 {
+				err = fmt.Errorf("día inválido")
+			}
+		case "BYDAY":
+			if len(val) < 3 {
+				return nil, fmt.Errorf("RRULE BYDAY=%s necesita un ordinal (p. ej. 4TH o -1MO)", val)
+			}
+			weekday, ok := icsWeekdays[val[len(val)-2:]]
+			nth, convErr := strconv.Atoi(val[:len(val)-2])
+			if !ok || convErr != nil || nth == 0 || nth < -5 || nth > 5 {
+				return nil, fmt.Errorf("RRULE BYDAY=%s no soportada", val)
+			}
+			rule.weekday, rule.nth = weekday, nth
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("RRULE %s no soportada", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("RRULE %s=%s inválida", key, val)
+		}
+	}
+
+	if freq != "YEARLY" {
+		return nil, fmt.Errorf("repetición FREQ=%s no soportada, sólo FREQ=YEARLY", freq)
+	}
+	if rule.nth != 0 && rule.monthDay != 0 {
+		return nil, fmt.Errorf("RRULE con BYDAY y BYMONTHDAY a la vez no soportada")
+	}
+	return rule, nil
+}
+
+// expand devuelve el comienzo de cada repetición desde start hasta el último año indicado
+func (rule *yearlyRule) expand(start time.Time, lastYear int) []time.Time {
+	var starts []time.Time
+	n := 0
+	for year := start.Year(); year <= lastYear; year += rule.interval {
+		t, ok := rule.occurrence(year, start)
+		if !ok || t.Before(start) {
+			continue
+		}
+		if !rule.until.IsZero() && t.After(rule.until) {
+			break
+		}
+		starts = append(starts, t)
+		if n++; rule.count > 0 && n >= rule.count {
+			break
+		}
+	}
+	return starts
+}
+
+// occurrence calcula la repetición de un año. Las fechas que no existen ese año (29 de
+// febrero, quinto lunes) se saltan, como indica RFC 5545.
+func (rule *yearlyRule) occurrence(year int, start time.Time) (time.Time, bool) {
+	month := rule.month
+	if month == 0 {
+		month = start.Month()
+	}
+	day := rule.monthDay
+	if day == 0 {
+		day = start.Day()
+	}
+	if rule.nth > 0 {
+		first := time.Date(year, month, 1, 0, 0, 0, 0, start.Location())
+		day = 1 + (int(rule.weekday)-int(first.Weekday())+7)%7 + (rule.nth-1)*7
+	} else if rule.nth < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location())
+		day = last.Day() - (int(last.Weekday())-int(rule.weekday)+7)%7 + (rule.nth+1)*7
+	}
+
+	t := time.Date(year, month, day, start.Hour(), start.Minute(), 0, 0, start.Location())
+	return t, day >= 1 && t.Month() == month
+}
+
+func positiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("se esperaba un número positivo")
+	}
+	return n, nil
+}
+
+// MergeHolidays añade los festivos nuevos a los existentes sin duplicar fechas
+func MergeHolidays(existing, added []models.Holiday) []models.Holiday {
+	byDate := make(map[string]models.Holiday, len(existing)+len(added))
+	for _, holiday := range existing {
+		byDate[holiday.Date] = holiday
+	}
+	for _, holiday := range added {
+		if _, ok := byDate[holiday.Date]; !ok {
+			byDate[holiday.Date] = holiday
+		}
+	}
+
+	merged := make([]models.Holiday, 0, len(byDate))
+	for _, holiday := range byDate {
+		merged = append(merged, holiday)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Date < merged[j].Date })
+	return merged
+}
+
+// unfoldLines junta las líneas continuadas (las que empiezan por espacio o tabulador)
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error al leer el calendario: %v", err)
+	}
+	return lines, nil
+}
+
+// splitProperty separa "NOMBRE;PARAM=VALOR:valor" en nombre, parámetros y valor
+func splitProperty(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	head, value := line[:colon], line[colon+1:]
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		if eq := strings.Index(param, "="); eq > 0 {
+			params[strings.ToUpper(param[:eq])] = strings.Trim(param[eq+1:], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSDate interpreta DATE (20251225), DATE-TIME en UTC (20251225T000000Z) o local,
+// con TZID opcional. Devuelve la medianoche del día en loc para las fechas sin hora.
+func parseICSDate(value string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("fecha iCalendar inválida %q", value)
+		}
+		return t, nil
+	}
+
+	eventLoc := loc
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			eventLoc = l
+		}
+	}
+	var t time.Time
+	var err error
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else {
+		t, err = time.ParseInLocation("20060102T150405", value, eventLoc)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha iCalendar inválida %q", value)
+	}
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), 0, 0, loc), nil
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
@@ -0,0 +1,161 @@
+package businesshours
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsNow fija los años expandidos: de 2025 a 2030
+var icsNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// calendarICS envuelve las propiedades de cada evento en un VCALENDAR
+func calendarICS(events ...string) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	for _, event := range events {
+		b.WriteString("BEGIN:VEVENT\r\n" + event + "END:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+func holidayDates(t *testing.T, ics string) []string {
+	t.Helper()
+	holidays, err := parseICS(strings.NewReader(ics), time.UTC, icsNow)
+	if err != nil {
+		t.Fatalf("parseICS: %v", err)
+	}
+	dates := make([]string, 0, len(holidays))
+	for _, holiday := range holidays {
+		dates = append(dates, holiday.Date)
+	}
+	return dates
+}
+
+func TestParseICSExpandsEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  []string
+	}{
+		{
+			name:  "día suelto",
+			event: "SUMMARY:Navidad\r\nDTSTART;VALUE=DATE:20261225\r\n",
+			want:  []string{"2026-12-25"},
+		},
+		{
+			name:  "varios días con DTEND exclusivo",
+			event: "SUMMARY:Puente\r\nDTSTART;VALUE=DATE:20261224\r\nDTEND;VALUE=DATE:20261226\r\n",
+			want:  []string{"2026-12-24", "2026-12-25"},
+		},
+		{
+			name:  "evento con hora que termina a media mañana",
+			event: "SUMMARY:Inventario\r\nDTSTART:20261230T180000Z\r\nDTEND:20261231T100000Z\r\n",
+			want:  []string{"2026-12-30", "2026-12-31"},
+		},
+		{
+			name:  "misma fecha cada año",
+			event: "SUMMARY:Navidad\r\nDTSTART;VALUE=DATE:20201225\r\nRRULE:FREQ=YEARLY\r\n",
+			want:  []string{"2025-12-25", "2026-12-25", "2027-12-25", "2028-12-25", "2029-12-25", "2030-12-25"},
+		},
+		{
+			name:  "cuarto jueves de noviembre",
+			event: "SUMMARY:Acción de Gracias\r\nDTSTART;VALUE=DATE:20201126\r\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH\r\n",
+			want:  []string{"2025-11-27", "2026-11-26", "2027-11-25", "2028-11-23", "2029-11-22", "2030-11-28"},
+		},
+		{
+			name:  "último lunes de mayo",
+			event: "SUMMARY:Memorial Day\r\nDTSTART;VALUE=DATE:20200525\r\nRRULE:FREQ=YEARLY;BYMONTH=5;BYDAY=-1MO\r\n",
+			want:  []string{"2025-05-26", "2026-05-25", "2027-05-31", "2028-05-29", "2029-05-28", "2030-05-27"},
+		},
+		{
+			name:  "COUNT",
+			event: "SUMMARY:Aniversario\r\nDTSTART;VALUE=DATE:20250301\r\nRRULE:FREQ=YEARLY;COUNT=3\r\n",
+			want:  []string{"2025-03-01", "2026-03-01", "2027-03-01"},
+		},
+		{
+			name:  "COUNT cuenta también las repeticiones anteriores al horizonte",
+			event: "SUMMARY:Aniversario\r\nDTSTART;VALUE=DATE:20230301\r\nRRULE:FREQ=YEARLY;COUNT=3\r\n",
+			want:  []string{"2025-03-01"},
+		},
+		{
+			name:  "UNTIL",
+			event: "SUMMARY:Fiesta local\r\nDTSTART;VALUE=DATE:20240915\r\nRRULE:FREQ=YEARLY;UNTIL=20270915\r\n",
+			want:  []string{"2025-09-15", "2026-09-15", "2027-09-15"},
+		},
+		{
+			name:  "INTERVAL",
+			event: "SUMMARY:Elecciones\r\nDTSTART;VALUE=DATE:20240601\r\nRRULE:FREQ=YEARLY;INTERVAL=2\r\n",
+			want:  []string{"2026-06-01", "2028-06-01", "2030-06-01"},
+		},
+		{
+			name:  "29 de febrero sólo en años bisiestos",
+			event: "SUMMARY:Bisiesto\r\nDTSTART;VALUE=DATE:20240229\r\nRRULE:FREQ=YEARLY\r\n",
+			want:  []string{"2028-02-29"},
+		},
+		{
+			name:  "repetición de varios días",
+			event: "SUMMARY:Navidad\r\nDTSTART;VALUE=DATE:20291224\r\nDTEND;VALUE=DATE:20291226\r\nRRULE:FREQ=YEARLY\r\n",
+			want:  []string{"2029-12-24", "2029-12-25", "2030-12-24", "2030-12-25"},
+		},
+		{
+			name:  "EXDATE y RDATE",
+			event: "SUMMARY:Cierre\r\nDTSTART;VALUE=DATE:20280110\r\nRRULE:FREQ=YEARLY\r\nEXDATE;VALUE=DATE:20290110\r\nRDATE;VALUE=DATE:20290111,20290112\r\n",
+			want:  []string{"2028-01-10", "2029-01-11", "2029-01-12", "2030-01-10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := holidayDates(t, calendarICS(tt.event)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("festivos = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseICSMergesOverlappingEvents(t *testing.T) {
+	ics := calendarICS(
+		"SUMMARY:Navidad\r\nDTSTART;VALUE=DATE:20291225\r\nRRULE:FREQ=YEARLY\r\n",
+		"SUMMARY:Cierre de año\r\nDTSTART;VALUE=DATE:20301225\r\nDTEND;VALUE=DATE:20301227\r\n",
+	)
+	want := []string{"2029-12-25", "2030-12-25", "2030-12-26"}
+	if got := holidayDates(t, ics); !reflect.DeepEqual(got, want) {
+		t.Errorf("festivos = %v, se esperaba %v", got, want)
+	}
+}
+
+func TestParseICSRejectsUnsupportedRecurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+	}{
+		{"semanal", "RRULE:FREQ=WEEKLY;BYDAY=MO\r\n"},
+		{"mensual", "RRULE:FREQ=MONTHLY\r\n"},
+		{"sin FREQ", "RRULE:COUNT=3\r\n"},
+		{"BYDAY sin ordinal", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=TH\r\n"},
+		{"BYDAY fuera de rango", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=6TH\r\n"},
+		{"varios valores", "RRULE:FREQ=YEARLY;BYMONTH=1,7\r\n"},
+		{"BYSETPOS", "RRULE:FREQ=YEARLY;BYSETPOS=1\r\n"},
+		{"BYDAY y BYMONTHDAY", "RRULE:FREQ=YEARLY;BYMONTH=5;BYMONTHDAY=1;BYDAY=1MO\r\n"},
+		{"mes inválido", "RRULE:FREQ=YEARLY;BYMONTH=13\r\n"},
+		{"COUNT no positivo", "RRULE:FREQ=YEARLY;COUNT=0\r\n"},
+		{"dos RRULE", "RRULE:FREQ=YEARLY\r\nRRULE:FREQ=YEARLY;BYMONTH=6\r\n"},
+		{"RDATE con periodos", "RDATE;VALUE=PERIOD:20270101T000000Z/20270102T000000Z\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := calendarICS("SUMMARY:Festivo\r\nDTSTART;VALUE=DATE:20260101\r\n" + tt.event)
+			if holidays, err := parseICS(strings.NewReader(ics), time.UTC, icsNow); err == nil {
+				t.Errorf("se esperaba un error y se obtuvo %v", holidays)
+			}
+		})
+	}
+}
+
+func TestParseICSRequiresDTSTART(t *testing.T) {
+	if _, err := parseICS(strings.NewReader(calendarICS("SUMMARY:Sin fecha\r\n")), time.UTC, icsNow); err == nil {
+		t.Error("se esperaba un error por el evento sin DTSTART")
+	}
+}
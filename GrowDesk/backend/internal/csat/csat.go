@@ -0,0 +1,226 @@
+// Package csat envía encuestas de satisfacción cuando un ticket se resuelve o se cierra
+// y agrega sus valoraciones. La encuesta llega al cliente por el widget (evento
+// csat_survey) y por correo, con enlaces de un clic firmados que no requieren sesión.
+// La configuración se lee del entorno:
+//
+//	CSAT_ENABLED   "false" desactiva el envío de encuestas
+//	CSAT_SECRET    clave para firmar los enlaces; sin ella se genera una al arrancar
+//	               y los enlaces enviados dejan de valer tras un reinicio
+//	PUBLIC_URL     URL pública del backend para los enlaces (http://localhost:8080)
+package csat
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/notify"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// ResponseWindow es el tiempo durante el que se acepta (y se puede cambiar) la respuesta
+const ResponseWindow = 30 * 24 * time.Hour
+
+// MaxCommentLength limita el comentario del cliente
+const MaxCommentLength = 2000
+
+// Canales por los que se responde una encuesta
+const (
+	ChannelWidget = "widget"
+	ChannelEmail  = "email"
+	ChannelWeb    = "web"
+)
+
+var (
+	// ErrInvalidToken indica que el token no corresponde a la encuesta
+	ErrInvalidToken = errors.New("enlace de encuesta inválido")
+	// ErrExpired indica que la encuesta ya no admite respuestas
+	ErrExpired = errors.New("la encuesta ha caducado")
+	// ErrInvalidRating indica una valoración fuera de 1 a 5
+	ErrInvalidRating = errors.New("la valoración debe estar entre 1 y 5")
+)
+
+// Config firma y construye los enlaces de las encuestas
+type Config struct {
+	enabled bool
+	secret  []byte
+	baseURL string
+}
+
+var (
+	defaultConfig *Config
+	defaultOnce   sync.Once
+)
+
+// Default devuelve la configuración leída del entorno
+func Default() *Config {
+	defaultOnce.Do(func() {
+		secret := []byte(os.Getenv("CSAT_SECRET"))
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			rand.Read(secret)
+			logging.Warnf("⚠️ CSAT_SECRET no definida: los enlaces de las encuestas caducarán al reiniciar")
+		}
+		baseURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080"
+		}
+		defaultConfig = &Config{
+			enabled: !strings.EqualFold(os.Getenv("CSAT_ENABLED"), "false"),
+			secret:  secret,
+			baseURL: baseURL,
+		}
+	})
+	return defaultConfig
+}
+
+// Enabled indica si se envían encuestas
+func (c *Config) Enabled() bool {
+	return c.enabled
+}
+
+// Token firma el ID de una encuesta
+func (c *Config) Token(surveyID string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte("csat:" + surveyID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify comprueba el token de una encuesta
+func (c *Config) Verify(surveyID, token string) bool {
+	return token != "" && hmac.Equal([]byte(c.Token(surveyID)), []byte(token))
+}
+
+// RatingURL devuelve el enlace de un clic que valora la encuesta con rating
+func (c *Config) RatingURL(surveyID string, rating int) string {
+	query := url.Values{}
+	query.Set("rating", fmt.Sprint(rating))
+	query.Set("token", c.Token(surveyID))
+	return c.baseURL + "/csat/" + url.PathEscape(surveyID) + "?" + query.Encode()
+}
+
+// Closing indica si el cambio de estado resuelve o cierra el ticket
+func Closing(previousStatus, status string) bool {
+	closed := func(s string) bool { return s == "resolved" || s == "closed" }
+	return closed(status) && !closed(previousStatus)
+}
+
+// Send crea la encuesta del ticket y la entrega por el widget y por correo. Cada ticket
+// se encuesta una sola vez: si ya tiene encuesta, o se cerró por fusión, no hace nada y
+// devuelve nil.
+func Send(store data.DataStore, ticket models.Ticket) (*models.CSATSurvey, error) {
+	cfg := Default()
+	if !cfg.Enabled() || ticket.MergedInto != "" {
+		return nil, nil
+	}
+	if _, err := store.GetCSATSurveyByTicket(ticket.ID); err == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	survey := models.CSATSurvey{
+		ID:            uuid.New().String(),
+		TicketID:      ticket.ID,
+		AgentID:       ticket.AssignedTo,
+		TeamID:        ticket.TeamID,
+		CategoryID:    ticket.CategoryID,
+		Category:      ticket.Category,
+		CustomerEmail: strings.TrimSpace(ticket.Customer.Email),
+		SentAt:        now,
+		ExpiresAt:     now.Add(ResponseWindow),
+	}
+	if err := store.CreateCSATSurvey(survey); err != nil {
+		return nil, err
+	}
+
+	store.SendCSATSurvey(ticket.ID, Invite(survey))
+	if survey.CustomerEmail != "" && notify.ValidateEmail(survey.CustomerEmail) == nil {
+		if err := notify.Default().SendEmail(surveyEmail(cfg, ticket, survey)); err != nil && err != notify.ErrEmailDisabled {
+			logging.Warnf("⚠️ No se pudo enviar la encuesta del ticket %s: %v", ticket.ID, err)
+		}
+	}
+
+	logging.Infof("⭐ Encuesta de satisfacción enviada para el ticket %s", ticket.ID)
+	return &survey, nil
+}
+
+// Invite es el evento csat_survey que recibe el widget
+func Invite(survey models.CSATSurvey) models.WebSocketMessage {
+	return models.WebSocketMessage{
+		Type:     "csat_survey",
+		TicketID: survey.TicketID,
+		Data: map[string]interface{}{
+			"surveyId":  survey.ID,
+			"token":     Default().Token(survey.ID),
+			"question":  "¿Cómo valorarías la atención recibida?",
+			"expiresAt": survey.ExpiresAt,
+		},
+	}
+}
+
+// Respond guarda la valoración del cliente. Mientras la encuesta no caduque se puede
+// cambiar: el enlace del correo guarda la valoración y el comentario llega después.
+func Respond(store data.DataStore, survey *models.CSATSurvey, req models.CSATResponseRequest) error {
+	if !Default().Verify(survey.ID, req.Token) {
+		return ErrInvalidToken
+	}
+	if time.Now().After(survey.ExpiresAt) {
+		return ErrExpired
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		return ErrInvalidRating
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if len([]rune(comment)) > MaxCommentLength {
+		return fmt.Errorf("el comentario no puede superar %d caracteres", MaxCommentLength)
+	}
+
+	now := time.Now()
+	survey.Rating = req.Rating
+	if comment != "" {
+		survey.Comment = comment
+	}
+	switch req.Channel {
+	case ChannelWidget, ChannelEmail, ChannelWeb:
+		survey.Channel = req.Channel
+	default:
+		survey.Channel = ChannelWeb
+	}
+	survey.RespondedAt = &now
+	return store.UpdateCSATSurvey(*survey)
+}
+
+func surveyEmail(cfg *Config, ticket models.Ticket, survey models.CSATSurvey) notify.Email {
+	labels := []string{"Muy insatisfecho", "Insatisfecho", "Neutral", "Satisfecho", "Muy satisfecho"}
+
+	var body strings.Builder
+	name := strings.TrimSpace(ticket.Customer.Name)
+	if name == "" {
+		name = "Hola"
+	} else {
+		name = "Hola, " + name
+	}
+	fmt.Fprintf(&body, "%s:\n\nTu solicitud \"%s\" (ticket %s) se ha resuelto.\n", name, ticket.Title, ticket.ID)
+	body.WriteString("¿Cómo valorarías la atención recibida? Basta con pulsar un enlace:\n\n")
+	for rating := 5; rating >= 1; rating-- {
+		fmt.Fprintf(&body, "%s %s\n%s\n\n", strings.Repeat("★", rating), labels[rating-1], cfg.RatingURL(survey.ID, rating))
+	}
+	fmt.Fprintf(&body, "La encuesta estará abierta hasta el %s.\n", survey.ExpiresAt.Format("02/01/2006"))
+
+	return notify.Email{
+		To:      []string{survey.CustomerEmail},
+		Subject: fmt.Sprintf("[Ticket %s] ¿Qué tal te atendimos?", ticket.ID),
+		Body:    body.String(),
+	}
+}
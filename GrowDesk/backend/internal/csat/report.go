@@ -0,0 +1,172 @@
+package csat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// Periodos de agregación
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Filter selecciona las encuestas por fecha de envío, agente, equipo o categoría
+type Filter struct {
+	From       *time.Time
+	To         *time.Time
+	TicketID   string
+	AgentID    string
+	TeamID     string
+	CategoryID string
+}
+
+// Matches indica si la encuesta cumple el filtro
+func (f Filter) Matches(survey models.CSATSurvey) bool {
+	switch {
+	case f.From != nil && survey.SentAt.Before(*f.From):
+		return false
+	case f.To != nil && !survey.SentAt.Before(*f.To):
+		return false
+	case f.TicketID != "" && survey.TicketID != f.TicketID:
+		return false
+	case f.AgentID != "" && survey.AgentID != f.AgentID:
+		return false
+	case f.TeamID != "" && survey.TeamID != f.TeamID:
+		return false
+	case f.CategoryID != "" && survey.CategoryID != f.CategoryID:
+		return false
+	}
+	return true
+}
+
+// ValidPeriod indica si period es day, week o month
+func ValidPeriod(period string) bool {
+	return period == PeriodDay || period == PeriodWeek || period == PeriodMonth
+}
+
+// PeriodKey devuelve el periodo al que pertenece t: 2026-10-19, 2026-W42 o 2026-10
+func PeriodKey(t time.Time, period string) string {
+	switch period {
+	case PeriodDay:
+		return t.Format("2006-01-02")
+	case PeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+// Report agrega las encuestas (ya filtradas) en total y por agente, equipo, categoría y
+// periodo. Las encuestas cuentan en el periodo en que se enviaron.
+func Report(store data.DataStore, surveys []models.CSATSurvey, period string) models.CSATReport {
+	agentNames := make(map[string]string)
+	if users, err := store.GetUsers(); err == nil {
+		for _, user := range users {
+			agentNames[user.ID] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+	}
+	teamNames := make(map[string]string)
+	if teams, err := store.GetTeams(); err == nil {
+		for _, team := range teams {
+			teamNames[team.ID] = team.Name
+		}
+	}
+
+	overall := &models.CSATAggregate{Key: "all"}
+	byAgent := make(map[string]*models.CSATAggregate)
+	byTeam := make(map[string]*models.CSATAggregate)
+	byCategory := make(map[string]*models.CSATAggregate)
+	byPeriod := make(map[string]*models.CSATAggregate)
+	totals := make(map[*models.CSATAggregate]int)
+
+	group := func(groups map[string]*models.CSATAggregate, key, label string) *models.CSATAggregate {
+		aggregate, ok := groups[key]
+		if !ok {
+			aggregate = &models.CSATAggregate{Key: key, Label: label}
+			groups[key] = aggregate
+		}
+		return aggregate
+	}
+
+	for _, survey := range surveys {
+		agentKey, agentLabel := survey.AgentID, agentNames[survey.AgentID]
+		if agentKey == "" {
+			agentKey, agentLabel = "unassigned", "Sin asignar"
+		}
+		teamKey, teamLabel := survey.TeamID, teamNames[survey.TeamID]
+		if teamKey == "" {
+			teamKey, teamLabel = "none", "Sin equipo"
+		}
+		categoryKey, categoryLabel := survey.CategoryID, survey.Category
+		if categoryKey == "" {
+			categoryKey = "none"
+			if categoryLabel == "" {
+				categoryLabel = "Sin categoría"
+			}
+		}
+
+		for _, aggregate := range []*models.CSATAggregate{
+			overall,
+			group(byAgent, agentKey, agentLabel),
+			group(byTeam, teamKey, teamLabel),
+			group(byCategory, categoryKey, categoryLabel),
+			group(byPeriod, PeriodKey(survey.SentAt, period), ""),
+		} {
+			aggregate.Sent++
+			if survey.Rating > 0 {
+				aggregate.Responses++
+				totals[aggregate] += survey.Rating
+				if survey.Rating >= 4 {
+					aggregate.Satisfied++
+				}
+			}
+		}
+	}
+
+	finish := func(aggregate *models.CSATAggregate) {
+		if aggregate.Sent > 0 {
+			aggregate.ResponseRate = round(100 * float64(aggregate.Responses) / float64(aggregate.Sent))
+		}
+		if aggregate.Responses > 0 {
+			aggregate.Average = round(float64(totals[aggregate]) / float64(aggregate.Responses))
+			aggregate.Score = round(100 * float64(aggregate.Satisfied) / float64(aggregate.Responses))
+		}
+	}
+	list := func(groups map[string]*models.CSATAggregate, byKey bool) []models.CSATAggregate {
+		result := make([]models.CSATAggregate, 0, len(groups))
+		for _, aggregate := range groups {
+			finish(aggregate)
+			result = append(result, *aggregate)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if !byKey && result[i].Responses != result[j].Responses {
+				return result[i].Responses > result[j].Responses
+			}
+			return result[i].Key < result[j].Key
+		})
+		return result
+	}
+
+	finish(overall)
+	return models.CSATReport{
+		Period:     period,
+		Overall:    *overall,
+		ByAgent:    list(byAgent, false),
+		ByTeam:     list(byTeam, false),
+		ByCategory: list(byCategory, false),
+		ByPeriod:   list(byPeriod, true),
+	}
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,485 @@
+// Package customfields valida las definiciones de los campos personalizados y los
+// valores que tickets y contactos guardan para ellos, y filtra y ordena tickets por
+// esos valores.
+package customfields
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// maxTextLength limita los valores de los campos de texto
+const maxTextLength = 1000
+
+// dateLayout es el formato de los campos de fecha
+const dateLayout = "2006-01-02"
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+var validTypes = map[string]bool{
+	models.CustomFieldText:        true,
+	models.CustomFieldNumber:      true,
+	models.CustomFieldDate:        true,
+	models.CustomFieldDropdown:    true,
+	models.CustomFieldMultiSelect: true,
+	models.CustomFieldCheckbox:    true,
+}
+
+// ValidateDefinition comprueba la clave, el tipo, la entidad y las opciones de un campo
+func ValidateDefinition(field models.CustomField) error {
+	if !keyPattern.MatchString(field.Key) {
+		return fmt.Errorf("clave inválida %q (minúsculas, números y guiones bajos, empezando por letra)", field.Key)
+	}
+	if strings.TrimSpace(field.Label) == "" {
+		return fmt.Errorf("la etiqueta del campo es obligatoria")
+	}
+	if !validTypes[field.Type] {
+		return fmt.Errorf("tipo de campo inválido: %q", field.Type)
+	}
+	if field.Entity != models.CustomFieldEntityTicket && field.Entity != models.CustomFieldEntityContact {
+		return fmt.Errorf("entidad inválida %q (ticket o contact)", field.Entity)
+	}
+
+	hasOptions := field.Type == models.CustomFieldDropdown || field.Type == models.CustomFieldMultiSelect
+	if !hasOptions {
+		if len(field.Options) > 0 {
+			return fmt.Errorf("sólo los campos dropdown y multi_select admiten opciones")
+		}
+		return nil
+	}
+	if len(field.Options) == 0 {
+		return fmt.Errorf("el campo %q necesita al menos una opción", field.Key)
+	}
+	seen := make(map[string]bool, len(field.Options))
+	for _, option := range field.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("las opciones no pueden estar vacías")
+		}
+		if seen[option] {
+			return fmt.Errorf("opción duplicada %q", option)
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+// ForEntity devuelve las definiciones activas de una entidad indexadas por clave
+func ForEntity(defs []models.CustomField, entity string) map[string]models.CustomField {
+	result := make(map[string]models.CustomField)
+	for _, def := range defs {
+		if def.Entity == entity && def.Active {
+			result[def.Key] = def
+		}
+	}
+	return result
+}
+
+// Normalize valida y convierte los valores recibidos al tipo de su campo. Las claves
+// desconocidas o inactivas son un error; un valor nil se conserva para que Merge lo borre.
+func Normalize(defs []models.CustomField, entity string, values map[string]interface{}) (map[string]interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	fields := ForEntity(defs, entity)
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		def, ok := fields[key]
+		if !ok {
+			return nil, fmt.Errorf("campo personalizado desconocido: %q", key)
+		}
+		if value == nil {
+			result[key] = nil
+			continue
+		}
+		normalized, err := coerce(def, value)
+		if err != nil {
+			return nil, fmt.Errorf("campo %q: %v", def.Label, err)
+		}
+		result[key] = normalized
+	}
+	return result, nil
+}
+
+func coerce(def models.CustomField, value interface{}) (interface{}, error) {
+	switch def.Type {
+	case models.CustomFieldText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("se esperaba un texto")
+		}
+		text = strings.TrimSpace(text)
+		if len([]rune(text)) > maxTextLength {
+			return nil, fmt.Errorf("no puede superar %d caracteres", maxTextLength)
+		}
+		return text, nil
+
+	case models.CustomFieldNumber:
+		switch v := value.(type) {
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("número inválido")
+			}
+			return v, nil
+		case string:
+			number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+				return nil, fmt.Errorf("se esperaba un número")
+			}
+			return number, nil
+		}
+		return nil, fmt.Errorf("se esperaba un número")
+
+	case models.CustomFieldDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("se esperaba una fecha YYYY-MM-DD")
+		}
+		if _, err := time.Parse(dateLayout, strings.TrimSpace(text)); err != nil {
+			return nil, fmt.Errorf("se esperaba una fecha YYYY-MM-DD")
+		}
+		return strings.TrimSpace(text), nil
+
+	case models.CustomFieldDropdown:
+		text, ok := value.(string)
+		if !ok || !hasOption(def.Options, text) {
+			return nil, fmt.Errorf("opción inválida (%s)", strings.Join(def.Options, ", "))
+		}
+		return text, nil
+
+	case models.CustomFieldMultiSelect:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("se esperaba una lista de opciones")
+		}
+		selected := make([]string, 0, len(list))
+		for _, item := range list {
+			text, ok := item.(string)
+			if !ok || !hasOption(def.Options, text) {
+				return nil, fmt.Errorf("opción inválida (%s)", strings.Join(def.Options, ", "))
+			}
+			if !hasOption(selected, text) {
+				selected = append(selected, text)
+			}
+		}
+		return selected, nil
+
+	case models.CustomFieldCheckbox:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			checked, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("se esperaba true o false")
+			}
+			return checked, nil
+		}
+		return nil, fmt.Errorf("se esperaba true o false")
+	}
+	return nil, fmt.Errorf("tipo de campo inválido: %q", def.Type)
+}
+
+func hasOption(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge aplica los cambios sobre los valores actuales; un valor nil borra el campo
+func Merge(current, changes map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(current)+len(changes))
+	for key, value := range current {
+		result[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = value
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// CheckRequired comprueba que estén los campos obligatorios de la entidad. Con
+// preChatOnly (tickets del widget) sólo se exigen los que se piden en el pre-chat.
+func CheckRequired(defs []models.CustomField, entity string, values map[string]interface{}, preChatOnly bool) error {
+	for _, def := range defs {
+		if def.Entity != entity || !def.Active || !def.Required {
+			continue
+		}
+		if preChatOnly && !def.ShowInPreChat {
+			continue
+		}
+		if isEmpty(values[def.Key]) {
+			return fmt.Errorf("el campo %q es obligatorio", def.Label)
+		}
+	}
+	return nil
+}
+
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// condition es un filtro sobre un campo: ?cf.<clave>=valor, ?cf.<clave>.gte= o ?cf.<clave>.lte=
+type condition struct {
+	def   models.CustomField
+	op    string // "eq", "gte" o "lte"
+	value string
+}
+
+// Filter selecciona tickets por sus campos personalizados
+type Filter struct {
+	conditions []condition
+}
+
+// ParseFilter lee los parámetros cf.<clave> de la consulta. Texto busca por contenido,
+// dropdown y checkbox por igualdad, multi_select si contiene la opción, y number y date
+// admiten además los rangos .gte y .lte.
+func ParseFilter(defs []models.CustomField, query url.Values) (Filter, error) {
+	fields := ForEntity(defs, models.CustomFieldEntityTicket)
+	var filter Filter
+	for param, values := range query {
+		if !strings.HasPrefix(param, "cf.") || len(values) == 0 {
+			continue
+		}
+		key, op := strings.TrimPrefix(param, "cf."), "eq"
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			key, op = key[:i], key[i+1:]
+		}
+		def, ok := fields[key]
+		if !ok {
+			return Filter{}, fmt.Errorf("campo personalizado desconocido: %q", key)
+		}
+		switch op {
+		case "eq":
+		case "gte", "lte":
+			if def.Type != models.CustomFieldNumber && def.Type != models.CustomFieldDate {
+				return Filter{}, fmt.Errorf("el campo %q no admite rangos", key)
+			}
+		default:
+			return Filter{}, fmt.Errorf("operador de filtro inválido: %q", op)
+		}
+		value := values[0]
+		if op != "eq" || def.Type == models.CustomFieldNumber || def.Type == models.CustomFieldDate || def.Type == models.CustomFieldCheckbox {
+			if _, err := coerce(def, value); err != nil {
+				return Filter{}, fmt.Errorf("filtro %s: %v", param, err)
+			}
+		}
+		filter.conditions = append(filter.conditions, condition{def: def, op: op, value: value})
+	}
+	return filter, nil
+}
+
+// Empty indica si el filtro no tiene condiciones
+func (f Filter) Empty() bool {
+	return len(f.conditions) == 0
+}
+
+// Matches indica si los valores de un ticket cumplen todas las condiciones
+func (f Filter) Matches(values map[string]interface{}) bool {
+	for _, c := range f.conditions {
+		if !c.matches(values[c.def.Key]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) matches(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	switch c.def.Type {
+	case models.CustomFieldText:
+		text, _ := value.(string)
+		return strings.Contains(strings.ToLower(text), strings.ToLower(c.value))
+	case models.CustomFieldDropdown:
+		text, _ := value.(string)
+		return text == c.value
+	case models.CustomFieldMultiSelect:
+		for _, item := range toStrings(value) {
+			if item == c.value {
+				return true
+			}
+		}
+		return false
+	case models.CustomFieldCheckbox:
+		checked, _ := value.(bool)
+		want, _ := strconv.ParseBool(c.value)
+		return checked == want
+	}
+
+	cmp, ok := compareValues(c.def.Type, value, c.value)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "gte":
+		return cmp >= 0
+	case "lte":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+// compareValues compara un valor guardado de tipo number o date con uno de la consulta
+func compareValues(fieldType string, value interface{}, other string) (int, bool) {
+	if fieldType == models.CustomFieldNumber {
+		a, ok := toNumber(value)
+		b, err := strconv.ParseFloat(other, 64)
+		if !ok || err != nil {
+			return 0, false
+		}
+		switch {
+		case a < b:
+			return -1, true
+		case a > b:
+			return 1, true
+		}
+		return 0, true
+	}
+	text, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	// Las fechas YYYY-MM-DD se ordenan igual que su texto
+	return strings.Compare(text, other), true
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if text, ok := item.(string); ok {
+				result = append(result, text)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// SortTickets ordena los tickets según ?sort= (createdAt, updatedAt o cf.<clave>) y
+// ?order= (asc o desc, por defecto desc). Los tickets sin valor van siempre al final.
+func SortTickets(defs []models.CustomField, tickets []models.Ticket, sortBy, order string) error {
+	if sortBy == "" {
+		return nil
+	}
+	desc := true
+	switch order {
+	case "", "desc":
+	case "asc":
+		desc = false
+	default:
+		return fmt.Errorf("orden inválido %q (asc o desc)", order)
+	}
+
+	switch sortBy {
+	case "createdAt", "updatedAt":
+		sort.SliceStable(tickets, func(i, j int) bool {
+			a, b := tickets[i].CreatedAt, tickets[j].CreatedAt
+			if sortBy == "updatedAt" {
+				a, b = tickets[i].UpdatedAt, tickets[j].UpdatedAt
+			}
+			if desc {
+				return a.After(b)
+			}
+			return a.Before(b)
+		})
+		return nil
+	}
+
+	if !strings.HasPrefix(sortBy, "cf.") {
+		return fmt.Errorf("campo de orden inválido: %q", sortBy)
+	}
+	key := strings.TrimPrefix(sortBy, "cf.")
+	def, ok := ForEntity(defs, models.CustomFieldEntityTicket)[key]
+	if !ok {
+		return fmt.Errorf("campo personalizado desconocido: %q", key)
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		a, b := tickets[i].CustomFields[key], tickets[j].CustomFields[key]
+		if a == nil || b == nil {
+			return a != nil
+		}
+		cmp := compareSortValues(def.Type, a, b)
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return nil
+}
+
+func compareSortValues(fieldType string, a, b interface{}) int {
+	switch fieldType {
+	case models.CustomFieldNumber:
+		x, _ := toNumber(a)
+		y, _ := toNumber(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case models.CustomFieldCheckbox:
+		x, _ := a.(bool)
+		y, _ := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case models.CustomFieldMultiSelect:
+		return strings.Compare(strings.Join(toStrings(a), ","), strings.Join(toStrings(b), ","))
+	}
+	x, _ := a.(string)
+	y, _ := b.(string)
+	return strings.Compare(strings.ToLower(x), strings.ToLower(y))
+}
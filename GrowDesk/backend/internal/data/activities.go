@@ -0,0 +1,45 @@
+package data
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// maxActivities limita el registro de actividad guardado en archivo
+const maxActivities = 10000
+
+// CreateActivity registra una actividad en el historial de auditoría
+func (s *Store) CreateActivity(activity models.Activity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if activity.ID == "" {
+		activity.ID = uuid.New().String()
+	}
+	if activity.Timestamp.IsZero() {
+		activity.Timestamp = time.Now()
+	}
+
+	s.Activities = append(s.Activities, activity)
+	if len(s.Activities) > maxActivities {
+		s.Activities = append([]models.Activity(nil), s.Activities[len(s.Activities)-maxActivities:]...)
+	}
+	return writeJSONFile(s.ActivitiesFile, s.Activities)
+}
+
+// GetActivities devuelve las actividades de un objeto (o todas si targetID está vacío),
+// de la más reciente a la más antigua
+func (s *Store) GetActivities(targetID string) ([]models.Activity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	activities := make([]models.Activity, 0)
+	for i := len(s.Activities) - 1; i >= 0; i-- {
+		if targetID == "" || s.Activities[i].TargetID == targetID {
+			activities = append(activities, s.Activities[i])
+		}
+	}
+	return activities, nil
+}
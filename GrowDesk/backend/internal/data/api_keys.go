@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetAPIKeys devuelve todas las claves de API
+func (s *Store) GetAPIKeys() ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.APIKey, len(s.APIKeys))
+	copy(keys, s.APIKeys)
+	return keys, nil
+}
+
+// GetAPIKeyByPrefix busca una clave de API por su parte pública
+func (s *Store) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.APIKeys {
+		if key.KeyPrefix == prefix {
+			keyCopy := key
+			return &keyCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("clave de API %s no encontrada", prefix)
+}
+
+// CreateAPIKey agrega una nueva clave de API
+func (s *Store) CreateAPIKey(key models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	s.APIKeys = append(s.APIKeys, key)
+	return writeJSONFile(s.APIKeysFile, s.APIKeys)
+}
+
+// RevokeAPIKey revoca una clave de API
+func (s *Store) RevokeAPIKey(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.APIKeys {
+		if key.ID == id {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				s.APIKeys[i].RevokedAt = &now
+			}
+			return writeJSONFile(s.APIKeysFile, s.APIKeys)
+		}
+	}
+
+	return fmt.Errorf("clave de API con ID %s no encontrada", id)
+}
+
+// TouchAPIKey registra el último uso de una clave de API
+func (s *Store) TouchAPIKey(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.APIKeys {
+		if key.ID == id {
+			now := time.Now()
+			s.APIKeys[i].LastUsedAt = &now
+			return writeJSONFile(s.APIKeysFile, s.APIKeys)
+		}
+	}
+
+	return fmt.Errorf("clave de API con ID %s no encontrada", id)
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetAssignmentPolicies devuelve las políticas de asignación automática
+func (s *Store) GetAssignmentPolicies() ([]models.AssignmentPolicy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policies := make([]models.AssignmentPolicy, len(s.AssignmentPolicies))
+	copy(policies, s.AssignmentPolicies)
+	return policies, nil
+}
+
+// GetAssignmentPolicy obtiene una política de asignación por ID
+func (s *Store) GetAssignmentPolicy(id string) (*models.AssignmentPolicy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, policy := range s.AssignmentPolicies {
+		if policy.ID == id {
+			policyCopy := policy
+			return &policyCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("política de asignación con ID %s no encontrada", id)
+}
+
+// CreateAssignmentPolicy agrega una nueva política de asignación
+func (s *Store) CreateAssignmentPolicy(policy models.AssignmentPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if policy.CreatedAt.IsZero() {
+		policy.CreatedAt = now
+	}
+	policy.UpdatedAt = now
+
+	s.AssignmentPolicies = append(s.AssignmentPolicies, policy)
+	return writeJSONFile(s.AssignmentPoliciesFile, s.AssignmentPolicies)
+}
+
+// UpdateAssignmentPolicy actualiza una política de asignación existente
+func (s *Store) UpdateAssignmentPolicy(policy models.AssignmentPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.AssignmentPolicies {
+		if existing.ID == policy.ID {
+			policy.CreatedAt = existing.CreatedAt
+			policy.UpdatedAt = time.Now()
+			s.AssignmentPolicies[i] = policy
+			return writeJSONFile(s.AssignmentPoliciesFile, s.AssignmentPolicies)
+		}
+	}
+
+	return fmt.Errorf("política de asignación con ID %s no encontrada", policy.ID)
+}
+
+// DeleteAssignmentPolicy elimina una política de asignación
+func (s *Store) DeleteAssignmentPolicy(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, policy := range s.AssignmentPolicies {
+		if policy.ID == id {
+			s.AssignmentPolicies = append(s.AssignmentPolicies[:i], s.AssignmentPolicies[i+1:]...)
+			return writeJSONFile(s.AssignmentPoliciesFile, s.AssignmentPolicies)
+		}
+	}
+
+	return fmt.Errorf("política de asignación con ID %s no encontrada", id)
+}
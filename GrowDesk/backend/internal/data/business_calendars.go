@@ -0,0 +1,114 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetBusinessCalendars devuelve los calendarios de atención ordenados por nombre
+func (s *Store) GetBusinessCalendars() ([]models.BusinessCalendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendars := make([]models.BusinessCalendar, len(s.BusinessCalendars))
+	for i, calendar := range s.BusinessCalendars {
+		calendars[i] = copyBusinessCalendar(calendar)
+	}
+	sort.SliceStable(calendars, func(i, j int) bool {
+		return strings.ToLower(calendars[i].Name) < strings.ToLower(calendars[j].Name)
+	})
+	return calendars, nil
+}
+
+// GetBusinessCalendar obtiene un calendario de atención por ID
+func (s *Store) GetBusinessCalendar(id string) (*models.BusinessCalendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, calendar := range s.BusinessCalendars {
+		if calendar.ID == id {
+			calendarCopy := copyBusinessCalendar(calendar)
+			return &calendarCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("calendario con ID %s no encontrado", id)
+}
+
+// CreateBusinessCalendar agrega un nuevo calendario de atención
+func (s *Store) CreateBusinessCalendar(calendar models.BusinessCalendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if calendar.ID == "" {
+		calendar.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if calendar.CreatedAt.IsZero() {
+		calendar.CreatedAt = now
+	}
+	calendar.UpdatedAt = now
+
+	s.BusinessCalendars = append(s.BusinessCalendars, copyBusinessCalendar(calendar))
+	return writeJSONFile(s.BusinessCalendarsFile, s.BusinessCalendars)
+}
+
+// UpdateBusinessCalendar actualiza un calendario de atención existente
+func (s *Store) UpdateBusinessCalendar(calendar models.BusinessCalendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.BusinessCalendars {
+		if existing.ID == calendar.ID {
+			calendar.CreatedAt = existing.CreatedAt
+			calendar.UpdatedAt = time.Now()
+			s.BusinessCalendars[i] = copyBusinessCalendar(calendar)
+			return writeJSONFile(s.BusinessCalendarsFile, s.BusinessCalendars)
+		}
+	}
+
+	return fmt.Errorf("calendario con ID %s no encontrado", calendar.ID)
+}
+
+// DeleteBusinessCalendar elimina un calendario. Los equipos que lo usaban pasan al
+// calendario por defecto, como hace la clave foránea en PostgreSQL.
+func (s *Store) DeleteBusinessCalendar(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, calendar := range s.BusinessCalendars {
+		if calendar.ID == id {
+			s.BusinessCalendars = append(s.BusinessCalendars[:i], s.BusinessCalendars[i+1:]...)
+			if err := writeJSONFile(s.BusinessCalendarsFile, s.BusinessCalendars); err != nil {
+				return err
+			}
+
+			changed := false
+			for j := range s.Teams {
+				if s.Teams[j].CalendarID == id {
+					s.Teams[j].CalendarID = ""
+					changed = true
+				}
+			}
+			if changed {
+				return writeJSONFile(s.TeamsFile, s.Teams)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("calendario con ID %s no encontrado", id)
+}
+
+// copyBusinessCalendar evita compartir los turnos, festivos y widgets con el almacén
+func copyBusinessCalendar(calendar models.BusinessCalendar) models.BusinessCalendar {
+	calendar.Hours = append([]models.WorkingShift{}, calendar.Hours...)
+	calendar.Holidays = append([]models.Holiday{}, calendar.Holidays...)
+	calendar.WidgetIDs = append([]string(nil), calendar.WidgetIDs...)
+	return calendar
+}
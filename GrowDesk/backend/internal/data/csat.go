@@ -0,0 +1,120 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// GetCSATSurveys devuelve todas las encuestas de satisfacción
+func (s *Store) GetCSATSurveys() ([]models.CSATSurvey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	surveys := make([]models.CSATSurvey, len(s.CSATSurveys))
+	copy(surveys, s.CSATSurveys)
+	return surveys, nil
+}
+
+// GetCSATSurvey obtiene una encuesta por su ID
+func (s *Store) GetCSATSurvey(id string) (*models.CSATSurvey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, survey := range s.CSATSurveys {
+		if survey.ID == id {
+			found := survey
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("encuesta con ID %s no encontrada", id)
+}
+
+// GetCSATSurveyByTicket obtiene la encuesta más reciente de un ticket
+func (s *Store) GetCSATSurveyByTicket(ticketID string) (*models.CSATSurvey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *models.CSATSurvey
+	for i, survey := range s.CSATSurveys {
+		if survey.TicketID == ticketID && (latest == nil || survey.SentAt.After(latest.SentAt)) {
+			latest = &s.CSATSurveys[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("el ticket %s no tiene encuesta", ticketID)
+	}
+	found := *latest
+	return &found, nil
+}
+
+// CreateCSATSurvey guarda una encuesta nueva
+func (s *Store) CreateCSATSurvey(survey models.CSATSurvey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if survey.ID == "" {
+		survey.ID = uuid.New().String()
+	}
+	for _, existing := range s.CSATSurveys {
+		if existing.ID == survey.ID {
+			return fmt.Errorf("ya existe una encuesta con ID %s", survey.ID)
+		}
+	}
+
+	s.CSATSurveys = append(s.CSATSurveys, survey)
+	return writeJSONFile(s.CSATSurveysFile, s.CSATSurveys)
+}
+
+// UpdateCSATSurvey guarda la respuesta de una encuesta
+func (s *Store) UpdateCSATSurvey(survey models.CSATSurvey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.CSATSurveys {
+		if existing.ID == survey.ID {
+			s.CSATSurveys[i] = survey
+			return writeJSONFile(s.CSATSurveysFile, s.CSATSurveys)
+		}
+	}
+	return fmt.Errorf("encuesta con ID %s no encontrada", survey.ID)
+}
+
+// dropCSATSurveys elimina las encuestas de los tickets borrados. Se llama con el bloqueo tomado.
+func (s *Store) dropCSATSurveys(ticketIDs map[string]bool) error {
+	kept := make([]models.CSATSurvey, 0, len(s.CSATSurveys))
+	for _, survey := range s.CSATSurveys {
+		if !ticketIDs[survey.TicketID] {
+			kept = append(kept, survey)
+		}
+	}
+	if len(kept) == len(s.CSATSurveys) {
+		return nil
+	}
+	s.CSATSurveys = kept
+	return writeJSONFile(s.CSATSurveysFile, s.CSATSurveys)
+}
+
+// SendCSATSurvey envía la encuesta a los clientes conectados al ticket; los agentes no la reciben
+func (s *Store) SendCSATSurvey(ticketID string, invite models.WebSocketMessage) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	payload, err := json.Marshal(invite)
+	if err != nil {
+		logging.Errorf("Error al serializar la encuesta: %v", err)
+		return
+	}
+	for _, conn := range s.TicketConnections[ticketID] {
+		if conn.Socket == nil || conn.Agent {
+			continue
+		}
+		if err := conn.Socket.WriteMessage(websocket.TextMessage, payload); err != nil {
+			logging.Errorf("Error al enviar la encuesta por WebSocket: %v", err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetCustomFields devuelve los campos personalizados ordenados por entidad y posición
+func (s *Store) GetCustomFields() ([]models.CustomField, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fields := make([]models.CustomField, len(s.CustomFields))
+	for i, field := range s.CustomFields {
+		fields[i] = copyCustomField(field)
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].Entity != fields[j].Entity {
+			return fields[i].Entity > fields[j].Entity // "ticket" antes que "contact"
+		}
+		if fields[i].Position != fields[j].Position {
+			return fields[i].Position < fields[j].Position
+		}
+		return fields[i].CreatedAt.Before(fields[j].CreatedAt)
+	})
+	return fields, nil
+}
+
+// GetCustomField obtiene un campo personalizado por ID
+func (s *Store) GetCustomField(id string) (*models.CustomField, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, field := range s.CustomFields {
+		if field.ID == id {
+			fieldCopy := copyCustomField(field)
+			return &fieldCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("campo personalizado con ID %s no encontrado", id)
+}
+
+// CreateCustomField agrega un campo personalizado. La clave es única por entidad.
+func (s *Store) CreateCustomField(field models.CustomField) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.CustomFields {
+		if existing.Entity == field.Entity && existing.Key == field.Key {
+			return fmt.Errorf("ya existe un campo %q para %s", field.Key, field.Entity)
+		}
+	}
+	if field.ID == "" {
+		field.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if field.CreatedAt.IsZero() {
+		field.CreatedAt = now
+	}
+	field.UpdatedAt = now
+
+	s.CustomFields = append(s.CustomFields, copyCustomField(field))
+	return writeJSONFile(s.CustomFieldsFile, s.CustomFields)
+}
+
+// UpdateCustomField actualiza un campo personalizado
+func (s *Store) UpdateCustomField(field models.CustomField) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.CustomFields {
+		if existing.ID == field.ID {
+			field.CreatedAt = existing.CreatedAt
+			field.UpdatedAt = time.Now()
+			s.CustomFields[i] = copyCustomField(field)
+			return writeJSONFile(s.CustomFieldsFile, s.CustomFields)
+		}
+	}
+
+	return fmt.Errorf("campo personalizado con ID %s no encontrado", field.ID)
+}
+
+// DeleteCustomField elimina un campo personalizado. Los valores ya guardados en tickets
+// y contactos se conservan pero dejan de mostrarse y validarse.
+func (s *Store) DeleteCustomField(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, field := range s.CustomFields {
+		if field.ID == id {
+			s.CustomFields = append(s.CustomFields[:i], s.CustomFields[i+1:]...)
+			return writeJSONFile(s.CustomFieldsFile, s.CustomFields)
+		}
+	}
+
+	return fmt.Errorf("campo personalizado con ID %s no encontrado", id)
+}
+
+// GetContacts devuelve los contactos ordenados por email
+func (s *Store) GetContacts() ([]models.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	contacts := make([]models.Contact, len(s.Contacts))
+	for i, contact := range s.Contacts {
+		contacts[i] = copyContact(contact)
+	}
+	sort.SliceStable(contacts, func(i, j int) bool { return contacts[i].Email < contacts[j].Email })
+	return contacts, nil
+}
+
+// GetContact obtiene un contacto por email (sin distinguir mayúsculas)
+func (s *Store) GetContact(email string) (*models.Contact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, contact := range s.Contacts {
+		if strings.EqualFold(contact.Email, email) {
+			contactCopy := copyContact(contact)
+			return &contactCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("contacto %s no encontrado", email)
+}
+
+// SaveContact crea o reemplaza un contacto
+func (s *Store) SaveContact(contact models.Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact.Email = strings.ToLower(strings.TrimSpace(contact.Email))
+	if contact.Email == "" {
+		return fmt.Errorf("el email del contacto es obligatorio")
+	}
+	now := time.Now()
+	contact.UpdatedAt = now
+
+	for i, existing := range s.Contacts {
+		if existing.Email == contact.Email {
+			contact.CreatedAt = existing.CreatedAt
+			s.Contacts[i] = copyContact(contact)
+			return writeJSONFile(s.ContactsFile, s.Contacts)
+		}
+	}
+
+	if contact.CreatedAt.IsZero() {
+		contact.CreatedAt = now
+	}
+	s.Contacts = append(s.Contacts, copyContact(contact))
+	return writeJSONFile(s.ContactsFile, s.Contacts)
+}
+
+func copyCustomField(field models.CustomField) models.CustomField {
+	field.Options = append([]string(nil), field.Options...)
+	return field
+}
+
+func copyContact(contact models.Contact) models.Contact {
+	if contact.CustomFields != nil {
+		values := make(map[string]interface{}, len(contact.CustomFields))
+		for key, value := range contact.CustomFields {
+			values[key] = value
+		}
+		contact.CustomFields = values
+	}
+	return contact
+}
@@ -1,6 +1,9 @@
 package data
 
 import (
+	"context"
+	"time"
+
 	"github.com/gorilla/websocket"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
 )
@@ -18,11 +21,60 @@ type DataStore interface {
 	// Métodos para tickets
 	GetTickets() ([]models.Ticket, error)
 	GetTicket(id string) (*models.Ticket, error)
+	GetTicketByExternalID(externalID string) (*models.Ticket, error)
 	CreateTicket(ticket models.Ticket) error
 	UpdateTicket(ticket models.Ticket) error
+	UpdateTicketWithMessage(ticket models.Ticket, message models.Message) (*models.Message, error) // Actualiza el ticket y añade el mensaje de forma atómica
 	DeleteTicket(id string) error
+	UpdateTicketsBatch(tickets []models.Ticket, deleteIDs []string) error            // Actualiza y elimina varios tickets de forma atómica
+	MergeTickets(source, target models.Ticket) (int, error)                          // Mueve mensajes, historial y seguidores de source a target y guarda ambos
+	SplitTicket(newTicket models.Ticket, sourceID string, messageIDs []string) error // Crea newTicket con los mensajes indicados de sourceID
 	AddTicketMessage(ticketID string, message models.Message) error
 
+	// Métodos para relaciones entre tickets. GetTicketRelations devuelve las relaciones
+	// en las que participa el ticket, en cualquiera de los dos sentidos.
+	GetTicketRelations(ticketID string) ([]models.TicketRelation, error)
+	CreateTicketRelation(relation models.TicketRelation) error
+	DeleteTicketRelation(id string) error
+
+	// Métodos para seguidores de tickets (agentes y contactos en copia)
+	GetTicketWatchers(ticketID string) ([]models.TicketWatcher, error)
+	GetWatchedTickets(userID string) ([]models.TicketWatcher, error) // Tickets que sigue un usuario
+	AddTicketWatcher(watcher models.TicketWatcher) error
+	UpdateTicketWatcher(watcher models.TicketWatcher) error
+	RemoveTicketWatcher(id string) error
+
+	// Métodos para encuestas de satisfacción (CSAT)
+	GetCSATSurveys() ([]models.CSATSurvey, error)
+	GetCSATSurvey(id string) (*models.CSATSurvey, error)
+	GetCSATSurveyByTicket(ticketID string) (*models.CSATSurvey, error)
+	CreateCSATSurvey(survey models.CSATSurvey) error
+	UpdateCSATSurvey(survey models.CSATSurvey) error
+	SendCSATSurvey(ticketID string, invite models.WebSocketMessage) // Entrega la encuesta a los clientes del widget conectados
+
+	// Métodos para informes. PostgreSQL los calcula en SQL sobre una vista materializada;
+	// el almacén JSON, en memoria.
+	ReportVolume(query models.ReportQuery) ([]models.VolumeRow, error)
+	ReportFirstResponse(query models.ReportQuery) ([]models.DurationRow, error)
+	ReportResolution(query models.ReportQuery) ([]models.DurationRow, error)
+	ReportBacklog(query models.ReportQuery) ([]models.BacklogRow, error)
+	ReportAgentWorkload(query models.ReportQuery) ([]models.AgentWorkloadRow, error)
+	RefreshReports() (time.Time, error) // Recalcula los datos de los informes; devuelve su fecha
+	ReportsDataAsOf() time.Time
+
+	// Métodos para exportaciones programadas y su historial (GetReportExports con
+	// scheduleID vacío devuelve todas, de la más reciente a la más antigua)
+	GetReportSchedules() ([]models.ReportSchedule, error)
+	GetReportSchedule(id string) (*models.ReportSchedule, error)
+	CreateReportSchedule(schedule models.ReportSchedule) error
+	UpdateReportSchedule(schedule models.ReportSchedule) error
+	DeleteReportSchedule(id string) error
+	GetReportExports(scheduleID string) ([]models.ReportExport, error)
+	GetReportExport(id string) (*models.ReportExport, error)
+	CreateReportExport(export models.ReportExport) error
+	UpdateReportExport(export models.ReportExport) error
+	DeleteReportExport(id string) error
+
 	// Métodos para categorías
 	GetCategories() ([]models.Category, error)
 	GetCategory(id string) (*models.Category, error)
@@ -39,8 +91,104 @@ type DataStore interface {
 	DeleteFAQ(id int) error
 	ToggleFAQPublish(id int) error
 
-	// Métodos para WebSocket
-	AddWSConnection(ticketID string, conn *websocket.Conn) string
+	// Métodos para claves de servicio
+	GetServiceKeys() ([]models.ServiceKey, error)
+	GetServiceKeyByPrefix(prefix string) (*models.ServiceKey, error)
+	CreateServiceKey(key models.ServiceKey) error
+	RevokeServiceKey(id string) error
+	TouchServiceKey(id string) error
+
+	// Métodos para claves de API
+	GetAPIKeys() ([]models.APIKey, error)
+	GetAPIKeyByPrefix(prefix string) (*models.APIKey, error)
+	CreateAPIKey(key models.APIKey) error
+	RevokeAPIKey(id string) error
+	TouchAPIKey(id string) error
+
+	// Métodos para reglas de enrutamiento
+	GetRoutingRules() ([]models.RoutingRule, error)
+	GetRoutingRule(id string) (*models.RoutingRule, error)
+	CreateRoutingRule(rule models.RoutingRule) error
+	UpdateRoutingRule(rule models.RoutingRule) error
+	DeleteRoutingRule(id string) error
+
+	// Métodos para políticas de asignación automática
+	GetAssignmentPolicies() ([]models.AssignmentPolicy, error)
+	GetAssignmentPolicy(id string) (*models.AssignmentPolicy, error)
+	CreateAssignmentPolicy(policy models.AssignmentPolicy) error
+	UpdateAssignmentPolicy(policy models.AssignmentPolicy) error
+	DeleteAssignmentPolicy(id string) error
+
+	// Métodos para equipos
+	GetTeams() ([]models.Team, error)
+	GetTeam(id string) (*models.Team, error)
+	CreateTeam(team models.Team) error
+	UpdateTeam(team models.Team) error
+	DeleteTeam(id string) error
+
+	// Métodos para macros (respuestas guardadas con acciones)
+	GetMacros() ([]models.Macro, error)
+	GetMacro(id string) (*models.Macro, error)
+	CreateMacro(macro models.Macro) error
+	UpdateMacro(macro models.Macro) error
+	DeleteMacro(id string) error
+	RecordMacroUsage(id string, usedAt time.Time) error
+
+	// Métodos para etiquetas. Renombrar o eliminar una etiqueta actualiza los tickets.
+	GetTags() ([]models.Tag, error)
+	GetTag(id string) (*models.Tag, error)
+	CreateTag(tag models.Tag) error
+	UpdateTag(tag models.Tag) error
+	DeleteTag(id string) error
+
+	// Métodos para campos personalizados
+	GetCustomFields() ([]models.CustomField, error)
+	GetCustomField(id string) (*models.CustomField, error)
+	CreateCustomField(field models.CustomField) error
+	UpdateCustomField(field models.CustomField) error
+	DeleteCustomField(id string) error
+
+	// Métodos para contactos (clientes identificados por email)
+	GetContacts() ([]models.Contact, error)
+	GetContact(email string) (*models.Contact, error)
+	SaveContact(contact models.Contact) error
+
+	// Métodos para calendarios de atención
+	GetBusinessCalendars() ([]models.BusinessCalendar, error)
+	GetBusinessCalendar(id string) (*models.BusinessCalendar, error)
+	CreateBusinessCalendar(calendar models.BusinessCalendar) error
+	UpdateBusinessCalendar(calendar models.BusinessCalendar) error
+	DeleteBusinessCalendar(id string) error
+
+	// Métodos para el historial de actividad (auditoría)
+	CreateActivity(activity models.Activity) error
+	GetActivities(targetID string) ([]models.Activity, error)
+
+	// Métodos para notificaciones de usuario (p. ej. menciones en notas internas)
+	CreateNotification(notification models.Notification) error
+	GetNotifications(userID string, unreadOnly bool) ([]models.Notification, error)
+	MarkNotificationRead(userID, id string) error
+	MarkAllNotificationsRead(userID string) (int, error)
+
+	// Métodos para WebSocket. Las conexiones de agentes (agent=true) son las únicas que
+	// reciben las notas internas.
+	AddWSConnection(ticketID string, conn *websocket.Conn, agent, notesOnly bool) string // notesOnly: canal privado de notas internas
 	RemoveWSConnection(ticketID, connectionID string)
 	BroadcastMessage(ticketID string, message models.Message)
+	RedirectWSConnections(fromTicketID, toTicketID string) int // Avisa a los suscriptores de fromTicketID y cierra sus conexiones
+}
+
+// ContextStore lo implementan los almacenes que pueden asociar sus consultas a una
+// solicitud, para que aparezcan en su traza
+type ContextStore interface {
+	WithContext(ctx context.Context) DataStore
+}
+
+// WithContext devuelve la vista de store asociada a ctx, o store si no lo admite. La vista
+// no hereda la cancelación de ctx: lo que sigue en segundo plano tras responder no debe fallar.
+func WithContext(store DataStore, ctx context.Context) DataStore {
+	if scoped, ok := store.(ContextStore); ok {
+		return scoped.WithContext(context.WithoutCancel(ctx))
+	}
+	return store
 }
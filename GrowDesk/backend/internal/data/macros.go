@@ -0,0 +1,116 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetMacros devuelve todas las macros ordenadas por nombre
+func (s *Store) GetMacros() ([]models.Macro, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	macros := make([]models.Macro, len(s.Macros))
+	for i, macro := range s.Macros {
+		macros[i] = copyMacro(macro)
+	}
+	sort.SliceStable(macros, func(i, j int) bool {
+		return strings.ToLower(macros[i].Name) < strings.ToLower(macros[j].Name)
+	})
+	return macros, nil
+}
+
+// GetMacro obtiene una macro por ID
+func (s *Store) GetMacro(id string) (*models.Macro, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, macro := range s.Macros {
+		if macro.ID == id {
+			macroCopy := copyMacro(macro)
+			return &macroCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("macro con ID %s no encontrada", id)
+}
+
+// CreateMacro agrega una nueva macro
+func (s *Store) CreateMacro(macro models.Macro) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if macro.ID == "" {
+		macro.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if macro.CreatedAt.IsZero() {
+		macro.CreatedAt = now
+	}
+	macro.UpdatedAt = now
+
+	s.Macros = append(s.Macros, copyMacro(macro))
+	return writeJSONFile(s.MacrosFile, s.Macros)
+}
+
+// UpdateMacro actualiza una macro existente. Las estadísticas de uso sólo cambian
+// con RecordMacroUsage.
+func (s *Store) UpdateMacro(macro models.Macro) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Macros {
+		if existing.ID == macro.ID {
+			macro.CreatedAt = existing.CreatedAt
+			macro.UsageCount = existing.UsageCount
+			macro.LastUsedAt = existing.LastUsedAt
+			macro.UpdatedAt = time.Now()
+			s.Macros[i] = copyMacro(macro)
+			return writeJSONFile(s.MacrosFile, s.Macros)
+		}
+	}
+
+	return fmt.Errorf("macro con ID %s no encontrada", macro.ID)
+}
+
+// DeleteMacro elimina una macro
+func (s *Store) DeleteMacro(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, macro := range s.Macros {
+		if macro.ID == id {
+			s.Macros = append(s.Macros[:i], s.Macros[i+1:]...)
+			return writeJSONFile(s.MacrosFile, s.Macros)
+		}
+	}
+
+	return fmt.Errorf("macro con ID %s no encontrada", id)
+}
+
+// RecordMacroUsage suma un uso a la macro
+func (s *Store) RecordMacroUsage(id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.Macros {
+		if s.Macros[i].ID == id {
+			s.Macros[i].UsageCount++
+			s.Macros[i].LastUsedAt = &usedAt
+			return writeJSONFile(s.MacrosFile, s.Macros)
+		}
+	}
+
+	return fmt.Errorf("macro con ID %s no encontrada", id)
+}
+
+// copyMacro evita compartir las etiquetas de las acciones con el almacén
+func copyMacro(macro models.Macro) models.Macro {
+	macro.Actions.AddTags = append([]string(nil), macro.Actions.AddTags...)
+	return macro
+}
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// maxNotifications limita las notificaciones guardadas en el archivo; se descartan las más antiguas
+const maxNotifications = 5000
+
+// CreateNotification guarda una notificación para un usuario
+func (s *Store) CreateNotification(notification models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if notification.ID == "" {
+		notification.ID = uuid.New().String()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	s.Notifications = append(s.Notifications, notification)
+	if len(s.Notifications) > maxNotifications {
+		s.Notifications = s.Notifications[len(s.Notifications)-maxNotifications:]
+	}
+	return writeJSONFile(s.NotificationsFile, s.Notifications)
+}
+
+// GetNotifications devuelve las notificaciones de un usuario, las más recientes primero
+func (s *Store) GetNotifications(userID string, unreadOnly bool) ([]models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notifications := make([]models.Notification, 0)
+	for _, notification := range s.Notifications {
+		if notification.UserID == userID && (!unreadOnly || !notification.Read) {
+			notifications = append(notifications, notification)
+		}
+	}
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	return notifications, nil
+}
+
+// MarkNotificationRead marca como leída una notificación del usuario
+func (s *Store) MarkNotificationRead(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, notification := range s.Notifications {
+		if notification.ID == id && notification.UserID == userID {
+			s.Notifications[i].Read = true
+			return writeJSONFile(s.NotificationsFile, s.Notifications)
+		}
+	}
+
+	return fmt.Errorf("notificación con ID %s no encontrada", id)
+}
+
+// MarkAllNotificationsRead marca como leídas todas las notificaciones del usuario
+func (s *Store) MarkAllNotificationsRead(userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := 0
+	for i, notification := range s.Notifications {
+		if notification.UserID == userID && !notification.Read {
+			s.Notifications[i].Read = true
+			marked++
+		}
+	}
+	if marked == 0 {
+		return 0, nil
+	}
+	return marked, writeJSONFile(s.NotificationsFile, s.Notifications)
+}
@@ -0,0 +1,165 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetReportSchedules devuelve todas las exportaciones programadas
+func (s *Store) GetReportSchedules() ([]models.ReportSchedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schedules := make([]models.ReportSchedule, len(s.ReportSchedules))
+	copy(schedules, s.ReportSchedules)
+	return schedules, nil
+}
+
+// GetReportSchedule obtiene una exportación programada por su ID
+func (s *Store) GetReportSchedule(id string) (*models.ReportSchedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, schedule := range s.ReportSchedules {
+		if schedule.ID == id {
+			found := schedule
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("exportación programada con ID %s no encontrada", id)
+}
+
+// CreateReportSchedule guarda una exportación programada nueva
+func (s *Store) CreateReportSchedule(schedule models.ReportSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	for _, existing := range s.ReportSchedules {
+		if existing.ID == schedule.ID {
+			return fmt.Errorf("ya existe una exportación programada con ID %s", schedule.ID)
+		}
+	}
+
+	s.ReportSchedules = append(s.ReportSchedules, schedule)
+	return writeJSONFile(s.ReportSchedulesFile, s.ReportSchedules)
+}
+
+// UpdateReportSchedule guarda los cambios de una exportación programada
+func (s *Store) UpdateReportSchedule(schedule models.ReportSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.ReportSchedules {
+		if existing.ID == schedule.ID {
+			s.ReportSchedules[i] = schedule
+			return writeJSONFile(s.ReportSchedulesFile, s.ReportSchedules)
+		}
+	}
+	return fmt.Errorf("exportación programada con ID %s no encontrada", schedule.ID)
+}
+
+// DeleteReportSchedule elimina una exportación programada. Sus ejecuciones se conservan
+// en el historial sin programación.
+func (s *Store) DeleteReportSchedule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, schedule := range s.ReportSchedules {
+		if schedule.ID != id {
+			continue
+		}
+		s.ReportSchedules = append(s.ReportSchedules[:i], s.ReportSchedules[i+1:]...)
+		if err := writeJSONFile(s.ReportSchedulesFile, s.ReportSchedules); err != nil {
+			return err
+		}
+		for j := range s.ReportExports {
+			if s.ReportExports[j].ScheduleID == id {
+				s.ReportExports[j].ScheduleID = ""
+			}
+		}
+		return writeJSONFile(s.ReportExportsFile, s.ReportExports)
+	}
+	return fmt.Errorf("exportación programada con ID %s no encontrada", id)
+}
+
+// GetReportExports devuelve las ejecuciones de una exportación programada, o todas si
+// scheduleID está vacío, de la más reciente a la más antigua
+func (s *Store) GetReportExports(scheduleID string) ([]models.ReportExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exports := make([]models.ReportExport, 0)
+	for _, export := range s.ReportExports {
+		if scheduleID == "" || export.ScheduleID == scheduleID {
+			exports = append(exports, export)
+		}
+	}
+	sort.SliceStable(exports, func(i, j int) bool { return exports[i].StartedAt.After(exports[j].StartedAt) })
+	return exports, nil
+}
+
+// GetReportExport obtiene una ejecución por su ID
+func (s *Store) GetReportExport(id string) (*models.ReportExport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, export := range s.ReportExports {
+		if export.ID == id {
+			found := export
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("exportación con ID %s no encontrada", id)
+}
+
+// CreateReportExport guarda una ejecución nueva
+func (s *Store) CreateReportExport(export models.ReportExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if export.ID == "" {
+		export.ID = uuid.New().String()
+	}
+	for _, existing := range s.ReportExports {
+		if existing.ID == export.ID {
+			return fmt.Errorf("ya existe una exportación con ID %s", export.ID)
+		}
+	}
+
+	s.ReportExports = append(s.ReportExports, export)
+	return writeJSONFile(s.ReportExportsFile, s.ReportExports)
+}
+
+// UpdateReportExport guarda el resultado de una ejecución
+func (s *Store) UpdateReportExport(export models.ReportExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.ReportExports {
+		if existing.ID == export.ID {
+			s.ReportExports[i] = export
+			return writeJSONFile(s.ReportExportsFile, s.ReportExports)
+		}
+	}
+	return fmt.Errorf("exportación con ID %s no encontrada", export.ID)
+}
+
+// DeleteReportExport elimina una ejecución del historial
+func (s *Store) DeleteReportExport(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, export := range s.ReportExports {
+		if export.ID == id {
+			s.ReportExports = append(s.ReportExports[:i], s.ReportExports[i+1:]...)
+			return writeJSONFile(s.ReportExportsFile, s.ReportExports)
+		}
+	}
+	return fmt.Errorf("exportación con ID %s no encontrada", id)
+}
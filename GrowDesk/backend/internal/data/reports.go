@@ -0,0 +1,86 @@
+package data
+
+import (
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+)
+
+// stampResolved fija ResolvedAt cuando el ticket pasa a resolved o closed, conserva la
+// fecha anterior si ya lo estaba y la borra al reabrirlo
+func stampResolved(ticket *models.Ticket, previous *time.Time, now time.Time) {
+	if !reports.Resolved(ticket.Status) {
+		ticket.ResolvedAt = nil
+		return
+	}
+	if ticket.ResolvedAt != nil {
+		return
+	}
+	if previous != nil {
+		ticket.ResolvedAt = previous
+		return
+	}
+	resolved := now
+	ticket.ResolvedAt = &resolved
+}
+
+// reportFacts extrae de los tickets (sin los fusionados) los datos de los informes y,
+// si se piden, las respuestas de los agentes
+func (s *Store) reportFacts(withReplies bool) ([]reports.Fact, []reports.Reply) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	facts := make([]reports.Fact, 0, len(s.Tickets))
+	var replies []reports.Reply
+	for _, ticket := range s.Tickets {
+		if ticket.MergedInto != "" {
+			continue
+		}
+		facts = append(facts, reports.NewFact(ticket))
+		if withReplies {
+			replies = append(replies, reports.Replies(ticket)...)
+		}
+	}
+	return facts, replies
+}
+
+// ReportVolume cuenta los tickets creados y resueltos en el periodo
+func (s *Store) ReportVolume(query models.ReportQuery) ([]models.VolumeRow, error) {
+	facts, _ := s.reportFacts(false)
+	return reports.Volume(facts, query), nil
+}
+
+// ReportFirstResponse mide el tiempo hasta la primera respuesta
+func (s *Store) ReportFirstResponse(query models.ReportQuery) ([]models.DurationRow, error) {
+	facts, _ := s.reportFacts(false)
+	return reports.FirstResponse(facts, query), nil
+}
+
+// ReportResolution mide el tiempo de resolución
+func (s *Store) ReportResolution(query models.ReportQuery) ([]models.DurationRow, error) {
+	facts, _ := s.reportFacts(false)
+	return reports.Resolution(facts, query), nil
+}
+
+// ReportBacklog cuenta los tickets abiertos
+func (s *Store) ReportBacklog(query models.ReportQuery) ([]models.BacklogRow, error) {
+	facts, _ := s.reportFacts(false)
+	return reports.Backlog(facts, query, time.Now()), nil
+}
+
+// ReportAgentWorkload resume la carga de trabajo de cada agente
+func (s *Store) ReportAgentWorkload(query models.ReportQuery) ([]models.AgentWorkloadRow, error) {
+	facts, replies := s.reportFacts(true)
+	return reports.AgentWorkload(facts, replies, query), nil
+}
+
+// RefreshReports no hace nada: el almacén JSON calcula los informes en cada consulta
+func (s *Store) RefreshReports() (time.Time, error) {
+	return time.Now(), nil
+}
+
+// ReportsDataAsOf devuelve el momento actual: los informes siempre están al día
+func (s *Store) ReportsDataAsOf() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/routing"
+)
+
+// GetRoutingRules devuelve las reglas de enrutamiento ordenadas por posición
+func (s *Store) GetRoutingRules() ([]models.RoutingRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]models.RoutingRule, len(s.RoutingRules))
+	copy(rules, s.RoutingRules)
+	routing.Sort(rules)
+	return rules, nil
+}
+
+// GetRoutingRule obtiene una regla de enrutamiento por ID
+func (s *Store) GetRoutingRule(id string) (*models.RoutingRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rule := range s.RoutingRules {
+		if rule.ID == id {
+			ruleCopy := rule
+			return &ruleCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("regla de enrutamiento con ID %s no encontrada", id)
+}
+
+// CreateRoutingRule agrega una nueva regla de enrutamiento
+func (s *Store) CreateRoutingRule(rule models.RoutingRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = now
+	}
+	rule.UpdatedAt = now
+
+	s.RoutingRules = append(s.RoutingRules, rule)
+	return writeJSONFile(s.RoutingRulesFile, s.RoutingRules)
+}
+
+// UpdateRoutingRule actualiza una regla de enrutamiento existente
+func (s *Store) UpdateRoutingRule(rule models.RoutingRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.RoutingRules {
+		if existing.ID == rule.ID {
+			rule.CreatedAt = existing.CreatedAt
+			rule.UpdatedAt = time.Now()
+			s.RoutingRules[i] = rule
+			return writeJSONFile(s.RoutingRulesFile, s.RoutingRules)
+		}
+	}
+
+	return fmt.Errorf("regla de enrutamiento con ID %s no encontrada", rule.ID)
+}
+
+// DeleteRoutingRule elimina una regla de enrutamiento
+func (s *Store) DeleteRoutingRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, rule := range s.RoutingRules {
+		if rule.ID == id {
+			s.RoutingRules = append(s.RoutingRules[:i], s.RoutingRules[i+1:]...)
+			return writeJSONFile(s.RoutingRulesFile, s.RoutingRules)
+		}
+	}
+
+	return fmt.Errorf("regla de enrutamiento con ID %s no encontrada", id)
+}
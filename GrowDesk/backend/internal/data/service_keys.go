@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetServiceKeys devuelve todas las claves de servicio
+func (s *Store) GetServiceKeys() ([]models.ServiceKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]models.ServiceKey, len(s.ServiceKeys))
+	copy(keys, s.ServiceKeys)
+	return keys, nil
+}
+
+// GetServiceKeyByPrefix busca una clave de servicio por su parte pública
+func (s *Store) GetServiceKeyByPrefix(prefix string) (*models.ServiceKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.ServiceKeys {
+		if key.KeyPrefix == prefix {
+			keyCopy := key
+			return &keyCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("clave de servicio %s no encontrada", prefix)
+}
+
+// CreateServiceKey agrega una nueva clave de servicio
+func (s *Store) CreateServiceKey(key models.ServiceKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	s.ServiceKeys = append(s.ServiceKeys, key)
+	return writeJSONFile(s.ServiceKeysFile, s.ServiceKeys)
+}
+
+// RevokeServiceKey revoca una clave de servicio
+func (s *Store) RevokeServiceKey(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.ServiceKeys {
+		if key.ID == id {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				s.ServiceKeys[i].RevokedAt = &now
+			}
+			return writeJSONFile(s.ServiceKeysFile, s.ServiceKeys)
+		}
+	}
+
+	return fmt.Errorf("clave de servicio con ID %s no encontrada", id)
+}
+
+// TouchServiceKey registra el último uso de una clave de servicio
+func (s *Store) TouchServiceKey(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.ServiceKeys {
+		if key.ID == id {
+			now := time.Now()
+			s.ServiceKeys[i].LastUsedAt = &now
+			return writeJSONFile(s.ServiceKeysFile, s.ServiceKeys)
+		}
+	}
+
+	return fmt.Errorf("clave de servicio con ID %s no encontrada", id)
+}
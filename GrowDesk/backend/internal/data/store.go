@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,14 +13,34 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
 )
 
 // Store representa el almacén de datos en memoria
 type Store struct {
-	Tickets    []models.Ticket
-	Users      []models.User
-	Categories []models.Category
-	FAQs       []models.FAQ
+	Tickets     []models.Ticket
+	Users       []models.User
+	Categories  []models.Category
+	FAQs        []models.FAQ
+	ServiceKeys []models.ServiceKey
+	APIKeys     []models.APIKey
+
+	RoutingRules       []models.RoutingRule
+	AssignmentPolicies []models.AssignmentPolicy
+	Activities         []models.Activity
+	Teams              []models.Team
+	BusinessCalendars  []models.BusinessCalendar
+	Macros             []models.Macro
+	Tags               []models.Tag
+	CustomFields       []models.CustomField
+	Contacts           []models.Contact
+	TicketRelations    []models.TicketRelation
+	Notifications      []models.Notification
+	TicketWatchers     []models.TicketWatcher
+	CSATSurveys        []models.CSATSurvey
+	ReportSchedules    []models.ReportSchedule
+	ReportExports      []models.ReportExport
 
 	// Conexiones WebSocket por ID de ticket
 	// Map de ID de ticket a lista de conexiones
@@ -29,10 +50,28 @@ type Store struct {
 	mu sync.RWMutex // Para seguridad de hilos
 
 	// Rutas de archivo para persistencia de datos
-	TicketsFile    string
-	UsersFile      string
-	CategoriesFile string
-	FAQsFile       string
+	TicketsFile     string
+	UsersFile       string
+	CategoriesFile  string
+	FAQsFile        string
+	ServiceKeysFile string
+	APIKeysFile     string
+
+	RoutingRulesFile       string
+	AssignmentPoliciesFile string
+	ActivitiesFile         string
+	TeamsFile              string
+	BusinessCalendarsFile  string
+	MacrosFile             string
+	TagsFile               string
+	CustomFieldsFile       string
+	ContactsFile           string
+	TicketRelationsFile    string
+	NotificationsFile      string
+	TicketWatchersFile     string
+	CSATSurveysFile        string
+	ReportSchedulesFile    string
+	ReportExportsFile      string
 }
 
 // WebSocketConnection representa una conexión WebSocket
@@ -40,6 +79,17 @@ type WebSocketConnection struct {
 	ID          string
 	Socket      *websocket.Conn
 	ConnectedAt time.Time
+	Agent       bool // Sólo las conexiones de agentes reciben notas internas
+	NotesOnly   bool // Canal privado de notas: no recibe los mensajes públicos
+}
+
+// receives indica si el mensaje debe enviarse por la conexión: las notas internas sólo a
+// los agentes y los mensajes públicos a todas salvo al canal de notas
+func (c WebSocketConnection) receives(message models.Message) bool {
+	if message.IsInternal {
+		return c.Agent
+	}
+	return !c.NotesOnly
 }
 
 // NewStore crea un nuevo almacén de datos y carga datos iniciales
@@ -58,6 +108,23 @@ func NewStore(dataDir string) *Store {
 		UsersFile:              filepath.Join(dataDir, "users.json"),
 		CategoriesFile:         filepath.Join(dataDir, "categories.json"),
 		FAQsFile:               filepath.Join(dataDir, "faqs.json"),
+		ServiceKeysFile:        filepath.Join(dataDir, "service_keys.json"),
+		APIKeysFile:            filepath.Join(dataDir, "api_keys.json"),
+		RoutingRulesFile:       filepath.Join(dataDir, "routing_rules.json"),
+		AssignmentPoliciesFile: filepath.Join(dataDir, "assignment_policies.json"),
+		ActivitiesFile:         filepath.Join(dataDir, "activities.json"),
+		TeamsFile:              filepath.Join(dataDir, "teams.json"),
+		BusinessCalendarsFile:  filepath.Join(dataDir, "business_calendars.json"),
+		MacrosFile:             filepath.Join(dataDir, "macros.json"),
+		TagsFile:               filepath.Join(dataDir, "tags.json"),
+		CustomFieldsFile:       filepath.Join(dataDir, "custom_fields.json"),
+		ContactsFile:           filepath.Join(dataDir, "contacts.json"),
+		TicketRelationsFile:    filepath.Join(dataDir, "ticket_relations.json"),
+		NotificationsFile:      filepath.Join(dataDir, "notifications.json"),
+		TicketWatchersFile:     filepath.Join(dataDir, "ticket_watchers.json"),
+		CSATSurveysFile:        filepath.Join(dataDir, "csat_surveys.json"),
+		ReportSchedulesFile:    filepath.Join(dataDir, "report_schedules.json"),
+		ReportExportsFile:      filepath.Join(dataDir, "report_exports.json"),
 	}
 
 	// Cargar datos desde archivos o inicializar con valores por defecto
@@ -65,6 +132,23 @@ func NewStore(dataDir string) *Store {
 	store.loadUsers()
 	store.loadCategories()
 	store.loadFAQs()
+	loadJSONFile(store.ServiceKeysFile, &store.ServiceKeys)
+	loadJSONFile(store.APIKeysFile, &store.APIKeys)
+	loadJSONFile(store.RoutingRulesFile, &store.RoutingRules)
+	loadJSONFile(store.AssignmentPoliciesFile, &store.AssignmentPolicies)
+	loadJSONFile(store.ActivitiesFile, &store.Activities)
+	loadJSONFile(store.TeamsFile, &store.Teams)
+	loadJSONFile(store.BusinessCalendarsFile, &store.BusinessCalendars)
+	loadJSONFile(store.MacrosFile, &store.Macros)
+	loadJSONFile(store.TagsFile, &store.Tags)
+	loadJSONFile(store.CustomFieldsFile, &store.CustomFields)
+	loadJSONFile(store.ContactsFile, &store.Contacts)
+	loadJSONFile(store.TicketRelationsFile, &store.TicketRelations)
+	loadJSONFile(store.NotificationsFile, &store.Notifications)
+	loadJSONFile(store.TicketWatchersFile, &store.TicketWatchers)
+	loadJSONFile(store.CSATSurveysFile, &store.CSATSurveys)
+	loadJSONFile(store.ReportSchedulesFile, &store.ReportSchedules)
+	loadJSONFile(store.ReportExportsFile, &store.ReportExports)
 
 	return store
 }
@@ -76,18 +160,18 @@ func (s *Store) loadTickets() {
 
 	data, err := os.ReadFile(s.TicketsFile)
 	if err != nil {
-		fmt.Printf("No se encontró archivo de tickets, iniciando con lista vacía: %v\n", err)
+		logging.Infof("No se encontró archivo de tickets, iniciando con lista vacía: %v", err)
 		s.Tickets = make([]models.Ticket, 0)
 		return
 	}
 
 	if err := json.Unmarshal(data, &s.Tickets); err != nil {
-		fmt.Printf("Error al analizar archivo de tickets, iniciando con lista vacía: %v\n", err)
+		logging.Errorf("Error al analizar archivo de tickets, iniciando con lista vacía: %v", err)
 		s.Tickets = make([]models.Ticket, 0)
 		return
 	}
 
-	fmt.Printf("Cargados %d tickets desde archivo\n", len(s.Tickets))
+	logging.Infof("Cargados %d tickets desde archivo", len(s.Tickets))
 }
 
 // LoadUsers carga usuarios desde archivo o inicializa con usuarios por defecto
@@ -97,18 +181,18 @@ func (s *Store) loadUsers() {
 
 	data, err := os.ReadFile(s.UsersFile)
 	if err != nil {
-		fmt.Println("No se encontró archivo de usuarios, inicializando con usuarios por defecto")
+		logging.Infof("No se encontró archivo de usuarios, inicializando con usuarios por defecto")
 		s.initializeDefaultUsers()
 		return
 	}
 
 	if err := json.Unmarshal(data, &s.Users); err != nil {
-		fmt.Printf("Error al analizar archivo de usuarios, inicializando con usuarios por defecto: %v\n", err)
+		logging.Errorf("Error al analizar archivo de usuarios, inicializando con usuarios por defecto: %v", err)
 		s.initializeDefaultUsers()
 		return
 	}
 
-	fmt.Printf("Cargados %d usuarios desde archivo\n", len(s.Users))
+	logging.Infof("Cargados %d usuarios desde archivo", len(s.Users))
 }
 
 // LoadCategories carga categorías desde archivo o inicializa con valores por defecto
@@ -116,7 +200,7 @@ func (s *Store) loadCategories() {
 	// Leemos el archivo fuera del mutex para evitar deadlocks
 	data, err := os.ReadFile(s.CategoriesFile)
 	if err != nil {
-		fmt.Println("No se encontró archivo de categorías, inicializando con categorías por defecto")
+		logging.Infof("No se encontró archivo de categorías, inicializando con categorías por defecto")
 		now := time.Now()
 		defaultCategories := []models.Category{
 			{
@@ -159,23 +243,23 @@ func (s *Store) loadCategories() {
 		// Guardar directamente a archivo sin usar SaveCategories
 		dataToSave, err := json.MarshalIndent(defaultCategories, "", "  ")
 		if err != nil {
-			fmt.Printf("Error al serializar categorías: %v\n", err)
+			logging.Errorf("Error al serializar categorías: %v", err)
 			return
 		}
 
 		if err := os.WriteFile(s.CategoriesFile, dataToSave, 0644); err != nil {
-			fmt.Printf("Error al escribir archivo de categorías: %v\n", err)
+			logging.Errorf("Error al escribir archivo de categorías: %v", err)
 			return
 		}
 
-		fmt.Printf("Guardadas %d categorías por defecto en archivo\n", len(defaultCategories))
+		logging.Infof("Guardadas %d categorías por defecto en archivo", len(defaultCategories))
 		return
 	}
 
 	// Si el archivo existe, lo deserializamos
 	var categories []models.Category
 	if err := json.Unmarshal(data, &categories); err != nil {
-		fmt.Printf("Error al analizar archivo de categorías, inicializando con valores por defecto: %v\n", err)
+		logging.Errorf("Error al analizar archivo de categorías, inicializando con valores por defecto: %v", err)
 		// Llamamos recursivamente para inicializar con valores por defecto
 		// Esta llamada es segura porque el archivo no se podrá leer en la próxima iteración
 		s.loadCategories()
@@ -187,7 +271,7 @@ func (s *Store) loadCategories() {
 	s.Categories = categories
 	s.mu.Unlock()
 
-	fmt.Printf("Cargados %d categorías desde archivo\n", len(categories))
+	logging.Infof("Cargados %d categorías desde archivo", len(categories))
 }
 
 // LoadFAQs carga FAQs desde archivo o inicializa con valores por defecto
@@ -226,7 +310,7 @@ func (s *Store) loadFAQs() error {
 				},
 			}
 			// Guardar las FAQs por defecto
-			return s.SaveFAQs()
+			return s.saveFAQs()
 		}
 		return fmt.Errorf("error al leer archivo de FAQs: %v", err)
 	}
@@ -256,7 +340,7 @@ func (s *Store) SaveTickets() error {
 		return fmt.Errorf("error al escribir archivo de tickets: %w", err)
 	}
 
-	fmt.Printf("Guardados %d tickets en archivo\n", len(tickets))
+	logging.Infof("Guardados %d tickets en archivo", len(tickets))
 	return nil
 }
 
@@ -276,7 +360,7 @@ func (s *Store) SaveUsers() error {
 		return fmt.Errorf("error al escribir archivo de usuarios: %w", err)
 	}
 
-	fmt.Printf("Guardados %d usuarios en archivo\n", len(users))
+	logging.Infof("Guardados %d usuarios en archivo", len(users))
 	return nil
 }
 
@@ -296,7 +380,7 @@ func (s *Store) SaveCategories() error {
 		return fmt.Errorf("error al escribir archivo de categorías: %w", err)
 	}
 
-	fmt.Printf("Guardados %d categorías en archivo\n", len(categories))
+	logging.Infof("Guardados %d categorías en archivo", len(categories))
 	return nil
 }
 
@@ -324,6 +408,43 @@ func (s *Store) SaveFAQs() error {
 	return nil
 }
 
+// saveTickets, saveUsers, saveCategories y saveFAQs escriben los datos en disco desde
+// métodos que ya tienen el mutex; llamar a los Save* desde ahí bloquearía el almacén.
+func (s *Store) saveTickets() error { return writeJSONFile(s.TicketsFile, s.Tickets) }
+
+func (s *Store) saveUsers() error { return writeJSONFile(s.UsersFile, s.Users) }
+
+func (s *Store) saveCategories() error { return writeJSONFile(s.CategoriesFile, s.Categories) }
+
+func (s *Store) saveFAQs() error { return writeJSONFile(s.FAQsFile, s.FAQs) }
+
+// loadJSONFile carga un archivo JSON en v. Si el archivo no existe, v queda sin cambios.
+func loadJSONFile(path string, v interface{}) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		logging.Errorf("Error al analizar archivo %s: %v", path, err)
+	}
+}
+
+// writeJSONFile serializa v en el archivo indicado.
+// A diferencia de los métodos Save*, no toma el mutex: el llamador ya debe tenerlo.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error al serializar %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error al escribir archivo %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
 // InitializeDefaultUsers inicializa el almacén con usuarios por defecto
 func (s *Store) initializeDefaultUsers() {
 	s.Users = []models.User{
@@ -360,7 +481,7 @@ func (s *Store) initializeDefaultUsers() {
 	}
 
 	// Guardar en archivo
-	s.SaveUsers()
+	s.saveUsers()
 }
 
 // InitializeDefaultFAQs inicializa el almacén con FAQs por defecto
@@ -424,7 +545,7 @@ func (s *Store) initializeDefaultFAQs() {
 	}
 
 	// Guardar en archivo
-	s.SaveFAQs()
+	s.saveFAQs()
 }
 
 // AddTicket agrega un nuevo ticket al almacén
@@ -433,11 +554,12 @@ func (s *Store) AddTicket(ticket models.Ticket) {
 	defer s.mu.Unlock()
 
 	if ticket.ID == "" {
-		ticket.ID = fmt.Sprintf("TICKET-%s", time.Now().Format("20060102-150405"))
+		ticket.ID = utils.GenerateTicketID(time.Now())
 	}
+	stampResolved(&ticket, nil, time.Now())
 
 	s.Tickets = append(s.Tickets, ticket)
-	s.SaveTickets()
+	s.saveTickets()
 }
 
 // GetTicket recupera un ticket por ID
@@ -454,6 +576,20 @@ func (s *Store) GetTicket(id string) (*models.Ticket, error) {
 	return nil, fmt.Errorf("Ticket no encontrado: %s", id)
 }
 
+// GetTicketByExternalID busca un ticket por el ID externo guardado en sus metadatos
+func (s *Store) GetTicketByExternalID(externalID string) (*models.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i, ticket := range s.Tickets {
+		if ticket.Metadata != nil && ticket.Metadata.ExternalID == externalID {
+			return &s.Tickets[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Ticket no encontrado para ID externo: %s", externalID)
+}
+
 // UpdateTicket actualiza un ticket existente
 func (s *Store) UpdateTicket(ticket models.Ticket) error {
 	s.mu.Lock()
@@ -468,16 +604,53 @@ func (s *Store) UpdateTicket(ticket models.Ticket) error {
 
 			// Asegurar que la fecha de actualización se establece
 			ticket.UpdatedAt = time.Now()
+			stampResolved(&ticket, existingTicket.ResolvedAt, ticket.UpdatedAt)
 
 			// Actualizar el ticket
 			s.Tickets[i] = ticket
-			return s.SaveTickets()
+			return s.saveTickets()
 		}
 	}
 
 	return fmt.Errorf("Ticket no encontrado: %s", ticket.ID)
 }
 
+// UpdateTicketWithMessage actualiza el ticket y le añade un mensaje bajo el mismo bloqueo,
+// de modo que ambos cambios se guardan juntos o no se guarda ninguno
+func (s *Store) UpdateTicketWithMessage(ticket models.Ticket, message models.Message) (*models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existingTicket := range s.Tickets {
+		if existingTicket.ID == ticket.ID {
+			if message.ID == "" {
+				message.ID = fmt.Sprintf("MSG-%s", uuid.New().String())
+			}
+			now := time.Now()
+			if message.Timestamp.IsZero() {
+				message.Timestamp = now
+			}
+			if message.CreatedAt.IsZero() {
+				message.CreatedAt = now
+			}
+
+			ticket.Messages = append(append([]models.Message{}, existingTicket.Messages...), message)
+			ticket.UpdatedAt = now
+			stampResolved(&ticket, existingTicket.ResolvedAt, now)
+
+			previous := s.Tickets[i]
+			s.Tickets[i] = ticket
+			if err := s.saveTickets(); err != nil {
+				s.Tickets[i] = previous
+				return nil, err
+			}
+			return &message, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Ticket no encontrado: %s", ticket.ID)
+}
+
 // AddMessageToTicket agrega un mensaje a un ticket
 func (s *Store) AddMessageToTicket(ticketID string, message models.Message) (*models.Message, error) {
 	s.mu.Lock()
@@ -497,7 +670,7 @@ func (s *Store) AddMessageToTicket(ticketID string, message models.Message) (*mo
 
 			s.Tickets[i].Messages = append(s.Tickets[i].Messages, message)
 			s.Tickets[i].UpdatedAt = time.Now()
-			s.SaveTickets()
+			s.saveTickets()
 			return &message, nil
 		}
 	}
@@ -542,7 +715,7 @@ func (s *Store) GetPublishedFAQs() []models.FAQ {
 	if len(s.FAQs) == 0 {
 		s.mu.RUnlock()
 		if err := s.loadFAQs(); err != nil {
-			fmt.Printf("Error al cargar FAQs: %v\n", err)
+			logging.Errorf("Error al cargar FAQs: %v", err)
 			return []models.FAQ{}
 		}
 		s.mu.RLock()
@@ -561,7 +734,7 @@ func (s *Store) GetPublishedFAQs() []models.FAQ {
 }
 
 // AddWSConnection agrega una conexión WebSocket para un ticket
-func (s *Store) AddWSConnection(ticketID string, conn *websocket.Conn) string {
+func (s *Store) AddWSConnection(ticketID string, conn *websocket.Conn, agent, notesOnly bool) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -578,9 +751,11 @@ func (s *Store) AddWSConnection(ticketID string, conn *websocket.Conn) string {
 		ID:          connectionID,
 		Socket:      conn,
 		ConnectedAt: time.Now(),
+		Agent:       agent,
+		NotesOnly:   notesOnly,
 	})
 
-	fmt.Printf("Añadido WebSocket conexión %s para ticket %s\n", connectionID, ticketID)
+	logging.Infof("Añadido WebSocket conexión %s para ticket %s", connectionID, ticketID)
 	return connectionID
 }
 
@@ -605,7 +780,7 @@ func (s *Store) RemoveWSConnection(ticketID, connectionID string) {
 			delete(s.TicketConnections, ticketID)
 		}
 
-		fmt.Printf("Eliminada WebSocket conexión %s para ticket %s\n", connectionID, ticketID)
+		logging.Infof("Eliminada WebSocket conexión %s para ticket %s", connectionID, ticketID)
 	}
 }
 
@@ -614,25 +789,27 @@ func (s *Store) BroadcastMessage(ticketID string, message models.Message) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fmt.Printf("Enviando mensaje a clientes para ticket %s\n", ticketID)
+	logging.Debugf("Enviando mensaje a clientes para ticket %s", ticketID)
 
 	// Preparar el mensaje para WebSocket
 	wsMessage := models.WebSocketMessage{
 		Type:     "new_message",
 		TicketID: ticketID,
 		Data: map[string]interface{}{
-			"id":        message.ID,
-			"content":   message.Content,
-			"isClient":  message.IsClient,
-			"timestamp": message.Timestamp,
-			"userName":  message.UserName,
+			"id":         message.ID,
+			"content":    message.Content,
+			"isClient":   message.IsClient,
+			"timestamp":  message.Timestamp,
+			"userName":   message.UserName,
+			"isInternal": message.IsInternal,
+			"mentions":   message.Mentions,
 		},
 	}
 
 	// Serializar el mensaje
 	messageData, err := json.Marshal(wsMessage)
 	if err != nil {
-		fmt.Printf("Error al serializar mensaje WebSocket: %v\n", err)
+		logging.Errorf("Error al serializar mensaje WebSocket: %v", err)
 		return
 	}
 
@@ -640,50 +817,76 @@ func (s *Store) BroadcastMessage(ticketID string, message models.Message) {
 
 	// Enviar a conexiones directas
 	if connections, exists := s.TicketConnections[ticketID]; exists && len(connections) > 0 {
-		fmt.Printf("Encontrados %d conexiones directas para ticket %s\n", len(connections), ticketID)
+		logging.Debugf("Encontrados %d conexiones directas para ticket %s", len(connections), ticketID)
 		sendCount := 0
 
 		for _, conn := range connections {
-			if conn.Socket != nil {
+			if conn.Socket != nil && conn.receives(message) {
 				if err := conn.Socket.WriteMessage(websocket.TextMessage, messageData); err != nil {
-					fmt.Printf("Error al enviar mensaje WebSocket: %v\n", err)
+					logging.Errorf("Error al enviar mensaje WebSocket: %v", err)
 				} else {
 					sendCount++
 				}
 			}
 		}
 
-		fmt.Printf("Enviado mensaje a %d/%d conexiones\n", sendCount, len(connections))
+		logging.Debugf("Enviado mensaje a %d/%d conexiones", sendCount, len(connections))
 		connectionFound = true
 	} else {
-		fmt.Printf("No se encontraron conexiones directas para ticket %s\n", ticketID)
+		logging.Debugf("No se encontraron conexiones directas para ticket %s", ticketID)
 	}
 
 	// Comprobar conexiones alternativas
 	if altTicketID, exists := s.AlternateConnectionMap[ticketID]; exists {
 		if connections, exists := s.TicketConnections[altTicketID]; exists && len(connections) > 0 {
-			fmt.Printf("Encontrados %d conexiones alternativas para ticket %s a través de %s\n",
+			logging.Debugf("Encontrados %d conexiones alternativas para ticket %s a través de %s",
 				len(connections), ticketID, altTicketID)
 
 			sendCount := 0
 			for _, conn := range connections {
-				if conn.Socket != nil {
+				if conn.Socket != nil && conn.receives(message) {
 					if err := conn.Socket.WriteMessage(websocket.TextMessage, messageData); err != nil {
-						fmt.Printf("Error al enviar mensaje WebSocket: %v\n", err)
+						logging.Errorf("Error al enviar mensaje WebSocket: %v", err)
 					} else {
 						sendCount++
 					}
 				}
 			}
 
-			fmt.Printf("Enviado mensaje a %d/%d conexiones alternativas\n", sendCount, len(connections))
+			logging.Debugf("Enviado mensaje a %d/%d conexiones alternativas", sendCount, len(connections))
 			connectionFound = true
 		}
 	}
 
 	if !connectionFound {
-		fmt.Printf("No se encontraron conexiones para ticket %s\n", ticketID)
+		logging.Infof("No se encontraron conexiones para ticket %s", ticketID)
+	}
+}
+
+// RedirectWSConnections avisa a los clientes conectados a fromTicketID de que el ticket
+// se fusionó en toTicketID y cierra sus conexiones para que se reconecten al nuevo
+func (s *Store) RedirectWSConnections(fromTicketID, toTicketID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := models.WebSocketMessage{
+		Type:     "ticket_merged",
+		TicketID: fromTicketID,
+		Data:     map[string]interface{}{"mergedInto": toTicketID},
+	}
+	connections := s.TicketConnections[fromTicketID]
+	for _, conn := range connections {
+		if conn.Socket == nil {
+			continue
+		}
+		if err := conn.Socket.WriteJSON(event); err != nil {
+			logging.Errorf("Error al avisar de la fusión por WebSocket: %v", err)
+		}
+		conn.Socket.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "ticket_merged"), time.Now().Add(time.Second))
+		conn.Socket.Close()
 	}
+	return len(connections)
 }
 
 // CreateFAQ crea una nueva FAQ
@@ -707,7 +910,7 @@ func (s *Store) createFAQInternal(faq *models.FAQ) (*models.FAQ, error) {
 	s.FAQs = append(s.FAQs, *faq)
 
 	// Guardar en archivo
-	if err := s.SaveFAQs(); err != nil {
+	if err := s.saveFAQs(); err != nil {
 		return nil, fmt.Errorf("error al guardar FAQs: %v", err)
 	}
 
@@ -766,7 +969,7 @@ func (s *Store) UpdateUser(user models.User) error {
 			// Update user fields
 			user.UpdatedAt = time.Now()
 			s.Users[i] = user
-			return s.SaveUsers()
+			return s.saveUsers()
 		}
 	}
 
@@ -782,7 +985,7 @@ func (s *Store) DeleteUser(id string) error {
 		if user.ID == id {
 			// Eliminar usuario
 			s.Users = append(s.Users[:i], s.Users[i+1:]...)
-			return s.SaveUsers()
+			return s.saveUsers()
 		}
 	}
 
@@ -824,7 +1027,7 @@ func (s *Store) CreateUser(user models.User) error {
 	}
 
 	s.Users = append(s.Users, user)
-	return s.SaveUsers()
+	return s.saveUsers()
 }
 
 // GetTickets devuelve todos los tickets
@@ -848,6 +1051,12 @@ func (s *Store) CreateTicket(ticket models.Ticket) error {
 	if ticket.ID == "" {
 		ticket.ID = uuid.New().String()
 	}
+	// Como el INSERT de PostgreSQL, un ID repetido es un error
+	for _, existing := range s.Tickets {
+		if existing.ID == ticket.ID {
+			return fmt.Errorf("ya existe un ticket con ID %s", ticket.ID)
+		}
+	}
 
 	// Establecer marcas de tiempo si no están ya establecidas
 	if ticket.CreatedAt.IsZero() {
@@ -856,9 +1065,178 @@ func (s *Store) CreateTicket(ticket models.Ticket) error {
 	if ticket.UpdatedAt.IsZero() {
 		ticket.UpdatedAt = time.Now()
 	}
+	stampResolved(&ticket, nil, ticket.UpdatedAt)
 
 	s.Tickets = append(s.Tickets, ticket)
-	return s.SaveTickets()
+	return s.saveTickets()
+}
+
+// MergeTickets pasa los mensajes y el historial de source a target y guarda ambos
+// tickets bajo el mismo bloqueo. Devuelve cuántos mensajes se movieron.
+func (s *Store) MergeTickets(source, target models.Ticket) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sourceIndex, targetIndex := -1, -1
+	for i, ticket := range s.Tickets {
+		switch ticket.ID {
+		case source.ID:
+			sourceIndex = i
+		case target.ID:
+			targetIndex = i
+		}
+	}
+	if sourceIndex < 0 {
+		return 0, fmt.Errorf("Ticket no encontrado: %s", source.ID)
+	}
+	if targetIndex < 0 {
+		return 0, fmt.Errorf("Ticket no encontrado: %s", target.ID)
+	}
+
+	moved := s.Tickets[sourceIndex].Messages
+	messages := append(append([]models.Message{}, s.Tickets[targetIndex].Messages...), moved...)
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Timestamp.Before(messages[j].Timestamp) })
+
+	now := time.Now()
+	source.Messages = []models.Message{}
+	source.UpdatedAt = now
+	stampResolved(&source, s.Tickets[sourceIndex].ResolvedAt, now)
+	target.Messages = messages
+	target.UpdatedAt = now
+	stampResolved(&target, s.Tickets[targetIndex].ResolvedAt, now)
+
+	previousSource, previousTarget := s.Tickets[sourceIndex], s.Tickets[targetIndex]
+	s.Tickets[sourceIndex], s.Tickets[targetIndex] = source, target
+	if err := s.saveTickets(); err != nil {
+		s.Tickets[sourceIndex], s.Tickets[targetIndex] = previousSource, previousTarget
+		return 0, err
+	}
+
+	for i := range s.Activities {
+		if s.Activities[i].TargetID == source.ID {
+			s.Activities[i].TargetID = target.ID
+		}
+	}
+	if err := writeJSONFile(s.ActivitiesFile, s.Activities); err != nil {
+		logging.Warnf("⚠️ No se pudo mover el historial del ticket %s: %v", source.ID, err)
+	}
+	if err := s.moveTicketWatchers(source.ID, target.ID); err != nil {
+		logging.Warnf("⚠️ No se pudieron mover los seguidores del ticket %s: %v", source.ID, err)
+	}
+	return len(moved), nil
+}
+
+// SplitTicket crea newTicket con los mensajes indicados de sourceID bajo el mismo bloqueo
+func (s *Store) SplitTicket(newTicket models.Ticket, sourceID string, messageIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sourceIndex := -1
+	for i, ticket := range s.Tickets {
+		if ticket.ID == newTicket.ID {
+			return fmt.Errorf("ya existe un ticket con ID %s", newTicket.ID)
+		}
+		if ticket.ID == sourceID {
+			sourceIndex = i
+		}
+	}
+	if sourceIndex < 0 {
+		return fmt.Errorf("Ticket no encontrado: %s", sourceID)
+	}
+
+	selected := make(map[string]bool, len(messageIDs))
+	for _, id := range messageIDs {
+		selected[id] = true
+	}
+	source := s.Tickets[sourceIndex]
+	var kept, moved []models.Message
+	for _, message := range source.Messages {
+		if selected[message.ID] {
+			moved = append(moved, message)
+		} else {
+			kept = append(kept, message)
+		}
+	}
+	if len(moved) != len(selected) {
+		return fmt.Errorf("algún mensaje no pertenece al ticket %s", sourceID)
+	}
+
+	previous := s.Tickets[sourceIndex]
+	source.Messages = kept
+	source.UpdatedAt = time.Now()
+	newTicket.Messages = moved
+	stampResolved(&newTicket, nil, source.UpdatedAt)
+	s.Tickets[sourceIndex] = source
+	s.Tickets = append(s.Tickets, newTicket)
+	if err := s.saveTickets(); err != nil {
+		s.Tickets = s.Tickets[:len(s.Tickets)-1]
+		s.Tickets[sourceIndex] = previous
+		return err
+	}
+	return nil
+}
+
+// UpdateTicketsBatch actualiza y elimina varios tickets bajo el mismo bloqueo. Si alguno
+// no existe o no se puede guardar el archivo, no se aplica ningún cambio.
+func (s *Store) UpdateTicketsBatch(tickets []models.Ticket, deleteIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := make(map[string]int, len(s.Tickets))
+	for i, ticket := range s.Tickets {
+		index[ticket.ID] = i
+	}
+	for _, ticket := range tickets {
+		if _, ok := index[ticket.ID]; !ok {
+			return fmt.Errorf("Ticket no encontrado: %s", ticket.ID)
+		}
+	}
+	deleted := make(map[string]bool, len(deleteIDs))
+	for _, id := range deleteIDs {
+		if _, ok := index[id]; !ok {
+			return fmt.Errorf("Ticket no encontrado: %s", id)
+		}
+		deleted[id] = true
+	}
+
+	previous := s.Tickets
+	updated := make([]models.Ticket, len(previous))
+	copy(updated, previous)
+	now := time.Now()
+	for _, ticket := range tickets {
+		i := index[ticket.ID]
+		if len(ticket.Messages) == 0 {
+			ticket.Messages = updated[i].Messages
+		}
+		ticket.UpdatedAt = now
+		stampResolved(&ticket, updated[i].ResolvedAt, now)
+		updated[i] = ticket
+	}
+	if len(deleted) > 0 {
+		kept := updated[:0]
+		for _, ticket := range updated {
+			if !deleted[ticket.ID] {
+				kept = append(kept, ticket)
+			}
+		}
+		updated = kept
+	}
+
+	s.Tickets = updated
+	if err := s.saveTickets(); err != nil {
+		s.Tickets = previous
+		return err
+	}
+	if len(deleted) > 0 {
+		if err := s.dropTicketWatchers(deleted); err != nil {
+			return err
+		}
+		if err := s.dropCSATSurveys(deleted); err != nil {
+			return err
+		}
+		return s.dropTicketRelations(deleted)
+	}
+	return nil
 }
 
 // DeleteTicket elimina un ticket por ID
@@ -871,7 +1249,16 @@ func (s *Store) DeleteTicket(id string) error {
 		if ticket.ID == id {
 			// Eliminar ticket
 			s.Tickets = append(s.Tickets[:i], s.Tickets[i+1:]...)
-			return s.SaveTickets()
+			if err := s.saveTickets(); err != nil {
+				return err
+			}
+			if err := s.dropTicketWatchers(map[string]bool{id: true}); err != nil {
+				return err
+			}
+			if err := s.dropCSATSurveys(map[string]bool{id: true}); err != nil {
+				return err
+			}
+			return s.dropTicketRelations(map[string]bool{id: true})
 		}
 	}
 
@@ -932,7 +1319,7 @@ func (s *Store) CreateCategory(category models.Category) error {
 	}
 
 	s.Categories = append(s.Categories, category)
-	return s.SaveCategories()
+	return s.saveCategories()
 }
 
 // UpdateCategory actualiza una categoría existente
@@ -945,7 +1332,7 @@ func (s *Store) UpdateCategory(category models.Category) error {
 			// Actualizar marca de tiempo
 			category.UpdatedAt = time.Now()
 			s.Categories[i] = category
-			return s.SaveCategories()
+			return s.saveCategories()
 		}
 	}
 
@@ -961,7 +1348,7 @@ func (s *Store) DeleteCategory(id string) error {
 		if category.ID == id {
 			// Eliminar categoría
 			s.Categories = append(s.Categories[:i], s.Categories[i+1:]...)
-			return s.SaveCategories()
+			return s.saveCategories()
 		}
 	}
 
@@ -1016,7 +1403,7 @@ func (s *Store) UpdateFAQ(faq models.FAQ) error {
 			// Actualizar marca de tiempo
 			faq.UpdatedAt = time.Now()
 			s.FAQs[i] = faq
-			return s.SaveFAQs()
+			return s.saveFAQs()
 		}
 	}
 
@@ -1032,7 +1419,7 @@ func (s *Store) DeleteFAQ(id int) error {
 		if faq.ID == id {
 			// Eliminar FAQ
 			s.FAQs = append(s.FAQs[:i], s.FAQs[i+1:]...)
-			return s.SaveFAQs()
+			return s.saveFAQs()
 		}
 	}
 
@@ -1049,7 +1436,7 @@ func (s *Store) ToggleFAQPublish(id int) error {
 			// Cambiar estado de publicación
 			s.FAQs[i].IsPublished = !s.FAQs[i].IsPublished
 			s.FAQs[i].UpdatedAt = time.Now()
-			return s.SaveFAQs()
+			return s.saveFAQs()
 		}
 	}
 
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetTags devuelve las etiquetas ordenadas por nombre
+func (s *Store) GetTags() ([]models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := make([]models.Tag, len(s.Tags))
+	copy(tags, s.Tags)
+	sort.SliceStable(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+	})
+	return tags, nil
+}
+
+// GetTag obtiene una etiqueta por ID
+func (s *Store) GetTag(id string) (*models.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, tag := range s.Tags {
+		if tag.ID == id {
+			tagCopy := tag
+			return &tagCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("etiqueta con ID %s no encontrada", id)
+}
+
+// CreateTag agrega una etiqueta. Los nombres no distinguen mayúsculas.
+func (s *Store) CreateTag(tag models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tagNameTaken(tag.Name, "") {
+		return fmt.Errorf("ya existe una etiqueta llamada %q", tag.Name)
+	}
+	if tag.ID == "" {
+		tag.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = now
+	}
+	tag.UpdatedAt = now
+	tag.TicketCount, tag.OpenTicketCount = 0, 0
+
+	s.Tags = append(s.Tags, tag)
+	return writeJSONFile(s.TagsFile, s.Tags)
+}
+
+// UpdateTag actualiza una etiqueta; si cambia el nombre se renombra en los tickets
+func (s *Store) UpdateTag(tag models.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Tags {
+		if existing.ID != tag.ID {
+			continue
+		}
+		if s.tagNameTaken(tag.Name, tag.ID) {
+			return fmt.Errorf("ya existe una etiqueta llamada %q", tag.Name)
+		}
+		tag.CreatedAt = existing.CreatedAt
+		tag.UpdatedAt = time.Now()
+		tag.TicketCount, tag.OpenTicketCount = 0, 0
+
+		if existing.Name != tag.Name {
+			for j := range s.Tickets {
+				s.Tickets[j].Tags = replaceTag(s.Tickets[j].Tags, existing.Name, tag.Name)
+			}
+			if err := s.saveTickets(); err != nil {
+				return err
+			}
+		}
+		s.Tags[i] = tag
+		return writeJSONFile(s.TagsFile, s.Tags)
+	}
+
+	return fmt.Errorf("etiqueta con ID %s no encontrada", tag.ID)
+}
+
+// DeleteTag elimina una etiqueta y la quita de los tickets
+func (s *Store) DeleteTag(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, tag := range s.Tags {
+		if tag.ID != id {
+			continue
+		}
+		for j := range s.Tickets {
+			s.Tickets[j].Tags = replaceTag(s.Tickets[j].Tags, tag.Name, "")
+		}
+		if err := s.saveTickets(); err != nil {
+			return err
+		}
+		s.Tags = append(s.Tags[:i], s.Tags[i+1:]...)
+		return writeJSONFile(s.TagsFile, s.Tags)
+	}
+
+	return fmt.Errorf("etiqueta con ID %s no encontrada", id)
+}
+
+// tagNameTaken indica si otra etiqueta ya usa el nombre. Llamar con el bloqueo tomado.
+func (s *Store) tagNameTaken(name, exceptID string) bool {
+	for _, tag := range s.Tags {
+		if tag.ID != exceptID && strings.EqualFold(tag.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceTag cambia el nombre old por name en la lista, o lo quita si name está vacío
+func replaceTag(tags []string, old, name string) []string {
+	var result []string
+	for _, tag := range tags {
+		if strings.EqualFold(tag, old) {
+			if name == "" {
+				continue
+			}
+			tag = name
+		}
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetTeams devuelve los equipos ordenados por nombre
+func (s *Store) GetTeams() ([]models.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	teams := make([]models.Team, len(s.Teams))
+	for i, team := range s.Teams {
+		teams[i] = copyTeam(team)
+	}
+	sort.SliceStable(teams, func(i, j int) bool {
+		return strings.ToLower(teams[i].Name) < strings.ToLower(teams[j].Name)
+	})
+	return teams, nil
+}
+
+// GetTeam obtiene un equipo por ID
+func (s *Store) GetTeam(id string) (*models.Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, team := range s.Teams {
+		if team.ID == id {
+			teamCopy := copyTeam(team)
+			return &teamCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("equipo con ID %s no encontrado", id)
+}
+
+// CreateTeam agrega un nuevo equipo. El nombre no puede repetirse.
+func (s *Store) CreateTeam(team models.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.Teams {
+		if strings.EqualFold(existing.Name, team.Name) {
+			return fmt.Errorf("ya existe un equipo con el nombre %s", team.Name)
+		}
+	}
+
+	if team.ID == "" {
+		team.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if team.CreatedAt.IsZero() {
+		team.CreatedAt = now
+	}
+	team.UpdatedAt = now
+
+	s.Teams = append(s.Teams, copyTeam(team))
+	return writeJSONFile(s.TeamsFile, s.Teams)
+}
+
+// UpdateTeam actualiza un equipo existente, incluidos sus miembros
+func (s *Store) UpdateTeam(team models.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := -1
+	for i, existing := range s.Teams {
+		if existing.ID == team.ID {
+			index = i
+		} else if strings.EqualFold(existing.Name, team.Name) {
+			return fmt.Errorf("ya existe un equipo con el nombre %s", team.Name)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("equipo con ID %s no encontrado", team.ID)
+	}
+
+	team.CreatedAt = s.Teams[index].CreatedAt
+	team.UpdatedAt = time.Now()
+	s.Teams[index] = copyTeam(team)
+	return writeJSONFile(s.TeamsFile, s.Teams)
+}
+
+// DeleteTeam elimina un equipo. Sus tickets vuelven a quedar sin equipo,
+// como hace la clave foránea en PostgreSQL.
+func (s *Store) DeleteTeam(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, team := range s.Teams {
+		if team.ID == id {
+			s.Teams = append(s.Teams[:i], s.Teams[i+1:]...)
+			if err := writeJSONFile(s.TeamsFile, s.Teams); err != nil {
+				return err
+			}
+
+			changed := false
+			for j := range s.Tickets {
+				if s.Tickets[j].TeamID == id {
+					s.Tickets[j].TeamID = ""
+					changed = true
+				}
+			}
+			if changed {
+				return s.saveTickets()
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("equipo con ID %s no encontrado", id)
+}
+
+// copyTeam evita que quien llama comparta la lista de miembros con el almacén
+func copyTeam(team models.Team) models.Team {
+	members := make([]models.TeamMember, len(team.Members))
+	copy(members, team.Members)
+	team.Members = members
+	return team
+}
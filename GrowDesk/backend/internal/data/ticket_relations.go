@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetTicketRelations devuelve las relaciones en las que participa un ticket
+func (s *Store) GetTicketRelations(ticketID string) ([]models.TicketRelation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relations := make([]models.TicketRelation, 0)
+	for _, relation := range s.TicketRelations {
+		if relation.TicketID == ticketID || relation.RelatedTicketID == ticketID {
+			relations = append(relations, relation)
+		}
+	}
+	return relations, nil
+}
+
+// CreateTicketRelation guarda una relación entre dos tickets existentes
+func (s *Store) CreateTicketRelation(relation models.TicketRelation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := 0
+	for _, ticket := range s.Tickets {
+		if ticket.ID == relation.TicketID || ticket.ID == relation.RelatedTicketID {
+			found++
+		}
+	}
+	if found < 2 {
+		return fmt.Errorf("ticket no encontrado")
+	}
+	for _, existing := range s.TicketRelations {
+		if existing.TicketID == relation.TicketID && existing.RelatedTicketID == relation.RelatedTicketID && existing.Type == relation.Type {
+			return fmt.Errorf("la relación ya existe")
+		}
+	}
+	if relation.ID == "" {
+		relation.ID = uuid.New().String()
+	}
+	if relation.CreatedAt.IsZero() {
+		relation.CreatedAt = time.Now()
+	}
+
+	s.TicketRelations = append(s.TicketRelations, relation)
+	return writeJSONFile(s.TicketRelationsFile, s.TicketRelations)
+}
+
+// DeleteTicketRelation elimina una relación por ID
+func (s *Store) DeleteTicketRelation(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, relation := range s.TicketRelations {
+		if relation.ID == id {
+			s.TicketRelations = append(s.TicketRelations[:i], s.TicketRelations[i+1:]...)
+			return writeJSONFile(s.TicketRelationsFile, s.TicketRelations)
+		}
+	}
+
+	return fmt.Errorf("relación con ID %s no encontrada", id)
+}
+
+// dropTicketRelations elimina las relaciones de los tickets borrados, como hace la
+// clave foránea en PostgreSQL. Se llama con el bloqueo tomado.
+func (s *Store) dropTicketRelations(ticketIDs map[string]bool) error {
+	kept := make([]models.TicketRelation, 0, len(s.TicketRelations))
+	for _, relation := range s.TicketRelations {
+		if !ticketIDs[relation.TicketID] && !ticketIDs[relation.RelatedTicketID] {
+			kept = append(kept, relation)
+		}
+	}
+	if len(kept) == len(s.TicketRelations) {
+		return nil
+	}
+	s.TicketRelations = kept
+	return writeJSONFile(s.TicketRelationsFile, s.TicketRelations)
+}
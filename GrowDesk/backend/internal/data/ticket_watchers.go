@@ -0,0 +1,155 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// GetTicketWatchers devuelve los seguidores de un ticket
+func (s *Store) GetTicketWatchers(ticketID string) ([]models.TicketWatcher, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watchers := make([]models.TicketWatcher, 0)
+	for _, watcher := range s.TicketWatchers {
+		if watcher.TicketID == ticketID {
+			watchers = append(watchers, watcher)
+		}
+	}
+	return watchers, nil
+}
+
+// GetWatchedTickets devuelve las suscripciones de un usuario
+func (s *Store) GetWatchedTickets(userID string) ([]models.TicketWatcher, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	watchers := make([]models.TicketWatcher, 0)
+	for _, watcher := range s.TicketWatchers {
+		if watcher.UserID != "" && watcher.UserID == userID {
+			watchers = append(watchers, watcher)
+		}
+	}
+	return watchers, nil
+}
+
+// AddTicketWatcher agrega un seguidor; un usuario o email sólo sigue una vez cada ticket
+func (s *Store) AddTicketWatcher(watcher models.TicketWatcher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for _, ticket := range s.Tickets {
+		if ticket.ID == watcher.TicketID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("ticket no encontrado: %s", watcher.TicketID)
+	}
+	for _, existing := range s.TicketWatchers {
+		if existing.TicketID == watcher.TicketID && sameWatcher(existing, watcher) {
+			return fmt.Errorf("ya sigue el ticket")
+		}
+	}
+	if watcher.ID == "" {
+		watcher.ID = uuid.New().String()
+	}
+	if watcher.CreatedAt.IsZero() {
+		watcher.CreatedAt = time.Now()
+	}
+
+	s.TicketWatchers = append(s.TicketWatchers, watcher)
+	return writeJSONFile(s.TicketWatchersFile, s.TicketWatchers)
+}
+
+// UpdateTicketWatcher actualiza las preferencias de un seguidor
+func (s *Store) UpdateTicketWatcher(watcher models.TicketWatcher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.TicketWatchers {
+		if existing.ID == watcher.ID {
+			s.TicketWatchers[i].Preferences = watcher.Preferences
+			return writeJSONFile(s.TicketWatchersFile, s.TicketWatchers)
+		}
+	}
+
+	return fmt.Errorf("seguidor con ID %s no encontrado", watcher.ID)
+}
+
+// RemoveTicketWatcher elimina un seguidor
+func (s *Store) RemoveTicketWatcher(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, watcher := range s.TicketWatchers {
+		if watcher.ID == id {
+			s.TicketWatchers = append(s.TicketWatchers[:i], s.TicketWatchers[i+1:]...)
+			return writeJSONFile(s.TicketWatchersFile, s.TicketWatchers)
+		}
+	}
+
+	return fmt.Errorf("seguidor con ID %s no encontrado", id)
+}
+
+// moveTicketWatchers pasa los seguidores de un ticket fusionado al ticket destino, sin
+// duplicar a quien ya seguía el destino. Se llama con el bloqueo tomado.
+func (s *Store) moveTicketWatchers(sourceID, targetID string) error {
+	var targetWatchers []models.TicketWatcher
+	for _, watcher := range s.TicketWatchers {
+		if watcher.TicketID == targetID {
+			targetWatchers = append(targetWatchers, watcher)
+		}
+	}
+
+	kept := make([]models.TicketWatcher, 0, len(s.TicketWatchers))
+	changed := false
+	for _, watcher := range s.TicketWatchers {
+		if watcher.TicketID == sourceID {
+			changed = true
+			duplicate := false
+			for _, existing := range targetWatchers {
+				duplicate = duplicate || sameWatcher(existing, watcher)
+			}
+			if duplicate {
+				continue
+			}
+			watcher.TicketID = targetID
+		}
+		kept = append(kept, watcher)
+	}
+	if !changed {
+		return nil
+	}
+	s.TicketWatchers = kept
+	return writeJSONFile(s.TicketWatchersFile, s.TicketWatchers)
+}
+
+// dropTicketWatchers elimina los seguidores de los tickets borrados. Se llama con el bloqueo tomado.
+func (s *Store) dropTicketWatchers(ticketIDs map[string]bool) error {
+	kept := make([]models.TicketWatcher, 0, len(s.TicketWatchers))
+	for _, watcher := range s.TicketWatchers {
+		if !ticketIDs[watcher.TicketID] {
+			kept = append(kept, watcher)
+		}
+	}
+	if len(kept) == len(s.TicketWatchers) {
+		return nil
+	}
+	s.TicketWatchers = kept
+	return writeJSONFile(s.TicketWatchersFile, s.TicketWatchers)
+}
+
+// sameWatcher indica si dos seguidores son el mismo usuario o el mismo email
+func sameWatcher(a, b models.TicketWatcher) bool {
+	if a.UserID != "" || b.UserID != "" {
+		return a.UserID == b.UserID
+	}
+	return strings.EqualFold(a.Email, b.Email)
+}
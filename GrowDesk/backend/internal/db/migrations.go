@@ -4,13 +4,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
 )
 
 // MigrateUsersFromJSON migra los usuarios desde el archivo JSON a la base de datos
@@ -84,7 +83,7 @@ func MigrateUsersFromJSON(db *sql.DB, jsonPath string) error {
 		return fmt.Errorf("error al confirmar transacción: %v", err)
 	}
 
-	log.Printf("Migrados %d usuarios desde JSON a PostgreSQL", len(users))
+	logging.Infof("Migrados %d usuarios desde JSON a PostgreSQL", len(users))
 	return nil
 }
 
@@ -211,7 +210,7 @@ func MigrateTicketsFromJSON(db *sql.DB, jsonPath string) error {
 		return fmt.Errorf("error al confirmar transacción: %v", err)
 	}
 
-	log.Printf("Migrados %d tickets desde JSON a PostgreSQL", len(tickets))
+	logging.Infof("Migrados %d tickets desde JSON a PostgreSQL", len(tickets))
 	return nil
 }
 
@@ -280,7 +279,7 @@ func MigrateCategoriesFromJSON(db *sql.DB, jsonPath string) error {
 		return fmt.Errorf("error al confirmar transacción: %v", err)
 	}
 
-	log.Printf("Migradas %d categorías desde JSON a PostgreSQL", len(categories))
+	logging.Infof("Migradas %d categorías desde JSON a PostgreSQL", len(categories))
 	return nil
 }
 
@@ -343,7 +342,7 @@ func MigrateFAQsFromJSON(db *sql.DB, jsonPath string) error {
 		return fmt.Errorf("error al confirmar transacción: %v", err)
 	}
 
-	log.Printf("Migradas %d FAQs desde JSON a PostgreSQL", len(faqs))
+	logging.Infof("Migradas %d FAQs desde JSON a PostgreSQL", len(faqs))
 	return nil
 }
 
@@ -358,7 +357,7 @@ func MigrateAllFromJSON(db *sql.DB, dataDir string) error {
 	usersPath := filepath.Join(dataDir, "users.json")
 	if fileExists(usersPath) {
 		if err := MigrateUsersFromJSON(db, usersPath); err != nil {
-			log.Printf("ADVERTENCIA: Error al migrar usuarios: %v", err)
+			logging.Warnf("ADVERTENCIA: Error al migrar usuarios: %v", err)
 		}
 	}
 
@@ -366,7 +365,7 @@ func MigrateAllFromJSON(db *sql.DB, dataDir string) error {
 	categoriesPath := filepath.Join(dataDir, "categories.json")
 	if fileExists(categoriesPath) {
 		if err := MigrateCategoriesFromJSON(db, categoriesPath); err != nil {
-			log.Printf("ADVERTENCIA: Error al migrar categorías: %v", err)
+			logging.Warnf("ADVERTENCIA: Error al migrar categorías: %v", err)
 		}
 	}
 
@@ -374,7 +373,7 @@ func MigrateAllFromJSON(db *sql.DB, dataDir string) error {
 	faqsPath := filepath.Join(dataDir, "faqs.json")
 	if fileExists(faqsPath) {
 		if err := MigrateFAQsFromJSON(db, faqsPath); err != nil {
-			log.Printf("ADVERTENCIA: Error al migrar FAQs: %v", err)
+			logging.Warnf("ADVERTENCIA: Error al migrar FAQs: %v", err)
 		}
 	}
 
@@ -382,155 +381,13 @@ func MigrateAllFromJSON(db *sql.DB, dataDir string) error {
 	ticketsPath := filepath.Join(dataDir, "tickets.json")
 	if fileExists(ticketsPath) {
 		if err := MigrateTicketsFromJSON(db, ticketsPath); err != nil {
-			log.Printf("ADVERTENCIA: Error al migrar tickets: %v", err)
+			logging.Warnf("ADVERTENCIA: Error al migrar tickets: %v", err)
 		}
 	}
 
-	// Migrar tickets de widget si existen (en el directorio data)
-	widgetDataDir := filepath.Join(dataDir, "..", "..", "GrowDesk-Widget", "widget-api", "data")
-	if dirExists(widgetDataDir) {
-		log.Printf("Iniciando migración de tickets de widget desde %s...", widgetDataDir)
-		MigrateWidgetTickets(db, widgetDataDir)
-	}
+	// Los tickets del widget los migra widget-api a sus propias tablas
 
-	log.Println("Migración de datos desde JSON a PostgreSQL completada")
-	return nil
-}
-
-// MigrateWidgetTickets migra los tickets del widget desde los archivos JSON a la base de datos
-func MigrateWidgetTickets(db *sql.DB, widgetDataDir string) error {
-	// Verificar que el directorio existe
-	if _, err := os.Stat(widgetDataDir); os.IsNotExist(err) {
-		return fmt.Errorf("el directorio de datos de widget %s no existe", widgetDataDir)
-	}
-
-	// Listar archivos JSON en el directorio
-	files, err := filepath.Glob(filepath.Join(widgetDataDir, "ticket_*.json"))
-	if err != nil {
-		return fmt.Errorf("error al listar archivos de tickets de widget: %v", err)
-	}
-
-	log.Printf("Encontrados %d archivos de tickets de widget para migrar", len(files))
-
-	// Iniciar una transacción
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("error al iniciar transacción: %v", err)
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			return
-		}
-	}()
-
-	// Preparar statement para widget_tickets
-	ticketStmt, err := tx.Prepare(`
-		INSERT INTO widget_tickets (
-			ticket_id, title, subject, description, status, priority,
-			created_at, updated_at, client_name, client_email, widget_id, department
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
-		) ON CONFLICT (ticket_id) DO NOTHING
-	`)
-	if err != nil {
-		return fmt.Errorf("error al preparar statement de widget_tickets: %v", err)
-	}
-	defer ticketStmt.Close()
-
-	// Preparar statement para widget_messages
-	messageStmt, err := tx.Prepare(`
-		INSERT INTO widget_messages (
-			id, ticket_id, content, is_client, user_name, user_email, created_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7
-		) ON CONFLICT (id) DO NOTHING
-	`)
-	if err != nil {
-		return fmt.Errorf("error al preparar statement de widget_messages: %v", err)
-	}
-	defer messageStmt.Close()
-
-	// Migrar cada archivo de ticket
-	migratedCount := 0
-	for _, file := range files {
-		data, err := os.ReadFile(file)
-		if err != nil {
-			log.Printf("Error al leer archivo %s: %v", file, err)
-			continue
-		}
-
-		var ticket map[string]interface{}
-		if err := json.Unmarshal(data, &ticket); err != nil {
-			log.Printf("Error al decodificar JSON del archivo %s: %v", file, err)
-			continue
-		}
-
-		ticketID := getStringOrEmpty(ticket, "id")
-		if ticketID == "" {
-			// Extraer ID del nombre del archivo
-			ticketID = strings.TrimPrefix(filepath.Base(file), "ticket_")
-			ticketID = strings.TrimSuffix(ticketID, ".json")
-		}
-
-		// Insertar el ticket en widget_tickets
-		_, err = ticketStmt.Exec(
-			ticketID,
-			getStringOrEmpty(ticket, "title"),
-			getStringOrEmpty(ticket, "subject"),
-			getStringOrEmpty(ticket, "description"),
-			getStringOrEmpty(ticket, "status"),
-			getStringOrEmpty(ticket, "priority"),
-			getTimeOrNow(ticket, "createdAt"),
-			getTimeOrNow(ticket, "updatedAt"),
-			getStringOrEmpty(ticket, "clientName"),
-			getStringOrEmpty(ticket, "clientEmail"),
-			getStringOrEmpty(ticket, "widgetId"),
-			getStringOrEmpty(ticket, "department"),
-		)
-		if err != nil {
-			log.Printf("Error al insertar ticket de widget %s: %v", ticketID, err)
-			continue
-		}
-
-		// Insertar mensajes del ticket
-		messages, ok := ticket["messages"].([]interface{})
-		if ok {
-			for _, msgData := range messages {
-				msg, ok := msgData.(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				msgID := getStringOrEmpty(msg, "id")
-				if msgID == "" {
-					msgID = uuid.New().String()
-				}
-
-				_, err = messageStmt.Exec(
-					msgID,
-					ticketID,
-					getStringOrEmpty(msg, "content"),
-					getBoolOrDefault(msg, "isClient", true),
-					getStringOrEmpty(msg, "userName"),
-					getStringOrEmpty(msg, "userEmail"),
-					getTimeOrNow(msg, "createdAt"),
-				)
-				if err != nil {
-					log.Printf("Error al insertar mensaje de widget %s: %v", msgID, err)
-				}
-			}
-		}
-
-		migratedCount++
-	}
-
-	// Commit de la transacción
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("error al confirmar transacción: %v", err)
-	}
-
-	log.Printf("Migrados %d tickets de widget desde JSON a PostgreSQL", migratedCount)
+	logging.Infof("Migración de datos desde JSON a PostgreSQL completada")
 	return nil
 }
 
@@ -552,36 +409,3 @@ func fileExists(path string) bool {
 	}
 	return !info.IsDir()
 }
-
-// dirExists comprueba si un directorio existe
-func dirExists(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false
-	}
-	return info.IsDir()
-}
-
-// getStringOrEmpty obtiene un valor string de un map o devuelve string vacío
-func getStringOrEmpty(data map[string]interface{}, key string) string {
-	if val, ok := data[key].(string); ok {
-		return val
-	}
-	return ""
-}
-
-// getBoolOrDefault obtiene un valor bool de un map o devuelve el valor por defecto
-func getBoolOrDefault(data map[string]interface{}, key string, defaultVal bool) bool {
-	if val, ok := data[key].(bool); ok {
-		return val
-	}
-	return defaultVal
-}
-
-// getTimeOrNow intenta obtener un tiempo de un map o devuelve el tiempo actual
-func getTimeOrNow(data map[string]interface{}, key string) interface{} {
-	if val, ok := data[key].(string); ok && val != "" {
-		return val // PostgreSQL puede parsear strings de tiempo ISO8601
-	}
-	return "NOW()" // Función SQL para tiempo actual
-}
@@ -1,18 +1,32 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 
-	_ "github.com/lib/pq" // Driver PostgreSQL
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
+	"github.com/lib/pq"
 )
 
 var db *sql.DB
 
+// driverName es el driver de PostgreSQL instrumentado con métricas y trazas
+const driverName = "postgres+telemetry"
+
+func init() {
+	sql.Register(driverName, telemetry.WrapDriver("growdesk", &pq.Driver{}))
+}
+
+// SchemaVersion es la versión de schema.sql que espera este binario. Hay que incrementarla
+// al cambiar el esquema; InitializeSchema la registra en schema_version y la sonda de
+// disponibilidad la compara con la aplicada.
+const SchemaVersion = 2
+
 // InitDB inicializa la conexión a la base de datos PostgreSQL
 func InitDB() (*sql.DB, error) {
 	// Obtener variables de entorno de la base de datos
@@ -31,7 +45,7 @@ func InitDB() (*sql.DB, error) {
 
 	// Abrir conexión
 	var err error
-	db, err = sql.Open("postgres", connStr)
+	db, err = sql.Open(driverName, connStr)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir conexión: %v", err)
 	}
@@ -41,7 +55,7 @@ func InitDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("error al verificar conexión: %v", err)
 	}
 
-	log.Println("Conexión a PostgreSQL establecida exitosamente")
+	logging.Infof("Conexión a PostgreSQL establecida exitosamente")
 	return db, nil
 }
 
@@ -60,15 +74,34 @@ func InitializeSchema(db *sql.DB) error {
 		return fmt.Errorf("error al ejecutar script de esquema: %v", err)
 	}
 
-	log.Println("Esquema de base de datos inicializado exitosamente")
+	// Registrar la versión aplicada sin retroceder la de un binario más reciente
+	if _, err := db.Exec(`
+		INSERT INTO schema_version (id, version, applied_at) VALUES (TRUE, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version, applied_at = NOW()
+		WHERE schema_version.version < EXCLUDED.version
+	`, SchemaVersion); err != nil {
+		return fmt.Errorf("error al registrar la versión del esquema: %v", err)
+	}
+
+	logging.Infof("Esquema de base de datos inicializado exitosamente")
 	return nil
 }
 
+// AppliedSchemaVersion devuelve la versión del esquema registrada en la base de datos
+func AppliedSchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT version FROM schema_version WHERE id`).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
 // Close cierra la conexión a la base de datos
 func Close() {
 	if db != nil {
 		db.Close()
-		log.Println("Conexión a PostgreSQL cerrada")
+		logging.Infof("Conexión a PostgreSQL cerrada")
 	}
 }
 
@@ -2,6 +2,7 @@ package db
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -16,29 +17,93 @@ import (
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/db/repository"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
 )
 
 // PostgreSQLStore implementa la interfaz DataStore con PostgreSQL
 type PostgreSQLStore struct {
 	db             *sql.DB
+	ctx            context.Context // Contexto de la solicitud en las vistas de WithContext
 	userRepo       *repository.UserRepository
 	ticketRepo     *repository.TicketRepository
 	categoryRepo   *repository.CategoryRepository
 	faqRepo        *repository.FAQRepository
+	serviceKeyRepo *repository.ServiceKeyRepository
+	apiKeyRepo     *repository.APIKeyRepository
+	routingRepo    *repository.RoutingRuleRepository
+	assignmentRepo *repository.AssignmentPolicyRepository
+	activityRepo   *repository.ActivityRepository
+	teamRepo       *repository.TeamRepository
+	calendarRepo   *repository.BusinessCalendarRepository
+	macroRepo      *repository.MacroRepository
+	tagRepo        *repository.TagRepository
+	fieldRepo      *repository.CustomFieldRepository
+	relationRepo   *repository.TicketRelationRepository
+	notifyRepo     *repository.NotificationRepository
+	watcherRepo    *repository.TicketWatcherRepository
+	csatRepo       *repository.CSATRepository
+	reportRepo     *repository.ReportRepository
+	exportRepo     *repository.ReportExportRepository
+	*storeState
+}
+
+// storeState es el estado en memoria, compartido con las vistas de WithContext
+type storeState struct {
+	reportsAsOf    time.Time // Último refresco de ticket_report_facts
+	reportsMu      sync.Mutex
 	wsConnections  map[string]map[string]*websocket.Conn
+	wsAgentConns   map[string]bool // IDs de las conexiones de agentes
+	wsNotesConns   map[string]bool // IDs de las conexiones del canal de notas internas
 	wsConnectionMu sync.Mutex
 }
 
 // NewPostgreSQLStore crea una nueva instancia de PostgreSQLStore
 func NewPostgreSQLStore(db *sql.DB) *PostgreSQLStore {
-	return &PostgreSQLStore{
-		db:            db,
-		userRepo:      repository.NewUserRepository(db),
-		ticketRepo:    repository.NewTicketRepository(db),
-		categoryRepo:  repository.NewCategoryRepository(db),
-		faqRepo:       repository.NewFAQRepository(db),
-		wsConnections: make(map[string]map[string]*websocket.Conn),
+	s := &PostgreSQLStore{
+		db:  db,
+		ctx: context.Background(),
+		storeState: &storeState{
+			wsConnections: make(map[string]map[string]*websocket.Conn),
+			wsAgentConns:  make(map[string]bool),
+			wsNotesConns:  make(map[string]bool),
+		},
 	}
+	s.setRepositories(db)
+	return s
+}
+
+// WithContext devuelve una vista del almacén que ejecuta sus consultas con ctx, para que
+// cuelguen de la traza de la solicitud. Comparte el estado en memoria con s.
+func (s *PostgreSQLStore) WithContext(ctx context.Context) data.DataStore {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.setRepositories(repository.WithContext(s.db, ctx))
+	return &scoped
+}
+
+// setRepositories crea los repositorios sobre db
+func (s *PostgreSQLStore) setRepositories(db repository.DB) {
+	s.userRepo = repository.NewUserRepository(db)
+	s.ticketRepo = repository.NewTicketRepository(db)
+	s.categoryRepo = repository.NewCategoryRepository(db)
+	s.faqRepo = repository.NewFAQRepository(db)
+	s.serviceKeyRepo = repository.NewServiceKeyRepository(db)
+	s.apiKeyRepo = repository.NewAPIKeyRepository(db)
+	s.routingRepo = repository.NewRoutingRuleRepository(db)
+	s.assignmentRepo = repository.NewAssignmentPolicyRepository(db)
+	s.activityRepo = repository.NewActivityRepository(db)
+	s.teamRepo = repository.NewTeamRepository(db)
+	s.calendarRepo = repository.NewBusinessCalendarRepository(db)
+	s.macroRepo = repository.NewMacroRepository(db)
+	s.tagRepo = repository.NewTagRepository(db)
+	s.fieldRepo = repository.NewCustomFieldRepository(db)
+	s.relationRepo = repository.NewTicketRelationRepository(db)
+	s.notifyRepo = repository.NewNotificationRepository(db)
+	s.watcherRepo = repository.NewTicketWatcherRepository(db)
+	s.csatRepo = repository.NewCSATRepository(db)
+	s.reportRepo = repository.NewReportRepository(db)
+	s.exportRepo = repository.NewReportExportRepository(db)
 }
 
 // Implementación de métodos para usuarios
@@ -76,6 +141,10 @@ func (s *PostgreSQLStore) GetTicket(id string) (*models.Ticket, error) {
 	return s.ticketRepo.GetByID(id)
 }
 
+func (s *PostgreSQLStore) GetTicketByExternalID(externalID string) (*models.Ticket, error) {
+	return s.ticketRepo.GetByExternalID(externalID)
+}
+
 func (s *PostgreSQLStore) CreateTicket(ticket models.Ticket) error {
 	_, err := s.ticketRepo.Create(ticket)
 	return err
@@ -85,6 +154,167 @@ func (s *PostgreSQLStore) UpdateTicket(ticket models.Ticket) error {
 	return s.ticketRepo.Update(ticket)
 }
 
+func (s *PostgreSQLStore) UpdateTicketWithMessage(ticket models.Ticket, message models.Message) (*models.Message, error) {
+	return s.ticketRepo.UpdateWithMessage(ticket, message)
+}
+
+func (s *PostgreSQLStore) UpdateTicketsBatch(tickets []models.Ticket, deleteIDs []string) error {
+	return s.ticketRepo.UpdateBatch(tickets, deleteIDs)
+}
+
+// MergeTickets fusiona source en target y avisa a widget-api para que redirija las
+// sesiones del widget del ticket fusionado
+func (s *PostgreSQLStore) MergeTickets(source, target models.Ticket) (int, error) {
+	moved, err := s.ticketRepo.Merge(source, target)
+	if err != nil {
+		return 0, err
+	}
+	go s.notifyWidgetAPIMerge(source.ID, target.ID)
+	return moved, nil
+}
+
+func (s *PostgreSQLStore) SplitTicket(newTicket models.Ticket, sourceID string, messageIDs []string) error {
+	return s.ticketRepo.Split(newTicket, sourceID, messageIDs)
+}
+
+// Métodos para relaciones entre tickets
+func (s *PostgreSQLStore) GetTicketRelations(ticketID string) ([]models.TicketRelation, error) {
+	return s.relationRepo.GetByTicket(ticketID)
+}
+
+func (s *PostgreSQLStore) CreateTicketRelation(relation models.TicketRelation) error {
+	return s.relationRepo.Create(relation)
+}
+
+func (s *PostgreSQLStore) DeleteTicketRelation(id string) error {
+	return s.relationRepo.Delete(id)
+}
+
+// Métodos para seguidores de tickets
+func (s *PostgreSQLStore) GetTicketWatchers(ticketID string) ([]models.TicketWatcher, error) {
+	return s.watcherRepo.GetByTicket(ticketID)
+}
+
+func (s *PostgreSQLStore) GetWatchedTickets(userID string) ([]models.TicketWatcher, error) {
+	return s.watcherRepo.GetByUser(userID)
+}
+
+func (s *PostgreSQLStore) AddTicketWatcher(watcher models.TicketWatcher) error {
+	return s.watcherRepo.Create(watcher)
+}
+
+func (s *PostgreSQLStore) UpdateTicketWatcher(watcher models.TicketWatcher) error {
+	return s.watcherRepo.UpdatePreferences(watcher)
+}
+
+func (s *PostgreSQLStore) RemoveTicketWatcher(id string) error {
+	return s.watcherRepo.Delete(id)
+}
+
+// Métodos para encuestas de satisfacción
+func (s *PostgreSQLStore) GetCSATSurveys() ([]models.CSATSurvey, error) {
+	return s.csatRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetCSATSurvey(id string) (*models.CSATSurvey, error) {
+	return s.csatRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) GetCSATSurveyByTicket(ticketID string) (*models.CSATSurvey, error) {
+	return s.csatRepo.GetByTicket(ticketID)
+}
+
+func (s *PostgreSQLStore) CreateCSATSurvey(survey models.CSATSurvey) error {
+	return s.csatRepo.Create(survey)
+}
+
+func (s *PostgreSQLStore) UpdateCSATSurvey(survey models.CSATSurvey) error {
+	return s.csatRepo.Update(survey)
+}
+
+// Métodos para informes
+func (s *PostgreSQLStore) ReportVolume(query models.ReportQuery) ([]models.VolumeRow, error) {
+	return s.reportRepo.Volume(query)
+}
+
+func (s *PostgreSQLStore) ReportFirstResponse(query models.ReportQuery) ([]models.DurationRow, error) {
+	return s.reportRepo.FirstResponse(query)
+}
+
+func (s *PostgreSQLStore) ReportResolution(query models.ReportQuery) ([]models.DurationRow, error) {
+	return s.reportRepo.Resolution(query)
+}
+
+func (s *PostgreSQLStore) ReportBacklog(query models.ReportQuery) ([]models.BacklogRow, error) {
+	return s.reportRepo.Backlog(query, time.Now())
+}
+
+func (s *PostgreSQLStore) ReportAgentWorkload(query models.ReportQuery) ([]models.AgentWorkloadRow, error) {
+	return s.reportRepo.AgentWorkload(query)
+}
+
+// RefreshReports refresca la vista materializada de los informes
+func (s *PostgreSQLStore) RefreshReports() (time.Time, error) {
+	started := time.Now()
+	if err := s.reportRepo.Refresh(); err != nil {
+		return s.ReportsDataAsOf(), err
+	}
+	s.reportsMu.Lock()
+	defer s.reportsMu.Unlock()
+	if started.After(s.reportsAsOf) {
+		s.reportsAsOf = started
+	}
+	return s.reportsAsOf, nil
+}
+
+// ReportsDataAsOf devuelve cuándo se refrescó por última vez la vista de los informes
+func (s *PostgreSQLStore) ReportsDataAsOf() time.Time {
+	s.reportsMu.Lock()
+	defer s.reportsMu.Unlock()
+	return s.reportsAsOf
+}
+
+// Métodos para exportaciones programadas
+func (s *PostgreSQLStore) GetReportSchedules() ([]models.ReportSchedule, error) {
+	return s.exportRepo.GetSchedules()
+}
+
+func (s *PostgreSQLStore) GetReportSchedule(id string) (*models.ReportSchedule, error) {
+	return s.exportRepo.GetSchedule(id)
+}
+
+func (s *PostgreSQLStore) CreateReportSchedule(schedule models.ReportSchedule) error {
+	return s.exportRepo.CreateSchedule(schedule)
+}
+
+func (s *PostgreSQLStore) UpdateReportSchedule(schedule models.ReportSchedule) error {
+	return s.exportRepo.UpdateSchedule(schedule)
+}
+
+func (s *PostgreSQLStore) DeleteReportSchedule(id string) error {
+	return s.exportRepo.DeleteSchedule(id)
+}
+
+func (s *PostgreSQLStore) GetReportExports(scheduleID string) ([]models.ReportExport, error) {
+	return s.exportRepo.GetExports(scheduleID)
+}
+
+func (s *PostgreSQLStore) GetReportExport(id string) (*models.ReportExport, error) {
+	return s.exportRepo.GetExport(id)
+}
+
+func (s *PostgreSQLStore) CreateReportExport(export models.ReportExport) error {
+	return s.exportRepo.CreateExport(export)
+}
+
+func (s *PostgreSQLStore) UpdateReportExport(export models.ReportExport) error {
+	return s.exportRepo.UpdateExport(export)
+}
+
+func (s *PostgreSQLStore) DeleteReportExport(id string) error {
+	return s.exportRepo.DeleteExport(id)
+}
+
 func (s *PostgreSQLStore) DeleteTicket(id string) error {
 	return s.ticketRepo.Delete(id)
 }
@@ -146,16 +376,253 @@ func (s *PostgreSQLStore) ToggleFAQPublish(id int) error {
 	return s.faqRepo.TogglePublish(id)
 }
 
+// Implementación de métodos para claves de servicio
+func (s *PostgreSQLStore) GetServiceKeys() ([]models.ServiceKey, error) {
+	return s.serviceKeyRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetServiceKeyByPrefix(prefix string) (*models.ServiceKey, error) {
+	return s.serviceKeyRepo.GetByPrefix(prefix)
+}
+
+func (s *PostgreSQLStore) CreateServiceKey(key models.ServiceKey) error {
+	return s.serviceKeyRepo.Create(key)
+}
+
+func (s *PostgreSQLStore) RevokeServiceKey(id string) error {
+	return s.serviceKeyRepo.Revoke(id)
+}
+
+func (s *PostgreSQLStore) TouchServiceKey(id string) error {
+	return s.serviceKeyRepo.Touch(id)
+}
+
+// Implementación de métodos para claves de API
+func (s *PostgreSQLStore) GetAPIKeys() ([]models.APIKey, error) {
+	return s.apiKeyRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	return s.apiKeyRepo.GetByPrefix(prefix)
+}
+
+func (s *PostgreSQLStore) CreateAPIKey(key models.APIKey) error {
+	return s.apiKeyRepo.Create(key)
+}
+
+func (s *PostgreSQLStore) RevokeAPIKey(id string) error {
+	return s.apiKeyRepo.Revoke(id)
+}
+
+func (s *PostgreSQLStore) TouchAPIKey(id string) error {
+	return s.apiKeyRepo.Touch(id)
+}
+
+// Implementación de métodos para reglas de enrutamiento
+func (s *PostgreSQLStore) GetRoutingRules() ([]models.RoutingRule, error) {
+	return s.routingRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetRoutingRule(id string) (*models.RoutingRule, error) {
+	return s.routingRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateRoutingRule(rule models.RoutingRule) error {
+	return s.routingRepo.Create(rule)
+}
+
+func (s *PostgreSQLStore) UpdateRoutingRule(rule models.RoutingRule) error {
+	return s.routingRepo.Update(rule)
+}
+
+func (s *PostgreSQLStore) DeleteRoutingRule(id string) error {
+	return s.routingRepo.Delete(id)
+}
+
+// Implementación de métodos para políticas de asignación automática
+func (s *PostgreSQLStore) GetAssignmentPolicies() ([]models.AssignmentPolicy, error) {
+	return s.assignmentRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetAssignmentPolicy(id string) (*models.AssignmentPolicy, error) {
+	return s.assignmentRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateAssignmentPolicy(policy models.AssignmentPolicy) error {
+	return s.assignmentRepo.Create(policy)
+}
+
+func (s *PostgreSQLStore) UpdateAssignmentPolicy(policy models.AssignmentPolicy) error {
+	return s.assignmentRepo.Update(policy)
+}
+
+func (s *PostgreSQLStore) DeleteAssignmentPolicy(id string) error {
+	return s.assignmentRepo.Delete(id)
+}
+
+// Implementación de métodos para equipos
+func (s *PostgreSQLStore) GetTeams() ([]models.Team, error) {
+	return s.teamRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetTeam(id string) (*models.Team, error) {
+	return s.teamRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateTeam(team models.Team) error {
+	return s.teamRepo.Create(team)
+}
+
+func (s *PostgreSQLStore) UpdateTeam(team models.Team) error {
+	return s.teamRepo.Update(team)
+}
+
+func (s *PostgreSQLStore) DeleteTeam(id string) error {
+	return s.teamRepo.Delete(id)
+}
+
+// Implementación de métodos para macros
+func (s *PostgreSQLStore) GetMacros() ([]models.Macro, error) {
+	return s.macroRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetMacro(id string) (*models.Macro, error) {
+	return s.macroRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateMacro(macro models.Macro) error {
+	return s.macroRepo.Create(macro)
+}
+
+func (s *PostgreSQLStore) UpdateMacro(macro models.Macro) error {
+	return s.macroRepo.Update(macro)
+}
+
+func (s *PostgreSQLStore) DeleteMacro(id string) error {
+	return s.macroRepo.Delete(id)
+}
+
+func (s *PostgreSQLStore) RecordMacroUsage(id string, usedAt time.Time) error {
+	return s.macroRepo.RecordUsage(id, usedAt)
+}
+
+// Implementación de métodos para etiquetas
+func (s *PostgreSQLStore) GetTags() ([]models.Tag, error) {
+	return s.tagRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetTag(id string) (*models.Tag, error) {
+	return s.tagRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateTag(tag models.Tag) error {
+	return s.tagRepo.Create(tag)
+}
+
+func (s *PostgreSQLStore) UpdateTag(tag models.Tag) error {
+	return s.tagRepo.Update(tag)
+}
+
+func (s *PostgreSQLStore) DeleteTag(id string) error {
+	return s.tagRepo.Delete(id)
+}
+
+// Implementación de métodos para campos personalizados y contactos
+func (s *PostgreSQLStore) GetCustomFields() ([]models.CustomField, error) {
+	return s.fieldRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetCustomField(id string) (*models.CustomField, error) {
+	return s.fieldRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateCustomField(field models.CustomField) error {
+	return s.fieldRepo.Create(field)
+}
+
+func (s *PostgreSQLStore) UpdateCustomField(field models.CustomField) error {
+	return s.fieldRepo.Update(field)
+}
+
+func (s *PostgreSQLStore) DeleteCustomField(id string) error {
+	return s.fieldRepo.Delete(id)
+}
+
+func (s *PostgreSQLStore) GetContacts() ([]models.Contact, error) {
+	return s.fieldRepo.GetContacts()
+}
+
+func (s *PostgreSQLStore) GetContact(email string) (*models.Contact, error) {
+	return s.fieldRepo.GetContact(email)
+}
+
+func (s *PostgreSQLStore) SaveContact(contact models.Contact) error {
+	return s.fieldRepo.SaveContact(contact)
+}
+
+// Implementación de métodos para calendarios de atención
+func (s *PostgreSQLStore) GetBusinessCalendars() ([]models.BusinessCalendar, error) {
+	return s.calendarRepo.GetAll()
+}
+
+func (s *PostgreSQLStore) GetBusinessCalendar(id string) (*models.BusinessCalendar, error) {
+	return s.calendarRepo.GetByID(id)
+}
+
+func (s *PostgreSQLStore) CreateBusinessCalendar(calendar models.BusinessCalendar) error {
+	return s.calendarRepo.Create(calendar)
+}
+
+func (s *PostgreSQLStore) UpdateBusinessCalendar(calendar models.BusinessCalendar) error {
+	return s.calendarRepo.Update(calendar)
+}
+
+func (s *PostgreSQLStore) DeleteBusinessCalendar(id string) error {
+	return s.calendarRepo.Delete(id)
+}
+
+// Implementación de métodos para el historial de actividad
+func (s *PostgreSQLStore) CreateActivity(activity models.Activity) error {
+	return s.activityRepo.Create(activity)
+}
+
+func (s *PostgreSQLStore) GetActivities(targetID string) ([]models.Activity, error) {
+	return s.activityRepo.GetByTarget(targetID)
+}
+
+// Implementación de métodos para notificaciones
+func (s *PostgreSQLStore) CreateNotification(notification models.Notification) error {
+	return s.notifyRepo.Create(notification)
+}
+
+func (s *PostgreSQLStore) GetNotifications(userID string, unreadOnly bool) ([]models.Notification, error) {
+	return s.notifyRepo.GetByUser(userID, unreadOnly)
+}
+
+func (s *PostgreSQLStore) MarkNotificationRead(userID, id string) error {
+	return s.notifyRepo.MarkRead(userID, id)
+}
+
+func (s *PostgreSQLStore) MarkAllNotificationsRead(userID string) (int, error) {
+	return s.notifyRepo.MarkAllRead(userID)
+}
+
 // Implementación de métodos para WebSocket
-func (s *PostgreSQLStore) AddWSConnection(ticketID string, conn *websocket.Conn) string {
+func (s *PostgreSQLStore) AddWSConnection(ticketID string, conn *websocket.Conn, agent, notesOnly bool) string {
 	s.wsConnectionMu.Lock()
 	defer s.wsConnectionMu.Unlock()
 
-	connectionID := fmt.Sprintf("conn-%d", len(s.wsConnections))
+	connectionID := fmt.Sprintf("conn-%d", time.Now().UnixNano())
 	if _, exists := s.wsConnections[ticketID]; !exists {
 		s.wsConnections[ticketID] = make(map[string]*websocket.Conn)
 	}
 	s.wsConnections[ticketID][connectionID] = conn
+	if agent {
+		s.wsAgentConns[connectionID] = true
+	}
+	if notesOnly {
+		s.wsNotesConns[connectionID] = true
+	}
 	return connectionID
 }
 
@@ -163,6 +630,8 @@ func (s *PostgreSQLStore) RemoveWSConnection(ticketID, connectionID string) {
 	s.wsConnectionMu.Lock()
 	defer s.wsConnectionMu.Unlock()
 
+	delete(s.wsAgentConns, connectionID)
+	delete(s.wsNotesConns, connectionID)
 	if conns, exists := s.wsConnections[ticketID]; exists {
 		delete(conns, connectionID)
 		if len(conns) == 0 {
@@ -176,65 +645,142 @@ func (s *PostgreSQLStore) BroadcastMessage(ticketID string, message models.Messa
 	defer s.wsConnectionMu.Unlock()
 
 	if conns, exists := s.wsConnections[ticketID]; exists {
-		for _, conn := range conns {
+		for connectionID, conn := range conns {
+			// Las notas internas sólo llegan a los agentes y el canal de notas no recibe
+			// los mensajes públicos
+			if message.IsInternal && !s.wsAgentConns[connectionID] {
+				continue
+			}
+			if !message.IsInternal && s.wsNotesConns[connectionID] {
+				continue
+			}
 			err := conn.WriteJSON(message)
 			if err != nil {
 				// Si hay error al enviar, simplemente registramos y continuamos
-				fmt.Printf("Error al enviar mensaje por WebSocket: %v\n", err)
+				logging.Errorf("Error al enviar mensaje por WebSocket: %v", err)
 			}
 		}
 	}
 
-	// Notificar al widget-api sobre el nuevo mensaje
-	go s.notifyWidgetAPI(ticketID, message)
+	// Notificar al widget-api sólo los mensajes de agentes; los del cliente
+	// ya los tiene porque llegan a través de su outbox. Las notas internas nunca.
+	if !message.IsClient && !message.IsInternal {
+		go s.notifyWidgetAPI(ticketID, message)
+	}
 }
 
-// notifyWidgetAPI envía una notificación HTTP al widget-api cuando hay un nuevo mensaje
-func (s *PostgreSQLStore) notifyWidgetAPI(ticketID string, message models.Message) {
-	// Obtener la URL del widget-api desde las variables de entorno
-	widgetAPIURL := os.Getenv("WIDGET_API_URL")
-	if widgetAPIURL == "" {
-		widgetAPIURL = "http://growdesk-widget-api:3000" // URL por defecto en Docker
-		fmt.Printf("WIDGET_API_URL no definida, usando valor por defecto: %s\n", widgetAPIURL)
+// RedirectWSConnections avisa a los clientes conectados a fromTicketID de que el ticket
+// se fusionó en toTicketID y cierra sus conexiones para que se reconecten al nuevo
+func (s *PostgreSQLStore) RedirectWSConnections(fromTicketID, toTicketID string) int {
+	s.wsConnectionMu.Lock()
+	defer s.wsConnectionMu.Unlock()
+
+	event := models.WebSocketMessage{
+		Type:     "ticket_merged",
+		TicketID: fromTicketID,
+		Data:     map[string]interface{}{"mergedInto": toTicketID},
+	}
+	conns := s.wsConnections[fromTicketID]
+	for _, conn := range conns {
+		if err := conn.WriteJSON(event); err != nil {
+			logging.Errorf("Error al avisar de la fusión por WebSocket: %v", err)
+		}
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "ticket_merged"), time.Now().Add(time.Second))
+		conn.Close()
 	}
+	return len(conns)
+}
 
-	// Normalizar URL
-	widgetAPIURL = strings.TrimSuffix(widgetAPIURL, "/")
+// SendCSATSurvey envía la encuesta a los clientes conectados directamente al backend y
+// a widget-api, que la reenvía a las sesiones del widget del ticket
+func (s *PostgreSQLStore) SendCSATSurvey(ticketID string, invite models.WebSocketMessage) {
+	s.wsConnectionMu.Lock()
+	for connectionID, conn := range s.wsConnections[ticketID] {
+		if s.wsAgentConns[connectionID] {
+			continue
+		}
+		if err := conn.WriteJSON(invite); err != nil {
+			logging.Errorf("Error al enviar la encuesta por WebSocket: %v", err)
+		}
+	}
+	s.wsConnectionMu.Unlock()
 
-	// Construir URL para la API de mensajes de agente
-	url := fmt.Sprintf("%s/api/agent/messages", widgetAPIURL)
+	go s.postToWidgetAPI("/api/agent/csat-survey", ticketID, invite)
+}
 
-	fmt.Printf("Notificando al widget-api sobre nuevo mensaje para ticket %s en URL: %s\n", ticketID, url)
+// notifyWidgetAPI envía una notificación HTTP al widget-api cuando hay un nuevo mensaje
+func (s *PostgreSQLStore) notifyWidgetAPI(ticketID string, message models.Message) {
+	logging.Infof("Notificando al widget-api sobre nuevo mensaje para ticket %s", ticketID)
 
 	// Crear estructura de mensaje para el widget-api
 	agentMessage := struct {
 		TicketID  string `json:"ticketId"`
+		MessageID string `json:"messageId"`
 		Content   string `json:"content"`
 		UserID    string `json:"userId,omitempty"`
 		AgentName string `json:"agentName,omitempty"`
 	}{
 		TicketID:  ticketID,
+		MessageID: message.ID,
 		Content:   message.Content,
 		UserID:    message.UserID,
 		AgentName: message.UserName,
 	}
 
+	s.postToWidgetAPI("/api/agent/messages", ticketID, agentMessage)
+}
+
+// notifyWidgetAPIMerge avisa al widget-api de que un ticket se fusionó en otro, para que
+// las sesiones del widget del ticket original sigan la conversación en el nuevo
+func (s *PostgreSQLStore) notifyWidgetAPIMerge(sourceID, targetID string) {
+	merge := struct {
+		TicketID   string `json:"ticketId"`
+		MergedInto string `json:"mergedInto"`
+	}{
+		TicketID:   sourceID,
+		MergedInto: targetID,
+	}
+
+	s.postToWidgetAPI("/api/agent/ticket-merged", sourceID, merge)
+}
+
+// postToWidgetAPI envía payload como JSON a la ruta indicada del widget-api
+func (s *PostgreSQLStore) postToWidgetAPI(path, ticketID string, payload interface{}) {
+	// Obtener la URL del widget-api desde las variables de entorno
+	widgetAPIURL := os.Getenv("WIDGET_API_URL")
+	if widgetAPIURL == "" {
+		widgetAPIURL = "http://growdesk-widget-api:3000" // URL por defecto en Docker
+		logging.Infof("WIDGET_API_URL no definida, usando valor por defecto: %s", widgetAPIURL)
+	}
+
+	// Normalizar URL
+	widgetAPIURL = strings.TrimSuffix(widgetAPIURL, "/")
+	url := widgetAPIURL + path
+
 	// Convertir a JSON
-	jsonData, err := json.Marshal(agentMessage)
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		fmt.Printf("Error al convertir mensaje a JSON para widget-api: %v\n", err)
+		logging.Errorf("Error al convertir notificación a JSON para widget-api: %v", err)
 		return
 	}
 
 	// Crear solicitud HTTP
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(s.ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		fmt.Printf("Error al crear solicitud HTTP para widget-api: %v\n", err)
+		logging.Errorf("Error al crear solicitud HTTP para widget-api: %v", err)
 		return
 	}
 
-	// Configurar cabeceras
+	// Configurar cabeceras; el ID de solicitud y la traza continúan en widget-api
 	req.Header.Set("Content-Type", "application/json")
+	logging.SetRequestHeader(s.ctx, req.Header)
+	telemetry.Inject(s.ctx, req.Header)
+
+	// Autenticarse ante widget-api con la clave de servicio compartida
+	if serviceKey := os.Getenv("WIDGET_API_SERVICE_KEY"); serviceKey != "" {
+		req.Header.Set("Authorization", "Bearer "+serviceKey)
+	}
 
 	// Crear cliente HTTP con timeout
 	client := &http.Client{
@@ -244,7 +790,7 @@ func (s *PostgreSQLStore) notifyWidgetAPI(ticketID string, message models.Messag
 	// Enviar solicitud
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("Error al enviar notificación al widget-api: %v\n", err)
+		logging.Errorf("Error al enviar notificación al widget-api: %v", err)
 		return
 	}
 	defer resp.Body.Close()
@@ -254,10 +800,10 @@ func (s *PostgreSQLStore) notifyWidgetAPI(ticketID string, message models.Messag
 
 	// Verificar código de respuesta
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		fmt.Printf("Notificación enviada correctamente al widget-api para ticket %s. Respuesta: %s\n", ticketID, string(body))
+		logging.Debugf("Notificación enviada correctamente al widget-api (%s) para ticket %s", path, ticketID)
 	} else {
-		fmt.Printf("Error al enviar notificación al widget-api para ticket %s. Código: %d, Respuesta: %s\n",
-			ticketID, resp.StatusCode, string(body))
+		logging.Errorf("Error al enviar notificación al widget-api (%s) para ticket %s. Código: %d, Respuesta: %s",
+			path, ticketID, resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 }
 
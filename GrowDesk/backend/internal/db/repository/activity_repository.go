@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// ActivityRepository maneja las operaciones de base de datos del historial de actividad
+type ActivityRepository struct {
+	db DB
+}
+
+// NewActivityRepository crea un nuevo repositorio de actividad
+func NewActivityRepository(db DB) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+// Create registra una actividad
+func (r *ActivityRepository) Create(activity models.Activity) error {
+	if activity.ID == "" {
+		activity.ID = uuid.New().String()
+	}
+	if activity.Timestamp.IsZero() {
+		activity.Timestamp = time.Now()
+	}
+
+	var metadataJSON sql.NullString
+	if len(activity.Metadata) > 0 {
+		data, err := json.Marshal(activity.Metadata)
+		if err != nil {
+			return fmt.Errorf("error al serializar metadata de la actividad: %v", err)
+		}
+		metadataJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO activities (id, user_id, type, target_id, description, metadata, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, activity.ID, nullString(activity.UserID), activity.Type, nullString(activity.TargetID),
+		activity.Description, metadataJSON, activity.Timestamp)
+	if err != nil {
+		return fmt.Errorf("error al registrar actividad: %v", err)
+	}
+
+	return nil
+}
+
+// GetByTarget obtiene las actividades de un objeto (todas si targetID está vacío), de la más reciente a la más antigua
+func (r *ActivityRepository) GetByTarget(targetID string) ([]models.Activity, error) {
+	query := `SELECT id, user_id, type, target_id, description, metadata, timestamp FROM activities`
+	args := []interface{}{}
+	if targetID != "" {
+		query += ` WHERE target_id = $1`
+		args = append(args, targetID)
+	}
+	query += ` ORDER BY timestamp DESC LIMIT 1000`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar actividades: %v", err)
+	}
+	defer rows.Close()
+
+	activities := make([]models.Activity, 0)
+	for rows.Next() {
+		var activity models.Activity
+		var userID, target sql.NullString
+		var metadataJSON []byte
+
+		if err := rows.Scan(&activity.ID, &userID, &activity.Type, &target, &activity.Description, &metadataJSON, &activity.Timestamp); err != nil {
+			return nil, fmt.Errorf("error al escanear actividad: %v", err)
+		}
+		activity.UserID = userID.String
+		activity.TargetID = target.String
+		if len(metadataJSON) > 0 {
+			json.Unmarshal(metadataJSON, &activity.Metadata)
+		}
+		activities = append(activities, activity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar actividades: %v", err)
+	}
+
+	return activities, nil
+}
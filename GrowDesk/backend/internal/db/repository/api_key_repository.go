@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// APIKeyRepository maneja las operaciones de base de datos para las claves de API
+type APIKeyRepository struct {
+	db DB
+}
+
+// NewAPIKeyRepository crea un nuevo repositorio de claves de API
+func NewAPIKeyRepository(db DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, owner_type, user_id, key_prefix, key_hash, scopes, rate_limit,
+		       expires_at, created_at, last_used_at, revoked_at`
+
+// scanAPIKey convierte una fila en una clave de API
+func scanAPIKey(scanner interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopesJSON []byte
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.OwnerType,
+		&key.UserID,
+		&key.KeyPrefix,
+		&key.KeyHash,
+		&scopesJSON,
+		&key.RateLimit,
+		&expiresAt,
+		&key.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = []string{}
+	if len(scopesJSON) > 0 {
+		if err := json.Unmarshal(scopesJSON, &key.Scopes); err != nil {
+			return nil, fmt.Errorf("error al parsear scopes de la clave %s: %v", key.ID, err)
+		}
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+// GetAll obtiene todas las claves de API
+func (r *APIKeyRepository) GetAll() ([]models.APIKey, error) {
+	rows, err := r.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar claves de API: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear clave de API: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar claves de API: %v", err)
+	}
+
+	return keys, nil
+}
+
+// GetByPrefix obtiene una clave de API por su parte pública
+func (r *APIKeyRepository) GetByPrefix(prefix string) (*models.APIKey, error) {
+	row := r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_prefix = $1`, prefix)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("clave de API %s no encontrada", prefix)
+		}
+		return nil, fmt.Errorf("error al consultar clave de API: %v", err)
+	}
+	return key, nil
+}
+
+// Create crea una nueva clave de API
+func (r *APIKeyRepository) Create(key models.APIKey) error {
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("error al serializar scopes: %v", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO api_keys (id, name, owner_type, user_id, key_prefix, key_hash, scopes, rate_limit, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, key.ID, key.Name, key.OwnerType, key.UserID, key.KeyPrefix, key.KeyHash, scopesJSON, key.RateLimit, key.ExpiresAt, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear clave de API: %v", err)
+	}
+
+	return nil
+}
+
+// Revoke marca una clave de API como revocada
+func (r *APIKeyRepository) Revoke(id string) error {
+	result, err := r.db.Exec(`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al revocar clave de API: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("clave de API con ID %s no encontrada", id)
+	}
+
+	return nil
+}
+
+// Touch registra el último uso de una clave de API
+func (r *APIKeyRepository) Touch(id string) error {
+	if _, err := r.db.Exec(`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error al actualizar último uso de la clave: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// AssignmentPolicyRepository maneja las operaciones de base de datos para las políticas de asignación
+type AssignmentPolicyRepository struct {
+	db DB
+}
+
+// NewAssignmentPolicyRepository crea un nuevo repositorio de políticas de asignación
+func NewAssignmentPolicyRepository(db DB) *AssignmentPolicyRepository {
+	return &AssignmentPolicyRepository{db: db}
+}
+
+const assignmentPolicyColumns = `id, name, team_id, department, strategy, agent_ids, max_open_tickets,
+		       enabled, last_assigned_to, created_at, updated_at`
+
+// scanAssignmentPolicy convierte una fila en una política de asignación
+func scanAssignmentPolicy(scanner interface{ Scan(...interface{}) error }) (*models.AssignmentPolicy, error) {
+	var policy models.AssignmentPolicy
+	var teamID, department, lastAssignedTo sql.NullString
+	var agentIDsJSON []byte
+
+	err := scanner.Scan(
+		&policy.ID,
+		&policy.Name,
+		&teamID,
+		&department,
+		&policy.Strategy,
+		&agentIDsJSON,
+		&policy.MaxOpenTickets,
+		&policy.Enabled,
+		&lastAssignedTo,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.TeamID = teamID.String
+	policy.Department = department.String
+	policy.LastAssignedTo = lastAssignedTo.String
+	if len(agentIDsJSON) > 0 {
+		if err := json.Unmarshal(agentIDsJSON, &policy.AgentIDs); err != nil {
+			return nil, fmt.Errorf("error al parsear agentes de la política %s: %v", policy.ID, err)
+		}
+	}
+
+	return &policy, nil
+}
+
+// GetAll obtiene todas las políticas de asignación
+func (r *AssignmentPolicyRepository) GetAll() ([]models.AssignmentPolicy, error) {
+	rows, err := r.db.Query(`SELECT ` + assignmentPolicyColumns + ` FROM assignment_policies ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar políticas de asignación: %v", err)
+	}
+	defer rows.Close()
+
+	policies := make([]models.AssignmentPolicy, 0)
+	for rows.Next() {
+		policy, err := scanAssignmentPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear política de asignación: %v", err)
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar políticas de asignación: %v", err)
+	}
+
+	return policies, nil
+}
+
+// GetByID obtiene una política de asignación por su ID
+func (r *AssignmentPolicyRepository) GetByID(id string) (*models.AssignmentPolicy, error) {
+	row := r.db.QueryRow(`SELECT `+assignmentPolicyColumns+` FROM assignment_policies WHERE id = $1`, id)
+	policy, err := scanAssignmentPolicy(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("política de asignación con ID %s no encontrada", id)
+		}
+		return nil, fmt.Errorf("error al consultar política de asignación: %v", err)
+	}
+	return policy, nil
+}
+
+// Create crea una nueva política de asignación
+func (r *AssignmentPolicyRepository) Create(policy models.AssignmentPolicy) error {
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if policy.CreatedAt.IsZero() {
+		policy.CreatedAt = now
+	}
+	policy.UpdatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO assignment_policies (id, name, team_id, department, strategy, agent_ids,
+		                                 max_open_tickets, enabled, last_assigned_to, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, policy.ID, policy.Name, nullString(policy.TeamID), nullString(policy.Department), policy.Strategy,
+		stringListJSON(policy.AgentIDs), policy.MaxOpenTickets, policy.Enabled, nullString(policy.LastAssignedTo),
+		policy.CreatedAt, policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear política de asignación: %v", err)
+	}
+
+	return nil
+}
+
+// Update actualiza una política de asignación existente
+func (r *AssignmentPolicyRepository) Update(policy models.AssignmentPolicy) error {
+	result, err := r.db.Exec(`
+		UPDATE assignment_policies
+		SET name = $2, team_id = $3, department = $4, strategy = $5, agent_ids = $6,
+		    max_open_tickets = $7, enabled = $8, last_assigned_to = $9, updated_at = NOW()
+		WHERE id = $1
+	`, policy.ID, policy.Name, nullString(policy.TeamID), nullString(policy.Department), policy.Strategy,
+		stringListJSON(policy.AgentIDs), policy.MaxOpenTickets, policy.Enabled, nullString(policy.LastAssignedTo))
+	if err != nil {
+		return fmt.Errorf("error al actualizar política de asignación: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("política de asignación con ID %s no encontrada", policy.ID)
+	}
+
+	return nil
+}
+
+// Delete elimina una política de asignación
+func (r *AssignmentPolicyRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM assignment_policies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar política de asignación: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("política de asignación con ID %s no encontrada", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// BusinessCalendarRepository maneja las operaciones de base de datos para los calendarios de atención
+type BusinessCalendarRepository struct {
+	db DB
+}
+
+// NewBusinessCalendarRepository crea un nuevo repositorio de calendarios de atención
+func NewBusinessCalendarRepository(db DB) *BusinessCalendarRepository {
+	return &BusinessCalendarRepository{db: db}
+}
+
+const businessCalendarColumns = `id, name, time_zone, hours, holidays, widget_ids, is_default, created_at, updated_at`
+
+// scanBusinessCalendar convierte una fila en un calendario de atención
+func scanBusinessCalendar(scanner interface{ Scan(...interface{}) error }) (*models.BusinessCalendar, error) {
+	var calendar models.BusinessCalendar
+	var hoursJSON, holidaysJSON, widgetIDsJSON []byte
+
+	err := scanner.Scan(
+		&calendar.ID,
+		&calendar.Name,
+		&calendar.TimeZone,
+		&hoursJSON,
+		&holidaysJSON,
+		&widgetIDsJSON,
+		&calendar.IsDefault,
+		&calendar.CreatedAt,
+		&calendar.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(hoursJSON, &calendar.Hours); err != nil {
+		return nil, fmt.Errorf("error al parsear turnos del calendario %s: %v", calendar.ID, err)
+	}
+	if err := json.Unmarshal(holidaysJSON, &calendar.Holidays); err != nil {
+		return nil, fmt.Errorf("error al parsear festivos del calendario %s: %v", calendar.ID, err)
+	}
+	if err := json.Unmarshal(widgetIDsJSON, &calendar.WidgetIDs); err != nil {
+		return nil, fmt.Errorf("error al parsear widgets del calendario %s: %v", calendar.ID, err)
+	}
+
+	return &calendar, nil
+}
+
+// GetAll obtiene todos los calendarios ordenados por nombre
+func (r *BusinessCalendarRepository) GetAll() ([]models.BusinessCalendar, error) {
+	rows, err := r.db.Query(`SELECT ` + businessCalendarColumns + ` FROM business_calendars ORDER BY LOWER(name)`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar calendarios: %v", err)
+	}
+	defer rows.Close()
+
+	calendars := make([]models.BusinessCalendar, 0)
+	for rows.Next() {
+		calendar, err := scanBusinessCalendar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear calendario: %v", err)
+		}
+		calendars = append(calendars, *calendar)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar calendarios: %v", err)
+	}
+
+	return calendars, nil
+}
+
+// GetByID obtiene un calendario por su ID
+func (r *BusinessCalendarRepository) GetByID(id string) (*models.BusinessCalendar, error) {
+	row := r.db.QueryRow(`SELECT `+businessCalendarColumns+` FROM business_calendars WHERE id = $1`, id)
+	calendar, err := scanBusinessCalendar(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("calendario con ID %s no encontrado", id)
+		}
+		return nil, fmt.Errorf("error al consultar calendario: %v", err)
+	}
+	return calendar, nil
+}
+
+// Create crea un nuevo calendario de atención
+func (r *BusinessCalendarRepository) Create(calendar models.BusinessCalendar) error {
+	if calendar.ID == "" {
+		calendar.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if calendar.CreatedAt.IsZero() {
+		calendar.CreatedAt = now
+	}
+	calendar.UpdatedAt = now
+
+	hours, holidays, widgetIDs := businessCalendarJSON(calendar)
+	_, err := r.db.Exec(`
+		INSERT INTO business_calendars (id, name, time_zone, hours, holidays, widget_ids, is_default, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, calendar.ID, calendar.Name, calendar.TimeZone, hours, holidays, widgetIDs, calendar.IsDefault, calendar.CreatedAt, calendar.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear calendario: %v", err)
+	}
+	return nil
+}
+
+// Update actualiza un calendario de atención existente
+func (r *BusinessCalendarRepository) Update(calendar models.BusinessCalendar) error {
+	hours, holidays, widgetIDs := businessCalendarJSON(calendar)
+	result, err := r.db.Exec(`
+		UPDATE business_calendars
+		SET name = $2, time_zone = $3, hours = $4, holidays = $5, widget_ids = $6, is_default = $7, updated_at = NOW()
+		WHERE id = $1
+	`, calendar.ID, calendar.Name, calendar.TimeZone, hours, holidays, widgetIDs, calendar.IsDefault)
+	if err != nil {
+		return fmt.Errorf("error al actualizar calendario: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("calendario con ID %s no encontrado", calendar.ID)
+	}
+	return nil
+}
+
+// Delete elimina un calendario; los equipos que lo usaban quedan sin calendario
+// por la clave foránea
+func (r *BusinessCalendarRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM business_calendars WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar calendario: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("calendario con ID %s no encontrado", id)
+	}
+	return nil
+}
+
+// businessCalendarJSON serializa los campos JSONB del calendario
+func businessCalendarJSON(calendar models.BusinessCalendar) (string, string, string) {
+	hours := calendar.Hours
+	if hours == nil {
+		hours = []models.WorkingShift{}
+	}
+	holidays := calendar.Holidays
+	if holidays == nil {
+		holidays = []models.Holiday{}
+	}
+	hoursJSON, _ := json.Marshal(hours)
+	holidaysJSON, _ := json.Marshal(holidays)
+	return string(hoursJSON), string(holidaysJSON), stringListJSON(calendar.WidgetIDs)
+}
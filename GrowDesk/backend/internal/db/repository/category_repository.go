@@ -10,11 +10,11 @@ import (
 
 // CategoryRepository maneja las operaciones de base de datos para las categorías
 type CategoryRepository struct {
-	db *sql.DB
+	db DB
 }
 
 // NewCategoryRepository crea un nuevo repositorio de categorías
-func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+func NewCategoryRepository(db DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
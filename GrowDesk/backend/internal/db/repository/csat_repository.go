@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// CSATRepository maneja las operaciones de base de datos para las encuestas de satisfacción
+type CSATRepository struct {
+	db DB
+}
+
+// NewCSATRepository crea un nuevo repositorio de encuestas de satisfacción
+func NewCSATRepository(db DB) *CSATRepository {
+	return &CSATRepository{db: db}
+}
+
+const csatColumns = `id, ticket_id, COALESCE(agent_id, ''), COALESCE(team_id, ''), COALESCE(category_id, ''),
+	COALESCE(category, ''), COALESCE(customer_email, ''), COALESCE(rating, 0), COALESCE(comment, ''),
+	COALESCE(channel, ''), sent_at, expires_at, responded_at`
+
+// scanCSATSurvey convierte una fila en una encuesta
+func scanCSATSurvey(scanner interface{ Scan(...interface{}) error }) (*models.CSATSurvey, error) {
+	var survey models.CSATSurvey
+	var respondedAt sql.NullTime
+
+	err := scanner.Scan(
+		&survey.ID,
+		&survey.TicketID,
+		&survey.AgentID,
+		&survey.TeamID,
+		&survey.CategoryID,
+		&survey.Category,
+		&survey.CustomerEmail,
+		&survey.Rating,
+		&survey.Comment,
+		&survey.Channel,
+		&survey.SentAt,
+		&survey.ExpiresAt,
+		&respondedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		survey.RespondedAt = &respondedAt.Time
+	}
+	return &survey, nil
+}
+
+// GetAll obtiene todas las encuestas, las más recientes primero
+func (r *CSATRepository) GetAll() ([]models.CSATSurvey, error) {
+	rows, err := r.db.Query(`SELECT ` + csatColumns + ` FROM csat_surveys ORDER BY sent_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar encuestas: %v", err)
+	}
+	defer rows.Close()
+
+	surveys := make([]models.CSATSurvey, 0)
+	for rows.Next() {
+		survey, err := scanCSATSurvey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear encuesta: %v", err)
+		}
+		surveys = append(surveys, *survey)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar encuestas: %v", err)
+	}
+	return surveys, nil
+}
+
+// GetByID obtiene una encuesta por su ID
+func (r *CSATRepository) GetByID(id string) (*models.CSATSurvey, error) {
+	survey, err := scanCSATSurvey(r.db.QueryRow(`SELECT `+csatColumns+` FROM csat_surveys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("encuesta con ID %s no encontrada", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener encuesta: %v", err)
+	}
+	return survey, nil
+}
+
+// GetByTicket obtiene la encuesta más reciente de un ticket
+func (r *CSATRepository) GetByTicket(ticketID string) (*models.CSATSurvey, error) {
+	survey, err := scanCSATSurvey(r.db.QueryRow(`
+		SELECT `+csatColumns+` FROM csat_surveys WHERE ticket_id = $1 ORDER BY sent_at DESC LIMIT 1
+	`, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("el ticket %s no tiene encuesta", ticketID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener encuesta: %v", err)
+	}
+	return survey, nil
+}
+
+// Create guarda una encuesta nueva
+func (r *CSATRepository) Create(survey models.CSATSurvey) error {
+	if survey.ID == "" {
+		survey.ID = uuid.New().String()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO csat_surveys (id, ticket_id, agent_id, team_id, category_id, category, customer_email,
+			sent_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, survey.ID, survey.TicketID, nullString(survey.AgentID), nullString(survey.TeamID),
+		nullString(survey.CategoryID), nullString(survey.Category), nullString(survey.CustomerEmail),
+		survey.SentAt, survey.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error al crear encuesta: %v", err)
+	}
+	return nil
+}
+
+// Update guarda la respuesta de una encuesta
+func (r *CSATRepository) Update(survey models.CSATSurvey) error {
+	var rating sql.NullInt64
+	if survey.Rating > 0 {
+		rating = sql.NullInt64{Int64: int64(survey.Rating), Valid: true}
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE csat_surveys SET rating = $2, comment = $3, channel = $4, responded_at = $5
+		WHERE id = $1
+	`, survey.ID, rating, nullString(survey.Comment), nullString(survey.Channel), survey.RespondedAt)
+	if err != nil {
+		return fmt.Errorf("error al actualizar encuesta: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("encuesta con ID %s no encontrada", survey.ID)
+	}
+	return nil
+}
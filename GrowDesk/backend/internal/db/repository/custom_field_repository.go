@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// CustomFieldRepository maneja las operaciones de base de datos para los campos
+// personalizados y los contactos
+type CustomFieldRepository struct {
+	db DB
+}
+
+// NewCustomFieldRepository crea un nuevo repositorio de campos personalizados
+func NewCustomFieldRepository(db DB) *CustomFieldRepository {
+	return &CustomFieldRepository{db: db}
+}
+
+const customFieldColumns = `id, key, label, COALESCE(description, ''), COALESCE(placeholder, ''), type, entity,
+		       options, required, show_in_prechat, position, active, created_at, updated_at`
+
+func scanCustomField(scanner interface{ Scan(...interface{}) error }) (*models.CustomField, error) {
+	var field models.CustomField
+	var optionsJSON []byte
+	err := scanner.Scan(
+		&field.ID,
+		&field.Key,
+		&field.Label,
+		&field.Description,
+		&field.Placeholder,
+		&field.Type,
+		&field.Entity,
+		&optionsJSON,
+		&field.Required,
+		&field.ShowInPreChat,
+		&field.Position,
+		&field.Active,
+		&field.CreatedAt,
+		&field.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(optionsJSON) > 0 {
+		if err := json.Unmarshal(optionsJSON, &field.Options); err != nil {
+			return nil, fmt.Errorf("error al parsear opciones del campo %s: %v", field.ID, err)
+		}
+	}
+	return &field, nil
+}
+
+// GetAll obtiene los campos personalizados ordenados por entidad y posición
+func (r *CustomFieldRepository) GetAll() ([]models.CustomField, error) {
+	rows, err := r.db.Query(`SELECT ` + customFieldColumns + ` FROM custom_fields ORDER BY entity DESC, position, created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar campos personalizados: %v", err)
+	}
+	defer rows.Close()
+
+	fields := make([]models.CustomField, 0)
+	for rows.Next() {
+		field, err := scanCustomField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear campo personalizado: %v", err)
+		}
+		fields = append(fields, *field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar campos personalizados: %v", err)
+	}
+	return fields, nil
+}
+
+// GetByID obtiene un campo personalizado por su ID
+func (r *CustomFieldRepository) GetByID(id string) (*models.CustomField, error) {
+	field, err := scanCustomField(r.db.QueryRow(`SELECT `+customFieldColumns+` FROM custom_fields WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("campo personalizado con ID %s no encontrado", id)
+		}
+		return nil, fmt.Errorf("error al consultar campo personalizado: %v", err)
+	}
+	return field, nil
+}
+
+// Create crea un campo personalizado
+func (r *CustomFieldRepository) Create(field models.CustomField) error {
+	if field.ID == "" {
+		field.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if field.CreatedAt.IsZero() {
+		field.CreatedAt = now
+	}
+	field.UpdatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO custom_fields (id, key, label, description, placeholder, type, entity, options,
+			required, show_in_prechat, position, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`, field.ID, field.Key, field.Label, nullString(field.Description), nullString(field.Placeholder),
+		field.Type, field.Entity, stringListJSON(field.Options), field.Required, field.ShowInPreChat,
+		field.Position, field.Active, field.CreatedAt, field.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "custom_fields_entity_key_key") {
+			return fmt.Errorf("ya existe un campo %q para %s", field.Key, field.Entity)
+		}
+		return fmt.Errorf("error al crear campo personalizado: %v", err)
+	}
+	return nil
+}
+
+// Update actualiza un campo personalizado
+func (r *CustomFieldRepository) Update(field models.CustomField) error {
+	result, err := r.db.Exec(`
+		UPDATE custom_fields
+		SET key = $2, label = $3, description = $4, placeholder = $5, type = $6, entity = $7,
+		    options = $8, required = $9, show_in_prechat = $10, position = $11, active = $12,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, field.ID, field.Key, field.Label, nullString(field.Description), nullString(field.Placeholder),
+		field.Type, field.Entity, stringListJSON(field.Options), field.Required, field.ShowInPreChat,
+		field.Position, field.Active)
+	if err != nil {
+		return fmt.Errorf("error al actualizar campo personalizado: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("campo personalizado con ID %s no encontrado", field.ID)
+	}
+	return nil
+}
+
+// Delete elimina un campo personalizado; los valores guardados se conservan
+func (r *CustomFieldRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM custom_fields WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar campo personalizado: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("campo personalizado con ID %s no encontrado", id)
+	}
+	return nil
+}
+
+func scanContact(scanner interface{ Scan(...interface{}) error }) (*models.Contact, error) {
+	var contact models.Contact
+	var fieldsJSON []byte
+	if err := scanner.Scan(&contact.Email, &contact.Name, &fieldsJSON, &contact.CreatedAt, &contact.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(fieldsJSON) > 0 {
+		if err := json.Unmarshal(fieldsJSON, &contact.CustomFields); err != nil {
+			return nil, fmt.Errorf("error al parsear campos del contacto %s: %v", contact.Email, err)
+		}
+		if len(contact.CustomFields) == 0 {
+			contact.CustomFields = nil
+		}
+	}
+	return &contact, nil
+}
+
+// GetContacts obtiene los contactos ordenados por email
+func (r *CustomFieldRepository) GetContacts() ([]models.Contact, error) {
+	rows, err := r.db.Query(`SELECT email, COALESCE(name, ''), custom_fields, created_at, updated_at FROM contacts ORDER BY email`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar contactos: %v", err)
+	}
+	defer rows.Close()
+
+	contacts := make([]models.Contact, 0)
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear contacto: %v", err)
+		}
+		contacts = append(contacts, *contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar contactos: %v", err)
+	}
+	return contacts, nil
+}
+
+// GetContact obtiene un contacto por email
+func (r *CustomFieldRepository) GetContact(email string) (*models.Contact, error) {
+	row := r.db.QueryRow(`
+		SELECT email, COALESCE(name, ''), custom_fields, created_at, updated_at
+		FROM contacts WHERE email = LOWER($1)
+	`, strings.TrimSpace(email))
+	contact, err := scanContact(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("contacto %s no encontrado", email)
+		}
+		return nil, fmt.Errorf("error al consultar contacto: %v", err)
+	}
+	return contact, nil
+}
+
+// SaveContact crea o reemplaza un contacto
+func (r *CustomFieldRepository) SaveContact(contact models.Contact) error {
+	email := strings.ToLower(strings.TrimSpace(contact.Email))
+	if email == "" {
+		return fmt.Errorf("el email del contacto es obligatorio")
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO contacts (email, name, custom_fields, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (email) DO UPDATE SET
+			name = EXCLUDED.name,
+			custom_fields = EXCLUDED.custom_fields,
+			updated_at = NOW()
+	`, email, nullString(contact.Name), customFieldsJSON(contact.CustomFields))
+	if err != nil {
+		return fmt.Errorf("error al guardar contacto: %v", err)
+	}
+	return nil
+}
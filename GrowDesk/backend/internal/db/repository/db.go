@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DB son las operaciones de *sql.DB que usan los repositorios
+type DB interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Begin() (*sql.Tx, error)
+}
+
+// WithContext devuelve una DB que ejecuta todas las consultas con ctx, para que cuelguen
+// de la traza de la solicitud
+func WithContext(db *sql.DB, ctx context.Context) DB {
+	return contextDB{db: db, ctx: ctx}
+}
+
+type contextDB struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+func (c contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c contextDB) Begin() (*sql.Tx, error) {
+	return c.db.BeginTx(c.ctx, nil)
+}
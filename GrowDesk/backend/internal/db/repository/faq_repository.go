@@ -10,11 +10,11 @@ import (
 
 // FAQRepository maneja las operaciones de base de datos para las FAQs
 type FAQRepository struct {
-	db *sql.DB
+	db DB
 }
 
 // NewFAQRepository crea un nuevo repositorio de FAQs
-func NewFAQRepository(db *sql.DB) *FAQRepository {
+func NewFAQRepository(db DB) *FAQRepository {
 	return &FAQRepository{db: db}
 }
 
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// MacroRepository maneja las operaciones de base de datos para las macros
+type MacroRepository struct {
+	db DB
+}
+
+// NewMacroRepository crea un nuevo repositorio de macros
+func NewMacroRepository(db DB) *MacroRepository {
+	return &MacroRepository{db: db}
+}
+
+const macroColumns = `id, name, COALESCE(description, ''), scope, owner_id, COALESCE(reply, ''), internal,
+		       actions, usage_count, last_used_at, created_at, updated_at`
+
+// scanMacro convierte una fila en una macro
+func scanMacro(scanner interface{ Scan(...interface{}) error }) (*models.Macro, error) {
+	var macro models.Macro
+	var actionsJSON []byte
+	var lastUsedAt sql.NullTime
+
+	err := scanner.Scan(
+		&macro.ID,
+		&macro.Name,
+		&macro.Description,
+		&macro.Scope,
+		&macro.OwnerID,
+		&macro.Reply,
+		&macro.Internal,
+		&actionsJSON,
+		&macro.UsageCount,
+		&lastUsedAt,
+		&macro.CreatedAt,
+		&macro.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(actionsJSON) > 0 {
+		if err := json.Unmarshal(actionsJSON, &macro.Actions); err != nil {
+			return nil, fmt.Errorf("error al parsear acciones de la macro %s: %v", macro.ID, err)
+		}
+	}
+	if lastUsedAt.Valid {
+		macro.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &macro, nil
+}
+
+// GetAll obtiene todas las macros ordenadas por nombre
+func (r *MacroRepository) GetAll() ([]models.Macro, error) {
+	rows, err := r.db.Query(`SELECT ` + macroColumns + ` FROM macros ORDER BY LOWER(name)`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar macros: %v", err)
+	}
+	defer rows.Close()
+
+	macros := make([]models.Macro, 0)
+	for rows.Next() {
+		macro, err := scanMacro(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear macro: %v", err)
+		}
+		macros = append(macros, *macro)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar macros: %v", err)
+	}
+
+	return macros, nil
+}
+
+// GetByID obtiene una macro por su ID
+func (r *MacroRepository) GetByID(id string) (*models.Macro, error) {
+	row := r.db.QueryRow(`SELECT `+macroColumns+` FROM macros WHERE id = $1`, id)
+	macro, err := scanMacro(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("macro con ID %s no encontrada", id)
+		}
+		return nil, fmt.Errorf("error al consultar macro: %v", err)
+	}
+	return macro, nil
+}
+
+// Create crea una nueva macro
+func (r *MacroRepository) Create(macro models.Macro) error {
+	if macro.ID == "" {
+		macro.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if macro.CreatedAt.IsZero() {
+		macro.CreatedAt = now
+	}
+	macro.UpdatedAt = now
+
+	actionsJSON, err := json.Marshal(macro.Actions)
+	if err != nil {
+		return fmt.Errorf("error al serializar acciones: %v", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO macros (id, name, description, scope, owner_id, reply, internal, actions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, macro.ID, macro.Name, nullString(macro.Description), macro.Scope, macro.OwnerID,
+		nullString(macro.Reply), macro.Internal, string(actionsJSON), macro.CreatedAt, macro.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear macro: %v", err)
+	}
+	return nil
+}
+
+// Update actualiza una macro existente sin tocar sus estadísticas de uso
+func (r *MacroRepository) Update(macro models.Macro) error {
+	actionsJSON, err := json.Marshal(macro.Actions)
+	if err != nil {
+		return fmt.Errorf("error al serializar acciones: %v", err)
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE macros
+		SET name = $2, description = $3, scope = $4, owner_id = $5, reply = $6, internal = $7,
+		    actions = $8, updated_at = NOW()
+		WHERE id = $1
+	`, macro.ID, macro.Name, nullString(macro.Description), macro.Scope, macro.OwnerID,
+		nullString(macro.Reply), macro.Internal, string(actionsJSON))
+	if err != nil {
+		return fmt.Errorf("error al actualizar macro: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("macro con ID %s no encontrada", macro.ID)
+	}
+	return nil
+}
+
+// Delete elimina una macro
+func (r *MacroRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM macros WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar macro: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("macro con ID %s no encontrada", id)
+	}
+	return nil
+}
+
+// RecordUsage suma un uso a la macro en una sola sentencia, sin carreras entre agentes
+func (r *MacroRepository) RecordUsage(id string, usedAt time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE macros SET usage_count = usage_count + 1, last_used_at = $2 WHERE id = $1
+	`, id, usedAt)
+	if err != nil {
+		return fmt.Errorf("error al registrar el uso de la macro: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("macro con ID %s no encontrada", id)
+	}
+	return nil
+}
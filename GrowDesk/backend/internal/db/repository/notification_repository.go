@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// NotificationRepository maneja las operaciones de base de datos para las notificaciones
+type NotificationRepository struct {
+	db DB
+}
+
+// NewNotificationRepository crea un nuevo repositorio de notificaciones
+func NewNotificationRepository(db DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create crea una notificación
+func (r *NotificationRepository) Create(notification models.Notification) error {
+	if notification.ID == "" {
+		notification.ID = uuid.New().String()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO notifications (id, user_id, message, type, read, related_id, related_type, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, notification.ID, notification.UserID, notification.Message, notification.Type, notification.Read,
+		nullString(notification.RelatedID), nullString(notification.RelatedType), notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear notificación: %v", err)
+	}
+	return nil
+}
+
+// GetByUser obtiene las notificaciones de un usuario, las más recientes primero
+func (r *NotificationRepository) GetByUser(userID string, unreadOnly bool) ([]models.Notification, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, message, type, COALESCE(read, FALSE), COALESCE(related_id, ''),
+		       COALESCE(related_type, ''), created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR NOT COALESCE(read, FALSE))
+		ORDER BY created_at DESC
+		LIMIT 500
+	`, userID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar notificaciones: %v", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]models.Notification, 0)
+	for rows.Next() {
+		var notification models.Notification
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Message, &notification.Type,
+			&notification.Read, &notification.RelatedID, &notification.RelatedType, &notification.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear notificación: %v", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar notificaciones: %v", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead marca como leída una notificación del usuario
+func (r *NotificationRepository) MarkRead(userID, id string) error {
+	result, err := r.db.Exec(`UPDATE notifications SET read = TRUE WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error al actualizar notificación: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("notificación con ID %s no encontrada", id)
+	}
+	return nil
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario
+func (r *NotificationRepository) MarkAllRead(userID string) (int, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET read = TRUE WHERE user_id = $1 AND NOT COALESCE(read, FALSE)`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error al actualizar notificaciones: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// ReportExportRepository maneja las exportaciones programadas y su historial
+type ReportExportRepository struct {
+	db DB
+}
+
+// NewReportExportRepository crea un nuevo repositorio de exportaciones
+func NewReportExportRepository(db DB) *ReportExportRepository {
+	return &ReportExportRepository{db: db}
+}
+
+const reportScheduleColumns = `id, name, schedule, time_zone, dataset, format, range_days, COALESCE(group_by, ''),
+	filters, delivery, enabled, COALESCE(created_by, ''), created_at, updated_at, last_run_at, next_run_at`
+
+// scanReportSchedule convierte una fila en una exportación programada
+func scanReportSchedule(scanner interface{ Scan(...interface{}) error }) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	var filtersJSON, deliveryJSON []byte
+	var lastRunAt, nextRunAt sql.NullTime
+
+	err := scanner.Scan(
+		&schedule.ID,
+		&schedule.Name,
+		&schedule.Schedule,
+		&schedule.TimeZone,
+		&schedule.Dataset,
+		&schedule.Format,
+		&schedule.RangeDays,
+		&schedule.GroupBy,
+		&filtersJSON,
+		&deliveryJSON,
+		&schedule.Enabled,
+		&schedule.CreatedBy,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+		&lastRunAt,
+		&nextRunAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(filtersJSON, &schedule.Filters)
+	json.Unmarshal(deliveryJSON, &schedule.Delivery)
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	return &schedule, nil
+}
+
+// GetSchedules obtiene todas las exportaciones programadas
+func (r *ReportExportRepository) GetSchedules() ([]models.ReportSchedule, error) {
+	rows, err := r.db.Query(`SELECT ` + reportScheduleColumns + ` FROM report_schedules ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar exportaciones programadas: %v", err)
+	}
+	defer rows.Close()
+
+	schedules := make([]models.ReportSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanReportSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear exportación programada: %v", err)
+		}
+		schedules = append(schedules, *schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar exportaciones programadas: %v", err)
+	}
+	return schedules, nil
+}
+
+// GetSchedule obtiene una exportación programada por su ID
+func (r *ReportExportRepository) GetSchedule(id string) (*models.ReportSchedule, error) {
+	schedule, err := scanReportSchedule(r.db.QueryRow(`SELECT `+reportScheduleColumns+` FROM report_schedules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("exportación programada con ID %s no encontrada", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener exportación programada: %v", err)
+	}
+	return schedule, nil
+}
+
+// CreateSchedule guarda una exportación programada nueva
+func (r *ReportExportRepository) CreateSchedule(schedule models.ReportSchedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.New().String()
+	}
+	filtersJSON, _ := json.Marshal(schedule.Filters)
+	deliveryJSON, _ := json.Marshal(schedule.Delivery)
+
+	_, err := r.db.Exec(`
+		INSERT INTO report_schedules (id, name, schedule, time_zone, dataset, format, range_days, group_by,
+			filters, delivery, enabled, created_by, created_at, updated_at, last_run_at, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, schedule.ID, schedule.Name, schedule.Schedule, schedule.TimeZone, schedule.Dataset, schedule.Format,
+		schedule.RangeDays, nullString(schedule.GroupBy), string(filtersJSON), string(deliveryJSON),
+		schedule.Enabled, nullString(schedule.CreatedBy), schedule.CreatedAt, schedule.UpdatedAt,
+		schedule.LastRunAt, schedule.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("error al crear exportación programada: %v", err)
+	}
+	return nil
+}
+
+// UpdateSchedule guarda los cambios de una exportación programada
+func (r *ReportExportRepository) UpdateSchedule(schedule models.ReportSchedule) error {
+	filtersJSON, _ := json.Marshal(schedule.Filters)
+	deliveryJSON, _ := json.Marshal(schedule.Delivery)
+
+	result, err := r.db.Exec(`
+		UPDATE report_schedules
+		SET name = $2, schedule = $3, time_zone = $4, dataset = $5, format = $6, range_days = $7,
+		    group_by = $8, filters = $9, delivery = $10, enabled = $11, updated_at = $12,
+		    last_run_at = $13, next_run_at = $14
+		WHERE id = $1
+	`, schedule.ID, schedule.Name, schedule.Schedule, schedule.TimeZone, schedule.Dataset, schedule.Format,
+		schedule.RangeDays, nullString(schedule.GroupBy), string(filtersJSON), string(deliveryJSON),
+		schedule.Enabled, schedule.UpdatedAt, schedule.LastRunAt, schedule.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("error al actualizar exportación programada: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("exportación programada con ID %s no encontrada", schedule.ID)
+	}
+	return nil
+}
+
+// DeleteSchedule elimina una exportación programada; su historial queda sin programación
+func (r *ReportExportRepository) DeleteSchedule(id string) error {
+	result, err := r.db.Exec(`DELETE FROM report_schedules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar exportación programada: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("exportación programada con ID %s no encontrada", id)
+	}
+	return nil
+}
+
+const reportExportColumns = `id, COALESCE(schedule_id, ''), name, dataset, format, status, COALESCE(error, ''),
+	COALESCE(file_name, ''), size, row_count, range_from, range_to, triggered_by, started_at, finished_at, deliveries`
+
+// scanReportExport convierte una fila en una ejecución
+func scanReportExport(scanner interface{ Scan(...interface{}) error }) (*models.ReportExport, error) {
+	var export models.ReportExport
+	var finishedAt sql.NullTime
+	var deliveriesJSON []byte
+
+	err := scanner.Scan(
+		&export.ID,
+		&export.ScheduleID,
+		&export.Name,
+		&export.Dataset,
+		&export.Format,
+		&export.Status,
+		&export.Error,
+		&export.FileName,
+		&export.Size,
+		&export.Rows,
+		&export.From,
+		&export.To,
+		&export.TriggeredBy,
+		&export.StartedAt,
+		&finishedAt,
+		&deliveriesJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		export.FinishedAt = &finishedAt.Time
+	}
+	json.Unmarshal(deliveriesJSON, &export.Deliveries)
+	return &export, nil
+}
+
+// GetExports obtiene las ejecuciones de una programación, o todas si scheduleID está
+// vacío, las más recientes primero
+func (r *ReportExportRepository) GetExports(scheduleID string) ([]models.ReportExport, error) {
+	query := `SELECT ` + reportExportColumns + ` FROM report_exports`
+	var args []interface{}
+	if scheduleID != "" {
+		query += ` WHERE schedule_id = $1`
+		args = append(args, scheduleID)
+	}
+	rows, err := r.db.Query(query+` ORDER BY started_at DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar exportaciones: %v", err)
+	}
+	defer rows.Close()
+
+	exports := make([]models.ReportExport, 0)
+	for rows.Next() {
+		export, err := scanReportExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear exportación: %v", err)
+		}
+		exports = append(exports, *export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar exportaciones: %v", err)
+	}
+	return exports, nil
+}
+
+// GetExport obtiene una ejecución por su ID
+func (r *ReportExportRepository) GetExport(id string) (*models.ReportExport, error) {
+	export, err := scanReportExport(r.db.QueryRow(`SELECT `+reportExportColumns+` FROM report_exports WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("exportación con ID %s no encontrada", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener exportación: %v", err)
+	}
+	return export, nil
+}
+
+// CreateExport guarda una ejecución nueva
+func (r *ReportExportRepository) CreateExport(export models.ReportExport) error {
+	if export.ID == "" {
+		export.ID = uuid.New().String()
+	}
+	deliveriesJSON, _ := json.Marshal(exportDeliveries(export.Deliveries))
+
+	_, err := r.db.Exec(`
+		INSERT INTO report_exports (id, schedule_id, name, dataset, format, status, error, file_name, size,
+			row_count, range_from, range_to, triggered_by, started_at, finished_at, deliveries)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, export.ID, nullString(export.ScheduleID), export.Name, export.Dataset, export.Format, export.Status,
+		nullString(export.Error), nullString(export.FileName), export.Size, export.Rows, export.From, export.To,
+		export.TriggeredBy, export.StartedAt, export.FinishedAt, string(deliveriesJSON))
+	if err != nil {
+		return fmt.Errorf("error al crear exportación: %v", err)
+	}
+	return nil
+}
+
+// UpdateExport guarda el resultado de una ejecución
+func (r *ReportExportRepository) UpdateExport(export models.ReportExport) error {
+	deliveriesJSON, _ := json.Marshal(exportDeliveries(export.Deliveries))
+
+	result, err := r.db.Exec(`
+		UPDATE report_exports
+		SET status = $2, error = $3, file_name = $4, size = $5, row_count = $6, finished_at = $7, deliveries = $8
+		WHERE id = $1
+	`, export.ID, export.Status, nullString(export.Error), nullString(export.FileName), export.Size,
+		export.Rows, export.FinishedAt, string(deliveriesJSON))
+	if err != nil {
+		return fmt.Errorf("error al actualizar exportación: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("exportación con ID %s no encontrada", export.ID)
+	}
+	return nil
+}
+
+// DeleteExport elimina una ejecución del historial
+func (r *ReportExportRepository) DeleteExport(id string) error {
+	result, err := r.db.Exec(`DELETE FROM report_exports WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar exportación: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("exportación con ID %s no encontrada", id)
+	}
+	return nil
+}
+
+// exportDeliveries evita guardar null en la columna deliveries
+func exportDeliveries(deliveries []models.ReportExportDelivery) []models.ReportExportDelivery {
+	if deliveries == nil {
+		return []models.ReportExportDelivery{}
+	}
+	return deliveries
+}
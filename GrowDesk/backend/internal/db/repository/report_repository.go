@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+	"github.com/lib/pq"
+)
+
+// ReportRepository calcula los informes sobre la vista materializada ticket_report_facts
+type ReportRepository struct {
+	db DB
+}
+
+// NewReportRepository crea un nuevo repositorio de informes
+func NewReportRepository(db DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Refresh recalcula la vista sin bloquear las lecturas
+func (r *ReportRepository) Refresh() error {
+	if _, err := r.db.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY ticket_report_facts`); err != nil {
+		return fmt.Errorf("error al refrescar ticket_report_facts: %v", err)
+	}
+	return nil
+}
+
+// reportSQL acumula los argumentos de una consulta de informe
+type reportSQL struct {
+	args []interface{}
+}
+
+// arg añade un argumento y devuelve su marcador ($n)
+func (q *reportSQL) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// filters devuelve las condiciones de los filtros de la consulta sobre la tabla alias,
+// empezando por AND. El filtro de agente se omite si withAgent es false.
+func (q *reportSQL) filters(alias string, query models.ReportQuery, withAgent bool) string {
+	var conditions []string
+	add := func(column, value string) {
+		if value != "" {
+			conditions = append(conditions, fmt.Sprintf("%s.%s = %s", alias, column, q.arg(value)))
+		}
+	}
+	add("category_id", query.CategoryID)
+	add("priority", query.Priority)
+	add("source", query.Source)
+	add("widget_id", query.WidgetID)
+	add("team_id", query.TeamID)
+	if withAgent {
+		add("assigned_to", query.AgentID)
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(conditions, " AND ")
+}
+
+// groupKey devuelve la expresión del grupo: el día o la semana ISO de column en la zona
+// horaria de la consulta, o la dimensión elegida
+func (q *reportSQL) groupKey(alias, column string, query models.ReportQuery) string {
+	switch query.GroupBy {
+	case reports.GroupDay:
+		return fmt.Sprintf("to_char(%s.%s AT TIME ZONE %s, 'YYYY-MM-DD')", alias, column, q.arg(timeZone(query)))
+	case reports.GroupWeek:
+		return fmt.Sprintf(`to_char(%s.%s AT TIME ZONE %s, 'IYYY-"W"IW')`, alias, column, q.arg(timeZone(query)))
+	case reports.GroupAgent:
+		return fmt.Sprintf("COALESCE(NULLIF(%s.assigned_to, ''), '%s')", alias, reports.KeyUnassigned)
+	}
+	column = map[string]string{
+		reports.GroupCategory: "category_id",
+		reports.GroupPriority: "priority",
+		reports.GroupSource:   "source",
+		reports.GroupWidget:   "widget_id",
+	}[query.GroupBy]
+	return fmt.Sprintf("COALESCE(NULLIF(%s.%s, ''), '%s')", alias, column, reports.KeyNone)
+}
+
+func timeZone(query models.ReportQuery) string {
+	if query.TimeZone == "" {
+		return "UTC"
+	}
+	return query.TimeZone
+}
+
+// seconds es la duración en segundos entre dos columnas, nunca negativa
+func seconds(from, to string) string {
+	return fmt.Sprintf("GREATEST(EXTRACT(EPOCH FROM %s - %s), 0)::float8", to, from)
+}
+
+// Volume cuenta los tickets creados y resueltos en el periodo
+func (r *ReportRepository) Volume(query models.ReportQuery) ([]models.VolumeRow, error) {
+	q := &reportSQL{}
+	from, to := q.arg(query.From), q.arg(query.To)
+	sqlQuery := `
+		SELECT key, SUM(created), SUM(resolved) FROM (
+			SELECT ` + q.groupKey("f", "created_at", query) + ` AS key, 1 AS created, 0 AS resolved
+			FROM ticket_report_facts f
+			WHERE f.created_at >= ` + from + ` AND f.created_at < ` + to + q.filters("f", query, true) + `
+			UNION ALL
+			SELECT ` + q.groupKey("f", "resolved_at", query) + `, 0, 1
+			FROM ticket_report_facts f
+			WHERE f.resolved_at >= ` + from + ` AND f.resolved_at < ` + to + q.filters("f", query, true) + `
+		) v
+		GROUP BY key ORDER BY key`
+
+	rows, err := r.db.Query(sqlQuery, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular el volumen de tickets: %v", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]models.VolumeRow)
+	for rows.Next() {
+		var row models.VolumeRow
+		if err := rows.Scan(&row.Key, &row.Created, &row.Resolved); err != nil {
+			return nil, fmt.Errorf("error al escanear el volumen de tickets: %v", err)
+		}
+		found[row.Key] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar el volumen de tickets: %v", err)
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	result := make([]models.VolumeRow, 0, len(found))
+	for _, key := range withBuckets(keys, query) {
+		row, ok := found[key]
+		if !ok {
+			row = models.VolumeRow{Key: key}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// FirstResponse mide el tiempo hasta la primera respuesta de los tickets creados en el periodo
+func (r *ReportRepository) FirstResponse(query models.ReportQuery) ([]models.DurationRow, error) {
+	q := &reportSQL{}
+	from, to := q.arg(query.From), q.arg(query.To)
+	sqlQuery := `
+		SELECT key, COUNT(secs), COUNT(*) - COUNT(secs), COALESCE(AVG(secs), 0),
+		       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY secs), 0),
+		       COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY secs), 0)
+		FROM (
+			SELECT ` + q.groupKey("f", "created_at", query) + ` AS key,
+			       ` + seconds("f.created_at", "f.first_response_at") + ` AS secs
+			FROM ticket_report_facts f
+			WHERE f.created_at >= ` + from + ` AND f.created_at < ` + to + q.filters("f", query, true) + `
+		) d
+		GROUP BY key ORDER BY key`
+	return r.durations(sqlQuery, q.args, query)
+}
+
+// Resolution mide el tiempo de resolución de los tickets resueltos en el periodo
+func (r *ReportRepository) Resolution(query models.ReportQuery) ([]models.DurationRow, error) {
+	q := &reportSQL{}
+	from, to := q.arg(query.From), q.arg(query.To)
+	sqlQuery := `
+		SELECT key, COUNT(secs), 0, COALESCE(AVG(secs), 0),
+		       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY secs), 0),
+		       COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY secs), 0)
+		FROM (
+			SELECT ` + q.groupKey("f", "resolved_at", query) + ` AS key,
+			       ` + seconds("f.created_at", "f.resolved_at") + ` AS secs
+			FROM ticket_report_facts f
+			WHERE f.resolved_at >= ` + from + ` AND f.resolved_at < ` + to + q.filters("f", query, true) + `
+		) d
+		GROUP BY key ORDER BY key`
+	return r.durations(sqlQuery, q.args, query)
+}
+
+func (r *ReportRepository) durations(sqlQuery string, args []interface{}, query models.ReportQuery) ([]models.DurationRow, error) {
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular tiempos: %v", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]models.DurationRow)
+	for rows.Next() {
+		var row models.DurationRow
+		if err := rows.Scan(&row.Key, &row.Count, &row.Pending, &row.AverageSeconds, &row.MedianSeconds, &row.P90Seconds); err != nil {
+			return nil, fmt.Errorf("error al escanear tiempos: %v", err)
+		}
+		row.AverageSeconds = reports.Round(row.AverageSeconds)
+		row.MedianSeconds = reports.Round(row.MedianSeconds)
+		row.P90Seconds = reports.Round(row.P90Seconds)
+		found[row.Key] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar tiempos: %v", err)
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	result := make([]models.DurationRow, 0, len(found))
+	for _, key := range withBuckets(keys, query) {
+		row, ok := found[key]
+		if !ok {
+			row = models.DurationRow{Key: key}
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// Backlog cuenta los tickets abiertos al final de cada intervalo o, si se agrupa por otra
+// dimensión, los abiertos ahora
+func (r *ReportRepository) Backlog(query models.ReportQuery, now time.Time) ([]models.BacklogRow, error) {
+	q := &reportSQL{}
+	var sqlQuery string
+	if reports.IsTimeGroup(query.GroupBy) {
+		var keys []string
+		var ends []time.Time
+		for _, bucket := range reports.Buckets(query) {
+			keys = append(keys, bucket.Key)
+			if bucket.End.After(now) {
+				bucket.End = now
+			}
+			ends = append(ends, bucket.End)
+		}
+		sqlQuery = `
+			SELECT b.key, COUNT(f.id), COALESCE(AVG(` + seconds("f.created_at", "b.at") + `), 0)
+			FROM unnest(` + q.arg(pq.Array(keys)) + `::text[], ` + q.arg(pq.Array(ends)) + `::timestamptz[]) AS b(key, at)
+			LEFT JOIN ticket_report_facts f
+			       ON f.created_at < b.at AND (f.resolved_at IS NULL OR f.resolved_at > b.at)` + q.filters("f", query, true) + `
+			GROUP BY b.key ORDER BY b.key`
+	} else {
+		nowArg := q.arg(now)
+		sqlQuery = `
+			SELECT ` + q.groupKey("f", "", query) + ` AS key, COUNT(*),
+			       COALESCE(AVG(` + seconds("f.created_at", nowArg+"::timestamptz") + `), 0)
+			FROM ticket_report_facts f
+			WHERE f.status NOT IN ('resolved', 'closed') AND f.created_at < ` + q.arg(query.To) + q.filters("f", query, true) + `
+			GROUP BY key ORDER BY key`
+	}
+
+	rows, err := r.db.Query(sqlQuery, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular el backlog: %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.BacklogRow, 0)
+	for rows.Next() {
+		var row models.BacklogRow
+		if err := rows.Scan(&row.Key, &row.Open, &row.AverageAgeSeconds); err != nil {
+			return nil, fmt.Errorf("error al escanear el backlog: %v", err)
+		}
+		row.AverageAgeSeconds = reports.Round(row.AverageAgeSeconds)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar el backlog: %v", err)
+	}
+	return result, nil
+}
+
+// AgentWorkload resume la carga de trabajo de cada agente. Las respuestas se cuentan por
+// quien las envió y los tickets por su asignado.
+func (r *ReportRepository) AgentWorkload(query models.ReportQuery) ([]models.AgentWorkloadRow, error) {
+	q := &reportSQL{}
+	from, to := q.arg(query.From), q.arg(query.To)
+	inRange := func(column string) string {
+		return column + " >= " + from + " AND " + column + " < " + to
+	}
+	sqlQuery := `
+		WITH f AS (
+			SELECT * FROM ticket_report_facts f WHERE TRUE` + q.filters("f", query, false) + `
+		), agents AS (
+			SELECT COALESCE(NULLIF(f.assigned_to, ''), '` + reports.KeyUnassigned + `') AS agent_id,
+			       COUNT(*) FILTER (WHERE f.status NOT IN ('resolved', 'closed')) AS open,
+			       COUNT(*) FILTER (WHERE ` + inRange("f.created_at") + `) AS assigned,
+			       COUNT(*) FILTER (WHERE ` + inRange("f.resolved_at") + `) AS resolved,
+			       AVG(` + seconds("f.created_at", "f.first_response_at") + `) FILTER (WHERE ` + inRange("f.created_at") + `) AS first_response,
+			       percentile_cont(0.5) WITHIN GROUP (ORDER BY ` + seconds("f.created_at", "f.resolved_at") + `)
+			           FILTER (WHERE ` + inRange("f.resolved_at") + `) AS resolution
+			FROM f GROUP BY 1
+		), replies AS (
+			SELECT m.user_id AS agent_id, COUNT(*) AS replies
+			FROM messages m JOIN f ON f.id = m.ticket_id
+			WHERE COALESCE(m.user_id, '') <> '' AND NOT COALESCE(m.is_client, FALSE)
+			  AND NOT COALESCE(m.is_internal, FALSE) AND ` + inRange("m.timestamp") + `
+			GROUP BY m.user_id
+		)
+		SELECT COALESCE(a.agent_id, r.agent_id), COALESCE(a.open, 0), COALESCE(a.assigned, 0),
+		       COALESCE(a.resolved, 0), COALESCE(r.replies, 0),
+		       COALESCE(a.first_response, 0), COALESCE(a.resolution, 0)
+		FROM agents a FULL OUTER JOIN replies r ON r.agent_id = a.agent_id
+		WHERE COALESCE(a.open, 0) + COALESCE(a.assigned, 0) + COALESCE(a.resolved, 0) + COALESCE(r.replies, 0) > 0`
+	if query.AgentID != "" {
+		sqlQuery += ` AND COALESCE(a.agent_id, r.agent_id) = ` + q.arg(query.AgentID)
+	}
+	sqlQuery += ` ORDER BY 1`
+
+	rows, err := r.db.Query(sqlQuery, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular la carga de los agentes: %v", err)
+	}
+	defer rows.Close()
+
+	result := make([]models.AgentWorkloadRow, 0)
+	for rows.Next() {
+		var row models.AgentWorkloadRow
+		if err := rows.Scan(&row.AgentID, &row.Open, &row.Assigned, &row.Resolved, &row.Replies,
+			&row.AverageFirstResponseSeconds, &row.MedianResolutionSeconds); err != nil {
+			return nil, fmt.Errorf("error al escanear la carga de los agentes: %v", err)
+		}
+		row.AverageFirstResponseSeconds = reports.Round(row.AverageFirstResponseSeconds)
+		row.MedianResolutionSeconds = reports.Round(row.MedianResolutionSeconds)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar la carga de los agentes: %v", err)
+	}
+	return result, nil
+}
+
+// withBuckets devuelve las claves en orden: todos los intervalos del periodo si se agrupa
+// por tiempo (aunque no tengan tickets) más las encontradas
+func withBuckets(found []string, query models.ReportQuery) []string {
+	seen := make(map[string]bool, len(found))
+	var keys []string
+	if reports.IsTimeGroup(query.GroupBy) {
+		for _, bucket := range reports.Buckets(query) {
+			keys = append(keys, bucket.Key)
+			seen[bucket.Key] = true
+		}
+	}
+	for _, key := range found {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// RoutingRuleRepository maneja las operaciones de base de datos para las reglas de enrutamiento
+type RoutingRuleRepository struct {
+	db DB
+}
+
+// NewRoutingRuleRepository crea un nuevo repositorio de reglas de enrutamiento
+func NewRoutingRuleRepository(db DB) *RoutingRuleRepository {
+	return &RoutingRuleRepository{db: db}
+}
+
+const routingRuleColumns = `id, name, position, enabled, stop_processing, conditions, actions, created_at, updated_at`
+
+// scanRoutingRule convierte una fila en una regla de enrutamiento
+func scanRoutingRule(scanner interface{ Scan(...interface{}) error }) (*models.RoutingRule, error) {
+	var rule models.RoutingRule
+	var conditionsJSON, actionsJSON []byte
+
+	err := scanner.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Position,
+		&rule.Enabled,
+		&rule.StopProcessing,
+		&conditionsJSON,
+		&actionsJSON,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conditionsJSON) > 0 {
+		if err := json.Unmarshal(conditionsJSON, &rule.Conditions); err != nil {
+			return nil, fmt.Errorf("error al parsear condiciones de la regla %s: %v", rule.ID, err)
+		}
+	}
+	if len(actionsJSON) > 0 {
+		if err := json.Unmarshal(actionsJSON, &rule.Actions); err != nil {
+			return nil, fmt.Errorf("error al parsear acciones de la regla %s: %v", rule.ID, err)
+		}
+	}
+
+	return &rule, nil
+}
+
+// GetAll obtiene todas las reglas ordenadas por posición
+func (r *RoutingRuleRepository) GetAll() ([]models.RoutingRule, error) {
+	rows, err := r.db.Query(`SELECT ` + routingRuleColumns + ` FROM routing_rules ORDER BY position, created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar reglas de enrutamiento: %v", err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.RoutingRule, 0)
+	for rows.Next() {
+		rule, err := scanRoutingRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear regla de enrutamiento: %v", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar reglas de enrutamiento: %v", err)
+	}
+
+	return rules, nil
+}
+
+// GetByID obtiene una regla de enrutamiento por su ID
+func (r *RoutingRuleRepository) GetByID(id string) (*models.RoutingRule, error) {
+	row := r.db.QueryRow(`SELECT `+routingRuleColumns+` FROM routing_rules WHERE id = $1`, id)
+	rule, err := scanRoutingRule(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("regla de enrutamiento con ID %s no encontrada", id)
+		}
+		return nil, fmt.Errorf("error al consultar regla de enrutamiento: %v", err)
+	}
+	return rule, nil
+}
+
+// Create crea una nueva regla de enrutamiento
+func (r *RoutingRuleRepository) Create(rule models.RoutingRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = now
+	}
+	rule.UpdatedAt = now
+
+	conditionsJSON, actionsJSON, err := marshalRoutingRule(rule)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO routing_rules (id, name, position, enabled, stop_processing, conditions, actions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, rule.ID, rule.Name, rule.Position, rule.Enabled, rule.StopProcessing, conditionsJSON, actionsJSON, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear regla de enrutamiento: %v", err)
+	}
+
+	return nil
+}
+
+// Update actualiza una regla de enrutamiento existente
+func (r *RoutingRuleRepository) Update(rule models.RoutingRule) error {
+	conditionsJSON, actionsJSON, err := marshalRoutingRule(rule)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE routing_rules
+		SET name = $2, position = $3, enabled = $4, stop_processing = $5,
+		    conditions = $6, actions = $7, updated_at = NOW()
+		WHERE id = $1
+	`, rule.ID, rule.Name, rule.Position, rule.Enabled, rule.StopProcessing, conditionsJSON, actionsJSON)
+	if err != nil {
+		return fmt.Errorf("error al actualizar regla de enrutamiento: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("regla de enrutamiento con ID %s no encontrada", rule.ID)
+	}
+
+	return nil
+}
+
+// Delete elimina una regla de enrutamiento
+func (r *RoutingRuleRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM routing_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar regla de enrutamiento: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("regla de enrutamiento con ID %s no encontrada", id)
+	}
+
+	return nil
+}
+
+func marshalRoutingRule(rule models.RoutingRule) ([]byte, []byte, error) {
+	conditionsJSON, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al serializar condiciones: %v", err)
+	}
+	actionsJSON, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al serializar acciones: %v", err)
+	}
+	return conditionsJSON, actionsJSON, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// ServiceKeyRepository maneja las operaciones de base de datos para las claves de servicio
+type ServiceKeyRepository struct {
+	db DB
+}
+
+// NewServiceKeyRepository crea un nuevo repositorio de claves de servicio
+func NewServiceKeyRepository(db DB) *ServiceKeyRepository {
+	return &ServiceKeyRepository{db: db}
+}
+
+const serviceKeyColumns = `id, name, service, key_prefix, key_hash, scopes, act_as_user_id,
+		       created_at, last_used_at, revoked_at`
+
+// scanServiceKey convierte una fila en una clave de servicio
+func scanServiceKey(scanner interface{ Scan(...interface{}) error }) (*models.ServiceKey, error) {
+	var key models.ServiceKey
+	var scopesJSON []byte
+	var actAsUserID sql.NullString
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Service,
+		&key.KeyPrefix,
+		&key.KeyHash,
+		&scopesJSON,
+		&actAsUserID,
+		&key.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = []string{}
+	if len(scopesJSON) > 0 {
+		if err := json.Unmarshal(scopesJSON, &key.Scopes); err != nil {
+			return nil, fmt.Errorf("error al parsear scopes de la clave %s: %v", key.ID, err)
+		}
+	}
+	key.ActAsUserID = actAsUserID.String
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+// GetAll obtiene todas las claves de servicio
+func (r *ServiceKeyRepository) GetAll() ([]models.ServiceKey, error) {
+	rows, err := r.db.Query(`SELECT ` + serviceKeyColumns + ` FROM service_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar claves de servicio: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.ServiceKey, 0)
+	for rows.Next() {
+		key, err := scanServiceKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear clave de servicio: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar claves de servicio: %v", err)
+	}
+
+	return keys, nil
+}
+
+// GetByPrefix obtiene una clave de servicio por su parte pública
+func (r *ServiceKeyRepository) GetByPrefix(prefix string) (*models.ServiceKey, error) {
+	row := r.db.QueryRow(`SELECT `+serviceKeyColumns+` FROM service_keys WHERE key_prefix = $1`, prefix)
+	key, err := scanServiceKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("clave de servicio %s no encontrada", prefix)
+		}
+		return nil, fmt.Errorf("error al consultar clave de servicio: %v", err)
+	}
+	return key, nil
+}
+
+// Create crea una nueva clave de servicio
+func (r *ServiceKeyRepository) Create(key models.ServiceKey) error {
+	if key.ID == "" {
+		key.ID = uuid.New().String()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+
+	scopesJSON, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("error al serializar scopes: %v", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO service_keys (id, name, service, key_prefix, key_hash, scopes, act_as_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, key.ID, key.Name, key.Service, key.KeyPrefix, key.KeyHash, scopesJSON, nullString(key.ActAsUserID), key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error al crear clave de servicio: %v", err)
+	}
+
+	return nil
+}
+
+// Revoke marca una clave de servicio como revocada
+func (r *ServiceKeyRepository) Revoke(id string) error {
+	result, err := r.db.Exec(`UPDATE service_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al revocar clave de servicio: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("clave de servicio con ID %s no encontrada", id)
+	}
+
+	return nil
+}
+
+// Touch registra el último uso de una clave de servicio
+func (r *ServiceKeyRepository) Touch(id string) error {
+	if _, err := r.db.Exec(`UPDATE service_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error al actualizar último uso de la clave: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// TagRepository maneja las operaciones de base de datos para las etiquetas
+type TagRepository struct {
+	db DB
+}
+
+// NewTagRepository crea un nuevo repositorio de etiquetas
+func NewTagRepository(db DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+const tagColumns = `id, name, COALESCE(color, ''), COALESCE(description, ''), created_at, updated_at`
+
+func scanTag(scanner interface{ Scan(...interface{}) error }) (*models.Tag, error) {
+	var tag models.Tag
+	err := scanner.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetAll obtiene todas las etiquetas ordenadas por nombre
+func (r *TagRepository) GetAll() ([]models.Tag, error) {
+	rows, err := r.db.Query(`SELECT ` + tagColumns + ` FROM tags ORDER BY LOWER(name)`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar etiquetas: %v", err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear etiqueta: %v", err)
+		}
+		tags = append(tags, *tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar etiquetas: %v", err)
+	}
+	return tags, nil
+}
+
+// GetByID obtiene una etiqueta por su ID
+func (r *TagRepository) GetByID(id string) (*models.Tag, error) {
+	tag, err := scanTag(r.db.QueryRow(`SELECT `+tagColumns+` FROM tags WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("etiqueta con ID %s no encontrada", id)
+		}
+		return nil, fmt.Errorf("error al consultar etiqueta: %v", err)
+	}
+	return tag, nil
+}
+
+// Create crea una etiqueta y enlaza los tickets que ya la usaban por nombre
+func (r *TagRepository) Create(tag models.Tag) error {
+	if tag.ID == "" {
+		tag.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = now
+	}
+	tag.UpdatedAt = now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO tags (id, name, color, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, tag.ID, tag.Name, nullString(tag.Color), nullString(tag.Description), tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_tags_name") {
+			return fmt.Errorf("ya existe una etiqueta llamada %q", tag.Name)
+		}
+		return fmt.Errorf("error al crear etiqueta: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO ticket_tags (ticket_id, tag_id)
+		SELECT t.id, $1 FROM tickets t
+		WHERE EXISTS (SELECT 1 FROM jsonb_array_elements_text(t.tags) AS e(value) WHERE LOWER(e.value) = LOWER($2))
+		ON CONFLICT DO NOTHING
+	`, tag.ID, tag.Name)
+	if err != nil {
+		return fmt.Errorf("error al enlazar tickets con la etiqueta: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// Update actualiza una etiqueta; si cambia el nombre se renombra en los tickets
+func (r *TagRepository) Update(tag models.Tag) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	if err := tx.QueryRow(`SELECT name FROM tags WHERE id = $1 FOR UPDATE`, tag.ID).Scan(&oldName); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("etiqueta con ID %s no encontrada", tag.ID)
+		}
+		return fmt.Errorf("error al consultar etiqueta: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE tags SET name = $2, color = $3, description = $4, updated_at = NOW()
+		WHERE id = $1
+	`, tag.ID, tag.Name, nullString(tag.Color), nullString(tag.Description))
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_tags_name") {
+			return fmt.Errorf("ya existe una etiqueta llamada %q", tag.Name)
+		}
+		return fmt.Errorf("error al actualizar etiqueta: %v", err)
+	}
+
+	if oldName != tag.Name {
+		_, err = tx.Exec(`
+			UPDATE tickets t
+			SET tags = (
+				SELECT COALESCE(jsonb_agg(CASE WHEN LOWER(e.value) = LOWER($1) THEN $2 ELSE e.value END ORDER BY e.ord), '[]'::jsonb)
+				FROM jsonb_array_elements_text(t.tags) WITH ORDINALITY AS e(value, ord)
+			)
+			WHERE t.id IN (SELECT ticket_id FROM ticket_tags WHERE tag_id = $3)
+		`, oldName, tag.Name, tag.ID)
+		if err != nil {
+			return fmt.Errorf("error al renombrar la etiqueta en los tickets: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// Delete elimina una etiqueta y la quita de los tickets
+func (r *TagRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	var name string
+	if err := tx.QueryRow(`SELECT name FROM tags WHERE id = $1 FOR UPDATE`, id).Scan(&name); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("etiqueta con ID %s no encontrada", id)
+		}
+		return fmt.Errorf("error al consultar etiqueta: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE tickets t
+		SET tags = (
+			SELECT COALESCE(jsonb_agg(e.value ORDER BY e.ord), '[]'::jsonb)
+			FROM jsonb_array_elements_text(t.tags) WITH ORDINALITY AS e(value, ord)
+			WHERE LOWER(e.value) <> LOWER($1)
+		)
+		WHERE t.id IN (SELECT ticket_id FROM ticket_tags WHERE tag_id = $2)
+	`, name, id)
+	if err != nil {
+		return fmt.Errorf("error al quitar la etiqueta de los tickets: %v", err)
+	}
+
+	// ticket_tags se borra en cascada
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("error al eliminar etiqueta: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
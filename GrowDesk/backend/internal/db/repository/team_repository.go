@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// TeamRepository maneja las operaciones de base de datos para los equipos y sus miembros
+type TeamRepository struct {
+	db DB
+}
+
+// NewTeamRepository crea un nuevo repositorio de equipos
+func NewTeamRepository(db DB) *TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+const teamColumns = `id, name, COALESCE(description, ''), COALESCE(calendar_id, ''), created_at, updated_at`
+
+// scanTeam convierte una fila en un equipo (sin miembros)
+func scanTeam(scanner interface{ Scan(...interface{}) error }) (*models.Team, error) {
+	var team models.Team
+	err := scanner.Scan(&team.ID, &team.Name, &team.Description, &team.CalendarID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	team.Members = make([]models.TeamMember, 0)
+	return &team, nil
+}
+
+// GetAll obtiene todos los equipos con sus miembros
+func (r *TeamRepository) GetAll() ([]models.Team, error) {
+	rows, err := r.db.Query(`SELECT ` + teamColumns + ` FROM teams ORDER BY LOWER(name)`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar equipos: %v", err)
+	}
+
+	teams := make([]models.Team, 0)
+	index := make(map[string]int)
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error al escanear equipo: %v", err)
+		}
+		index[team.ID] = len(teams)
+		teams = append(teams, *team)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error al iterar equipos: %v", err)
+	}
+	rows.Close()
+
+	memberRows, err := r.db.Query(`SELECT team_id, user_id, role FROM team_members ORDER BY created_at, user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar miembros de equipos: %v", err)
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var teamID string
+		var member models.TeamMember
+		if err := memberRows.Scan(&teamID, &member.UserID, &member.Role); err != nil {
+			return nil, fmt.Errorf("error al escanear miembro de equipo: %v", err)
+		}
+		if i, ok := index[teamID]; ok {
+			teams[i].Members = append(teams[i].Members, member)
+		}
+	}
+	if err := memberRows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar miembros de equipos: %v", err)
+	}
+
+	return teams, nil
+}
+
+// GetByID obtiene un equipo por su ID con sus miembros
+func (r *TeamRepository) GetByID(id string) (*models.Team, error) {
+	row := r.db.QueryRow(`SELECT `+teamColumns+` FROM teams WHERE id = $1`, id)
+	team, err := scanTeam(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("equipo con ID %s no encontrado", id)
+		}
+		return nil, fmt.Errorf("error al consultar equipo: %v", err)
+	}
+
+	rows, err := r.db.Query(`SELECT user_id, role FROM team_members WHERE team_id = $1 ORDER BY created_at, user_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar miembros del equipo: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.TeamMember
+		if err := rows.Scan(&member.UserID, &member.Role); err != nil {
+			return nil, fmt.Errorf("error al escanear miembro del equipo: %v", err)
+		}
+		team.Members = append(team.Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar miembros del equipo: %v", err)
+	}
+
+	return team, nil
+}
+
+// Create crea un nuevo equipo con sus miembros
+func (r *TeamRepository) Create(team models.Team) error {
+	if team.ID == "" {
+		team.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if team.CreatedAt.IsZero() {
+		team.CreatedAt = now
+	}
+	team.UpdatedAt = now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO teams (id, name, description, calendar_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, team.ID, team.Name, nullString(team.Description), nullString(team.CalendarID), team.CreatedAt, team.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("ya existe un equipo con el nombre %s", team.Name)
+		}
+		return fmt.Errorf("error al crear equipo: %v", err)
+	}
+
+	if err := insertTeamMembers(tx, team); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// Update actualiza un equipo y reemplaza su lista de miembros
+func (r *TeamRepository) Update(team models.Team) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE teams SET name = $2, description = $3, calendar_id = $4, updated_at = NOW()
+		WHERE id = $1
+	`, team.ID, team.Name, nullString(team.Description), nullString(team.CalendarID))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("ya existe un equipo con el nombre %s", team.Name)
+		}
+		return fmt.Errorf("error al actualizar equipo: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("equipo con ID %s no encontrado", team.ID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM team_members WHERE team_id = $1`, team.ID); err != nil {
+		return fmt.Errorf("error al actualizar miembros del equipo: %v", err)
+	}
+	if err := insertTeamMembers(tx, team); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// Delete elimina un equipo. Los miembros se borran en cascada y los tickets
+// quedan sin equipo por la clave foránea.
+func (r *TeamRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM teams WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar equipo: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al obtener filas afectadas: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("equipo con ID %s no encontrado", id)
+	}
+
+	return nil
+}
+
+func insertTeamMembers(tx *sql.Tx, team models.Team) error {
+	for _, member := range team.Members {
+		_, err := tx.Exec(`
+			INSERT INTO team_members (team_id, user_id, role, created_at)
+			VALUES ($1, $2, $3, NOW())
+		`, team.ID, member.UserID, member.Role)
+		if err != nil {
+			return fmt.Errorf("error al agregar miembro %s al equipo: %v", member.UserID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// TicketRelationRepository maneja las operaciones de base de datos para las
+// relaciones entre tickets
+type TicketRelationRepository struct {
+	db DB
+}
+
+// NewTicketRelationRepository crea un nuevo repositorio de relaciones entre tickets
+func NewTicketRelationRepository(db DB) *TicketRelationRepository {
+	return &TicketRelationRepository{db: db}
+}
+
+// GetByTicket obtiene las relaciones en las que participa un ticket
+func (r *TicketRelationRepository) GetByTicket(ticketID string) ([]models.TicketRelation, error) {
+	rows, err := r.db.Query(`
+		SELECT id, ticket_id, related_ticket_id, type, COALESCE(created_by, ''), created_at
+		FROM ticket_relations
+		WHERE ticket_id = $1 OR related_ticket_id = $1
+		ORDER BY created_at
+	`, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar relaciones del ticket: %v", err)
+	}
+	defer rows.Close()
+
+	relations := make([]models.TicketRelation, 0)
+	for rows.Next() {
+		var relation models.TicketRelation
+		if err := rows.Scan(&relation.ID, &relation.TicketID, &relation.RelatedTicketID, &relation.Type,
+			&relation.CreatedBy, &relation.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear relación: %v", err)
+		}
+		relations = append(relations, relation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar relaciones: %v", err)
+	}
+	return relations, nil
+}
+
+// Create crea una relación entre dos tickets
+func (r *TicketRelationRepository) Create(relation models.TicketRelation) error {
+	if relation.ID == "" {
+		relation.ID = uuid.New().String()
+	}
+	if relation.CreatedAt.IsZero() {
+		relation.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO ticket_relations (id, ticket_id, related_ticket_id, type, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, relation.ID, relation.TicketID, relation.RelatedTicketID, relation.Type,
+		nullString(relation.CreatedBy), relation.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "ticket_relations_ticket_id_related_ticket_id_type_key"):
+			return fmt.Errorf("la relación ya existe")
+		case strings.Contains(err.Error(), "foreign key"):
+			return fmt.Errorf("ticket no encontrado")
+		}
+		return fmt.Errorf("error al crear relación: %v", err)
+	}
+	return nil
+}
+
+// Delete elimina una relación
+func (r *TicketRelationRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM ticket_relations WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar relación: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("relación con ID %s no encontrada", id)
+	}
+	return nil
+}
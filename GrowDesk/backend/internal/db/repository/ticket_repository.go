@@ -4,129 +4,41 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
 )
 
 // TicketRepository maneja las operaciones de base de datos relacionadas con tickets
 type TicketRepository struct {
-	DB *sql.DB
+	DB DB
 }
 
 // NewTicketRepository crea una nueva instancia del repositorio de tickets
-func NewTicketRepository(db *sql.DB) *TicketRepository {
+func NewTicketRepository(db DB) *TicketRepository {
 	return &TicketRepository{
 		DB: db,
 	}
 }
 
-// GetAll obtiene todos los tickets de la base de datos
-func (r *TicketRepository) GetAll() ([]models.Ticket, error) {
-	query := `
-		SELECT t.id, t.title, t.subject, t.description, t.status, t.priority, 
-		       t.category, t.category_id, t.assigned_to, t.created_by, t.user_id,
-		       t.source, t.widget_id, t.department, t.metadata,
-		       t.created_at, t.updated_at
-		FROM tickets t
-		ORDER BY t.created_at DESC
-	`
-
-	rows, err := r.DB.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("error al consultar tickets: %v", err)
-	}
-	defer rows.Close()
-
-	tickets := make([]models.Ticket, 0)
-	for rows.Next() {
-		var ticket models.Ticket
-		var categoryID, assignedTo, createdBy, userID, metadataJSON sql.NullString
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(
-			&ticket.ID,
-			&ticket.Title,
-			&ticket.Subject,
-			&ticket.Description,
-			&ticket.Status,
-			&ticket.Priority,
-			&ticket.Category,
-			&categoryID,
-			&assignedTo,
-			&createdBy,
-			&userID,
-			&ticket.Source,
-			&ticket.WidgetID,
-			&ticket.Department,
-			&metadataJSON,
-			&createdAt,
-			&updatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error al escanear ticket: %v", err)
-		}
-
-		// Asignar valores nulos
-		if categoryID.Valid {
-			ticket.CategoryID = categoryID.String
-		}
-		if assignedTo.Valid {
-			ticket.AssignedTo = assignedTo.String
-		}
-		if createdBy.Valid {
-			ticket.CreatedBy = createdBy.String
-		}
-		if userID.Valid {
-			ticket.UserID = userID.String
-		}
-
-		// Parsear metadata JSON si existe
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			var metadata models.Metadata
-			if err := json.Unmarshal([]byte(metadataJSON.String), &metadata); err == nil {
-				ticket.Metadata = &metadata
-			}
-		}
-
-		ticket.CreatedAt = createdAt
-		ticket.UpdatedAt = updatedAt
-
-		// Cargar mensajes del ticket
-		messages, err := r.getMessagesForTicket(ticket.ID)
-		if err != nil {
-			return nil, fmt.Errorf("error al obtener mensajes para ticket %s: %v", ticket.ID, err)
-		}
-		ticket.Messages = messages
-
-		tickets = append(tickets, ticket)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error al iterar tickets: %v", err)
-	}
-
-	return tickets, nil
-}
-
-// GetByID obtiene un ticket por su ID
-func (r *TicketRepository) GetByID(id string) (*models.Ticket, error) {
-	query := `
-		SELECT t.id, t.title, t.subject, t.description, t.status, t.priority, 
-		       t.category, t.category_id, t.assigned_to, t.created_by, t.user_id,
-		       t.source, t.widget_id, t.department, t.metadata,
-		       t.created_at, t.updated_at
-		FROM tickets t
-		WHERE t.id = $1
-	`
+// ticketColumns son las columnas que leen las consultas de tickets, en el orden de scanTicket
+const ticketColumns = `
+	t.id, t.title, COALESCE(t.subject, ''), t.description, t.status, COALESCE(t.priority, ''),
+	COALESCE(t.category, ''), t.category_id, t.assigned_to, t.created_by, t.user_id,
+	COALESCE(t.source, ''), COALESCE(t.widget_id, ''), COALESCE(t.department, ''), t.metadata,
+	t.tags, t.team_id, t.custom_fields, COALESCE(t.merged_into, ''), COALESCE(t.split_from, ''),
+	t.created_at, t.updated_at, t.resolved_at`
 
+// scanTicket lee una fila de ticketColumns
+func scanTicket(scanner interface{ Scan(...interface{}) error }) (models.Ticket, error) {
 	var ticket models.Ticket
-	var categoryID, assignedTo, createdBy, userID, metadataJSON sql.NullString
-	var createdAt, updatedAt time.Time
+	var categoryID, assignedTo, createdBy, userID, metadataJSON, tagsJSON, teamID, customFieldsJSON sql.NullString
+	var resolvedAt sql.NullTime
 
-	err := r.DB.QueryRow(query, id).Scan(
+	err := scanner.Scan(
 		&ticket.ID,
 		&ticket.Title,
 		&ticket.Subject,
@@ -142,28 +54,27 @@ func (r *TicketRepository) GetByID(id string) (*models.Ticket, error) {
 		&ticket.WidgetID,
 		&ticket.Department,
 		&metadataJSON,
-		&createdAt,
-		&updatedAt,
+		&tagsJSON,
+		&teamID,
+		&customFieldsJSON,
+		&ticket.MergedInto,
+		&ticket.SplitFrom,
+		&ticket.CreatedAt,
+		&ticket.UpdatedAt,
+		&resolvedAt,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("ticket con ID %s no encontrado", id)
-		}
-		return nil, fmt.Errorf("error al consultar ticket: %v", err)
+		return ticket, err
 	}
 
 	// Asignar valores nulos
-	if categoryID.Valid {
-		ticket.CategoryID = categoryID.String
-	}
-	if assignedTo.Valid {
-		ticket.AssignedTo = assignedTo.String
-	}
-	if createdBy.Valid {
-		ticket.CreatedBy = createdBy.String
-	}
-	if userID.Valid {
-		ticket.UserID = userID.String
+	ticket.CategoryID = categoryID.String
+	ticket.AssignedTo = assignedTo.String
+	ticket.CreatedBy = createdBy.String
+	ticket.UserID = userID.String
+	ticket.TeamID = teamID.String
+	if resolvedAt.Valid {
+		ticket.ResolvedAt = &resolvedAt.Time
 	}
 
 	// Parsear metadata JSON si existe
@@ -173,9 +84,66 @@ func (r *TicketRepository) GetByID(id string) (*models.Ticket, error) {
 			ticket.Metadata = &metadata
 		}
 	}
+	if tagsJSON.Valid && tagsJSON.String != "" {
+		json.Unmarshal([]byte(tagsJSON.String), &ticket.Tags)
+	}
+	if customFieldsJSON.Valid && customFieldsJSON.String != "" {
+		json.Unmarshal([]byte(customFieldsJSON.String), &ticket.CustomFields)
+		if len(ticket.CustomFields) == 0 {
+			ticket.CustomFields = nil
+		}
+	}
+
+	return ticket, nil
+}
+
+// GetAll obtiene todos los tickets de la base de datos
+func (r *TicketRepository) GetAll() ([]models.Ticket, error) {
+	query := `SELECT ` + ticketColumns + ` FROM tickets t ORDER BY t.created_at DESC`
+
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar tickets: %v", err)
+	}
+	defer rows.Close()
+
+	tickets := make([]models.Ticket, 0)
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error al escanear ticket: %v", err)
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar tickets: %v", err)
+	}
+	rows.Close()
 
-	ticket.CreatedAt = createdAt
-	ticket.UpdatedAt = updatedAt
+	// Cargar mensajes de cada ticket una vez cerrado el cursor
+	for i := range tickets {
+		messages, err := r.getMessagesForTicket(tickets[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener mensajes para ticket %s: %v", tickets[i].ID, err)
+		}
+		tickets[i].Messages = messages
+	}
+
+	return tickets, nil
+}
+
+// GetByID obtiene un ticket por su ID
+func (r *TicketRepository) GetByID(id string) (*models.Ticket, error) {
+	query := `SELECT ` + ticketColumns + ` FROM tickets t WHERE t.id = $1`
+
+	ticket, err := scanTicket(r.DB.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ticket con ID %s no encontrado", id)
+		}
+		return nil, fmt.Errorf("error al consultar ticket: %v", err)
+	}
 
 	// Cargar mensajes del ticket
 	messages, err := r.getMessagesForTicket(ticket.ID)
@@ -187,6 +155,20 @@ func (r *TicketRepository) GetByID(id string) (*models.Ticket, error) {
 	return &ticket, nil
 }
 
+// GetByExternalID obtiene un ticket por el ID externo guardado en sus metadatos
+func (r *TicketRepository) GetByExternalID(externalID string) (*models.Ticket, error) {
+	var id string
+	err := r.DB.QueryRow(`SELECT id FROM tickets WHERE metadata->>'externalId' = $1 LIMIT 1`, externalID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("ticket con ID externo %s no encontrado", externalID)
+		}
+		return nil, fmt.Errorf("error al consultar ticket por ID externo: %v", err)
+	}
+
+	return r.GetByID(id)
+}
+
 // Create crea un nuevo ticket en la base de datos
 func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error) {
 	// Iniciar transacción
@@ -196,9 +178,24 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 	}
 	defer tx.Rollback()
 
+	if err := createTicketTx(tx, &ticket); err != nil {
+		return nil, err
+	}
+
+	// Confirmar transacción
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+
+	logging.Infof("Ticket creado con ID: %s", ticket.ID)
+	return &ticket, nil
+}
+
+// createTicketTx inserta el ticket y sus mensajes dentro de una transacción
+func createTicketTx(tx *sql.Tx, ticket *models.Ticket) error {
 	// Generar ID si no existe
 	if ticket.ID == "" {
-		ticket.ID = fmt.Sprintf("TICKET-%s", time.Now().Format("20060102-150405"))
+		ticket.ID = utils.GenerateTicketID(time.Now())
 	}
 
 	// Establecer timestamps si no están definidos
@@ -215,7 +212,7 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 	if ticket.Metadata != nil {
 		data, err := json.Marshal(ticket.Metadata)
 		if err != nil {
-			return nil, fmt.Errorf("error al serializar metadata: %v", err)
+			return fmt.Errorf("error al serializar metadata: %v", err)
 		}
 		metadataJSON = sql.NullString{String: string(data), Valid: true}
 	}
@@ -225,14 +222,15 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 		INSERT INTO tickets (
 			id, title, subject, description, status, priority, category, category_id,
 			assigned_to, created_by, user_id, source, widget_id, department, metadata,
-			created_at, updated_at
+			tags, team_id, custom_fields, merged_into, split_from, created_at, updated_at, resolved_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+			CASE WHEN $5 IN ('resolved', 'closed') THEN COALESCE($23, $22) END
 		)
 		RETURNING id
 	`
 
-	err = tx.QueryRow(
+	err := tx.QueryRow(
 		query,
 		ticket.ID,
 		ticket.Title,
@@ -249,12 +247,21 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 		ticket.WidgetID,
 		ticket.Department,
 		metadataJSON,
+		stringListJSON(ticket.Tags),
+		nullString(ticket.TeamID),
+		customFieldsJSON(ticket.CustomFields),
+		nullString(ticket.MergedInto),
+		nullString(ticket.SplitFrom),
 		ticket.CreatedAt,
 		ticket.UpdatedAt,
+		ticket.ResolvedAt,
 	).Scan(&ticket.ID)
 
 	if err != nil {
-		return nil, fmt.Errorf("error al crear ticket: %v", err)
+		return fmt.Errorf("error al crear ticket: %v", err)
+	}
+	if err := syncTicketTagsTx(tx, ticket.ID, ticket.Tags); err != nil {
+		return err
 	}
 
 	// Insertar mensajes si existen
@@ -279,13 +286,13 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 		messageQuery := `
 			INSERT INTO messages (
 				id, ticket_id, content, is_client, is_internal, user_id,
-				user_name, user_email, timestamp, created_at
+				user_name, user_email, timestamp, created_at, mentions
 			) VALUES (
-				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 			)
 		`
 
-		_, err = tx.Exec(
+		_, err := tx.Exec(
 			messageQuery,
 			message.ID,
 			ticket.ID,
@@ -297,20 +304,15 @@ func (r *TicketRepository) Create(ticket models.Ticket) (*models.Ticket, error)
 			nullString(message.UserEmail),
 			message.Timestamp,
 			message.CreatedAt,
+			stringListJSON(message.Mentions),
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("error al crear mensaje para ticket: %v", err)
+			return fmt.Errorf("error al crear mensaje para ticket: %v", err)
 		}
 	}
 
-	// Confirmar transacción
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error al confirmar transacción: %v", err)
-	}
-
-	log.Printf("Ticket creado con ID: %s", ticket.ID)
-	return &ticket, nil
+	return nil
 }
 
 // Update actualiza un ticket existente
@@ -322,6 +324,41 @@ func (r *TicketRepository) Update(ticket models.Ticket) error {
 	}
 	defer tx.Rollback()
 
+	if err := updateTicketTx(tx, ticket); err != nil {
+		return err
+	}
+
+	// Confirmar transacción
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+
+	return nil
+}
+
+// UpdateWithMessage actualiza el ticket y le añade un mensaje en una sola transacción
+func (r *TicketRepository) UpdateWithMessage(ticket models.Ticket, message models.Message) (*models.Message, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateTicketTx(tx, ticket); err != nil {
+		return nil, err
+	}
+	if err := insertMessageTx(tx, ticket.ID, &message); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return &message, nil
+}
+
+// updateTicketTx actualiza los campos del ticket (no sus mensajes) dentro de una transacción
+func updateTicketTx(tx *sql.Tx, ticket models.Ticket) error {
 	// Actualizar timestamp
 	ticket.UpdatedAt = time.Now()
 
@@ -341,7 +378,9 @@ func (r *TicketRepository) Update(ticket models.Ticket) error {
 		SET title = $2, subject = $3, description = $4, status = $5,
 		    priority = $6, category = $7, category_id = $8, assigned_to = $9,
 		    created_by = $10, user_id = $11, source = $12, widget_id = $13,
-		    department = $14, metadata = $15, updated_at = $16
+		    department = $14, metadata = $15, tags = $16, team_id = $17, custom_fields = $18,
+		    merged_into = $19, split_from = $20, updated_at = $21,
+		    resolved_at = CASE WHEN $5 IN ('resolved', 'closed') THEN COALESCE(resolved_at, $21) END
 		WHERE id = $1
 	`
 
@@ -362,6 +401,11 @@ func (r *TicketRepository) Update(ticket models.Ticket) error {
 		ticket.WidgetID,
 		ticket.Department,
 		metadataJSON,
+		stringListJSON(ticket.Tags),
+		nullString(ticket.TeamID),
+		customFieldsJSON(ticket.CustomFields),
+		nullString(ticket.MergedInto),
+		nullString(ticket.SplitFrom),
 		ticket.UpdatedAt,
 	)
 
@@ -378,6 +422,43 @@ func (r *TicketRepository) Update(ticket models.Ticket) error {
 		return fmt.Errorf("ticket con ID %s no encontrado", ticket.ID)
 	}
 
+	return syncTicketTagsTx(tx, ticket.ID, ticket.Tags)
+}
+
+// syncTicketTagsTx rehace los enlaces del ticket con el catálogo de etiquetas. La
+// columna tags conserva los nombres para leer el ticket sin joins.
+func syncTicketTagsTx(tx *sql.Tx, ticketID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM ticket_tags WHERE ticket_id = $1`, ticketID); err != nil {
+		return fmt.Errorf("error al actualizar etiquetas del ticket: %v", err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO ticket_tags (ticket_id, tag_id)
+		SELECT $1, id FROM tags
+		WHERE LOWER(name) IN (SELECT LOWER(value) FROM jsonb_array_elements_text($2::jsonb))
+		ON CONFLICT DO NOTHING
+	`, ticketID, stringListJSON(tags))
+	if err != nil {
+		return fmt.Errorf("error al actualizar etiquetas del ticket: %v", err)
+	}
+	return nil
+}
+
+// Delete elimina un ticket y sus mensajes
+func (r *TicketRepository) Delete(id string) error {
+	// Iniciar transacción
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteTicketTx(tx, id); err != nil {
+		return err
+	}
+
 	// Confirmar transacción
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("error al confirmar transacción: %v", err)
@@ -386,17 +467,111 @@ func (r *TicketRepository) Update(ticket models.Ticket) error {
 	return nil
 }
 
-// Delete elimina un ticket y sus mensajes
-func (r *TicketRepository) Delete(id string) error {
-	// Iniciar transacción
+// UpdateBatch actualiza y elimina varios tickets en una sola transacción: si falla
+// alguno no se aplica ningún cambio del lote
+func (r *TicketRepository) UpdateBatch(tickets []models.Ticket, deleteIDs []string) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return fmt.Errorf("error al iniciar transacción: %v", err)
 	}
 	defer tx.Rollback()
 
+	for _, ticket := range tickets {
+		if err := updateTicketTx(tx, ticket); err != nil {
+			return err
+		}
+	}
+	for _, id := range deleteIDs {
+		if err := deleteTicketTx(tx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// Merge pasa los mensajes (con sus adjuntos) y el historial de source a target y guarda
+// ambos tickets en una sola transacción. Devuelve cuántos mensajes se movieron.
+func (r *TicketRepository) Merge(source, target models.Ticket) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE messages SET ticket_id = $2 WHERE ticket_id = $1`, source.ID, target.ID)
+	if err != nil {
+		return 0, fmt.Errorf("error al mover mensajes: %v", err)
+	}
+	moved, _ := result.RowsAffected()
+
+	if _, err := tx.Exec(`UPDATE activities SET target_id = $2 WHERE target_id = $1`, source.ID, target.ID); err != nil {
+		return 0, fmt.Errorf("error al mover historial: %v", err)
+	}
+	// Los seguidores pasan al destino salvo que ya lo siguieran; el resto se borra con el ticket
+	if _, err := tx.Exec(`
+		UPDATE ticket_watchers w SET ticket_id = $2
+		WHERE w.ticket_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM ticket_watchers t
+			WHERE t.ticket_id = $2
+			  AND ((w.user_id IS NOT NULL AND t.user_id = w.user_id)
+			    OR (w.user_id IS NULL AND t.user_id IS NULL AND LOWER(t.email) = LOWER(w.email)))
+		)
+	`, source.ID, target.ID); err != nil {
+		return 0, fmt.Errorf("error al mover seguidores: %v", err)
+	}
+	if err := updateTicketTx(tx, source); err != nil {
+		return 0, err
+	}
+	if err := updateTicketTx(tx, target); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return int(moved), nil
+}
+
+// Split crea el ticket nuevo y le pasa los mensajes indicados de sourceID en una sola
+// transacción. Los mensajes de newTicket.Messages se ignoran.
+func (r *TicketRepository) Split(newTicket models.Ticket, sourceID string, messageIDs []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %v", err)
+	}
+	defer tx.Rollback()
+
+	newTicket.Messages = nil
+	if err := createTicketTx(tx, &newTicket); err != nil {
+		return err
+	}
+	for _, id := range messageIDs {
+		result, err := tx.Exec(`UPDATE messages SET ticket_id = $3 WHERE id = $1 AND ticket_id = $2`, id, sourceID, newTicket.ID)
+		if err != nil {
+			return fmt.Errorf("error al mover mensaje %s: %v", id, err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("el mensaje %s no pertenece al ticket %s", id, sourceID)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE tickets SET updated_at = NOW() WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("error al actualizar ticket: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+	return nil
+}
+
+// deleteTicketTx elimina un ticket, sus mensajes y su metadata dentro de una transacción
+func deleteTicketTx(tx *sql.Tx, id string) error {
 	// Eliminar mensajes asociados
-	_, err = tx.Exec("DELETE FROM messages WHERE ticket_id = $1", id)
+	_, err := tx.Exec("DELETE FROM messages WHERE ticket_id = $1", id)
 	if err != nil {
 		return fmt.Errorf("error al eliminar mensajes del ticket: %v", err)
 	}
@@ -422,11 +597,6 @@ func (r *TicketRepository) Delete(id string) error {
 		return fmt.Errorf("ticket con ID %s no encontrado", id)
 	}
 
-	// Confirmar transacción
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("error al confirmar transacción: %v", err)
-	}
-
 	return nil
 }
 
@@ -450,6 +620,29 @@ func (r *TicketRepository) AddMessage(ticketID string, message models.Message) (
 		return nil, fmt.Errorf("ticket con ID %s no encontrado", ticketID)
 	}
 
+	if err := insertMessageTx(tx, ticketID, &message); err != nil {
+		return nil, err
+	}
+
+	// Actualizar timestamp del ticket
+	now := time.Now()
+	_, err = tx.Exec("UPDATE tickets SET updated_at = $1 WHERE id = $2", now, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("error al actualizar timestamp del ticket: %v", err)
+	}
+
+	// Confirmar transacción
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error al confirmar transacción: %v", err)
+	}
+
+	logging.Infof("Mensaje añadido con ID: %s", message.ID)
+	return &message, nil
+}
+
+// insertMessageTx inserta un mensaje del ticket dentro de una transacción, completando
+// su ID y sus marcas de tiempo si faltan
+func insertMessageTx(tx *sql.Tx, ticketID string, message *models.Message) error {
 	// Generar ID para mensaje si no existe
 	if message.ID == "" {
 		message.ID = uuid.New().String()
@@ -468,14 +661,14 @@ func (r *TicketRepository) AddMessage(ticketID string, message models.Message) (
 	query := `
 		INSERT INTO messages (
 			id, ticket_id, content, is_client, is_internal, user_id,
-			user_name, user_email, timestamp, created_at
+			user_name, user_email, timestamp, created_at, mentions
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 		)
 		RETURNING id
 	`
 
-	err = tx.QueryRow(
+	err := tx.QueryRow(
 		query,
 		message.ID,
 		ticketID,
@@ -487,32 +680,21 @@ func (r *TicketRepository) AddMessage(ticketID string, message models.Message) (
 		nullString(message.UserEmail),
 		message.Timestamp,
 		message.CreatedAt,
+		stringListJSON(message.Mentions),
 	).Scan(&message.ID)
 
 	if err != nil {
-		return nil, fmt.Errorf("error al crear mensaje: %v", err)
-	}
-
-	// Actualizar timestamp del ticket
-	_, err = tx.Exec("UPDATE tickets SET updated_at = $1 WHERE id = $2", now, ticketID)
-	if err != nil {
-		return nil, fmt.Errorf("error al actualizar timestamp del ticket: %v", err)
-	}
-
-	// Confirmar transacción
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("error al confirmar transacción: %v", err)
+		return fmt.Errorf("error al crear mensaje: %v", err)
 	}
 
-	log.Printf("Mensaje añadido con ID: %s", message.ID)
-	return &message, nil
+	return nil
 }
 
 // getMessagesForTicket obtiene todos los mensajes para un ticket
 func (r *TicketRepository) getMessagesForTicket(ticketID string) ([]models.Message, error) {
 	query := `
 		SELECT id, content, is_client, is_internal, user_id, user_name, user_email,
-		       timestamp, created_at
+		       timestamp, created_at, mentions
 		FROM messages
 		WHERE ticket_id = $1
 		ORDER BY timestamp ASC
@@ -529,6 +711,7 @@ func (r *TicketRepository) getMessagesForTicket(ticketID string) ([]models.Messa
 		var message models.Message
 		var userID, userName, userEmail sql.NullString
 		var timestamp, createdAt time.Time
+		var mentionsJSON []byte
 
 		err := rows.Scan(
 			&message.ID,
@@ -540,10 +723,16 @@ func (r *TicketRepository) getMessagesForTicket(ticketID string) ([]models.Messa
 			&userEmail,
 			&timestamp,
 			&createdAt,
+			&mentionsJSON,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error al escanear mensaje: %v", err)
 		}
+		if len(mentionsJSON) > 0 {
+			if err := json.Unmarshal(mentionsJSON, &message.Mentions); err != nil {
+				return nil, fmt.Errorf("error al parsear menciones del mensaje %s: %v", message.ID, err)
+			}
+		}
 
 		// Asignar valores nulos
 		if userID.Valid {
@@ -576,3 +765,21 @@ func nullString(s string) sql.NullString {
 	}
 	return sql.NullString{String: s, Valid: true}
 }
+
+// stringListJSON serializa una lista de cadenas para una columna JSONB
+// customFieldsJSON serializa los valores de los campos personalizados para una columna JSONB
+func customFieldsJSON(values map[string]interface{}) string {
+	if len(values) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func stringListJSON(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
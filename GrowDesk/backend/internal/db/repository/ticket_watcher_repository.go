@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// TicketWatcherRepository maneja las operaciones de base de datos para los
+// seguidores de tickets
+type TicketWatcherRepository struct {
+	db DB
+}
+
+// NewTicketWatcherRepository crea un nuevo repositorio de seguidores de tickets
+func NewTicketWatcherRepository(db DB) *TicketWatcherRepository {
+	return &TicketWatcherRepository{db: db}
+}
+
+const watcherColumns = `id, ticket_id, COALESCE(user_id, ''), COALESCE(email, ''), preferences,
+	COALESCE(added_by, ''), created_at`
+
+// GetByTicket obtiene los seguidores de un ticket
+func (r *TicketWatcherRepository) GetByTicket(ticketID string) ([]models.TicketWatcher, error) {
+	return r.query(`SELECT `+watcherColumns+` FROM ticket_watchers WHERE ticket_id = $1 ORDER BY created_at`, ticketID)
+}
+
+// GetByUser obtiene los tickets que sigue un usuario
+func (r *TicketWatcherRepository) GetByUser(userID string) ([]models.TicketWatcher, error) {
+	return r.query(`SELECT `+watcherColumns+` FROM ticket_watchers WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+}
+
+func (r *TicketWatcherRepository) query(query string, arg string) ([]models.TicketWatcher, error) {
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar seguidores: %v", err)
+	}
+	defer rows.Close()
+
+	watchers := make([]models.TicketWatcher, 0)
+	for rows.Next() {
+		var watcher models.TicketWatcher
+		var preferencesJSON []byte
+		if err := rows.Scan(&watcher.ID, &watcher.TicketID, &watcher.UserID, &watcher.Email, &preferencesJSON,
+			&watcher.AddedBy, &watcher.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear seguidor: %v", err)
+		}
+		if err := json.Unmarshal(preferencesJSON, &watcher.Preferences); err != nil {
+			return nil, fmt.Errorf("error al decodificar preferencias del seguidor: %v", err)
+		}
+		watchers = append(watchers, watcher)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar seguidores: %v", err)
+	}
+	return watchers, nil
+}
+
+// Create agrega un seguidor a un ticket
+func (r *TicketWatcherRepository) Create(watcher models.TicketWatcher) error {
+	if watcher.ID == "" {
+		watcher.ID = uuid.New().String()
+	}
+	if watcher.CreatedAt.IsZero() {
+		watcher.CreatedAt = time.Now()
+	}
+	preferencesJSON, err := json.Marshal(watcher.Preferences)
+	if err != nil {
+		return fmt.Errorf("error al codificar preferencias: %v", err)
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO ticket_watchers (id, ticket_id, user_id, email, preferences, added_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, watcher.ID, watcher.TicketID, nullString(watcher.UserID), nullString(watcher.Email), preferencesJSON,
+		nullString(watcher.AddedBy), watcher.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "idx_ticket_watchers_"):
+			return fmt.Errorf("ya sigue el ticket")
+		case strings.Contains(err.Error(), "foreign key"):
+			return fmt.Errorf("ticket o usuario no encontrado")
+		}
+		return fmt.Errorf("error al agregar seguidor: %v", err)
+	}
+	return nil
+}
+
+// UpdatePreferences actualiza las preferencias de un seguidor
+func (r *TicketWatcherRepository) UpdatePreferences(watcher models.TicketWatcher) error {
+	preferencesJSON, err := json.Marshal(watcher.Preferences)
+	if err != nil {
+		return fmt.Errorf("error al codificar preferencias: %v", err)
+	}
+	result, err := r.db.Exec(`UPDATE ticket_watchers SET preferences = $2 WHERE id = $1`, watcher.ID, preferencesJSON)
+	if err != nil {
+		return fmt.Errorf("error al actualizar seguidor: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("seguidor con ID %s no encontrado", watcher.ID)
+	}
+	return nil
+}
+
+// Delete elimina un seguidor
+func (r *TicketWatcherRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM ticket_watchers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error al eliminar seguidor: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("seguidor con ID %s no encontrado", id)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,24 +11,67 @@ import (
 
 // UserRepository maneja las operaciones de base de datos para los usuarios
 type UserRepository struct {
-	db *sql.DB
+	db DB
 }
 
 // NewUserRepository crea un nuevo repositorio de usuarios
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+const userColumns = `id, first_name, last_name, email, password, role, COALESCE(department, ''), active,
+		       position, phone, language, skills, max_open_tickets, availability, schedule, created_at, updated_at`
+
+// scanUser convierte una fila de userColumns en un usuario (incluida la contraseña)
+func scanUser(scanner interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var user models.User
+	var position, phone, language sql.NullString
+	var skillsJSON, scheduleJSON []byte
+
+	err := scanner.Scan(
+		&user.ID,
+		&user.FirstName,
+		&user.LastName,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.Department,
+		&user.Active,
+		&position,
+		&phone,
+		&language,
+		&skillsJSON,
+		&user.MaxOpenTickets,
+		&user.Availability,
+		&scheduleJSON,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convertir sql.NullString a string
+	user.Position = position.String
+	user.Phone = phone.String
+	user.Language = language.String
+	if len(skillsJSON) > 0 {
+		if err := json.Unmarshal(skillsJSON, &user.Skills); err != nil {
+			return nil, fmt.Errorf("error al parsear habilidades del usuario %s: %v", user.ID, err)
+		}
+	}
+	if len(scheduleJSON) > 0 {
+		if err := json.Unmarshal(scheduleJSON, &user.Schedule); err != nil {
+			return nil, fmt.Errorf("error al parsear horario del usuario %s: %v", user.ID, err)
+		}
+	}
+
+	return &user, nil
+}
+
 // GetAll obtiene todos los usuarios
 func (r *UserRepository) GetAll() ([]models.User, error) {
-	query := `
-		SELECT id, first_name, last_name, email, role, department, active, 
-		       position, phone, language, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC
-	`
-
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, fmt.Errorf("error al consultar usuarios: %v", err)
 	}
@@ -35,35 +79,12 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 
 	users := make([]models.User, 0)
 	for rows.Next() {
-		var user models.User
-		var createdAt, updatedAt time.Time
-		var position, phone, language sql.NullString
-
-		err := rows.Scan(
-			&user.ID,
-			&user.FirstName,
-			&user.LastName,
-			&user.Email,
-			&user.Role,
-			&user.Department,
-			&user.Active,
-			&position,
-			&phone,
-			&language,
-			&createdAt,
-			&updatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error al escanear usuario: %v", err)
 		}
-
-		// Convertir sql.NullString a string
-		user.Position = position.String
-		user.Phone = phone.String
-		user.Language = language.String
-		user.CreatedAt = createdAt
-		user.UpdatedAt = updatedAt
-		users = append(users, user)
+		user.Password = ""
+		users = append(users, *user)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -75,31 +96,7 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 
 // GetByID obtiene un usuario por su ID
 func (r *UserRepository) GetByID(id string) (*models.User, error) {
-	query := `
-		SELECT id, first_name, last_name, email, role, department, active,
-		       position, phone, language, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-
-	var user models.User
-	var createdAt, updatedAt time.Time
-	var position, phone, language sql.NullString
-
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.Email,
-		&user.Role,
-		&user.Department,
-		&user.Active,
-		&position,
-		&phone,
-		&language,
-		&createdAt,
-		&updatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("usuario con ID %s no encontrado", id)
@@ -107,44 +104,13 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 		return nil, fmt.Errorf("error al consultar usuario: %v", err)
 	}
 
-	// Convertir sql.NullString a string
-	user.Position = position.String
-	user.Phone = phone.String
-	user.Language = language.String
-	user.CreatedAt = createdAt
-	user.UpdatedAt = updatedAt
-
-	return &user, nil
+	user.Password = ""
+	return user, nil
 }
 
 // GetByEmail obtiene un usuario por su correo electrónico
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
-	query := `
-		SELECT id, first_name, last_name, email, password, role, department, active,
-		       position, phone, language, created_at, updated_at
-		FROM users
-		WHERE email = $1
-	`
-
-	var user models.User
-	var createdAt, updatedAt time.Time
-	var position, phone, language sql.NullString
-
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.FirstName,
-		&user.LastName,
-		&user.Email,
-		&user.Password,
-		&user.Role,
-		&user.Department,
-		&user.Active,
-		&position,
-		&phone,
-		&language,
-		&createdAt,
-		&updatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = $1`, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("usuario con email %s no encontrado", email)
@@ -152,22 +118,16 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		return nil, fmt.Errorf("error al consultar usuario por email: %v", err)
 	}
 
-	// Convertir sql.NullString a string
-	user.Position = position.String
-	user.Phone = phone.String
-	user.Language = language.String
-	user.CreatedAt = createdAt
-	user.UpdatedAt = updatedAt
-
-	return &user, nil
+	return user, nil
 }
 
 // Create crea un nuevo usuario
 func (r *UserRepository) Create(user models.User) (*models.User, error) {
 	query := `
 		INSERT INTO users (id, first_name, last_name, email, password, role, department,
-		                  active, position, phone, language, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		                  active, position, phone, language, skills, max_open_tickets,
+		                  availability, schedule, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id
 	`
 
@@ -192,6 +152,10 @@ func (r *UserRepository) Create(user models.User) (*models.User, error) {
 		user.Position,
 		user.Phone,
 		user.Language,
+		stringListJSON(user.Skills),
+		user.MaxOpenTickets,
+		availabilityOrDefault(user.Availability),
+		scheduleValue(user.Schedule),
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
@@ -209,7 +173,8 @@ func (r *UserRepository) Update(user models.User) error {
 		UPDATE users
 		SET first_name = $2, last_name = $3, email = $4, role = $5,
 		    department = $6, active = $7, position = $8, phone = $9,
-		    language = $10, updated_at = $11
+		    language = $10, skills = $11, max_open_tickets = $12, availability = $13,
+		    schedule = $14, updated_at = $15
 		WHERE id = $1
 	`
 
@@ -228,6 +193,10 @@ func (r *UserRepository) Update(user models.User) error {
 		user.Position,
 		user.Phone,
 		user.Language,
+		stringListJSON(user.Skills),
+		user.MaxOpenTickets,
+		availabilityOrDefault(user.Availability),
+		scheduleValue(user.Schedule),
 		user.UpdatedAt,
 	)
 	if err != nil {
@@ -293,3 +262,19 @@ func (r *UserRepository) UpdatePassword(id string, password string) error {
 
 	return nil
 }
+
+func availabilityOrDefault(availability string) string {
+	if availability == "" {
+		return models.AvailabilityOffline
+	}
+	return availability
+}
+
+// scheduleValue serializa el horario del agente; nil se guarda como NULL
+func scheduleValue(schedule *models.WorkingSchedule) interface{} {
+	if schedule == nil {
+		return nil
+	}
+	data, _ := json.Marshal(schedule)
+	return string(data)
+}
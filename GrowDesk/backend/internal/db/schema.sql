@@ -1,5 +1,13 @@
 -- schema.sql - Definición del esquema de la base de datos GrowDesk
 
+-- Versión del esquema aplicada (una sola fila). La registra InitializeSchema con
+-- db.SchemaVersion, que hay que incrementar al modificar este archivo.
+CREATE TABLE IF NOT EXISTS schema_version (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version INTEGER NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Extensiones necesarias
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
@@ -17,10 +25,19 @@ CREATE TABLE IF NOT EXISTS users (
     position TEXT,
     phone TEXT,
     language TEXT,
+    skills JSONB NOT NULL DEFAULT '[]',
+    max_open_tickets INTEGER NOT NULL DEFAULT 0,
+    availability TEXT NOT NULL DEFAULT 'offline',
+    schedule JSONB,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+ALTER TABLE users ADD COLUMN IF NOT EXISTS skills JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_tickets INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS availability TEXT NOT NULL DEFAULT 'offline';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS schedule JSONB;
+
 -- Tabla de categorías
 CREATE TABLE IF NOT EXISTS categories (
     id TEXT PRIMARY KEY,
@@ -50,10 +67,23 @@ CREATE TABLE IF NOT EXISTS tickets (
     widget_id TEXT,
     department TEXT,
     metadata JSONB,
+    tags JSONB NOT NULL DEFAULT '[]',
+    team_id TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS team_id TEXT;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS merged_into TEXT;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS split_from TEXT;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE;
+
+-- Los tickets resueltos antes de existir resolved_at toman su última actualización
+UPDATE tickets SET resolved_at = updated_at
+WHERE status IN ('resolved', 'closed') AND resolved_at IS NULL;
+
 -- Tabla de metadatos de tickets
 CREATE TABLE IF NOT EXISTS ticket_metadata (
     id SERIAL PRIMARY KEY,
@@ -89,6 +119,9 @@ CREATE TABLE IF NOT EXISTS messages (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Agentes mencionados con @ en el mensaje (IDs de usuario)
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS mentions JSONB NOT NULL DEFAULT '[]';
+
 -- Tabla de FAQs
 CREATE TABLE IF NOT EXISTS faqs (
     id SERIAL PRIMARY KEY,
@@ -116,69 +149,18 @@ CREATE TABLE IF NOT EXISTS widget_settings (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
--- Tabla de tickets de widget (para el widget-api)
-CREATE TABLE IF NOT EXISTS widget_tickets (
-    ticket_id TEXT PRIMARY KEY,
-    title TEXT,
-    subject TEXT,
-    description TEXT,
-    status TEXT,
-    priority TEXT,
-    client_name TEXT,
-    client_email TEXT,
-    widget_id TEXT,
-    department TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
-
--- Tabla de mensajes de widget (para el widget-api)
-CREATE TABLE IF NOT EXISTS widget_messages (
-    id TEXT PRIMARY KEY,
-    ticket_id TEXT REFERENCES widget_tickets(ticket_id) ON DELETE CASCADE,
-    content TEXT,
-    is_client BOOLEAN DEFAULT TRUE,
-    user_name TEXT,
-    user_email TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
-
--- Tabla de tickets de widget (para el sistema principal)
-CREATE TABLE IF NOT EXISTS widget_tickets_main (
-    id TEXT PRIMARY KEY,
-    ticket_id TEXT UNIQUE NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
-    title TEXT NOT NULL,
-    subject TEXT,
-    description TEXT,
-    status TEXT NOT NULL,
-    priority TEXT,
-    client_name TEXT NOT NULL,
-    client_email TEXT NOT NULL,
-    widget_id TEXT REFERENCES widget_settings(widget_id),
-    department TEXT,
-    synced BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
-
--- Tabla de mensajes de widget (para el sistema principal)
-CREATE TABLE IF NOT EXISTS widget_messages_main (
-    id TEXT PRIMARY KEY,
-    widget_ticket_id TEXT REFERENCES widget_tickets_main(ticket_id) ON DELETE CASCADE,
-    content TEXT NOT NULL,
-    is_client BOOLEAN DEFAULT FALSE,
-    user_name TEXT,
-    user_email TEXT,
-    synced BOOLEAN DEFAULT FALSE,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-);
+-- Los tickets y mensajes del widget viven en widget_tickets/widget_messages,
+-- tablas propiedad de widget-api (ver GrowDesk-Widget/widget-api/schema.sql).
+-- Las copias duplicadas *_main ya no se usan.
+DROP TABLE IF EXISTS widget_messages_main;
+DROP TABLE IF EXISTS widget_tickets_main CASCADE;
 
 -- Tabla de sesiones de widget
 CREATE TABLE IF NOT EXISTS widget_sessions (
     id TEXT PRIMARY KEY,
     name TEXT,
     email TEXT,
-    ticket_id TEXT REFERENCES widget_tickets_main(ticket_id) ON DELETE CASCADE,
+    ticket_id TEXT,
     widget_id TEXT REFERENCES widget_settings(widget_id),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     expires_at TIMESTAMP WITH TIME ZONE,
@@ -233,34 +215,275 @@ CREATE TABLE IF NOT EXISTS attachments (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
--- Tabla de tickets para widget-api
-CREATE TABLE IF NOT EXISTS widget_tickets_api (
-    ticket_id TEXT PRIMARY KEY,
-    title TEXT,
-    subject TEXT,
-    description TEXT,
-    status TEXT,
-    priority TEXT,
-    client_name TEXT,
-    client_email TEXT,
-    widget_id TEXT,
+-- Tabla de claves de servicio (autenticación entre servicios, p. ej. widget-api)
+CREATE TABLE IF NOT EXISTS service_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    service TEXT NOT NULL,
+    key_prefix TEXT UNIQUE NOT NULL,
+    key_hash TEXT NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    act_as_user_id TEXT REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Tabla de claves de API para integraciones (personales o de la organización)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    owner_type TEXT NOT NULL CHECK (owner_type IN ('user', 'org')),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key_prefix TEXT UNIQUE NOT NULL,
+    key_hash TEXT NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    rate_limit INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Tabla de reglas de enrutamiento de tickets, evaluadas por posición al crear un ticket
+CREATE TABLE IF NOT EXISTS routing_rules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    stop_processing BOOLEAN NOT NULL DEFAULT FALSE,
+    conditions JSONB NOT NULL DEFAULT '{}',
+    actions JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Tabla de políticas de asignación automática por equipo o departamento
+CREATE TABLE IF NOT EXISTS assignment_policies (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    team_id TEXT,
     department TEXT,
+    strategy TEXT NOT NULL CHECK (strategy IN ('round_robin', 'least_open', 'skills')),
+    agent_ids JSONB NOT NULL DEFAULT '[]',
+    max_open_tickets INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_assigned_to TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
--- Tabla de mensajes para widget-api
-CREATE TABLE IF NOT EXISTS widget_messages_api (
+-- Tabla de equipos: agrupan agentes y tienen su propia cola de tickets
+CREATE TABLE IF NOT EXISTS teams (
     id TEXT PRIMARY KEY,
-    ticket_id TEXT REFERENCES widget_tickets_api(ticket_id) ON DELETE CASCADE,
-    content TEXT,
-    is_client BOOLEAN DEFAULT TRUE,
-    user_name TEXT,
-    user_email TEXT,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    synced BOOLEAN DEFAULT FALSE
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Tabla de miembros de equipos
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id TEXT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'lead')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+-- Tabla de calendarios de atención: turnos semanales, festivos y widgets que los usan
+CREATE TABLE IF NOT EXISTS business_calendars (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    time_zone TEXT NOT NULL DEFAULT '',
+    hours JSONB NOT NULL DEFAULT '[]',
+    holidays JSONB NOT NULL DEFAULT '[]',
+    widget_ids JSONB NOT NULL DEFAULT '[]',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Calendario de atención de cada equipo; al borrarlo el equipo usa el calendario por defecto
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS calendar_id TEXT REFERENCES business_calendars(id) ON DELETE SET NULL;
+
+-- Tabla de macros: respuestas guardadas con acciones, personales o compartidas
+CREATE TABLE IF NOT EXISTS macros (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    scope TEXT NOT NULL DEFAULT 'personal' CHECK (scope IN ('personal', 'shared')),
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reply TEXT,
+    internal BOOLEAN NOT NULL DEFAULT FALSE,
+    actions JSONB NOT NULL DEFAULT '{}',
+    usage_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Catálogo de etiquetas y su relación con los tickets. tickets.tags guarda además los
+-- nombres para leer el ticket sin joins.
+CREATE TABLE IF NOT EXISTS tags (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    color TEXT,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags(LOWER(name));
+
+CREATE TABLE IF NOT EXISTS ticket_tags (
+    ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    tag_id TEXT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (ticket_id, tag_id)
+);
+
+-- Campos personalizados de tickets y contactos definidos por los administradores
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id TEXT PRIMARY KEY,
+    key TEXT NOT NULL,
+    label TEXT NOT NULL,
+    description TEXT,
+    placeholder TEXT,
+    type TEXT NOT NULL CHECK (type IN ('text', 'number', 'date', 'dropdown', 'multi_select', 'checkbox')),
+    entity TEXT NOT NULL CHECK (entity IN ('ticket', 'contact')),
+    options JSONB NOT NULL DEFAULT '[]',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    show_in_prechat BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (entity, key)
+);
+
+-- Contactos: clientes identificados por email con sus campos personalizados
+CREATE TABLE IF NOT EXISTS contacts (
+    email TEXT PRIMARY KEY,
+    name TEXT,
+    custom_fields JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Relaciones entre tickets, guardadas en un solo sentido: duplicate_of, related_to,
+-- child_of (ticket_id es hijo de related_ticket_id) y blocks
+CREATE TABLE IF NOT EXISTS ticket_relations (
+    id TEXT PRIMARY KEY,
+    ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    related_ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('duplicate_of', 'related_to', 'child_of', 'blocks')),
+    created_by TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (ticket_id, related_ticket_id, type),
+    CHECK (ticket_id <> related_ticket_id)
+);
+
+-- Seguidores de tickets: agentes (user_id) o contactos del cliente en copia (email)
+CREATE TABLE IF NOT EXISTS ticket_watchers (
+    id TEXT PRIMARY KEY,
+    ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT,
+    preferences JSONB NOT NULL DEFAULT '{}'::jsonb,
+    added_by TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (user_id IS NOT NULL OR email IS NOT NULL)
+);
+
+-- Encuestas de satisfacción (CSAT) enviadas al resolver o cerrar un ticket. Agente,
+-- equipo y categoría se copian al enviarla para agregar las valoraciones.
+CREATE TABLE IF NOT EXISTS csat_surveys (
+    id TEXT PRIMARY KEY,
+    ticket_id TEXT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    agent_id TEXT,
+    team_id TEXT,
+    category_id TEXT,
+    category TEXT,
+    customer_email TEXT,
+    rating INTEGER CHECK (rating IS NULL OR rating BETWEEN 1 AND 5),
+    comment TEXT,
+    channel TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Exportaciones programadas de tickets, mensajes o métricas (CSV/XLSX)
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    dataset TEXT NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('csv', 'xlsx')),
+    range_days INTEGER NOT NULL DEFAULT 7,
+    group_by TEXT,
+    filters JSONB NOT NULL DEFAULT '{}',
+    delivery JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Historial de ejecuciones; el archivo generado se guarda en disco (EXPORTS_DIR)
+CREATE TABLE IF NOT EXISTS report_exports (
+    id TEXT PRIMARY KEY,
+    schedule_id TEXT REFERENCES report_schedules(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    dataset TEXT NOT NULL,
+    format TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT,
+    file_name TEXT,
+    size BIGINT NOT NULL DEFAULT 0,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    range_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    range_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    triggered_by TEXT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+    deliveries JSONB NOT NULL DEFAULT '[]'
+);
+
+-- Los tickets referencian a su equipo; al borrar el equipo vuelven a quedar sin equipo
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_tickets_team') THEN
+        UPDATE tickets SET team_id = NULL
+        WHERE team_id IS NOT NULL AND team_id NOT IN (SELECT id FROM teams);
+        ALTER TABLE tickets ADD CONSTRAINT fk_tickets_team
+            FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+-- Datos de los informes (/api/reports/*): una fila por ticket no fusionado con su primera
+-- respuesta pública de un agente. La aplicación la refresca periódicamente.
+CREATE MATERIALIZED VIEW IF NOT EXISTS ticket_report_facts AS
+SELECT
+    t.id,
+    t.created_at,
+    CASE WHEN t.status IN ('resolved', 'closed') THEN COALESCE(t.resolved_at, t.updated_at) END AS resolved_at,
+    t.status,
+    COALESCE(t.priority, '') AS priority,
+    COALESCE(t.category_id, '') AS category_id,
+    COALESCE(t.source, '') AS source,
+    COALESCE(t.widget_id, '') AS widget_id,
+    COALESCE(t.assigned_to, '') AS assigned_to,
+    COALESCE(t.team_id, '') AS team_id,
+    (
+        SELECT MIN(m.timestamp) FROM messages m
+        WHERE m.ticket_id = t.id AND NOT COALESCE(m.is_client, FALSE) AND NOT COALESCE(m.is_internal, FALSE)
+    ) AS first_response_at
+FROM tickets t
+WHERE COALESCE(t.merged_into, '') = '';
+
 -- Índices
 CREATE INDEX IF NOT EXISTS idx_tickets_status ON tickets(status);
 CREATE INDEX IF NOT EXISTS idx_tickets_user_id ON tickets(user_id);
@@ -269,13 +492,32 @@ CREATE INDEX IF NOT EXISTS idx_tickets_assigned_to ON tickets(assigned_to);
 CREATE INDEX IF NOT EXISTS idx_messages_ticket_id ON messages(ticket_id);
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 CREATE INDEX IF NOT EXISTS idx_faqs_is_published ON faqs(is_published);
-CREATE INDEX IF NOT EXISTS idx_widget_tickets_main_widget_id ON widget_tickets_main(widget_id);
-CREATE INDEX IF NOT EXISTS idx_widget_messages_main_widget_ticket_id ON widget_messages_main(widget_ticket_id);
 CREATE INDEX IF NOT EXISTS idx_activities_user_id ON activities(user_id);
 CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
 CREATE INDEX IF NOT EXISTS idx_notifications_read ON notifications(read);
-CREATE INDEX IF NOT EXISTS idx_widget_messages_ticket_id ON widget_messages(ticket_id);
-CREATE INDEX IF NOT EXISTS idx_widget_messages_api_ticket_id ON widget_messages_api(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_team_id ON tickets(team_id);
+CREATE INDEX IF NOT EXISTS idx_routing_rules_position ON routing_rules(position);
+CREATE INDEX IF NOT EXISTS idx_activities_target_id ON activities(target_id);
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_macros_owner_id ON macros(owner_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_tags_tag_id ON ticket_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_custom_fields ON tickets USING GIN (custom_fields);
+CREATE INDEX IF NOT EXISTS idx_tickets_merged_into ON tickets(merged_into);
+-- Búsqueda de la creación idempotente por ID externo
+CREATE INDEX IF NOT EXISTS idx_tickets_external_id ON tickets ((metadata->>'externalId'));
+CREATE INDEX IF NOT EXISTS idx_ticket_relations_related ON ticket_relations(related_ticket_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_watchers_user ON ticket_watchers(ticket_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_watchers_email ON ticket_watchers(ticket_id, LOWER(email)) WHERE user_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_ticket_watchers_user_id ON ticket_watchers(user_id);
+CREATE INDEX IF NOT EXISTS idx_csat_surveys_ticket_id ON csat_surveys(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_csat_surveys_sent_at ON csat_surveys(sent_at);
+CREATE INDEX IF NOT EXISTS idx_messages_timestamp ON messages(timestamp);
+CREATE INDEX IF NOT EXISTS idx_report_exports_schedule_id ON report_exports(schedule_id);
+CREATE INDEX IF NOT EXISTS idx_report_exports_started_at ON report_exports(started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_report_facts_id ON ticket_report_facts(id);
+CREATE INDEX IF NOT EXISTS idx_ticket_report_facts_created_at ON ticket_report_facts(created_at);
+CREATE INDEX IF NOT EXISTS idx_ticket_report_facts_resolved_at ON ticket_report_facts(resolved_at);
 
 -- Datos iniciales por defecto
 -- Insertar usuarios por defecto si no existen
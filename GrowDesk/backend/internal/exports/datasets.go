@@ -0,0 +1,205 @@
+package exports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+)
+
+// Conjuntos de datos exportables: tickets y mensajes en bruto, o una de las métricas de
+// /api/reports/*
+const (
+	DatasetTickets       = "tickets"
+	DatasetMessages      = "messages"
+	DatasetVolume        = "volume"
+	DatasetFirstResponse = "first-response"
+	DatasetResolution    = "resolution"
+	DatasetBacklog       = "backlog"
+	DatasetAgents        = "agents"
+)
+
+// ValidDataset indica si dataset es un conjunto de datos conocido
+func ValidDataset(dataset string) bool {
+	switch dataset {
+	case DatasetTickets, DatasetMessages, DatasetVolume, DatasetFirstResponse, DatasetResolution,
+		DatasetBacklog, DatasetAgents:
+		return true
+	}
+	return false
+}
+
+// IsMetric indica si el conjunto de datos es una métrica agrupada
+func IsMetric(dataset string) bool {
+	return dataset != DatasetTickets && dataset != DatasetMessages
+}
+
+// Build genera las filas del conjunto de datos para el periodo y filtros de query
+func Build(store data.DataStore, dataset string, query models.ReportQuery) (Table, error) {
+	switch dataset {
+	case DatasetTickets:
+		return ticketsTable(store, query)
+	case DatasetMessages:
+		return messagesTable(store, query)
+	}
+	return metricTable(store, dataset, query)
+}
+
+// matchingTickets devuelve los tickets que cumplen los filtros de query
+func matchingTickets(store data.DataStore, query models.ReportQuery) ([]models.Ticket, error) {
+	tickets, err := store.GetTickets()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tickets: %v", err)
+	}
+	matching := make([]models.Ticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		if reports.NewFact(ticket).Matches(query) {
+			matching = append(matching, ticket)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].CreatedAt.Before(matching[j].CreatedAt) })
+	return matching, nil
+}
+
+// ticketsTable exporta los tickets creados en el periodo
+func ticketsTable(store data.DataStore, query models.ReportQuery) (Table, error) {
+	tickets, err := matchingTickets(store, query)
+	if err != nil {
+		return Table{}, err
+	}
+	agentName := reports.Labeler(store, reports.GroupAgent)
+	teamNames := make(map[string]string)
+	if teams, err := store.GetTeams(); err == nil {
+		for _, team := range teams {
+			teamNames[team.ID] = team.Name
+		}
+	}
+
+	table := Table{
+		Sheet: "Tickets",
+		Columns: []string{"ID", "Título", "Estado", "Prioridad", "Categoría", "Origen", "Widget",
+			"Asignado a", "Equipo", "Cliente", "Email del cliente", "Etiquetas", "Creado",
+			"Primera respuesta", "Resuelto", "Actualizado", "Mensajes", "Fusionado en"},
+	}
+	for _, ticket := range tickets {
+		if ticket.CreatedAt.Before(query.From) || !ticket.CreatedAt.Before(query.To) {
+			continue
+		}
+		fact := reports.NewFact(ticket)
+		assigned := ""
+		if ticket.AssignedTo != "" {
+			if assigned = agentName(ticket.AssignedTo); assigned == "" {
+				assigned = ticket.AssignedTo
+			}
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			ticket.ID, ticket.Title, ticket.Status, ticket.Priority, ticket.Category, ticket.Source,
+			ticket.WidgetID, assigned, teamNames[ticket.TeamID], ticket.Customer.Name, ticket.Customer.Email,
+			strings.Join(ticket.Tags, ", "), ticket.CreatedAt, fact.FirstResponseAt, fact.ResolvedAt,
+			ticket.UpdatedAt, len(ticket.Messages), ticket.MergedInto,
+		})
+	}
+	return table, nil
+}
+
+// messagesTable exporta los mensajes enviados en el periodo, notas internas incluidas
+func messagesTable(store data.DataStore, query models.ReportQuery) (Table, error) {
+	tickets, err := matchingTickets(store, query)
+	if err != nil {
+		return Table{}, err
+	}
+
+	type ticketMessage struct {
+		ticketID string
+		message  models.Message
+	}
+	var messages []ticketMessage
+	for _, ticket := range tickets {
+		for _, message := range ticket.Messages {
+			if !message.Timestamp.Before(query.From) && message.Timestamp.Before(query.To) {
+				messages = append(messages, ticketMessage{ticket.ID, message})
+			}
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].message.Timestamp.Before(messages[j].message.Timestamp)
+	})
+
+	table := Table{
+		Sheet:   "Mensajes",
+		Columns: []string{"Ticket", "ID", "Fecha", "Tipo", "Usuario", "Nombre", "Email", "Contenido"},
+	}
+	for _, m := range messages {
+		kind := "agente"
+		if m.message.IsInternal {
+			kind = "nota interna"
+		} else if m.message.IsClient {
+			kind = "cliente"
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			m.ticketID, m.message.ID, m.message.Timestamp, kind, m.message.UserID, m.message.UserName,
+			m.message.UserEmail, m.message.Content,
+		})
+	}
+	return table, nil
+}
+
+// metricTable exporta una de las métricas de los informes con sus etiquetas
+func metricTable(store data.DataStore, dataset string, query models.ReportQuery) (Table, error) {
+	label := reports.Labeler(store, query.GroupBy)
+	table := Table{Sheet: dataset}
+
+	switch dataset {
+	case DatasetVolume:
+		rows, err := store.ReportVolume(query)
+		if err != nil {
+			return table, err
+		}
+		table.Columns = []string{"Grupo", "Nombre", "Creados", "Resueltos"}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []interface{}{row.Key, label(row.Key), row.Created, row.Resolved})
+		}
+	case DatasetFirstResponse, DatasetResolution:
+		var rows []models.DurationRow
+		var err error
+		if dataset == DatasetFirstResponse {
+			rows, err = store.ReportFirstResponse(query)
+		} else {
+			rows, err = store.ReportResolution(query)
+		}
+		if err != nil {
+			return table, err
+		}
+		table.Columns = []string{"Grupo", "Nombre", "Tickets", "Sin respuesta", "Media (s)", "Mediana (s)", "P90 (s)"}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []interface{}{row.Key, label(row.Key), row.Count, row.Pending,
+				row.AverageSeconds, row.MedianSeconds, row.P90Seconds})
+		}
+	case DatasetBacklog:
+		rows, err := store.ReportBacklog(query)
+		if err != nil {
+			return table, err
+		}
+		table.Columns = []string{"Grupo", "Nombre", "Abiertos", "Antigüedad media (s)"}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []interface{}{row.Key, label(row.Key), row.Open, row.AverageAgeSeconds})
+		}
+	case DatasetAgents:
+		rows, err := store.ReportAgentWorkload(query)
+		if err != nil {
+			return table, err
+		}
+		table.Columns = []string{"Agente", "Nombre", "Abiertos", "Asignados", "Resueltos", "Respuestas",
+			"Primera respuesta media (s)", "Resolución mediana (s)"}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []interface{}{row.AgentID, label(row.AgentID), row.Open, row.Assigned,
+				row.Resolved, row.Replies, row.AverageFirstResponseSeconds, row.MedianResolutionSeconds})
+		}
+	default:
+		return table, fmt.Errorf("conjunto de datos desconocido: %s", dataset)
+	}
+	return table, nil
+}
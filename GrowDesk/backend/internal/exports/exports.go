@@ -0,0 +1,431 @@
+// Package exports genera exportaciones programadas de tickets, mensajes y métricas en CSV
+// o XLSX, las guarda en disco para descargarlas y las entrega por correo o webhook. La
+// configuración se lee del entorno:
+//
+//	EXPORTS_DIR              carpeta de los archivos (por defecto $DATA_DIR/exports)
+//	EXPORTS_SECRET           clave para firmar los enlaces de descarga
+//	EXPORTS_RETENTION_DAYS   días que se conservan las ejecuciones (90)
+//	PUBLIC_URL               URL pública del backend para los enlaces
+package exports
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/notify"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Estados de una ejecución
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Canales y estados de entrega
+const (
+	ChannelEmail    = "email"
+	ChannelWebhook  = "webhook"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+	TriggerSchedule = "schedule"
+)
+
+// Límites de las exportaciones
+const (
+	MaxRangeDays      = 366
+	DefaultRangeDays  = 7
+	LinkTTL           = 7 * 24 * time.Hour
+	maxAttachmentSize = 10 << 20 // Los archivos mayores se envían como enlace
+)
+
+var (
+	// ErrInvalidLink indica que el enlace de descarga no es válido o ha caducado
+	ErrInvalidLink = errors.New("enlace de descarga inválido o caducado")
+	// ErrFileMissing indica que la ejecución no tiene archivo (falló o se borró)
+	ErrFileMissing = errors.New("la exportación no tiene archivo disponible")
+)
+
+// Config guarda dónde se escriben los archivos y cómo se firman sus enlaces
+type Config struct {
+	dir       string
+	secret    []byte
+	baseURL   string
+	retention time.Duration
+}
+
+var (
+	defaultConfig *Config
+	defaultOnce   sync.Once
+)
+
+// Default devuelve la configuración leída del entorno
+func Default() *Config {
+	defaultOnce.Do(func() {
+		dir := os.Getenv("EXPORTS_DIR")
+		if dir == "" {
+			dataDir := os.Getenv("DATA_DIR")
+			if dataDir == "" {
+				dataDir = "./data"
+			}
+			dir = filepath.Join(dataDir, "exports")
+		}
+		secret := []byte(os.Getenv("EXPORTS_SECRET"))
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			rand.Read(secret)
+			logging.Warnf("⚠️ EXPORTS_SECRET no definida: los enlaces de descarga caducarán al reiniciar")
+		}
+		baseURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080"
+		}
+		retentionDays := 90
+		if days, err := strconv.Atoi(os.Getenv("EXPORTS_RETENTION_DAYS")); err == nil && days > 0 {
+			retentionDays = days
+		}
+		defaultConfig = &Config{
+			dir:       dir,
+			secret:    secret,
+			baseURL:   baseURL,
+			retention: time.Duration(retentionDays) * 24 * time.Hour,
+		}
+	})
+	return defaultConfig
+}
+
+// Path devuelve la ruta en disco del archivo de una ejecución
+func (c *Config) Path(export models.ReportExport) string {
+	return filepath.Join(c.dir, export.ID+"."+export.Format)
+}
+
+// Token firma la descarga de una ejecución hasta expires (segundos Unix)
+func (c *Config) Token(exportID string, expires int64) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte("export:" + exportID + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify comprueba el token y la caducidad de un enlace de descarga
+func (c *Config) Verify(exportID, expires, token string) error {
+	at, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || token == "" || time.Now().Unix() > at {
+		return ErrInvalidLink
+	}
+	if !hmac.Equal([]byte(c.Token(exportID, at)), []byte(token)) {
+		return ErrInvalidLink
+	}
+	return nil
+}
+
+// DownloadURL devuelve un enlace de descarga firmado que no requiere sesión
+func (c *Config) DownloadURL(exportID string) (string, time.Time) {
+	expiresAt := time.Now().Add(LinkTTL)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("token", c.Token(exportID, expiresAt.Unix()))
+	return c.baseURL + "/exports/" + url.PathEscape(exportID) + "/download?" + query.Encode(), expiresAt
+}
+
+// Validate normaliza y comprueba una exportación programada
+func Validate(schedule *models.ReportSchedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" {
+		return fmt.Errorf("El nombre es obligatorio")
+	}
+	if _, err := ParseCron(schedule.Schedule); err != nil {
+		return err
+	}
+	if schedule.TimeZone == "" {
+		schedule.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+		return fmt.Errorf("Zona horaria inválida: %s", schedule.TimeZone)
+	}
+	if !ValidDataset(schedule.Dataset) {
+		return fmt.Errorf("dataset inválido: usa tickets, messages, volume, first-response, resolution, backlog o agents")
+	}
+	if schedule.Format != FormatCSV && schedule.Format != FormatXLSX {
+		return fmt.Errorf("Formato inválido: usa csv o xlsx")
+	}
+	if schedule.RangeDays == 0 {
+		schedule.RangeDays = DefaultRangeDays
+	}
+	if schedule.RangeDays < 1 || schedule.RangeDays > MaxRangeDays {
+		return fmt.Errorf("rangeDays debe estar entre 1 y %d", MaxRangeDays)
+	}
+	switch {
+	case !IsMetric(schedule.Dataset):
+		schedule.GroupBy = ""
+	case schedule.Dataset == DatasetAgents:
+		schedule.GroupBy = reports.GroupAgent
+	case schedule.GroupBy == "":
+		schedule.GroupBy = reports.GroupDay
+	case !reports.ValidGroupBy(schedule.GroupBy):
+		return fmt.Errorf("groupBy inválido: usa day, week, category, priority, source, widget o agent")
+	}
+	emails := make([]string, 0, len(schedule.Delivery.Emails))
+	for _, email := range schedule.Delivery.Emails {
+		email = strings.TrimSpace(email)
+		if err := notify.ValidateEmail(email); err != nil {
+			return err
+		}
+		emails = append(emails, email)
+	}
+	schedule.Delivery.Emails = emails
+	if schedule.Delivery.WebhookURL != "" {
+		if err := notify.ValidateWebhookURL(schedule.Delivery.WebhookURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextRun calcula la próxima ejecución posterior a after
+func NextRun(schedule models.ReportSchedule, after time.Time) (*time.Time, error) {
+	cron, err := ParseCron(schedule.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	next := cron.Next(after, loc)
+	if next.IsZero() {
+		return nil, fmt.Errorf("la expresión cron %q no se cumple nunca", schedule.Schedule)
+	}
+	return &next, nil
+}
+
+// Query devuelve el periodo y filtros de una ejecución: los RangeDays días completos
+// anteriores al día de now en la zona horaria de la programación
+func Query(schedule models.ReportSchedule, now time.Time) models.ReportQuery {
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	to := reports.BucketStart(now, reports.GroupDay, loc)
+	return models.ReportQuery{
+		From:       to.AddDate(0, 0, -schedule.RangeDays),
+		To:         to,
+		Location:   loc,
+		TimeZone:   loc.String(),
+		GroupBy:    schedule.GroupBy,
+		CategoryID: schedule.Filters.CategoryID,
+		Priority:   schedule.Filters.Priority,
+		Source:     schedule.Filters.Source,
+		WidgetID:   schedule.Filters.WidgetID,
+		AgentID:    schedule.Filters.AgentID,
+		TeamID:     schedule.Filters.TeamID,
+	}
+}
+
+// running cuenta las ejecuciones en curso en este proceso
+var running int64
+
+// Running devuelve cuántas exportaciones se están generando en este proceso
+func Running() int {
+	return int(atomic.LoadInt64(&running))
+}
+
+// Run registra una ejecución de la programación y la genera y entrega en segundo plano.
+// Devuelve la ejecución en estado running; done, si no es nil, recibe el resultado final.
+func Run(store data.DataStore, schedule models.ReportSchedule, triggeredBy string, done func(models.ReportExport)) (*models.ReportExport, error) {
+	query := Query(schedule, time.Now())
+	export := models.ReportExport{
+		ID:          uuid.New().String(),
+		ScheduleID:  schedule.ID,
+		Name:        schedule.Name,
+		Dataset:     schedule.Dataset,
+		Format:      schedule.Format,
+		Status:      StatusRunning,
+		From:        query.From,
+		To:          query.To,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	if err := store.CreateReportExport(export); err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&running, 1)
+	go func() {
+		defer atomic.AddInt64(&running, -1)
+		result := generate(store, schedule, export, query)
+		if err := store.UpdateReportExport(result); err != nil {
+			logging.Warnf("⚠️ No se pudo guardar la exportación %s: %v", export.ID, err)
+		}
+		if done != nil {
+			done(result)
+		}
+	}()
+	return &export, nil
+}
+
+// generate escribe el archivo de la ejecución y lo entrega
+func generate(store data.DataStore, schedule models.ReportSchedule, export models.ReportExport, query models.ReportQuery) models.ReportExport {
+	fail := func(err error) models.ReportExport {
+		finished := time.Now()
+		export.Status, export.Error, export.FinishedAt = StatusFailed, err.Error(), &finished
+		logging.Errorf("❌ Exportación %s (%s) fallida: %v", export.ID, export.Name, err)
+		return export
+	}
+
+	if IsMetric(schedule.Dataset) {
+		if _, err := store.RefreshReports(); err != nil {
+			logging.Warnf("⚠️ No se pudieron refrescar los informes antes de exportar: %v", err)
+		}
+	}
+	table, err := Build(store, schedule.Dataset, query)
+	if err != nil {
+		return fail(err)
+	}
+
+	config := Default()
+	if err := os.MkdirAll(config.dir, 0755); err != nil {
+		return fail(fmt.Errorf("error al crear la carpeta de exportaciones: %v", err))
+	}
+	path := config.Path(export)
+	file, err := os.Create(path)
+	if err != nil {
+		return fail(fmt.Errorf("error al crear el archivo: %v", err))
+	}
+	if err := Write(file, table, schedule.Format, query.Location); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fail(fmt.Errorf("error al escribir el archivo: %v", err))
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fail(fmt.Errorf("error al escribir el archivo: %v", err))
+	}
+	info, _ := os.Stat(path)
+
+	export.FileName = fileName(schedule, query)
+	export.Size = info.Size()
+	export.Rows = len(table.Rows)
+	export.Deliveries = deliver(config, schedule, export)
+	finished := time.Now()
+	export.Status, export.FinishedAt = StatusSucceeded, &finished
+	logging.Infof("📊 Exportación %s (%s): %d filas, %d bytes", export.ID, export.Name, export.Rows, export.Size)
+	return export
+}
+
+// fileName es el nombre con el que se descarga el archivo: nombre-desde-hasta.formato
+func fileName(schedule models.ReportSchedule, query models.ReportQuery) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, schedule.Name)
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = schedule.Dataset
+	}
+	last := query.To.In(query.Location).AddDate(0, 0, -1)
+	return fmt.Sprintf("%s-%s-%s.%s", slug, query.From.In(query.Location).Format("20060102"), last.Format("20060102"), schedule.Format)
+}
+
+// deliver envía el archivo por correo (adjunto, o enlace si es muy grande) y avisa al
+// webhook con un enlace de descarga firmado
+func deliver(config *Config, schedule models.ReportSchedule, export models.ReportExport) []models.ReportExportDelivery {
+	var deliveries []models.ReportExportDelivery
+	result := func(channel, target string, err error) models.ReportExportDelivery {
+		delivery := models.ReportExportDelivery{Channel: channel, Target: target, Status: DeliverySent}
+		if err != nil {
+			delivery.Status, delivery.Error = DeliveryFailed, err.Error()
+			logging.Warnf("⚠️ No se pudo entregar la exportación %s por %s a %s: %v", export.ID, channel, target, err)
+		}
+		return delivery
+	}
+	link, expiresAt := config.DownloadURL(export.ID)
+	period := fmt.Sprintf("%s – %s", export.From.Format("02/01/2006"), export.To.Add(-time.Second).Format("02/01/2006"))
+
+	if len(schedule.Delivery.Emails) > 0 {
+		email := notify.Email{
+			To:      schedule.Delivery.Emails,
+			Subject: fmt.Sprintf("[GrowDesk] %s (%s)", schedule.Name, period),
+			Body: fmt.Sprintf("Exportación programada \"%s\" del periodo %s: %d filas.\n",
+				schedule.Name, period, export.Rows),
+		}
+		var err error
+		if export.Size <= maxAttachmentSize {
+			var content []byte
+			if content, err = os.ReadFile(config.Path(export)); err == nil {
+				email.Attachments = []notify.Attachment{{Name: export.FileName, ContentType: ContentType(export.Format), Data: content}}
+				email.Body += "\nEl archivo va adjunto.\n"
+			}
+		} else {
+			email.Body += fmt.Sprintf("\nEl archivo es demasiado grande para adjuntarlo. Descárgalo aquí (válido hasta el %s):\n%s\n",
+				expiresAt.Format("02/01/2006"), link)
+		}
+		if err == nil {
+			err = notify.Default().SendEmail(email)
+		}
+		deliveries = append(deliveries, result(ChannelEmail, strings.Join(schedule.Delivery.Emails, ", "), err))
+	}
+
+	if schedule.Delivery.WebhookURL != "" {
+		err := notify.Default().PostWebhook(schedule.Delivery.WebhookURL, "report.export", map[string]interface{}{
+			"event":       "report.export",
+			"export":      export,
+			"downloadUrl": link,
+			"expiresAt":   expiresAt,
+		})
+		deliveries = append(deliveries, result(ChannelWebhook, schedule.Delivery.WebhookURL, err))
+	}
+	return deliveries
+}
+
+// Remove borra el archivo de una ejecución y la quita del historial
+func Remove(store data.DataStore, export models.ReportExport) error {
+	if export.FileName != "" {
+		if err := os.Remove(Default().Path(export)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error al borrar el archivo: %v", err)
+		}
+	}
+	return store.DeleteReportExport(export.ID)
+}
+
+// Cleanup borra las ejecuciones más antiguas que EXPORTS_RETENTION_DAYS
+func Cleanup(store data.DataStore, now time.Time) (int, error) {
+	exports, err := store.GetReportExports("")
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	cutoff := now.Add(-Default().retention)
+	for _, export := range exports {
+		if export.Status == StatusRunning || !export.StartedAt.Before(cutoff) {
+			continue
+		}
+		if err := Remove(store, export); err != nil {
+			logging.Warnf("⚠️ No se pudo borrar la exportación %s: %v", export.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
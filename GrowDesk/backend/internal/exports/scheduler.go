@@ -0,0 +1,79 @@
+package exports
+
+import (
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Intervalos del planificador
+const (
+	schedulerTick   = time.Minute
+	cleanupInterval = time.Hour
+)
+
+// StartScheduler comprueba cada minuto las exportaciones programadas y lanza las que
+// toquen; además borra cada hora las ejecuciones que superan la retención
+func StartScheduler(store data.DataStore) {
+	go func() {
+		ticker := time.NewTicker(schedulerTick)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			now := time.Now()
+			runDue(store, now)
+			if now.Sub(lastCleanup) >= cleanupInterval {
+				if removed, err := Cleanup(store, now); err != nil {
+					logging.Warnf("⚠️ No se pudieron limpiar las exportaciones: %v", err)
+				} else if removed > 0 {
+					logging.Infof("🧹 %d exportaciones antiguas borradas", removed)
+				}
+				lastCleanup = now
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// runDue lanza las programaciones activas cuya próxima ejecución ya ha llegado. La
+// siguiente ejecución se guarda antes de lanzar la actual para no repetirla si el
+// proceso se reinicia a mitad.
+func runDue(store data.DataStore, now time.Time) {
+	schedules, err := store.GetReportSchedules()
+	if err != nil {
+		logging.Warnf("⚠️ No se pudieron obtener las exportaciones programadas: %v", err)
+		return
+	}
+	for _, schedule := range schedules {
+		if !schedule.Enabled {
+			continue
+		}
+		due := schedule.NextRunAt != nil && !schedule.NextRunAt.After(now)
+		if schedule.NextRunAt != nil && !due {
+			continue
+		}
+
+		next, err := NextRun(schedule, now)
+		if err != nil {
+			logging.Warnf("⚠️ Exportación programada %s (%s): %v", schedule.ID, schedule.Name, err)
+			continue
+		}
+		schedule.NextRunAt = next
+		if due {
+			ranAt := now
+			schedule.LastRunAt = &ranAt
+		}
+		if err := store.UpdateReportSchedule(schedule); err != nil {
+			logging.Warnf("⚠️ No se pudo actualizar la exportación programada %s: %v", schedule.ID, err)
+			continue
+		}
+		if !due {
+			continue
+		}
+		logging.Infof("⏰ Lanzando la exportación programada %s (%s)", schedule.ID, schedule.Name)
+		if _, err := Run(store, schedule, TriggerSchedule, nil); err != nil {
+			logging.Errorf("❌ No se pudo lanzar la exportación %s: %v", schedule.ID, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatos de archivo
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentType devuelve el tipo MIME del formato
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Table son las filas de una exportación. Las celdas pueden ser string, int, int64,
+// float64, bool, time.Time, *time.Time o nil.
+type Table struct {
+	Sheet   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Write escribe la tabla en el formato indicado; las fechas se muestran en loc
+func Write(w io.Writer, table Table, format string, loc *time.Location) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, table, loc)
+	case FormatXLSX:
+		return writeXLSX(w, table, loc)
+	}
+	return fmt.Errorf("formato no soportado: %s", format)
+}
+
+// writeCSV escribe la tabla como CSV con BOM para que Excel reconozca UTF-8
+func writeCSV(w io.Writer, table Table, loc *time.Location) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = csvCell(row[i], loc)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell convierte una celda en texto. Los textos que empiezan por =, +, - o @ se
+// prefijan con ' para que las hojas de cálculo no los ejecuten como fórmulas.
+func csvCell(value interface{}, loc *time.Location) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.In(loc).Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return csvCell(*v, loc)
+	case bool:
+		if v {
+			return "sí"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// Partes fijas del libro XLSX: una hoja, y estilos para fechas (1) y cabecera en negrita (2)
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
+)
+
+// maxXLSXText es el máximo de caracteres de una celda de Excel
+const maxXLSXText = 32767
+
+// writeXLSX escribe la tabla como un libro de Excel de una hoja
+func writeXLSX(w io.Writer, table Table, loc *time.Location) error {
+	// Excel no admite []:*?/\ en el nombre de la hoja ni más de 31 caracteres
+	sheet := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, table.Sheet)
+	if utf8.RuneCountInString(sheet) > 31 {
+		sheet = string([]rune(sheet)[:31])
+	}
+	if sheet == "" {
+		sheet = "Datos"
+	}
+
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + xmlText(sheet) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]interface{}, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+	writeXLSXRow(&buf, 1, header, loc, 2)
+	for i, row := range table.Rows {
+		writeXLSXRow(&buf, i+2, row, loc, 0)
+		if buf.Len() > 64*1024 {
+			if _, err := file.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// writeXLSXRow escribe una fila; style se aplica a las celdas de texto (2 = cabecera)
+func writeXLSXRow(buf *bytes.Buffer, number int, row []interface{}, loc *time.Location, style int) {
+	fmt.Fprintf(buf, `<row r="%d">`, number)
+	for i, value := range row {
+		ref := columnName(i) + strconv.Itoa(number)
+		if t, ok := value.(*time.Time); ok {
+			if t == nil {
+				continue
+			}
+			value = *t
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			if utf8.RuneCountInString(v) > maxXLSXText {
+				v = string([]rune(v)[:maxXLSXText])
+			}
+			styleAttr := ""
+			if style > 0 {
+				styleAttr = fmt.Sprintf(` s="%d"`, style)
+			}
+			fmt.Fprintf(buf, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlText(v))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case time.Time:
+			if !v.IsZero() {
+				fmt.Fprintf(buf, `<c r="%s" s="1"><v>%s</v></c>`, ref, strconv.FormatFloat(excelSerial(v.In(loc)), 'f', -1, 64))
+			}
+		case int, int64:
+			fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(buf, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlText(fmt.Sprint(v)))
+		}
+	}
+	buf.WriteString(`</row>`)
+}
+
+// excelSerial convierte la hora local de t en un número de serie de Excel (días desde 1899-12-30)
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// columnName devuelve la letra de la columna i (0 = A, 26 = AA)
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xmlText escapa el texto para XML y reemplaza los caracteres que XML no admite
+func xmlText(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// ActivityHandler expone el historial de actividad (auditoría)
+type ActivityHandler struct {
+	Store data.DataStore
+}
+
+// GetActivities lista la actividad registrada, opcionalmente de un objeto (?targetId=) y de un tipo (?type=)
+func (h *ActivityHandler) GetActivities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if role, _ := r.Context().Value(middleware.RoleKey).(string); role == "" || role == "customer" {
+		http.Error(w, "No tienes permiso para consultar la actividad", http.StatusForbidden)
+		return
+	}
+
+	activities, err := requestStore(h.Store, r).GetActivities(r.URL.Query().Get("targetId"))
+	if err != nil {
+		http.Error(w, "Error al obtener la actividad", http.StatusInternalServerError)
+		return
+	}
+
+	if activityType := r.URL.Query().Get("type"); activityType != "" {
+		filtered := activities[:0]
+		for _, activity := range activities {
+			if activity.Type == activityType {
+				filtered = append(filtered, activity)
+			}
+		}
+		activities = filtered
+	}
+
+	utils.WriteJSON(w, http.StatusOK, activities)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// APIKeyHandler contiene manejadores para administrar claves de API de integraciones
+type APIKeyHandler struct {
+	Store data.DataStore
+}
+
+// GetAPIKeys lista las claves de API del usuario (o todas con ?all=true para administradores)
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	all := r.URL.Query().Get("all") == "true" && isAdmin(r)
+
+	keys, err := requestStore(h.Store, r).GetAPIKeys()
+	if err != nil {
+		http.Error(w, "Error al obtener claves de API", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		if !all && key.UserID != userID {
+			continue
+		}
+		key.KeyHash = ""
+		result = append(result, key)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// CreateAPIKey crea una clave de API y devuelve su valor en claro una única vez
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if userID == "" {
+		http.Error(w, "No autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.APIKeyRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la clave", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "El nombre es requerido", http.StatusBadRequest)
+		return
+	}
+
+	ownerType := req.OwnerType
+	if ownerType == "" {
+		ownerType = middleware.APIKeyOwnerUser
+	}
+	if ownerType != middleware.APIKeyOwnerUser && ownerType != middleware.APIKeyOwnerOrg {
+		http.Error(w, "ownerType debe ser 'user' u 'org'", http.StatusBadRequest)
+		return
+	}
+	if ownerType == middleware.APIKeyOwnerOrg && !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden crear claves de organización", http.StatusForbidden)
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		http.Error(w, "Se requiere al menos un scope", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !middleware.HasScope(middleware.APIKeyScopes, scope) {
+			http.Error(w, fmt.Sprintf("Scope inválido: %s", scope), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.RateLimit < 0 {
+		http.Error(w, "rateLimit no puede ser negativo", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, "expiresAt debe ser una fecha futura", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := requestStore(h.Store, r).GetUser(userID); err != nil {
+		http.Error(w, "Usuario no encontrado", http.StatusBadRequest)
+		return
+	}
+
+	plainKey, prefix, err := utils.GenerateAPIKey(utils.APIKeyPrefix)
+	if err != nil {
+		http.Error(w, "Error al generar la clave", http.StatusInternalServerError)
+		return
+	}
+
+	key := models.APIKey{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		OwnerType: ownerType,
+		UserID:    userID,
+		KeyPrefix: prefix,
+		KeyHash:   utils.HashAPIKey(plainKey),
+		Scopes:    req.Scopes,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := requestStore(h.Store, r).CreateAPIKey(key); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear clave de API: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	key.KeyHash = ""
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":    plainKey,
+		"apiKey": key,
+	})
+}
+
+// RevokeAPIKey revoca una clave de API (su propietario o un administrador)
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	// Formato de URL: /api/api-keys/:id
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[3] == "" {
+		http.Error(w, "ID de clave inválido", http.StatusBadRequest)
+		return
+	}
+	keyID := parts[3]
+
+	keys, err := requestStore(h.Store, r).GetAPIKeys()
+	if err != nil {
+		http.Error(w, "Error al obtener claves de API", http.StatusInternalServerError)
+		return
+	}
+
+	var found *models.APIKey
+	for i := range keys {
+		if keys[i].ID == keyID {
+			found = &keys[i]
+			break
+		}
+	}
+	if found == nil {
+		http.Error(w, "Clave de API no encontrada", http.StatusNotFound)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if found.UserID != userID && !isAdmin(r) {
+		http.Error(w, "No tienes permiso para revocar esta clave", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).RevokeAPIKey(keyID); err != nil {
+		http.Error(w, "Clave de API no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
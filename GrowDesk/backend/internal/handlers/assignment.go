@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/assignment"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/businesshours"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// AssignmentHandler contiene manejadores para la asignación automática de tickets
+type AssignmentHandler struct {
+	Store data.DataStore
+}
+
+// GetAssignmentPolicies lista las políticas de asignación
+func (h *AssignmentHandler) GetAssignmentPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar políticas de asignación", http.StatusForbidden)
+		return
+	}
+
+	policies, err := requestStore(h.Store, r).GetAssignmentPolicies()
+	if err != nil {
+		http.Error(w, "Error al obtener políticas de asignación", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, policies)
+}
+
+// CreateAssignmentPolicy crea una política de asignación
+func (h *AssignmentHandler) CreateAssignmentPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar políticas de asignación", http.StatusForbidden)
+		return
+	}
+
+	var policy models.AssignmentPolicy
+	if err := utils.DecodeJSON(r, &policy); err != nil {
+		http.Error(w, "Error al leer datos de la política", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validatePolicy(policy, ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy.ID = uuid.New().String()
+	policy.LastAssignedTo = ""
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateAssignmentPolicy(policy); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear política de asignación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, policy)
+}
+
+// UpdateAssignmentPolicy reemplaza una política de asignación
+func (h *AssignmentHandler) UpdateAssignmentPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar políticas de asignación", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetAssignmentPolicy(pathID(r))
+	if err != nil {
+		http.Error(w, "Política de asignación no encontrada", http.StatusNotFound)
+		return
+	}
+
+	var policy models.AssignmentPolicy
+	if err := utils.DecodeJSON(r, &policy); err != nil {
+		http.Error(w, "Error al leer datos de la política", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validatePolicy(policy, existing.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy.ID = existing.ID
+	policy.LastAssignedTo = existing.LastAssignedTo
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateAssignmentPolicy(policy); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar política de asignación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, policy)
+}
+
+// DeleteAssignmentPolicy elimina una política de asignación
+func (h *AssignmentHandler) DeleteAssignmentPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar políticas de asignación", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteAssignmentPolicy(pathID(r)); err != nil {
+		http.Error(w, "Política de asignación no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateAgentSettings actualiza habilidades, carga máxima, disponibilidad y horario de un
+// agente. El propio agente puede cambiar su disponibilidad y su horario; el resto sólo un
+// administrador.
+// Si el agente pasa a offline o ausente se reparten sus tickets abiertos.
+func (h *AssignmentHandler) UpdateAgentSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	// Formato de URL: /api/users/:id/assignment
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[3] == "" {
+		http.Error(w, "URL de usuario inválida", http.StatusBadRequest)
+		return
+	}
+	agentID := parts[3]
+
+	var req models.AgentSettingsRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos del agente", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !isAdmin(r) && (userID != agentID || req.Skills != nil || req.MaxOpenTickets != nil) {
+		http.Error(w, "No tienes permiso para modificar la configuración de este agente", http.StatusForbidden)
+		return
+	}
+
+	switch req.Availability {
+	case "", models.AvailabilityAvailable, models.AvailabilityBusy, models.AvailabilityAway, models.AvailabilityOffline:
+	default:
+		http.Error(w, "Disponibilidad inválida", http.StatusBadRequest)
+		return
+	}
+	if req.MaxOpenTickets != nil && *req.MaxOpenTickets < 0 {
+		http.Error(w, "maxOpenTickets no puede ser negativo", http.StatusBadRequest)
+		return
+	}
+	if req.Schedule != nil {
+		if err := businesshours.ValidateSchedule(*req.Schedule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	agent, err := requestStore(h.Store, r).GetUser(agentID)
+	if err != nil {
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	previous := agent.Availability
+	if req.Skills != nil {
+		agent.Skills = *req.Skills
+	}
+	if req.MaxOpenTickets != nil {
+		agent.MaxOpenTickets = *req.MaxOpenTickets
+	}
+	if req.Availability != "" {
+		agent.Availability = req.Availability
+	}
+	if req.Schedule != nil {
+		agent.Schedule = req.Schedule
+	} else if req.ClearSchedule {
+		agent.Schedule = nil
+	}
+
+	if err := requestStore(h.Store, r).UpdateUser(*agent); err != nil {
+		http.Error(w, "Error al actualizar usuario", http.StatusInternalServerError)
+		return
+	}
+
+	agent.Password = ""
+	response := map[string]interface{}{"user": agent}
+	if previous != agent.Availability && !acceptsTickets(agent.Availability) {
+		decisions, err := assignment.ReassignFrom(requestStore(h.Store, r), agent.ID)
+		if err != nil {
+			logging.Warnf("⚠️ Error al reasignar tickets del agente %s: %v", agent.ID, err)
+		}
+		response["reassigned"] = decisions
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// validatePolicy comprueba la estrategia, los agentes y que no haya otra política para
+// el mismo equipo o departamento
+func (h *AssignmentHandler) validatePolicy(policy models.AssignmentPolicy, id string) error {
+	if strings.TrimSpace(policy.Name) == "" {
+		return fmt.Errorf("el nombre de la política es obligatorio")
+	}
+	switch policy.Strategy {
+	case models.AssignRoundRobin, models.AssignLeastOpen, models.AssignSkills:
+	default:
+		return fmt.Errorf("estrategia inválida %q (round_robin, least_open o skills)", policy.Strategy)
+	}
+	if policy.MaxOpenTickets < 0 {
+		return fmt.Errorf("maxOpenTickets no puede ser negativo")
+	}
+	for _, agentID := range policy.AgentIDs {
+		if _, err := h.Store.GetUser(agentID); err != nil {
+			return fmt.Errorf("el usuario %s no existe", agentID)
+		}
+	}
+
+	policies, err := h.Store.GetAssignmentPolicies()
+	if err != nil {
+		return fmt.Errorf("error al obtener políticas de asignación")
+	}
+	for _, other := range policies {
+		if other.ID != id && other.TeamID == policy.TeamID && strings.EqualFold(other.Department, policy.Department) {
+			return fmt.Errorf("ya existe la política %q para ese equipo o departamento", other.Name)
+		}
+	}
+	return nil
+}
+
+// autoAssignTicket aplica la política de asignación a un ticket nuevo sin agente
+func autoAssignTicket(store data.DataStore, ticket *models.Ticket) {
+	if _, err := assignment.AutoAssign(store, ticket); err != nil {
+		logging.Warnf("⚠️ Error en la asignación automática del ticket %s: %v", ticket.ID, err)
+	}
+}
+
+// acceptsTickets indica si con esa disponibilidad el agente conserva sus tickets
+func acceptsTickets(availability string) bool {
+	return availability == models.AvailabilityAvailable || availability == models.AvailabilityBusy
+}
+
+// pathID extrae el ID de rutas con la forma /api/<recurso>/:id
+func pathID(r *http.Request) string {
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	return parts[3]
+}
+
+// requestStore devuelve el almacén asociado a la solicitud, para que sus consultas SQL
+// aparezcan en la traza de r
+func requestStore(store data.DataStore, r *http.Request) data.DataStore {
+	return data.WithContext(store, r.Context())
+}
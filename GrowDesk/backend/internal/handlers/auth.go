@@ -10,6 +10,7 @@ import (
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
 )
 
 // AuthHandler contiene manejadores para autenticación
@@ -38,44 +39,37 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// DEBUG: Log del intento de login
-	fmt.Printf("🔐 LOGIN ATTEMPT: Email=%s, Password=%s\n", loginReq.Email, loginReq.Password)
+	// Nunca registrar la contraseña; el email se enmascara al escribir el log
+	logging.Debugf("🔐 LOGIN ATTEMPT: Email=%s", loginReq.Email)
 
 	// Buscar usuario en la base de datos por email
-	fmt.Printf("🔍 SEARCHING USER: Buscando usuario con email %s...\n", loginReq.Email)
-	user, err := h.Store.GetUserByEmail(loginReq.Email)
+	user, err := requestStore(h.Store, r).GetUserByEmail(loginReq.Email)
 	if err != nil {
-		fmt.Printf("❌ USER NOT FOUND: Error al buscar usuario: %v\n", err)
+		logging.Warnf("❌ USER NOT FOUND: Error al buscar usuario: %v", err)
 		http.Error(w, "Credenciales inválidas", http.StatusUnauthorized)
 		return
 	}
 
-	fmt.Printf("✅ USER FOUND: ID=%s, Email=%s, Password=%s, Active=%t\n",
-		user.ID, user.Email, user.Password, user.Active)
-
 	// Verificar la contraseña (en un sistema real usaríamos hash, pero para desarrollo comparamos directamente)
-	fmt.Printf("🔑 PASSWORD CHECK: Esperado='%s', Recibido='%s', Match=%t\n",
-		user.Password, loginReq.Password, user.Password == loginReq.Password)
-
 	if user.Password != loginReq.Password {
-		fmt.Printf("❌ PASSWORD MISMATCH: Contraseña incorrecta\n")
+		logging.Warnf("❌ PASSWORD MISMATCH: Contraseña incorrecta para el usuario %s", user.ID)
 		http.Error(w, "Credenciales inválidas", http.StatusUnauthorized)
 		return
 	}
 
 	// Verificar que el usuario esté activo
 	if !user.Active {
-		fmt.Printf("❌ USER INACTIVE: Usuario no está activo\n")
+		logging.Warnf("❌ USER INACTIVE: Usuario %s no está activo", user.ID)
 		http.Error(w, "Usuario desactivado", http.StatusUnauthorized)
 		return
 	}
 
-	fmt.Printf("🎯 LOGIN SUCCESS: Generando token para usuario %s\n", user.ID)
+	logging.Infof("🎯 LOGIN SUCCESS: Generando token para usuario %s", user.ID)
 
 	// Generar token real con la información del usuario
 	token, err := utils.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
-		fmt.Printf("⚠️ TOKEN GENERATION FAILED: %v, usando mock token\n", err)
+		logging.Warnf("⚠️ TOKEN GENERATION FAILED: %v, usando mock token", err)
 		// Fallback a mock token si hay problemas
 		token = utils.GenerateMockToken()
 	}
@@ -94,7 +88,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	fmt.Printf("✅ LOGIN COMPLETE: Devolviendo respuesta para usuario %s\n", user.ID)
+	logging.Infof("✅ LOGIN COMPLETE: Devolviendo respuesta para usuario %s", user.ID)
 
 	// Devolver token y información de usuario real
 	w.Header().Set("Content-Type", "application/json")
@@ -109,31 +103,31 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("📝 REGISTER: Iniciando registro de nuevo usuario\n")
+	logging.Debugf("📝 REGISTER: Iniciando registro de nuevo usuario")
 
 	// Parsear el cuerpo de la solicitud
 	var registerReq models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&registerReq); err != nil {
-		fmt.Printf("❌ REGISTER ERROR: Error al decodificar cuerpo: %v\n", err)
+		logging.Errorf("❌ REGISTER ERROR: Error al decodificar cuerpo: %v", err)
 		http.Error(w, "El cuerpo de la solicitud es inválido", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("📝 REGISTER DATA: Email=%s, FirstName=%s, LastName=%s\n",
+	logging.Debugf("📝 REGISTER DATA: Email=%s, FirstName=%s, LastName=%s",
 		registerReq.Email, registerReq.FirstName, registerReq.LastName)
 
 	// Validar campos requeridos
 	if registerReq.Email == "" || registerReq.Password == "" ||
 		registerReq.FirstName == "" || registerReq.LastName == "" {
-		fmt.Printf("❌ REGISTER ERROR: Campos incompletos\n")
+		logging.Errorf("❌ REGISTER ERROR: Campos incompletos")
 		http.Error(w, "Todos los campos son requeridos", http.StatusBadRequest)
 		return
 	}
 
 	// Verificar si el usuario ya existe
-	_, err := h.Store.GetUserByEmail(registerReq.Email)
+	_, err := requestStore(h.Store, r).GetUserByEmail(registerReq.Email)
 	if err == nil {
-		fmt.Printf("❌ REGISTER ERROR: El email %s ya está registrado\n", registerReq.Email)
+		logging.Errorf("❌ REGISTER ERROR: El email %s ya está registrado", registerReq.Email)
 		http.Error(w, "El correo ya está registrado", http.StatusConflict)
 		return
 	}
@@ -141,7 +135,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Generar ID único para el usuario - USAR MISMO FORMATO QUE LA SECCIÓN DE ADMIN
 	// Cambio: usar formato numérico para el ID como en la sección de administración
 	userID := fmt.Sprintf("%d", time.Now().UnixNano())
-	fmt.Printf("📝 REGISTER: ID generado para nuevo usuario: %s\n", userID)
+	logging.Debugf("📝 REGISTER: ID generado para nuevo usuario: %s", userID)
 
 	// Crear objeto de usuario
 	newUser := models.User{
@@ -156,29 +150,29 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now(),
 	}
 
-	fmt.Printf("📝 REGISTER: Intentando guardar nuevo usuario en la base de datos...\n")
+	logging.Debugf("📝 REGISTER: Intentando guardar nuevo usuario en la base de datos...")
 	// Guardar usuario en la base de datos
-	if err := h.Store.CreateUser(newUser); err != nil {
-		fmt.Printf("❌ REGISTER ERROR: Error al crear usuario en BD: %v\n", err)
+	if err := requestStore(h.Store, r).CreateUser(newUser); err != nil {
+		logging.Errorf("❌ REGISTER ERROR: Error al crear usuario en BD: %v", err)
 		http.Error(w, "Error al crear usuario: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	fmt.Printf("✅ REGISTER SUCCESS: Usuario %s (%s) guardado correctamente\n",
+	logging.Infof("✅ REGISTER SUCCESS: Usuario %s (%s) guardado correctamente",
 		userID, registerReq.Email)
 
 	// Para diagnóstico: Verificar inmediatamente si el usuario se puede recuperar
-	savedUser, err := h.Store.GetUserByEmail(registerReq.Email)
+	savedUser, err := requestStore(h.Store, r).GetUserByEmail(registerReq.Email)
 	if err != nil {
-		fmt.Printf("⚠️ REGISTER WARNING: El usuario se guardó pero no se puede recuperar: %v\n", err)
+		logging.Warnf("⚠️ REGISTER WARNING: El usuario se guardó pero no se puede recuperar: %v", err)
 	} else {
-		fmt.Printf("✅ REGISTER VERIFICATION: Usuario verificado en BD: ID=%s, Email=%s\n",
+		logging.Debugf("✅ REGISTER VERIFICATION: Usuario verificado en BD: ID=%s, Email=%s",
 			savedUser.ID, savedUser.Email)
 	}
 
 	// Generar token JWT con la información del usuario
 	token, err := utils.GenerateToken(newUser.ID, newUser.Email, newUser.Role)
 	if err != nil {
-		fmt.Printf("⚠️ REGISTER WARNING: Error al generar token JWT: %v\n", err)
+		logging.Warnf("⚠️ REGISTER WARNING: Error al generar token JWT: %v", err)
 		// Fallback a mock token si hay problemas
 		token = utils.GenerateMockToken()
 	}
@@ -189,7 +183,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		User:  newUser,
 	}
 
-	fmt.Printf("✅ REGISTER COMPLETE: Devolviendo respuesta para usuario %s\n", newUser.ID)
+	logging.Infof("✅ REGISTER COMPLETE: Devolviendo respuesta para usuario %s", newUser.ID)
 
 	// Devolver token y información de usuario
 	w.Header().Set("Content-Type", "application/json")
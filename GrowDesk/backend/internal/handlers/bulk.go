@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/bulk"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// BulkTicketHandler contiene manejadores para las operaciones masivas sobre tickets
+type BulkTicketHandler struct {
+	Store  data.DataStore
+	Runner *bulk.Runner
+}
+
+// canRunBulk indica si quien hace la solicitud puede usar las operaciones masivas. Son
+// de los agentes: las claves de servicio (como la de widget-api) y las de organización
+// ven todos los tickets, así que no pueden cerrarlos o reasignarlos en bloque.
+func canRunBulk(r *http.Request) bool {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	return isAgent(r) && userID != "" && !middleware.IsServicePrincipal(r) && !middleware.IsOrgAPIKey(r)
+}
+
+// StartBulkOperation selecciona los tickets (por ID o con un filtro) y lanza la operación
+// en segundo plano. Responde 202 con la operación para consultar su progreso.
+func (h *BulkTicketHandler) StartBulkOperation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !canRunBulk(r) {
+		http.Error(w, "No tienes permiso para modificar tickets en bloque", http.StatusForbidden)
+		return
+	}
+
+	var req models.BulkTicketRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la operación", http.StatusBadRequest)
+		return
+	}
+	if (len(req.TicketIDs) == 0) == (strings.TrimSpace(req.Filter) == "") {
+		http.Error(w, "Indica los tickets con ticketIds o con filter, pero no ambos", http.StatusBadRequest)
+		return
+	}
+	if req.Operations.Delete && !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden eliminar tickets", http.StatusForbidden)
+		return
+	}
+
+	ops, err := bulk.Validate(requestStore(h.Store, r), req.Operations)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+
+	var selected []string
+	var rejected []models.BulkTicketError
+	if len(req.TicketIDs) > 0 {
+		seen := make(map[string]bool, len(req.TicketIDs))
+		for _, id := range req.TicketIDs {
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ticket, err := requestStore(h.Store, r).GetTicket(id)
+			if err != nil {
+				rejected = append(rejected, models.BulkTicketError{TicketID: id, Error: "Ticket no encontrado"})
+				continue
+			}
+			if !scope.allows(*ticket) {
+				rejected = append(rejected, models.BulkTicketError{TicketID: id, Error: "No tienes acceso a este ticket"})
+				continue
+			}
+			selected = append(selected, id)
+		}
+	} else {
+		query, err := url.ParseQuery(strings.TrimPrefix(req.Filter, "?"))
+		if err != nil {
+			http.Error(w, "Filtro inválido", http.StatusBadRequest)
+			return
+		}
+		fieldDefs, err := requestStore(h.Store, r).GetCustomFields()
+		if err != nil {
+			http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+			return
+		}
+		filter, err := parseTicketFilter(fieldDefs, query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tickets, err := requestStore(h.Store, r).GetTickets()
+		if err != nil {
+			http.Error(w, "Error al obtener tickets", http.StatusInternalServerError)
+			return
+		}
+		for _, ticket := range tickets {
+			if scope.allows(ticket) && filter.matches(ticket) {
+				selected = append(selected, ticket.ID)
+			}
+		}
+	}
+
+	if len(selected)+len(rejected) > bulk.MaxTickets {
+		http.Error(w, fmt.Sprintf("No se pueden procesar más de %d tickets a la vez", bulk.MaxTickets), http.StatusBadRequest)
+		return
+	}
+	if len(selected) == 0 && len(rejected) == 0 {
+		http.Error(w, "El filtro no selecciona ningún ticket", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	job := h.Runner.Start(selected, rejected, ops, userID)
+
+	logging.Infof("📦 Operación masiva %s lanzada sobre %d tickets", job.ID, job.Total)
+	utils.WriteJSON(w, http.StatusAccepted, job)
+}
+
+// GetBulkOperations lista las operaciones masivas del usuario (todas para administradores)
+func (h *BulkTicketHandler) GetBulkOperations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !canRunBulk(r) {
+		http.Error(w, "No tienes permiso para ver las operaciones masivas", http.StatusForbidden)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if isAdmin(r) {
+		userID = ""
+	}
+
+	utils.WriteJSON(w, http.StatusOK, h.Runner.List(userID))
+}
+
+// GetBulkOperation devuelve el progreso y los errores de una operación masiva
+func (h *BulkTicketHandler) GetBulkOperation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !canRunBulk(r) {
+		http.Error(w, "No tienes permiso para ver las operaciones masivas", http.StatusForbidden)
+		return
+	}
+
+	// Formato de URL: /api/tickets/bulk/:jobId
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	job, ok := h.Runner.Get(parts[len(parts)-1])
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok || (!isAdmin(r) && job.CreatedBy != userID) {
+		http.Error(w, "Operación no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, job)
+}
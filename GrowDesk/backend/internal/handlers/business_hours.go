@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/businesshours"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// maxICSSize limita el tamaño de los calendarios .ics importados
+const maxICSSize = 1 << 20
+
+// BusinessHoursHandler contiene manejadores para los calendarios de atención
+type BusinessHoursHandler struct {
+	Store data.DataStore
+}
+
+// GetBusinessCalendars lista los calendarios de atención
+func (h *BusinessHoursHandler) GetBusinessCalendars(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	calendars, err := requestStore(h.Store, r).GetBusinessCalendars()
+	if err != nil {
+		http.Error(w, "Error al obtener calendarios", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, calendars)
+}
+
+// GetBusinessCalendar devuelve un calendario de atención
+func (h *BusinessHoursHandler) GetBusinessCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	calendar, err := requestStore(h.Store, r).GetBusinessCalendar(pathID(r))
+	if err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, calendar)
+}
+
+// CreateBusinessCalendar crea un calendario de atención (sólo administradores)
+func (h *BusinessHoursHandler) CreateBusinessCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	var calendar models.BusinessCalendar
+	if err := utils.DecodeJSON(r, &calendar); err != nil {
+		http.Error(w, "Error al leer datos del calendario", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateCalendar(&calendar, ""); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	calendar.ID = uuid.New().String()
+	calendar.CreatedAt = time.Now()
+	calendar.UpdatedAt = calendar.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateBusinessCalendar(calendar); err != nil {
+		http.Error(w, "Error al crear calendario", http.StatusInternalServerError)
+		return
+	}
+	h.keepSingleDefault(calendar)
+
+	utils.WriteJSON(w, http.StatusCreated, calendar)
+}
+
+// UpdateBusinessCalendar reemplaza un calendario de atención (sólo administradores)
+func (h *BusinessHoursHandler) UpdateBusinessCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetBusinessCalendar(pathID(r))
+	if err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	var calendar models.BusinessCalendar
+	if err := utils.DecodeJSON(r, &calendar); err != nil {
+		http.Error(w, "Error al leer datos del calendario", http.StatusBadRequest)
+		return
+	}
+	if calendar.Holidays == nil {
+		calendar.Holidays = existing.Holidays
+	}
+
+	if err := h.validateCalendar(&calendar, existing.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	calendar.ID = existing.ID
+	calendar.CreatedAt = existing.CreatedAt
+	calendar.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateBusinessCalendar(calendar); err != nil {
+		http.Error(w, "Error al actualizar calendario", http.StatusInternalServerError)
+		return
+	}
+	h.keepSingleDefault(calendar)
+
+	utils.WriteJSON(w, http.StatusOK, calendar)
+}
+
+// DeleteBusinessCalendar elimina un calendario; sus equipos pasan al calendario por defecto
+func (h *BusinessHoursHandler) DeleteBusinessCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteBusinessCalendar(pathID(r)); err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportHolidays añade al calendario los festivos de un archivo iCalendar (.ics) enviado
+// como cuerpo de la solicitud. Con ?replace=true sustituye los festivos existentes.
+func (h *BusinessHoursHandler) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar los horarios de atención", http.StatusForbidden)
+		return
+	}
+
+	calendar, err := requestStore(h.Store, r).GetBusinessCalendar(pathID(r))
+	if err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+	c, err := businesshours.New(*calendar)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	holidays, err := businesshours.ParseICS(io.LimitReader(r.Body, maxICSSize), c.Location())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Calendario iCalendar inválido: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("replace") == "true" {
+		calendar.Holidays = holidays
+	} else {
+		calendar.Holidays = businesshours.MergeHolidays(calendar.Holidays, holidays)
+	}
+
+	if err := requestStore(h.Store, r).UpdateBusinessCalendar(*calendar); err != nil {
+		http.Error(w, "Error al actualizar calendario", http.StatusInternalServerError)
+		return
+	}
+
+	logging.Infof("📅 %d festivos importados en el calendario %s", len(holidays), calendar.Name)
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"imported": len(holidays),
+		"calendar": calendar,
+	})
+}
+
+// GetBusinessHoursStatus indica si el calendario está abierto (?at=, RFC 3339; por
+// defecto ahora) y cuándo vuelve a abrir
+func (h *BusinessHoursHandler) GetBusinessHoursStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	calendar, err := requestStore(h.Store, r).GetBusinessCalendar(pathID(r))
+	if err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Parámetro at inválido (RFC 3339)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	status, err := businesshours.Status(*calendar, at)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, status)
+}
+
+// GetBusinessTime devuelve el tiempo hábil entre ?from= y ?to= (RFC 3339)
+func (h *BusinessHoursHandler) GetBusinessTime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	calendar, err := requestStore(h.Store, r).GetBusinessCalendar(pathID(r))
+	if err != nil {
+		http.Error(w, "Calendario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	from, errFrom := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	to, errTo := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "Los parámetros from y to son obligatorios (RFC 3339)", http.StatusBadRequest)
+		return
+	}
+
+	c, err := businesshours.New(*calendar)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	duration := c.Between(from, to)
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"calendarId": calendar.ID,
+		"from":       from,
+		"to":         to,
+		"seconds":    int64(duration / time.Second),
+		"duration":   duration.String(),
+	})
+}
+
+// validateCalendar comprueba el nombre, los turnos y festivos, y que ningún widget
+// tenga ya otro calendario
+func (h *BusinessHoursHandler) validateCalendar(calendar *models.BusinessCalendar, id string) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if calendar.Name == "" {
+		return fmt.Errorf("el nombre del calendario es obligatorio")
+	}
+	if calendar.Hours == nil {
+		calendar.Hours = make([]models.WorkingShift, 0)
+	}
+	if calendar.Holidays == nil {
+		calendar.Holidays = make([]models.Holiday, 0)
+	}
+	if err := businesshours.Validate(*calendar); err != nil {
+		return err
+	}
+
+	calendars, err := h.Store.GetBusinessCalendars()
+	if err != nil {
+		return fmt.Errorf("error al obtener calendarios")
+	}
+	for _, other := range calendars {
+		if other.ID == id {
+			continue
+		}
+		for _, widgetID := range calendar.WidgetIDs {
+			for _, otherWidget := range other.WidgetIDs {
+				if widgetID == otherWidget {
+					return fmt.Errorf("el widget %s ya usa el calendario %q", widgetID, other.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keepSingleDefault desmarca el resto de calendarios cuando uno pasa a ser el de por defecto
+func (h *BusinessHoursHandler) keepSingleDefault(calendar models.BusinessCalendar) {
+	if !calendar.IsDefault {
+		return
+	}
+	calendars, err := h.Store.GetBusinessCalendars()
+	if err != nil {
+		return
+	}
+	for _, other := range calendars {
+		if other.ID != calendar.ID && other.IsDefault {
+			other.IsDefault = false
+			if err := h.Store.UpdateBusinessCalendar(other); err != nil {
+				logging.Warnf("⚠️ Error al desmarcar el calendario por defecto %s: %v", other.ID, err)
+			}
+		}
+	}
+}
+
+// outOfHoursReply añade una respuesta automática al ticket nuevo si llega fuera del
+// horario de atención, indicando cuándo se volverá a atender
+func outOfHoursReply(store data.DataStore, ticket *models.Ticket) {
+	calendar, err := businesshours.Resolve(store, ticket.TeamID, ticket.WidgetID)
+	if err != nil || calendar == nil {
+		return
+	}
+	status, err := businesshours.Status(*calendar, ticket.CreatedAt)
+	if err != nil || status.Open {
+		return
+	}
+
+	content := "Hemos recibido tu mensaje fuera de nuestro horario de atención. Te responderemos lo antes posible."
+	if status.NextOpenAt != nil {
+		c, _ := businesshours.New(*calendar)
+		next := status.NextOpenAt.In(c.Location())
+		content = fmt.Sprintf("Hemos recibido tu mensaje fuera de nuestro horario de atención. Te responderemos a partir del %s a las %s (%s).",
+			next.Format("02/01/2006"), next.Format("15:04"), next.Location())
+	}
+
+	now := time.Now()
+	ticket.Messages = append(ticket.Messages, models.Message{
+		ID:        fmt.Sprintf("MSG-%s-auto", now.Format("20060102150405.000")),
+		Content:   content,
+		IsClient:  false,
+		Timestamp: now,
+		CreatedAt: now,
+		UserName:  "GrowDesk",
+	})
+}
@@ -29,7 +29,7 @@ func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Reques
 	utils.SetCORS(w)
 
 	// Obtener categorías del almacén
-	categories, err := h.Store.GetCategories()
+	categories, err := requestStore(h.Store, r).GetCategories()
 	if err != nil {
 		http.Error(w, "Error al obtener categorías", http.StatusInternalServerError)
 		return
@@ -61,7 +61,7 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	categoryID := segments[3]
 
 	// Obtener categoría del almacén
-	category, err := h.Store.GetCategory(categoryID)
+	category, err := requestStore(h.Store, r).GetCategory(categoryID)
 	if err != nil {
 		http.Error(w, "Categoría no encontrada", http.StatusNotFound)
 		return
@@ -118,7 +118,7 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	category.Active = true
 
 	// Guardar en el almacén
-	if err := h.Store.CreateCategory(category); err != nil {
+	if err := requestStore(h.Store, r).CreateCategory(category); err != nil {
 		http.Error(w, "Error al crear categoría", http.StatusInternalServerError)
 		return
 	}
@@ -156,7 +156,7 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	categoryID := segments[3]
 
 	// Obtener categoría existente
-	existingCategory, err := h.Store.GetCategory(categoryID)
+	existingCategory, err := requestStore(h.Store, r).GetCategory(categoryID)
 	if err != nil {
 		http.Error(w, "Categoría no encontrada", http.StatusNotFound)
 		return
@@ -190,7 +190,7 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	existingCategory.UpdatedAt = time.Now()
 
 	// Guardar en el almacén
-	if err := h.Store.UpdateCategory(*existingCategory); err != nil {
+	if err := requestStore(h.Store, r).UpdateCategory(*existingCategory); err != nil {
 		http.Error(w, "Error al actualizar categoría", http.StatusInternalServerError)
 		return
 	}
@@ -228,7 +228,7 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 	categoryID := segments[3]
 
 	// Eliminar la categoría
-	if err := h.Store.DeleteCategory(categoryID); err != nil {
+	if err := requestStore(h.Store, r).DeleteCategory(categoryID); err != nil {
 		http.Error(w, "Error al eliminar categoría", http.StatusInternalServerError)
 		return
 	}
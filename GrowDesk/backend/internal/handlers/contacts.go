@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/customfields"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// ContactHandler contiene manejadores para los contactos (clientes) y sus campos personalizados
+type ContactHandler struct {
+	Store data.DataStore
+}
+
+// contactUpdateRequest es el cuerpo de PUT /api/contacts/:email. Un valor null en
+// customFields borra ese campo.
+type contactUpdateRequest struct {
+	Name         *string                `json:"name,omitempty"`
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+}
+
+// GetContacts lista los contactos
+func (h *ContactHandler) GetContacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los contactos", http.StatusForbidden)
+		return
+	}
+
+	contacts, err := requestStore(h.Store, r).GetContacts()
+	if err != nil {
+		http.Error(w, "Error al obtener contactos", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, contacts)
+}
+
+// GetContact devuelve un contacto por su email
+func (h *ContactHandler) GetContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los contactos", http.StatusForbidden)
+		return
+	}
+
+	contact, err := requestStore(h.Store, r).GetContact(contactEmail(r))
+	if err != nil {
+		http.Error(w, "Contacto no encontrado", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, contact)
+}
+
+// UpdateContact cambia el nombre y los campos personalizados de un contacto; si no
+// existe lo crea
+func (h *ContactHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para modificar contactos", http.StatusForbidden)
+		return
+	}
+
+	email := contactEmail(r)
+	if email == "" {
+		http.Error(w, "Email de contacto inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req contactUpdateRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos del contacto", http.StatusBadRequest)
+		return
+	}
+
+	contact := models.Contact{Email: email, CreatedAt: time.Now()}
+	if existing, err := requestStore(h.Store, r).GetContact(email); err == nil {
+		contact = *existing
+	}
+	if req.Name != nil {
+		contact.Name = *req.Name
+	}
+	if req.CustomFields != nil {
+		fieldDefs, err := requestStore(h.Store, r).GetCustomFields()
+		if err != nil {
+			http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+			return
+		}
+		changes, err := customfields.Normalize(fieldDefs, models.CustomFieldEntityContact, req.CustomFields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contact.CustomFields = customfields.Merge(contact.CustomFields, changes)
+	}
+	contact.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).SaveContact(contact); err != nil {
+		http.Error(w, "Error al guardar contacto", http.StatusInternalServerError)
+		return
+	}
+
+	saved, err := requestStore(h.Store, r).GetContact(email)
+	if err != nil {
+		saved = &contact
+	}
+	utils.WriteJSON(w, http.StatusOK, saved)
+}
+
+// contactEmail extrae el email de /api/contacts/:email
+func contactEmail(r *http.Request) string {
+	email, err := url.PathUnescape(pathID(r))
+	if err != nil {
+		return ""
+	}
+	return email
+}
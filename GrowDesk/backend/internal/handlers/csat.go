@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/csat"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// CSATHandler maneja las encuestas de satisfacción
+type CSATHandler struct {
+	Store data.DataStore
+}
+
+// csatPage es la página que ve el cliente al pulsar el enlace del correo
+var csatPage = template.Must(template.New("csat").Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Encuesta de satisfacción</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 3rem auto; padding: 0 1rem; color: #1f2937; }
+.stars { font-size: 2rem; color: #f59e0b; }
+textarea { width: 100%; min-height: 6rem; }
+button { margin-top: .75rem; padding: .5rem 1rem; }
+</style>
+</head>
+<body>
+{{if .Error}}
+<h1>No se pudo guardar tu valoración</h1>
+<p>{{.Error}}</p>
+{{else}}
+<h1>¡Gracias por tu valoración!</h1>
+<p class="stars">{{.Stars}}</p>
+{{if .Commented}}
+<p>Hemos recibido tu comentario.</p>
+{{else}}
+<form method="post" action="/csat/{{.SurveyID}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<input type="hidden" name="rating" value="{{.Rating}}">
+<label for="comment">¿Quieres contarnos algo más?</label>
+<textarea id="comment" name="comment" maxlength="2000"></textarea>
+<button type="submit">Enviar comentario</button>
+</form>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+type csatPageData struct {
+	SurveyID  string
+	Token     string
+	Rating    int
+	Stars     string
+	Commented bool
+	Error     string
+}
+
+// RespondSurvey maneja /csat/:id, la respuesta pública a una encuesta. GET es el enlace
+// de un clic del correo (?rating=&token=) y devuelve una página para añadir un
+// comentario; POST acepta JSON (widget-api) o el formulario de esa página.
+func (h *CSATHandler) RespondSurvey(w http.ResponseWriter, r *http.Request) {
+	if utils.HandleCORS(w, r) {
+		return
+	}
+
+	var req models.CSATResponseRequest
+	html := true
+	switch r.Method {
+	case http.MethodGet:
+		req.Token = r.URL.Query().Get("token")
+		req.Rating, _ = strconv.Atoi(r.URL.Query().Get("rating"))
+		req.Channel = csat.ChannelEmail
+	case http.MethodPost:
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			html = false
+			if err := utils.DecodeJSON(r, &req); err != nil {
+				http.Error(w, "Error al leer la respuesta", http.StatusBadRequest)
+				return
+			}
+		} else {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Error al leer la respuesta", http.StatusBadRequest)
+				return
+			}
+			req.Token = r.PostForm.Get("token")
+			req.Rating, _ = strconv.Atoi(r.PostForm.Get("rating"))
+			req.Comment = r.PostForm.Get("comment")
+			req.Channel = csat.ChannelWeb
+		}
+	default:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	surveyID := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/csat/")
+	fail := func(status int, message string) {
+		if !html {
+			http.Error(w, message, status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		csatPage.Execute(w, csatPageData{Error: message})
+	}
+
+	survey, err := requestStore(h.Store, r).GetCSATSurvey(surveyID)
+	if err != nil {
+		// Mismo mensaje que un token inválido para no revelar qué encuestas existen
+		fail(http.StatusForbidden, csat.ErrInvalidToken.Error())
+		return
+	}
+	if err := csat.Respond(requestStore(h.Store, r), survey, req); err != nil {
+		switch {
+		case errors.Is(err, csat.ErrInvalidToken):
+			fail(http.StatusForbidden, err.Error())
+		case errors.Is(err, csat.ErrExpired):
+			fail(http.StatusGone, err.Error())
+		default:
+			fail(http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	logging.Infof("⭐ Ticket %s valorado con %d (%s)", survey.TicketID, survey.Rating, survey.Channel)
+
+	if !html {
+		utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"rating":  survey.Rating,
+			"comment": survey.Comment,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	csatPage.Execute(w, csatPageData{
+		SurveyID:  survey.ID,
+		Token:     req.Token,
+		Rating:    survey.Rating,
+		Stars:     strings.Repeat("★", survey.Rating) + strings.Repeat("☆", 5-survey.Rating),
+		Commented: r.Method == http.MethodPost,
+	})
+}
+
+// GetSurveys maneja GET /api/csat/surveys: las encuestas con sus valoraciones y
+// comentarios. Filtros: ticketId, agentId, teamId, categoryId, from, to y
+// responded=true|false.
+func (h *CSATHandler) GetSurveys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	surveys, ok := h.visibleSurveys(w, r)
+	if !ok {
+		return
+	}
+	if responded := r.URL.Query().Get("responded"); responded != "" {
+		want := responded == "true"
+		filtered := make([]models.CSATSurvey, 0, len(surveys))
+		for _, survey := range surveys {
+			if (survey.Rating > 0) == want {
+				filtered = append(filtered, survey)
+			}
+		}
+		surveys = filtered
+	}
+	utils.WriteJSON(w, http.StatusOK, surveys)
+}
+
+// GetReport maneja GET /api/csat/report: valoración media, porcentaje de satisfechos y
+// tasa de respuesta en total y por agente, equipo, categoría y periodo
+// (?period=day|week|month, por defecto month). Admite los filtros de GetSurveys.
+func (h *CSATHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = csat.PeriodMonth
+	}
+	if !csat.ValidPeriod(period) {
+		http.Error(w, "Periodo inválido: usa day, week o month", http.StatusBadRequest)
+		return
+	}
+
+	surveys, ok := h.visibleSurveys(w, r)
+	if !ok {
+		return
+	}
+	filter, _ := csatFilter(r)
+	report := csat.Report(requestStore(h.Store, r), surveys, period)
+	report.From, report.To = filter.From, filter.To
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+// visibleSurveys devuelve las encuestas que cumplen los filtros de la URL y que quien
+// hace la solicitud puede ver según sus equipos
+func (h *CSATHandler) visibleSurveys(w http.ResponseWriter, r *http.Request) ([]models.CSATSurvey, bool) {
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver las encuestas", http.StatusForbidden)
+		return nil, false
+	}
+	filter, err := csatFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	surveys, err := requestStore(h.Store, r).GetCSATSurveys()
+	if err != nil {
+		http.Error(w, "Error al obtener encuestas", http.StatusInternalServerError)
+		return nil, false
+	}
+	visible := make([]models.CSATSurvey, 0, len(surveys))
+	for _, survey := range surveys {
+		if filter.Matches(survey) && scope.allows(models.Ticket{AssignedTo: survey.AgentID, TeamID: survey.TeamID}) {
+			visible = append(visible, survey)
+		}
+	}
+	return visible, true
+}
+
+// csatFilter lee los filtros de las encuestas de la URL
+func csatFilter(r *http.Request) (csat.Filter, error) {
+	query := r.URL.Query()
+	filter := csat.Filter{
+		TicketID:   query.Get("ticketId"),
+		AgentID:    query.Get("agentId"),
+		TeamID:     query.Get("teamId"),
+		CategoryID: query.Get("categoryId"),
+	}
+	var err error
+	if filter.From, err = parseDateParam(query.Get("from"), false, time.Local); err != nil {
+		return filter, fmt.Errorf("fecha from inválida: %v", err)
+	}
+	if filter.To, err = parseDateParam(query.Get("to"), true, time.Local); err != nil {
+		return filter, fmt.Errorf("fecha to inválida: %v", err)
+	}
+	return filter, nil
+}
+
+// parseDateParam acepta RFC3339 o AAAA-MM-DD en la zona horaria loc. Con endOfDay, una
+// fecha sin hora incluye el día entero.
+func parseDateParam(value string, endOfDay bool, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("usa AAAA-MM-DD o RFC3339")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// onStatusChange avisa a los seguidores del cambio de estado y, si el ticket acaba de
+// resolverse o cerrarse, envía la encuesta de satisfacción
+func onStatusChange(store data.DataStore, ticket models.Ticket, previousStatus, userID string) {
+	if ticket.Status == previousStatus {
+		return
+	}
+	go watchers.Notify(store, ticket, watchers.StatusEvent(userID, previousStatus, ticket.Status))
+	if csat.Closing(previousStatus, ticket.Status) {
+		go func() {
+			if _, err := csat.Send(store, ticket); err != nil {
+				logging.Warnf("⚠️ No se pudo crear la encuesta del ticket %s: %v", ticket.ID, err)
+			}
+		}()
+	}
+}
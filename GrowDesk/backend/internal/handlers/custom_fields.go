@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/customfields"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// CustomFieldHandler contiene manejadores para los campos personalizados de tickets y contactos
+type CustomFieldHandler struct {
+	Store data.DataStore
+}
+
+// GetCustomFields lista los campos personalizados; ?entity=ticket|contact filtra por entidad
+func (h *CustomFieldHandler) GetCustomFields(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los campos personalizados", http.StatusForbidden)
+		return
+	}
+
+	fields, err := requestStore(h.Store, r).GetCustomFields()
+	if err != nil {
+		http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+		return
+	}
+
+	entity := r.URL.Query().Get("entity")
+	result := make([]models.CustomField, 0, len(fields))
+	for _, field := range fields {
+		if entity == "" || field.Entity == entity {
+			result = append(result, field)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// GetCustomField devuelve un campo personalizado
+func (h *CustomFieldHandler) GetCustomField(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los campos personalizados", http.StatusForbidden)
+		return
+	}
+
+	field, err := requestStore(h.Store, r).GetCustomField(pathID(r))
+	if err != nil {
+		http.Error(w, "Campo personalizado no encontrado", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, field)
+}
+
+// CreateCustomField crea un campo personalizado (sólo administradores). Si no se indica
+// la entidad el campo es de ticket.
+func (h *CustomFieldHandler) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar campos personalizados", http.StatusForbidden)
+		return
+	}
+
+	field := models.CustomField{Active: true}
+	if err := utils.DecodeJSON(r, &field); err != nil {
+		http.Error(w, "Error al leer datos del campo", http.StatusBadRequest)
+		return
+	}
+	field.Key = strings.TrimSpace(field.Key)
+	if field.Entity == "" {
+		field.Entity = models.CustomFieldEntityTicket
+	}
+	if err := customfields.ValidateDefinition(field); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	field.ID = uuid.New().String()
+	field.CreatedAt = time.Now()
+	field.UpdatedAt = field.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateCustomField(field); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	logging.Infof("🧩 Campo personalizado creado: %s.%s (%s)", field.Entity, field.Key, field.Type)
+	utils.WriteJSON(w, http.StatusCreated, field)
+}
+
+// UpdateCustomField modifica un campo personalizado (sólo administradores). La clave, el
+// tipo y la entidad no cambian para no invalidar los valores ya guardados.
+func (h *CustomFieldHandler) UpdateCustomField(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar campos personalizados", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetCustomField(pathID(r))
+	if err != nil {
+		http.Error(w, "Campo personalizado no encontrado", http.StatusNotFound)
+		return
+	}
+
+	field := *existing
+	if err := utils.DecodeJSON(r, &field); err != nil {
+		http.Error(w, "Error al leer datos del campo", http.StatusBadRequest)
+		return
+	}
+	if field.Key != existing.Key || field.Type != existing.Type || field.Entity != existing.Entity {
+		http.Error(w, "La clave, el tipo y la entidad de un campo no se pueden cambiar", http.StatusBadRequest)
+		return
+	}
+	if err := customfields.ValidateDefinition(field); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	field.ID = existing.ID
+	field.CreatedAt = existing.CreatedAt
+	field.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateCustomField(field); err != nil {
+		http.Error(w, "Error al actualizar campo personalizado", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, field)
+}
+
+// DeleteCustomField elimina un campo personalizado (sólo administradores). Los valores
+// guardados en tickets y contactos se conservan; para ocultarlo sin borrarlo basta con
+// desactivarlo.
+func (h *CustomFieldHandler) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar campos personalizados", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteCustomField(pathID(r)); err != nil {
+		http.Error(w, "Campo personalizado no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPreChatFields devuelve a widget-api los campos activos que se piden en el
+// formulario pre-chat, de ticket y de contacto, en su orden
+func (h *CustomFieldHandler) GetPreChatFields(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	fields, err := requestStore(h.Store, r).GetCustomFields()
+	if err != nil {
+		http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]models.CustomField, 0)
+	for _, field := range fields {
+		if field.Active && field.ShowInPreChat {
+			result = append(result, field)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, result)
+}
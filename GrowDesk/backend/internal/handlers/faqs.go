@@ -38,10 +38,10 @@ func (h *FAQHandler) GetAllFAQs(w http.ResponseWriter, r *http.Request) {
 	if published != "" {
 		// Convertir string a bool
 		isPublished := published == "true"
-		faqs, err = h.Store.GetFAQsByStatus(isPublished)
+		faqs, err = requestStore(h.Store, r).GetFAQsByStatus(isPublished)
 	} else {
 		// Obtener todas las FAQs
-		faqs, err = h.Store.GetFAQs()
+		faqs, err = requestStore(h.Store, r).GetFAQs()
 	}
 
 	if err != nil {
@@ -65,7 +65,7 @@ func (h *FAQHandler) GetPublishedFAQs(w http.ResponseWriter, r *http.Request) {
 	utils.SetCORS(w)
 
 	// Obtener FAQs publicadas
-	faqs, err := h.Store.GetFAQsByStatus(true)
+	faqs, err := requestStore(h.Store, r).GetFAQsByStatus(true)
 	if err != nil {
 		http.Error(w, "Error al obtener FAQs", http.StatusInternalServerError)
 		return
@@ -100,7 +100,7 @@ func (h *FAQHandler) GetFAQ(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Obtener FAQ por ID
-	faq, err := h.Store.GetFAQ(id)
+	faq, err := requestStore(h.Store, r).GetFAQ(id)
 	if err != nil {
 		http.Error(w, "FAQ no encontrada", http.StatusNotFound)
 		return
@@ -148,7 +148,7 @@ func (h *FAQHandler) CreateFAQ(w http.ResponseWriter, r *http.Request) {
 	faq.UpdatedAt = now
 
 	// Guardar en el almacén
-	if err := h.Store.CreateFAQ(faq); err != nil {
+	if err := requestStore(h.Store, r).CreateFAQ(faq); err != nil {
 		http.Error(w, "Error al crear FAQ", http.StatusInternalServerError)
 		return
 	}
@@ -208,7 +208,7 @@ func (h *FAQHandler) UpdateFAQ(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Obtener la FAQ existente
-	faq, err := h.Store.GetFAQ(id)
+	faq, err := requestStore(h.Store, r).GetFAQ(id)
 	if err != nil {
 		http.Error(w, "FAQ no encontrada", http.StatusNotFound)
 		return
@@ -224,7 +224,7 @@ func (h *FAQHandler) UpdateFAQ(w http.ResponseWriter, r *http.Request) {
 	faq.UpdatedAt = time.Now()
 
 	// Guardar cambios
-	if err := h.Store.UpdateFAQ(*faq); err != nil {
+	if err := requestStore(h.Store, r).UpdateFAQ(*faq); err != nil {
 		http.Error(w, "Error al actualizar FAQ", http.StatusInternalServerError)
 		return
 	}
@@ -266,7 +266,7 @@ func (h *FAQHandler) DeleteFAQ(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Eliminar la FAQ
-	if err := h.Store.DeleteFAQ(id); err != nil {
+	if err := requestStore(h.Store, r).DeleteFAQ(id); err != nil {
 		http.Error(w, "Error al eliminar FAQ", http.StatusInternalServerError)
 		return
 	}
@@ -307,13 +307,13 @@ func (h *FAQHandler) TogglePublishFAQ(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Llamar al método para alternar estado de publicación
-	if err := h.Store.ToggleFAQPublish(id); err != nil {
+	if err := requestStore(h.Store, r).ToggleFAQPublish(id); err != nil {
 		http.Error(w, "Error al cambiar estado de publicación", http.StatusInternalServerError)
 		return
 	}
 
 	// Obtener la FAQ actualizada
-	faq, err := h.Store.GetFAQ(id)
+	faq, err := requestStore(h.Store, r).GetFAQ(id)
 	if err != nil {
 		http.Error(w, "Error al obtener FAQ actualizada", http.StatusInternalServerError)
 		return
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// LogLevelHandler consulta y cambia el nivel de los logs sin reiniciar el servidor
+type LogLevelHandler struct{}
+
+// GetLogLevel devuelve el nivel de registro actual
+func (h *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden consultar el nivel de registro", http.StatusForbidden)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"level": logging.Level()})
+}
+
+// SetLogLevel cambia el nivel de registro (debug, info, warn o error)
+func (h *LogLevelHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden cambiar el nivel de registro", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Level == "" {
+		http.Error(w, "Se requiere el nivel de registro (debug, info, warn o error)", http.StatusBadRequest)
+		return
+	}
+
+	previous := logging.Level()
+	if err := logging.SetLevel(req.Level); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	slog.WarnContext(r.Context(), "nivel de registro cambiado",
+		slog.String("from", previous), slog.String("to", logging.Level()), slog.String("user_id", userID))
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"level": logging.Level()})
+}
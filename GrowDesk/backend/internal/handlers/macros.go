@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/macros"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// MacroHandler contiene manejadores para las macros y su aplicación a los tickets
+type MacroHandler struct {
+	Store data.DataStore
+}
+
+// GetMacros lista las macros compartidas y las personales de quien hace la solicitud.
+// Los administradores ven todas.
+func (h *MacroHandler) GetMacros(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := requestStore(h.Store, r).GetMacros()
+	if err != nil {
+		http.Error(w, "Error al obtener macros", http.StatusInternalServerError)
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	visible := make([]models.Macro, 0, len(list))
+	for _, macro := range list {
+		if !isAdmin(r) && !macros.VisibleTo(macro, userID) {
+			continue
+		}
+		if scope != "" && macro.Scope != scope {
+			continue
+		}
+		visible = append(visible, macro)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, visible)
+}
+
+// GetMacro devuelve una macro visible para quien hace la solicitud
+func (h *MacroHandler) GetMacro(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	macro, err := requestStore(h.Store, r).GetMacro(pathID(r))
+	if err != nil || (!isAdmin(r) && !macros.VisibleTo(*macro, userID)) {
+		http.Error(w, "Macro no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, macro)
+}
+
+// CreateMacro crea una macro. Cualquier agente puede crear macros personales; las
+// compartidas sólo los administradores.
+func (h *MacroHandler) CreateMacro(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	var macro models.Macro
+	if err := utils.DecodeJSON(r, &macro); err != nil {
+		http.Error(w, "Error al leer datos de la macro", http.StatusBadRequest)
+		return
+	}
+	if macro.Scope == "" {
+		macro.Scope = models.MacroScopePersonal
+	}
+	if macro.Scope == models.MacroScopeShared && !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden crear macros compartidas", http.StatusForbidden)
+		return
+	}
+
+	if err := macros.Validate(macro); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := resolveMacroTags(requestStore(h.Store, r), &macro); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	macro.ID = uuid.New().String()
+	macro.OwnerID = userID
+	macro.UsageCount = 0
+	macro.LastUsedAt = nil
+	macro.CreatedAt = time.Now()
+	macro.UpdatedAt = macro.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateMacro(macro); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear macro: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	logging.Infof("⚡ Macro creada: %s (%s)", macro.Name, macro.Scope)
+	utils.WriteJSON(w, http.StatusCreated, macro)
+}
+
+// UpdateMacro reemplaza una macro. Su autor puede editar sus macros personales; las
+// compartidas sólo los administradores.
+func (h *MacroHandler) UpdateMacro(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetMacro(pathID(r))
+	if err != nil || (!isAdmin(r) && !macros.VisibleTo(*existing, userID)) {
+		http.Error(w, "Macro no encontrada", http.StatusNotFound)
+		return
+	}
+	if !canManageMacro(r, *existing, userID) {
+		http.Error(w, "No tienes permiso para modificar esta macro", http.StatusForbidden)
+		return
+	}
+
+	var macro models.Macro
+	if err := utils.DecodeJSON(r, &macro); err != nil {
+		http.Error(w, "Error al leer datos de la macro", http.StatusBadRequest)
+		return
+	}
+	if macro.Scope == "" {
+		macro.Scope = existing.Scope
+	}
+	if macro.Scope == models.MacroScopeShared && !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden compartir macros", http.StatusForbidden)
+		return
+	}
+
+	if err := macros.Validate(macro); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := resolveMacroTags(requestStore(h.Store, r), &macro); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	macro.ID = existing.ID
+	macro.OwnerID = existing.OwnerID
+	macro.UsageCount = existing.UsageCount
+	macro.LastUsedAt = existing.LastUsedAt
+	macro.CreatedAt = existing.CreatedAt
+	macro.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateMacro(macro); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar macro: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, macro)
+}
+
+// DeleteMacro elimina una macro (su autor o un administrador)
+func (h *MacroHandler) DeleteMacro(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetMacro(pathID(r))
+	if err != nil || (!isAdmin(r) && !macros.VisibleTo(*existing, userID)) {
+		http.Error(w, "Macro no encontrada", http.StatusNotFound)
+		return
+	}
+	if !canManageMacro(r, *existing, userID) {
+		http.Error(w, "No tienes permiso para eliminar esta macro", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteMacro(existing.ID); err != nil {
+		http.Error(w, "Macro no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ApplyMacro aplica una macro a un ticket: /api/tickets/:id/macros/:macroId/apply.
+// Las acciones y la respuesta se guardan juntas; si algo falla el ticket no cambia.
+func (h *MacroHandler) ApplyMacro(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, ok := macroUser(w, r)
+	if !ok {
+		return
+	}
+
+	segments := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(segments) != 7 {
+		http.Error(w, "URL de macro inválida", http.StatusBadRequest)
+		return
+	}
+	ticketID, macroID := segments[3], segments[5]
+
+	ticket, err := requestStore(h.Store, r).GetTicket(ticketID)
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	macro, err := requestStore(h.Store, r).GetMacro(macroID)
+	if err != nil || !macros.VisibleTo(*macro, userID) {
+		http.Error(w, "Macro no encontrada", http.StatusNotFound)
+		return
+	}
+
+	agent, err := requestStore(h.Store, r).GetUser(userID)
+	if err != nil {
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	previousStatus := ticket.Status
+	changes, err := macros.Apply(requestStore(h.Store, r), ticket, *macro, *agent)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	now := time.Now()
+	ticket.UpdatedAt = now
+	result := models.MacroApplyResult{Changes: changes}
+
+	if reply := strings.TrimSpace(macros.Render(macro.Reply, *ticket, *agent)); reply != "" {
+		message := models.Message{
+			ID:         utils.GenerateMessageID(),
+			Content:    reply,
+			IsInternal: macro.Internal,
+			Timestamp:  now,
+			CreatedAt:  now,
+			UserID:     agent.ID,
+			UserName:   strings.TrimSpace(agent.FirstName + " " + agent.LastName),
+			UserEmail:  agent.Email,
+		}
+		saved, err := requestStore(h.Store, r).UpdateTicketWithMessage(*ticket, message)
+		if err != nil {
+			http.Error(w, "Error al aplicar la macro", http.StatusInternalServerError)
+			return
+		}
+		result.Message = saved
+		// Las respuestas internas sólo llegan a las conexiones de agentes
+		requestStore(h.Store, r).BroadcastMessage(ticket.ID, *saved)
+		go watchers.Notify(requestStore(h.Store, r), *ticket, watchers.MessageEvent(*saved))
+	} else if err := requestStore(h.Store, r).UpdateTicket(*ticket); err != nil {
+		http.Error(w, "Error al aplicar la macro", http.StatusInternalServerError)
+		return
+	}
+	onStatusChange(requestStore(h.Store, r), *ticket, previousStatus, agent.ID)
+
+	if err := requestStore(h.Store, r).RecordMacroUsage(macro.ID, now); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar el uso de la macro %s: %v", macro.ID, err)
+	}
+	activity := models.Activity{
+		UserID:      agent.ID,
+		Type:        "macro.applied",
+		TargetID:    ticket.ID,
+		Description: fmt.Sprintf("Macro %q aplicada al ticket %s", macro.Name, ticket.ID),
+		Metadata: map[string]any{
+			"macroId": macro.ID,
+			"changes": changes,
+			"replied": result.Message != nil,
+		},
+	}
+	if err := requestStore(h.Store, r).CreateActivity(activity); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar la aplicación de la macro %s: %v", macro.ID, err)
+	}
+
+	if updated, err := requestStore(h.Store, r).GetTicket(ticket.ID); err == nil {
+		ticket = updated
+	}
+	result.Ticket = *ticket
+
+	logging.Infof("⚡ Macro %s aplicada al ticket %s (%d cambios)", macro.Name, ticket.ID, len(changes))
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// resolveMacroTags ajusta las etiquetas que añade la macro al catálogo, creando las que
+// falten. Las que quita sólo se normalizan.
+func resolveMacroTags(store data.DataStore, macro *models.Macro) error {
+	added, err := tags.Resolve(store, macro.Actions.AddTags)
+	if err != nil {
+		return err
+	}
+	macro.Actions.AddTags = added
+	removed := make([]string, 0, len(macro.Actions.RemoveTags))
+	for _, name := range macro.Actions.RemoveTags {
+		if name = tags.Normalize(name); name != "" {
+			removed = append(removed, name)
+		}
+	}
+	macro.Actions.RemoveTags = removed
+	return nil
+}
+
+// macroUser devuelve el usuario de la solicitud. Las macros son de los agentes, así
+// que los clientes y las claves de servicio no pueden usarlas.
+func macroUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !isAgent(r) || userID == "" || middleware.IsServicePrincipal(r) {
+		http.Error(w, "Solo los agentes pueden usar macros", http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
+
+// canManageMacro indica si el usuario puede modificar o eliminar la macro
+func canManageMacro(r *http.Request, macro models.Macro, userID string) bool {
+	if isAdmin(r) {
+		return true
+	}
+	return macro.Scope == models.MacroScopePersonal && macro.OwnerID == userID
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/customfields"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/relations"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// maxMergeDepth limita las fusiones encadenadas que se siguen al redirigir un ticket
+const maxMergeDepth = 10
+
+// ticketMergeRequest es el cuerpo de POST /api/tickets/:id/merge
+type ticketMergeRequest struct {
+	TargetID string `json:"targetId"`
+}
+
+// ticketSplitRequest es el cuerpo de POST /api/tickets/:id/split
+type ticketSplitRequest struct {
+	MessageIDs []string `json:"messageIds"`
+	Title      string   `json:"title,omitempty"`
+}
+
+// MergeTicket fusiona el ticket de la URL en targetId: le pasa sus mensajes (con los
+// adjuntos) y su historial, cierra el original apuntando al destino y redirige a los
+// clientes conectados al original
+func (h *TicketHandler) MergeTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para fusionar tickets", http.StatusForbidden)
+		return
+	}
+
+	source, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *source) {
+		return
+	}
+
+	var req ticketMergeRequest
+	if err := utils.DecodeJSON(r, &req); err != nil || req.TargetID == "" {
+		http.Error(w, "Se requiere el ticket destino (targetId)", http.StatusBadRequest)
+		return
+	}
+	if req.TargetID == source.ID {
+		http.Error(w, "No se puede fusionar un ticket consigo mismo", http.StatusBadRequest)
+		return
+	}
+	if source.MergedInto != "" {
+		http.Error(w, fmt.Sprintf("El ticket ya se fusionó en %s", source.MergedInto), http.StatusConflict)
+		return
+	}
+
+	target, err := requestStore(h.Store, r).GetTicket(req.TargetID)
+	if err != nil {
+		http.Error(w, "Ticket destino no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *target) {
+		return
+	}
+	if target.MergedInto != "" {
+		http.Error(w, fmt.Sprintf("El ticket destino se fusionó en %s", target.MergedInto), http.StatusConflict)
+		return
+	}
+
+	// El destino conserva sus datos y suma las etiquetas y los campos que le falten
+	target.Tags = tags.Add(target.Tags, source.Tags)
+	target.CustomFields = customfields.Merge(source.CustomFields, target.CustomFields)
+	source.Status = "closed"
+	source.MergedInto = target.ID
+
+	moved, err := requestStore(h.Store, r).MergeTickets(*source, *target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error al fusionar tickets: %v", err), http.StatusInternalServerError)
+		return
+	}
+	redirected := requestStore(h.Store, r).RedirectWSConnections(source.ID, target.ID)
+
+	// El ticket fusionado queda enlazado como duplicado del destino
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	duplicate := models.TicketRelation{
+		TicketID:        source.ID,
+		RelatedTicketID: target.ID,
+		Type:            models.TicketRelationDuplicateOf,
+		CreatedBy:       userID,
+		CreatedAt:       time.Now(),
+	}
+	if relations.Validate(requestStore(h.Store, r), duplicate) == nil {
+		if err := requestStore(h.Store, r).CreateTicketRelation(duplicate); err != nil {
+			logging.Warnf("⚠️ No se pudo enlazar el ticket %s como duplicado de %s: %v", source.ID, target.ID, err)
+		}
+	}
+
+	metadata := map[string]any{"sourceId": source.ID, "targetId": target.ID, "messagesMoved": moved}
+	for _, activity := range []models.Activity{
+		{UserID: userID, Type: "ticket.merged", TargetID: target.ID, Description: fmt.Sprintf("Ticket %s fusionado en este ticket", source.ID), Metadata: metadata},
+		{UserID: userID, Type: "ticket.merged", TargetID: source.ID, Description: fmt.Sprintf("Ticket fusionado en %s", target.ID), Metadata: metadata},
+	} {
+		if err := requestStore(h.Store, r).CreateActivity(activity); err != nil {
+			logging.Warnf("⚠️ No se pudo registrar la fusión del ticket %s: %v", source.ID, err)
+		}
+	}
+
+	merged, err := requestStore(h.Store, r).GetTicket(target.ID)
+	if err != nil {
+		merged = target
+	}
+
+	logging.Infof("🔀 Ticket %s fusionado en %s: %d mensajes movidos, %d conexiones redirigidas", source.ID, target.ID, moved, redirected)
+	utils.WriteJSON(w, http.StatusOK, merged)
+}
+
+// SplitTicket crea un ticket nuevo con los mensajes indicados del ticket de la URL. El
+// nuevo ticket hereda el cliente, la cola, la categoría y las etiquetas del original.
+func (h *TicketHandler) SplitTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para dividir tickets", http.StatusForbidden)
+		return
+	}
+
+	source, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *source) {
+		return
+	}
+	if source.MergedInto != "" {
+		http.Error(w, fmt.Sprintf("El ticket se fusionó en %s", source.MergedInto), http.StatusConflict)
+		return
+	}
+
+	var req ticketSplitRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la división", http.StatusBadRequest)
+		return
+	}
+
+	selected := make(map[string]bool, len(req.MessageIDs))
+	for _, id := range req.MessageIDs {
+		selected[id] = true
+	}
+	var moved []models.Message
+	for _, message := range source.Messages {
+		if selected[message.ID] {
+			moved = append(moved, message)
+		}
+	}
+	switch {
+	case len(selected) == 0:
+		http.Error(w, "Indica los mensajes que pasan al ticket nuevo (messageIds)", http.StatusBadRequest)
+		return
+	case len(moved) != len(selected):
+		http.Error(w, "Algún mensaje no pertenece al ticket", http.StatusBadRequest)
+		return
+	case len(moved) == len(source.Messages):
+		http.Error(w, "El ticket original debe conservar al menos un mensaje", http.StatusBadRequest)
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = fmt.Sprintf("%s (dividido)", source.Title)
+	}
+	// La descripción es visible para el cliente: nunca se toma de una nota interna
+	description := title
+	for _, message := range moved {
+		if !message.IsInternal {
+			description = message.Content
+			break
+		}
+	}
+	now := time.Now()
+	newTicket := models.Ticket{
+		ID:           newTicketID(requestStore(h.Store, r), now),
+		Title:        title,
+		Description:  description,
+		Status:       "open",
+		Priority:     source.Priority,
+		Category:     source.Category,
+		CategoryID:   source.CategoryID,
+		CreatedBy:    source.CreatedBy,
+		UserID:       source.UserID,
+		Customer:     source.Customer,
+		Source:       source.Source,
+		WidgetID:     source.WidgetID,
+		Department:   source.Department,
+		TeamID:       source.TeamID,
+		Tags:         append([]string(nil), source.Tags...),
+		CustomFields: customfields.Merge(source.CustomFields, nil),
+		SplitFrom:    source.ID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if source.Metadata != nil {
+		// El ID externo identifica al ticket original; el nuevo no lo hereda
+		metadata := *source.Metadata
+		metadata.ExternalID = ""
+		newTicket.Metadata = &metadata
+	}
+
+	if err := requestStore(h.Store, r).SplitTicket(newTicket, source.ID, req.MessageIDs); err != nil {
+		http.Error(w, fmt.Sprintf("Error al dividir ticket: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	metadata := map[string]any{"sourceId": source.ID, "ticketId": newTicket.ID, "messageIds": req.MessageIDs}
+	for _, activity := range []models.Activity{
+		{UserID: userID, Type: "ticket.split", TargetID: source.ID, Description: fmt.Sprintf("%d mensajes separados al ticket %s", len(moved), newTicket.ID), Metadata: metadata},
+		{UserID: userID, Type: "ticket.split", TargetID: newTicket.ID, Description: fmt.Sprintf("Ticket separado de %s", source.ID), Metadata: metadata},
+	} {
+		if err := requestStore(h.Store, r).CreateActivity(activity); err != nil {
+			logging.Warnf("⚠️ No se pudo registrar la división del ticket %s: %v", source.ID, err)
+		}
+	}
+
+	created, err := requestStore(h.Store, r).GetTicket(newTicket.ID)
+	if err != nil {
+		created = &newTicket
+	}
+
+	logging.Infof("✂️ Ticket %s dividido: %d mensajes pasan a %s", source.ID, len(moved), newTicket.ID)
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+// followMerged devuelve el ticket indicado o, si se fusionó, el ticket en el que acabó
+func followMerged(store data.DataStore, ticketID string) (*models.Ticket, error) {
+	ticket, err := store.GetTicket(ticketID)
+	for depth := 0; err == nil && ticket.MergedInto != "" && depth < maxMergeDepth; depth++ {
+		var next *models.Ticket
+		if next, err = store.GetTicket(ticket.MergedInto); err == nil {
+			ticket = next
+		}
+	}
+	return ticket, err
+}
+
+// newTicketID genera un ID de ticket con el formato habitual que no esté en uso
+func newTicketID(store data.DataStore, now time.Time) string {
+	for {
+		id := utils.GenerateTicketID(now)
+		if _, err := store.GetTicket(id); err != nil {
+			return id
+		}
+	}
+}
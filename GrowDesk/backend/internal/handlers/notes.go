@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// seesInternalNotes indica si quien hace la solicitud puede ver y escribir notas
+// internas: los agentes y las integraciones sí; los clientes y widget-api, que atiende
+// al cliente desde el widget, no
+func seesInternalNotes(r *http.Request) bool {
+	if !isAgent(r) {
+		return false
+	}
+	if middleware.IsServicePrincipal(r) {
+		scopes, _ := r.Context().Value(middleware.ScopesKey).([]string)
+		return !middleware.HasScope(scopes, middleware.ScopeWidget)
+	}
+	return true
+}
+
+// publicMessages devuelve los mensajes sin las notas internas
+func publicMessages(messages []models.Message) []models.Message {
+	public := make([]models.Message, 0, len(messages))
+	for _, message := range messages {
+		if !message.IsInternal {
+			public = append(public, message)
+		}
+	}
+	return public
+}
+
+// ticketView devuelve una copia del ticket para responder a la solicitud, sin las notas
+// internas si quien la hace no puede verlas. Se copia porque el almacén JSON devuelve su
+// propio ticket y quitarle los mensajes lo modificaría en memoria.
+func ticketView(r *http.Request, ticket models.Ticket) models.Ticket {
+	if !seesInternalNotes(r) {
+		ticket.Messages = publicMessages(ticket.Messages)
+	}
+	return ticket
+}
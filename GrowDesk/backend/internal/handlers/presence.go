@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/presence"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// PresenceHandler expone la presencia de los agentes y la disponibilidad del chat en vivo
+type PresenceHandler struct {
+	Store   data.DataStore
+	Tracker *presence.Tracker
+}
+
+// GetPresence lista el estado de presencia de los agentes
+func (h *PresenceHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver la presencia de los agentes", http.StatusForbidden)
+		return
+	}
+
+	agents, err := presence.Agents(requestStore(h.Store, r), h.Tracker, time.Now())
+	if err != nil {
+		http.Error(w, "Error al obtener la presencia de los agentes", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, agents)
+}
+
+// Heartbeat mantiene conectado al agente mientras tenga el panel abierto, aunque no
+// tenga ningún chat activo
+func (h *PresenceHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !isAgent(r) || userID == "" || middleware.IsServicePrincipal(r) {
+		http.Error(w, "Solo los agentes pueden notificar su presencia", http.StatusForbidden)
+		return
+	}
+
+	user, err := requestStore(h.Store, r).GetUser(userID)
+	if err != nil {
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		return
+	}
+
+	h.Tracker.Touch(userID)
+	utils.WriteJSON(w, http.StatusOK, presence.Of(h.Tracker, *user, time.Now()))
+}
+
+// GetWidgetAvailability indica al widget si está dentro del horario de atención y hay
+// agentes en línea para el chat en vivo. ?teamId= limita el cálculo a los miembros de un
+// equipo; ?widgetId= (o X-Widget-ID) elige el calendario del widget.
+func (h *PresenceHandler) GetWidgetAvailability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	teamID := r.URL.Query().Get("teamId")
+	widgetID := r.URL.Query().Get("widgetId")
+	if widgetID == "" {
+		widgetID = r.Header.Get("X-Widget-ID")
+	}
+	availability, err := presence.WidgetAvailability(requestStore(h.Store, r), h.Tracker, teamID, widgetID, time.Now())
+	if err != nil {
+		if teamID != "" {
+			http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error al calcular la disponibilidad", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, availability)
+}
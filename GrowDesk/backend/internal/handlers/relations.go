@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/relations"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// GetTicketRelations maneja GET /api/tickets/:id/relations
+func (h *TicketHandler) GetTicketRelations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	links, err := relations.Links(requestStore(h.Store, r), ticket.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, links)
+}
+
+// CreateTicketRelation maneja POST /api/tickets/:id/relations. El tipo se indica desde
+// el ticket de la URL: {"type": "parent_of", "ticketId": "..."} hace a este ticket
+// padre del otro.
+func (h *TicketHandler) CreateTicketRelation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para relacionar tickets", http.StatusForbidden)
+		return
+	}
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	var req models.TicketRelationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la relación", http.StatusBadRequest)
+		return
+	}
+
+	relation, err := relations.Canonical(ticket.ID, req.TicketID, req.Type)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	related, err := requestStore(h.Store, r).GetTicket(req.TicketID)
+	if err != nil {
+		http.Error(w, "Ticket relacionado no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *related) {
+		return
+	}
+	if err := relations.Validate(requestStore(h.Store, r), relation); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	relation.CreatedBy = userID
+	relation.CreatedAt = time.Now()
+	if err := requestStore(h.Store, r).CreateTicketRelation(relation); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear relación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// El ID lo asigna el almacén; se recupera para devolver la relación completa
+	links, err := relations.Links(requestStore(h.Store, r), ticket.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var created models.TicketLink
+	for _, link := range links {
+		if link.TicketID == related.ID {
+			created = link
+		}
+	}
+
+	h.recordRelationActivity(userID, "ticket.related", ticket.ID, related.ID, created.Type,
+		fmt.Sprintf("Relación %s con el ticket %s", created.Type, related.ID))
+
+	logging.Infof("🔗 Ticket %s relacionado con %s (%s)", ticket.ID, related.ID, created.Type)
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+// DeleteTicketRelation maneja DELETE /api/tickets/:id/relations/:relationId
+func (h *TicketHandler) DeleteTicketRelation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para relacionar tickets", http.StatusForbidden)
+		return
+	}
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	relationID := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")[5]
+	rels, err := requestStore(h.Store, r).GetTicketRelations(ticket.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener relaciones", http.StatusInternalServerError)
+		return
+	}
+	var link *models.TicketLink
+	for _, rel := range rels {
+		if rel.ID == relationID {
+			found := relations.Link(requestStore(h.Store, r), ticket.ID, rel)
+			link = &found
+		}
+	}
+	if link == nil {
+		http.Error(w, "Relación no encontrada", http.StatusNotFound)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteTicketRelation(relationID); err != nil {
+		http.Error(w, fmt.Sprintf("Error al eliminar relación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	h.recordRelationActivity(userID, "ticket.unrelated", ticket.ID, link.TicketID, link.Type,
+		fmt.Sprintf("Relación %s con el ticket %s eliminada", link.Type, link.TicketID))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cascadeToChildren lleva el estado de un ticket padre recién resuelto o cerrado a sus
+// hijos abiertos y, si hay mensaje, se lo envía a cada hijo como respuesta del agente.
+// Devuelve los IDs de los hijos actualizados.
+func (h *TicketHandler) cascadeToChildren(parent models.Ticket, message string, userID string) []string {
+	rels, err := h.Store.GetTicketRelations(parent.ID)
+	if err != nil {
+		logging.Warnf("⚠️ No se pudieron obtener los hijos del ticket %s: %v", parent.ID, err)
+		return nil
+	}
+
+	var agent models.User
+	if user, err := h.Store.GetUser(userID); err == nil {
+		agent = *user
+	}
+
+	var updated []string
+	for _, childID := range relations.Children(rels, parent.ID) {
+		child, err := h.Store.GetTicket(childID)
+		if err != nil || child.MergedInto != "" || child.Status == "resolved" || child.Status == "closed" {
+			continue
+		}
+
+		now := time.Now()
+		previousStatus := child.Status
+		child.Status = parent.Status
+		child.UpdatedAt = now
+		if message == "" {
+			err = h.Store.UpdateTicket(*child)
+		} else {
+			reply := models.Message{
+				ID:        utils.GenerateMessageID(),
+				Content:   message,
+				Timestamp: now,
+				CreatedAt: now,
+				UserID:    agent.ID,
+				UserName:  strings.TrimSpace(agent.FirstName + " " + agent.LastName),
+				UserEmail: agent.Email,
+			}
+			var saved *models.Message
+			if saved, err = h.Store.UpdateTicketWithMessage(*child, reply); err == nil {
+				h.Store.BroadcastMessage(child.ID, *saved)
+				go watchers.Notify(h.Store, *child, watchers.MessageEvent(*saved))
+			}
+		}
+		if err != nil {
+			logging.Warnf("⚠️ No se pudo actualizar el ticket hijo %s: %v", child.ID, err)
+			continue
+		}
+		onStatusChange(h.Store, *child, previousStatus, userID)
+
+		if err := h.Store.CreateActivity(models.Activity{
+			UserID:      userID,
+			Type:        "ticket.cascaded",
+			TargetID:    child.ID,
+			Description: fmt.Sprintf("Estado %s heredado del ticket padre %s", parent.Status, parent.ID),
+			Metadata:    map[string]any{"parentId": parent.ID, "status": parent.Status, "notified": message != ""},
+		}); err != nil {
+			logging.Warnf("⚠️ No se pudo registrar la actividad del ticket %s: %v", child.ID, err)
+		}
+		updated = append(updated, child.ID)
+	}
+
+	if len(updated) > 0 {
+		logging.Infof("🌳 Ticket padre %s %s: %d hijos actualizados", parent.ID, parent.Status, len(updated))
+	}
+	return updated
+}
+
+// recordRelationActivity registra el cambio de una relación en el historial del ticket
+func (h *TicketHandler) recordRelationActivity(userID, activityType, ticketID, relatedID, relType, description string) {
+	if err := h.Store.CreateActivity(models.Activity{
+		UserID:      userID,
+		Type:        activityType,
+		TargetID:    ticketID,
+		Description: description,
+		Metadata:    map[string]any{"relatedTicketId": relatedID, "type": relType},
+	}); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar la actividad del ticket %s: %v", ticketID, err)
+	}
+}
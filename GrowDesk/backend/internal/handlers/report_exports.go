@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/exports"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// ReportExportHandler maneja las exportaciones programadas (/api/report-schedules) y su
+// historial de ejecuciones (/api/exports). Sólo para administradores.
+type ReportExportHandler struct {
+	Store data.DataStore
+}
+
+// GetSchedules lista las exportaciones programadas
+func (h *ReportExportHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	schedules, err := requestStore(h.Store, r).GetReportSchedules()
+	if err != nil {
+		http.Error(w, "Error al obtener exportaciones programadas", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, schedules)
+}
+
+// GetSchedule devuelve una exportación programada
+func (h *ReportExportHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	schedule, err := requestStore(h.Store, r).GetReportSchedule(pathID(r))
+	if err != nil {
+		http.Error(w, "Exportación programada no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, schedule)
+}
+
+// CreateSchedule crea una exportación programada; se activa salvo que enabled sea false
+func (h *ReportExportHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	var req models.ReportScheduleRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la exportación", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	schedule := models.ReportSchedule{
+		ID:        uuid.New().String(),
+		Enabled:   true,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	schedule.UpdatedAt = schedule.CreatedAt
+	applyScheduleRequest(&schedule, req)
+
+	if err := h.prepare(&schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := requestStore(h.Store, r).CreateReportSchedule(schedule); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear exportación programada: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, schedule)
+}
+
+// UpdateSchedule modifica los campos indicados de una exportación programada
+func (h *ReportExportHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetReportSchedule(pathID(r))
+	if err != nil {
+		http.Error(w, "Exportación programada no encontrada", http.StatusNotFound)
+		return
+	}
+
+	var req models.ReportScheduleRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la exportación", http.StatusBadRequest)
+		return
+	}
+
+	schedule := *existing
+	applyScheduleRequest(&schedule, req)
+	schedule.UpdatedAt = time.Now()
+
+	if err := h.prepare(&schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := requestStore(h.Store, r).UpdateReportSchedule(schedule); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar exportación programada: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, schedule)
+}
+
+// DeleteSchedule elimina una exportación programada; su historial se conserva
+func (h *ReportExportHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteReportSchedule(pathID(r)); err != nil {
+		http.Error(w, "Exportación programada no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunSchedule maneja POST /api/report-schedules/:id/run: lanza la exportación ahora, sin
+// cambiar su próxima ejecución. Responde 202 con la ejecución en curso.
+func (h *ReportExportHandler) RunSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	schedule, err := requestStore(h.Store, r).GetReportSchedule(pathID(r))
+	if err != nil {
+		http.Error(w, "Exportación programada no encontrada", http.StatusNotFound)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	export, err := exports.Run(requestStore(h.Store, r), *schedule, userID, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error al lanzar la exportación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, export)
+}
+
+// GetExports maneja GET /api/exports: historial de ejecuciones, de la más reciente a la
+// más antigua, opcionalmente de una sola programación (scheduleId)
+func (h *ReportExportHandler) GetExports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	list, err := requestStore(h.Store, r).GetReportExports(r.URL.Query().Get("scheduleId"))
+	if err != nil {
+		http.Error(w, "Error al obtener exportaciones", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// GetExport devuelve una ejecución
+func (h *ReportExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	export, err := requestStore(h.Store, r).GetReportExport(pathID(r))
+	if err != nil {
+		http.Error(w, "Exportación no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, export)
+}
+
+// DeleteExport borra una ejecución del historial junto con su archivo
+func (h *ReportExportHandler) DeleteExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	export, err := requestStore(h.Store, r).GetReportExport(pathID(r))
+	if err != nil {
+		http.Error(w, "Exportación no encontrada", http.StatusNotFound)
+		return
+	}
+	if export.Status == exports.StatusRunning {
+		http.Error(w, "La exportación todavía se está generando", http.StatusConflict)
+		return
+	}
+
+	if err := exports.Remove(requestStore(h.Store, r), *export); err != nil {
+		http.Error(w, fmt.Sprintf("Error al eliminar la exportación: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DownloadExport maneja GET /api/exports/:id/download: descarga el archivo generado
+func (h *ReportExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar exportaciones", http.StatusForbidden)
+		return
+	}
+
+	h.serveFile(w, r, pathID(r))
+}
+
+// PublicDownload maneja GET /exports/:id/download?expires=&token=: descarga con el enlace
+// firmado que se envía por correo y webhook, sin sesión
+func (h *ReportExportHandler) PublicDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) != 3 || segments[2] != "download" {
+		http.NotFound(w, r)
+		return
+	}
+	exportID := segments[1]
+	if err := exports.Default().Verify(exportID, r.URL.Query().Get("expires"), r.URL.Query().Get("token")); err != nil {
+		http.Error(w, "Enlace de descarga inválido o caducado", http.StatusForbidden)
+		return
+	}
+
+	h.serveFile(w, r, exportID)
+}
+
+// serveFile envía el archivo de una ejecución terminada con su nombre de descarga
+func (h *ReportExportHandler) serveFile(w http.ResponseWriter, r *http.Request, exportID string) {
+	export, err := requestStore(h.Store, r).GetReportExport(exportID)
+	if err != nil {
+		http.Error(w, "Exportación no encontrada", http.StatusNotFound)
+		return
+	}
+	if export.Status != exports.StatusSucceeded || export.FileName == "" {
+		http.Error(w, exports.ErrFileMissing.Error(), http.StatusNotFound)
+		return
+	}
+
+	file, err := os.Open(exports.Default().Path(*export))
+	if err != nil {
+		http.Error(w, exports.ErrFileMissing.Error(), http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", exports.ContentType(export.Format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.FileName}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	modified := export.StartedAt
+	if export.FinishedAt != nil {
+		modified = *export.FinishedAt
+	}
+	http.ServeContent(w, r, export.FileName, modified, file)
+}
+
+// prepare valida la programación y recalcula su próxima ejecución
+func (h *ReportExportHandler) prepare(schedule *models.ReportSchedule) error {
+	if err := exports.Validate(schedule); err != nil {
+		return err
+	}
+	schedule.NextRunAt = nil
+	if !schedule.Enabled {
+		return nil
+	}
+	next, err := exports.NextRun(*schedule, time.Now())
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = next
+	return nil
+}
+
+// applyScheduleRequest copia en la programación los campos informados en la solicitud
+func applyScheduleRequest(schedule *models.ReportSchedule, req models.ReportScheduleRequest) {
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+	if req.Schedule != nil {
+		schedule.Schedule = *req.Schedule
+	}
+	if req.TimeZone != nil {
+		schedule.TimeZone = *req.TimeZone
+	}
+	if req.Dataset != nil {
+		schedule.Dataset = *req.Dataset
+	}
+	if req.Format != nil {
+		schedule.Format = strings.ToLower(*req.Format)
+	}
+	if req.RangeDays != nil {
+		schedule.RangeDays = *req.RangeDays
+	}
+	if req.GroupBy != nil {
+		schedule.GroupBy = *req.GroupBy
+	}
+	if req.Filters != nil {
+		schedule.Filters = *req.Filters
+	}
+	if req.Delivery != nil {
+		schedule.Delivery = *req.Delivery
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/reports"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Límites de los parámetros de los informes
+const (
+	defaultReportDays = 30
+	maxReportDays     = 731
+)
+
+// ReportHandler maneja los informes de tickets (/api/reports/*)
+type ReportHandler struct {
+	Store data.DataStore
+}
+
+// GetVolume maneja GET /api/reports/volume: tickets creados y resueltos por grupo
+func (h *ReportHandler) GetVolume(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "volume", reports.GroupDay, func(query models.ReportQuery, label func(string) string) (interface{}, error) {
+		rows, err := requestStore(h.Store, r).ReportVolume(query)
+		for i := range rows {
+			rows[i].Label = label(rows[i].Key)
+		}
+		return rows, err
+	})
+}
+
+// GetFirstResponse maneja GET /api/reports/first-response: tiempo hasta la primera
+// respuesta pública de un agente, agrupado por la fecha de creación del ticket
+func (h *ReportHandler) GetFirstResponse(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "first-response", reports.GroupDay, func(query models.ReportQuery, label func(string) string) (interface{}, error) {
+		rows, err := requestStore(h.Store, r).ReportFirstResponse(query)
+		for i := range rows {
+			rows[i].Label = label(rows[i].Key)
+		}
+		return rows, err
+	})
+}
+
+// GetResolution maneja GET /api/reports/resolution: tiempo de resolución, agrupado por la
+// fecha de resolución
+func (h *ReportHandler) GetResolution(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "resolution", reports.GroupDay, func(query models.ReportQuery, label func(string) string) (interface{}, error) {
+		rows, err := requestStore(h.Store, r).ReportResolution(query)
+		for i := range rows {
+			rows[i].Label = label(rows[i].Key)
+		}
+		return rows, err
+	})
+}
+
+// GetBacklog maneja GET /api/reports/backlog: tickets abiertos al final de cada día o
+// semana, o abiertos ahora agrupados por otra dimensión
+func (h *ReportHandler) GetBacklog(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "backlog", reports.GroupDay, func(query models.ReportQuery, label func(string) string) (interface{}, error) {
+		rows, err := requestStore(h.Store, r).ReportBacklog(query)
+		for i := range rows {
+			rows[i].Label = label(rows[i].Key)
+		}
+		return rows, err
+	})
+}
+
+// GetAgentWorkload maneja GET /api/reports/agents: carga de trabajo por agente. Siempre
+// agrupa por agente.
+func (h *ReportHandler) GetAgentWorkload(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "agents", reports.GroupAgent, func(query models.ReportQuery, label func(string) string) (interface{}, error) {
+		rows, err := requestStore(h.Store, r).ReportAgentWorkload(query)
+		for i := range rows {
+			rows[i].Label = label(rows[i].AgentID)
+		}
+		return rows, err
+	})
+}
+
+// RefreshReports maneja POST /api/reports/refresh: recalcula ya los datos de los
+// informes en lugar de esperar al siguiente refresco periódico (sólo administradores)
+func (h *ReportHandler) RefreshReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Sólo los administradores pueden refrescar los informes", http.StatusForbidden)
+		return
+	}
+	asOf, err := requestStore(h.Store, r).RefreshReports()
+	if err != nil {
+		logging.Errorf("❌ Error al refrescar los informes: %v", err)
+		http.Error(w, "Error al refrescar los informes", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{"dataAsOf": asOf})
+}
+
+// serve valida la solicitud y los permisos, ejecuta el informe y responde con sus filas
+func (h *ReportHandler) serve(w http.ResponseWriter, r *http.Request, metric, defaultGroupBy string,
+	run func(query models.ReportQuery, label func(string) string) (interface{}, error)) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los informes", http.StatusForbidden)
+		return
+	}
+	query, err := reportQuery(r, defaultGroupBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if metric == "agents" {
+		query.GroupBy = reports.GroupAgent
+	}
+
+	// Quien no es administrador sólo ve los informes de uno de sus equipos
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+	if !scope.all && !scope.teams[query.TeamID] {
+		http.Error(w, "Indica en teamId uno de tus equipos para ver sus informes", http.StatusForbidden)
+		return
+	}
+
+	rows, err := run(query, reports.Labeler(requestStore(h.Store, r), query.GroupBy))
+	if err != nil {
+		logging.Errorf("❌ Error al calcular el informe %s: %v", metric, err)
+		http.Error(w, "Error al calcular el informe", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, models.ReportResponse{
+		Metric:      metric,
+		Query:       query,
+		DataAsOf:    requestStore(h.Store, r).ReportsDataAsOf(),
+		GeneratedAt: time.Now(),
+		Rows:        rows,
+	})
+}
+
+// reportQuery lee los parámetros de los informes: from y to (AAAA-MM-DD en la zona horaria
+// tz, o RFC3339; por defecto los últimos 30 días), tz (IANA, por defecto UTC), groupBy y
+// los filtros categoryId, priority, source, widgetId, agentId y teamId
+func reportQuery(r *http.Request, defaultGroupBy string) (models.ReportQuery, error) {
+	values := r.URL.Query()
+	query := models.ReportQuery{
+		TimeZone:   values.Get("tz"),
+		GroupBy:    values.Get("groupBy"),
+		CategoryID: values.Get("categoryId"),
+		Priority:   values.Get("priority"),
+		Source:     values.Get("source"),
+		WidgetID:   values.Get("widgetId"),
+		AgentID:    values.Get("agentId"),
+		TeamID:     values.Get("teamId"),
+	}
+	if query.TimeZone == "" {
+		query.TimeZone = "UTC"
+	}
+	location, err := time.LoadLocation(query.TimeZone)
+	if err != nil {
+		return query, fmt.Errorf("Zona horaria inválida: %s", query.TimeZone)
+	}
+	query.Location = location
+
+	if query.GroupBy == "" {
+		query.GroupBy = defaultGroupBy
+	}
+	if !reports.ValidGroupBy(query.GroupBy) {
+		return query, fmt.Errorf("groupBy inválido: usa day, week, category, priority, source, widget o agent")
+	}
+
+	from, err := parseDateParam(values.Get("from"), false, location)
+	if err != nil {
+		return query, fmt.Errorf("fecha from inválida: %v", err)
+	}
+	to, err := parseDateParam(values.Get("to"), true, location)
+	if err != nil {
+		return query, fmt.Errorf("fecha to inválida: %v", err)
+	}
+	if to == nil {
+		tomorrow := reports.BucketStart(time.Now(), reports.GroupDay, location).AddDate(0, 0, 1)
+		to = &tomorrow
+	}
+	if from == nil {
+		start := to.AddDate(0, 0, -defaultReportDays)
+		from = &start
+	}
+	if !from.Before(*to) {
+		return query, fmt.Errorf("from debe ser anterior a to")
+	}
+	if to.Sub(*from) > maxReportDays*24*time.Hour {
+		return query, fmt.Errorf("El periodo no puede superar %d días", maxReportDays)
+	}
+	query.From, query.To = *from, *to
+	return query, nil
+}
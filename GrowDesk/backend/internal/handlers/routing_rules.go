@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/businesshours"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/routing"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// RoutingHandler contiene manejadores para administrar las reglas de enrutamiento de tickets
+type RoutingHandler struct {
+	Store data.DataStore
+}
+
+// routingDryRunRequest es el cuerpo de POST /api/routing-rules/dry-run. Si Rules viene
+// informado se evalúan esas reglas (sin guardarlas) en lugar de las configuradas.
+type routingDryRunRequest struct {
+	Ticket models.Ticket         `json:"ticket"`
+	At     *time.Time            `json:"at,omitempty"`
+	Rules  *[]models.RoutingRule `json:"rules,omitempty"`
+}
+
+// GetRoutingRules lista las reglas de enrutamiento en orden de evaluación
+func (h *RoutingHandler) GetRoutingRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	rules, err := requestStore(h.Store, r).GetRoutingRules()
+	if err != nil {
+		http.Error(w, "Error al obtener reglas de enrutamiento", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rules)
+}
+
+// GetRoutingRule devuelve una regla de enrutamiento
+func (h *RoutingHandler) GetRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	rule, err := requestStore(h.Store, r).GetRoutingRule(pathID(r))
+	if err != nil {
+		http.Error(w, "Regla de enrutamiento no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rule)
+}
+
+// CreateRoutingRule crea una regla; sin posición se coloca al final
+func (h *RoutingHandler) CreateRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	var rule models.RoutingRule
+	if err := utils.DecodeJSON(r, &rule); err != nil {
+		http.Error(w, "Error al leer datos de la regla", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if rule.Position == 0 {
+		rules, err := requestStore(h.Store, r).GetRoutingRules()
+		if err != nil {
+			http.Error(w, "Error al obtener reglas de enrutamiento", http.StatusInternalServerError)
+			return
+		}
+		for _, existing := range rules {
+			if existing.Position >= rule.Position {
+				rule.Position = existing.Position + 1
+			}
+		}
+		if rule.Position == 0 {
+			rule.Position = 1
+		}
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateRoutingRule(rule); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear regla de enrutamiento: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateRoutingRule reemplaza una regla de enrutamiento
+func (h *RoutingHandler) UpdateRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetRoutingRule(pathID(r))
+	if err != nil {
+		http.Error(w, "Regla de enrutamiento no encontrada", http.StatusNotFound)
+		return
+	}
+
+	var rule models.RoutingRule
+	if err := utils.DecodeJSON(r, &rule); err != nil {
+		http.Error(w, "Error al leer datos de la regla", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = time.Now()
+	if rule.Position == 0 {
+		rule.Position = existing.Position
+	}
+
+	if err := requestStore(h.Store, r).UpdateRoutingRule(rule); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar regla de enrutamiento: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rule)
+}
+
+// DeleteRoutingRule elimina una regla de enrutamiento
+func (h *RoutingHandler) DeleteRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteRoutingRule(pathID(r)); err != nil {
+		http.Error(w, "Regla de enrutamiento no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderRoutingRules asigna posiciones consecutivas según el orden de IDs recibido.
+// Las reglas no incluidas conservan su orden relativo detrás de las indicadas.
+func (h *RoutingHandler) ReorderRoutingRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := utils.DecodeJSON(r, &req); err != nil || len(req.IDs) == 0 {
+		http.Error(w, "Se requiere la lista de IDs en el nuevo orden", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := requestStore(h.Store, r).GetRoutingRules()
+	if err != nil {
+		http.Error(w, "Error al obtener reglas de enrutamiento", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[string]models.RoutingRule, len(rules))
+	for _, rule := range rules {
+		byID[rule.ID] = rule
+	}
+
+	ordered := make([]models.RoutingRule, 0, len(rules))
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		rule, ok := byID[id]
+		if !ok {
+			http.Error(w, fmt.Sprintf("Regla de enrutamiento %s no encontrada", id), http.StatusBadRequest)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, rule)
+		}
+	}
+	for _, rule := range rules {
+		if !seen[rule.ID] {
+			ordered = append(ordered, rule)
+		}
+	}
+
+	for i := range ordered {
+		if ordered[i].Position == i+1 {
+			continue
+		}
+		ordered[i].Position = i + 1
+		if err := requestStore(h.Store, r).UpdateRoutingRule(ordered[i]); err != nil {
+			http.Error(w, fmt.Sprintf("Error al reordenar reglas de enrutamiento: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, ordered)
+}
+
+// DryRunRoutingRules evalúa las reglas contra un ticket de ejemplo sin crear nada
+func (h *RoutingHandler) DryRunRoutingRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar reglas de enrutamiento", http.StatusForbidden)
+		return
+	}
+
+	var req routingDryRunRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la prueba", http.StatusBadRequest)
+		return
+	}
+
+	var rules []models.RoutingRule
+	if req.Rules != nil {
+		rules = *req.Rules
+		for i, rule := range rules {
+			if err := routing.Validate(rule); err != nil {
+				http.Error(w, fmt.Sprintf("Regla %d: %v", i+1, err), http.StatusBadRequest)
+				return
+			}
+			if rules[i].ID == "" {
+				rules[i].ID = fmt.Sprintf("borrador-%d", i+1)
+			}
+		}
+	} else {
+		var err error
+		if rules, err = requestStore(h.Store, r).GetRoutingRules(); err != nil {
+			http.Error(w, "Error al obtener reglas de enrutamiento", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ticket := req.Ticket
+	if req.At != nil {
+		ticket.CreatedAt = *req.At
+	}
+	input := routing.InputFromTicket(ticket)
+	result := routing.Evaluate(rules, input, routingHours(requestStore(h.Store, r)))
+	routing.Apply(&ticket, result.Actions)
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"input":   input,
+		"matched": result.Matched,
+		"actions": result.Actions,
+		"ticket":  ticket,
+	})
+}
+
+// validateRule comprueba la regla y que existan la categoría y el agente que asigna.
+// Las etiquetas se ajustan al catálogo, creando las que falten.
+func (h *RoutingHandler) validateRule(rule *models.RoutingRule) error {
+	if err := routing.Validate(*rule); err != nil {
+		return err
+	}
+	var err error
+	if rule.Actions.Tags, err = tags.Resolve(h.Store, rule.Actions.Tags); err != nil {
+		return err
+	}
+	if rule.Conditions.Tags, err = tags.Resolve(h.Store, rule.Conditions.Tags); err != nil {
+		return err
+	}
+	if hours := rule.Conditions.BusinessHours; hours != nil && hours.CalendarID != "" {
+		if _, err := h.Store.GetBusinessCalendar(hours.CalendarID); err != nil {
+			return fmt.Errorf("el calendario %s no existe", hours.CalendarID)
+		}
+	}
+	if rule.Actions.CategoryID != "" {
+		if _, err := h.Store.GetCategory(rule.Actions.CategoryID); err != nil {
+			return fmt.Errorf("la categoría %s no existe", rule.Actions.CategoryID)
+		}
+	}
+	if rule.Actions.AssignTo != "" {
+		if _, err := h.Store.GetUser(rule.Actions.AssignTo); err != nil {
+			return fmt.Errorf("el usuario %s no existe", rule.Actions.AssignTo)
+		}
+	}
+	if rule.Actions.TeamID != "" {
+		if _, err := h.Store.GetTeam(rule.Actions.TeamID); err != nil {
+			return fmt.Errorf("el equipo %s no existe", rule.Actions.TeamID)
+		}
+	}
+	return nil
+}
+
+// routingHours evalúa las condiciones de horario de las reglas con los calendarios de
+// atención, festivos incluidos. Si el ticket no tiene calendario se atiende siempre.
+func routingHours(store data.DataStore) routing.Hours {
+	parsed := make(map[string]*businesshours.Calendar)
+	return func(calendarID string, in routing.Input) (bool, error) {
+		var calendar *models.BusinessCalendar
+		var err error
+		if calendarID != "" {
+			calendar, err = store.GetBusinessCalendar(calendarID)
+		} else {
+			calendar, err = businesshours.Resolve(store, in.TeamID, in.WidgetID)
+		}
+		if err != nil {
+			return false, err
+		}
+		if calendar == nil {
+			return true, nil
+		}
+
+		c, ok := parsed[calendar.ID]
+		if !ok {
+			if c, err = businesshours.New(*calendar); err != nil {
+				return false, err
+			}
+			parsed[calendar.ID] = c
+		}
+		return c.IsOpen(in.Time), nil
+	}
+}
+
+// routeTicket aplica las reglas de enrutamiento a un ticket nuevo. Si ninguna regla
+// fija la categoría se usa DEFAULT_CATEGORY_ID o, en su defecto, la primera categoría activa.
+func routeTicket(store data.DataStore, ticket *models.Ticket) {
+	rules, err := store.GetRoutingRules()
+	if err != nil {
+		logging.Warnf("⚠️ No se pudieron obtener las reglas de enrutamiento: %v", err)
+	}
+
+	result := routing.Evaluate(rules, routing.InputFromTicket(*ticket), routingHours(store))
+	actions := result.Actions
+
+	// Las reglas pueden apuntar a categorías, agentes o equipos eliminados después de crearlas
+	if actions.CategoryID != "" {
+		if _, err := store.GetCategory(actions.CategoryID); err != nil {
+			logging.Warnf("⚠️ La categoría %s de las reglas de enrutamiento no existe", actions.CategoryID)
+			actions.CategoryID = ""
+		}
+	}
+	if actions.AssignTo != "" {
+		if user, err := store.GetUser(actions.AssignTo); err != nil || !user.Active {
+			logging.Warnf("⚠️ El agente %s de las reglas de enrutamiento no existe o está inactivo", actions.AssignTo)
+			actions.AssignTo = ""
+		}
+	}
+	if actions.TeamID != "" {
+		if _, err := store.GetTeam(actions.TeamID); err != nil {
+			logging.Warnf("⚠️ El equipo %s de las reglas de enrutamiento no existe", actions.TeamID)
+			actions.TeamID = ""
+		}
+	}
+
+	routing.Apply(ticket, actions)
+	if resolved, err := tags.Resolve(store, ticket.Tags); err != nil {
+		logging.Warnf("⚠️ No se pudieron registrar las etiquetas del ticket %s: %v", ticket.ID, err)
+	} else {
+		ticket.Tags = resolved
+	}
+	resolveTeam(store, ticket)
+	for _, match := range result.Matched {
+		logging.Infof("🧭 Ticket %s enrutado por la regla %q", ticket.ID, match.RuleName)
+	}
+
+	if ticket.CategoryID == "" {
+		ticket.CategoryID = defaultCategoryID(store)
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = "medium"
+	}
+}
+
+// defaultCategoryID devuelve la categoría para tickets que ninguna regla clasifica
+func defaultCategoryID(store data.DataStore) string {
+	if id := os.Getenv("DEFAULT_CATEGORY_ID"); id != "" {
+		if _, err := store.GetCategory(id); err == nil {
+			return id
+		}
+		logging.Warnf("⚠️ DEFAULT_CATEGORY_ID=%s no corresponde a ninguna categoría", id)
+	}
+
+	categories, err := store.GetCategories()
+	if err != nil {
+		return ""
+	}
+	for _, category := range categories {
+		if category.Active {
+			logging.Warnf("⚠️ Ticket sin categoría: se usa %s (%s); configura una regla de enrutamiento o DEFAULT_CATEGORY_ID", category.Name, category.ID)
+			return category.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+)
+
+// ServiceKeyHandler contiene manejadores para administrar claves de servicio
+type ServiceKeyHandler struct {
+	Store data.DataStore
+}
+
+// isAdmin comprueba que la solicitud provenga de un usuario administrador
+func isAdmin(r *http.Request) bool {
+	role, ok := r.Context().Value(middleware.RoleKey).(string)
+	return ok && role == "admin"
+}
+
+// GetServiceKeys lista las claves de servicio (sin sus hashes)
+func (h *ServiceKeyHandler) GetServiceKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar claves de servicio", http.StatusForbidden)
+		return
+	}
+
+	keys, err := requestStore(h.Store, r).GetServiceKeys()
+	if err != nil {
+		http.Error(w, "Error al obtener claves de servicio", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range keys {
+		keys[i].KeyHash = ""
+	}
+
+	utils.WriteJSON(w, http.StatusOK, keys)
+}
+
+// CreateServiceKey crea una clave de servicio y devuelve su valor en claro una única vez
+func (h *ServiceKeyHandler) CreateServiceKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar claves de servicio", http.StatusForbidden)
+		return
+	}
+
+	var req models.ServiceKeyRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos de la clave", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" || req.Service == "" {
+		http.Error(w, "Nombre y servicio son requeridos", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		http.Error(w, "Se requiere al menos un scope", http.StatusBadRequest)
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !middleware.HasScope(middleware.ValidScopes, scope) {
+			http.Error(w, fmt.Sprintf("Scope inválido: %s", scope), http.StatusBadRequest)
+			return
+		}
+	}
+
+	actAs := req.ActAsUserID
+	if actAs == "" {
+		actAs = "widget-system"
+	}
+	if _, err := requestStore(h.Store, r).GetUser(actAs); err != nil {
+		http.Error(w, "El usuario indicado en actAsUserId no existe", http.StatusBadRequest)
+		return
+	}
+
+	plainKey, prefix, err := utils.GenerateAPIKey(utils.ServiceKeyPrefix)
+	if err != nil {
+		http.Error(w, "Error al generar la clave", http.StatusInternalServerError)
+		return
+	}
+
+	key := models.ServiceKey{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Service:     req.Service,
+		KeyPrefix:   prefix,
+		KeyHash:     utils.HashAPIKey(plainKey),
+		Scopes:      req.Scopes,
+		ActAsUserID: actAs,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := requestStore(h.Store, r).CreateServiceKey(key); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear clave de servicio: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	key.KeyHash = ""
+	utils.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"key":        plainKey,
+		"serviceKey": key,
+	})
+}
+
+// RevokeServiceKey revoca una clave de servicio
+func (h *ServiceKeyHandler) RevokeServiceKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar claves de servicio", http.StatusForbidden)
+		return
+	}
+
+	// Formato de URL: /api/service-keys/:id
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[3] == "" {
+		http.Error(w, "ID de clave inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := requestStore(h.Store, r).RevokeServiceKey(parts[3]); err != nil {
+		http.Error(w, "Clave de servicio no encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
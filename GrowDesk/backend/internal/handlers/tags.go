@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// maxBulkTagTickets limita los tickets de una operación de etiquetado masivo
+const maxBulkTagTickets = 500
+
+// TagHandler contiene manejadores para el catálogo de etiquetas y el etiquetado masivo
+type TagHandler struct {
+	Store data.DataStore
+}
+
+// GetTags lista las etiquetas con el número de tickets que las usan
+func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver las etiquetas", http.StatusForbidden)
+		return
+	}
+
+	list, err := h.tagsWithCounts()
+	if err != nil {
+		http.Error(w, "Error al obtener etiquetas", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// GetTag devuelve una etiqueta
+func (h *TagHandler) GetTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver las etiquetas", http.StatusForbidden)
+		return
+	}
+
+	tag, err := requestStore(h.Store, r).GetTag(pathID(r))
+	if err != nil {
+		http.Error(w, "Etiqueta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tag)
+}
+
+// AutocompleteTags sugiere etiquetas para ?q= (hasta ?limit=, 10 por defecto)
+func (h *TagHandler) AutocompleteTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver las etiquetas", http.StatusForbidden)
+		return
+	}
+
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			http.Error(w, "limit debe ser un número entre 1 y 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	list, err := h.tagsWithCounts()
+	if err != nil {
+		http.Error(w, "Error al obtener etiquetas", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tags.Suggest(list, r.URL.Query().Get("q"), limit))
+}
+
+// CreateTag crea una etiqueta (cualquier agente)
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para crear etiquetas", http.StatusForbidden)
+		return
+	}
+
+	var tag models.Tag
+	if err := utils.DecodeJSON(r, &tag); err != nil {
+		http.Error(w, "Error al leer datos de la etiqueta", http.StatusBadRequest)
+		return
+	}
+	if err := tags.Validate(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tag.ID = uuid.New().String()
+	tag.Name = tags.Normalize(tag.Name)
+	if tag.Color == "" {
+		tag.Color = tags.DefaultColor
+	}
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = tag.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateTag(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	logging.Infof("🏷️ Etiqueta creada: %s", tag.Name)
+	utils.WriteJSON(w, http.StatusCreated, tag)
+}
+
+// UpdateTag cambia el nombre, el color o la descripción de una etiqueta (sólo
+// administradores). Renombrarla actualiza los tickets, las reglas y las macros.
+func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar etiquetas", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetTag(pathID(r))
+	if err != nil {
+		http.Error(w, "Etiqueta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	var tag models.Tag
+	if err := utils.DecodeJSON(r, &tag); err != nil {
+		http.Error(w, "Error al leer datos de la etiqueta", http.StatusBadRequest)
+		return
+	}
+	if tag.Name == "" {
+		tag.Name = existing.Name
+	}
+	if err := tags.Validate(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tag.ID = existing.ID
+	tag.Name = tags.Normalize(tag.Name)
+	tag.CreatedAt = existing.CreatedAt
+	tag.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateTag(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if tag.Name != existing.Name {
+		if err := tags.RenameReferences(requestStore(h.Store, r), existing.Name, tag.Name); err != nil {
+			logging.Warnf("⚠️ No se pudo renombrar la etiqueta %q en reglas y macros: %v", existing.Name, err)
+		}
+		logging.Infof("🏷️ Etiqueta renombrada: %s -> %s", existing.Name, tag.Name)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tag)
+}
+
+// DeleteTag elimina una etiqueta y la quita de tickets, reglas y macros (sólo administradores)
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar etiquetas", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetTag(pathID(r))
+	if err != nil {
+		http.Error(w, "Etiqueta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteTag(existing.ID); err != nil {
+		http.Error(w, "Error al eliminar etiqueta", http.StatusInternalServerError)
+		return
+	}
+	if err := tags.RenameReferences(requestStore(h.Store, r), existing.Name, ""); err != nil {
+		logging.Warnf("⚠️ No se pudo quitar la etiqueta %q de reglas y macros: %v", existing.Name, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BulkTagTickets añade y quita etiquetas a varios tickets. Los tickets a los que no se
+// tiene acceso o que no existen se informan en "failed" sin detener el resto.
+func (h *TagHandler) BulkTagTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para etiquetar tickets", http.StatusForbidden)
+		return
+	}
+
+	var req models.BulkTagRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos del etiquetado", http.StatusBadRequest)
+		return
+	}
+	if len(req.TicketIDs) == 0 {
+		http.Error(w, "Se requiere al menos un ticket", http.StatusBadRequest)
+		return
+	}
+	if len(req.TicketIDs) > maxBulkTagTickets {
+		http.Error(w, fmt.Sprintf("No se pueden etiquetar más de %d tickets a la vez", maxBulkTagTickets), http.StatusBadRequest)
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		http.Error(w, "Indica etiquetas para añadir o quitar", http.StatusBadRequest)
+		return
+	}
+
+	add, err := tags.Resolve(requestStore(h.Store, r), req.Add)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+
+	result := models.BulkTagResult{Updated: make([]string, 0, len(req.TicketIDs)), Failed: make(map[string]string)}
+	for _, id := range req.TicketIDs {
+		ticket, err := requestStore(h.Store, r).GetTicket(id)
+		if err != nil {
+			result.Failed[id] = "Ticket no encontrado"
+			continue
+		}
+		if !scope.allows(*ticket) {
+			result.Failed[id] = "No tienes acceso a este ticket"
+			continue
+		}
+
+		ticket.Tags = tags.Remove(tags.Add(ticket.Tags, add), req.Remove)
+		ticket.UpdatedAt = time.Now()
+		if err := requestStore(h.Store, r).UpdateTicket(*ticket); err != nil {
+			result.Failed[id] = "Error al actualizar ticket"
+			continue
+		}
+		result.Updated = append(result.Updated, id)
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	activity := models.Activity{
+		UserID:      userID,
+		Type:        "tickets.bulk_tagged",
+		Description: fmt.Sprintf("Etiquetado masivo de %d tickets", len(result.Updated)),
+		Metadata: map[string]any{
+			"ticketIds": result.Updated,
+			"added":     add,
+			"removed":   req.Remove,
+		},
+	}
+	if err := requestStore(h.Store, r).CreateActivity(activity); err != nil {
+		logging.Warnf("⚠️ No se pudo registrar el etiquetado masivo: %v", err)
+	}
+
+	logging.Infof("🏷️ Etiquetado masivo: %d tickets actualizados, %d fallidos", len(result.Updated), len(result.Failed))
+	utils.WriteJSON(w, http.StatusOK, result)
+}
+
+// tagsWithCounts devuelve el catálogo con el uso de cada etiqueta
+func (h *TagHandler) tagsWithCounts() ([]models.Tag, error) {
+	catalog, err := h.Store.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := h.Store.GetTickets()
+	if err != nil {
+		return nil, err
+	}
+	return tags.WithCounts(catalog, tickets), nil
+}
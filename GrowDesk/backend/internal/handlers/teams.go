@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/teams"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// TeamHandler contiene manejadores para equipos, sus miembros y su cola de tickets
+type TeamHandler struct {
+	Store data.DataStore
+}
+
+// teamMemberRequest añade un miembro a un equipo o cambia su rol
+type teamMemberRequest struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role,omitempty"`
+}
+
+// GetTeams lista los equipos
+func (h *TeamHandler) GetTeams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los equipos", http.StatusForbidden)
+		return
+	}
+
+	list, err := requestStore(h.Store, r).GetTeams()
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// GetTeam devuelve un equipo con sus miembros
+func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los equipos", http.StatusForbidden)
+		return
+	}
+
+	team, err := requestStore(h.Store, r).GetTeam(pathID(r))
+	if err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, team)
+}
+
+// CreateTeam crea un equipo (sólo administradores)
+func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar equipos", http.StatusForbidden)
+		return
+	}
+
+	var team models.Team
+	if err := utils.DecodeJSON(r, &team); err != nil {
+		http.Error(w, "Error al leer datos del equipo", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validateTeam(&team); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team.ID = uuid.New().String()
+	team.CreatedAt = time.Now()
+	team.UpdatedAt = team.CreatedAt
+
+	if err := requestStore(h.Store, r).CreateTeam(team); err != nil {
+		http.Error(w, fmt.Sprintf("Error al crear equipo: %v", err), http.StatusConflict)
+		return
+	}
+
+	logging.Infof("👥 Equipo creado: %s (%d miembros)", team.Name, len(team.Members))
+	utils.WriteJSON(w, http.StatusCreated, team)
+}
+
+// UpdateTeam reemplaza el nombre, la descripción y los miembros de un equipo (sólo administradores)
+func (h *TeamHandler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar equipos", http.StatusForbidden)
+		return
+	}
+
+	existing, err := requestStore(h.Store, r).GetTeam(pathID(r))
+	if err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	var team models.Team
+	if err := utils.DecodeJSON(r, &team); err != nil {
+		http.Error(w, "Error al leer datos del equipo", http.StatusBadRequest)
+		return
+	}
+	if team.Members == nil {
+		team.Members = existing.Members
+	}
+
+	if err := h.validateTeam(&team); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team.ID = existing.ID
+	team.CreatedAt = existing.CreatedAt
+	team.UpdatedAt = time.Now()
+
+	if err := requestStore(h.Store, r).UpdateTeam(team); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar equipo: %v", err), http.StatusConflict)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, team)
+}
+
+// DeleteTeam elimina un equipo; sus tickets quedan sin equipo (sólo administradores)
+func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAdmin(r) {
+		http.Error(w, "Solo los administradores pueden gestionar equipos", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).DeleteTeam(pathID(r)); err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddTeamMember añade un miembro al equipo o cambia su rol. Los responsables del
+// equipo pueden añadir miembros; sólo un administrador puede nombrar responsables.
+func (h *TeamHandler) AddTeamMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	team, err := requestStore(h.Store, r).GetTeam(pathID(r))
+	if err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	var req teamMemberRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos del miembro", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.TeamRoleMember
+	}
+	if req.Role != models.TeamRoleMember && req.Role != models.TeamRoleLead {
+		http.Error(w, "Rol inválido (member o lead)", http.StatusBadRequest)
+		return
+	}
+
+	current := teams.MemberRole(*team, req.UserID)
+	if !isAdmin(r) && (!isTeamLead(r, *team) || req.Role == models.TeamRoleLead || current == models.TeamRoleLead) {
+		http.Error(w, "Solo los administradores y los responsables del equipo pueden gestionar sus miembros", http.StatusForbidden)
+		return
+	}
+
+	user, err := requestStore(h.Store, r).GetUser(req.UserID)
+	if err != nil {
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		return
+	}
+	if user.Role == "customer" {
+		http.Error(w, "Los clientes no pueden pertenecer a un equipo", http.StatusBadRequest)
+		return
+	}
+
+	if current == "" {
+		team.Members = append(team.Members, models.TeamMember{UserID: req.UserID, Role: req.Role})
+	} else {
+		for i := range team.Members {
+			if team.Members[i].UserID == req.UserID {
+				team.Members[i].Role = req.Role
+			}
+		}
+	}
+
+	if err := requestStore(h.Store, r).UpdateTeam(*team); err != nil {
+		http.Error(w, "Error al actualizar miembros del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, team)
+}
+
+// RemoveTeamMember quita un miembro del equipo. Los responsables pueden quitar
+// miembros; sólo un administrador puede quitar a un responsable.
+func (h *TeamHandler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	// Formato de URL: /api/teams/:id/members/:userId
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 6 || parts[5] == "" {
+		http.Error(w, "URL de miembro inválida", http.StatusBadRequest)
+		return
+	}
+	userID := parts[5]
+
+	team, err := requestStore(h.Store, r).GetTeam(pathID(r))
+	if err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	current := teams.MemberRole(*team, userID)
+	if current == "" {
+		http.Error(w, "El usuario no pertenece al equipo", http.StatusNotFound)
+		return
+	}
+	if !isAdmin(r) && (!isTeamLead(r, *team) || current == models.TeamRoleLead) {
+		http.Error(w, "Solo los administradores y los responsables del equipo pueden gestionar sus miembros", http.StatusForbidden)
+		return
+	}
+
+	members := make([]models.TeamMember, 0, len(team.Members))
+	for _, member := range team.Members {
+		if member.UserID != userID {
+			members = append(members, member)
+		}
+	}
+	team.Members = members
+
+	if err := requestStore(h.Store, r).UpdateTeam(*team); err != nil {
+		http.Error(w, "Error al actualizar miembros del equipo", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, team)
+}
+
+// GetTeamTickets devuelve la cola del equipo. Con ?unassigned=true sólo los tickets
+// pendientes de agente; ?status= filtra por estado.
+func (h *TeamHandler) GetTeamTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	team, err := requestStore(h.Store, r).GetTeam(pathID(r))
+	if err != nil {
+		http.Error(w, "Equipo no encontrado", http.StatusNotFound)
+		return
+	}
+
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+	if !scope.all && !scope.teams[team.ID] {
+		http.Error(w, "No perteneces a este equipo", http.StatusForbidden)
+		return
+	}
+
+	tickets, err := requestStore(h.Store, r).GetTickets()
+	if err != nil {
+		http.Error(w, "Error al obtener tickets", http.StatusInternalServerError)
+		return
+	}
+
+	unassigned := r.URL.Query().Get("unassigned") == "true"
+	status := r.URL.Query().Get("status")
+	queue := make([]models.Ticket, 0)
+	for _, ticket := range tickets {
+		if ticket.TeamID != team.ID {
+			continue
+		}
+		if unassigned && ticket.AssignedTo != "" {
+			continue
+		}
+		if status != "" && ticket.Status != status {
+			continue
+		}
+		queue = append(queue, ticket)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, queue)
+}
+
+// validateTeam comprueba el nombre y normaliza la lista de miembros
+func (h *TeamHandler) validateTeam(team *models.Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return fmt.Errorf("el nombre del equipo es obligatorio")
+	}
+	if team.CalendarID != "" {
+		if _, err := h.Store.GetBusinessCalendar(team.CalendarID); err != nil {
+			return fmt.Errorf("el calendario %s no existe", team.CalendarID)
+		}
+	}
+
+	members := make([]models.TeamMember, 0, len(team.Members))
+	seen := make(map[string]bool)
+	for _, member := range team.Members {
+		if member.Role == "" {
+			member.Role = models.TeamRoleMember
+		}
+		if member.Role != models.TeamRoleMember && member.Role != models.TeamRoleLead {
+			return fmt.Errorf("rol inválido %q para el miembro %s (member o lead)", member.Role, member.UserID)
+		}
+		if seen[member.UserID] {
+			return fmt.Errorf("el usuario %s aparece más de una vez", member.UserID)
+		}
+		user, err := h.Store.GetUser(member.UserID)
+		if err != nil {
+			return fmt.Errorf("el usuario %s no existe", member.UserID)
+		}
+		if user.Role == "customer" {
+			return fmt.Errorf("el usuario %s es un cliente y no puede pertenecer a un equipo", member.UserID)
+		}
+		seen[member.UserID] = true
+		members = append(members, member)
+	}
+	team.Members = members
+	return nil
+}
+
+// isAgent indica si la solicitud procede de un usuario del equipo de soporte (no un cliente)
+func isAgent(r *http.Request) bool {
+	role, _ := r.Context().Value(middleware.RoleKey).(string)
+	return role != "" && role != "customer"
+}
+
+// isTeamLead indica si el usuario de la solicitud es responsable del equipo
+func isTeamLead(r *http.Request, team models.Team) bool {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	return userID != "" && teams.IsLead(team, userID)
+}
+
+// ticketScope decide qué tickets puede ver y modificar quien hace la solicitud. Los
+// administradores, las claves de organización y los servicios ven todos; el resto,
+// los de sus equipos, los suyos y los que aún no tienen equipo.
+type ticketScope struct {
+	all    bool
+	userID string
+	role   string
+	teams  map[string]bool
+}
+
+func newTicketScope(store data.DataStore, r *http.Request) (*ticketScope, error) {
+	scope := &ticketScope{teams: make(map[string]bool)}
+	scope.role, _ = r.Context().Value(middleware.RoleKey).(string)
+	scope.userID, _ = r.Context().Value(middleware.UserIDKey).(string)
+
+	switch scope.role {
+	case "admin", "api", middleware.PrincipalService:
+		scope.all = true
+		return scope, nil
+	}
+
+	list, err := store.GetTeams()
+	if err != nil {
+		return nil, err
+	}
+	scope.teams = teams.TeamsOf(list, scope.userID)
+	return scope, nil
+}
+
+func (s *ticketScope) allows(ticket models.Ticket) bool {
+	if s.all {
+		return true
+	}
+	if s.userID != "" && (ticket.AssignedTo == s.userID || ticket.UserID == s.userID || ticket.CreatedBy == s.userID) {
+		return true
+	}
+	if ticket.TeamID == "" {
+		return s.role != "" && s.role != "customer"
+	}
+	return s.teams[ticket.TeamID]
+}
+
+// checkTicketAccess responde 403 si quien hace la solicitud no puede acceder al ticket
+func checkTicketAccess(store data.DataStore, w http.ResponseWriter, r *http.Request, ticket models.Ticket) bool {
+	scope, err := newTicketScope(store, r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return false
+	}
+	if !scope.allows(ticket) {
+		http.Error(w, "No tienes acceso a los tickets de este equipo", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// setTicketTeam mueve el ticket a la cola del equipo. El departamento sigue al equipo
+// para los clientes que aún lo usan.
+func setTicketTeam(ticket *models.Ticket, team models.Team) {
+	ticket.TeamID = team.ID
+	ticket.Department = team.Name
+}
+
+// resolveTeam completa el equipo de un ticket nuevo a partir de su departamento, o el
+// departamento a partir del equipo
+func resolveTeam(store data.DataStore, ticket *models.Ticket) {
+	if ticket.TeamID != "" {
+		if team, err := store.GetTeam(ticket.TeamID); err == nil && ticket.Department == "" {
+			ticket.Department = team.Name
+		}
+		return
+	}
+	if strings.TrimSpace(ticket.Department) == "" {
+		return
+	}
+	list, err := store.GetTeams()
+	if err != nil {
+		return
+	}
+	if team := teams.FindByName(list, ticket.Department); team != nil {
+		ticket.TeamID = team.ID
+	}
+}
@@ -1,19 +1,24 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/customfields"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/mentions"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/relations"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
 )
 
 // TicketHandler contiene manejadores para operaciones de tickets
@@ -33,14 +38,117 @@ func (h *TicketHandler) GetAllTickets(w http.ResponseWriter, r *http.Request) {
 	utils.SetCORS(w)
 
 	// Obtener tickets del almacén
-	tickets, err := h.Store.GetTickets()
+	tickets, err := requestStore(h.Store, r).GetTickets()
 	if err != nil {
 		http.Error(w, "Error al obtener tickets", http.StatusInternalServerError)
 		return
 	}
 
+	// Los agentes sólo ven los tickets de sus equipos; el resto de parámetros filtran
+	// el listado (ver parseTicketFilter)
+	scope, err := newTicketScope(requestStore(h.Store, r), r)
+	if err != nil {
+		http.Error(w, "Error al obtener equipos", http.StatusInternalServerError)
+		return
+	}
+	fieldDefs, err := requestStore(h.Store, r).GetCustomFields()
+	if err != nil {
+		http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+		return
+	}
+	filter, err := parseTicketFilter(fieldDefs, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hideNotes := !seesInternalNotes(r)
+	visible := make([]models.Ticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		if scope.allows(ticket) && filter.matches(ticket) {
+			if hideNotes {
+				ticket.Messages = publicMessages(ticket.Messages)
+			}
+			visible = append(visible, ticket)
+		}
+	}
+
+	// ?sort=createdAt|updatedAt|cf.<clave> y ?order=asc|desc
+	if err := customfields.SortTickets(fieldDefs, visible, r.URL.Query().Get("sort"), r.URL.Query().Get("order")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Devolver tickets como JSON
-	utils.WriteJSON(w, http.StatusOK, tickets)
+	utils.WriteJSON(w, http.StatusOK, visible)
+}
+
+// ticketFilter son los filtros del listado de tickets, que también sirven para elegir
+// los tickets de una operación masiva
+type ticketFilter struct {
+	teamID     string
+	statuses   []string
+	priorities []string
+	assignedTo string
+	categoryID string
+	tags       tags.Filter
+	fields     customfields.Filter
+}
+
+// parseTicketFilter lee ?teamId= (o "none"), ?status= y ?priority= (listas separadas por
+// comas), ?assignedTo= (o "none"), ?categoryId=, ?tags=, ?tagsAll=, ?tagsNone= y ?cf.<clave>=
+func parseTicketFilter(fieldDefs []models.CustomField, query url.Values) (ticketFilter, error) {
+	fields, err := customfields.ParseFilter(fieldDefs, query)
+	if err != nil {
+		return ticketFilter{}, err
+	}
+	return ticketFilter{
+		teamID:     query.Get("teamId"),
+		statuses:   splitQueryList(query.Get("status")),
+		priorities: splitQueryList(query.Get("priority")),
+		assignedTo: query.Get("assignedTo"),
+		categoryID: query.Get("categoryId"),
+		tags:       tags.ParseFilter(query),
+		fields:     fields,
+	}, nil
+}
+
+// matches indica si el ticket cumple todos los filtros
+func (f ticketFilter) matches(ticket models.Ticket) bool {
+	if f.teamID != "" && ticket.TeamID != f.teamID && !(f.teamID == "none" && ticket.TeamID == "") {
+		return false
+	}
+	if len(f.statuses) > 0 && !containsString(f.statuses, ticket.Status) {
+		return false
+	}
+	if len(f.priorities) > 0 && !containsString(f.priorities, ticket.Priority) {
+		return false
+	}
+	if f.assignedTo != "" && ticket.AssignedTo != f.assignedTo && !(f.assignedTo == "none" && ticket.AssignedTo == "") {
+		return false
+	}
+	if f.categoryID != "" && ticket.CategoryID != f.categoryID {
+		return false
+	}
+	return f.tags.Matches(ticket.Tags) && f.fields.Matches(ticket.CustomFields)
+}
+
+func splitQueryList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
 }
 
 // GetTicket devuelve un ticket específico por ID
@@ -62,11 +170,34 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 	ticketID := parts[len(parts)-1]
 
 	// Obtener el ticket
-	ticket, err := h.Store.GetTicket(ticketID)
+	ticket, err := requestStore(h.Store, r).GetTicket(ticketID)
 	if err != nil {
 		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
 		return
 	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	// Se responde con una copia: el almacén JSON devuelve su propio ticket
+	view := *ticket
+	ticket = &view
+	if !seesInternalNotes(r) {
+		ticket.Messages = publicMessages(ticket.Messages)
+	}
+
+	// Incluir las relaciones con otros tickets
+	if links, err := relations.Links(requestStore(h.Store, r), ticket.ID); err == nil {
+		ticket.Relations = links
+	} else {
+		logging.Warnf("⚠️ No se pudieron obtener las relaciones del ticket %s: %v", ticket.ID, err)
+	}
+	// Y sus seguidores, que sólo ven los agentes
+	if isAgent(r) {
+		if list, err := requestStore(h.Store, r).GetTicketWatchers(ticket.ID); err == nil && len(list) > 0 {
+			ticket.Watchers = list
+		}
+	}
 
 	// Devolver el ticket
 	w.Header().Set("Content-Type", "application/json")
@@ -75,11 +206,11 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 
 // CreateTicket maneja la creación de un nuevo ticket
 func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
-	fmt.Printf("🚀 HANDLER CreateTicket INICIADO - URL: %s, Método: %s\n", r.URL.Path, r.Method)
+	logging.Debugf("🚀 HANDLER CreateTicket INICIADO - URL: %s, Método: %s", r.URL.Path, r.Method)
 
 	// Esta función solo maneja solicitudes POST
 	if r.Method != http.MethodPost {
-		fmt.Printf("❌ Error: Método no permitido: %s\n", r.Method)
+		logging.Errorf("❌ Error: Método no permitido: %s", r.Method)
 		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 		return
 	}
@@ -89,9 +220,9 @@ func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 
 	// Obtener ID de usuario del contexto
 	userID := r.Context().Value(middleware.UserIDKey).(string)
-	fmt.Printf("🔑 UserID obtenido del contexto: %s\n", userID)
+	logging.Debugf("🔑 UserID obtenido del contexto: %s", userID)
 	if userID == "" {
-		fmt.Printf("❌ Error: UserID vacío\n")
+		logging.Errorf("❌ Error: UserID vacío")
 		http.Error(w, "No autorizado", http.StatusUnauthorized)
 		return
 	}
@@ -99,21 +230,74 @@ func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 	// Decodificar cuerpo de la solicitud
 	var ticketReq models.TicketRequest
 	if err := utils.DecodeJSON(r, &ticketReq); err != nil {
-		fmt.Printf("❌ Error al decodificar JSON: %v\n", err)
+		logging.Errorf("❌ Error al decodificar JSON: %v", err)
 		http.Error(w, "Error al leer datos del ticket", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("📝 Datos del ticket recibidos: Title=%s, Description=%s, CategoryID=%s, Priority=%s\n",
+	logging.Debugf("📝 Datos del ticket recibidos: Title=%s, Description=%s, CategoryID=%s, Priority=%s",
 		ticketReq.Title, ticketReq.Description, ticketReq.CategoryID, ticketReq.Priority)
 
-	// Validar campos requeridos
-	if ticketReq.Title == "" || ticketReq.Description == "" || ticketReq.CategoryID == "" {
-		fmt.Printf("❌ Error: Campos requeridos faltantes - Title=%s, Description=%s, CategoryID=%s\n",
-			ticketReq.Title, ticketReq.Description, ticketReq.CategoryID)
-		http.Error(w, "Título, descripción y categoría son requeridos", http.StatusBadRequest)
+	// Validar campos requeridos; la categoría la deciden las reglas de enrutamiento si no viene
+	if ticketReq.Title == "" || ticketReq.Description == "" {
+		logging.Errorf("❌ Error: Campos requeridos faltantes - Title=%s, Description=%s",
+			ticketReq.Title, ticketReq.Description)
+		http.Error(w, "Título y descripción son requeridos", http.StatusBadRequest)
+		return
+	}
+
+	// Creación idempotente: si el mismo cliente (p. ej. widget-api al reintentar) ya creó
+	// este ticket con el mismo ID externo, devolver el existente en lugar de duplicarlo.
+	// Si el ID externo pertenece a otro principal se responde 409 sin revelar el ticket.
+	if ticketReq.Metadata != nil {
+		ticketReq.Metadata.CreatedBy = middleware.PrincipalID(r)
+	}
+	if ticketReq.Metadata != nil && ticketReq.Metadata.ExternalID != "" {
+		if existing, err := requestStore(h.Store, r).GetTicketByExternalID(ticketReq.Metadata.ExternalID); err == nil {
+			if !createdBySamePrincipal(*existing, ticketReq.Metadata.CreatedBy, userID) {
+				logging.Warnf("ID externo %s ya usado por otro principal (%s)", ticketReq.Metadata.ExternalID, ticketReq.Metadata.CreatedBy)
+				http.Error(w, "Ya existe un ticket con este ID externo", http.StatusConflict)
+				return
+			}
+			if !checkTicketAccess(requestStore(h.Store, r), w, r, *existing) {
+				return
+			}
+			logging.Infof("♻️ Ticket ya existente para ID externo %s: %s", ticketReq.Metadata.ExternalID, existing.ID)
+			utils.WriteJSON(w, http.StatusOK, ticketView(r, *existing))
+			return
+		}
+	}
+
+	// Validar los campos personalizados del ticket y del contacto. En los tickets del
+	// widget sólo se exigen los obligatorios que se piden en el formulario pre-chat.
+	fieldDefs, err := requestStore(h.Store, r).GetCustomFields()
+	if err != nil {
+		http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+		return
+	}
+	customFields, err := customfields.Normalize(fieldDefs, models.CustomFieldEntityTicket, ticketReq.CustomFields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	contactFields, err := customfields.Normalize(fieldDefs, models.CustomFieldEntityContact, ticketReq.ContactFields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	customFields = customfields.Merge(nil, customFields)
+	contactFields = customfields.Merge(nil, contactFields)
+	fromWidget := ticketReq.Metadata != nil && ticketReq.Metadata.Source == "widget"
+	if err := customfields.CheckRequired(fieldDefs, models.CustomFieldEntityTicket, customFields, fromWidget); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fromWidget {
+		if err := customfields.CheckRequired(fieldDefs, models.CustomFieldEntityContact, contactFields, true); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Crear mensaje inicial
 	initialMessage := models.Message{
@@ -125,38 +309,87 @@ func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 		Timestamp: time.Now(),
 		CreatedAt: time.Now(),
 	}
-	fmt.Printf("💬 Mensaje inicial creado: ID=%s, Content=%s\n", initialMessage.ID, initialMessage.Content)
+	logging.Debugf("💬 Mensaje inicial creado: ID=%s, Content=%s", initialMessage.ID, initialMessage.Content)
 
 	// Crear nuevo ticket
 	newTicket := models.Ticket{
-		ID:          fmt.Sprintf("TICKET-%s", time.Now().Format("20060102-150405")),
-		Title:       ticketReq.Title,
-		Description: ticketReq.Description,
-		CategoryID:  ticketReq.CategoryID,
-		Status:      "open",
-		Priority:    ticketReq.Priority,
-		UserID:      userID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		Messages:    []models.Message{initialMessage},
-		Metadata:    ticketReq.Metadata,
-	}
-	fmt.Printf("🎫 Ticket creado: ID=%s, Title=%s\n", newTicket.ID, newTicket.Title)
+		ID:           newTicketID(requestStore(h.Store, r), time.Now()),
+		Title:        ticketReq.Title,
+		Description:  ticketReq.Description,
+		CategoryID:   ticketReq.CategoryID,
+		Status:       "open",
+		Priority:     ticketReq.Priority,
+		UserID:       userID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		Messages:     []models.Message{initialMessage},
+		Metadata:     ticketReq.Metadata,
+		Customer:     models.Customer{Name: ticketReq.UserName, Email: ticketReq.UserEmail},
+		Tags:         ticketReq.Tags,
+		CustomFields: customFields,
+	}
+	if meta := ticketReq.Metadata; meta != nil {
+		newTicket.Source = meta.Source
+		newTicket.WidgetID = meta.WidgetID
+		newTicket.Department = meta.Department
+		if meta.ClientName != "" {
+			newTicket.Customer.Name = meta.ClientName
+		}
+		if meta.ClientEmail != "" {
+			newTicket.Customer.Email = meta.ClientEmail
+		}
+	}
+
+	// Completar categoría, departamento, prioridad, etiquetas y asignación según las reglas
+	routeTicket(requestStore(h.Store, r), &newTicket)
+	autoAssignTicket(requestStore(h.Store, r), &newTicket)
+	outOfHoursReply(requestStore(h.Store, r), &newTicket)
+	logging.Infof("🎫 Ticket creado: ID=%s, Title=%s, CategoryID=%s", newTicket.ID, newTicket.Title, newTicket.CategoryID)
 
 	// Agregar ticket al almacén
-	fmt.Printf("💾 Intentando guardar ticket en el almacén...\n")
-	if err := h.Store.CreateTicket(newTicket); err != nil {
-		fmt.Printf("❌ Error al crear ticket en el almacén: %v\n", err)
+	logging.Debugf("💾 Intentando guardar ticket en el almacén...")
+	if err := requestStore(h.Store, r).CreateTicket(newTicket); err != nil {
+		logging.Errorf("❌ Error al crear ticket en el almacén: %v", err)
 		http.Error(w, fmt.Sprintf("Error al crear ticket: %v", err), http.StatusInternalServerError)
 		return
 	}
-	fmt.Printf("✅ Ticket guardado exitosamente en el almacén\n")
+	logging.Infof("✅ Ticket guardado exitosamente en el almacén")
+
+	// Registrar el contacto del cliente con los campos que vienen con el ticket
+	if newTicket.Customer.Email != "" {
+		if err := h.saveContact(newTicket.Customer, contactFields); err != nil {
+			logging.Warnf("⚠️ No se pudo guardar el contacto %s: %v", newTicket.Customer.Email, err)
+		}
+	}
 
 	// Devolver ticket creado
-	fmt.Printf("📤 Enviando respuesta exitosa\n")
+	logging.Debugf("📤 Enviando respuesta exitosa")
 	utils.WriteJSON(w, http.StatusCreated, newTicket)
 }
 
+// createdBySamePrincipal indica si ticket lo creó el principal de la solicitud. Los tickets
+// anteriores a CreatedBy se comparan por el usuario con el que se crearon.
+func createdBySamePrincipal(ticket models.Ticket, principal, userID string) bool {
+	if ticket.Metadata != nil && ticket.Metadata.CreatedBy != "" {
+		return ticket.Metadata.CreatedBy == principal
+	}
+	return userID != "" && ticket.UserID == userID
+}
+
+// saveContact crea o actualiza el contacto del cliente con los campos recibidos
+func (h *TicketHandler) saveContact(customer models.Customer, fields map[string]interface{}) error {
+	contact := models.Contact{Email: customer.Email, Name: customer.Name, CreatedAt: time.Now()}
+	if existing, err := h.Store.GetContact(customer.Email); err == nil {
+		contact = *existing
+		if customer.Name != "" {
+			contact.Name = customer.Name
+		}
+	}
+	contact.CustomFields = customfields.Merge(contact.CustomFields, fields)
+	contact.UpdatedAt = time.Now()
+	return h.Store.SaveContact(contact)
+}
+
 // UpdateTicket maneja la actualización de un ticket existente
 func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	// Solo maneja solicitudes PUT
@@ -179,11 +412,14 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	ticketID := segments[3]
 
 	// Obtener el ticket existente
-	ticket, err := h.Store.GetTicket(ticketID)
+	ticket, err := requestStore(h.Store, r).GetTicket(ticketID)
 	if err != nil {
 		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
 		return
 	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
 
 	// Decodificar cuerpo de la solicitud
 	var updates models.TicketUpdateRequest
@@ -193,6 +429,7 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Actualizar los campos del ticket
+	previousStatus := ticket.Status
 	if updates.Status != "" {
 		ticket.Status = updates.Status
 	}
@@ -205,24 +442,70 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	if updates.Category != "" {
 		ticket.Category = updates.Category
 	}
-	if updates.Department != "" {
+	if updates.TeamID != "" {
+		team, err := requestStore(h.Store, r).GetTeam(updates.TeamID)
+		if err != nil {
+			http.Error(w, "Equipo no encontrado", http.StatusBadRequest)
+			return
+		}
+		setTicketTeam(ticket, *team)
+	} else if updates.Department != "" && updates.Department != ticket.Department {
+		// Cambiar el departamento mueve el ticket al equipo con ese nombre, si existe
 		ticket.Department = updates.Department
+		ticket.TeamID = ""
+		resolveTeam(requestStore(h.Store, r), ticket)
 	}
 	if updates.Subject != "" {
 		ticket.Subject = updates.Subject
 	}
+	if updates.Tags != nil {
+		resolved, err := tags.Resolve(requestStore(h.Store, r), updates.Tags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ticket.Tags = resolved
+	}
+	if updates.CustomFields != nil {
+		fieldDefs, err := requestStore(h.Store, r).GetCustomFields()
+		if err != nil {
+			http.Error(w, "Error al obtener campos personalizados", http.StatusInternalServerError)
+			return
+		}
+		changes, err := customfields.Normalize(fieldDefs, models.CustomFieldEntityTicket, updates.CustomFields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		merged := customfields.Merge(ticket.CustomFields, changes)
+		if err := customfields.CheckRequired(fieldDefs, models.CustomFieldEntityTicket, merged, ticket.Source == "widget"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ticket.CustomFields = merged
+	}
 
 	// Actualizar timestamp
 	ticket.UpdatedAt = time.Now()
 
 	// Guardar en el almacén
-	if err := h.Store.UpdateTicket(*ticket); err != nil {
+	if err := requestStore(h.Store, r).UpdateTicket(*ticket); err != nil {
 		http.Error(w, "Error al actualizar ticket", http.StatusInternalServerError)
 		return
 	}
 
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	onStatusChange(requestStore(h.Store, r), *ticket, previousStatus, userID)
+
+	// Resolver o cerrar un ticket padre se propaga a sus hijos abiertos
+	closing := ticket.Status == "resolved" || ticket.Status == "closed"
+	if closing && previousStatus != "resolved" && previousStatus != "closed" &&
+		(updates.CascadeChildren == nil || *updates.CascadeChildren) {
+		h.cascadeToChildren(*ticket, strings.TrimSpace(updates.CascadeMessage), userID)
+	}
+
 	// Devolver ticket actualizado
-	utils.WriteJSON(w, http.StatusOK, ticket)
+	utils.WriteJSON(w, http.StatusOK, ticketView(r, *ticket))
 }
 
 // GetTicketMessages devuelve mensajes para un ticket específico
@@ -245,21 +528,30 @@ func (h *TicketHandler) GetTicketMessages(w http.ResponseWriter, r *http.Request
 	ticketID := parts[len(parts)-2]
 
 	// Obtener el ticket
-	ticket, err := h.Store.GetTicket(ticketID)
+	ticket, err := requestStore(h.Store, r).GetTicket(ticketID)
 	if err != nil {
 		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
 		return
 	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	// Las notas internas no salen hacia los canales del cliente
+	messages := ticket.Messages
+	if !seesInternalNotes(r) {
+		messages = publicMessages(messages)
+	}
 
 	// Devolver los mensajes
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ticket.Messages)
+	json.NewEncoder(w).Encode(messages)
 }
 
 // AddTicketMessage añade un nuevo mensaje a un ticket existente
 func (h *TicketHandler) AddTicketMessage(w http.ResponseWriter, r *http.Request) {
 	// DEBUG: Log al inicio del handler
-	fmt.Printf("🚀 HANDLER AddTicketMessage INICIADO - URL: %s, Método: %s\n", r.URL.Path, r.Method)
+	logging.Debugf("🚀 HANDLER AddTicketMessage INICIADO - URL: %s, Método: %s", r.URL.Path, r.Method)
 
 	// Solo maneja solicitudes POST
 	if r.Method != http.MethodPost {
@@ -278,6 +570,15 @@ func (h *TicketHandler) AddTicketMessage(w http.ResponseWriter, r *http.Request)
 	// Obtener el ID desde la URL (asumiendo formato /tickets/ID/messages)
 	ticketID := parts[len(parts)-2]
 
+	// Los mensajes a un ticket fusionado van al ticket en el que se fusionó
+	ticket, err := followMerged(requestStore(h.Store, r), ticketID)
+	if err == nil {
+		if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+			return
+		}
+		ticketID = ticket.ID
+	}
+
 	// Parsear el cuerpo de la solicitud
 	var messageReq models.NewMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&messageReq); err != nil {
@@ -298,31 +599,62 @@ func (h *TicketHandler) AddTicketMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Sólo los agentes escriben notas internas, y nunca como cliente
+	if messageReq.IsInternal {
+		if !seesInternalNotes(r) {
+			http.Error(w, "Solo los agentes pueden añadir notas internas", http.StatusForbidden)
+			return
+		}
+		if messageReq.IsClient {
+			http.Error(w, "Una nota interna no puede ser un mensaje del cliente", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Crear nuevo mensaje
 	message := models.Message{
-		ID:        utils.GenerateMessageID(),
-		Content:   content, // Usar el contenido determinado
-		IsClient:  messageReq.IsClient,
-		Timestamp: time.Now(),
-		CreatedAt: time.Now(),
-		UserName:  messageReq.UserName,
-		UserEmail: messageReq.UserEmail,
+		ID:         utils.GenerateMessageID(),
+		Content:    content, // Usar el contenido determinado
+		IsClient:   messageReq.IsClient,
+		IsInternal: messageReq.IsInternal,
+		Timestamp:  time.Now(),
+		CreatedAt:  time.Now(),
+		UserName:   messageReq.UserName,
+		UserEmail:  messageReq.UserEmail,
+	}
+	if !message.IsClient && seesInternalNotes(r) {
+		// Los mensajes de agentes quedan a nombre de quien los envía y avisan a los mencionados
+		if userID, _ := r.Context().Value(middleware.UserIDKey).(string); userID != "" && !middleware.IsServicePrincipal(r) {
+			message.UserID = userID
+			if user, err := requestStore(h.Store, r).GetUser(userID); err == nil && message.UserName == "" {
+				message.UserName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+			}
+		}
+		if err := mentions.Resolve(requestStore(h.Store, r), &message); err != nil {
+			logging.Warnf("⚠️ No se pudieron resolver las menciones: %v", err)
+		}
 	}
 
 	// Agregar mensaje al ticket
-	if err := h.Store.AddTicketMessage(ticketID, message); err != nil {
+	if err := requestStore(h.Store, r).AddTicketMessage(ticketID, message); err != nil {
 		http.Error(w, "Failed to add message: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// DEBUG: Log antes de BroadcastMessage
-	fmt.Printf("🔄 LLAMANDO A BroadcastMessage para ticket %s con mensaje: %s\n", ticketID, message.Content)
+	logging.Debugf("🔄 LLAMANDO A BroadcastMessage para ticket %s con mensaje: %s", ticketID, message.Content)
 
 	// Broadcast a los clientes WebSocket
-	h.Store.BroadcastMessage(ticketID, message)
+	requestStore(h.Store, r).BroadcastMessage(ticketID, message)
+	if len(message.Mentions) > 0 && ticket != nil {
+		mentions.Notify(requestStore(h.Store, r), *ticket, message)
+	}
+	if ticket != nil {
+		go watchers.Notify(requestStore(h.Store, r), *ticket, watchers.MessageEvent(message))
+	}
 
 	// DEBUG: Log después de BroadcastMessage
-	fmt.Printf("✅ BroadcastMessage completado para ticket %s\n", ticketID)
+	logging.Debugf("✅ BroadcastMessage completado para ticket %s", ticketID)
 
 	// Devolver respuesta de éxito
 	response := struct {
@@ -342,12 +674,12 @@ func (h *TicketHandler) AddTicketMessage(w http.ResponseWriter, r *http.Request)
 
 // CreateWidgetTicket maneja la creación de tickets desde el widget de soporte
 func (h *TicketHandler) AssignTicket(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("=== INICIANDO ASIGNACIÓN DE TICKET ===")
-	fmt.Printf("URL: %s, Método: %s\n", r.URL.Path, r.Method)
+	logging.Debugf("=== INICIANDO ASIGNACIÓN DE TICKET ===")
+	logging.Debugf("URL: %s, Método: %s", r.URL.Path, r.Method)
 
 	// Solo maneja solicitudes POST
 	if r.Method != http.MethodPost {
-		fmt.Println("Error: Método no permitido")
+		logging.Errorf("Error: Método no permitido")
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -358,7 +690,7 @@ func (h *TicketHandler) AssignTicket(w http.ResponseWriter, r *http.Request) {
 	// Extraer el ID del ticket desde la URL
 	// Formato de URL: /api/tickets/:id/assign
 	parts := strings.Split(r.URL.Path, "/")
-	fmt.Printf("Partes de la URL: %v\n", parts)
+	logging.Debugf("Partes de la URL: %v", parts)
 
 	// Buscar el índice de "tickets" en la URL
 	ticketIndex := -1
@@ -370,16 +702,16 @@ func (h *TicketHandler) AssignTicket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if ticketIndex == -1 || ticketIndex+1 >= len(parts) {
-		fmt.Println("Error: No se pudo encontrar el patrón 'tickets/:id' en la URL")
+		logging.Errorf("Error: No se pudo encontrar el patrón 'tickets/:id' en la URL")
 		http.Error(w, "URL mal formada", http.StatusBadRequest)
 		return
 	}
 
 	ticketID := parts[ticketIndex+1]
-	fmt.Printf("ID del ticket extraído: %s\n", ticketID)
+	logging.Debugf("ID del ticket extraído: %s", ticketID)
 
 	if ticketID == "" || ticketID == "assign" {
-		fmt.Println("Error: ID de ticket inválido")
+		logging.Errorf("Error: ID de ticket inválido")
 		http.Error(w, "ID de ticket inválido", http.StatusBadRequest)
 		return
 	}
@@ -390,74 +722,76 @@ func (h *TicketHandler) AssignTicket(w http.ResponseWriter, r *http.Request) {
 		Status     string `json:"status,omitempty"`
 	}
 
-	// Log del cuerpo de la solicitud
-	body, _ := io.ReadAll(r.Body)
-	r.Body = io.NopCloser(bytes.NewBuffer(body))
-	fmt.Printf("Cuerpo de la solicitud: %s\n", string(body))
-
 	if err := json.NewDecoder(r.Body).Decode(&assignReq); err != nil {
-		fmt.Printf("Error al decodificar el cuerpo: %v\n", err)
+		logging.Errorf("Error al decodificar el cuerpo: %v", err)
 		http.Error(w, "El cuerpo de la solicitud es inválido", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("Datos decodificados: assignedTo=%s, status=%s\n", assignReq.AssignedTo, assignReq.Status)
+	logging.Debugf("Datos decodificados: assignedTo=%s, status=%s", assignReq.AssignedTo, assignReq.Status)
 
 	// Validar que se proporcionó el ID del usuario asignado
 	if assignReq.AssignedTo == "" {
-		fmt.Println("Error: No se proporcionó ID de usuario para asignar")
+		logging.Errorf("Error: No se proporcionó ID de usuario para asignar")
 		http.Error(w, "Se requiere el ID del usuario asignado", http.StatusBadRequest)
 		return
 	}
 
 	// Verificar que el usuario existe
-	fmt.Printf("Buscando usuario con ID: %s\n", assignReq.AssignedTo)
-	user, err := h.Store.GetUser(assignReq.AssignedTo)
+	logging.Debugf("Buscando usuario con ID: %s", assignReq.AssignedTo)
+	user, err := requestStore(h.Store, r).GetUser(assignReq.AssignedTo)
 	if err != nil {
-		fmt.Printf("Error al buscar usuario: %v\n", err)
+		logging.Errorf("Error al buscar usuario: %v", err)
 		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
 		return
 	}
-	fmt.Printf("Usuario encontrado: %s %s (ID: %s)\n", user.FirstName, user.LastName, user.ID)
+	logging.Debugf("Usuario encontrado: %s %s (ID: %s)", user.FirstName, user.LastName, user.ID)
 
 	// Obtener el ticket existente
-	fmt.Printf("Buscando ticket con ID: %s\n", ticketID)
-	ticket, err := h.Store.GetTicket(ticketID)
+	logging.Debugf("Buscando ticket con ID: %s", ticketID)
+	ticket, err := requestStore(h.Store, r).GetTicket(ticketID)
 	if err != nil {
-		fmt.Printf("Error al buscar ticket: %v\n", err)
+		logging.Errorf("Error al buscar ticket: %v", err)
 		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
 		return
 	}
-	fmt.Printf("Ticket encontrado: %s (ID: %s)\n", ticket.Title, ticket.ID)
+	logging.Debugf("Ticket encontrado: %s (ID: %s)", ticket.Title, ticket.ID)
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
 
 	// Actualizar el ticket con la asignación
-	fmt.Printf("Actualizando ticket: Asignando de '%s' a '%s'\n", ticket.AssignedTo, assignReq.AssignedTo)
+	logging.Debugf("Actualizando ticket: Asignando de '%s' a '%s'", ticket.AssignedTo, assignReq.AssignedTo)
 	ticket.AssignedTo = assignReq.AssignedTo
 
 	// Si se proporciona un nuevo estado, actualizarlo; si no, usar "assigned"
+	previousStatus := ticket.Status
 	if assignReq.Status != "" {
 		ticket.Status = assignReq.Status
 	} else {
 		ticket.Status = "assigned"
 	}
-	fmt.Printf("Estado del ticket actualizado a: %s\n", ticket.Status)
+	logging.Infof("Estado del ticket actualizado a: %s", ticket.Status)
 
 	ticket.UpdatedAt = time.Now()
 
 	// Guardar en el almacén
-	fmt.Printf("Guardando ticket actualizado en la base de datos...\n")
-	if err := h.Store.UpdateTicket(*ticket); err != nil {
-		fmt.Printf("Error al actualizar ticket en base de datos: %v\n", err)
+	logging.Debugf("Guardando ticket actualizado en la base de datos...")
+	if err := requestStore(h.Store, r).UpdateTicket(*ticket); err != nil {
+		logging.Errorf("Error al actualizar ticket en base de datos: %v", err)
 		http.Error(w, "Error al asignar ticket", http.StatusInternalServerError)
 		return
 	}
-	fmt.Println("Ticket actualizado exitosamente en la base de datos")
+	logging.Infof("Ticket actualizado exitosamente en la base de datos")
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	onStatusChange(requestStore(h.Store, r), *ticket, previousStatus, userID)
 
 	// Devolver ticket actualizado
 	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Ticket asignado correctamente",
-		"ticket":  ticket,
+		"ticket":  ticketView(r, *ticket),
 	})
-	fmt.Println("=== ASIGNACIÓN DE TICKET COMPLETADA ===")
+	logging.Debugf("=== ASIGNACIÓN DE TICKET COMPLETADA ===")
 }
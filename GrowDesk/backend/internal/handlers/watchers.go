@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/notify"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// GetTicketWatchers maneja GET /api/tickets/:id/watchers
+func (h *TicketHandler) GetTicketWatchers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para ver los seguidores", http.StatusForbidden)
+		return
+	}
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	list, err := requestStore(h.Store, r).GetTicketWatchers(ticket.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener seguidores", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, list)
+}
+
+// AddTicketWatcher maneja POST /api/tickets/:id/watchers. Sin userId ni email, quien
+// hace la solicitud empieza a seguir el ticket; con userId agrega a otro agente y con
+// email pone en copia a un contacto del cliente.
+func (h *TicketHandler) AddTicketWatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !isAgent(r) || userID == "" || middleware.IsServicePrincipal(r) {
+		http.Error(w, "No tienes permiso para seguir tickets", http.StatusForbidden)
+		return
+	}
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return
+	}
+
+	var req models.TicketWatcherRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		http.Error(w, "Error al leer datos del seguidor", http.StatusBadRequest)
+		return
+	}
+
+	watcher := models.TicketWatcher{TicketID: ticket.ID, AddedBy: userID, CreatedAt: time.Now()}
+	switch {
+	case req.UserID != "" && req.Email != "":
+		http.Error(w, "Indica userId o email, no ambos", http.StatusBadRequest)
+		return
+	case req.Email != "":
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+		if err := notify.ValidateEmail(email); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		watcher.Email = email
+	default:
+		watcher.UserID = userID
+		if req.UserID != "" {
+			watcher.UserID = req.UserID
+		}
+		user, err := requestStore(h.Store, r).GetUser(watcher.UserID)
+		if err != nil {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		if user.Role == "customer" {
+			http.Error(w, "Sólo los agentes pueden seguir tickets; los clientes se agregan en copia por email", http.StatusBadRequest)
+			return
+		}
+	}
+
+	watcher.Preferences = watchers.DefaultPreferences(watcher.UserID != "")
+	if req.Preferences != nil {
+		watcher.Preferences = *req.Preferences
+	}
+	watchers.Normalize(&watcher)
+	if err := watchers.ValidatePreferences(watcher.Preferences); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := requestStore(h.Store, r).AddTicketWatcher(watcher); err != nil {
+		if strings.Contains(err.Error(), "ya sigue") {
+			http.Error(w, "Ya sigue este ticket", http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error al agregar seguidor: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// El ID lo asigna el almacén; se recupera para devolver el seguidor completo
+	created := watcher
+	if list, err := requestStore(h.Store, r).GetTicketWatchers(ticket.ID); err == nil {
+		for _, existing := range list {
+			if existing.UserID == watcher.UserID && strings.EqualFold(existing.Email, watcher.Email) {
+				created = existing
+			}
+		}
+	}
+
+	who := watcher.UserID
+	if who == "" {
+		who = watcher.Email
+	}
+	requestStore(h.Store, r).CreateActivity(models.Activity{
+		UserID:      userID,
+		Type:        "ticket.watcher_added",
+		TargetID:    ticket.ID,
+		Description: fmt.Sprintf("%s sigue el ticket", who),
+		Metadata:    map[string]interface{}{"watcherId": created.ID, "userId": watcher.UserID, "email": watcher.Email},
+	})
+
+	logging.Infof("👀 %s sigue el ticket %s", who, ticket.ID)
+	utils.WriteJSON(w, http.StatusCreated, created)
+}
+
+// UpdateTicketWatcher maneja PUT /api/tickets/:id/watchers/:watcherId. Cada agente
+// cambia sus propias preferencias; las de otros sólo un administrador. Las de los
+// contactos en copia, cualquier agente con acceso al ticket.
+func (h *TicketHandler) UpdateTicketWatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	watcher, ok := h.findWatcher(w, r)
+	if !ok {
+		return
+	}
+	if !h.canManageWatcher(r, *watcher, false) {
+		http.Error(w, "No puedes cambiar las preferencias de este seguidor", http.StatusForbidden)
+		return
+	}
+
+	var preferences models.WatcherPreferences
+	if err := utils.DecodeJSON(r, &preferences); err != nil {
+		http.Error(w, "Error al leer las preferencias", http.StatusBadRequest)
+		return
+	}
+	watcher.Preferences = preferences
+	watchers.Normalize(watcher)
+	if err := watchers.ValidatePreferences(watcher.Preferences); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := requestStore(h.Store, r).UpdateTicketWatcher(*watcher); err != nil {
+		http.Error(w, fmt.Sprintf("Error al actualizar seguidor: %v", err), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, watcher)
+}
+
+// RemoveTicketWatcher maneja DELETE /api/tickets/:id/watchers/:watcherId. Cada agente
+// puede dejar de seguir; a otros los quita un administrador o quien los agregó.
+func (h *TicketHandler) RemoveTicketWatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	watcher, ok := h.findWatcher(w, r)
+	if !ok {
+		return
+	}
+	if !h.canManageWatcher(r, *watcher, true) {
+		http.Error(w, "No puedes quitar a este seguidor", http.StatusForbidden)
+		return
+	}
+
+	if err := requestStore(h.Store, r).RemoveTicketWatcher(watcher.ID); err != nil {
+		http.Error(w, fmt.Sprintf("Error al quitar seguidor: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	who := watcher.UserID
+	if who == "" {
+		who = watcher.Email
+	}
+	requestStore(h.Store, r).CreateActivity(models.Activity{
+		UserID:      userID,
+		Type:        "ticket.watcher_removed",
+		TargetID:    watcher.TicketID,
+		Description: fmt.Sprintf("%s dejó de seguir el ticket", who),
+		Metadata:    map[string]interface{}{"watcherId": watcher.ID, "userId": watcher.UserID, "email": watcher.Email},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWatchedTickets maneja GET /api/watching: los tickets que sigue el usuario autenticado
+func (h *TicketHandler) GetWatchedTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	utils.SetCORS(w)
+
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	if !isAgent(r) || userID == "" {
+		http.Error(w, "No autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := requestStore(h.Store, r).GetWatchedTickets(userID)
+	if err != nil {
+		http.Error(w, "Error al obtener tickets seguidos", http.StatusInternalServerError)
+		return
+	}
+
+	watched := make([]models.WatchedTicket, 0, len(list))
+	for _, watcher := range list {
+		ticket, err := requestStore(h.Store, r).GetTicket(watcher.TicketID)
+		if err != nil {
+			continue
+		}
+		watched = append(watched, models.WatchedTicket{Watcher: watcher, Ticket: *ticket})
+	}
+	utils.WriteJSON(w, http.StatusOK, watched)
+}
+
+// findWatcher obtiene el seguidor de /api/tickets/:id/watchers/:watcherId comprobando
+// el acceso al ticket
+func (h *TicketHandler) findWatcher(w http.ResponseWriter, r *http.Request) (*models.TicketWatcher, bool) {
+	if !isAgent(r) {
+		http.Error(w, "No tienes permiso para gestionar seguidores", http.StatusForbidden)
+		return nil, false
+	}
+
+	ticket, err := requestStore(h.Store, r).GetTicket(pathID(r))
+	if err != nil {
+		http.Error(w, "Ticket no encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	if !checkTicketAccess(requestStore(h.Store, r), w, r, *ticket) {
+		return nil, false
+	}
+
+	watcherID := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")[5]
+	list, err := requestStore(h.Store, r).GetTicketWatchers(ticket.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener seguidores", http.StatusInternalServerError)
+		return nil, false
+	}
+	for _, watcher := range list {
+		if watcher.ID == watcherID {
+			return &watcher, true
+		}
+	}
+	http.Error(w, "Seguidor no encontrado", http.StatusNotFound)
+	return nil, false
+}
+
+// canManageWatcher indica si quien hace la solicitud puede modificar al seguidor. Los
+// contactos en copia los gestiona cualquier agente; a los agentes, ellos mismos, un
+// administrador o, para quitarlos, quien los agregó.
+func (h *TicketHandler) canManageWatcher(r *http.Request, watcher models.TicketWatcher, removing bool) bool {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	switch {
+	case isAdmin(r):
+		return true
+	case watcher.UserID == "":
+		return true
+	case userID != "" && watcher.UserID == userID:
+		return true
+	case removing && userID != "" && watcher.AddedBy == userID:
+		return true
+	}
+	return false
+}
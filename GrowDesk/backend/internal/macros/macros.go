@@ -0,0 +1,160 @@
+// Package macros prepara y aplica las macros de los agentes: una respuesta guardada con
+// marcadores ({{customer.name}}, {{ticket.id}}, {{agent.firstName}}...) y un conjunto de
+// cambios sobre el ticket (estado, prioridad, categoría, asignación y etiquetas).
+package macros
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/tags"
+)
+
+// AssignToMe asigna el ticket a quien aplica la macro
+const AssignToMe = "me"
+
+// placeholderPattern reconoce {{ nombre.campo }} con espacios opcionales
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z]+\.[a-zA-Z]+)\s*\}\}`)
+
+// placeholders son los valores que admite una respuesta
+var placeholders = map[string]func(ticket models.Ticket, agent models.User) string{
+	"customer.name":  func(t models.Ticket, _ models.User) string { return t.Customer.Name },
+	"customer.email": func(t models.Ticket, _ models.User) string { return t.Customer.Email },
+	"ticket.id":      func(t models.Ticket, _ models.User) string { return t.ID },
+	"ticket.title":   func(t models.Ticket, _ models.User) string { return t.Title },
+	"ticket.subject": func(t models.Ticket, _ models.User) string {
+		if t.Subject != "" {
+			return t.Subject
+		}
+		return t.Title
+	},
+	"ticket.status":   func(t models.Ticket, _ models.User) string { return t.Status },
+	"ticket.priority": func(t models.Ticket, _ models.User) string { return t.Priority },
+	"agent.firstName": func(_ models.Ticket, a models.User) string { return a.FirstName },
+	"agent.lastName":  func(_ models.Ticket, a models.User) string { return a.LastName },
+	"agent.name": func(_ models.Ticket, a models.User) string {
+		return strings.TrimSpace(a.FirstName + " " + a.LastName)
+	},
+	"agent.email": func(_ models.Ticket, a models.User) string { return a.Email },
+}
+
+// Render sustituye los marcadores de la respuesta con los datos del ticket y del agente.
+// Los marcadores desconocidos se dejan tal cual.
+func Render(template string, ticket models.Ticket, agent models.User) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		key := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := placeholders[key]; ok {
+			return value(ticket, agent)
+		}
+		return match
+	})
+}
+
+// ValidateTemplate rechaza las respuestas con marcadores que no existen
+func ValidateTemplate(template string) error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := placeholders[match[1]]; !ok {
+			return fmt.Errorf("marcador desconocido {{%s}}", match[1])
+		}
+	}
+	return nil
+}
+
+// Validate comprueba que una macro esté bien formada
+func Validate(macro models.Macro) error {
+	if strings.TrimSpace(macro.Name) == "" {
+		return fmt.Errorf("el nombre de la macro es obligatorio")
+	}
+	switch macro.Scope {
+	case models.MacroScopePersonal, models.MacroScopeShared:
+	default:
+		return fmt.Errorf("ámbito inválido %q, se esperaba personal o shared", macro.Scope)
+	}
+	if err := ValidateTemplate(macro.Reply); err != nil {
+		return err
+	}
+	a := macro.Actions
+	switch a.Status {
+	case "", "open", "assigned", "in_progress", "pending", "resolved", "closed":
+	default:
+		return fmt.Errorf("estado inválido %q", a.Status)
+	}
+	switch a.Priority {
+	case "", "low", "medium", "high", "urgent":
+	default:
+		return fmt.Errorf("prioridad inválida %q", a.Priority)
+	}
+	if strings.TrimSpace(macro.Reply) == "" && a.Status == "" && a.Priority == "" && a.CategoryID == "" && a.AssignTo == "" && len(a.AddTags) == 0 && len(a.RemoveTags) == 0 {
+		return fmt.Errorf("la macro debe definir una respuesta o al menos una acción")
+	}
+	return nil
+}
+
+// Apply aplica las acciones de la macro sobre el ticket y devuelve la lista de cambios.
+// No guarda nada: si la categoría o el agente no existen devuelve error sin haber
+// modificado el ticket, para que la macro se aplique entera o no se aplique.
+func Apply(store data.DataStore, ticket *models.Ticket, macro models.Macro, agent models.User) ([]string, error) {
+	a := macro.Actions
+	updated := *ticket
+	updated.Tags = append([]string(nil), ticket.Tags...)
+	changes := make([]string, 0)
+
+	if a.Status != "" && a.Status != updated.Status {
+		updated.Status = a.Status
+		changes = append(changes, fmt.Sprintf("estado: %s", a.Status))
+	}
+	if a.Priority != "" && a.Priority != updated.Priority {
+		updated.Priority = a.Priority
+		changes = append(changes, fmt.Sprintf("prioridad: %s", a.Priority))
+	}
+	if a.CategoryID != "" && a.CategoryID != updated.CategoryID {
+		category, err := store.GetCategory(a.CategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("la categoría %s de la macro no existe", a.CategoryID)
+		}
+		updated.CategoryID = category.ID
+		updated.Category = category.Name
+		changes = append(changes, fmt.Sprintf("categoría: %s", category.Name))
+	}
+	if a.AssignTo != "" {
+		assignee := a.AssignTo
+		if assignee == AssignToMe {
+			assignee = agent.ID
+		}
+		if assignee != updated.AssignedTo {
+			user, err := store.GetUser(assignee)
+			if err != nil || user.Role == "customer" {
+				return nil, fmt.Errorf("el agente %s de la macro no existe", assignee)
+			}
+			updated.AssignedTo = user.ID
+			if a.Status == "" && updated.Status == "open" {
+				updated.Status = "assigned"
+			}
+			changes = append(changes, fmt.Sprintf("asignado a: %s", strings.TrimSpace(user.FirstName+" "+user.LastName)))
+		}
+	}
+	for _, tag := range a.AddTags {
+		tag = tags.Normalize(tag)
+		if tag != "" && !tags.Has(updated.Tags, tag) {
+			updated.Tags = append(updated.Tags, tag)
+			changes = append(changes, fmt.Sprintf("etiqueta: %s", tag))
+		}
+	}
+	for _, tag := range a.RemoveTags {
+		if tags.Has(updated.Tags, tag) {
+			updated.Tags = tags.Remove(updated.Tags, []string{tag})
+			changes = append(changes, fmt.Sprintf("sin etiqueta: %s", tags.Normalize(tag)))
+		}
+	}
+
+	*ticket = updated
+	return changes, nil
+}
+
+// VisibleTo indica si el usuario puede ver y aplicar la macro
+func VisibleTo(macro models.Macro, userID string) bool {
+	return macro.Scope == models.MacroScopeShared || macro.OwnerID == userID
+}
@@ -0,0 +1,147 @@
+// Package mentions reconoce las menciones @agente en los mensajes de los tickets y
+// avisa a los agentes mencionados con una notificación.
+package mentions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// mentionPattern captura "@usuario", "@nombre.apellido" o "@correo@dominio" cuando la @
+// no forma parte de una palabra (así no se confunde con un email dentro del texto)
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.+-]+(?:@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)+)?)`)
+
+// Parse devuelve los agentes mencionados en content. Cada agente se reconoce por su
+// email, la parte local del email o "nombre.apellido" y, si no hay otro agente con el
+// mismo nombre, también por su nombre; un alias que corresponda a varios agentes se
+// ignora por ambiguo.
+func Parse(content string, users []models.User) []models.User {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	aliases := make(map[string][]int)
+	firstNames := make(map[string][]int)
+	for i, user := range users {
+		if user.Role == "customer" || !user.Active {
+			continue
+		}
+		for _, alias := range userAliases(user) {
+			if !containsIndex(aliases[alias], i) {
+				aliases[alias] = append(aliases[alias], i)
+			}
+		}
+		if first := strings.ToLower(strings.Join(strings.Fields(user.FirstName), "")); first != "" {
+			firstNames[first] = append(firstNames[first], i)
+		}
+	}
+
+	var mentioned []models.User
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		alias := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		candidates, ok := aliases[alias]
+		if !ok {
+			candidates = firstNames[alias]
+		}
+		if len(candidates) == 1 {
+			user := users[candidates[0]]
+			if !seen[user.ID] {
+				seen[user.ID] = true
+				mentioned = append(mentioned, user)
+			}
+		}
+	}
+	return mentioned
+}
+
+// IDs devuelve los IDs de los usuarios
+func IDs(users []models.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+// Resolve reconoce las menciones de un mensaje de agente y las guarda en message.Mentions
+func Resolve(store data.DataStore, message *models.Message) error {
+	if message.IsClient || !strings.Contains(message.Content, "@") {
+		return nil
+	}
+	users, err := store.GetUsers()
+	if err != nil {
+		return fmt.Errorf("error al obtener usuarios: %v", err)
+	}
+	message.Mentions = IDs(Parse(message.Content, users))
+	return nil
+}
+
+// Notify crea una notificación para cada agente mencionado en message, salvo para su
+// autor. Devuelve cuántas notificaciones se crearon.
+func Notify(store data.DataStore, ticket models.Ticket, message models.Message) int {
+	author := message.UserName
+	if author == "" {
+		author = "Un agente"
+	}
+	kind := "un mensaje"
+	if message.IsInternal {
+		kind = "una nota interna"
+	}
+
+	created := 0
+	for _, userID := range message.Mentions {
+		if userID == message.UserID {
+			continue
+		}
+		notification := models.Notification{
+			UserID:      userID,
+			Type:        models.NotificationTypeMention,
+			Message:     fmt.Sprintf("%s te mencionó en %s del ticket %s: %s", author, kind, ticket.ID, ticket.Title),
+			RelatedID:   ticket.ID,
+			RelatedType: "ticket",
+		}
+		if err := store.CreateNotification(notification); err != nil {
+			logging.Warnf("⚠️ No se pudo notificar la mención a %s: %v", userID, err)
+			continue
+		}
+		created++
+	}
+	if created > 0 {
+		logging.Infof("📣 %d agentes mencionados en el ticket %s", created, ticket.ID)
+	}
+	return created
+}
+
+// userAliases devuelve los alias con los que se puede mencionar a un usuario, salvo el
+// nombre de pila, que sólo se usa si ningún otro alias coincide
+func userAliases(user models.User) []string {
+	var aliases []string
+	if email := strings.ToLower(strings.TrimSpace(user.Email)); email != "" {
+		aliases = append(aliases, email)
+		if at := strings.Index(email, "@"); at > 0 {
+			aliases = append(aliases, email[:at])
+		}
+	}
+	first := strings.ToLower(strings.Join(strings.Fields(user.FirstName), ""))
+	last := strings.ToLower(strings.Join(strings.Fields(user.LastName), ""))
+	if first != "" && last != "" {
+		aliases = append(aliases, first+"."+last)
+	}
+	return aliases
+}
+
+func containsIndex(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/ratelimit"
+)
+
+// PrincipalAPIKey identifica solicitudes autenticadas con una clave de API
+const PrincipalAPIKey = "api_key"
+
+// Claves del contexto con el ID y el tipo de propietario de la clave de API que
+// autenticó la solicitud
+const (
+	APIKeyIDKey    ContextKey = "apiKeyID"
+	APIKeyOwnerKey ContextKey = "apiKeyOwner"
+)
+
+// Tipos de propietario de una clave de API
+const (
+	APIKeyOwnerUser = "user"
+	APIKeyOwnerOrg  = "org"
+)
+
+// APIKeyScopes enumera los scopes que pueden concederse a una clave de API.
+// El scope widget queda reservado a las claves de servicio.
+var APIKeyScopes = []string{ScopeTicketsRead, ScopeTicketsWrite, ScopeTicketsBulk, ScopeCategoriesRead, ScopeFAQsRead}
+
+// keyStore es el almacén usado por Auth para validar claves de API
+var keyStore data.DataStore
+
+// apiKeyLimiter aplica el límite de solicitudes por clave
+var apiKeyLimiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+
+// IsOrgAPIKey indica si la solicitud fue autenticada con una clave de API de organización
+func IsOrgAPIKey(r *http.Request) bool {
+	principal, _ := r.Context().Value(PrincipalKey).(string)
+	owner, _ := r.Context().Value(APIKeyOwnerKey).(string)
+	return principal == PrincipalAPIKey && owner == APIKeyOwnerOrg
+}
+
+// SetKeyStore configura el almacén con el que Auth valida las claves de API
+func SetKeyStore(store data.DataStore) {
+	keyStore = store
+}
+
+// SetRateLimiter configura el limitador compartido (p. ej. Redis) para las claves de API
+func SetRateLimiter(limiter ratelimit.Limiter) {
+	apiKeyLimiter = limiter
+}
+
+// defaultAPIKeyRateLimit devuelve el límite por minuto de las claves sin límite propio
+func defaultAPIKeyRateLimit() int {
+	if value, err := strconv.Atoi(os.Getenv("API_KEY_RATE_LIMIT")); err == nil && value > 0 {
+		return value
+	}
+	return 60
+}
+
+// authenticateAPIKey valida una clave de API y registra su uso
+func authenticateAPIKey(store data.DataStore, key string) (*models.APIKey, error) {
+	apiKey, err := store.GetAPIKeyByPrefix(utils.APIKeyPublicPart(key))
+	if err != nil {
+		return nil, fmt.Errorf("clave de API desconocida")
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, fmt.Errorf("clave de API revocada")
+	}
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, fmt.Errorf("clave de API expirada")
+	}
+	if !utils.CompareAPIKeyHash(key, apiKey.KeyHash) {
+		return nil, fmt.Errorf("clave de API inválida")
+	}
+
+	// Registrar el uso como mucho una vez por minuto para no escribir en cada solicitud
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > time.Minute {
+		if err := store.TouchAPIKey(apiKey.ID); err != nil {
+			logging.Errorf("Error al registrar uso de la clave de API %s: %v", apiKey.KeyPrefix, err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// apiKeyRequest autentica la clave, comprueba el scope y aplica su límite de solicitudes;
+// devuelve la solicitud con el contexto del propietario de la clave
+func apiKeyRequest(store data.DataStore, w http.ResponseWriter, r *http.Request, key string) (*http.Request, bool) {
+	if store == nil {
+		http.Error(w, "No autorizado: las claves de API no están habilitadas", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	apiKey, err := authenticateAPIKey(store, key)
+	if err != nil {
+		http.Error(w, "No autorizado: "+err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// El scope se comprueba antes del límite: una solicitud que se va a rechazar no consume
+	// tokens del bucket de la clave
+	scope := RequiredScope(r)
+	if scope == "" || !HasScope(apiKey.Scopes, scope) {
+		http.Error(w, "Prohibido: la clave de API no tiene el scope requerido", http.StatusForbidden)
+		return nil, false
+	}
+
+	limit := apiKey.RateLimit
+	if limit <= 0 {
+		limit = defaultAPIKeyRateLimit()
+	}
+	allowed, wait, err := apiKeyLimiter.Allow("apikey:"+apiKey.ID, limit, time.Minute)
+	if err != nil {
+		logging.Errorf("Error en el limitador de solicitudes: %v", err)
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Demasiadas solicitudes para esta clave de API", http.StatusTooManyRequests)
+		return nil, false
+	}
+
+	// Las claves personales actúan con el rol de su usuario; las de organización con un rol propio
+	email := ""
+	role := "api"
+	user, err := store.GetUser(apiKey.UserID)
+	if err != nil || !user.Active {
+		http.Error(w, "No autorizado: el propietario de la clave no está activo", http.StatusUnauthorized)
+		return nil, false
+	}
+	if apiKey.OwnerType == APIKeyOwnerUser {
+		email = user.Email
+		role = user.Role
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, apiKey.UserID)
+	ctx = context.WithValue(ctx, EmailKey, email)
+	ctx = context.WithValue(ctx, RoleKey, role)
+	ctx = context.WithValue(ctx, PrincipalKey, PrincipalAPIKey)
+	ctx = context.WithValue(ctx, APIKeyIDKey, apiKey.ID)
+	ctx = context.WithValue(ctx, APIKeyOwnerKey, apiKey.OwnerType)
+	ctx = context.WithValue(ctx, ScopesKey, apiKey.Scopes)
+
+	return r.WithContext(ctx), true
+}
@@ -49,6 +49,16 @@ func Auth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Las claves de API se aceptan junto a los tokens JWT
+		if strings.HasPrefix(tokenString, utils.APIKeyPrefix) {
+			req, ok := apiKeyRequest(keyStore, w, r, tokenString)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+
 		// Validar token
 		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/ratelimit"
+)
+
+// trustedProxies son los proxies cuyos X-Forwarded-For / X-Real-IP se aceptan
+var trustedProxies = &ratelimit.Proxies{}
+
+// SetTrustedProxies configura los proxies de confianza (TRUSTED_PROXIES)
+func SetTrustedProxies(proxies *ratelimit.Proxies) {
+	trustedProxies = proxies
+}
+
+// ClientIP obtiene la IP del cliente. X-Forwarded-For y X-Real-IP sólo se respetan si la
+// conexión llega desde un proxy de confianza.
+func ClientIP(r *http.Request) string {
+	return trustedProxies.ClientIP(r)
+}
+
+// rateLimitSubject devuelve el identificador del ámbito o "" si la solicitud no lo aporta
+func rateLimitSubject(r *http.Request, scope string) string {
+	switch scope {
+	case ratelimit.ScopeIP:
+		return ClientIP(r)
+	case ratelimit.ScopeWidget:
+		return r.Header.Get("X-Widget-ID")
+	case ratelimit.ScopeSession:
+		if id := r.Header.Get("X-Session-ID"); id != "" {
+			return ratelimit.SessionSubject(ClientIP(r), id)
+		}
+		if cookie, err := r.Cookie("growdesk_session"); err == nil {
+			return ratelimit.SessionSubject(ClientIP(r), cookie.Value)
+		}
+	}
+	return ""
+}
+
+// RateLimit aplica las reglas de la ruta y responde 429 con Retry-After al superarlas.
+// Con RATE_LIMIT_ENABLED=false no se limita nada.
+func RateLimit(limiter ratelimit.Limiter, route string, rules []ratelimit.Rule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.ToLower(os.Getenv("RATE_LIMIT_ENABLED")) == "false" || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, rule := range rules {
+				if rule.Limit <= 0 {
+					continue
+				}
+				subject := rateLimitSubject(r, rule.Scope)
+				if subject == "" {
+					continue
+				}
+
+				allowed, wait, err := limiter.Allow(ratelimit.Key(route, rule.Scope, subject), rule.Limit, rule.Per)
+				if err != nil {
+					logging.Errorf("Error en el limitador de solicitudes (se usa memoria): %v", err)
+				}
+				if !allowed {
+					retryAfter := int(math.Ceil(wait.Seconds()))
+					if retryAfter < 1 {
+						retryAfter = 1
+					}
+					utils.SetCORS(w)
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					http.Error(w, "Demasiadas solicitudes, inténtalo de nuevo más tarde", http.StatusTooManyRequests)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
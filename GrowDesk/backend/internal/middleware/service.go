@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Claves del contexto para distinguir el tipo de principal autenticado
+const (
+	PrincipalKey   ContextKey = "principal"
+	ServiceNameKey ContextKey = "serviceName"
+	ScopesKey      ContextKey = "scopes"
+)
+
+// Tipos de principal
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// Scopes que pueden concederse a una clave
+const (
+	ScopeTicketsRead    = "tickets:read"
+	ScopeTicketsWrite   = "tickets:write"
+	ScopeTicketsBulk    = "tickets:bulk" // Operaciones masivas; sólo claves de API personales
+	ScopeCategoriesRead = "categories:read"
+	ScopeFAQsRead       = "faqs:read"
+	ScopeWidget         = "widget"
+)
+
+// ValidScopes enumera los scopes reconocidos
+var ValidScopes = []string{ScopeTicketsRead, ScopeTicketsWrite, ScopeTicketsBulk, ScopeCategoriesRead, ScopeFAQsRead, ScopeWidget}
+
+// RequiredScope devuelve el scope necesario para una solicitud autenticada con clave,
+// o "" si la ruta no admite claves de API
+func RequiredScope(r *http.Request) string {
+	path := r.URL.Path
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case strings.HasPrefix(path, "/api/tickets/bulk"):
+		return ScopeTicketsBulk
+	case strings.HasPrefix(path, "/api/tickets") && read:
+		return ScopeTicketsRead
+	case strings.HasPrefix(path, "/api/tickets"):
+		return ScopeTicketsWrite
+	case strings.HasPrefix(path, "/api/categories") && read:
+		return ScopeCategoriesRead
+	case strings.HasPrefix(path, "/api/faqs") && read:
+		return ScopeFAQsRead
+	case strings.HasPrefix(path, "/widget/"):
+		return ScopeWidget
+	}
+	return ""
+}
+
+// HasScope indica si la lista de scopes contiene el scope requerido
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServicePrincipal indica si la solicitud fue autenticada por un servicio
+func IsServicePrincipal(r *http.Request) bool {
+	principal, _ := r.Context().Value(PrincipalKey).(string)
+	return principal == PrincipalService
+}
+
+// PrincipalID identifica a quien hace la solicitud: el servicio (por su nombre, que se
+// mantiene al rotar la clave), la clave de API o el usuario
+func PrincipalID(r *http.Request) string {
+	ctx := r.Context()
+	principal, _ := ctx.Value(PrincipalKey).(string)
+	switch principal {
+	case PrincipalService:
+		service, _ := ctx.Value(ServiceNameKey).(string)
+		return PrincipalService + ":" + service
+	case PrincipalAPIKey:
+		keyID, _ := ctx.Value(APIKeyIDKey).(string)
+		return PrincipalAPIKey + ":" + keyID
+	}
+	userID, _ := ctx.Value(UserIDKey).(string)
+	return PrincipalUser + ":" + userID
+}
+
+// authenticateServiceKey valida una clave de servicio y registra su uso
+func authenticateServiceKey(store data.DataStore, key string) (*models.ServiceKey, error) {
+	serviceKey, err := store.GetServiceKeyByPrefix(utils.APIKeyPublicPart(key))
+	if err != nil {
+		return nil, fmt.Errorf("clave de servicio desconocida")
+	}
+	if serviceKey.RevokedAt != nil {
+		return nil, fmt.Errorf("clave de servicio revocada")
+	}
+	if !utils.CompareAPIKeyHash(key, serviceKey.KeyHash) {
+		return nil, fmt.Errorf("clave de servicio inválida")
+	}
+
+	// Registrar el uso como mucho una vez por minuto para no escribir en cada solicitud
+	if serviceKey.LastUsedAt == nil || time.Since(*serviceKey.LastUsedAt) > time.Minute {
+		if err := store.TouchServiceKey(serviceKey.ID); err != nil {
+			logging.Errorf("Error al registrar uso de la clave de servicio %s: %v", serviceKey.KeyPrefix, err)
+		}
+	}
+
+	return serviceKey, nil
+}
+
+// serviceRequest autentica la clave y comprueba el scope; devuelve la solicitud con el contexto del servicio
+func serviceRequest(store data.DataStore, w http.ResponseWriter, r *http.Request, key, scope string) (*http.Request, bool) {
+	serviceKey, err := authenticateServiceKey(store, key)
+	if err != nil {
+		http.Error(w, "No autorizado: "+err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	if scope == "" || !HasScope(serviceKey.Scopes, scope) {
+		http.Error(w, "Prohibido: la clave de servicio no tiene el scope requerido", http.StatusForbidden)
+		return nil, false
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, serviceKey.ActAsUserID)
+	ctx = context.WithValue(ctx, EmailKey, "")
+	ctx = context.WithValue(ctx, RoleKey, PrincipalService)
+	ctx = context.WithValue(ctx, PrincipalKey, PrincipalService)
+	ctx = context.WithValue(ctx, ServiceNameKey, serviceKey.Service)
+	ctx = context.WithValue(ctx, ScopesKey, serviceKey.Scopes)
+
+	return r.WithContext(ctx), true
+}
+
+// ServiceAuth restringe una ruta a servicios autenticados con una clave que tenga el scope indicado
+func ServiceAuth(store data.DataStore, scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ExtractToken(r)
+		if !strings.HasPrefix(key, utils.ServiceKeyPrefix) {
+			http.Error(w, "No autorizado: se requiere una clave de servicio", http.StatusUnauthorized)
+			return
+		}
+
+		req, ok := serviceRequest(store, w, r, key, scope)
+		if !ok {
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// WithServiceKeys extiende un middleware de usuarios (Auth o MockAuth) para aceptar
+// también claves de servicio y claves de API. El scope requerido se deduce de la ruta
+// (ver RequiredScope).
+func WithServiceKeys(store data.DataStore, userAuth func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		userHandler := userAuth(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := ExtractToken(r)
+			if strings.HasPrefix(key, utils.APIKeyPrefix) {
+				req, ok := apiKeyRequest(store, w, r, key)
+				if !ok {
+					return
+				}
+				next.ServeHTTP(w, req)
+				return
+			}
+			if !strings.HasPrefix(key, utils.ServiceKeyPrefix) {
+				userHandler.ServeHTTP(w, r)
+				return
+			}
+
+			req, ok := serviceRequest(store, w, r, key, RequiredScope(r))
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
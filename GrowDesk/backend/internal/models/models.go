@@ -19,8 +19,76 @@ type User struct {
 	Position   string    `json:"position,omitempty"`
 	Phone      string    `json:"phone,omitempty"`
 	Language   string    `json:"language,omitempty"`
+
+	// Datos para la asignación automática de tickets
+	Skills         []string `json:"skills,omitempty"`         // IDs de categorías en las que es experto
+	MaxOpenTickets int      `json:"maxOpenTickets,omitempty"` // 0 = el límite de la política
+	Availability   string   `json:"availability,omitempty"`   // Ver constantes Availability*; vacío = offline
+
+	// Horario laboral; nil = sin restricción de horario
+	Schedule *WorkingSchedule `json:"schedule,omitempty"`
+}
+
+// WorkingSchedule es el horario semanal de un agente en su zona horaria
+type WorkingSchedule struct {
+	TimeZone string         `json:"timeZone"` // Nombre IANA, p. ej. "America/Santiago"; vacío = UTC
+	Shifts   []WorkingShift `json:"shifts"`
+}
+
+// WorkingShift es un turno dentro de un día de la semana. Si End es anterior a
+// Start, el turno termina al día siguiente.
+type WorkingShift struct {
+	Weekday int    `json:"weekday"` // 0 = domingo ... 6 = sábado
+	Start   string `json:"start"`   // "HH:MM"
+	End     string `json:"end"`     // "HH:MM"
 }
 
+// Estados de disponibilidad de un agente. Sólo los agentes disponibles reciben tickets nuevos.
+const (
+	AvailabilityAvailable = "available"
+	AvailabilityBusy      = "busy"
+	AvailabilityAway      = "away"
+	AvailabilityOffline   = "offline"
+)
+
+// Estados de presencia efectivos, calculados a partir de la disponibilidad manual,
+// el horario y las conexiones del agente
+const (
+	PresenceOnline   = "online"
+	PresenceBusy     = "busy"
+	PresenceAway     = "away"
+	PresenceOffShift = "off_shift"
+	PresenceOffline  = "offline"
+)
+
+// AgentPresence es el estado de presencia de un agente en un momento dado
+type AgentPresence struct {
+	UserID       string     `json:"userId"`
+	Name         string     `json:"name"`
+	Department   string     `json:"department,omitempty"`
+	Availability string     `json:"availability"` // Estado elegido por el agente
+	Status       string     `json:"status"`       // Estado efectivo, ver constantes Presence*
+	Connected    bool       `json:"connected"`
+	Connections  int        `json:"connections"`
+	OnShift      bool       `json:"onShift"`
+	LastSeen     *time.Time `json:"lastSeen,omitempty"`
+}
+
+// WidgetAvailability indica al widget si puede ofrecer chat en vivo o sólo dejar un mensaje
+type WidgetAvailability struct {
+	LiveChatAvailable bool       `json:"liveChatAvailable"`
+	Mode              string     `json:"mode"` // "live_chat" o "leave_message"
+	OnlineAgents      int        `json:"onlineAgents"`
+	Open              bool       `json:"open"`                 // Dentro del horario de atención
+	NextOpenAt        *time.Time `json:"nextOpenAt,omitempty"` // Próxima apertura si está cerrado
+}
+
+// Modos del widget según la disponibilidad de los agentes
+const (
+	WidgetModeLiveChat     = "live_chat"
+	WidgetModeLeaveMessage = "leave_message"
+)
+
 // LoginRequest representa los datos de la solicitud de inicio de sesión
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -62,6 +130,18 @@ type Ticket struct {
 	WidgetID    string    `json:"widgetId,omitempty"`
 	Department  string    `json:"department,omitempty"`
 	Metadata    *Metadata `json:"metadata,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	TeamID      string    `json:"teamId,omitempty"`
+
+	CustomFields map[string]interface{} `json:"customFields,omitempty"` // Valores de los campos personalizados de ticket
+
+	MergedInto string `json:"mergedInto,omitempty"` // Ticket en el que se fusionó este (ya cerrado)
+	SplitFrom  string `json:"splitFrom,omitempty"`  // Ticket del que se separó este
+
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"` // Cuándo pasó a resolved o closed; se borra al reabrirlo
+
+	Relations []TicketLink    `json:"relations,omitempty"` // Sólo en GET /api/tickets/:id; no se guarda con el ticket
+	Watchers  []TicketWatcher `json:"watchers,omitempty"`  // Sólo en GET /api/tickets/:id para agentes
 }
 
 // Customer representa a un cliente de un ticket
@@ -81,6 +161,7 @@ type Message struct {
 	UserID     string    `json:"userId,omitempty"`
 	UserName   string    `json:"userName,omitempty"`
 	UserEmail  string    `json:"userEmail,omitempty"`
+	Mentions   []string  `json:"mentions,omitempty"` // IDs de los agentes mencionados con @
 }
 
 // NewMessageRequest representa una solicitud para agregar un nuevo mensaje
@@ -95,12 +176,22 @@ type NewMessageRequest struct {
 
 // TicketUpdateRequest representa una solicitud para actualizar un ticket
 type TicketUpdateRequest struct {
-	Status     string `json:"status,omitempty"`
-	Priority   string `json:"priority,omitempty"`
-	AssignedTo string `json:"assignedTo,omitempty"`
-	Category   string `json:"category,omitempty"`
-	Department string `json:"department,omitempty"`
-	Subject    string `json:"subject,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	AssignedTo string   `json:"assignedTo,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Department string   `json:"department,omitempty"`
+	TeamID     string   `json:"teamId,omitempty"`
+	Subject    string   `json:"subject,omitempty"`
+	Tags       []string `json:"tags,omitempty"` // Reemplaza las etiquetas del ticket si se envía
+
+	// Campos personalizados a cambiar; un valor null borra el campo
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+
+	// Al resolver o cerrar un ticket padre: CascadeChildren=false deja a los hijos como
+	// están y CascadeMessage es la respuesta común que reciben los hijos
+	CascadeChildren *bool  `json:"cascadeChildren,omitempty"`
+	CascadeMessage  string `json:"cascadeMessage,omitempty"`
 }
 
 // Category representa una categoría de ticket
@@ -141,6 +232,16 @@ type Metadata struct {
 	UserAgent  string `json:"userAgent,omitempty"`
 	ScreenSize string `json:"screenSize,omitempty"`
 	ExternalID string `json:"externalId,omitempty"`
+	// CreatedBy es el principal que creó el ticket (lo fija el backend, nunca el cliente).
+	// Sólo ese principal puede recuperar el ticket reenviando el mismo ExternalID.
+	CreatedBy string `json:"createdBy,omitempty"`
+
+	// Datos del origen que envía el widget y que usan las reglas de enrutamiento
+	Source      string `json:"source,omitempty"`
+	WidgetID    string `json:"widgetId,omitempty"`
+	ClientName  string `json:"clientName,omitempty"`
+	ClientEmail string `json:"clientEmail,omitempty"`
+	Department  string `json:"department,omitempty"`
 }
 
 // TicketResponse es la respuesta después de crear un ticket desde el widget
@@ -174,6 +275,12 @@ type Activity struct {
 	Metadata    map[string]any `json:"metadata,omitempty"`
 }
 
+// Tipos de notificación
+const (
+	NotificationTypeMention      = "mention"       // Un agente mencionó al usuario en un ticket
+	NotificationTypeTicketUpdate = "ticket_update" // Novedades de un ticket que el usuario sigue
+)
+
 // Notification representa una notificación para un usuario
 type Notification struct {
 	ID          string    `json:"id"`
@@ -213,36 +320,6 @@ type WidgetSetting struct {
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
-// WidgetTicket representa un ticket creado desde el widget
-type WidgetTicket struct {
-	ID          string    `json:"id"`
-	TicketID    string    `json:"ticketId"`
-	Title       string    `json:"title"`
-	Subject     string    `json:"subject"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	Priority    string    `json:"priority"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	ClientName  string    `json:"clientName"`
-	ClientEmail string    `json:"clientEmail"`
-	WidgetID    string    `json:"widgetId"`
-	Department  string    `json:"department"`
-	Synced      bool      `json:"synced"`
-}
-
-// WidgetMessage representa un mensaje en un ticket de widget
-type WidgetMessage struct {
-	ID             string    `json:"id"`
-	WidgetTicketID string    `json:"widgetTicketId"`
-	Content        string    `json:"content"`
-	IsClient       bool      `json:"isClient"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UserName       string    `json:"userName"`
-	UserEmail      string    `json:"userEmail"`
-	Synced         bool      `json:"synced"`
-}
-
 // WidgetSession representa una sesión de usuario en el widget
 type WidgetSession struct {
 	ID         string    `json:"id"`
@@ -279,4 +356,626 @@ type TicketRequest struct {
 	UserEmail   string    `json:"userEmail,omitempty"`
 	IsClient    bool      `json:"isClient"`
 	Metadata    *Metadata `json:"metadata,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+
+	CustomFields  map[string]interface{} `json:"customFields,omitempty"`  // Campos personalizados del ticket
+	ContactFields map[string]interface{} `json:"contactFields,omitempty"` // Campos personalizados del contacto
+}
+
+// ServiceKey representa una credencial de servicio (p. ej. widget-api) para hablar con el backend.
+// Sólo se guarda el hash de la clave; el valor en claro se muestra una única vez al crearla.
+type ServiceKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Service     string     `json:"service"`
+	KeyPrefix   string     `json:"keyPrefix"`
+	KeyHash     string     `json:"keyHash,omitempty"`
+	Scopes      []string   `json:"scopes"`
+	ActAsUserID string     `json:"actAsUserId,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+}
+
+// ServiceKeyRequest representa una solicitud para crear una clave de servicio
+type ServiceKeyRequest struct {
+	Name        string   `json:"name"`
+	Service     string   `json:"service"`
+	Scopes      []string `json:"scopes"`
+	ActAsUserID string   `json:"actAsUserId,omitempty"`
+}
+
+// APIKey representa una clave de API para integraciones (monitorización, CRM...).
+// Puede pertenecer a un usuario o a la organización; sólo se guarda su hash.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	OwnerType  string     `json:"ownerType"` // "user" u "org"
+	UserID     string     `json:"userId"`    // Propietario, o creador en las claves de organización
+	KeyPrefix  string     `json:"keyPrefix"`
+	KeyHash    string     `json:"keyHash,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rateLimit"` // Solicitudes por minuto (0 = límite por defecto)
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyRequest representa una solicitud para crear una clave de API
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	OwnerType string     `json:"ownerType,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	RateLimit int        `json:"rateLimit,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// RoutingRule es una regla de enrutamiento de tickets. Las reglas activas se evalúan
+// por orden de Position al crear un ticket; todas las condiciones indicadas deben cumplirse.
+type RoutingRule struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Position       int               `json:"position"`
+	Enabled        bool              `json:"enabled"`
+	StopProcessing bool              `json:"stopProcessing"` // No evaluar más reglas si ésta coincide
+	Conditions     RoutingConditions `json:"conditions"`
+	Actions        RoutingActions    `json:"actions"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
+}
+
+// RoutingConditions son las condiciones de una regla. Las listas coinciden si
+// contienen alguno de los valores; una condición vacía no se tiene en cuenta.
+type RoutingConditions struct {
+	WidgetIDs     []string                `json:"widgetIds,omitempty"`
+	Sources       []string                `json:"sources,omitempty"`
+	EmailDomains  []string                `json:"emailDomains,omitempty"`
+	Keywords      []string                `json:"keywords,omitempty"`    // En el título o la descripción
+	URLContains   []string                `json:"urlContains,omitempty"` // En la URL de los metadatos
+	Tags          []string                `json:"tags,omitempty"`        // El ticket ya tiene alguna de las etiquetas
+	BusinessHours *BusinessHoursCondition `json:"businessHours,omitempty"`
+}
+
+// BusinessHoursCondition se cumple mientras un calendario de atención está abierto
+// (turnos y festivos), o cerrado con Outside. Sin CalendarID se usa el calendario que
+// corresponde al ticket: el de su equipo, el de su widget o el predeterminado.
+type BusinessHoursCondition struct {
+	CalendarID string `json:"calendarId,omitempty"`
+	Outside    bool   `json:"outside,omitempty"`
+}
+
+// RoutingActions son los cambios que aplica una regla al ticket
+type RoutingActions struct {
+	CategoryID string   `json:"categoryId,omitempty"`
+	Department string   `json:"department,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	AssignTo   string   `json:"assignTo,omitempty"`
+	TeamID     string   `json:"teamId,omitempty"`
+}
+
+// Estrategias de asignación automática
+const (
+	AssignRoundRobin = "round_robin"
+	AssignLeastOpen  = "least_open"
+	AssignSkills     = "skills"
+)
+
+// AssignmentPolicy define cómo se asignan automáticamente los tickets de un equipo o
+// departamento. Una política sin equipo ni departamento se aplica al resto de tickets.
+type AssignmentPolicy struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	TeamID         string    `json:"teamId,omitempty"`
+	Department     string    `json:"department,omitempty"`
+	Strategy       string    `json:"strategy"`
+	AgentIDs       []string  `json:"agentIds,omitempty"`       // Vacío = miembros del equipo o agentes del departamento
+	MaxOpenTickets int       `json:"maxOpenTickets,omitempty"` // Carga máxima por agente; 0 = sin límite
+	Enabled        bool      `json:"enabled"`
+	LastAssignedTo string    `json:"lastAssignedTo,omitempty"` // Estado del round-robin
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// AgentSettingsRequest actualiza los datos de asignación de un agente
+type AgentSettingsRequest struct {
+	Skills         *[]string        `json:"skills,omitempty"`
+	MaxOpenTickets *int             `json:"maxOpenTickets,omitempty"`
+	Availability   string           `json:"availability,omitempty"`
+	Schedule       *WorkingSchedule `json:"schedule,omitempty"`
+	ClearSchedule  bool             `json:"clearSchedule,omitempty"` // Quita el horario del agente
+}
+
+// Roles de un miembro dentro de un equipo
+const (
+	TeamRoleMember = "member"
+	TeamRoleLead   = "lead" // Puede gestionar los miembros y la cola del equipo
+)
+
+// Team es un equipo de agentes con su propia cola de tickets. Sustituye a los
+// departamentos de texto libre, que se mantienen sólo por compatibilidad.
+type Team struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	CalendarID  string       `json:"calendarId,omitempty"` // Horario de atención; vacío = el calendario por defecto
+	Members     []TeamMember `json:"members"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// TeamMember es la pertenencia de un usuario a un equipo
+type TeamMember struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"` // "member" o "lead"
+}
+
+// BusinessCalendar es un horario de atención: turnos semanales en una zona horaria y
+// los días festivos en los que no se atiende. Se asigna a equipos y widgets; el
+// calendario por defecto se usa cuando no hay uno asignado.
+type BusinessCalendar struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	TimeZone  string         `json:"timeZone"` // Nombre IANA; vacío = UTC
+	Hours     []WorkingShift `json:"hours"`
+	Holidays  []Holiday      `json:"holidays"`
+	WidgetIDs []string       `json:"widgetIds,omitempty"` // Widgets que usan este calendario
+	IsDefault bool           `json:"isDefault"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// Holiday es un día sin atención en la zona horaria del calendario
+type Holiday struct {
+	Date string `json:"date"` // "YYYY-MM-DD"
+	Name string `json:"name,omitempty"`
+}
+
+// BusinessHoursStatus indica si un calendario está abierto en un instante dado
+type BusinessHoursStatus struct {
+	CalendarID string     `json:"calendarId"`
+	At         time.Time  `json:"at"`
+	Open       bool       `json:"open"`
+	NextOpenAt *time.Time `json:"nextOpenAt,omitempty"`
+}
+
+// Ámbitos de una macro
+const (
+	MacroScopePersonal = "personal" // Sólo la ve y la usa su autor
+	MacroScopeShared   = "shared"   // Disponible para todos los agentes
+)
+
+// Macro es una respuesta guardada con acciones que se aplican juntas a un ticket. La
+// respuesta admite marcadores como {{customer.name}}, {{ticket.id}} o {{agent.firstName}}.
+type Macro struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Scope       string       `json:"scope"`   // "personal" o "shared"
+	OwnerID     string       `json:"ownerId"` // Autor de la macro
+	Reply       string       `json:"reply,omitempty"`
+	Internal    bool         `json:"internal,omitempty"` // La respuesta se añade como nota interna
+	Actions     MacroActions `json:"actions"`
+	UsageCount  int          `json:"usageCount"`
+	LastUsedAt  *time.Time   `json:"lastUsedAt,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// MacroActions son los cambios que una macro aplica al ticket; los campos vacíos no se tocan
+type MacroActions struct {
+	Status     string   `json:"status,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	CategoryID string   `json:"categoryId,omitempty"`
+	AssignTo   string   `json:"assignTo,omitempty"` // ID de agente o "me" para quien aplica la macro
+	AddTags    []string `json:"addTags,omitempty"`
+	RemoveTags []string `json:"removeTags,omitempty"`
+}
+
+// MacroApplyResult es la respuesta al aplicar una macro a un ticket
+type MacroApplyResult struct {
+	Ticket  Ticket   `json:"ticket"`
+	Message *Message `json:"message,omitempty"`
+	Changes []string `json:"changes"`
+}
+
+// Tag es una etiqueta del catálogo. Los tickets guardan el nombre de sus etiquetas;
+// renombrar o eliminar una etiqueta actualiza todos los tickets que la usan.
+type Tag struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Color           string    `json:"color,omitempty"` // "#RRGGBB"
+	Description     string    `json:"description,omitempty"`
+	TicketCount     int       `json:"ticketCount"`     // Calculado al listar
+	OpenTicketCount int       `json:"openTicketCount"` // Calculado al listar
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// BulkTagRequest añade y quita etiquetas a varios tickets a la vez
+type BulkTagRequest struct {
+	TicketIDs []string `json:"ticketIds"`
+	Add       []string `json:"add,omitempty"`
+	Remove    []string `json:"remove,omitempty"`
+}
+
+// BulkTagResult indica qué tickets se actualizaron y por qué fallaron los demás
+type BulkTagResult struct {
+	Updated []string          `json:"updated"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// Estados de una operación masiva sobre tickets
+const (
+	BulkJobQueued    = "queued"
+	BulkJobRunning   = "running"
+	BulkJobCompleted = "completed" // Terminada, aunque algunos tickets hayan fallado
+	BulkJobFailed    = "failed"    // No se pudo procesar ningún ticket
+)
+
+// BulkTicketRequest es el cuerpo de POST /api/tickets/bulk. Los tickets se eligen por ID
+// o con un filtro con la misma sintaxis que GET /api/tickets (p. ej. "status=open&tags=caida").
+type BulkTicketRequest struct {
+	TicketIDs  []string             `json:"ticketIds,omitempty"`
+	Filter     string               `json:"filter,omitempty"`
+	Operations BulkTicketOperations `json:"operations"`
+}
+
+// BulkTicketOperations son los cambios que se aplican a cada ticket seleccionado. Los
+// campos vacíos no se modifican; Delete elimina los tickets y no admite otros cambios.
+type BulkTicketOperations struct {
+	Status     string   `json:"status,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	AssignedTo string   `json:"assignedTo,omitempty"`
+	CategoryID string   `json:"categoryId,omitempty"`
+	AddTags    []string `json:"addTags,omitempty"`
+	RemoveTags []string `json:"removeTags,omitempty"`
+	Delete     bool     `json:"delete,omitempty"`
+}
+
+// BulkTicketError es el motivo por el que no se pudo procesar un ticket
+type BulkTicketError struct {
+	TicketID string `json:"ticketId"`
+	Error    string `json:"error"`
+}
+
+// BulkJob es una operación masiva en curso o terminada, con su progreso
+type BulkJob struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Operations BulkTicketOperations `json:"operations"`
+	Total      int                  `json:"total"`
+	Processed  int                  `json:"processed"`
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Errors     []BulkTicketError    `json:"errors"`
+	CreatedBy  string               `json:"createdBy"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	StartedAt  *time.Time           `json:"startedAt,omitempty"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+}
+
+// Tipos de campo personalizado
+const (
+	CustomFieldText        = "text"
+	CustomFieldNumber      = "number"
+	CustomFieldDate        = "date" // "YYYY-MM-DD"
+	CustomFieldDropdown    = "dropdown"
+	CustomFieldMultiSelect = "multi_select"
+	CustomFieldCheckbox    = "checkbox"
+)
+
+// Entidades a las que se puede añadir un campo personalizado
+const (
+	CustomFieldEntityTicket  = "ticket"
+	CustomFieldEntityContact = "contact"
+)
+
+// CustomField es un campo definido por los administradores para tickets o contactos.
+// Los valores se guardan por Key en CustomFields del ticket o del contacto.
+type CustomField struct {
+	ID            string    `json:"id"`
+	Key           string    `json:"key"` // Identificador estable, p. ej. "order_number"
+	Label         string    `json:"label"`
+	Description   string    `json:"description,omitempty"`
+	Placeholder   string    `json:"placeholder,omitempty"`
+	Type          string    `json:"type"`              // text, number, date, dropdown, multi_select o checkbox
+	Entity        string    `json:"entity"`            // "ticket" o "contact"
+	Options       []string  `json:"options,omitempty"` // Para dropdown y multi_select
+	Required      bool      `json:"required"`
+	ShowInPreChat bool      `json:"showInPreChat"` // Se pide en el formulario inicial del widget
+	Position      int       `json:"position"`
+	Active        bool      `json:"active"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Contact es un cliente identificado por su email, con sus campos personalizados
+type Contact struct {
+	Email        string                 `json:"email"`
+	Name         string                 `json:"name,omitempty"`
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+}
+
+// Tipos de relación entre tickets. Se guardan en un solo sentido (duplicate_of,
+// related_to, child_of y blocks, de TicketID a RelatedTicketID); los inversos sólo
+// aparecen al ver la relación desde el otro ticket.
+const (
+	TicketRelationDuplicateOf  = "duplicate_of"
+	TicketRelationDuplicatedBy = "duplicated_by"
+	TicketRelationRelatedTo    = "related_to"
+	TicketRelationChildOf      = "child_of"
+	TicketRelationParentOf     = "parent_of"
+	TicketRelationBlocks       = "blocks"
+	TicketRelationBlockedBy    = "blocked_by"
+)
+
+// TicketRelation es una relación guardada entre dos tickets
+type TicketRelation struct {
+	ID              string    `json:"id"`
+	TicketID        string    `json:"ticketId"`
+	RelatedTicketID string    `json:"relatedTicketId"`
+	Type            string    `json:"type"`
+	CreatedBy       string    `json:"createdBy,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// TicketLink es una relación vista desde uno de sus tickets
+type TicketLink struct {
+	ID        string    `json:"id"` // ID de la relación
+	Type      string    `json:"type"`
+	TicketID  string    `json:"ticketId"` // El otro ticket
+	Title     string    `json:"title,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TicketRelationRequest es el cuerpo de POST /api/tickets/:id/relations
+type TicketRelationRequest struct {
+	Type     string `json:"type"`
+	TicketID string `json:"ticketId"`
+}
+
+// TicketWatcher es un seguidor de un ticket: un agente que recibe sus novedades
+// (UserID) o un contacto del cliente en copia (Email)
+type TicketWatcher struct {
+	ID          string             `json:"id"`
+	TicketID    string             `json:"ticketId"`
+	UserID      string             `json:"userId,omitempty"`
+	Email       string             `json:"email,omitempty"`
+	Preferences WatcherPreferences `json:"preferences"`
+	AddedBy     string             `json:"addedBy,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt"`
+}
+
+// WatcherPreferences indica por qué canales y de qué novedades se avisa a un seguidor
+type WatcherPreferences struct {
+	InApp         bool   `json:"inApp"`                // Notificación en el panel
+	Email         bool   `json:"email"`                // Correo electrónico
+	WebhookURL    string `json:"webhookUrl,omitempty"` // POST con el evento; vacío = sin webhook
+	Messages      bool   `json:"messages"`             // Mensajes nuevos
+	StatusChanges bool   `json:"statusChanges"`        // Cambios de estado
+	InternalNotes bool   `json:"internalNotes"`        // Notas internas (sólo agentes)
+}
+
+// TicketWatcherRequest es el cuerpo de POST /api/tickets/:id/watchers. Sin userId ni
+// email, el usuario autenticado empieza a seguir el ticket.
+type TicketWatcherRequest struct {
+	UserID      string              `json:"userId,omitempty"`
+	Email       string              `json:"email,omitempty"`
+	Preferences *WatcherPreferences `json:"preferences,omitempty"`
+}
+
+// WatchedTicket es un elemento de GET /api/watching: la suscripción y el ticket seguido
+type WatchedTicket struct {
+	Watcher TicketWatcher `json:"watcher"`
+	Ticket  Ticket        `json:"ticket"`
+}
+
+// CSATSurvey es la encuesta de satisfacción que se envía al cliente cuando su ticket se
+// resuelve o se cierra. Guarda el agente, equipo y categoría de ese momento para poder
+// agregar las valoraciones aunque el ticket cambie después.
+type CSATSurvey struct {
+	ID            string     `json:"id"`
+	TicketID      string     `json:"ticketId"`
+	AgentID       string     `json:"agentId,omitempty"`
+	TeamID        string     `json:"teamId,omitempty"`
+	CategoryID    string     `json:"categoryId,omitempty"`
+	Category      string     `json:"category,omitempty"`
+	CustomerEmail string     `json:"customerEmail,omitempty"`
+	Rating        int        `json:"rating,omitempty"` // 1 a 5; 0 mientras no se responde
+	Comment       string     `json:"comment,omitempty"`
+	Channel       string     `json:"channel,omitempty"` // Por dónde respondió: widget, email o web
+	SentAt        time.Time  `json:"sentAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	RespondedAt   *time.Time `json:"respondedAt,omitempty"`
+}
+
+// CSATResponseRequest es la respuesta del cliente a una encuesta. El token firmado
+// sustituye a la autenticación: llega en el enlace del correo o en el evento del widget.
+type CSATResponseRequest struct {
+	Token   string `json:"token"`
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// CSATAggregate resume las encuestas de un agente, equipo, categoría o periodo
+type CSATAggregate struct {
+	Key          string  `json:"key"`
+	Label        string  `json:"label,omitempty"`
+	Sent         int     `json:"sent"`
+	Responses    int     `json:"responses"`
+	Satisfied    int     `json:"satisfied"`    // Valoraciones de 4 o 5
+	ResponseRate float64 `json:"responseRate"` // Porcentaje de encuestas respondidas
+	Average      float64 `json:"average"`      // Valoración media
+	Score        float64 `json:"score"`        // CSAT: porcentaje de respuestas satisfechas
+}
+
+// CSATReport es la respuesta de GET /api/csat/report
+type CSATReport struct {
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+	Period     string          `json:"period"`
+	Overall    CSATAggregate   `json:"overall"`
+	ByAgent    []CSATAggregate `json:"byAgent"`
+	ByTeam     []CSATAggregate `json:"byTeam"`
+	ByCategory []CSATAggregate `json:"byCategory"`
+	ByPeriod   []CSATAggregate `json:"byPeriod"`
+}
+
+// ReportQuery son los parámetros comunes de /api/reports/*. From y To (exclusivo) son
+// instantes; Location es la zona horaria con la que se agrupa por día o semana.
+type ReportQuery struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Location *time.Location `json:"-"`
+	TimeZone string         `json:"timeZone"`
+	GroupBy  string         `json:"groupBy"` // day, week, category, priority, source, widget o agent
+
+	// Filtros opcionales
+	CategoryID string `json:"categoryId,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+	Source     string `json:"source,omitempty"`
+	WidgetID   string `json:"widgetId,omitempty"`
+	AgentID    string `json:"agentId,omitempty"`
+	TeamID     string `json:"teamId,omitempty"`
+}
+
+// ReportBucket es un intervalo de tiempo de un informe agrupado por día o semana
+type ReportBucket struct {
+	Key string    // 2026-10-19 o 2026-W43
+	End time.Time // Fin exclusivo del intervalo
+}
+
+// VolumeRow son los tickets creados y resueltos de un grupo
+type VolumeRow struct {
+	Key      string `json:"key"`
+	Label    string `json:"label,omitempty"`
+	Created  int    `json:"created"`
+	Resolved int    `json:"resolved"`
+}
+
+// DurationRow resume una duración (primera respuesta o resolución) de un grupo. Count son
+// los tickets medidos; Pending, en primera respuesta, los que aún no tienen respuesta.
+type DurationRow struct {
+	Key            string  `json:"key"`
+	Label          string  `json:"label,omitempty"`
+	Count          int     `json:"count"`
+	Pending        int     `json:"pending,omitempty"`
+	AverageSeconds float64 `json:"averageSeconds"`
+	MedianSeconds  float64 `json:"medianSeconds"`
+	P90Seconds     float64 `json:"p90Seconds"`
+}
+
+// BacklogRow son los tickets abiertos de un grupo: al final de cada intervalo si se
+// agrupa por día o semana, o ahora si se agrupa por otra dimensión
+type BacklogRow struct {
+	Key               string  `json:"key"`
+	Label             string  `json:"label,omitempty"`
+	Open              int     `json:"open"`
+	AverageAgeSeconds float64 `json:"averageAgeSeconds,omitempty"`
+}
+
+// AgentWorkloadRow es la carga de trabajo de un agente en el periodo
+type AgentWorkloadRow struct {
+	AgentID                     string  `json:"agentId"`
+	Label                       string  `json:"label,omitempty"`
+	Open                        int     `json:"open"`     // Tickets abiertos asignados ahora
+	Assigned                    int     `json:"assigned"` // Tickets creados en el periodo y asignados al agente
+	Resolved                    int     `json:"resolved"` // Tickets del agente resueltos en el periodo
+	Replies                     int     `json:"replies"`  // Respuestas públicas enviadas en el periodo
+	AverageFirstResponseSeconds float64 `json:"averageFirstResponseSeconds"`
+	MedianResolutionSeconds     float64 `json:"medianResolutionSeconds"`
+}
+
+// ReportResponse envuelve las filas de un informe con los parámetros usados
+type ReportResponse struct {
+	Metric      string      `json:"metric"`
+	Query       ReportQuery `json:"query"`
+	DataAsOf    time.Time   `json:"dataAsOf"` // Momento de los datos (la vista materializada puede ir por detrás)
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Rows        interface{} `json:"rows"`
+}
+
+// ReportSchedule es una exportación programada de tickets, mensajes o métricas
+type ReportSchedule struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"` // Expresión cron de 5 campos o @daily, @weekly, @monthly...
+	TimeZone string `json:"timeZone"` // Zona horaria de la expresión y del periodo (IANA)
+	Dataset  string `json:"dataset"`  // tickets, messages, volume, first-response, resolution, backlog o agents
+	Format   string `json:"format"`   // csv o xlsx
+
+	RangeDays int            `json:"rangeDays"`         // Días completos anteriores a la ejecución que cubre
+	GroupBy   string         `json:"groupBy,omitempty"` // Agrupación de las métricas
+	Filters   ReportFilters  `json:"filters"`
+	Delivery  ReportDelivery `json:"delivery"`
+	Enabled   bool           `json:"enabled"`
+	CreatedBy string         `json:"createdBy,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	LastRunAt *time.Time     `json:"lastRunAt,omitempty"`
+	NextRunAt *time.Time     `json:"nextRunAt,omitempty"`
+}
+
+// ReportFilters son los filtros opcionales de una exportación
+type ReportFilters struct {
+	CategoryID string `json:"categoryId,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+	Source     string `json:"source,omitempty"`
+	WidgetID   string `json:"widgetId,omitempty"`
+	AgentID    string `json:"agentId,omitempty"`
+	TeamID     string `json:"teamId,omitempty"`
+}
+
+// ReportDelivery indica a quién se entrega cada ejecución
+type ReportDelivery struct {
+	Emails     []string `json:"emails,omitempty"`     // Reciben el archivo adjunto
+	WebhookURL string   `json:"webhookUrl,omitempty"` // Recibe el evento report.export con un enlace de descarga firmado
+}
+
+// ReportScheduleRequest crea o modifica una exportación programada
+type ReportScheduleRequest struct {
+	Name      *string         `json:"name,omitempty"`
+	Schedule  *string         `json:"schedule,omitempty"`
+	TimeZone  *string         `json:"timeZone,omitempty"`
+	Dataset   *string         `json:"dataset,omitempty"`
+	Format    *string         `json:"format,omitempty"`
+	RangeDays *int            `json:"rangeDays,omitempty"`
+	GroupBy   *string         `json:"groupBy,omitempty"`
+	Filters   *ReportFilters  `json:"filters,omitempty"`
+	Delivery  *ReportDelivery `json:"delivery,omitempty"`
+	Enabled   *bool           `json:"enabled,omitempty"`
+}
+
+// ReportExport es una ejecución de una exportación: su historial y el archivo generado
+type ReportExport struct {
+	ID          string                 `json:"id"`
+	ScheduleID  string                 `json:"scheduleId"`
+	Name        string                 `json:"name"`
+	Dataset     string                 `json:"dataset"`
+	Format      string                 `json:"format"`
+	Status      string                 `json:"status"` // running, succeeded o failed
+	Error       string                 `json:"error,omitempty"`
+	FileName    string                 `json:"fileName,omitempty"`
+	Size        int64                  `json:"size"`
+	Rows        int                    `json:"rows"`
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	TriggeredBy string                 `json:"triggeredBy"` // schedule o el ID del usuario que la lanzó
+	StartedAt   time.Time              `json:"startedAt"`
+	FinishedAt  *time.Time             `json:"finishedAt,omitempty"`
+	Deliveries  []ReportExportDelivery `json:"deliveries,omitempty"`
+}
+
+// ReportExportDelivery es el resultado de entregar una ejecución por un canal
+type ReportExportDelivery struct {
+	Channel string `json:"channel"` // email o webhook
+	Target  string `json:"target"`
+	Status  string `json:"status"` // sent o failed
+	Error   string `json:"error,omitempty"`
 }
@@ -0,0 +1,213 @@
+// Package notify envía avisos fuera de la aplicación: correos por SMTP y webhooks HTTP
+// firmados. La configuración se lee del entorno:
+//
+//	SMTP_HOST, SMTP_PORT (587), SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+//	WEBHOOK_SECRET   clave para firmar los webhooks (cabecera X-GrowDesk-Signature)
+//
+// Sin SMTP_HOST los correos se descartan con ErrEmailDisabled.
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEmailDisabled indica que no hay servidor SMTP configurado
+var ErrEmailDisabled = errors.New("envío de correo deshabilitado: SMTP_HOST no está definido")
+
+// webhookTimeout limita cada entrega de webhook
+const webhookTimeout = 10 * time.Second
+
+// Email es un correo de texto plano, con archivos adjuntos opcionales
+type Email struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment es un archivo adjunto de un correo
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Sender envía correos y webhooks con una configuración fija
+type Sender struct {
+	smtpHost      string
+	smtpPort      string
+	smtpUsername  string
+	smtpPassword  string
+	from          string
+	webhookSecret string
+	client        *http.Client
+}
+
+var (
+	defaultSender *Sender
+	defaultOnce   sync.Once
+)
+
+// Default devuelve el Sender configurado desde el entorno
+func Default() *Sender {
+	defaultOnce.Do(func() {
+		defaultSender = &Sender{
+			smtpHost:      os.Getenv("SMTP_HOST"),
+			smtpPort:      envOr("SMTP_PORT", "587"),
+			smtpUsername:  os.Getenv("SMTP_USERNAME"),
+			smtpPassword:  os.Getenv("SMTP_PASSWORD"),
+			from:          envOr("SMTP_FROM", "soporte@growdesk.com"),
+			webhookSecret: os.Getenv("WEBHOOK_SECRET"),
+			client:        &http.Client{Timeout: webhookTimeout},
+		}
+	})
+	return defaultSender
+}
+
+// EmailEnabled indica si hay un servidor SMTP configurado
+func (s *Sender) EmailEnabled() bool {
+	return s.smtpHost != ""
+}
+
+// SendEmail envía un correo de texto plano
+func (s *Sender) SendEmail(email Email) error {
+	if !s.EmailEnabled() {
+		return ErrEmailDisabled
+	}
+	if len(email.To) == 0 {
+		return fmt.Errorf("el correo no tiene destinatarios")
+	}
+	for _, to := range email.To {
+		if err := ValidateEmail(to); err != nil {
+			return err
+		}
+	}
+
+	var auth smtp.Auth
+	if s.smtpUsername != "" {
+		auth = smtp.PlainAuth("", s.smtpUsername, s.smtpPassword, s.smtpHost)
+	}
+	return smtp.SendMail(s.smtpHost+":"+s.smtpPort, auth, s.from, email.To, s.buildMessage(email))
+}
+
+// buildMessage compone las cabeceras y el cuerpo del correo
+func (s *Sender) buildMessage(email Email) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	body := strings.ReplaceAll(email.Body, "\n", "\r\n")
+	if len(email.Attachments) == 0 {
+		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		msg.WriteString(body)
+		return msg.Bytes()
+	}
+
+	parts := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", parts.Boundary())
+	text, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	text.Write([]byte(body))
+	for _, attachment := range email.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+	parts.Close()
+	return msg.Bytes()
+}
+
+// PostWebhook envía payload como JSON a url. Si hay WEBHOOK_SECRET, la cabecera
+// X-GrowDesk-Signature lleva "sha256=" y el HMAC-SHA256 del cuerpo.
+func (s *Sender) PostWebhook(target, event string, payload interface{}) error {
+	if err := ValidateWebhookURL(target); err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error al serializar webhook: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error al crear webhook: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GrowDesk-Webhook/1.0")
+	req.Header.Set("X-GrowDesk-Event", event)
+	if s.webhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-GrowDesk-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al enviar webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("el webhook respondió con código %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ValidateEmail comprueba que la dirección sea un email simple (sin nombre)
+func ValidateEmail(address string) error {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address {
+		return fmt.Errorf("email inválido %q", address)
+	}
+	return nil
+}
+
+// ValidateWebhookURL comprueba que la URL sea http(s) y tenga host
+func ValidateWebhookURL(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("URL de webhook inválida %q", target)
+	}
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,206 @@
+// Package presence calcula si un agente está conectado y en su horario laboral, y a partir
+// de ahí y del horario de atención si el widget puede ofrecer chat en vivo.
+package presence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/businesshours"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// heartbeatTTL es el tiempo que un agente sigue conectado tras su último latido si no
+// tiene ninguna conexión WebSocket abierta
+const heartbeatTTL = 2 * time.Minute
+
+// Tracker lleva la cuenta de las conexiones WebSocket y los latidos de cada agente.
+// El estado vive en memoria: tras un reinicio los agentes vuelven a aparecer al reconectar.
+type Tracker struct {
+	mu          sync.Mutex
+	connections map[string]int
+	lastSeen    map[string]time.Time
+}
+
+// NewTracker crea un registro de presencia vacío
+func NewTracker() *Tracker {
+	return &Tracker{
+		connections: make(map[string]int),
+		lastSeen:    make(map[string]time.Time),
+	}
+}
+
+// defaultTracker es el registro que comparten el WebSocket y los manejadores HTTP
+var defaultTracker = NewTracker()
+
+// Default devuelve el registro de presencia del proceso
+func Default() *Tracker {
+	return defaultTracker
+}
+
+// Connect registra una conexión WebSocket abierta por el agente
+func (t *Tracker) Connect(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connections[userID]++
+	t.lastSeen[userID] = time.Now()
+}
+
+// Disconnect registra el cierre de una conexión del agente
+func (t *Tracker) Disconnect(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.connections[userID] <= 1 {
+		delete(t.connections, userID)
+	} else {
+		t.connections[userID]--
+	}
+	t.lastSeen[userID] = time.Now()
+}
+
+// Touch registra un latido del agente (p. ej. el panel abierto sin ningún chat activo)
+func (t *Tracker) Touch(userID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastSeen[userID] = time.Now()
+}
+
+// State devuelve las conexiones abiertas del agente, su última actividad y si se le
+// considera conectado en el instante now
+func (t *Tracker) State(userID string, now time.Time) (connections int, lastSeen time.Time, connected bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	connections = t.connections[userID]
+	lastSeen = t.lastSeen[userID]
+	connected = connections > 0 || (!lastSeen.IsZero() && now.Sub(lastSeen) < heartbeatTTL)
+	return connections, lastSeen, connected
+}
+
+// OnShift indica si el instante now cae dentro de algún turno del horario. Un agente
+// sin horario se considera siempre en turno.
+func OnShift(schedule *models.WorkingSchedule, now time.Time) bool {
+	if schedule == nil {
+		return true
+	}
+	calendar, err := businesshours.FromSchedule(*schedule)
+	if err != nil {
+		return false
+	}
+	return calendar.IsOpen(now)
+}
+
+// Of calcula la presencia efectiva de un agente. La disponibilidad manual manda sobre el
+// resto: un agente "offline" nunca aparece conectado. Fuera de turno o sin conexión el
+// agente no puede atender chats aunque se haya marcado como disponible.
+func Of(tracker *Tracker, user models.User, now time.Time) models.AgentPresence {
+	connections, lastSeen, connected := tracker.State(user.ID, now)
+	availability := user.Availability
+	if availability == "" {
+		availability = models.AvailabilityOffline
+	}
+
+	p := models.AgentPresence{
+		UserID:       user.ID,
+		Name:         strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Department:   user.Department,
+		Availability: availability,
+		Connected:    connected,
+		Connections:  connections,
+		OnShift:      OnShift(user.Schedule, now),
+	}
+	if !lastSeen.IsZero() {
+		p.LastSeen = &lastSeen
+	}
+
+	switch {
+	case !user.Active || availability == models.AvailabilityOffline:
+		p.Status = models.PresenceOffline
+	case !p.OnShift:
+		p.Status = models.PresenceOffShift
+	case !connected:
+		p.Status = models.PresenceOffline
+	case availability == models.AvailabilityAway:
+		p.Status = models.PresenceAway
+	case availability == models.AvailabilityBusy:
+		p.Status = models.PresenceBusy
+	default:
+		p.Status = models.PresenceOnline
+	}
+	return p
+}
+
+// Agents devuelve la presencia de todos los agentes (usuarios que no son clientes),
+// ordenada por nombre
+func Agents(store data.DataStore, tracker *Tracker, now time.Time) ([]models.AgentPresence, error) {
+	users, err := store.GetUsers()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener usuarios: %v", err)
+	}
+
+	result := make([]models.AgentPresence, 0, len(users))
+	for _, user := range users {
+		if user.Role == "customer" || user.ID == "widget-system" {
+			continue
+		}
+		result = append(result, Of(tracker, user, now))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+	return result, nil
+}
+
+// WidgetAvailability decide si el widget ofrece chat en vivo: hace falta estar dentro del
+// horario de atención (el del equipo, el del widget o el calendario por defecto) y tener
+// al menos un agente en línea. Si se indica un equipo, sólo cuentan sus miembros.
+func WidgetAvailability(store data.DataStore, tracker *Tracker, teamID, widgetID string, now time.Time) (*models.WidgetAvailability, error) {
+	result := &models.WidgetAvailability{Mode: models.WidgetModeLeaveMessage, Open: true}
+
+	calendar, err := businesshours.Resolve(store, teamID, widgetID)
+	if err != nil {
+		return nil, err
+	}
+	if calendar != nil {
+		status, err := businesshours.Status(*calendar, now)
+		if err != nil {
+			return nil, err
+		}
+		result.Open = status.Open
+		result.NextOpenAt = status.NextOpenAt
+	}
+
+	agents, err := Agents(store, tracker, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var members map[string]bool
+	if teamID != "" {
+		team, err := store.GetTeam(teamID)
+		if err != nil {
+			return nil, err
+		}
+		members = make(map[string]bool, len(team.Members))
+		for _, member := range team.Members {
+			members[member.UserID] = true
+		}
+	}
+
+	for _, agent := range agents {
+		if members != nil && !members[agent.UserID] {
+			continue
+		}
+		if agent.Status == models.PresenceOnline {
+			result.OnlineAgents++
+		}
+	}
+	if result.Open && result.OnlineAgents > 0 {
+		result.LiveChatAvailable = true
+		result.Mode = models.WidgetModeLiveChat
+	}
+	return result, nil
+}
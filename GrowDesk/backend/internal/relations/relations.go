@@ -0,0 +1,155 @@
+// Package relations gestiona las relaciones entre tickets (duplicado, relacionado,
+// padre/hijo y bloqueo): las guarda en un solo sentido, evita ciclos y las presenta
+// desde el punto de vista de cada ticket.
+package relations
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// maxDepth limita los recorridos de jerarquías y cadenas de bloqueo
+const maxDepth = 50
+
+// inverses relaciona cada tipo guardado con su inverso
+var inverses = map[string]string{
+	models.TicketRelationDuplicateOf: models.TicketRelationDuplicatedBy,
+	models.TicketRelationRelatedTo:   models.TicketRelationRelatedTo,
+	models.TicketRelationChildOf:     models.TicketRelationParentOf,
+	models.TicketRelationBlocks:      models.TicketRelationBlockedBy,
+}
+
+// Canonical devuelve la relación en el sentido en que se guarda: "parent_of" de A a B
+// se guarda como "child_of" de B a A
+func Canonical(ticketID, relatedID, relType string) (models.TicketRelation, error) {
+	if relatedID == "" {
+		return models.TicketRelation{}, fmt.Errorf("se requiere el ticket relacionado (ticketId)")
+	}
+	if ticketID == relatedID {
+		return models.TicketRelation{}, fmt.Errorf("un ticket no puede relacionarse consigo mismo")
+	}
+	if _, ok := inverses[relType]; ok {
+		return models.TicketRelation{TicketID: ticketID, RelatedTicketID: relatedID, Type: relType}, nil
+	}
+	for stored, inverse := range inverses {
+		if inverse == relType {
+			return models.TicketRelation{TicketID: relatedID, RelatedTicketID: ticketID, Type: stored}, nil
+		}
+	}
+	return models.TicketRelation{}, fmt.Errorf("tipo de relación inválido %q", relType)
+}
+
+// Validate comprueba una relación nueva contra las ya guardadas: dos tickets sólo se
+// relacionan una vez, un ticket tiene como mucho un padre y ni la jerarquía ni los
+// bloqueos pueden formar ciclos
+func Validate(store data.DataStore, relation models.TicketRelation) error {
+	existing, err := store.GetTicketRelations(relation.TicketID)
+	if err != nil {
+		return fmt.Errorf("error al obtener relaciones: %v", err)
+	}
+	for _, other := range existing {
+		if other.TicketID == relation.RelatedTicketID || other.RelatedTicketID == relation.RelatedTicketID {
+			return fmt.Errorf("los tickets %s y %s ya están relacionados", relation.TicketID, relation.RelatedTicketID)
+		}
+	}
+
+	switch relation.Type {
+	case models.TicketRelationChildOf:
+		if parent := Parent(existing, relation.TicketID); parent != "" {
+			return fmt.Errorf("el ticket %s ya es hijo de %s", relation.TicketID, parent)
+		}
+		// El nuevo padre no puede descender del hijo
+		ancestor := relation.RelatedTicketID
+		for depth := 0; ancestor != "" && depth < maxDepth; depth++ {
+			if ancestor == relation.TicketID {
+				return fmt.Errorf("la relación crearía un ciclo en la jerarquía")
+			}
+			rels, err := store.GetTicketRelations(ancestor)
+			if err != nil {
+				return fmt.Errorf("error al obtener relaciones: %v", err)
+			}
+			ancestor = Parent(rels, ancestor)
+		}
+	case models.TicketRelationBlocks:
+		// El ticket bloqueado no puede bloquear, directa o indirectamente, al que lo bloquea
+		pending, seen := []string{relation.RelatedTicketID}, map[string]bool{}
+		for len(pending) > 0 && len(seen) < maxDepth {
+			current := pending[0]
+			pending = pending[1:]
+			if current == relation.TicketID {
+				return fmt.Errorf("la relación crearía un ciclo de bloqueos")
+			}
+			if seen[current] {
+				continue
+			}
+			seen[current] = true
+			rels, err := store.GetTicketRelations(current)
+			if err != nil {
+				return fmt.Errorf("error al obtener relaciones: %v", err)
+			}
+			for _, rel := range rels {
+				if rel.Type == models.TicketRelationBlocks && rel.TicketID == current {
+					pending = append(pending, rel.RelatedTicketID)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Parent devuelve el padre de ticketID según sus relaciones, o "" si no tiene
+func Parent(rels []models.TicketRelation, ticketID string) string {
+	for _, rel := range rels {
+		if rel.Type == models.TicketRelationChildOf && rel.TicketID == ticketID {
+			return rel.RelatedTicketID
+		}
+	}
+	return ""
+}
+
+// Children devuelve los hijos de ticketID según sus relaciones
+func Children(rels []models.TicketRelation, ticketID string) []string {
+	var children []string
+	for _, rel := range rels {
+		if rel.Type == models.TicketRelationChildOf && rel.RelatedTicketID == ticketID {
+			children = append(children, rel.TicketID)
+		}
+	}
+	return children
+}
+
+// Link presenta una relación desde ticketID, con el título y el estado del otro ticket
+func Link(store data.DataStore, ticketID string, rel models.TicketRelation) models.TicketLink {
+	link := models.TicketLink{ID: rel.ID, Type: rel.Type, TicketID: rel.RelatedTicketID, CreatedAt: rel.CreatedAt}
+	if rel.TicketID != ticketID {
+		link.Type = inverses[rel.Type]
+		link.TicketID = rel.TicketID
+	}
+	if other, err := store.GetTicket(link.TicketID); err == nil {
+		link.Title = other.Title
+		link.Status = other.Status
+	}
+	return link
+}
+
+// Links presenta las relaciones de ticketID ordenadas por tipo y fecha
+func Links(store data.DataStore, ticketID string) ([]models.TicketLink, error) {
+	rels, err := store.GetTicketRelations(ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener relaciones: %v", err)
+	}
+	links := make([]models.TicketLink, 0, len(rels))
+	for _, rel := range rels {
+		links = append(links, Link(store, ticketID, rel))
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Type != links[j].Type {
+			return links[i].Type < links[j].Type
+		}
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+	return links, nil
+}
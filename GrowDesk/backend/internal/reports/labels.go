@@ -0,0 +1,42 @@
+package reports
+
+import (
+	"strings"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// NameSource da nombre a los agentes y categorías; lo implementa data.DataStore
+type NameSource interface {
+	GetUsers() ([]models.User, error)
+	GetCategories() ([]models.Category, error)
+}
+
+// Labeler devuelve el nombre legible de cada grupo: el nombre del agente o de la
+// categoría, o un texto para los grupos sin valor
+func Labeler(source NameSource, groupBy string) func(string) string {
+	names := make(map[string]string)
+	switch groupBy {
+	case GroupAgent:
+		if users, err := source.GetUsers(); err == nil {
+			for _, user := range users {
+				names[user.ID] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+			}
+		}
+	case GroupCategory:
+		if categories, err := source.GetCategories(); err == nil {
+			for _, category := range categories {
+				names[category.ID] = category.Name
+			}
+		}
+	}
+	return func(key string) string {
+		switch key {
+		case KeyUnassigned:
+			return "Sin asignar"
+		case KeyNone:
+			return "Sin definir"
+		}
+		return names[key]
+	}
+}
@@ -0,0 +1,45 @@
+package reports
+
+import (
+	"os"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// DefaultRefreshInterval es cada cuánto se refrescan los datos de los informes si no se
+// configura REPORTS_REFRESH_INTERVAL
+const DefaultRefreshInterval = 5 * time.Minute
+
+// Refresher recalcula los datos de los informes; lo implementa data.DataStore
+type Refresher interface {
+	RefreshReports() (time.Time, error)
+}
+
+// RefreshInterval lee REPORTS_REFRESH_INTERVAL (p. ej. 1m o 15m)
+func RefreshInterval() time.Duration {
+	value := os.Getenv("REPORTS_REFRESH_INTERVAL")
+	if value == "" {
+		return DefaultRefreshInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		logging.Warnf("⚠️ REPORTS_REFRESH_INTERVAL inválido (%q), se usa %s", value, DefaultRefreshInterval)
+		return DefaultRefreshInterval
+	}
+	return interval
+}
+
+// StartRefresher refresca los datos de los informes ahora y después cada interval
+func StartRefresher(store Refresher, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := store.RefreshReports(); err != nil {
+				logging.Warnf("⚠️ No se pudieron refrescar los informes: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
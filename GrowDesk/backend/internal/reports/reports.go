@@ -0,0 +1,439 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// Agrupaciones de los informes
+const (
+	GroupDay      = "day"
+	GroupWeek     = "week"
+	GroupCategory = "category"
+	GroupPriority = "priority"
+	GroupSource   = "source"
+	GroupWidget   = "widget"
+	GroupAgent    = "agent"
+)
+
+// Claves de los grupos sin valor
+const (
+	KeyNone       = "none"
+	KeyUnassigned = "unassigned"
+)
+
+// ValidGroupBy indica si groupBy es una agrupación conocida
+func ValidGroupBy(groupBy string) bool {
+	switch groupBy {
+	case GroupDay, GroupWeek, GroupCategory, GroupPriority, GroupSource, GroupWidget, GroupAgent:
+		return true
+	}
+	return false
+}
+
+// IsTimeGroup indica si se agrupa por intervalos de tiempo
+func IsTimeGroup(groupBy string) bool {
+	return groupBy == GroupDay || groupBy == GroupWeek
+}
+
+// Resolved indica si el estado cuenta como resuelto
+func Resolved(status string) bool {
+	return status == "resolved" || status == "closed"
+}
+
+// Fact son los datos de un ticket que usan los informes; equivale a una fila de la vista
+// ticket_report_facts de PostgreSQL
+type Fact struct {
+	ID              string
+	CreatedAt       time.Time
+	ResolvedAt      *time.Time
+	FirstResponseAt *time.Time // Primera respuesta pública de un agente
+	Status          string
+	Priority        string
+	CategoryID      string
+	Source          string
+	WidgetID        string
+	AssignedTo      string
+	TeamID          string
+}
+
+// Reply es una respuesta pública de un agente
+type Reply struct {
+	TicketID string
+	UserID   string
+	At       time.Time
+}
+
+// NewFact extrae los datos del ticket. Los tickets resueltos antes de que existiera
+// resolvedAt usan la fecha de su última actualización.
+func NewFact(ticket models.Ticket) Fact {
+	fact := Fact{
+		ID:         ticket.ID,
+		CreatedAt:  ticket.CreatedAt,
+		ResolvedAt: ticket.ResolvedAt,
+		Status:     ticket.Status,
+		Priority:   ticket.Priority,
+		CategoryID: ticket.CategoryID,
+		Source:     ticket.Source,
+		WidgetID:   ticket.WidgetID,
+		AssignedTo: ticket.AssignedTo,
+		TeamID:     ticket.TeamID,
+	}
+	if fact.ResolvedAt == nil && Resolved(ticket.Status) {
+		updated := ticket.UpdatedAt
+		fact.ResolvedAt = &updated
+	}
+	for _, message := range ticket.Messages {
+		if message.IsClient || message.IsInternal {
+			continue
+		}
+		if fact.FirstResponseAt == nil || message.Timestamp.Before(*fact.FirstResponseAt) {
+			at := message.Timestamp
+			fact.FirstResponseAt = &at
+		}
+	}
+	return fact
+}
+
+// Replies devuelve las respuestas públicas de agentes del ticket
+func Replies(ticket models.Ticket) []Reply {
+	var replies []Reply
+	for _, message := range ticket.Messages {
+		if !message.IsClient && !message.IsInternal && message.UserID != "" {
+			replies = append(replies, Reply{TicketID: ticket.ID, UserID: message.UserID, At: message.Timestamp})
+		}
+	}
+	return replies
+}
+
+// Matches indica si el ticket cumple los filtros de la consulta (no las fechas)
+func (f Fact) Matches(query models.ReportQuery) bool {
+	switch {
+	case query.CategoryID != "" && f.CategoryID != query.CategoryID:
+		return false
+	case query.Priority != "" && f.Priority != query.Priority:
+		return false
+	case query.Source != "" && f.Source != query.Source:
+		return false
+	case query.WidgetID != "" && f.WidgetID != query.WidgetID:
+		return false
+	case query.AgentID != "" && f.AssignedTo != query.AgentID:
+		return false
+	case query.TeamID != "" && f.TeamID != query.TeamID:
+		return false
+	}
+	return true
+}
+
+// Key devuelve el grupo del ticket para una agrupación por dimensión
+func (f Fact) Key(groupBy string) string {
+	var key string
+	switch groupBy {
+	case GroupCategory:
+		key = f.CategoryID
+	case GroupPriority:
+		key = f.Priority
+	case GroupSource:
+		key = f.Source
+	case GroupWidget:
+		key = f.WidgetID
+	case GroupAgent:
+		if f.AssignedTo == "" {
+			return KeyUnassigned
+		}
+		return f.AssignedTo
+	}
+	if key == "" {
+		return KeyNone
+	}
+	return key
+}
+
+// groupKey devuelve el grupo del ticket para el instante t, que es la fecha que decide el
+// intervalo cuando se agrupa por tiempo
+func (f Fact) groupKey(t time.Time, query models.ReportQuery) string {
+	if IsTimeGroup(query.GroupBy) {
+		return BucketKey(t, query.GroupBy, query.Location)
+	}
+	return f.Key(query.GroupBy)
+}
+
+// BucketStart devuelve el inicio del día, o del lunes de la semana, de t en loc
+func BucketStart(t time.Time, groupBy string, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	if groupBy == GroupWeek {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+// BucketKey devuelve el intervalo de t en loc: 2026-10-19 o 2026-W43 (semana ISO)
+func BucketKey(t time.Time, groupBy string, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	if groupBy == GroupWeek {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01-02")
+}
+
+// Buckets devuelve los intervalos que cubren [From, To) según la agrupación
+func Buckets(query models.ReportQuery) []models.ReportBucket {
+	var buckets []models.ReportBucket
+	for start := BucketStart(query.From, query.GroupBy, query.Location); start.Before(query.To); {
+		end := start.AddDate(0, 0, 1)
+		if query.GroupBy == GroupWeek {
+			end = start.AddDate(0, 0, 7)
+		}
+		buckets = append(buckets, models.ReportBucket{Key: BucketKey(start, query.GroupBy, query.Location), End: end})
+		start = end
+	}
+	return buckets
+}
+
+// inRange indica si t está en [From, To)
+func inRange(t time.Time, query models.ReportQuery) bool {
+	return !t.Before(query.From) && t.Before(query.To)
+}
+
+// Volume cuenta los tickets creados y resueltos en el periodo. Cada ticket cuenta en el
+// intervalo en que se creó y en el que se resolvió.
+func Volume(facts []Fact, query models.ReportQuery) []models.VolumeRow {
+	rows := make(map[string]*models.VolumeRow)
+	row := func(key string) *models.VolumeRow {
+		if rows[key] == nil {
+			rows[key] = &models.VolumeRow{Key: key}
+		}
+		return rows[key]
+	}
+	if IsTimeGroup(query.GroupBy) {
+		for _, bucket := range Buckets(query) {
+			row(bucket.Key)
+		}
+	}
+
+	for _, fact := range facts {
+		if !fact.Matches(query) {
+			continue
+		}
+		if inRange(fact.CreatedAt, query) {
+			row(fact.groupKey(fact.CreatedAt, query)).Created++
+		}
+		if fact.ResolvedAt != nil && inRange(*fact.ResolvedAt, query) {
+			row(fact.groupKey(*fact.ResolvedAt, query)).Resolved++
+		}
+	}
+
+	result := make([]models.VolumeRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// FirstResponse mide el tiempo hasta la primera respuesta de los tickets creados en el
+// periodo, agrupados por la fecha de creación
+func FirstResponse(facts []Fact, query models.ReportQuery) []models.DurationRow {
+	durations := make(map[string][]float64)
+	pending := make(map[string]int)
+	for _, fact := range facts {
+		if !fact.Matches(query) || !inRange(fact.CreatedAt, query) {
+			continue
+		}
+		key := fact.groupKey(fact.CreatedAt, query)
+		if fact.FirstResponseAt == nil {
+			pending[key]++
+			if _, ok := durations[key]; !ok {
+				durations[key] = nil
+			}
+			continue
+		}
+		durations[key] = append(durations[key], math.Max(0, fact.FirstResponseAt.Sub(fact.CreatedAt).Seconds()))
+	}
+	return durationRows(durations, pending, query)
+}
+
+// Resolution mide el tiempo desde la creación hasta la resolución de los tickets
+// resueltos en el periodo, agrupados por la fecha de resolución
+func Resolution(facts []Fact, query models.ReportQuery) []models.DurationRow {
+	durations := make(map[string][]float64)
+	for _, fact := range facts {
+		if !fact.Matches(query) || fact.ResolvedAt == nil || !inRange(*fact.ResolvedAt, query) {
+			continue
+		}
+		key := fact.groupKey(*fact.ResolvedAt, query)
+		durations[key] = append(durations[key], math.Max(0, fact.ResolvedAt.Sub(fact.CreatedAt).Seconds()))
+	}
+	return durationRows(durations, nil, query)
+}
+
+func durationRows(durations map[string][]float64, pending map[string]int, query models.ReportQuery) []models.DurationRow {
+	if IsTimeGroup(query.GroupBy) {
+		for _, bucket := range Buckets(query) {
+			if _, ok := durations[bucket.Key]; !ok {
+				durations[bucket.Key] = nil
+			}
+		}
+	}
+
+	rows := make([]models.DurationRow, 0, len(durations))
+	for key, values := range durations {
+		sort.Float64s(values)
+		row := models.DurationRow{Key: key, Count: len(values), Pending: pending[key]}
+		if len(values) > 0 {
+			var sum float64
+			for _, value := range values {
+				sum += value
+			}
+			row.AverageSeconds = Round(sum / float64(len(values)))
+			row.MedianSeconds = Round(Percentile(values, 0.5))
+			row.P90Seconds = Round(Percentile(values, 0.9))
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+	return rows
+}
+
+// Backlog cuenta los tickets abiertos. Agrupado por día o semana, son los abiertos al final
+// de cada intervalo (o ahora, para el intervalo en curso); agrupado por otra dimensión, son
+// los abiertos ahora creados antes del final del periodo.
+func Backlog(facts []Fact, query models.ReportQuery, now time.Time) []models.BacklogRow {
+	var rows []models.BacklogRow
+	if IsTimeGroup(query.GroupBy) {
+		for _, bucket := range Buckets(query) {
+			at := bucket.End
+			if at.After(now) {
+				at = now
+			}
+			row := models.BacklogRow{Key: bucket.Key}
+			var age float64
+			for _, fact := range facts {
+				if fact.Matches(query) && fact.CreatedAt.Before(at) && (fact.ResolvedAt == nil || fact.ResolvedAt.After(at)) {
+					row.Open++
+					age += at.Sub(fact.CreatedAt).Seconds()
+				}
+			}
+			if row.Open > 0 {
+				row.AverageAgeSeconds = Round(age / float64(row.Open))
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	groups := make(map[string]*models.BacklogRow)
+	ages := make(map[string]float64)
+	for _, fact := range facts {
+		if !fact.Matches(query) || Resolved(fact.Status) || !fact.CreatedAt.Before(query.To) {
+			continue
+		}
+		key := fact.Key(query.GroupBy)
+		if groups[key] == nil {
+			groups[key] = &models.BacklogRow{Key: key}
+		}
+		groups[key].Open++
+		ages[key] += now.Sub(fact.CreatedAt).Seconds()
+	}
+	for key, row := range groups {
+		row.AverageAgeSeconds = Round(ages[key] / float64(row.Open))
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+	return rows
+}
+
+// AgentWorkload resume por agente los tickets abiertos asignados ahora, los creados y
+// resueltos en el periodo, y las respuestas que envió. El filtro de agente elige la fila;
+// los demás filtros se aplican a los tickets.
+func AgentWorkload(facts []Fact, replies []Reply, query models.ReportQuery) []models.AgentWorkloadRow {
+	ticketQuery := query
+	ticketQuery.AgentID = ""
+
+	rows := make(map[string]*models.AgentWorkloadRow)
+	row := func(key string) *models.AgentWorkloadRow {
+		if rows[key] == nil {
+			rows[key] = &models.AgentWorkloadRow{AgentID: key}
+		}
+		return rows[key]
+	}
+	firstResponses := make(map[string][]float64)
+	resolutions := make(map[string][]float64)
+	matching := make(map[string]bool, len(facts))
+
+	for _, fact := range facts {
+		if !fact.Matches(ticketQuery) {
+			continue
+		}
+		matching[fact.ID] = true
+		key := fact.Key(GroupAgent)
+		if !Resolved(fact.Status) {
+			row(key).Open++
+		}
+		if inRange(fact.CreatedAt, query) {
+			row(key).Assigned++
+			if fact.FirstResponseAt != nil {
+				firstResponses[key] = append(firstResponses[key], math.Max(0, fact.FirstResponseAt.Sub(fact.CreatedAt).Seconds()))
+			}
+		}
+		if fact.ResolvedAt != nil && inRange(*fact.ResolvedAt, query) {
+			row(key).Resolved++
+			resolutions[key] = append(resolutions[key], math.Max(0, fact.ResolvedAt.Sub(fact.CreatedAt).Seconds()))
+		}
+	}
+	for _, reply := range replies {
+		if matching[reply.TicketID] && inRange(reply.At, query) {
+			row(reply.UserID).Replies++
+		}
+	}
+
+	result := make([]models.AgentWorkloadRow, 0, len(rows))
+	for key, r := range rows {
+		if query.AgentID != "" && key != query.AgentID {
+			continue
+		}
+		if values := firstResponses[key]; len(values) > 0 {
+			var sum float64
+			for _, value := range values {
+				sum += value
+			}
+			r.AverageFirstResponseSeconds = Round(sum / float64(len(values)))
+		}
+		if values := resolutions[key]; len(values) > 0 {
+			sort.Float64s(values)
+			r.MedianResolutionSeconds = Round(Percentile(values, 0.5))
+		}
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AgentID < result[j].AgentID })
+	return result
+}
+
+// Percentile interpola linealmente entre los valores ordenados, igual que percentile_cont
+// de PostgreSQL
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// Round redondea los segundos a un decimal
+func Round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
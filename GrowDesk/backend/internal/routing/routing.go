@@ -0,0 +1,266 @@
+// Package routing evalúa las reglas de enrutamiento que deciden la categoría,
+// el departamento, la prioridad, las etiquetas y la asignación de un ticket nuevo.
+package routing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// Input son los datos del ticket sobre los que se evalúan las condiciones
+type Input struct {
+	WidgetID string    `json:"widgetId,omitempty"`
+	TeamID   string    `json:"teamId,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Email    string    `json:"email,omitempty"`
+	Subject  string    `json:"subject,omitempty"`
+	Body     string    `json:"body,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	Time     time.Time `json:"time,omitempty"` // Momento de creación; ahora si está vacío
+}
+
+// Match identifica una regla que coincidió
+type Match struct {
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+}
+
+// Result es el resultado de evaluar las reglas
+type Result struct {
+	Matched []Match               `json:"matched"`
+	Actions models.RoutingActions `json:"actions"`
+}
+
+// InputFromTicket extrae los datos de enrutamiento de un ticket
+func InputFromTicket(ticket models.Ticket) Input {
+	in := Input{
+		WidgetID: ticket.WidgetID,
+		TeamID:   ticket.TeamID,
+		Source:   ticket.Source,
+		Email:    ticket.Customer.Email,
+		Subject:  ticket.Title,
+		Body:     ticket.Description,
+		Tags:     ticket.Tags,
+		Time:     ticket.CreatedAt,
+	}
+	if ticket.Subject != "" && ticket.Subject != ticket.Title {
+		in.Subject = ticket.Title + " " + ticket.Subject
+	}
+	if ticket.Metadata != nil {
+		in.URL = ticket.Metadata.URL
+		if in.WidgetID == "" {
+			in.WidgetID = ticket.Metadata.WidgetID
+		}
+		if in.Source == "" {
+			in.Source = ticket.Metadata.Source
+		}
+		if in.Email == "" {
+			in.Email = ticket.Metadata.ClientEmail
+		}
+	}
+	return in
+}
+
+// Sort ordena las reglas por posición y, a igual posición, por fecha de creación
+func Sort(rules []models.RoutingRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Position != rules[j].Position {
+			return rules[i].Position < rules[j].Position
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+}
+
+// Hours indica si el calendario calendarID ("" = el que corresponde al ticket) está
+// abierto en in.Time. Lo implementa handlers con el paquete businesshours, que routing no
+// puede importar (data depende de routing).
+type Hours func(calendarID string, in Input) (bool, error)
+
+// Evaluate aplica las reglas activas en orden. Cada campo lo fija la primera regla que
+// lo define; las etiquetas se acumulan y las condiciones de etiqueta de una regla ven
+// también las añadidas por las reglas anteriores. Una regla con StopProcessing detiene
+// la evaluación. hours resuelve las condiciones de horario de atención.
+func Evaluate(rules []models.RoutingRule, in Input, hours Hours) Result {
+	if in.Time.IsZero() {
+		in.Time = time.Now()
+	}
+
+	ordered := make([]models.RoutingRule, len(rules))
+	copy(ordered, rules)
+	Sort(ordered)
+
+	result := Result{Matched: make([]Match, 0)}
+	initialTags := in.Tags
+	for _, rule := range ordered {
+		in.Tags = appendTags(append([]string(nil), initialTags...), result.Actions.Tags)
+		if !rule.Enabled || !Matches(rule.Conditions, in, hours) {
+			continue
+		}
+		result.Matched = append(result.Matched, Match{RuleID: rule.ID, RuleName: rule.Name})
+		mergeActions(&result.Actions, rule.Actions)
+		if rule.StopProcessing {
+			break
+		}
+	}
+	return result
+}
+
+// Matches indica si los datos cumplen todas las condiciones indicadas
+func Matches(cond models.RoutingConditions, in Input, hours Hours) bool {
+	if len(cond.WidgetIDs) > 0 && !containsFold(cond.WidgetIDs, in.WidgetID) {
+		return false
+	}
+	if len(cond.Sources) > 0 && !containsFold(cond.Sources, in.Source) {
+		return false
+	}
+	if len(cond.EmailDomains) > 0 && !matchesDomain(cond.EmailDomains, in.Email) {
+		return false
+	}
+	if len(cond.Keywords) > 0 && !containsAny(in.Subject+"\n"+in.Body, cond.Keywords) {
+		return false
+	}
+	if len(cond.URLContains) > 0 && !containsAny(in.URL, cond.URLContains) {
+		return false
+	}
+	if len(cond.Tags) > 0 && !containsAnyTag(in.Tags, cond.Tags) {
+		return false
+	}
+	if cond.BusinessHours != nil {
+		if hours == nil {
+			return false
+		}
+		open, err := hours(cond.BusinessHours.CalendarID, in)
+		if err != nil || open == cond.BusinessHours.Outside {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply aplica al ticket las acciones resultantes. Sólo completa los campos que el
+// ticket no trae ya; las etiquetas se añaden sin duplicados.
+func Apply(ticket *models.Ticket, actions models.RoutingActions) {
+	if actions.CategoryID != "" && ticket.CategoryID == "" {
+		ticket.CategoryID = actions.CategoryID
+	}
+	if actions.Department != "" && ticket.Department == "" {
+		ticket.Department = actions.Department
+	}
+	if actions.Priority != "" && ticket.Priority == "" {
+		ticket.Priority = actions.Priority
+	}
+	if actions.AssignTo != "" && ticket.AssignedTo == "" {
+		ticket.AssignedTo = actions.AssignTo
+	}
+	if actions.TeamID != "" && ticket.TeamID == "" {
+		ticket.TeamID = actions.TeamID
+	}
+	ticket.Tags = appendTags(ticket.Tags, actions.Tags)
+}
+
+// Validate comprueba que una regla esté bien formada
+func Validate(rule models.RoutingRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("el nombre de la regla es obligatorio")
+	}
+	switch rule.Actions.Priority {
+	case "", "low", "medium", "high", "urgent":
+	default:
+		return fmt.Errorf("prioridad inválida %q", rule.Actions.Priority)
+	}
+	a := rule.Actions
+	if a.CategoryID == "" && a.Department == "" && a.Priority == "" && len(a.Tags) == 0 && a.AssignTo == "" && a.TeamID == "" {
+		return fmt.Errorf("la regla debe definir al menos una acción")
+	}
+	return nil
+}
+
+func mergeActions(dst *models.RoutingActions, src models.RoutingActions) {
+	if dst.CategoryID == "" {
+		dst.CategoryID = src.CategoryID
+	}
+	if dst.Department == "" {
+		dst.Department = src.Department
+	}
+	if dst.Priority == "" {
+		dst.Priority = src.Priority
+	}
+	if dst.AssignTo == "" {
+		dst.AssignTo = src.AssignTo
+	}
+	if dst.TeamID == "" {
+		dst.TeamID = src.TeamID
+	}
+	dst.Tags = appendTags(dst.Tags, src.Tags)
+}
+
+func appendTags(tags, extra []string) []string {
+	for _, tag := range extra {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func containsAnyTag(tags, wanted []string) bool {
+	for _, tag := range wanted {
+		if containsFold(tags, strings.TrimSpace(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(text string, needles []string) bool {
+	text = strings.ToLower(text)
+	for _, needle := range needles {
+		needle = strings.ToLower(strings.TrimSpace(needle))
+		if needle != "" && strings.Contains(text, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDomain admite dominios exactos ("empresa.com") y subdominios ("*.empresa.com" o ".empresa.com")
+func matchesDomain(domains []string, email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(d, "@")))
+		switch {
+		case d == "":
+			continue
+		case strings.HasPrefix(d, "*."):
+			if strings.HasSuffix(domain, d[1:]) {
+				return true
+			}
+		case strings.HasPrefix(d, "."):
+			if strings.HasSuffix(domain, d) {
+				return true
+			}
+		case domain == d:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+// Package tags gestiona las etiquetas de los tickets: normaliza los nombres contra el
+// catálogo, añade y quita etiquetas sin duplicados y filtra tickets por etiqueta.
+package tags
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// DefaultColor es el color de las etiquetas creadas sobre la marcha
+const DefaultColor = "#6B7280"
+
+// maxNameLength limita el nombre de una etiqueta
+const maxNameLength = 50
+
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Normalize quita los espacios sobrantes de un nombre de etiqueta
+func Normalize(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Validate comprueba el nombre y el color de una etiqueta
+func Validate(tag models.Tag) error {
+	name := Normalize(tag.Name)
+	if name == "" {
+		return fmt.Errorf("el nombre de la etiqueta es obligatorio")
+	}
+	if len([]rune(name)) > maxNameLength {
+		return fmt.Errorf("el nombre de la etiqueta no puede superar %d caracteres", maxNameLength)
+	}
+	if strings.Contains(name, ",") {
+		return fmt.Errorf("el nombre de la etiqueta no puede contener comas")
+	}
+	if tag.Color != "" && !colorPattern.MatchString(tag.Color) {
+		return fmt.Errorf("color inválido %q (formato #RRGGBB)", tag.Color)
+	}
+	return nil
+}
+
+// Resolve convierte los nombres al nombre del catálogo (sin distinguir mayúsculas) y
+// crea las etiquetas que aún no existen. Devuelve la lista sin duplicados.
+func Resolve(store data.DataStore, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	catalog, err := store.GetTags()
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener etiquetas: %v", err)
+	}
+	byName := make(map[string]string, len(catalog))
+	for _, tag := range catalog {
+		byName[strings.ToLower(tag.Name)] = tag.Name
+	}
+
+	var result []string
+	for _, name := range names {
+		name = Normalize(name)
+		if name == "" || Has(result, name) {
+			continue
+		}
+		if existing, ok := byName[strings.ToLower(name)]; ok {
+			result = append(result, existing)
+			continue
+		}
+		tag := models.Tag{ID: uuid.New().String(), Name: name, Color: DefaultColor, CreatedAt: time.Now()}
+		if err := Validate(tag); err != nil {
+			return nil, err
+		}
+		if err := store.CreateTag(tag); err != nil {
+			return nil, err
+		}
+		byName[strings.ToLower(name)] = name
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// Has indica si la lista contiene la etiqueta
+func Has(list []string, name string) bool {
+	for _, tag := range list {
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add añade las etiquetas que falten respetando el orden
+func Add(list, extra []string) []string {
+	result := append([]string(nil), list...)
+	for _, name := range extra {
+		if name != "" && !Has(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Remove quita las etiquetas indicadas
+func Remove(list, remove []string) []string {
+	var result []string
+	for _, name := range list {
+		if !Has(remove, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Filter selecciona tickets por etiqueta. Any exige alguna de las etiquetas, All todas
+// y None ninguna; una lista vacía no filtra.
+type Filter struct {
+	Any  []string
+	All  []string
+	None []string
+}
+
+// ParseFilter lee ?tags=a,b (alguna), ?tagsAll=a,b (todas) y ?tagsNone=a,b (ninguna)
+func ParseFilter(query url.Values) Filter {
+	return Filter{
+		Any:  splitList(query.Get("tags")),
+		All:  splitList(query.Get("tagsAll")),
+		None: splitList(query.Get("tagsNone")),
+	}
+}
+
+// Empty indica si el filtro no tiene condiciones
+func (f Filter) Empty() bool {
+	return len(f.Any) == 0 && len(f.All) == 0 && len(f.None) == 0
+}
+
+// Matches indica si las etiquetas de un ticket cumplen el filtro
+func (f Filter) Matches(list []string) bool {
+	if len(f.Any) > 0 {
+		found := false
+		for _, name := range f.Any {
+			if Has(list, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, name := range f.All {
+		if !Has(list, name) {
+			return false
+		}
+	}
+	for _, name := range f.None {
+		if Has(list, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithCounts completa cuántos tickets (y cuántos abiertos) usan cada etiqueta
+func WithCounts(catalog []models.Tag, tickets []models.Ticket) []models.Tag {
+	total := make(map[string]int)
+	open := make(map[string]int)
+	for _, ticket := range tickets {
+		closed := ticket.Status == "resolved" || ticket.Status == "closed"
+		for _, name := range ticket.Tags {
+			key := strings.ToLower(name)
+			total[key]++
+			if !closed {
+				open[key]++
+			}
+		}
+	}
+
+	result := make([]models.Tag, len(catalog))
+	for i, tag := range catalog {
+		tag.TicketCount = total[strings.ToLower(tag.Name)]
+		tag.OpenTicketCount = open[strings.ToLower(tag.Name)]
+		result[i] = tag
+	}
+	return result
+}
+
+// Suggest devuelve hasta limit etiquetas que empiezan por (o, después, contienen) el
+// texto buscado, las más usadas primero
+func Suggest(catalog []models.Tag, query string, limit int) []models.Tag {
+	query = strings.ToLower(Normalize(query))
+	var prefix, contains []models.Tag
+	for _, tag := range catalog {
+		name := strings.ToLower(tag.Name)
+		switch {
+		case strings.HasPrefix(name, query):
+			prefix = append(prefix, tag)
+		case strings.Contains(name, query):
+			contains = append(contains, tag)
+		}
+	}
+	byUsage := func(list []models.Tag) {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].TicketCount > list[j].TicketCount })
+	}
+	byUsage(prefix)
+	byUsage(contains)
+
+	result := append(prefix, contains...)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	if result == nil {
+		result = make([]models.Tag, 0)
+	}
+	return result
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = Normalize(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// RenameReferences cambia la etiqueta old por name en las reglas de enrutamiento y en
+// las macros, o la quita si name está vacío. Los tickets los actualiza el almacén.
+func RenameReferences(store data.DataStore, old, name string) error {
+	rename := func(list []string) ([]string, bool) {
+		if !Has(list, old) {
+			return list, false
+		}
+		if name == "" {
+			return Remove(list, []string{old}), true
+		}
+		result := make([]string, 0, len(list))
+		for _, tag := range list {
+			if strings.EqualFold(tag, old) {
+				tag = name
+			}
+			result = append(result, tag)
+		}
+		return result, true
+	}
+
+	rules, err := store.GetRoutingRules()
+	if err != nil {
+		return fmt.Errorf("error al obtener reglas de enrutamiento: %v", err)
+	}
+	for _, rule := range rules {
+		var changedActions, changedConditions bool
+		rule.Actions.Tags, changedActions = rename(rule.Actions.Tags)
+		rule.Conditions.Tags, changedConditions = rename(rule.Conditions.Tags)
+		if changedActions || changedConditions {
+			if err := store.UpdateRoutingRule(rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	macros, err := store.GetMacros()
+	if err != nil {
+		return fmt.Errorf("error al obtener macros: %v", err)
+	}
+	for _, macro := range macros {
+		var changedAdd, changedRemove bool
+		macro.Actions.AddTags, changedAdd = rename(macro.Actions.AddTags)
+		macro.Actions.RemoveTags, changedRemove = rename(macro.Actions.RemoveTags)
+		if changedAdd || changedRemove {
+			if err := store.UpdateMacro(macro); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SyncCatalog da de alta en el catálogo las etiquetas que los tickets ya usaban antes
+// de que existiera. Devuelve cuántas etiquetas se crearon.
+func SyncCatalog(store data.DataStore) (int, error) {
+	tickets, err := store.GetTickets()
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener tickets: %v", err)
+	}
+	var names []string
+	for _, ticket := range tickets {
+		names = Add(names, ticket.Tags)
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	before, err := store.GetTags()
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener etiquetas: %v", err)
+	}
+	if _, err := Resolve(store, names); err != nil {
+		return 0, err
+	}
+	after, err := store.GetTags()
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener etiquetas: %v", err)
+	}
+	return len(after) - len(before), nil
+}
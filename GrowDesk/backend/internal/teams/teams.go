@@ -0,0 +1,189 @@
+// Package teams reúne la lógica de pertenencia a equipos y la migración de los antiguos
+// departamentos de texto libre a equipos.
+package teams
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+)
+
+// MemberRole devuelve el rol del usuario en el equipo, o "" si no es miembro
+func MemberRole(team models.Team, userID string) string {
+	for _, member := range team.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// IsLead indica si el usuario es responsable del equipo
+func IsLead(team models.Team, userID string) bool {
+	return MemberRole(team, userID) == models.TeamRoleLead
+}
+
+// MemberIDs devuelve los IDs de los miembros del equipo
+func MemberIDs(team models.Team) []string {
+	ids := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		ids = append(ids, member.UserID)
+	}
+	return ids
+}
+
+// TeamsOf devuelve el conjunto de equipos a los que pertenece el usuario
+func TeamsOf(teams []models.Team, userID string) map[string]bool {
+	result := make(map[string]bool)
+	for _, team := range teams {
+		if MemberRole(team, userID) != "" {
+			result[team.ID] = true
+		}
+	}
+	return result
+}
+
+// FindByName busca un equipo por nombre sin distinguir mayúsculas
+func FindByName(teams []models.Team, name string) *models.Team {
+	name = strings.TrimSpace(name)
+	for i := range teams {
+		if strings.EqualFold(teams[i].Name, name) {
+			return &teams[i]
+		}
+	}
+	return nil
+}
+
+// ActivityDepartmentsMigrated marca en el historial que la migración ya se ejecutó
+const ActivityDepartmentsMigrated = "teams.departments_migrated"
+
+// migrationTarget es el TargetID con el que se registra la migración
+const migrationTarget = "teams"
+
+// MigrationResult resume los cambios hechos por MigrateDepartments
+type MigrationResult struct {
+	TeamsCreated   int `json:"teamsCreated"`
+	MembersAdded   int `json:"membersAdded"`
+	TicketsUpdated int `json:"ticketsUpdated"`
+}
+
+// MigrateDepartments convierte los departamentos de usuarios y tickets en equipos:
+// crea un equipo por departamento, añade a sus usuarios como miembros y asigna el
+// equipo a los tickets que aún no tienen uno. Se ejecuta una sola vez (queda registrada
+// en el historial de actividad) para no recrear equipos que un administrador haya
+// borrado después. El campo Department se conserva por compatibilidad.
+func MigrateDepartments(store data.DataStore) (*MigrationResult, error) {
+	activities, err := store.GetActivities(migrationTarget)
+	if err != nil {
+		return nil, fmt.Errorf("error al consultar el historial de migraciones: %v", err)
+	}
+	for _, activity := range activities {
+		if activity.Type == ActivityDepartmentsMigrated {
+			return nil, nil
+		}
+	}
+
+	result, err := migrateDepartments(store)
+	if err != nil {
+		return result, err
+	}
+
+	activity := models.Activity{
+		Type:        ActivityDepartmentsMigrated,
+		TargetID:    migrationTarget,
+		Description: fmt.Sprintf("Departamentos migrados a equipos: %d equipos, %d miembros, %d tickets", result.TeamsCreated, result.MembersAdded, result.TicketsUpdated),
+		Metadata: map[string]any{
+			"teamsCreated":   result.TeamsCreated,
+			"membersAdded":   result.MembersAdded,
+			"ticketsUpdated": result.TicketsUpdated,
+		},
+	}
+	if err := store.CreateActivity(activity); err != nil {
+		return result, fmt.Errorf("error al registrar la migración de departamentos: %v", err)
+	}
+	return result, nil
+}
+
+func migrateDepartments(store data.DataStore) (*MigrationResult, error) {
+	result := &MigrationResult{}
+
+	existing, err := store.GetTeams()
+	if err != nil {
+		return result, fmt.Errorf("error al obtener equipos: %v", err)
+	}
+	users, err := store.GetUsers()
+	if err != nil {
+		return result, fmt.Errorf("error al obtener usuarios: %v", err)
+	}
+	tickets, err := store.GetTickets()
+	if err != nil {
+		return result, fmt.Errorf("error al obtener tickets: %v", err)
+	}
+
+	byName := make(map[string]*models.Team)
+	for i := range existing {
+		byName[strings.ToLower(strings.TrimSpace(existing[i].Name))] = &existing[i]
+	}
+
+	// Equipo de cada departamento, creándolo si no existe
+	teamFor := func(department string) (*models.Team, error) {
+		key := strings.ToLower(strings.TrimSpace(department))
+		if team, ok := byName[key]; ok {
+			return team, nil
+		}
+		team := &models.Team{
+			ID:          uuid.New().String(),
+			Name:        strings.TrimSpace(department),
+			Description: "Creado a partir del departamento " + strings.TrimSpace(department),
+			Members:     make([]models.TeamMember, 0),
+		}
+		if err := store.CreateTeam(*team); err != nil {
+			return nil, fmt.Errorf("error al crear el equipo %s: %v", team.Name, err)
+		}
+		byName[key] = team
+		result.TeamsCreated++
+		return team, nil
+	}
+
+	changed := make(map[string]*models.Team)
+	for _, user := range users {
+		if strings.TrimSpace(user.Department) == "" || user.Role == "customer" {
+			continue
+		}
+		team, err := teamFor(user.Department)
+		if err != nil {
+			return result, err
+		}
+		if MemberRole(*team, user.ID) != "" {
+			continue
+		}
+		team.Members = append(team.Members, models.TeamMember{UserID: user.ID, Role: models.TeamRoleMember})
+		changed[team.ID] = team
+		result.MembersAdded++
+	}
+	for _, team := range changed {
+		if err := store.UpdateTeam(*team); err != nil {
+			return result, fmt.Errorf("error al actualizar miembros del equipo %s: %v", team.Name, err)
+		}
+	}
+
+	for _, ticket := range tickets {
+		if ticket.TeamID != "" || strings.TrimSpace(ticket.Department) == "" {
+			continue
+		}
+		team, err := teamFor(ticket.Department)
+		if err != nil {
+			return result, err
+		}
+		ticket.TeamID = team.ID
+		if err := store.UpdateTicket(ticket); err != nil {
+			return result, fmt.Errorf("error al asignar equipo al ticket %s: %v", ticket.ID, err)
+		}
+		result.TicketsUpdated++
+	}
+
+	return result, nil
+}
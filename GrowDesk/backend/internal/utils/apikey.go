@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefijos de las claves de API según el tipo de principal
+const (
+	ServiceKeyPrefix = "gdsk_"
+	APIKeyPrefix     = "gdpk_"
+)
+
+// GenerateAPIKey genera una clave con el formato <prefijo><id>_<secreto>.
+// Devuelve la clave completa y su parte pública (<prefijo><id>), usada para buscarla.
+// El id tiene 8 bytes aleatorios para que no colisione con el de otra clave.
+func GenerateAPIKey(prefix string) (string, string, error) {
+	idBytes := make([]byte, 8)
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("error al generar clave: %v", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", fmt.Errorf("error al generar clave: %v", err)
+	}
+
+	public := prefix + hex.EncodeToString(idBytes)
+	return public + "_" + hex.EncodeToString(secretBytes), public, nil
+}
+
+// APIKeyPublicPart extrae la parte pública de una clave (todo lo anterior al último "_")
+func APIKeyPublicPart(key string) string {
+	i := strings.LastIndex(key, "_")
+	if i <= 0 {
+		return ""
+	}
+	return key[:i]
+}
+
+// HashAPIKey calcula el hash SHA-256 de una clave de API
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareAPIKeyHash compara en tiempo constante una clave con su hash almacenado
+func CompareAPIKeyHash(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -35,9 +36,15 @@ func GenerateTimestamp() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
-// GenerateTicketID genera un nuevo ID de ticket en el formato TICKET-YYYYMMDD-HHMMSS
-func GenerateTicketID() string {
-	return fmt.Sprintf("TICKET-%s", time.Now().Format("20060102-150405"))
+// GenerateTicketID genera un nuevo ID de ticket en el formato TICKET-YYYYMMDD-HHMMSS-XXXXXXXXXXXX.
+// El sufijo aleatorio evita colisiones entre los tickets creados en el mismo segundo
+// (p. ej. cuando el outbox de widget-api entrega varios a la vez).
+func GenerateTicketID(now time.Time) string {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("TICKET-%s-%d", now.Format("20060102-150405"), now.UnixNano())
+	}
+	return fmt.Sprintf("TICKET-%s-%s", now.Format("20060102-150405"), hex.EncodeToString(suffix))
 }
 
 // GenerateMessageID genera un nuevo ID de mensaje en el formato MSG-TIMESTAMP
@@ -50,6 +57,7 @@ func SetCORS(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Widget-ID, X-Widget-Token")
+	w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 }
 
@@ -0,0 +1,255 @@
+// Package watchers reparte las novedades de un ticket entre sus seguidores: agentes
+// que siguen tickets ajenos y contactos del cliente en copia. Cada seguidor elige por
+// qué canales (panel, correo, webhook) y de qué novedades se le avisa.
+package watchers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/notify"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+)
+
+// Tipos de evento; también se usan como cabecera X-GrowDesk-Event de los webhooks
+const (
+	EventMessage       = "ticket.message"
+	EventStatusChanged = "ticket.status_changed"
+)
+
+// Event es una novedad de un ticket
+type Event struct {
+	Type       string
+	ActorID    string          // Usuario que la provocó; no se le avisa a sí mismo
+	ActorEmail string          // Email del cliente cuando el autor es el cliente
+	Message    *models.Message // Para EventMessage
+	OldStatus  string          // Para EventStatusChanged
+	NewStatus  string
+}
+
+// MessageEvent construye el evento de un mensaje nuevo; el autor no recibe su propio mensaje
+func MessageEvent(message models.Message) Event {
+	event := Event{Type: EventMessage, ActorID: message.UserID, Message: &message}
+	if message.IsClient {
+		event.ActorEmail = message.UserEmail
+	}
+	return event
+}
+
+// StatusEvent construye el evento de un cambio de estado
+func StatusEvent(actorID, oldStatus, newStatus string) Event {
+	return Event{Type: EventStatusChanged, ActorID: actorID, OldStatus: oldStatus, NewStatus: newStatus}
+}
+
+// webhookPayload es el cuerpo de los webhooks de seguidores
+type webhookPayload struct {
+	Event     string          `json:"event"`
+	WatcherID string          `json:"watcherId"`
+	TicketID  string          `json:"ticketId"`
+	Title     string          `json:"title"`
+	Status    string          `json:"status"`
+	OldStatus string          `json:"oldStatus,omitempty"`
+	NewStatus string          `json:"newStatus,omitempty"`
+	Message   *models.Message `json:"message,omitempty"`
+	SentAt    time.Time       `json:"sentAt"`
+}
+
+// DefaultPreferences devuelve las preferencias iniciales: los agentes reciben todo en el
+// panel y los contactos en copia reciben mensajes y cambios de estado por correo
+func DefaultPreferences(agent bool) models.WatcherPreferences {
+	if agent {
+		return models.WatcherPreferences{InApp: true, Messages: true, StatusChanges: true, InternalNotes: true}
+	}
+	return models.WatcherPreferences{Email: true, Messages: true, StatusChanges: true}
+}
+
+// Normalize ajusta las preferencias a lo que el seguidor puede recibir: los contactos en
+// copia no tienen panel ni ven notas internas
+func Normalize(watcher *models.TicketWatcher) {
+	if watcher.UserID == "" {
+		watcher.Preferences.InApp = false
+		watcher.Preferences.InternalNotes = false
+	}
+	watcher.Preferences.WebhookURL = strings.TrimSpace(watcher.Preferences.WebhookURL)
+}
+
+// ValidatePreferences comprueba la URL del webhook, si la hay
+func ValidatePreferences(preferences models.WatcherPreferences) error {
+	if preferences.WebhookURL == "" {
+		return nil
+	}
+	return notify.ValidateWebhookURL(preferences.WebhookURL)
+}
+
+// IsWatching indica si userID sigue el ticket
+func IsWatching(store data.DataStore, ticketID, userID string) bool {
+	watchers, err := store.GetTicketWatchers(ticketID)
+	if err != nil {
+		return false
+	}
+	for _, watcher := range watchers {
+		if watcher.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify avisa a los seguidores del ticket según sus preferencias. Los correos y
+// webhooks son lentos, así que conviene llamarla en una goroutine. Devuelve a cuántos
+// seguidores se avisó.
+func Notify(store data.DataStore, ticket models.Ticket, event Event) int {
+	watchers, err := store.GetTicketWatchers(ticket.ID)
+	if err != nil {
+		logging.Warnf("⚠️ No se pudieron obtener los seguidores del ticket %s: %v", ticket.ID, err)
+		return 0
+	}
+
+	notified := 0
+	for _, watcher := range watchers {
+		if !wants(watcher, event) {
+			continue
+		}
+		if deliver(store, ticket, watcher, event) {
+			notified++
+		}
+	}
+	if notified > 0 {
+		logging.Infof("👀 %d seguidores avisados del ticket %s (%s)", notified, ticket.ID, event.Type)
+	}
+	return notified
+}
+
+// wants indica si el seguidor quiere recibir el evento y puede verlo
+func wants(watcher models.TicketWatcher, event Event) bool {
+	if watcher.UserID != "" && watcher.UserID == event.ActorID {
+		return false
+	}
+	if watcher.UserID == "" && event.ActorEmail != "" && strings.EqualFold(watcher.Email, event.ActorEmail) {
+		return false
+	}
+
+	preferences := watcher.Preferences
+	switch event.Type {
+	case EventMessage:
+		if event.Message == nil {
+			return false
+		}
+		if event.Message.IsInternal {
+			return watcher.UserID != "" && preferences.InternalNotes
+		}
+		return preferences.Messages
+	case EventStatusChanged:
+		return preferences.StatusChanges
+	}
+	return false
+}
+
+// deliver envía el evento por cada canal activo del seguidor. Devuelve true si al menos
+// un canal lo entregó.
+func deliver(store data.DataStore, ticket models.Ticket, watcher models.TicketWatcher, event Event) bool {
+	summary := describe(ticket, event)
+	delivered := false
+
+	if watcher.Preferences.InApp && watcher.UserID != "" {
+		notification := models.Notification{
+			UserID:      watcher.UserID,
+			Type:        models.NotificationTypeTicketUpdate,
+			Message:     summary,
+			RelatedID:   ticket.ID,
+			RelatedType: "ticket",
+		}
+		if err := store.CreateNotification(notification); err != nil {
+			logging.Warnf("⚠️ No se pudo notificar al seguidor %s: %v", watcher.ID, err)
+		} else {
+			delivered = true
+		}
+	}
+
+	if watcher.Preferences.Email {
+		if address := watcherEmail(store, watcher); address != "" {
+			email := notify.Email{
+				To:      []string{address},
+				Subject: fmt.Sprintf("[Ticket %s] %s", ticket.ID, ticket.Title),
+				Body:    emailBody(ticket, event, summary),
+			}
+			if err := notify.Default().SendEmail(email); err != nil {
+				if err != notify.ErrEmailDisabled {
+					logging.Warnf("⚠️ No se pudo enviar el correo al seguidor %s: %v", watcher.ID, err)
+				}
+			} else {
+				delivered = true
+			}
+		}
+	}
+
+	if watcher.Preferences.WebhookURL != "" {
+		payload := webhookPayload{
+			Event:     event.Type,
+			WatcherID: watcher.ID,
+			TicketID:  ticket.ID,
+			Title:     ticket.Title,
+			Status:    ticket.Status,
+			OldStatus: event.OldStatus,
+			NewStatus: event.NewStatus,
+			Message:   event.Message,
+			SentAt:    time.Now(),
+		}
+		if err := notify.Default().PostWebhook(watcher.Preferences.WebhookURL, event.Type, payload); err != nil {
+			logging.Warnf("⚠️ No se pudo enviar el webhook al seguidor %s: %v", watcher.ID, err)
+		} else {
+			delivered = true
+		}
+	}
+
+	return delivered
+}
+
+// watcherEmail devuelve el correo del contacto en copia o el del agente seguidor
+func watcherEmail(store data.DataStore, watcher models.TicketWatcher) string {
+	if watcher.UserID == "" {
+		return watcher.Email
+	}
+	user, err := store.GetUser(watcher.UserID)
+	if err != nil || user == nil {
+		return ""
+	}
+	return user.Email
+}
+
+// describe resume el evento en una línea
+func describe(ticket models.Ticket, event Event) string {
+	switch event.Type {
+	case EventStatusChanged:
+		return fmt.Sprintf("El ticket %s (%s) pasó de %s a %s", ticket.ID, ticket.Title, event.OldStatus, event.NewStatus)
+	default:
+		author := "El cliente"
+		if event.Message != nil && !event.Message.IsClient {
+			author = event.Message.UserName
+			if author == "" {
+				author = "Un agente"
+			}
+		}
+		kind := "un mensaje"
+		if event.Message != nil && event.Message.IsInternal {
+			kind = "una nota interna"
+		}
+		return fmt.Sprintf("%s escribió %s en el ticket %s: %s", author, kind, ticket.ID, ticket.Title)
+	}
+}
+
+func emailBody(ticket models.Ticket, event Event, summary string) string {
+	var body strings.Builder
+	body.WriteString(summary)
+	body.WriteString("\n\n")
+	if event.Type == EventMessage && event.Message != nil {
+		body.WriteString(event.Message.Content)
+		body.WriteString("\n\n")
+	}
+	fmt.Fprintf(&body, "Estado actual: %s\n", ticket.Status)
+	body.WriteString("\nRecibes este correo porque sigues este ticket.\n")
+	return body.String()
+}
@@ -5,12 +5,20 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/data"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/mentions"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/middleware"
 	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/models"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/presence"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/utils"
+	"github.com/hmdev/GrowDeskV2/GrowDesk/backend/internal/watchers"
+	"github.com/hmdev/GrowDeskV2/pkg/logging"
+	"github.com/hmdev/GrowDeskV2/pkg/telemetry"
 )
 
 // Upgrader para conexiones WebSocket
@@ -23,6 +31,26 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// Contadores del hub de WebSocket para las sondas de salud
+var (
+	openConnections int64
+	upgradeFailures int64
+)
+
+// HubStats resume el estado de las conexiones WebSocket
+type HubStats struct {
+	Connections     int64 `json:"connections"`
+	UpgradeFailures int64 `json:"upgradeFailures"`
+}
+
+// Stats devuelve las conexiones abiertas y los intentos de conexión fallidos desde el arranque
+func Stats() HubStats {
+	return HubStats{
+		Connections:     atomic.LoadInt64(&openConnections),
+		UpgradeFailures: atomic.LoadInt64(&upgradeFailures),
+	}
+}
+
 // ChatHandlerWithStore maneja las conexiones WebSocket para el chat de tickets
 // usando cualquier implementación de DataStore
 func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
@@ -36,17 +64,17 @@ func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
 		}
 
 		ticketID := pathParts[len(pathParts)-1]
-		fmt.Printf("Solicitud de conexión WebSocket para el ticket: %s\n", ticketID)
+		logging.Infof("Solicitud de conexión WebSocket para el ticket: %s", ticketID)
 
 		// Verificar si el ticket existe
 		ticket, err := store.GetTicket(ticketID)
 		if err != nil {
-			fmt.Printf("Error al buscar ticket %s: %v\n", ticketID, err)
+			logging.Errorf("Error al buscar ticket %s: %v", ticketID, err)
 			// En lugar de devolver un error, enviamos un mensaje simulado
 			// para que el frontend pueda mostrar un modo de desarrollo
 			conn, err := upgrader.Upgrade(w, r, nil)
 			if err != nil {
-				fmt.Printf("Error al actualizar a WebSocket: %v\n", err)
+				logging.Errorf("Error al actualizar a WebSocket: %v", err)
 				return
 			}
 
@@ -63,7 +91,7 @@ func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
 			}
 
 			if err := conn.WriteJSON(welcomeMsg); err != nil {
-				fmt.Printf("Error al enviar mensaje de desarrollo: %v\n", err)
+				logging.Errorf("Error al enviar mensaje de desarrollo: %v", err)
 			}
 
 			// Mantener la conexión abierta pero en modo simulado
@@ -74,7 +102,7 @@ func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
 		// Actualizar la conexión a WebSocket
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			fmt.Printf("Error al actualizar a WebSocket: %v\n", err)
+			logging.Errorf("Error al actualizar a WebSocket: %v", err)
 			return
 		}
 
@@ -90,7 +118,7 @@ func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
 		}
 
 		if err := conn.WriteJSON(welcomeMsg); err != nil {
-			fmt.Printf("Error al enviar el mensaje de bienvenida: %v\n", err)
+			logging.Errorf("Error al enviar el mensaje de bienvenida: %v", err)
 		}
 
 		// Enviar historial de mensajes
@@ -102,7 +130,7 @@ func ChatHandlerWithStore(store data.DataStore) http.HandlerFunc {
 			}
 
 			if err := conn.WriteJSON(historyMsg); err != nil {
-				fmt.Printf("Error al enviar el historial de mensajes: %v\n", err)
+				logging.Errorf("Error al enviar el historial de mensajes: %v", err)
 			}
 		}
 
@@ -119,7 +147,7 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 	conn.SetPingHandler(func(appData string) error {
 		err := conn.WriteControl(websocket.PongMessage, []byte{}, time.Now().Add(10*time.Second))
 		if err != nil {
-			fmt.Printf("Error al enviar pong: %v\n", err)
+			logging.Errorf("Error al enviar pong: %v", err)
 			return err
 		}
 		return nil
@@ -132,7 +160,7 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 
 		for range ticker.C {
 			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				fmt.Printf("Error al enviar ping: %v\n", err)
+				logging.Errorf("Error al enviar ping: %v", err)
 				return
 			}
 		}
@@ -144,7 +172,7 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				fmt.Printf("Error al leer mensaje: %v\n", err)
+				logging.Errorf("Error al leer mensaje: %v", err)
 			}
 			break
 		}
@@ -160,11 +188,11 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 		}
 
 		if err := json.Unmarshal(data, &message); err != nil {
-			fmt.Printf("Error al analizar mensaje: %v\n", err)
+			logging.Errorf("Error al analizar mensaje: %v", err)
 			continue
 		}
 
-		fmt.Printf("Mensaje simulado recibido: %s\n", string(data))
+		logging.Debugf("Mensaje simulado recibido: %s", string(data))
 
 		// Para cualquier mensaje de tipo new_message, enviar una respuesta simulada
 		if message.Type == "new_message" {
@@ -191,7 +219,7 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 
 			// Enviar respuesta
 			if err := conn.WriteJSON(resp); err != nil {
-				fmt.Printf("Error al enviar respuesta simulada: %v\n", err)
+				logging.Errorf("Error al enviar respuesta simulada: %v", err)
 			}
 
 			// Simular respuesta del agente
@@ -209,7 +237,7 @@ func handleSimulatedMessages(conn *websocket.Conn, ticketID string) {
 			}
 
 			if err := conn.WriteJSON(agentResp); err != nil {
-				fmt.Printf("Error al enviar respuesta del agente simulado: %v\n", err)
+				logging.Errorf("Error al enviar respuesta del agente simulado: %v", err)
 			}
 		}
 	}
@@ -223,7 +251,7 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 	conn.SetPingHandler(func(appData string) error {
 		err := conn.WriteControl(websocket.PongMessage, []byte{}, time.Now().Add(10*time.Second))
 		if err != nil {
-			fmt.Printf("Error al enviar pong: %v\n", err)
+			logging.Errorf("Error al enviar pong: %v", err)
 			return err
 		}
 		return nil
@@ -236,7 +264,7 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 
 		for range ticker.C {
 			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				fmt.Printf("Error al enviar ping: %v\n", err)
+				logging.Errorf("Error al enviar ping: %v", err)
 				return
 			}
 		}
@@ -248,7 +276,7 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 		_, data, err := conn.ReadMessage()
 		if err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				fmt.Printf("Error al leer mensaje: %v\n", err)
+				logging.Errorf("Error al leer mensaje: %v", err)
 			}
 			break
 		}
@@ -264,11 +292,11 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 		}
 
 		if err := json.Unmarshal(data, &message); err != nil {
-			fmt.Printf("Error al analizar mensaje: %v\n", err)
+			logging.Errorf("Error al analizar mensaje: %v", err)
 			continue
 		}
 
-		fmt.Printf("Mensaje recibido: %s\n", string(data))
+		logging.Debugf("Mensaje recibido: %s", string(data))
 
 		// Manejar mensaje basado en el tipo
 		switch message.Type {
@@ -313,7 +341,7 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 			// Agregar mensaje al ticket
 			err := store.AddTicketMessage(ticketID, newMessage)
 			if err != nil {
-				fmt.Printf("Error agregando mensaje al ticket: %v\n", err)
+				logging.Errorf("Error agregando mensaje al ticket: %v", err)
 				continue
 			}
 
@@ -325,7 +353,7 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 			}
 
 			if err := conn.WriteJSON(resp); err != nil {
-				fmt.Printf("Error al enviar la confirmación de mensaje: %v\n", err)
+				logging.Errorf("Error al enviar la confirmación de mensaje: %v", err)
 			}
 
 			// No podemos hacer broadcast con la interfaz DataStore
@@ -334,8 +362,20 @@ func handleIncomingMessages(conn *websocket.Conn, store data.DataStore, ticketID
 	}
 }
 
-// ChatHandler maneja las conexiones WebSocket para el chat de tickets
+// ChatHandler maneja las conexiones WebSocket para el chat de tickets. Las notas
+// internas sólo llegan a las conexiones de agentes autenticados.
 func ChatHandler(store data.DataStore) http.HandlerFunc {
+	return chatHandler(store, false)
+}
+
+// NotesHandler maneja el canal privado de notas internas de un ticket
+// (/api/ws/notes/:ticketID). Sólo admite agentes autenticados; por él sólo circulan
+// notas internas y todo lo que se escribe en él se guarda como nota.
+func NotesHandler(store data.DataStore) http.HandlerFunc {
+	return chatHandler(store, true)
+}
+
+func chatHandler(store data.DataStore, notesOnly bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extraer el ID del ticket desde la URL
 		// Formato de URL: /api/ws/chat/:ticketID
@@ -346,7 +386,7 @@ func ChatHandler(store data.DataStore) http.HandlerFunc {
 		}
 
 		ticketID := pathParts[len(pathParts)-1]
-		fmt.Printf("Solicitud de conexión WebSocket para el ticket: %s\n", ticketID)
+		logging.Infof("Solicitud de conexión WebSocket para el ticket: %s", ticketID)
 
 		// Verificar si el ticket existe
 		ticket, err := store.GetTicket(ticketID)
@@ -355,15 +395,38 @@ func ChatHandler(store data.DataStore) http.HandlerFunc {
 			return
 		}
 
+		// Si el ticket se fusionó, la conversación continúa en el ticket destino
+		for depth := 0; ticket.MergedInto != "" && depth < 10; depth++ {
+			target, err := store.GetTicket(ticket.MergedInto)
+			if err != nil {
+				break
+			}
+			logging.Infof("🔀 Ticket %s fusionado, conectando al ticket %s", ticketID, target.ID)
+			ticket, ticketID = target, target.ID
+		}
+
+		// Las conexiones de agentes autenticados cuentan para su presencia y reciben las notas
+		agentID := agentFromRequest(r)
+		if notesOnly && agentID == "" {
+			http.Error(w, "El canal de notas internas es sólo para agentes", http.StatusUnauthorized)
+			return
+		}
+
 		// Actualizar la conexión a WebSocket
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			fmt.Printf("Error al actualizar a WebSocket: %v\n", err)
+			atomic.AddInt64(&upgradeFailures, 1)
+			logging.Errorf("Error al actualizar a WebSocket: %v", err)
 			return
 		}
 
 		// Agregar la conexión al almacén
-		connectionID := store.AddWSConnection(ticketID, conn)
+		connectionID := store.AddWSConnection(ticketID, conn, agentID != "", notesOnly)
+		atomic.AddInt64(&openConnections, 1)
+		wsConnections.Add(1, ticketID, channelName(notesOnly))
+		if agentID != "" {
+			presence.Default().Connect(agentID)
+		}
 
 		// Enviar mensaje de bienvenida
 		welcomeMsg := models.WebSocketMessage{
@@ -377,40 +440,80 @@ func ChatHandler(store data.DataStore) http.HandlerFunc {
 		}
 
 		if err := conn.WriteJSON(welcomeMsg); err != nil {
-			fmt.Printf("Error al enviar el mensaje de bienvenida: %v\n", err)
+			logging.Errorf("Error al enviar el mensaje de bienvenida: %v", err)
 		}
 
-		// Enviar historial de mensajes
-		if ticket != nil && len(ticket.Messages) > 0 {
+		// Enviar historial de mensajes: sin notas para los clientes, sólo notas en el canal privado
+		history := make([]models.Message, 0, len(ticket.Messages))
+		for _, message := range ticket.Messages {
+			if (message.IsInternal && agentID != "") || (!message.IsInternal && !notesOnly) {
+				history = append(history, message)
+			}
+		}
+		if len(history) > 0 {
 			historyMsg := models.WebSocketMessage{
 				Type:     "message_history",
 				TicketID: ticketID,
-				Messages: ticket.Messages,
+				Messages: history,
 			}
 
 			if err := conn.WriteJSON(historyMsg); err != nil {
-				fmt.Printf("Error al enviar el historial de mensajes: %v\n", err)
+				logging.Errorf("Error al enviar el historial de mensajes: %v", err)
 			}
 		}
 
 		// Manejar mensajes entrantes en una goroutine
-		go handleMessages(conn, store, ticketID, connectionID)
+		go handleMessages(conn, store, ticketID, connectionID, agentID, notesOnly)
 	}
 }
 
+// wsConnections cuenta las conexiones WebSocket abiertas por ticket y canal (chat o notes)
+var wsConnections = telemetry.NewGaugeVec("growdesk_websocket_connections",
+	"Conexiones WebSocket abiertas por ticket y canal", "ticket_id", "channel")
+
+// channelName es la etiqueta del canal en las métricas
+func channelName(notesOnly bool) string {
+	if notesOnly {
+		return "notes"
+	}
+	return "chat"
+}
+
+// agentFromRequest devuelve el ID del agente si la conexión trae un token válido, en el
+// encabezado Authorization o en ?token= (los navegadores no permiten encabezados en WebSocket)
+func agentFromRequest(r *http.Request) string {
+	token := middleware.ExtractToken(r)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return ""
+	}
+	claims, err := utils.ValidateToken(token)
+	if err != nil || claims.Role == "" || claims.Role == "customer" {
+		return ""
+	}
+	return claims.UserID
+}
+
 // handleMessages procesa mensajes entrantes de WebSocket
-func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connectionID string) {
+func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connectionID, agentID string, notesOnly bool) {
 	defer func() {
 		conn.Close()
 		store.RemoveWSConnection(ticketID, connectionID)
-		fmt.Printf("Conexión WebSocket cerrada para el ticket: %s\n", ticketID)
+		atomic.AddInt64(&openConnections, -1)
+		wsConnections.Add(-1, ticketID, channelName(notesOnly))
+		if agentID != "" {
+			presence.Default().Disconnect(agentID)
+		}
+		logging.Infof("Conexión WebSocket cerrada para el ticket: %s", ticketID)
 	}()
 
 	// Configurar el manejador de ping
 	conn.SetPingHandler(func(appData string) error {
 		err := conn.WriteControl(websocket.PongMessage, []byte{}, time.Now().Add(10*time.Second))
 		if err != nil {
-			fmt.Printf("Error al enviar pong: %v\n", err)
+			logging.Errorf("Error al enviar pong: %v", err)
 			return err
 		}
 		return nil
@@ -423,7 +526,7 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 
 		for range ticker.C {
 			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				fmt.Printf("Error al enviar ping: %v\n", err)
+				logging.Errorf("Error al enviar ping: %v", err)
 				return
 			}
 		}
@@ -435,7 +538,7 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 		_, data, err := conn.ReadMessage()
 		if err != nil {
 			if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				fmt.Printf("Error al leer mensaje: %v\n", err)
+				logging.Errorf("Error al leer mensaje: %v", err)
 			}
 			break
 		}
@@ -451,11 +554,11 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 		}
 
 		if err := json.Unmarshal(data, &message); err != nil {
-			fmt.Printf("Error al analizar mensaje: %v\n", err)
+			logging.Errorf("Error al analizar mensaje: %v", err)
 			continue
 		}
 
-		fmt.Printf("Mensaje recibido: %s\n", string(data))
+		logging.Debugf("Mensaje recibido: %s", string(data))
 
 		// Manejar mensaje basado en el tipo
 		switch message.Type {
@@ -471,13 +574,13 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 			}
 
 			if err := conn.WriteJSON(resp); err != nil {
-				fmt.Printf("Error al enviar la respuesta de identificación: %v\n", err)
+				logging.Errorf("Error al enviar la respuesta de identificación: %v", err)
 			}
 
 		case "new_message":
 			// Extraer contenido del mensaje
 			var content string
-			var isClient bool
+			var isClient, isInternal bool
 			var userName string
 
 			if message.Data != nil {
@@ -491,6 +594,9 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 				if un, ok := message.Data["userName"].(string); ok {
 					userName = un
 				}
+				if ii, ok := message.Data["isInternal"].(bool); ok {
+					isInternal = ii
+				}
 			} else {
 				// Campos directos
 				content = message.Content
@@ -502,20 +608,34 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 				continue
 			}
 
+			// Sólo los agentes escriben notas internas; en el canal privado todo es nota
+			if agentID == "" {
+				isInternal = false
+			} else if notesOnly {
+				isInternal = true
+			}
+
 			// Crear objeto de mensaje
 			newMessage := models.Message{
-				ID:        fmt.Sprintf("MSG-%s", time.Now().Format("20060102150405.000")),
-				Content:   content,
-				IsClient:  isClient,
-				Timestamp: time.Now(),
-				CreatedAt: time.Now(),
-				UserName:  userName,
+				ID:         fmt.Sprintf("MSG-%s", time.Now().Format("20060102150405.000")),
+				Content:    content,
+				IsClient:   isClient && !isInternal,
+				IsInternal: isInternal,
+				Timestamp:  time.Now(),
+				CreatedAt:  time.Now(),
+				UserName:   userName,
+			}
+			if agentID != "" && !newMessage.IsClient {
+				newMessage.UserID = agentID
+				if err := mentions.Resolve(store, &newMessage); err != nil {
+					logging.Errorf("Error al resolver menciones: %v", err)
+				}
 			}
 
 			// Agregar mensaje al ticket
 			err := store.AddTicketMessage(ticketID, newMessage)
 			if err != nil {
-				fmt.Printf("Error agregando mensaje al ticket: %v\n", err)
+				logging.Errorf("Error agregando mensaje al ticket: %v", err)
 				continue
 			}
 
@@ -527,11 +647,17 @@ func handleMessages(conn *websocket.Conn, store data.DataStore, ticketID, connec
 			}
 
 			if err := conn.WriteJSON(resp); err != nil {
-				fmt.Printf("Error al enviar la confirmación de mensaje: %v\n", err)
+				logging.Errorf("Error al enviar la confirmación de mensaje: %v", err)
 			}
 
 			// Broadcast a todos los clientes
 			store.BroadcastMessage(ticketID, newMessage)
+			if ticket, err := store.GetTicket(ticketID); err == nil {
+				if len(newMessage.Mentions) > 0 {
+					mentions.Notify(store, *ticket, newMessage)
+				}
+				go watchers.Notify(store, *ticket, watchers.MessageEvent(newMessage))
+			}
 		}
 	}
 }
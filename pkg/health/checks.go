@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Database comprueba que la base de datos responde e informa del uso del pool de conexiones
+func Database(db *sql.DB) func(ctx context.Context) Result {
+	return func(ctx context.Context) Result {
+		if db == nil {
+			return Down("base de datos no inicializada", nil)
+		}
+		stats := db.Stats()
+		details := map[string]interface{}{
+			"openConnections": stats.OpenConnections,
+			"inUse":           stats.InUse,
+			"idle":            stats.Idle,
+			"waitCount":       stats.WaitCount,
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return Down("la base de datos no responde: "+err.Error(), details)
+		}
+		if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+			return Degraded("pool de conexiones agotado", details)
+		}
+		return OK("", details)
+	}
+}
+
+// Peer comprueba que otro servicio responde en url (normalmente su sonda de vida). Se
+// registra como no crítica: si el otro servicio cae, éste sigue atendiendo y reintenta.
+func Peer(url string) func(ctx context.Context) Result {
+	client := &http.Client{}
+	return func(ctx context.Context) Result {
+		details := map[string]interface{}{"url": url}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return Down("URL inválida: "+err.Error(), details)
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return Down("no se pudo conectar: "+err.Error(), details)
+		}
+		resp.Body.Close()
+		details["statusCode"] = resp.StatusCode
+		details["latencyMs"] = float64(time.Since(start).Microseconds()) / 1000
+		if resp.StatusCode >= 300 {
+			return Down(fmt.Sprintf("respondió %d", resp.StatusCode), details)
+		}
+		return OK("", details)
+	}
+}
@@ -0,0 +1,211 @@
+// Package health expone las sondas de vida (liveness) y de disponibilidad (readiness) que
+// comparten el backend y widget-api. Cada dependencia se registra como una comprobación
+// con nombre; las críticas (p. ej. la base de datos) dejan el servicio como no disponible
+// (503) si fallan y las demás sólo lo marcan como degradado.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status es el resultado de una comprobación o del informe completo
+type Status string
+
+// Estados posibles, de mejor a peor
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Result es el resultado de una comprobación
+type Result struct {
+	Status     Status                 `json:"status"`
+	Critical   bool                   `json:"critical"`
+	Message    string                 `json:"message,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	DurationMs float64                `json:"durationMs"`
+}
+
+// OK, Degraded y Down construyen el resultado de una comprobación
+func OK(message string, details map[string]interface{}) Result {
+	return Result{Status: StatusOK, Message: message, Details: details}
+}
+
+// Degraded indica que la dependencia funciona con problemas o no es imprescindible
+func Degraded(message string, details map[string]interface{}) Result {
+	return Result{Status: StatusDegraded, Message: message, Details: details}
+}
+
+// Down indica que la dependencia no está disponible
+func Down(message string, details map[string]interface{}) Result {
+	return Result{Status: StatusDown, Message: message, Details: details}
+}
+
+// Report es el informe detallado que devuelven las sondas
+type Report struct {
+	Status        Status            `json:"status"`
+	Service       string            `json:"service"`
+	Version       string            `json:"version,omitempty"`
+	Timestamp     time.Time         `json:"timestamp"`
+	UptimeSeconds int64             `json:"uptimeSeconds"`
+	Draining      bool              `json:"draining,omitempty"`
+	Checks        map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) Result
+}
+
+// Checker agrupa las comprobaciones de un servicio
+type Checker struct {
+	service  string
+	version  string
+	started  time.Time
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+// New crea un Checker. HEALTH_CHECK_TIMEOUT acota cada comprobación (por defecto 2s) y
+// APP_VERSION se incluye en los informes si está definida.
+func New(service string) *Checker {
+	timeout := 2 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("HEALTH_CHECK_TIMEOUT")); err == nil && d > 0 {
+		timeout = d
+	}
+	return &Checker{service: service, version: os.Getenv("APP_VERSION"), started: time.Now(), timeout: timeout}
+}
+
+// Add registra una comprobación. Si es crítica y falla, el servicio deja de estar disponible.
+func (c *Checker) Add(name string, critical bool, run func(ctx context.Context) Result) {
+	c.mu.Lock()
+	c.checks = append(c.checks, check{name: name, critical: critical, run: run})
+	c.mu.Unlock()
+}
+
+// SetDraining marca el servicio como en cierre: la sonda de disponibilidad devuelve 503
+// para que el orquestador deje de enviarle tráfico antes de apagarlo
+func (c *Checker) SetDraining(draining bool) {
+	c.draining.Store(draining)
+}
+
+// Run ejecuta todas las comprobaciones en paralelo y resume el estado del servicio
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	report := c.report()
+	report.Checks = make(map[string]Result, len(checks))
+	for i, chk := range checks {
+		result := results[i]
+		report.Checks[chk.name] = result
+		switch {
+		case result.Status == StatusDown && chk.critical:
+			report.Status = StatusDown
+		case result.Status != StatusOK && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	if report.Draining {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// runCheck ejecuta una comprobación con el tiempo máximo configurado. Una comprobación
+// que no responde a tiempo o entra en pánico cuenta como caída.
+func (c *Checker) runCheck(ctx context.Context, chk check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- Down("la comprobación falló inesperadamente", nil)
+			}
+		}()
+		done <- chk.run(ctx)
+	}()
+
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Down("tiempo de espera agotado", nil)
+	}
+	result.Critical = chk.critical
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	return result
+}
+
+func (c *Checker) report() Report {
+	return Report{
+		Status:        StatusOK,
+		Service:       c.service,
+		Version:       c.version,
+		Timestamp:     time.Now().UTC(),
+		UptimeSeconds: int64(time.Since(c.started).Seconds()),
+		Draining:      c.draining.Load(),
+	}
+}
+
+// LiveHandler responde a la sonda de vida: el proceso atiende solicitudes. No consulta
+// dependencias, para que un fallo de la base de datos no provoque reinicios en cadena.
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		writeReport(w, http.StatusOK, c.report())
+	})
+}
+
+// ReadyHandler responde a la sonda de disponibilidad con el informe de todas las
+// comprobaciones: 200 si el servicio puede recibir tráfico (aunque esté degradado) y 503
+// si falla alguna comprobación crítica o el servicio se está cerrando.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		report := c.Run(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
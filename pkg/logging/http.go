@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader es el encabezado con el que se recibe, devuelve y propaga el ID de
+// solicitud entre el backend y widget-api
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID devuelve una copia de ctx con el ID de solicitud indicado
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID devuelve el ID de solicitud de ctx, o "" si no hay
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func requestIDAttr(ctx context.Context) slog.Attr {
+	return slog.String("request_id", RequestID(ctx))
+}
+
+// SetRequestHeader añade a header el ID de solicitud de ctx, para que el servicio
+// destino registre sus logs con el mismo ID
+func SetRequestHeader(ctx context.Context, header http.Header) {
+	if id := RequestID(ctx); id != "" {
+		header.Set(RequestIDHeader, id)
+	}
+}
+
+// NewRequestID genera un ID de solicitud aleatorio (32 caracteres hexadecimales)
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// IncomingRequestID conserva el ID recibido de otro servicio si es corto e imprimible, para
+// que no se puedan inyectar saltos de línea ni valores enormes en los logs; si no, genera uno
+func IncomingRequestID(id string) string {
+	if id == "" || len(id) > 128 {
+		return NewRequestID()
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return NewRequestID()
+		}
+	}
+	return id
+}
+
+// Middleware asigna un ID a cada solicitud (o conserva el de X-Request-ID), lo devuelve
+// en la respuesta y registra una línea por solicitud con método, ruta, estado y duración.
+// Nunca registra encabezados ni cuerpos. Las sondas de salud y /metrics se registran en
+// nivel debug para no llenar los logs.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := IncomingRequestID(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithRequestID(r.Context(), id)
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		l := slog.LevelInfo
+		switch {
+		case recorder.status >= 500:
+			l = slog.LevelError
+		case strings.HasPrefix(r.URL.Path, "/api/health") || r.URL.Path == "/metrics":
+			l = slog.LevelDebug
+		}
+		slog.LogAttrs(ctx, l, "solicitud HTTP",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Int64("bytes", recorder.bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// responseRecorder guarda el estado y el tamaño de la respuesta. Implementa Hijacker y
+// Flusher para no romper los WebSocket ni las respuestas en streaming.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("la respuesta no admite Hijack")
+	}
+	r.status, r.wroteHeader = http.StatusSwitchingProtocols, true
+	return hijacker.Hijack()
+}
+
+// Transport envuelve un http.RoundTripper para enviar el ID de solicitud de la llamada
+// en X-Request-ID, de modo que el servicio destino registre sus logs con el mismo ID
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implementa http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := RequestID(req.Context()); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		// RoundTrip no debe modificar la solicitud original
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,149 @@
+// Package logging configura el logger estructurado (log/slog) que comparten el backend y
+// widget-api: salida JSON o texto, nivel modificable en tiempo de ejecución, ID de
+// solicitud en cada registro (propagado entre servicios con X-Request-ID) y redacción
+// automática de contraseñas, tokens y emails con las mismas reglas en ambos servicios.
+//
+// Tras Init, log.Printf y slog.Info escriben a través del mismo manejador, así que la
+// redacción se aplica también a los registros que no usan este paquete.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// level es el nivel mínimo de registro, modificable con SetLevel
+var level = new(slog.LevelVar)
+
+// contextAttrs son atributos que se extraen del contexto de cada registro (request_id y
+// los que se añadan con AddContextAttr, como el ID de traza)
+var contextAttrs = struct {
+	sync.RWMutex
+	fns []func(context.Context) slog.Attr
+}{fns: []func(context.Context) slog.Attr{requestIDAttr}}
+
+// Init instala el logger por defecto del proceso. Se configura con LOG_LEVEL (debug, info,
+// warn, error; por defecto info) y LOG_FORMAT (json o text; por defecto json).
+func Init(service string) {
+	if err := SetLevel(os.Getenv("LOG_LEVEL")); err != nil {
+		fmt.Fprintf(os.Stderr, "LOG_LEVEL inválido, usando info: %v\n", err)
+	}
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, os.Getenv("LOG_FORMAT"))).With("service", service))
+}
+
+// NewHandler crea el manejador que usa Init: redacta los datos sensibles, añade los
+// atributos del contexto y respeta el nivel configurado
+func NewHandler(w io.Writer, format string) slog.Handler {
+	// Con LOG_LEVEL=debug al arrancar se incluye también el archivo y la línea de origen
+	options := &slog.HandlerOptions{Level: level, AddSource: level.Level() <= slog.LevelDebug}
+	var base slog.Handler
+	if strings.EqualFold(format, "text") {
+		base = slog.NewTextHandler(w, options)
+	} else {
+		base = slog.NewJSONHandler(w, options)
+	}
+	return &handler{base}
+}
+
+// SetLevel cambia el nivel mínimo de registro. Una cadena vacía equivale a info.
+func SetLevel(name string) error {
+	if strings.TrimSpace(name) == "" {
+		level.Set(slog.LevelInfo)
+		return nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return fmt.Errorf("nivel de registro desconocido: %s", name)
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level devuelve el nivel actual en minúsculas (debug, info, warn, error)
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// AddContextAttr registra una función que extrae un atributo del contexto de cada
+// registro; los atributos vacíos se omiten
+func AddContextAttr(fn func(context.Context) slog.Attr) {
+	contextAttrs.Lock()
+	contextAttrs.fns = append(contextAttrs.fns, fn)
+	contextAttrs.Unlock()
+}
+
+// handler añade los atributos del contexto y redacta mensaje y atributos antes de
+// delegar en el manejador de slog
+type handler struct {
+	slog.Handler
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	if ctx != nil {
+		contextAttrs.RLock()
+		for _, fn := range contextAttrs.fns {
+			if a := fn(ctx); !a.Equal(slog.Attr{}) && a.Value.String() != "" {
+				redacted.AddAttrs(a)
+			}
+		}
+		contextAttrs.RUnlock()
+	}
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &handler{h.Handler.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{h.Handler.WithGroup(name)}
+}
+
+// Debugf, Infof, Warnf y Errorf registran un mensaje con formato al estilo de fmt.Printf.
+// Se usan en los registros de texto libre; para datos estructurados usar slog directamente.
+func Debugf(format string, args ...interface{}) {
+	logf(context.Background(), slog.LevelDebug, format, args)
+}
+
+// Infof registra un mensaje informativo con formato
+func Infof(format string, args ...interface{}) {
+	logf(context.Background(), slog.LevelInfo, format, args)
+}
+
+// Warnf registra una advertencia con formato
+func Warnf(format string, args ...interface{}) {
+	logf(context.Background(), slog.LevelWarn, format, args)
+}
+
+// Errorf registra un error con formato
+func Errorf(format string, args ...interface{}) {
+	logf(context.Background(), slog.LevelError, format, args)
+}
+
+// logf crea el registro con la ubicación de quien llamó a Infof y compañía
+func logf(ctx context.Context, l slog.Level, format string, args []interface{}) {
+	logger := slog.Default()
+	if !logger.Enabled(ctx, l) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), l, strings.TrimRight(fmt.Sprintf(format, args...), "\n"), pcs[0])
+	_ = logger.Handler().Handle(ctx, r)
+}
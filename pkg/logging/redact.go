@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+)
+
+// redacted sustituye a los valores sensibles
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveKeys son los nombres de atributo cuyo valor nunca se registra
+	sensitiveKeys = regexp.MustCompile(`(?i)password|passwd|secret|token|authorization|cookie|api[_-]?key|service[_-]?key|credential|signature`)
+
+	// sensitiveAssignment detecta pares clave=valor o "clave": "valor" dentro de un texto
+	sensitiveAssignment = regexp.MustCompile(`(?i)("?\b(?:password|passwd|contraseña|secret|token|access_token|refresh_token|api[_-]?key|authorization)"?\s*[:=]\s*)((?:bearer\s+|basic\s+)?(?:"[^"]*"|'[^']*'|[^\s,;&}]+))`)
+
+	// bearerToken detecta credenciales en encabezados Authorization
+	bearerToken = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+
+	// jwtToken detecta JWT sueltos (cabecera.carga.firma)
+	jwtToken = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+	// apiKey detecta claves de servicio (gdsk_) y de API (gdpk_) completas
+	apiKey = regexp.MustCompile(`\bgd[sp]k_[A-Za-z0-9]+_[A-Za-z0-9_-]+`)
+
+	// email detecta direcciones de correo, que se enmascaran conservando el dominio
+	email = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+// Redact elimina de un texto contraseñas, tokens, claves y emails
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	s = sensitiveAssignment.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerToken.ReplaceAllString(s, "${1} "+redacted)
+	s = jwtToken.ReplaceAllString(s, redacted)
+	s = apiKey.ReplaceAllString(s, redacted)
+	s = email.ReplaceAllString(s, "${1}***@${2}")
+	return s
+}
+
+// redactAttr oculta el valor de los atributos sensibles y redacta el resto de textos,
+// incluidos los de los grupos
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys.MatchString(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+		if s, ok := a.Value.Any().(interface{ String() string }); ok {
+			return slog.String(a.Key, Redact(s.String()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Proxies es la lista de proxies de confianza. X-Forwarded-For y X-Real-IP sólo se
+// tienen en cuenta cuando la solicitud llega desde uno de ellos; si no, cualquier cliente
+// podría cambiar de IP (y de bucket) en cada solicitud.
+type Proxies struct {
+	specs []string
+	nets  []*net.IPNet
+}
+
+// ParseProxies interpreta una lista separada por comas de IPs o rangos CIDR
+// (p. ej. "10.0.0.0/8,192.168.1.10")
+func ParseProxies(spec string) (*Proxies, error) {
+	p := &Proxies{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cidr := entry
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("proxy de confianza inválido %q", entry)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("proxy de confianza inválido %q", entry)
+		}
+		p.specs = append(p.specs, entry)
+		p.nets = append(p.nets, ipNet)
+	}
+	return p, nil
+}
+
+// ProxiesFromEnv lee TRUSTED_PROXIES. Si no está definida (o no es válida) no se confía
+// en ningún proxy y la IP del cliente es la de la conexión.
+func ProxiesFromEnv() *Proxies {
+	p, err := ParseProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		slog.Error("TRUSTED_PROXIES: " + err.Error() + "; no se confía en ningún proxy")
+		return &Proxies{}
+	}
+	return p
+}
+
+// List devuelve las entradas configuradas (p. ej. para gin.Engine.SetTrustedProxies)
+func (p *Proxies) List() []string {
+	return append([]string(nil), p.specs...)
+}
+
+// Trusted indica si ip pertenece a un proxy de confianza
+func (p *Proxies) Trusted(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range p.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP obtiene la IP del cliente. Si la conexión viene de un proxy de confianza se
+// recorre X-Forwarded-For de derecha a izquierda saltando los proxies de confianza; la
+// primera IP que no lo es corresponde al cliente. Las entradas más a la izquierda las
+// puede escribir el propio cliente, por eso no se usa la primera.
+func (p *Proxies) ClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !p.Trusted(net.ParseIP(remote)) {
+		return remote
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !p.Trusted(ip) {
+			return client
+		}
+	}
+	if client != "" {
+		return client
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remote
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"vacía", "", nil, false},
+		{"IP y rango", "10.0.0.0/8, 192.168.1.10", []string{"10.0.0.0/8", "192.168.1.10"}, false},
+		{"IPv6", "::1,fd00::/8", []string{"::1", "fd00::/8"}, false},
+		{"entradas vacías", "10.0.0.1,,", []string{"10.0.0.1"}, false},
+		{"IP inválida", "10.0.0.300", nil, true},
+		{"rango inválido", "10.0.0.0/33", nil, true},
+		{"nombre de host", "proxy.local", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProxies(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProxies(%q) error = %v, se esperaba error: %v", tt.spec, err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(p.List(), tt.want) && len(tt.want)+len(p.List()) > 0 {
+				t.Errorf("List() = %v, se esperaba %v", p.List(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProxiesTrusted(t *testing.T) {
+	p, err := ParseProxies("10.0.0.0/8,192.168.1.10,fd00::/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"fd00::1", true},
+		{"203.0.113.7", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := p.Trusted(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("Trusted(%q) = %v, se esperaba %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		remote  string
+		xff     []string
+		realIP  string
+		want    string
+	}{
+		{
+			name:   "sin proxies de confianza se usa la conexión",
+			remote: "203.0.113.7:4321",
+			want:   "203.0.113.7",
+		},
+		{
+			name:   "X-Forwarded-For de un cliente sin proxies configurados se ignora",
+			remote: "203.0.113.7:4321",
+			xff:    []string{"1.2.3.4"},
+			realIP: "5.6.7.8",
+			want:   "203.0.113.7",
+		},
+		{
+			name:    "X-Forwarded-For de un par que no es de confianza se ignora",
+			proxies: "10.0.0.0/8",
+			remote:  "203.0.113.7:4321",
+			xff:     []string{"1.2.3.4"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "X-Real-IP de un par que no es de confianza se ignora",
+			proxies: "10.0.0.0/8",
+			remote:  "203.0.113.7:4321",
+			realIP:  "1.2.3.4",
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "un proxy de confianza",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"198.51.100.20"},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "entradas a la izquierda escritas por el cliente no se usan",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"1.2.3.4, 198.51.100.20"},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "se saltan varios proxies de confianza de derecha a izquierda",
+			proxies: "10.0.0.0/8,172.16.0.0/12",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"1.2.3.4, 198.51.100.20, 172.16.5.4, 10.0.0.9"},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "varios encabezados X-Forwarded-For se leen en orden",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"1.2.3.4", "198.51.100.20, 10.0.0.9"},
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "una entrada inválida corta el recorrido",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"198.51.100.20, basura, 10.0.0.9"},
+			want:    "10.0.0.9",
+		},
+		{
+			name:    "todo son proxies de confianza: la más lejana",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			xff:     []string{"10.0.0.7, 10.0.0.9"},
+			want:    "10.0.0.7",
+		},
+		{
+			name:    "sin X-Forwarded-For se usa X-Real-IP del proxy",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			realIP:  "198.51.100.20",
+			want:    "198.51.100.20",
+		},
+		{
+			name:    "X-Real-IP inválida",
+			proxies: "10.0.0.0/8",
+			remote:  "10.0.0.2:80",
+			realIP:  "no-es-una-ip",
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "IPv6",
+			proxies: "fd00::/8",
+			remote:  "[fd00::2]:80",
+			xff:     []string{"2001:db8::1"},
+			want:    "2001:db8::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProxies(tt.proxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, value := range tt.xff {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := p.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxiesFromEnvIgnoresInvalidSpec(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,no-es-un-proxy")
+	p := ProxiesFromEnv()
+	if len(p.List()) != 0 || p.Trusted(net.ParseIP("10.0.0.1")) {
+		t.Errorf("con TRUSTED_PROXIES inválida no se debería confiar en ningún proxy, List() = %v", p.List())
+	}
+}
@@ -0,0 +1,5 @@
+// Package ratelimit implementa el límite de solicitudes que comparten el backend y
+// widget-api: token buckets en memoria o en Redis (con un cliente RESP mínimo), las reglas
+// por ruta configurables con RATE_LIMITS y la obtención de la IP del cliente detrás de
+// proxies de confianza (TRUSTED_PROXIES).
+package ratelimit
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter limita solicitudes con token buckets identificados por clave
+type Limiter interface {
+	// Allow consume un token del bucket de la clave. limit es la capacidad del bucket,
+	// que se rellena por completo en cada periodo per. Si no quedan tokens devuelve
+	// false y el tiempo a esperar hasta el siguiente token.
+	Allow(key string, limit int, per time.Duration) (bool, time.Duration, error)
+}
+
+// bucket es un token bucket individual
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// MemoryLimiter implementa token buckets en memoria, uno por clave
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time // Reloj, sustituible en las pruebas
+}
+
+// NewMemoryLimiter crea un limitador en memoria
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow consume un token del bucket de la clave (ver Limiter)
+func (l *MemoryLimiter) Allow(key string, limit int, per time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 || per <= 0 {
+		return true, 0, nil
+	}
+
+	now := l.now()
+	rate := float64(limit) / per.Seconds()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now, per)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// sweep elimina los buckets inactivos para que el mapa no crezca sin límite
+func (l *MemoryLimiter) sweep(now time.Time, per time.Duration) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	idle := per * 2
+	if idle < 10*time.Minute {
+		idle = 10 * time.Minute
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idle {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock es un reloj que sólo avanza cuando la prueba lo pide
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*MemoryLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	l := NewMemoryLimiter()
+	l.now = clock.now
+	l.lastSweep = clock.t
+	return l, clock
+}
+
+func TestMemoryLimiterTokenBucket(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		allowed bool
+		wait    time.Duration
+	}
+	tests := []struct {
+		name  string
+		limit int
+		per   time.Duration
+		steps []step
+	}{
+		{
+			name:  "agota la capacidad y espera al siguiente token",
+			limit: 3, per: 3 * time.Second,
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, true, 0}, {0, false, time.Second}, {400 * time.Millisecond, false, 600 * time.Millisecond}},
+		},
+		{
+			name:  "rellena a razón de limit por periodo",
+			limit: 2, per: time.Minute,
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, false, 30 * time.Second}, {30 * time.Second, true, 0}, {0, false, 30 * time.Second}},
+		},
+		{
+			name:  "no acumula más de la capacidad",
+			limit: 2, per: time.Second,
+			steps: []step{{0, true, 0}, {time.Hour, true, 0}, {0, true, 0}, {0, false, 500 * time.Millisecond}},
+		},
+		{
+			name:  "límite cero desactiva la regla",
+			limit: 0, per: time.Second,
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, true, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter()
+			for i, s := range tt.steps {
+				clock.advance(s.advance)
+				allowed, wait, err := l.Allow("k", tt.limit, tt.per)
+				if err != nil {
+					t.Fatalf("paso %d: %v", i, err)
+				}
+				if allowed != s.allowed || wait != s.wait {
+					t.Errorf("paso %d: Allow = %v, %v; se esperaba %v, %v", i, allowed, wait, s.allowed, s.wait)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter()
+	if allowed, _, _ := l.Allow("a", 1, time.Minute); !allowed {
+		t.Fatal("la primera solicitud de a debería pasar")
+	}
+	if allowed, _, _ := l.Allow("a", 1, time.Minute); allowed {
+		t.Error("la segunda solicitud de a debería rechazarse")
+	}
+	if allowed, _, _ := l.Allow("b", 1, time.Minute); !allowed {
+		t.Error("el bucket de b no debería verse afectado por a")
+	}
+}
+
+func TestMemoryLimiterSweepsIdleBuckets(t *testing.T) {
+	l, clock := newTestLimiter()
+	l.Allow("idle", 1, time.Minute)
+	clock.advance(5 * time.Minute)
+	l.Allow("active", 1, time.Minute)
+	if len(l.buckets) != 2 {
+		t.Fatalf("buckets = %d, se esperaban 2", len(l.buckets))
+	}
+
+	// Tras 10 minutos sin uso el bucket se elimina y la clave vuelve a tener capacidad
+	clock.advance(6 * time.Minute)
+	l.Allow("active", 1, time.Minute)
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("el bucket inactivo debería haberse eliminado")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("el bucket activo no debería eliminarse")
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenBucketScript implementa el token bucket de forma atómica en Redis.
+// Devuelve {permitido (0/1), milisegundos de espera}.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = capacity / period
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, wait}
+`
+
+// RedisLimiter comparte los buckets entre todas las instancias de un servicio.
+// Si Redis no responde se recurre a un limitador en memoria y se devuelve el error.
+type RedisLimiter struct {
+	client   *redisClient
+	prefix   string
+	sha      string
+	fallback *MemoryLimiter
+}
+
+// NewRedisLimiter crea un limitador respaldado por Redis; prefix se antepone a las claves
+func NewRedisLimiter(addr, password string, db int, prefix string) *RedisLimiter {
+	sum := sha1.Sum([]byte(tokenBucketScript))
+	return &RedisLimiter{
+		client:   newRedisClient(addr, password, db),
+		prefix:   prefix,
+		sha:      hex.EncodeToString(sum[:]),
+		fallback: NewMemoryLimiter(),
+	}
+}
+
+// Allow consume un token del bucket de la clave (ver Limiter)
+func (l *RedisLimiter) Allow(key string, limit int, per time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 || per <= 0 {
+		return true, 0, nil
+	}
+
+	args := []string{
+		l.sha, "1", l.prefix + key,
+		strconv.Itoa(limit),
+		strconv.FormatInt(per.Milliseconds(), 10),
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}
+
+	reply, err := l.client.Do(append([]string{"EVALSHA"}, args...)...)
+	if err != nil && strings.HasPrefix(err.Error(), "redis: NOSCRIPT") {
+		args[0] = tokenBucketScript
+		reply, err = l.client.Do(append([]string{"EVAL"}, args...)...)
+	}
+	if err != nil {
+		allowed, wait, _ := l.fallback.Allow(key, limit, per)
+		return allowed, wait, err
+	}
+
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 2 {
+		allowed, wait, _ := l.fallback.Allow(key, limit, per)
+		return allowed, wait, fmt.Errorf("respuesta inesperada del script de límite: %v", reply)
+	}
+	allowed, _ := items[0].(int64)
+	waitMs, _ := items[1].(int64)
+	return allowed == 1, time.Duration(waitMs) * time.Millisecond, nil
+}
+
+// Addr devuelve la dirección del servidor Redis
+func (l *RedisLimiter) Addr() string {
+	return l.client.addr
+}
+
+// Ping comprueba que Redis responde (lo usan las sondas de salud)
+func (l *RedisLimiter) Ping() error {
+	_, err := l.client.Do("PING")
+	return err
+}
@@ -0,0 +1,215 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis es un servidor RESP que responde a cada comando con lo que devuelva reply
+type fakeRedis struct {
+	listener net.Listener
+	reply    func(args []string) string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, reply func(args []string) string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{listener: listener, reply: reply}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeRedis) addr() string { return s.listener.Addr().String() }
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args[0])
+		s.mu.Unlock()
+		if _, err := conn.Write([]byte(s.reply(args))); err != nil {
+			return
+		}
+	}
+}
+
+// received devuelve los nombres de los comandos recibidos, en orden
+func (s *fakeRedis) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if n <= 0 {
+		return nil, fmt.Errorf("comando inválido: %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedisLimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		reply       func(args []string) string
+		wantAllowed bool
+		wantWait    time.Duration
+		wantErr     bool
+		wantCmds    []string
+	}{
+		{
+			name:        "permitido",
+			reply:       func([]string) string { return "*2\r\n:1\r\n:0\r\n" },
+			wantAllowed: true,
+			wantCmds:    []string{"EVALSHA"},
+		},
+		{
+			name:     "rechazado con tiempo de espera",
+			reply:    func([]string) string { return "*2\r\n:0\r\n:1500\r\n" },
+			wantWait: 1500 * time.Millisecond,
+			wantCmds: []string{"EVALSHA"},
+		},
+		{
+			name: "script no cargado se reintenta con EVAL",
+			reply: func(args []string) string {
+				if args[0] == "EVALSHA" {
+					return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+				}
+				return "*2\r\n:1\r\n:0\r\n"
+			},
+			wantAllowed: true,
+			wantCmds:    []string{"EVALSHA", "EVAL"},
+		},
+		{
+			name:        "error del servidor recurre a memoria",
+			reply:       func([]string) string { return "-ERR fallo interno\r\n" },
+			wantAllowed: true,
+			wantErr:     true,
+			wantCmds:    []string{"EVALSHA"},
+		},
+		{
+			name:        "respuesta inesperada recurre a memoria",
+			reply:       func([]string) string { return "+OK\r\n" },
+			wantAllowed: true,
+			wantErr:     true,
+			wantCmds:    []string{"EVALSHA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRedis(t, tt.reply)
+			l := NewRedisLimiter(server.addr(), "", 0, "test:")
+			allowed, wait, err := l.Allow("k", 1, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Allow error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if allowed != tt.wantAllowed || wait != tt.wantWait {
+				t.Errorf("Allow = %v, %v; se esperaba %v, %v", allowed, wait, tt.wantAllowed, tt.wantWait)
+			}
+			if got := server.received(); strings.Join(got, ",") != strings.Join(tt.wantCmds, ",") {
+				t.Errorf("comandos = %v, se esperaba %v", got, tt.wantCmds)
+			}
+		})
+	}
+}
+
+func TestRedisLimiterSendsAuthAndSelect(t *testing.T) {
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] == "EVALSHA" {
+			return "*2\r\n:1\r\n:0\r\n"
+		}
+		return "+OK\r\n"
+	})
+	l := NewRedisLimiter(server.addr(), "secreto", 2, "test:")
+	if _, _, err := l.Allow("k", 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	want := "AUTH,SELECT,EVALSHA"
+	if got := strings.Join(server.received(), ","); got != want {
+		t.Errorf("comandos = %s, se esperaba %s", got, want)
+	}
+}
+
+func TestRedisLimiterFallsBackToMemoryWhenUnreachable(t *testing.T) {
+	// Un puerto que se acaba de liberar rechaza la conexión
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	l := NewRedisLimiter(addr, "", 0, "test:")
+	if l.Ping() == nil {
+		t.Fatal("Ping debería fallar sin servidor")
+	}
+	for i, want := range []bool{true, true, false} {
+		allowed, wait, err := l.Allow("k", 2, time.Minute)
+		if err == nil {
+			t.Fatalf("solicitud %d: se esperaba el error de conexión", i)
+		}
+		if allowed != want {
+			t.Errorf("solicitud %d: allowed = %v, se esperaba %v", i, allowed, want)
+		}
+		if !want && wait <= 0 {
+			t.Errorf("solicitud %d: se esperaba un tiempo de espera, wait = %v", i, wait)
+		}
+	}
+}
+
+func TestRedisLimiterDisabledRuleSkipsRedis(t *testing.T) {
+	server := newFakeRedis(t, func([]string) string { return "-ERR no debería llamarse\r\n" })
+	l := NewRedisLimiter(server.addr(), "", 0, "test:")
+	if allowed, _, err := l.Allow("k", 0, time.Minute); !allowed || err != nil {
+		t.Errorf("Allow = %v, %v; se esperaba permitido sin error", allowed, err)
+	}
+	if got := server.received(); len(got) != 0 {
+		t.Errorf("no debería enviarse ningún comando, se enviaron %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ámbitos sobre los que se aplica un límite
+const (
+	ScopeIP      = "ip"
+	ScopeWidget  = "widget"
+	ScopeSession = "session"
+)
+
+// Rule define un token bucket: Limit solicitudes por periodo Per en un ámbito
+type Rule struct {
+	Scope string
+	Limit int
+	Per   time.Duration
+}
+
+// Routes asigna a cada nombre de ruta sus reglas de límite
+type Routes map[string][]Rule
+
+// NewFromEnv crea el limitador según RATE_LIMIT_STORE ("memory" o "redis"). prefix
+// separa en Redis las claves de cada servicio (p. ej. "growdesk:rl:").
+func NewFromEnv(prefix string) Limiter {
+	if strings.ToLower(os.Getenv("RATE_LIMIT_STORE")) != "redis" {
+		slog.Info("Límite de solicitudes en memoria")
+		return NewMemoryLimiter()
+	}
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		host := os.Getenv("REDIS_HOST")
+		if host == "" {
+			host = "localhost"
+		}
+		port := os.Getenv("REDIS_PORT")
+		if port == "" {
+			port = "6379"
+		}
+		addr = host + ":" + port
+	}
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+
+	slog.Info("Límite de solicitudes con Redis", slog.String("addr", addr))
+	return NewRedisLimiter(addr, os.Getenv("REDIS_PASSWORD"), db, prefix)
+}
+
+// LoadRoutes combina los límites por defecto con los definidos en spec.
+// Formato: "ruta:ámbito=límite/periodo,ámbito=límite/periodo;ruta:..."
+// p. ej. "faqs:ip=120/1m,widget=1000/1m" (límite 0 desactiva el ámbito)
+func LoadRoutes(defaults Routes, spec string) Routes {
+	routes := make(Routes, len(defaults))
+	for route, rules := range defaults {
+		routes[route] = append([]Rule(nil), rules...)
+	}
+
+	for _, routeSpec := range strings.Split(spec, ";") {
+		routeSpec = strings.TrimSpace(routeSpec)
+		if routeSpec == "" {
+			continue
+		}
+		route, rulesSpec, ok := strings.Cut(routeSpec, ":")
+		if !ok {
+			slog.Warn(fmt.Sprintf("RATE_LIMITS: entrada inválida %q", routeSpec))
+			continue
+		}
+
+		for _, ruleSpec := range strings.Split(rulesSpec, ",") {
+			rule, err := ParseRule(strings.TrimSpace(ruleSpec))
+			if err != nil {
+				slog.Warn("RATE_LIMITS: " + err.Error())
+				continue
+			}
+			routes[route] = setRule(routes[route], rule)
+		}
+	}
+
+	// Los ámbitos widget y sesión los elige el cliente; sin el de IP bastaría con
+	// cambiarlos en cada solicitud para no agotar nunca un bucket
+	for route, rules := range routes {
+		if !hasActive(rules, ScopeIP) && (hasActive(rules, ScopeWidget) || hasActive(rules, ScopeSession)) {
+			for _, rule := range defaults[route] {
+				if rule.Scope == ScopeIP && rule.Limit > 0 {
+					slog.Warn(fmt.Sprintf("RATE_LIMITS: la ruta %s no puede quedarse sin límite por IP, se mantiene %d/%s", route, rule.Limit, rule.Per))
+					routes[route] = setRule(rules, rule)
+				}
+			}
+		}
+	}
+
+	return routes
+}
+
+func hasActive(rules []Rule, scope string) bool {
+	for _, rule := range rules {
+		if rule.Scope == scope && rule.Limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRule interpreta una regla con formato "ámbito=límite/periodo"
+func ParseRule(spec string) (Rule, error) {
+	scope, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("regla inválida %q", spec)
+	}
+	if scope != ScopeIP && scope != ScopeWidget && scope != ScopeSession {
+		return Rule{}, fmt.Errorf("ámbito desconocido %q", scope)
+	}
+	limitStr, perStr, ok := strings.Cut(value, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("regla inválida %q, se esperaba límite/periodo", spec)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return Rule{}, fmt.Errorf("límite inválido en %q", spec)
+	}
+	per, err := time.ParseDuration(perStr)
+	if err != nil || per <= 0 {
+		return Rule{}, fmt.Errorf("periodo inválido en %q", spec)
+	}
+	return Rule{Scope: scope, Limit: limit, Per: per}, nil
+}
+
+// Key construye la clave del bucket de una regla. El identificador se resume para no
+// guardar cookies ni IDs de sesión en claro.
+func Key(route, scope, subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return fmt.Sprintf("%s:%s:%s", route, scope, hex.EncodeToString(sum[:12]))
+}
+
+// SessionSubject identifica el bucket de sesión. La sesión la declara el cliente, así que
+// se combina con su IP para que nadie agote el bucket de otro enviando su ID. A quien
+// cambia de sesión en cada solicitud lo frena el límite por IP, que LoadRoutes mantiene.
+func SessionSubject(clientIP, session string) string {
+	if session == "" {
+		return ""
+	}
+	return clientIP + "|" + session
+}
+
+func setRule(rules []Rule, rule Rule) []Rule {
+	for i := range rules {
+		if rules[i].Scope == rule.Scope {
+			rules[i] = rule
+			return rules
+		}
+	}
+	return append(rules, rule)
+}
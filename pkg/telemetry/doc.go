@@ -0,0 +1,6 @@
+// Package telemetry reúne la instrumentación que comparten el backend y widget-api:
+// métricas de Prometheus (client_golang) expuestas en /metrics y trazas de OpenTelemetry
+// (SDK oficial) que se propagan con el encabezado W3C traceparent y se exportan a un
+// colector OTLP/HTTP. Incluye el middleware HTTP del backend, el transporte de los
+// clientes HTTP y un envoltorio de drivers de database/sql.
+package telemetry
@@ -0,0 +1,91 @@
+package telemetry
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Middleware mide todas las solicitudes del enrutador y abre un span de servidor por cada
+// una, continuando la traza de traceparent si la trae. Las métricas se llaman
+// <prefix>_http_requests_total, <prefix>_http_request_duration_seconds y
+// <prefix>_http_requests_in_flight. Las series se etiquetan con el patrón registrado en
+// mux (p. ej. /api/tickets/) y no con la URL, para que los IDs no multipliquen las series.
+func Middleware(prefix string, mux *http.ServeMux, next http.Handler) http.Handler {
+	requests := NewCounterVec(prefix+"_http_requests_total",
+		"Solicitudes HTTP atendidas por ruta, método y estado", "route", "method", "status")
+	duration := NewHistogramVec(prefix+"_http_request_duration_seconds",
+		"Duración de las solicitudes HTTP por ruta y método", nil, "route", "method")
+	inFlight := NewGaugeVec(prefix+"_http_requests_in_flight",
+		"Solicitudes HTTP en curso")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := StartSpan(Extract(r.Context(), r.Header), r.Method+" "+route, SpanKindServer)
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("http.target", r.URL.Path),
+		)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		inFlight.Add(1)
+		start := time.Now()
+		defer func() {
+			inFlight.Add(-1)
+			requests.Inc(route, r.Method, strconv.Itoa(recorder.status))
+			duration.Observe(time.Since(start).Seconds(), route, r.Method)
+			span.SetAttributes(attribute.Int("http.status_code", recorder.status))
+			if recorder.status >= 500 {
+				RecordError(span, errors.New(http.StatusText(recorder.status)))
+			}
+			span.End()
+		}()
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+	})
+}
+
+// statusRecorder guarda el estado de la respuesta. Implementa Hijacker y Flusher para no
+// romper los WebSocket ni las respuestas en streaming.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("la respuesta no admite Hijack")
+	}
+	r.status, r.wroteHeader = http.StatusSwitchingProtocols, true
+	return hijacker.Hijack()
+}
@@ -0,0 +1,172 @@
+package telemetry
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultBuckets son los límites (en segundos) de los histogramas de latencia
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// registry guarda las métricas expuestas en /metrics, además de las del runtime de Go y
+// del proceso (go_goroutines, process_start_time_seconds...)
+var registry = prometheus.NewRegistry()
+
+// families guarda las métricas creadas por nombre, para que crear dos veces la misma
+// (p. ej. varios Transport) devuelva la existente en lugar de fallar
+var families = struct {
+	sync.Mutex
+	byName map[string]interface{}
+}{byName: make(map[string]interface{})}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// family devuelve la métrica registrada con ese nombre o la crea con create
+func family(name string, create func() (interface{}, prometheus.Collector)) interface{} {
+	families.Lock()
+	defer families.Unlock()
+	if existing, ok := families.byName[name]; ok {
+		return existing
+	}
+	metric, collector := create()
+	registry.MustRegister(collector)
+	families.byName[name] = metric
+	return metric
+}
+
+// CounterVec es un contador con etiquetas
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+// NewCounterVec crea y registra un contador
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	metric := family(name, func() (interface{}, prometheus.Collector) {
+		vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+		return &CounterVec{vec: vec}, vec
+	})
+	counter, ok := metric.(*CounterVec)
+	if !ok {
+		panic(fmt.Sprintf("telemetry: %s ya está registrada con otro tipo", name))
+	}
+	return counter
+}
+
+// Inc suma uno a la serie de los valores indicados
+func (c *CounterVec) Inc(values ...string) { c.vec.WithLabelValues(values...).Inc() }
+
+// Add suma delta (>= 0) a la serie de los valores indicados
+func (c *CounterVec) Add(delta float64, values ...string) {
+	c.vec.WithLabelValues(values...).Add(delta)
+}
+
+// GaugeVec es un valor que sube y baja, con etiquetas
+type GaugeVec struct {
+	vec    *prometheus.GaugeVec
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec crea y registra un indicador
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	metric := family(name, func() (interface{}, prometheus.Collector) {
+		vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labels)
+		return &GaugeVec{vec: vec, values: make(map[string]float64)}, vec
+	})
+	gauge, ok := metric.(*GaugeVec)
+	if !ok {
+		panic(fmt.Sprintf("telemetry: %s ya está registrada con otro tipo", name))
+	}
+	return gauge
+}
+
+// Set fija el valor de la serie
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[strings.Join(values, "\xff")] = value
+	g.vec.WithLabelValues(values...).Set(value)
+}
+
+// Add suma delta a la serie y devuelve el nuevo valor. Las series que vuelven a cero se
+// eliminan, para que etiquetas como el ID de un ticket no se acumulen indefinidamente.
+func (g *GaugeVec) Add(delta float64, values ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := strings.Join(values, "\xff")
+	value := g.values[key] + delta
+	if value == 0 && len(values) > 0 {
+		delete(g.values, key)
+		g.vec.DeleteLabelValues(values...)
+		return 0
+	}
+	g.values[key] = value
+	g.vec.WithLabelValues(values...).Set(value)
+	return value
+}
+
+// HistogramVec reparte observaciones (p. ej. duraciones en segundos) en intervalos
+type HistogramVec struct {
+	vec *prometheus.HistogramVec
+}
+
+// NewHistogramVec crea y registra un histograma; sin límites usa DefaultBuckets
+func NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	if len(bounds) == 0 {
+		bounds = DefaultBuckets
+	}
+	metric := family(name, func() (interface{}, prometheus.Collector) {
+		vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: bounds}, labels)
+		return &HistogramVec{vec: vec}, vec
+	})
+	histogram, ok := metric.(*HistogramVec)
+	if !ok {
+		panic(fmt.Sprintf("telemetry: %s ya está registrada con otro tipo", name))
+	}
+	return histogram
+}
+
+// Observe registra una observación en la serie de los valores indicados
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.vec.WithLabelValues(values...).Observe(value)
+}
+
+// NewGaugeFunc crea y registra un indicador sin etiquetas cuyo valor se calcula al exponer
+// las métricas, p. ej. la profundidad de una cola
+func NewGaugeFunc(name, help string, fn func() float64) {
+	family(name, func() (interface{}, prometheus.Collector) {
+		gauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, fn)
+		return gauge, gauge
+	})
+}
+
+// MetricsHandler expone todas las métricas registradas en el formato de texto de Prometheus.
+// Si METRICS_TOKEN está definida, exige Authorization: Bearer con ese valor.
+func MetricsHandler() http.Handler {
+	token := os.Getenv("METRICS_TOKEN")
+	metrics := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "No autorizado", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
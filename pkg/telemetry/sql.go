@@ -0,0 +1,232 @@
+package telemetry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementLength acota el SQL que se guarda en los spans (sólo el texto, nunca los
+// parámetros)
+const maxStatementLength = 2000
+
+// WrapDriver envuelve un driver de database/sql para medir cada consulta
+// (<prefix>_db_query_duration_seconds y <prefix>_db_query_errors_total) y abrir un span
+// hijo del span activo en el contexto de la llamada. Las sentencias de una transacción sin
+// contexto propio cuelgan del contexto con el que se abrió la transacción.
+func WrapDriver(prefix string, d driver.Driver) driver.Driver {
+	return &tracedDriver{
+		Driver: d,
+		metrics: &dbMetrics{
+			duration: NewHistogramVec(prefix+"_db_query_duration_seconds",
+				"Duración de las consultas SQL por operación", nil, "operation"),
+			errors: NewCounterVec(prefix+"_db_query_errors_total",
+				"Consultas SQL fallidas por operación", "operation"),
+		},
+	}
+}
+
+type dbMetrics struct {
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+type tracedDriver struct {
+	driver.Driver
+	metrics *dbMetrics
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, metrics: d.metrics}, nil
+}
+
+type tracedConn struct {
+	driver.Conn
+	metrics *dbMetrics
+	txCtx   context.Context
+}
+
+// observe abre el span de la sentencia y devuelve la función que la cierra y la mide
+func (c *tracedConn) observe(ctx context.Context, query string) func(error) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() && c.txCtx != nil {
+		ctx = c.txCtx
+	}
+	operation := sqlOperation(query)
+	_, span := StartSpan(ctx, "db "+operation, SpanKindClient)
+	if span.IsRecording() {
+		statement := query
+		if len(statement) > maxStatementLength {
+			statement = statement[:maxStatementLength]
+		}
+		span.SetAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", strings.TrimSpace(statement)),
+		)
+	}
+	start := time.Now()
+	return func(err error) {
+		c.metrics.duration.Observe(time.Since(start).Seconds(), operation)
+		if err != nil && !errors.Is(err, driver.ErrSkip) && !errors.Is(err, io.EOF) {
+			c.metrics.errors.Inc(operation)
+			RecordError(span, err)
+		}
+		span.End()
+	}
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := c.observe(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	done(err)
+	return rows, err
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	done := c.observe(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	done(err)
+	return result, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.txCtx = ctx
+	return &tracedTx{Tx: tx, conn: c}, nil
+}
+
+func (c *tracedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+type tracedTx struct {
+	driver.Tx
+	conn *tracedConn
+}
+
+func (t *tracedTx) Commit() error {
+	t.conn.txCtx = nil
+	return t.Tx.Commit()
+}
+
+func (t *tracedTx) Rollback() error {
+	t.conn.txCtx = nil
+	return t.Tx.Rollback()
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	conn  *tracedConn
+	query string
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	done := s.conn.observe(ctx, s.query)
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedToValues(args))
+	}
+	done(err)
+	return rows, err
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	done := s.conn.observe(ctx, s.query)
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedToValues(args))
+	}
+	done(err)
+	return result, err
+}
+
+func namedToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// sqlOperation devuelve la primera palabra clave de la sentencia (SELECT, INSERT, WITH...),
+// saltando espacios y comentarios de línea
+func sqlOperation(query string) string {
+	for {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if !strings.HasPrefix(query, "--") {
+			break
+		}
+		if i := strings.IndexByte(query, '\n'); i >= 0 {
+			query = query[i+1:]
+		} else {
+			query = ""
+		}
+	}
+	end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(query)
+	}
+	if end == 0 || end > 16 {
+		return "OTHER"
+	}
+	return strings.ToUpper(query[:end])
+}
@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tipos de span, para que quien instrumenta no tenga que importar el paquete trace
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindClient   = trace.SpanKindClient
+)
+
+// instrumentationName identifica a este paquete como origen de los spans
+const instrumentationName = "github.com/hmdev/GrowDeskV2/pkg/telemetry"
+
+// propagator lee y escribe el encabezado traceparent (W3C Trace Context). Se usa aunque
+// las trazas estén desactivadas, para que la traza de otro servicio no se corte aquí.
+var propagator = propagation.TraceContext{}
+
+// provider es el TracerProvider del SDK; nil mientras las trazas están desactivadas
+var provider struct {
+	sync.Mutex
+	tp *sdktrace.TracerProvider
+}
+
+// StartSpan abre un span hijo del activo en ctx (o del recibido en traceparent). Con las
+// trazas desactivadas el span no se registra, pero conserva el contexto de la traza
+// recibida para seguir propagándola.
+func StartSpan(ctx context.Context, name string, kind trace.SpanKind) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(kind))
+}
+
+// RecordError marca el span como fallido con el mensaje del error; no hace nada si err es nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject añade a header el encabezado traceparent del span activo en ctx
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract lee el encabezado traceparent de otro servicio para continuar su traza
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID devuelve el ID de la traza activa en ctx en hexadecimal, o "" si no hay
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// InitTracing activa la exportación de trazas si hay un colector configurado. El
+// exportador OTLP/HTTP del SDK lee las variables estándar:
+//
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  URL completa (p. ej. http://otel-collector:4318/v1/traces)
+//	OTEL_EXPORTER_OTLP_ENDPOINT         URL base del colector; se añade /v1/traces
+//	OTEL_EXPORTER_OTLP_HEADERS          encabezados extra, clave=valor separados por comas
+//	OTEL_SERVICE_NAME                   nombre del servicio (por defecto service)
+//	OTEL_TRACES_SAMPLER_ARG             proporción de trazas nuevas que se muestrean (0-1)
+//
+// Sin colector, StartSpan no registra spans pero traceparent se sigue propagando.
+func InitTracing(service string) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/"); base != "" {
+			endpoint = base + "/v1/traces"
+		}
+	}
+	otel.SetTextMapPropagator(propagator)
+	if endpoint == "" {
+		slog.Info("ℹ️ Trazas desactivadas: define OTEL_EXPORTER_OTLP_ENDPOINT para enviarlas a un colector")
+		return
+	}
+
+	ratio := 1.0
+	if value := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			ratio = parsed
+		} else {
+			slog.Warn("⚠️ OTEL_TRACES_SAMPLER_ARG inválido, se muestrean todas las trazas", slog.String("value", value))
+		}
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		slog.Error("No se pudo crear el exportador de trazas: " + err.Error())
+		return
+	}
+	// OTEL_SERVICE_NAME y OTEL_RESOURCE_ATTRIBUTES tienen prioridad sobre el nombre por defecto
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", service)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		slog.Warn("⚠️ Atributos del recurso de trazas incompletos: " + err.Error())
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("⚠️ Error al exportar trazas: " + err.Error())
+	}))
+
+	provider.Lock()
+	provider.tp = tp
+	provider.Unlock()
+	slog.Info("🔭 Trazas activadas",
+		slog.String("service", service), slog.String("endpoint", endpoint), slog.Float64("ratio", ratio))
+}
+
+// ShutdownTracing envía los spans pendientes antes de cerrar el proceso
+func ShutdownTracing(ctx context.Context) {
+	provider.Lock()
+	tp := provider.tp
+	provider.Unlock()
+	if tp == nil {
+		return
+	}
+	if err := tp.Shutdown(ctx); err != nil {
+		slog.Warn("⚠️ No se pudieron enviar los spans pendientes: " + err.Error())
+	}
+}
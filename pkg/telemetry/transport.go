@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Transport envuelve un http.RoundTripper para medir las solicitudes salientes, abrir un
+// span de cliente y enviar traceparent, de modo que la traza continúa en el servicio
+// destino
+type Transport struct {
+	base     http.RoundTripper
+	requests *CounterVec
+	duration *HistogramVec
+}
+
+// NewTransport crea un Transport sobre base (o http.DefaultTransport si es nil). Las
+// métricas se llaman <prefix>_requests_total y <prefix>_request_duration_seconds
+// (p. ej. widget_api_backend_requests_total).
+func NewTransport(prefix string, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base: base,
+		requests: NewCounterVec(prefix+"_requests_total",
+			"Solicitudes HTTP salientes por ruta, método y resultado (estado o error)", "route", "method", "status"),
+		duration: NewHistogramVec(prefix+"_request_duration_seconds",
+			"Duración de las solicitudes HTTP salientes por ruta y método", nil, "route", "method"),
+	}
+}
+
+// RoundTrip implementa http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := RouteTemplate(req.URL.Path)
+	ctx, span := StartSpan(req.Context(), req.Method+" "+route, SpanKindClient)
+	span.SetAttributes(
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+	)
+
+	// RoundTrip no debe modificar la solicitud original
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.duration.Observe(time.Since(start).Seconds(), route, req.Method)
+	status := "error"
+	if err != nil {
+		RecordError(span, err)
+	} else {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			RecordError(span, errors.New(http.StatusText(resp.StatusCode)))
+		}
+	}
+	t.requests.Inc(route, req.Method, status)
+	span.End()
+	return resp, err
+}
+
+// RouteTemplate sustituye por :id los segmentos de la ruta que parecen identificadores
+// (contienen dígitos), para que las métricas no tengan una serie por ticket
+func RouteTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.IndexFunc(segment, unicode.IsDigit) >= 0 {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}